@@ -0,0 +1,101 @@
+package regclient
+
+import (
+	"context"
+	"io"
+
+	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/types/blob"
+	"github.com/regclient/regclient/types/descriptor"
+	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/ping"
+	"github.com/regclient/regclient/types/platform"
+	"github.com/regclient/regclient/types/ref"
+	"github.com/regclient/regclient/types/referrer"
+	"github.com/regclient/regclient/types/repo"
+	"github.com/regclient/regclient/types/tag"
+)
+
+// The interfaces in this file are implemented by [*RegClient].
+// Library consumers may accept the narrowest interface they need, allowing tests to mock only the methods they use.
+
+// ArtifactAPI pulls and pushes artifacts.
+type ArtifactAPI interface {
+	ArtifactGet(ctx context.Context, r ref.Ref, opts ...ManifestOpts) (*Artifact, error)
+	ArtifactPut(ctx context.Context, r ref.Ref, opts ...ArtifactOpts) (manifest.Manifest, error)
+}
+
+// BlobAPI accesses blobs.
+type BlobAPI interface {
+	BlobCopy(ctx context.Context, refSrc ref.Ref, refTgt ref.Ref, d descriptor.Descriptor, opts ...BlobOpts) error
+	BlobDelete(ctx context.Context, r ref.Ref, d descriptor.Descriptor) error
+	BlobGet(ctx context.Context, r ref.Ref, d descriptor.Descriptor, opts ...BlobOpts) (blob.Reader, error)
+	BlobGetOCIConfig(ctx context.Context, r ref.Ref, d descriptor.Descriptor) (blob.OCIConfig, error)
+	BlobHead(ctx context.Context, r ref.Ref, d descriptor.Descriptor) (blob.Reader, error)
+	BlobMount(ctx context.Context, refSrc ref.Ref, refTgt ref.Ref, d descriptor.Descriptor) error
+	BlobPut(ctx context.Context, r ref.Ref, d descriptor.Descriptor, rdr io.Reader) (descriptor.Descriptor, error)
+}
+
+// ImageAPI copies, exports, imports, and inspects images.
+type ImageAPI interface {
+	ImageCheckBase(ctx context.Context, r ref.Ref, opts ...ImageOpts) error
+	ImageConfig(ctx context.Context, r ref.Ref, opts ...ImageOpts) (*blob.BOCIConfig, error)
+	ImageCopy(ctx context.Context, refSrc ref.Ref, refTgt ref.Ref, opts ...ImageOpts) error
+	ImageExport(ctx context.Context, r ref.Ref, outStream io.Writer, opts ...ImageOpts) error
+	ImageImport(ctx context.Context, r ref.Ref, rs io.ReadSeeker, opts ...ImageOpts) error
+}
+
+// IndexAPI creates and updates indexes.
+type IndexAPI interface {
+	IndexCreate(ctx context.Context, r ref.Ref, opts ...IndexOpts) (manifest.Manifest, error)
+	IndexUpdate(ctx context.Context, r ref.Ref, opts ...IndexOpts) (manifest.Manifest, error)
+}
+
+// ManifestAPI accesses manifests.
+type ManifestAPI interface {
+	ManifestDelete(ctx context.Context, r ref.Ref, opts ...ManifestOpts) error
+	ManifestGet(ctx context.Context, r ref.Ref, opts ...ManifestOpts) (manifest.Manifest, error)
+	ManifestHead(ctx context.Context, r ref.Ref, opts ...ManifestOpts) (manifest.Manifest, error)
+	ManifestPlatform(ctx context.Context, r ref.Ref, p platform.Platform) (descriptor.Descriptor, error)
+	ManifestPut(ctx context.Context, r ref.Ref, m manifest.Manifest, opts ...ManifestOpts) error
+}
+
+// ReferrerAPI accesses referrers of a subject manifest.
+type ReferrerAPI interface {
+	ReferrerDelete(ctx context.Context, r ref.Ref) error
+	ReferrerList(ctx context.Context, rSubject ref.Ref, opts ...scheme.ReferrerOpts) (referrer.ReferrerList, error)
+	ReferrerPut(ctx context.Context, rSubject ref.Ref, m manifest.Manifest) error
+}
+
+// RepoAPI lists repositories on a registry.
+type RepoAPI interface {
+	RepoList(ctx context.Context, hostname string, opts ...scheme.RepoOpts) (*repo.RepoList, error)
+	RepoListNamespace(ctx context.Context, hostname, namespace string) ([]string, error)
+	RepoListWalk(ctx context.Context, hostname string, fn func(*repo.RepoList) error, opts ...scheme.RepoOpts) error
+}
+
+// TagAPI lists, inspects, and deletes tags.
+type TagAPI interface {
+	TagDelete(ctx context.Context, r ref.Ref) error
+	TagInfo(ctx context.Context, r ref.Ref) (tag.Info, error)
+	TagList(ctx context.Context, r ref.Ref, opts ...scheme.TagOpts) (*tag.List, error)
+	TagListWalk(ctx context.Context, r ref.Ref, fn func(*tag.List) error, opts ...scheme.TagOpts) error
+}
+
+// API includes every method of [*RegClient].
+type API interface {
+	ArtifactAPI
+	BlobAPI
+	ImageAPI
+	IndexAPI
+	ManifestAPI
+	ReferrerAPI
+	RepoAPI
+	TagAPI
+	CleanupApply(ctx context.Context, r ref.Ref, plan *CleanupPlan) error
+	CleanupPlan(ctx context.Context, r ref.Ref, opts ...CleanupOpts) (*CleanupPlan, error)
+	Close(ctx context.Context, r ref.Ref) error
+	Ping(ctx context.Context, r ref.Ref) (ping.Result, error)
+}
+
+var _ API = (*RegClient)(nil)
@@ -0,0 +1,236 @@
+package regclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/regclient/regclient/types/descriptor"
+	"github.com/regclient/regclient/types/errs"
+	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/mediatype"
+	v1 "github.com/regclient/regclient/types/oci/v1"
+	"github.com/regclient/regclient/types/ref"
+	"github.com/regclient/regclient/types/warning"
+)
+
+// Artifact contains the values extracted from an artifact manifest by [RegClient.ArtifactGet].
+type Artifact struct {
+	Manifest     manifest.Manifest       // manifest containing the artifact
+	ArtifactType string                  // artifactType, defaulting to the config media type when not set
+	Config       descriptor.Descriptor   // config descriptor, this is not set for the OCI artifact media type
+	Blobs        []descriptor.Descriptor // layers or blobs of the artifact
+	Annotations  map[string]string       // annotations on the manifest
+	Subject      *descriptor.Descriptor  // subject descriptor when the artifact is a referrer
+}
+
+type artifactOpt struct {
+	artifactType string
+	annotations  map[string]string
+	config       *artifactBlob
+	blobs        []artifactBlob
+	subject      *descriptor.Descriptor
+	mOpts        []ManifestOpts
+}
+
+type artifactBlob struct {
+	d   descriptor.Descriptor
+	rdr io.Reader
+}
+
+// ArtifactOpts define options for the Artifact* commands.
+type ArtifactOpts func(*artifactOpt)
+
+// ArtifactWithAnnotations sets annotations on the manifest in ArtifactPut.
+func ArtifactWithAnnotations(annotations map[string]string) ArtifactOpts {
+	return func(opts *artifactOpt) {
+		if opts.annotations == nil {
+			opts.annotations = map[string]string{}
+		}
+		for k, v := range annotations {
+			opts.annotations[k] = v
+		}
+	}
+}
+
+// ArtifactWithArtifactType sets the artifactType of the manifest in ArtifactPut.
+// This is required when the config is not set.
+func ArtifactWithArtifactType(artifactType string) ArtifactOpts {
+	return func(opts *artifactOpt) {
+		opts.artifactType = artifactType
+	}
+}
+
+// ArtifactWithBlob adds a blob to the artifact in ArtifactPut.
+// The descriptor must include the media type, and may include annotations.
+// The digest and size are computed when not provided.
+// Blobs are included in the manifest in the order they are added.
+func ArtifactWithBlob(d descriptor.Descriptor, rdr io.Reader) ArtifactOpts {
+	return func(opts *artifactOpt) {
+		opts.blobs = append(opts.blobs, artifactBlob{d: d, rdr: rdr})
+	}
+}
+
+// ArtifactWithConfig sets the config blob of the artifact in ArtifactPut.
+// The descriptor must include the media type.
+// The digest and size are computed when not provided.
+// By default, the empty JSON config is used, see [mediatype.OCI1Empty].
+func ArtifactWithConfig(d descriptor.Descriptor, rdr io.Reader) ArtifactOpts {
+	return func(opts *artifactOpt) {
+		opts.config = &artifactBlob{d: d, rdr: rdr}
+	}
+}
+
+// ArtifactWithManifestOpts passes options to the ManifestPut in ArtifactPut.
+func ArtifactWithManifestOpts(mOpts ...ManifestOpts) ArtifactOpts {
+	return func(opts *artifactOpt) {
+		opts.mOpts = append(opts.mOpts, mOpts...)
+	}
+}
+
+// ArtifactWithSubject sets the subject of the artifact in ArtifactPut, making the artifact a referrer.
+// The descriptor should include the media type, digest, and size of the subject manifest.
+func ArtifactWithSubject(d descriptor.Descriptor) ArtifactOpts {
+	return func(opts *artifactOpt) {
+		dCopy := descriptor.Descriptor{
+			MediaType: d.MediaType,
+			Digest:    d.Digest,
+			Size:      d.Size,
+		}
+		opts.subject = &dCopy
+	}
+}
+
+// ArtifactGet retrieves an artifact manifest and extracts the artifact details.
+// Both the OCI image manifest and the OCI artifact manifest media types are supported.
+// Blobs are not retrieved, use [RegClient.BlobGet] with the returned descriptors.
+func (rc *RegClient) ArtifactGet(ctx context.Context, r ref.Ref, opts ...ManifestOpts) (*Artifact, error) {
+	if !r.IsSet() {
+		return nil, fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), errs.ErrInvalidReference)
+	}
+	m, err := rc.ManifestGet(ctx, r, opts...)
+	if err != nil {
+		return nil, err
+	}
+	a := Artifact{
+		Manifest: m,
+	}
+	switch orig := m.GetOrig().(type) {
+	case v1.Manifest:
+		a.ArtifactType = orig.ArtifactType
+		if a.ArtifactType == "" {
+			a.ArtifactType = orig.Config.MediaType
+		}
+		a.Config = orig.Config
+		a.Blobs = orig.Layers
+		a.Annotations = orig.Annotations
+		a.Subject = orig.Subject
+	case v1.ArtifactManifest:
+		a.ArtifactType = orig.ArtifactType
+		a.Blobs = orig.Blobs
+		a.Annotations = orig.Annotations
+		a.Subject = orig.Subject
+	default:
+		return nil, fmt.Errorf("manifest is not an artifact: %s%.0w", m.GetDescriptor().MediaType, errs.ErrUnsupportedMediaType)
+	}
+	return &a, nil
+}
+
+// ArtifactPut pushes the blobs and an OCI image manifest for an artifact.
+// If the reference does not include a tag or digest, the manifest is pushed by digest.
+// The pushed manifest is returned.
+func (rc *RegClient) ArtifactPut(ctx context.Context, r ref.Ref, opts ...ArtifactOpts) (manifest.Manifest, error) {
+	if !r.IsSetRepo() {
+		return nil, fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), errs.ErrInvalidReference)
+	}
+	opt := artifactOpt{}
+	for _, optFn := range opts {
+		optFn(&opt)
+	}
+	// validate media types
+	if opt.artifactType != "" && !mediatype.Valid(opt.artifactType) {
+		return nil, fmt.Errorf("invalid artifact type: %s%.0w", opt.artifactType, errs.ErrUnsupportedMediaType)
+	}
+	if opt.config == nil {
+		if opt.artifactType == "" {
+			return nil, fmt.Errorf("artifact type is required without a config%.0w", errs.ErrUnsupportedMediaType)
+		}
+		opt.config = &artifactBlob{
+			d: descriptor.Descriptor{
+				MediaType: mediatype.OCI1Empty,
+				Digest:    descriptor.EmptyDigest,
+				Size:      int64(len(descriptor.EmptyData)),
+			},
+			rdr: bytes.NewReader(descriptor.EmptyData),
+		}
+	}
+	if !mediatype.Valid(opt.config.d.MediaType) {
+		return nil, fmt.Errorf("invalid config media type: %s%.0w", opt.config.d.MediaType, errs.ErrUnsupportedMediaType)
+	}
+	for _, b := range opt.blobs {
+		if !mediatype.Valid(b.d.MediaType) {
+			return nil, fmt.Errorf("invalid blob media type: %s%.0w", b.d.MediaType, errs.ErrUnsupportedMediaType)
+		}
+	}
+	// dedup warnings
+	if w := warning.FromContext(ctx); w == nil {
+		ctx = warning.NewContext(ctx, &warning.Warning{Hook: warning.DefaultHook()})
+	}
+
+	// push the config and blobs
+	confDesc, err := rc.artifactBlobPut(ctx, r, *opt.config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to push config: %w", err)
+	}
+	layers := make([]descriptor.Descriptor, 0, len(opt.blobs))
+	for _, b := range opt.blobs {
+		d, err := rc.artifactBlobPut(ctx, r, b)
+		if err != nil {
+			return nil, fmt.Errorf("failed to push blob: %w", err)
+		}
+		layers = append(layers, d)
+	}
+
+	// generate and push the manifest
+	m, err := manifest.New(manifest.WithOrig(v1.Manifest{
+		Versioned:    v1.ManifestSchemaVersion,
+		MediaType:    mediatype.OCI1Manifest,
+		ArtifactType: opt.artifactType,
+		Config:       confDesc,
+		Layers:       layers,
+		Annotations:  opt.annotations,
+		Subject:      opt.subject,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	if r.Tag == "" && r.Digest == "" {
+		r = r.SetDigest(m.GetDescriptor().Digest.String())
+	}
+	err = rc.ManifestPut(ctx, r, m, opt.mOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// artifactBlobPut pushes a blob if it does not already exist, returning the resulting descriptor.
+func (rc *RegClient) artifactBlobPut(ctx context.Context, r ref.Ref, b artifactBlob) (descriptor.Descriptor, error) {
+	if b.d.Digest != "" && b.d.Size > 0 {
+		if _, err := rc.BlobHead(ctx, r, b.d); err == nil {
+			return b.d, nil
+		}
+	}
+	if b.rdr == nil {
+		return b.d, fmt.Errorf("reader is not defined for blob %s%.0w", b.d.Digest.String(), errs.ErrNotFound)
+	}
+	d, err := rc.BlobPut(ctx, r, b.d, b.rdr)
+	if err != nil {
+		return b.d, err
+	}
+	// preserve fields not returned by the push
+	d.MediaType = b.d.MediaType
+	d.Annotations = b.d.Annotations
+	return d, nil
+}
@@ -0,0 +1,169 @@
+package regclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/olareg/olareg"
+	oConfig "github.com/olareg/olareg/config"
+
+	"github.com/regclient/regclient/config"
+	"github.com/regclient/regclient/types/descriptor"
+	"github.com/regclient/regclient/types/errs"
+	"github.com/regclient/regclient/types/mediatype"
+	"github.com/regclient/regclient/types/ref"
+)
+
+func TestArtifact(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	boolT := true
+	regHandler := olareg.New(oConfig.Config{
+		Storage: oConfig.ConfigStorage{
+			StoreType: oConfig.StoreMem,
+			RootDir:   "./testdata",
+		},
+		API: oConfig.ConfigAPI{
+			Referrer: oConfig.ConfigAPIReferrer{
+				Enabled: &boolT,
+			},
+		},
+	})
+	ts := httptest.NewServer(regHandler)
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	t.Cleanup(func() {
+		ts.Close()
+		_ = regHandler.Close()
+	})
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	rc := New(
+		WithConfigHost(config.Host{
+			Name:     tsHost,
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+		}),
+		WithSlog(log),
+	)
+	tempDir := t.TempDir()
+	blobA := []byte("hello world")
+	blobB := []byte(`{"policy":"allow"}`)
+	confData := []byte(`{"version":1}`)
+	atExample := "application/vnd.example.artifact"
+	for _, reg := range []string{"ocidir://" + tempDir, tsHost} {
+		reg := reg
+		t.Run(reg, func(t *testing.T) {
+			t.Parallel()
+			r, err := ref.New(reg + "/artifact:v1")
+			if err != nil {
+				t.Fatalf("failed to parse ref: %v", err)
+			}
+			t.Run("put-get", func(t *testing.T) {
+				m, err := rc.ArtifactPut(ctx, r,
+					ArtifactWithArtifactType(atExample),
+					ArtifactWithAnnotations(map[string]string{"org.example.key": "value"}),
+					ArtifactWithBlob(descriptor.Descriptor{MediaType: "text/plain", Annotations: map[string]string{"org.opencontainers.image.title": "hello.txt"}}, bytes.NewReader(blobA)),
+					ArtifactWithBlob(descriptor.Descriptor{MediaType: "application/json"}, bytes.NewReader(blobB)),
+				)
+				if err != nil {
+					t.Fatalf("failed to put artifact: %v", err)
+				}
+				a, err := rc.ArtifactGet(ctx, r)
+				if err != nil {
+					t.Fatalf("failed to get artifact: %v", err)
+				}
+				if a.Manifest.GetDescriptor().Digest != m.GetDescriptor().Digest {
+					t.Errorf("digest mismatch, expected %s, received %s", m.GetDescriptor().Digest, a.Manifest.GetDescriptor().Digest)
+				}
+				if a.ArtifactType != atExample {
+					t.Errorf("unexpected artifact type: %s", a.ArtifactType)
+				}
+				if a.Config.MediaType != mediatype.OCI1Empty || a.Config.Digest != descriptor.EmptyDigest {
+					t.Errorf("unexpected config: %v", a.Config)
+				}
+				if a.Annotations["org.example.key"] != "value" {
+					t.Errorf("missing annotation: %v", a.Annotations)
+				}
+				if a.Subject != nil {
+					t.Errorf("unexpected subject: %v", a.Subject)
+				}
+				if len(a.Blobs) != 2 {
+					t.Fatalf("unexpected blob count: %d", len(a.Blobs))
+				}
+				if a.Blobs[0].MediaType != "text/plain" || a.Blobs[0].Annotations["org.opencontainers.image.title"] != "hello.txt" || a.Blobs[0].Size != int64(len(blobA)) {
+					t.Errorf("unexpected first blob: %v", a.Blobs[0])
+				}
+				for i, expect := range [][]byte{blobA, blobB} {
+					br, err := rc.BlobGet(ctx, r, a.Blobs[i])
+					if err != nil {
+						t.Fatalf("failed to get blob %d: %v", i, err)
+					}
+					b, err := io.ReadAll(br)
+					_ = br.Close()
+					if err != nil {
+						t.Fatalf("failed to read blob %d: %v", i, err)
+					}
+					if !bytes.Equal(b, expect) {
+						t.Errorf("blob %d mismatch, expected %s, received %s", i, expect, b)
+					}
+				}
+			})
+			t.Run("config-subject", func(t *testing.T) {
+				mSubject, err := rc.ManifestHead(ctx, r, WithManifestRequireDigest())
+				if err != nil {
+					t.Fatalf("failed to head subject: %v", err)
+				}
+				rDigest := r.SetTag("")
+				m, err := rc.ArtifactPut(ctx, rDigest,
+					ArtifactWithConfig(descriptor.Descriptor{MediaType: "application/vnd.example.config+json"}, bytes.NewReader(confData)),
+					ArtifactWithBlob(descriptor.Descriptor{MediaType: "text/plain"}, bytes.NewReader(blobA)),
+					ArtifactWithSubject(mSubject.GetDescriptor()),
+				)
+				if err != nil {
+					t.Fatalf("failed to put artifact: %v", err)
+				}
+				a, err := rc.ArtifactGet(ctx, rDigest.SetDigest(m.GetDescriptor().Digest.String()))
+				if err != nil {
+					t.Fatalf("failed to get artifact: %v", err)
+				}
+				if a.ArtifactType != "application/vnd.example.config+json" {
+					t.Errorf("artifact type did not default to config media type: %s", a.ArtifactType)
+				}
+				if a.Config.Size != int64(len(confData)) {
+					t.Errorf("unexpected config: %v", a.Config)
+				}
+				if a.Subject == nil || a.Subject.Digest != mSubject.GetDescriptor().Digest {
+					t.Errorf("unexpected subject: %v", a.Subject)
+				}
+				rl, err := rc.ReferrerList(ctx, r)
+				if err != nil {
+					t.Fatalf("failed to list referrers: %v", err)
+				}
+				if len(rl.Descriptors) != 1 || rl.Descriptors[0].Digest != m.GetDescriptor().Digest {
+					t.Errorf("unexpected referrers: %v", rl.Descriptors)
+				}
+			})
+			t.Run("invalid", func(t *testing.T) {
+				_, err := rc.ArtifactPut(ctx, r, ArtifactWithBlob(descriptor.Descriptor{MediaType: "text/plain"}, bytes.NewReader(blobA)))
+				if err == nil || !errors.Is(err, errs.ErrUnsupportedMediaType) {
+					t.Errorf("missing artifact type did not fail: %v", err)
+				}
+				_, err = rc.ArtifactPut(ctx, r, ArtifactWithArtifactType(atExample), ArtifactWithBlob(descriptor.Descriptor{MediaType: "invalid"}, bytes.NewReader(blobA)))
+				if err == nil || !errors.Is(err, errs.ErrUnsupportedMediaType) {
+					t.Errorf("invalid media type did not fail: %v", err)
+				}
+				_, err = rc.ArtifactGet(ctx, r.SetTag("missing"))
+				if err == nil {
+					t.Errorf("get of missing artifact did not fail")
+				}
+			})
+		})
+	}
+}
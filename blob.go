@@ -23,7 +23,8 @@ import (
 const blobCBFreq = time.Millisecond * 100
 
 type blobOpt struct {
-	callback func(kind types.CallbackKind, instance string, state types.CallbackState, cur, total int64)
+	callback   func(kind types.CallbackKind, instance string, state types.CallbackState, cur, total int64)
+	skipVerify bool
 }
 
 // BlobOpts define options for the Image* commands.
@@ -36,6 +37,13 @@ func BlobWithCallback(callback func(kind types.CallbackKind, instance string, st
 	}
 }
 
+// BlobWithSkipVerify disables the digest check in BlobGet for raw access to the content.
+func BlobWithSkipVerify() BlobOpts {
+	return func(opts *blobOpt) {
+		opts.skipVerify = true
+	}
+}
+
 // BlobCopy copies a blob between two locations.
 // If the blob already exists in the target, the copy is skipped.
 // A server side cross repository blob mount is attempted.
@@ -108,8 +116,8 @@ func (rc *RegClient) BlobCopy(ctx context.Context, refSrc ref.Ref, refTgt ref.Re
 		ctx = ctxMulti
 	}
 
-	// try mounting blob from the source repo is the registry is the same
-	if ref.EqualRegistry(refSrc, refTgt) {
+	// try mounting blob from the source repo is the registry is the same, or between OCI Layouts on the local filesystem
+	if ref.EqualRegistry(refSrc, refTgt) || (refSrc.Scheme == "ocidir" && refTgt.Scheme == "ocidir") {
 		err := rc.BlobMount(ctx, refSrc, refTgt, d)
 		if err == nil {
 			if opt.callback != nil {
@@ -121,10 +129,18 @@ func (rc *RegClient) BlobCopy(ctx context.Context, refSrc ref.Ref, refTgt ref.Re
 				slog.String("digest", string(d.Digest)))
 			return nil
 		}
-		rc.slog.Warn("Failed to mount blob",
-			slog.String("src", refSrc.Reference),
-			slog.String("tgt", refTgt.Reference),
-			slog.String("err", err.Error()))
+		if errors.Is(err, errs.ErrMountReturnedLocation) || errors.Is(err, errs.ErrUnsupported) {
+			// registry rejected the mount, fall back to a normal upload
+			rc.slog.Debug("Blob mount rejected, pushing blob",
+				slog.String("src", refSrc.Reference),
+				slog.String("tgt", refTgt.Reference),
+				slog.String("digest", string(d.Digest)))
+		} else {
+			rc.slog.Warn("Failed to mount blob",
+				slog.String("src", refSrc.Reference),
+				slog.String("tgt", refTgt.Reference),
+				slog.String("err", err.Error()))
+		}
 	}
 	// fast options failed, download layer from source and push to target
 	blobIO, err := rc.BlobGet(ctx, refSrc, d)
@@ -178,6 +194,7 @@ func (rc *RegClient) BlobCopy(ctx context.Context, refSrc ref.Ref, refTgt ref.Re
 // BlobDelete removes a blob from the registry.
 // This method should only be used to repair a damaged registry.
 // Typically a server side garbage collection should be used to purge unused blobs.
+// See [RegClient.CleanupPlan] to find blobs that are no longer referenced by any tag.
 func (rc *RegClient) BlobDelete(ctx context.Context, r ref.Ref, d descriptor.Descriptor) error {
 	if !r.IsSetRepo() {
 		return fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), errs.ErrInvalidReference)
@@ -191,7 +208,14 @@ func (rc *RegClient) BlobDelete(ctx context.Context, r ref.Ref, d descriptor.Des
 
 // BlobGet retrieves a blob, returning a reader.
 // This reader must be closed to free up resources that limit concurrent pulls.
-func (rc *RegClient) BlobGet(ctx context.Context, r ref.Ref, d descriptor.Descriptor) (blob.Reader, error) {
+// The content is hashed while it is read, and the final read returns an error
+// wrapping [errs.ErrDigestMismatch] if it does not match the descriptor.
+// Use [BlobWithSkipVerify] to read content that fails this check.
+func (rc *RegClient) BlobGet(ctx context.Context, r ref.Ref, d descriptor.Descriptor, opts ...BlobOpts) (blob.Reader, error) {
+	var opt blobOpt
+	for _, optFn := range opts {
+		optFn(&opt)
+	}
 	data, err := d.GetData()
 	if err == nil {
 		return blob.NewReader(blob.WithDesc(d), blob.WithRef(r), blob.WithReader(bytes.NewReader(data))), nil
@@ -199,11 +223,31 @@ func (rc *RegClient) BlobGet(ctx context.Context, r ref.Ref, d descriptor.Descri
 	if !r.IsSetRepo() {
 		return nil, fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), errs.ErrInvalidReference)
 	}
+	if d.Digest.Validate() != nil && !opt.skipVerify {
+		return nil, fmt.Errorf("digest is required to verify the blob: %s%.0w", d.Digest.String(), errs.ErrMissingDigest)
+	}
 	schemeAPI, err := rc.schemeGet(r.Scheme)
 	if err != nil {
 		return nil, err
 	}
-	return schemeAPI.BlobGet(ctx, r, d)
+	// local content does not need to be cached
+	useCache := rc.blobCacheDir != "" && r.Scheme == "reg" && d.Digest.Validate() == nil && !opt.skipVerify
+	if useCache {
+		if b := rc.blobCacheGet(r, d); b != nil {
+			return b, nil
+		}
+	}
+	b, err := schemeAPI.BlobGet(ctx, r, d)
+	if err != nil {
+		return b, err
+	}
+	if opt.skipVerify {
+		b.SkipVerify()
+	}
+	if useCache {
+		b = rc.blobCacheAdd(r, b)
+	}
+	return b, nil
 }
 
 // BlobGetOCIConfig retrieves an OCI config from a blob, automatically extracting the JSON.
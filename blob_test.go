@@ -27,6 +27,7 @@ import (
 func TestBlobGet(t *testing.T) {
 	t.Parallel()
 	blobRepo := "/proj/repo"
+	corruptRepo := "/proj/corrupt"
 	privateRepo := "/proj/private"
 	ctx := context.Background()
 	// include a random blob
@@ -140,6 +141,23 @@ func TestBlobGet(t *testing.T) {
 				},
 			},
 		},
+		// content that does not match the digest
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "GET corrupt d1",
+				Method: "GET",
+				Path:   "/v2" + corruptRepo + "/blobs/" + d1.String(),
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Body:   blob2,
+				Headers: http.Header{
+					"Content-Length":        {fmt.Sprintf("%d", blobLen)},
+					"Content-Type":          {"application/octet-stream"},
+					"Docker-Content-Digest": {d1.String()},
+				},
+			},
+		},
 		// forbidden
 		{
 			ReqEntry: reqresp.ReqEntry{
@@ -268,6 +286,52 @@ func TestBlobGet(t *testing.T) {
 		}
 	})
 
+	t.Run("Corrupt", func(t *testing.T) {
+		ref, err := ref.New(tsURL.Host + corruptRepo)
+		if err != nil {
+			t.Fatalf("Failed creating ref: %v", err)
+		}
+		br, err := rc.BlobGet(ctx, ref, descriptor.Descriptor{Digest: d1, Size: int64(blobLen)})
+		if err != nil {
+			t.Fatalf("Failed running BlobGet: %v", err)
+		}
+		defer br.Close()
+		_, err = io.ReadAll(br)
+		if !errors.Is(err, errs.ErrDigestMismatch) {
+			t.Errorf("Error does not match \"ErrDigestMismatch\": %v", err)
+		}
+	})
+
+	t.Run("Skip verify", func(t *testing.T) {
+		ref, err := ref.New(tsURL.Host + corruptRepo)
+		if err != nil {
+			t.Fatalf("Failed creating ref: %v", err)
+		}
+		br, err := rc.BlobGet(ctx, ref, descriptor.Descriptor{Digest: d1, Size: int64(blobLen)}, BlobWithSkipVerify())
+		if err != nil {
+			t.Fatalf("Failed running BlobGet: %v", err)
+		}
+		defer br.Close()
+		brBlob, err := io.ReadAll(br)
+		if err != nil {
+			t.Fatalf("Failed reading blob: %v", err)
+		}
+		if !bytes.Equal(blob2, brBlob) {
+			t.Errorf("Blob does not match")
+		}
+	})
+
+	t.Run("Missing digest", func(t *testing.T) {
+		ref, err := ref.New(tsURL.Host + blobRepo)
+		if err != nil {
+			t.Fatalf("Failed creating ref: %v", err)
+		}
+		_, err = rc.BlobGet(ctx, ref, descriptor.Descriptor{Size: int64(blobLen)})
+		if !errors.Is(err, errs.ErrMissingDigest) {
+			t.Errorf("Error does not match \"ErrMissingDigest\": %v", err)
+		}
+	})
+
 	t.Run("Forbidden", func(t *testing.T) {
 		ref, err := ref.New(tsURL.Host + privateRepo)
 		if err != nil {
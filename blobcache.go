@@ -0,0 +1,120 @@
+package regclient
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/regclient/regclient/types/blob"
+	"github.com/regclient/regclient/types/descriptor"
+	"github.com/regclient/regclient/types/ref"
+)
+
+// blobCachePath returns the file for a blob in the cache directory.
+func (rc *RegClient) blobCachePath(d descriptor.Descriptor) string {
+	return filepath.Join(rc.blobCacheDir, "blobs", d.Digest.Algorithm().String(), d.Digest.Encoded())
+}
+
+// blobCacheGet returns a reader for a blob in the cache, or nil when the blob is not cached.
+func (rc *RegClient) blobCacheGet(r ref.Ref, d descriptor.Descriptor) blob.Reader {
+	//#nosec G304 the cache path is built from a validated digest
+	fd, err := os.Open(rc.blobCachePath(d))
+	if err != nil {
+		return nil
+	}
+	fi, err := fd.Stat()
+	if err != nil || (d.Size > 0 && fi.Size() != d.Size) {
+		_ = fd.Close()
+		return nil
+	}
+	d.Size = fi.Size()
+	rc.slog.Debug("Blob cache hit",
+		slog.String("ref", r.CommonName()),
+		slog.String("digest", d.Digest.String()))
+	return blob.NewReader(
+		blob.WithRef(r),
+		blob.WithReader(fd),
+		blob.WithDesc(d),
+	)
+}
+
+// blobCacheAdd returns a reader that saves the blob to the cache as it is read.
+// The blob is only added to the cache after the full content is read and the digest is verified.
+func (rc *RegClient) blobCacheAdd(r ref.Ref, b blob.Reader) blob.Reader {
+	d := b.GetDescriptor()
+	dir := filepath.Dir(rc.blobCachePath(d))
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		rc.slog.Warn("Failed to create blob cache directory",
+			slog.String("dir", dir),
+			slog.String("err", err.Error()))
+		return b
+	}
+	fh, err := os.CreateTemp(dir, ".tmp-"+d.Digest.Encoded()+"-*")
+	if err != nil {
+		rc.slog.Warn("Failed to create blob cache file",
+			slog.String("dir", dir),
+			slog.String("err", err.Error()))
+		return b
+	}
+	return blob.NewReader(
+		blob.WithRef(r),
+		blob.WithReader(&blobCacheWriter{rc: rc, b: b, fh: fh, file: rc.blobCachePath(d)}),
+		blob.WithDesc(d),
+		blob.WithHeader(b.RawHeaders()),
+		blob.WithResp(b.Response()),
+	)
+}
+
+// blobCacheWriter copies the content of a blob to a temporary file that is renamed into the cache after a verified read.
+type blobCacheWriter struct {
+	rc   *RegClient
+	b    blob.Reader
+	fh   *os.File
+	file string
+	err  error
+	done bool
+}
+
+func (w *blobCacheWriter) Read(p []byte) (int, error) {
+	n, err := w.b.Read(p)
+	if n > 0 && w.err == nil {
+		_, w.err = w.fh.Write(p[:n])
+	}
+	if errors.Is(err, io.EOF) && !w.done {
+		// the blob reader returns a different error on a digest mismatch
+		w.done = true
+		w.finish(err == io.EOF)
+	}
+	return n, err
+}
+
+func (w *blobCacheWriter) Close() error {
+	if !w.done {
+		w.done = true
+		w.finish(false)
+	}
+	return w.b.Close()
+}
+
+// finish moves the temporary file into the cache when the content is complete.
+func (w *blobCacheWriter) finish(ok bool) {
+	tmp := w.fh.Name()
+	errC := w.fh.Close()
+	if ok && w.err == nil && errC == nil {
+		err := os.Rename(tmp, w.file)
+		if err == nil {
+			return
+		}
+		w.err = fmt.Errorf("failed to add blob to cache: %w", err)
+	}
+	_ = os.Remove(tmp)
+	if w.err != nil {
+		w.rc.slog.Warn("Failed to cache blob",
+			slog.String("file", w.file),
+			slog.String("err", w.err.Error()))
+	}
+}
@@ -0,0 +1,144 @@
+package regclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/regclient/regclient/config"
+	"github.com/regclient/regclient/internal/reqresp"
+	"github.com/regclient/regclient/types/descriptor"
+	"github.com/regclient/regclient/types/ref"
+)
+
+func TestBlobCache(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	blobRepo := "/proj/repo"
+	seed := time.Now().UTC().Unix()
+	t.Logf("Using seed %d", seed)
+	blobLen := 1024
+	d1, blob1 := reqresp.NewRandomBlob(blobLen, seed)
+	d2, _ := reqresp.NewRandomBlob(blobLen, seed+1)
+	rrs := []reqresp.ReqResp{
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "GET for d1",
+				Method: "GET",
+				Path:   "/v2" + blobRepo + "/blobs/" + d1.String(),
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Body:   blob1,
+				Headers: http.Header{
+					"Content-Length":        {fmt.Sprintf("%d", blobLen)},
+					"Content-Type":          {"application/octet-stream"},
+					"Docker-Content-Digest": {d1.String()},
+				},
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "GET corrupt d2",
+				Method: "GET",
+				Path:   "/v2" + blobRepo + "/blobs/" + d2.String(),
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Body:   blob1,
+				Headers: http.Header{
+					"Content-Length":        {fmt.Sprintf("%d", blobLen)},
+					"Content-Type":          {"application/octet-stream"},
+					"Docker-Content-Digest": {d2.String()},
+				},
+			},
+		},
+	}
+	rrs = append(rrs, reqresp.BaseEntries...)
+	var mu sync.Mutex
+	blobGets := 0
+	handler := reqresp.NewHandler(t, rrs)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet && strings.Contains(req.URL.Path, "/blobs/") {
+			mu.Lock()
+			blobGets++
+			mu.Unlock()
+		}
+		handler.ServeHTTP(w, req)
+	}))
+	t.Cleanup(ts.Close)
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	cacheDir := t.TempDir()
+	rc := New(
+		WithConfigHost(config.Host{
+			Name:     tsHost,
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+		}),
+		WithBlobCache(cacheDir),
+	)
+	r, err := ref.New(tsHost + blobRepo)
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	getCount := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return blobGets
+	}
+
+	t.Run("cached", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			br, err := rc.BlobGet(ctx, r, descriptor.Descriptor{Digest: d1, Size: int64(blobLen)})
+			if err != nil {
+				t.Fatalf("failed to get blob: %v", err)
+			}
+			out, err := io.ReadAll(br)
+			_ = br.Close()
+			if err != nil {
+				t.Fatalf("failed to read blob: %v", err)
+			}
+			if !bytes.Equal(out, blob1) {
+				t.Errorf("blob content mismatch")
+			}
+		}
+		if getCount() != 1 {
+			t.Errorf("blob pulled %d times", getCount())
+		}
+		_, err := os.Stat(filepath.Join(cacheDir, "blobs", d1.Algorithm().String(), d1.Encoded()))
+		if err != nil {
+			t.Errorf("blob missing from cache: %v", err)
+		}
+	})
+	t.Run("not cached on mismatch", func(t *testing.T) {
+		br, err := rc.BlobGet(ctx, r, descriptor.Descriptor{Digest: d2, Size: int64(blobLen)})
+		if err != nil {
+			t.Fatalf("failed to get blob: %v", err)
+		}
+		_, err = io.ReadAll(br)
+		_ = br.Close()
+		if err == nil {
+			t.Errorf("read of corrupt blob did not fail")
+		}
+		entries, err := os.ReadDir(filepath.Join(cacheDir, "blobs", d2.Algorithm().String()))
+		if err != nil {
+			t.Fatalf("failed to read cache dir: %v", err)
+		}
+		for _, e := range entries {
+			if e.Name() != d1.Encoded() {
+				t.Errorf("unexpected file in cache: %s", e.Name())
+			}
+		}
+	})
+}
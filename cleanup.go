@@ -0,0 +1,409 @@
+package regclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/descriptor"
+	"github.com/regclient/regclient/types/errs"
+	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/mediatype"
+	"github.com/regclient/regclient/types/ref"
+)
+
+type cleanupOpt struct {
+	include    []string
+	exclude    []string
+	olderThan  time.Time
+	keepLatest int
+}
+
+// CleanupOpts define options for [RegClient.CleanupPlan].
+type CleanupOpts func(*cleanupOpt)
+
+// CleanupWithExclude keeps tags matching any of the regular expressions.
+// Expressions are bound to the beginning and end of the tag.
+// Excluded tags are kept even when they match an include or the age.
+func CleanupWithExclude(exp ...string) CleanupOpts {
+	return func(opts *cleanupOpt) {
+		opts.exclude = append(opts.exclude, exp...)
+	}
+}
+
+// CleanupWithInclude selects tags matching any of the regular expressions.
+// Expressions are bound to the beginning and end of the tag.
+// Without an include, all tags are selected by the other options.
+func CleanupWithInclude(exp ...string) CleanupOpts {
+	return func(opts *cleanupOpt) {
+		opts.include = append(opts.include, exp...)
+	}
+}
+
+// CleanupWithOlderThan selects tags on images created before t.
+// The created time is read from the "org.opencontainers.image.created" annotation, or from the image config.
+// For an index, the newest platform is used.
+// Tags without a created time are kept.
+func CleanupWithOlderThan(t time.Time) CleanupOpts {
+	return func(opts *cleanupOpt) {
+		opts.olderThan = t
+	}
+}
+
+// CleanupWithKeepLatest keeps the selected tags of the n most recently created images.
+// Multiple tags of the same image count as one image.
+// Without an include or age, all other tags are selected.
+// Tags without a created time are kept.
+func CleanupWithKeepLatest(n int) CleanupOpts {
+	return func(opts *cleanupOpt) {
+		opts.keepLatest = n
+	}
+}
+
+// CleanupPlan lists the content of a repository that is eligible for deletion.
+type CleanupPlan struct {
+	Tags      []CleanupTag            // tags selected for deletion
+	Manifests []descriptor.Descriptor // manifests only referenced by the selected tags, sorted with parents before children
+	Blobs     []descriptor.Descriptor // blobs only referenced by the listed manifests
+}
+
+// CleanupTag is a tag selected for deletion and the digest it referenced when the plan was created.
+type CleanupTag struct {
+	Tag    string
+	Digest digest.Digest
+}
+
+// cleanupSet tracks the manifests and blobs found from walking a set of tags.
+type cleanupSet struct {
+	seen      map[digest.Digest]bool
+	manifests []descriptor.Descriptor
+	blobs     []descriptor.Descriptor
+}
+
+// CleanupPlan returns the tags, manifests, and blobs in a repository eligible for deletion.
+// Tags are selected with [CleanupWithInclude], [CleanupWithExclude], [CleanupWithOlderThan], and [CleanupWithKeepLatest].
+// Manifests and blobs are only included when they are not referenced by any remaining tag.
+// Referrers of every walked manifest are kept along with the content they reference, even when the subject is deleted.
+// Other untagged manifests are not listed, since registries do not provide an API to discover them.
+// The plan is not applied, see [RegClient.CleanupApply].
+func (rc *RegClient) CleanupPlan(ctx context.Context, r ref.Ref, opts ...CleanupOpts) (*CleanupPlan, error) {
+	if !r.IsSetRepo() {
+		return nil, fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), errs.ErrInvalidReference)
+	}
+	opt := cleanupOpt{}
+	for _, optFn := range opts {
+		optFn(&opt)
+	}
+	if opt.keepLatest < 0 {
+		return nil, fmt.Errorf("cleanup keep latest must not be negative: %d", opt.keepLatest)
+	}
+	if len(opt.include) == 0 && opt.olderThan.IsZero() && opt.keepLatest == 0 {
+		return nil, fmt.Errorf("cleanup requires an include, age, or keep latest to select tags")
+	}
+	reInclude, err := cleanupRegexp(opt.include)
+	if err != nil {
+		return nil, err
+	}
+	reExclude, err := cleanupRegexp(opt.exclude)
+	if err != nil {
+		return nil, err
+	}
+	tl, err := rc.TagList(ctx, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	tags, err := tl.GetTags()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	plan := CleanupPlan{
+		Tags:      []CleanupTag{},
+		Manifests: []descriptor.Descriptor{},
+		Blobs:     []descriptor.Descriptor{},
+	}
+	type cleanupEntry struct {
+		tag      string
+		m        manifest.Manifest
+		selected bool
+		created  time.Time
+	}
+	entries := make([]*cleanupEntry, 0, len(tags))
+	candidates := []*cleanupEntry{}
+	for _, t := range tags {
+		rTag := r.SetTag(t)
+		m, err := rc.ManifestGet(ctx, rTag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get manifest %s: %w", rTag.CommonName(), err)
+		}
+		e := &cleanupEntry{tag: t, m: m}
+		e.selected = cleanupMatch(t, reInclude, reExclude)
+		if e.selected && (!opt.olderThan.IsZero() || opt.keepLatest > 0) {
+			created, ok := rc.cleanupCreated(ctx, rTag, m)
+			e.created = created
+			e.selected = ok && (opt.olderThan.IsZero() || created.Before(opt.olderThan))
+		}
+		if e.selected {
+			candidates = append(candidates, e)
+		}
+		entries = append(entries, e)
+	}
+	if opt.keepLatest > 0 {
+		sort.SliceStable(candidates, func(a, b int) bool {
+			return candidates[a].created.After(candidates[b].created)
+		})
+		// tags are kept by image, every tag of a kept image is kept
+		kept := map[digest.Digest]bool{}
+		for _, e := range candidates {
+			dig := e.m.GetDescriptor().Digest
+			if !kept[dig] && len(kept) >= opt.keepLatest {
+				continue
+			}
+			kept[dig] = true
+			e.selected = false
+		}
+	}
+	keep := cleanupSet{seen: map[digest.Digest]bool{}}
+	rm := cleanupSet{seen: map[digest.Digest]bool{}}
+	for _, e := range entries {
+		walk := &keep
+		if e.selected {
+			plan.Tags = append(plan.Tags, CleanupTag{Tag: e.tag, Digest: e.m.GetDescriptor().Digest})
+			walk = &rm
+		}
+		err = rc.cleanupWalk(ctx, r, e.m, walk, &keep)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, d := range rm.manifests {
+		if !keep.seen[d.Digest] {
+			plan.Manifests = append(plan.Manifests, d)
+		}
+	}
+	for _, d := range rm.blobs {
+		if !keep.seen[d.Digest] {
+			plan.Blobs = append(plan.Blobs, d)
+		}
+	}
+	return &plan, nil
+}
+
+// CleanupApply deletes the content listed in a plan from [RegClient.CleanupPlan].
+// Tags on manifests that are still referenced are deleted with [RegClient.TagDelete].
+// Manifests are deleted before blobs, which requires the registry to support deleting blobs.
+// The first error stops the cleanup.
+func (rc *RegClient) CleanupApply(ctx context.Context, r ref.Ref, plan *CleanupPlan) error {
+	if !r.IsSetRepo() {
+		return fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), errs.ErrInvalidReference)
+	}
+	if plan == nil {
+		return fmt.Errorf("cleanup plan is not set")
+	}
+	rmManifests := map[digest.Digest]bool{}
+	for _, d := range plan.Manifests {
+		rmManifests[d.Digest] = true
+	}
+	for _, t := range plan.Tags {
+		// deleting the manifest also removes the tag
+		if rmManifests[t.Digest] {
+			continue
+		}
+		rTag := r.SetTag(t.Tag)
+		rc.slog.Debug("cleanup tag",
+			slog.String("ref", rTag.CommonName()))
+		err := rc.TagDelete(ctx, rTag)
+		if err != nil {
+			return fmt.Errorf("failed to delete tag %s: %w", rTag.CommonName(), err)
+		}
+	}
+	for _, d := range plan.Manifests {
+		rDig := r.SetDigest(d.Digest.String())
+		rc.slog.Debug("cleanup manifest",
+			slog.String("ref", rDig.CommonName()))
+		err := rc.ManifestDelete(ctx, rDig)
+		if err != nil && !errors.Is(err, errs.ErrNotFound) {
+			return fmt.Errorf("failed to delete manifest %s: %w", rDig.CommonName(), err)
+		}
+	}
+	for _, d := range plan.Blobs {
+		rc.slog.Debug("cleanup blob",
+			slog.String("repo", r.CommonName()),
+			slog.String("digest", d.Digest.String()))
+		err := rc.BlobDelete(ctx, r, d)
+		if err != nil && !errors.Is(err, errs.ErrNotFound) {
+			return fmt.Errorf("failed to delete blob %s: %w", d.Digest.String(), err)
+		}
+	}
+	return nil
+}
+
+// cleanupCreated returns the created time of a manifest and true when one was found.
+func (rc *RegClient) cleanupCreated(ctx context.Context, r ref.Ref, m manifest.Manifest) (time.Time, bool) {
+	if ma, ok := m.(manifest.Annotator); ok {
+		annotations, err := ma.GetAnnotations()
+		if err == nil && annotations[types.AnnotationCreated] != "" {
+			created, err := time.Parse(time.RFC3339, annotations[types.AnnotationCreated])
+			if err == nil {
+				return created, true
+			}
+		}
+	}
+	if mi, ok := m.(manifest.Imager); ok {
+		d, err := mi.GetConfig()
+		if err != nil || (d.MediaType != mediatype.OCI1ImageConfig && d.MediaType != mediatype.Docker2ImageConfig) {
+			return time.Time{}, false
+		}
+		conf, err := rc.BlobGetOCIConfig(ctx, r, d)
+		if err != nil || conf.GetConfig().Created == nil {
+			return time.Time{}, false
+		}
+		return *conf.GetConfig().Created, true
+	}
+	if mi, ok := m.(manifest.Indexer); ok {
+		dl, err := mi.GetManifestList()
+		if err != nil {
+			return time.Time{}, false
+		}
+		newest := time.Time{}
+		found := false
+		for _, d := range dl {
+			if d.Digest == m.GetDescriptor().Digest {
+				continue
+			}
+			mChild, err := rc.ManifestGet(ctx, r.SetDigest(d.Digest.String()), WithManifestDesc(d))
+			if err != nil {
+				continue
+			}
+			if _, ok := mChild.(manifest.Indexer); ok {
+				// nested indexes are not searched
+				continue
+			}
+			created, ok := rc.cleanupCreated(ctx, r, mChild)
+			if ok && (!found || created.After(newest)) {
+				newest = created
+				found = true
+			}
+		}
+		return newest, found
+	}
+	return time.Time{}, false
+}
+
+// cleanupWalk adds a manifest, its child manifests, and their blobs to the set.
+// Referrers of each manifest are not deleted, so they are walked into the keep set.
+func (rc *RegClient) cleanupWalk(ctx context.Context, r ref.Ref, m manifest.Manifest, set, keep *cleanupSet) error {
+	mDesc := m.GetDescriptor()
+	if set.seen[mDesc.Digest] {
+		return nil
+	}
+	set.seen[mDesc.Digest] = true
+	set.manifests = append(set.manifests, descriptor.Descriptor{
+		MediaType: mDesc.MediaType,
+		Digest:    mDesc.Digest,
+		Size:      mDesc.Size,
+	})
+	if mi, ok := m.(manifest.Indexer); ok {
+		dl, err := mi.GetManifestList()
+		if err != nil {
+			return fmt.Errorf("failed to get manifest list: %w", err)
+		}
+		for _, d := range dl {
+			if set.seen[d.Digest] {
+				continue
+			}
+			mChild, err := rc.ManifestGet(ctx, r.SetDigest(d.Digest.String()), WithManifestDesc(d))
+			if err != nil {
+				// children may be missing from a repository, e.g. when only some platforms were copied
+				if errors.Is(err, errs.ErrNotFound) {
+					continue
+				}
+				return fmt.Errorf("failed to get manifest %s: %w", d.Digest.String(), err)
+			}
+			err = rc.cleanupWalk(ctx, r, mChild, set, keep)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if mi, ok := m.(manifest.Imager); ok {
+		dl := []descriptor.Descriptor{}
+		d, err := mi.GetConfig()
+		if err == nil && d.Digest != "" {
+			dl = append(dl, d)
+		}
+		layers, err := mi.GetLayers()
+		if err != nil {
+			return fmt.Errorf("failed to get layers: %w", err)
+		}
+		dl = append(dl, layers...)
+		for _, d := range dl {
+			// external layers are not stored in the repository
+			if set.seen[d.Digest] || len(d.URLs) > 0 {
+				continue
+			}
+			set.seen[d.Digest] = true
+			set.blobs = append(set.blobs, d)
+		}
+	}
+	// a failure to list the referrers would allow deleting blobs they use
+	rl, err := rc.ReferrerList(ctx, r.SetDigest(mDesc.Digest.String()))
+	if errors.Is(err, errs.ErrUnsupportedMediaType) {
+		// a digest tag that is not a referrers index, e.g. a cosign signature, is walked with the other tags
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to list referrers of %s: %w", mDesc.Digest.String(), err)
+	}
+	for _, d := range rl.Descriptors {
+		if keep.seen[d.Digest] {
+			continue
+		}
+		mReferrer, err := rc.ManifestGet(ctx, r.SetDigest(d.Digest.String()), WithManifestDesc(d))
+		if err != nil {
+			return fmt.Errorf("failed to get referrer %s: %w", d.Digest.String(), err)
+		}
+		err = rc.cleanupWalk(ctx, r, mReferrer, keep, keep)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cleanupMatch returns true when the tag matches an include and no exclude.
+func cleanupMatch(t string, reInclude, reExclude []*regexp.Regexp) bool {
+	for _, re := range reExclude {
+		if re.MatchString(t) {
+			return false
+		}
+	}
+	if len(reInclude) == 0 {
+		return true
+	}
+	for _, re := range reInclude {
+		if re.MatchString(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanupRegexp compiles a list of expressions bound to the beginning and end of the string.
+func cleanupRegexp(expList []string) ([]*regexp.Regexp, error) {
+	reList := make([]*regexp.Regexp, 0, len(expList))
+	for _, exp := range expList {
+		re, err := regexp.Compile("^(?:" + exp + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse regexp \"%s\": %w", exp, err)
+		}
+		reList = append(reList, re)
+	}
+	return reList, nil
+}
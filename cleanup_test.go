@@ -0,0 +1,288 @@
+package regclient
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/olareg/olareg"
+	oConfig "github.com/olareg/olareg/config"
+	"github.com/opencontainers/go-digest"
+
+	"github.com/regclient/regclient/config"
+	"github.com/regclient/regclient/internal/copyfs"
+	"github.com/regclient/regclient/types/descriptor"
+	"github.com/regclient/regclient/types/ref"
+)
+
+func TestCleanup(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	boolT := true
+	regHandler := olareg.New(oConfig.Config{
+		Storage: oConfig.ConfigStorage{
+			StoreType: oConfig.StoreMem,
+			RootDir:   "./testdata",
+		},
+		API: oConfig.ConfigAPI{
+			DeleteEnabled: &boolT,
+			Blob: oConfig.ConfigAPIBlob{
+				DeleteEnabled: &boolT,
+			},
+		},
+	})
+	ts := httptest.NewServer(regHandler)
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	t.Cleanup(func() {
+		ts.Close()
+		_ = regHandler.Close()
+	})
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	rc := New(
+		WithConfigHost(config.Host{
+			Name:     tsHost,
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+		}),
+		WithSlog(log),
+	)
+	tempDir := t.TempDir()
+	err := copyfs.Copy(tempDir+"/testrepo", "./testdata/testrepo")
+	if err != nil {
+		t.Fatalf("failed to copy testrepo to tempDir: %v", err)
+	}
+
+	t.Run("missing criteria", func(t *testing.T) {
+		r, err := ref.New(tsHost + "/testrepo")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		_, err = rc.CleanupPlan(ctx, r)
+		if err == nil {
+			t.Errorf("plan without criteria did not fail")
+		}
+		_, err = rc.CleanupPlan(ctx, r, CleanupWithInclude("[invalid"))
+		if err == nil {
+			t.Errorf("plan with an invalid regexp did not fail")
+		}
+	})
+	t.Run("older than", func(t *testing.T) {
+		r, err := ref.New(tsHost + "/testrepo")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		plan, err := rc.CleanupPlan(ctx, r, CleanupWithOlderThan(time.Unix(0, 0)))
+		if err != nil {
+			t.Fatalf("failed to plan: %v", err)
+		}
+		if len(plan.Tags) != 0 || len(plan.Manifests) != 0 || len(plan.Blobs) != 0 {
+			t.Errorf("content selected before any image was created: %v", plan)
+		}
+		plan, err = rc.CleanupPlan(ctx, r, CleanupWithInclude("v.*"), CleanupWithExclude("v1", "v3"), CleanupWithOlderThan(time.Now()))
+		if err != nil {
+			t.Fatalf("failed to plan: %v", err)
+		}
+		if len(plan.Tags) != 1 || plan.Tags[0].Tag != "v2" {
+			t.Errorf("unexpected tags: %v", plan.Tags)
+		}
+		// each alternative is bound to the full tag, "b" must not match b1
+		plan, err = rc.CleanupPlan(ctx, r, CleanupWithInclude("b|v1"), CleanupWithOlderThan(time.Now()))
+		if err != nil {
+			t.Fatalf("failed to plan: %v", err)
+		}
+		if len(plan.Tags) != 1 || plan.Tags[0].Tag != "v1" {
+			t.Errorf("unexpected tags: %v", plan.Tags)
+		}
+	})
+	t.Run("keep latest", func(t *testing.T) {
+		r, err := ref.New(tsHost + "/testrepo")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		_, err = rc.CleanupPlan(ctx, r, CleanupWithKeepLatest(-1))
+		if err == nil {
+			t.Errorf("plan with a negative keep latest did not fail")
+		}
+		// the b* images are older than the v* images
+		plan, err := rc.CleanupPlan(ctx, r, CleanupWithInclude("[bv][0-9]"), CleanupWithKeepLatest(3))
+		if err != nil {
+			t.Fatalf("failed to plan: %v", err)
+		}
+		if len(plan.Tags) != 3 || plan.Tags[0].Tag != "b1" || plan.Tags[1].Tag != "b2" || plan.Tags[2].Tag != "b3" {
+			t.Errorf("unexpected tags: %v", plan.Tags)
+		}
+		plan, err = rc.CleanupPlan(ctx, r, CleanupWithInclude("[bv][0-9]"), CleanupWithKeepLatest(10))
+		if err != nil {
+			t.Fatalf("failed to plan: %v", err)
+		}
+		if len(plan.Tags) != 0 {
+			t.Errorf("unexpected tags: %v", plan.Tags)
+		}
+	})
+	t.Run("keep latest images", func(t *testing.T) {
+		repoDir := t.TempDir()
+		err := copyfs.Copy(repoDir+"/testrepo", "./testdata/testrepo")
+		if err != nil {
+			t.Fatalf("failed to copy testrepo to tempDir: %v", err)
+		}
+		r, err := ref.New("ocidir://" + repoDir + "/testrepo")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		// a second tag on the same image does not use another slot
+		err = rc.ImageCopy(ctx, r.SetTag("v1"), r.SetTag("v4"))
+		if err != nil {
+			t.Fatalf("failed to tag v4: %v", err)
+		}
+		plan, err := rc.CleanupPlan(ctx, r, CleanupWithInclude("[bv][0-9]"), CleanupWithKeepLatest(3))
+		if err != nil {
+			t.Fatalf("failed to plan: %v", err)
+		}
+		if len(plan.Tags) != 3 || plan.Tags[0].Tag != "b1" || plan.Tags[1].Tag != "b2" || plan.Tags[2].Tag != "b3" {
+			t.Errorf("unexpected tags: %v", plan.Tags)
+		}
+	})
+	t.Run("referrers", func(t *testing.T) {
+		// a new repository on a registry with the referrers API, where only the referrers use the empty config
+		r, err := ref.New(tsHost + "/cleanup-referrers")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		// referrers are pushed by digest, without a tag
+		rUntagged := r.SetTag("")
+		rV3, err := ref.New(tsHost + "/testrepo:v3")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		err = rc.ImageCopy(ctx, rV3, r.SetTag("v3"))
+		if err != nil {
+			t.Fatalf("failed to copy v3: %v", err)
+		}
+		mV3, err := rc.ManifestHead(ctx, r.SetTag("v3"), WithManifestRequireDigest())
+		if err != nil {
+			t.Fatalf("failed to head v3: %v", err)
+		}
+		layerDesc := func(content string) descriptor.Descriptor {
+			return descriptor.Descriptor{MediaType: "application/example.data", Digest: digest.FromString(content), Size: int64(len(content))}
+		}
+		// the untagged referrer of a kept image and the deleted artifact both use the empty config
+		_, err = rc.ArtifactPut(ctx, rUntagged,
+			ArtifactWithArtifactType("application/example.sig"),
+			ArtifactWithSubject(mV3.GetDescriptor()),
+			ArtifactWithBlob(layerDesc("referrer"), strings.NewReader("referrer")))
+		if err != nil {
+			t.Fatalf("failed to put referrer: %v", err)
+		}
+		mArt, err := rc.ArtifactPut(ctx, r.SetTag("artifact"),
+			ArtifactWithArtifactType("application/example.data"),
+			ArtifactWithBlob(layerDesc("artifact"), strings.NewReader("artifact")))
+		if err != nil {
+			t.Fatalf("failed to put artifact: %v", err)
+		}
+		// referrers of a deleted manifest are not deleted, and neither are their blobs
+		_, err = rc.ArtifactPut(ctx, rUntagged,
+			ArtifactWithArtifactType("application/example.sig"),
+			ArtifactWithSubject(mArt.GetDescriptor()),
+			ArtifactWithBlob(layerDesc("artifact-referrer"), strings.NewReader("artifact-referrer")))
+		if err != nil {
+			t.Fatalf("failed to put referrer: %v", err)
+		}
+		plan, err := rc.CleanupPlan(ctx, r, CleanupWithInclude("artifact"))
+		if err != nil {
+			t.Fatalf("failed to plan: %v", err)
+		}
+		if len(plan.Tags) != 1 || plan.Tags[0].Tag != "artifact" {
+			t.Errorf("unexpected tags: %v", plan.Tags)
+		}
+		if len(plan.Manifests) != 1 || plan.Manifests[0].Digest != mArt.GetDescriptor().Digest {
+			t.Errorf("unexpected manifests: %v", plan.Manifests)
+		}
+		if len(plan.Blobs) != 1 || plan.Blobs[0].Digest != layerDesc("artifact").Digest {
+			t.Errorf("unexpected blobs: %v", plan.Blobs)
+		}
+		err = rc.CleanupApply(ctx, r, plan)
+		if err != nil {
+			t.Fatalf("failed to apply: %v", err)
+		}
+		_, err = rc.BlobHead(ctx, r, descriptor.Descriptor{Digest: descriptor.EmptyDigest})
+		if err != nil {
+			t.Errorf("empty config used by a referrer was deleted: %v", err)
+		}
+	})
+
+	tt := []struct {
+		name string
+		repo string
+	}{
+		{
+			name: "reg",
+			repo: tsHost + "/testrepo",
+		},
+		{
+			name: "ocidir",
+			repo: "ocidir://" + tempDir + "/testrepo",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := ref.New(tc.repo)
+			if err != nil {
+				t.Fatalf("failed to parse ref %s: %v", tc.repo, err)
+			}
+			plan, err := rc.CleanupPlan(ctx, r, CleanupWithInclude("v.*"), CleanupWithExclude("v3"))
+			if err != nil {
+				t.Fatalf("failed to plan: %v", err)
+			}
+			if len(plan.Tags) != 2 || plan.Tags[0].Tag != "v1" || plan.Tags[1].Tag != "v2" {
+				t.Fatalf("unexpected tags: %v", plan.Tags)
+			}
+			if len(plan.Manifests) == 0 || len(plan.Blobs) == 0 {
+				t.Fatalf("no manifests or blobs selected: %v", plan)
+			}
+			// content referenced by the kept tag is not selected
+			mKeep, err := rc.ManifestGet(ctx, r.SetTag("v3"))
+			if err != nil {
+				t.Fatalf("failed to get manifest: %v", err)
+			}
+			for _, d := range plan.Manifests {
+				if d.Digest == mKeep.GetDescriptor().Digest {
+					t.Errorf("manifest of a kept tag was selected: %s", d.Digest)
+				}
+			}
+			err = rc.CleanupApply(ctx, r, plan)
+			if err != nil {
+				t.Fatalf("failed to apply: %v", err)
+			}
+			for _, tag := range plan.Tags {
+				_, err = rc.ManifestHead(ctx, r.SetTag(tag.Tag))
+				if err == nil {
+					t.Errorf("tag %s was not deleted", tag.Tag)
+				}
+			}
+			for _, d := range plan.Manifests {
+				_, err = rc.ManifestHead(ctx, r.SetDigest(d.Digest.String()))
+				if err == nil {
+					t.Errorf("manifest %s was not deleted", d.Digest)
+				}
+			}
+			for _, d := range plan.Blobs {
+				_, err = rc.BlobHead(ctx, r, d)
+				if err == nil {
+					t.Errorf("blob %s was not deleted", d.Digest)
+				}
+			}
+			// the kept image is still complete
+			err = rc.ImageExport(ctx, r.SetTag("v3"), io.Discard)
+			if err != nil {
+				t.Errorf("kept image is not complete: %v", err)
+			}
+		})
+	}
+}
@@ -118,6 +118,13 @@ func configExpandTemplates(c *Config) error {
 			return err
 		}
 		c.Creds[i].ClientKey = val
+		for k, v := range c.Creds[i].Headers {
+			val, err = template.String(v, nil)
+			if err != nil {
+				return err
+			}
+			c.Creds[i].Headers[k] = val
+		}
 	}
 	return nil
 }
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// serveMetrics runs an http server for the Prometheus metrics until the context is done.
+func (rootOpts *rootCmd) serveMetrics(ctx context.Context) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", rootOpts.metrics)
+	srv := &http.Server{
+		Addr:              rootOpts.metricsAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		sctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(sctx)
+	}()
+	rootOpts.log.Info("Serving metrics",
+		slog.String("addr", rootOpts.metricsAddr))
+	err := srv.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		rootOpts.log.Error("Metrics server failed",
+			slog.String("addr", rootOpts.metricsAddr),
+			slog.String("err", err.Error()))
+	}
+}
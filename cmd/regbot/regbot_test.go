@@ -113,6 +113,31 @@ defaults:
 			},
 			expErr: nil,
 		},
+		{
+			name: "CheckBase",
+			script: ConfigScript{
+				Name: "CheckBase",
+				Script: `
+				if image.checkBase("registry.example.org/testrepo:v1", {base = "registry.example.org/testrepo:b1"}) ~= true then
+					error "v1 base changed"
+				end
+				if image.checkBase("registry.example.org/testrepo:v2") ~= false then
+					error "v2 base not changed"
+				end
+				`,
+			},
+			expErr: nil,
+		},
+		{
+			name: "CheckBaseMissing",
+			script: ConfigScript{
+				Name: "CheckBaseMissing",
+				Script: `
+				image.checkBase("registry.example.org/testrepo:v1")
+				`,
+			},
+			expErr: ErrScriptFailed,
+		},
 		{
 			name: "CopyLatest",
 			script: ConfigScript{
@@ -16,6 +16,7 @@ import (
 	"github.com/regclient/regclient/config"
 	"github.com/regclient/regclient/internal/pqueue"
 	"github.com/regclient/regclient/internal/version"
+	"github.com/regclient/regclient/pkg/metrics"
 	"github.com/regclient/regclient/pkg/template"
 	"github.com/regclient/regclient/scheme/reg"
 	"github.com/regclient/regclient/types"
@@ -29,15 +30,17 @@ More details at https://github.com/regclient/regclient`
 )
 
 type rootCmd struct {
-	confFile  string
-	dryRun    bool
-	verbosity string
-	logopts   []string
-	format    string // for Go template formatting of various commands
-	log       *slog.Logger
-	conf      *Config
-	rc        *regclient.RegClient
-	throttle  *pqueue.Queue[struct{}]
+	confFile    string
+	dryRun      bool
+	verbosity   string
+	logopts     []string
+	format      string // for Go template formatting of various commands
+	log         *slog.Logger
+	conf        *Config
+	rc          *regclient.RegClient
+	throttle    *pqueue.Queue[struct{}]
+	metricsAddr string
+	metrics     *metrics.Metrics
 }
 
 func NewRootCmd() (*cobra.Command, *rootCmd) {
@@ -80,6 +83,7 @@ returns after the last script completes.`,
 	rootTopCmd.PersistentFlags().StringVarP(&rootOpts.verbosity, "verbosity", "v", slog.LevelInfo.String(), "Log level (debug, info, warn, error, fatal, panic)")
 	rootTopCmd.PersistentFlags().StringArrayVar(&rootOpts.logopts, "logopt", []string{}, "Log options")
 	versionCmd.Flags().StringVarP(&rootOpts.format, "format", "", "{{printPretty .}}", "Format output with go template syntax")
+	serverCmd.Flags().StringVar(&rootOpts.metricsAddr, "metrics", "", "Listen address to serve Prometheus metrics on /metrics, e.g. :9090")
 
 	_ = rootTopCmd.MarkPersistentFlagFilename("config")
 	_ = serverCmd.MarkPersistentFlagRequired("config")
@@ -161,11 +165,17 @@ func (rootOpts *rootCmd) runOnce(cmd *cobra.Command, args []string) error {
 
 // runServer stays running with cron scheduled tasks
 func (rootOpts *rootCmd) runServer(cmd *cobra.Command, args []string) error {
+	if rootOpts.metricsAddr != "" {
+		rootOpts.metrics = metrics.New()
+	}
 	err := rootOpts.loadConf()
 	if err != nil {
 		return err
 	}
 	ctx := cmd.Context()
+	if rootOpts.metrics != nil {
+		go rootOpts.serveMetrics(ctx)
+	}
 	var wg sync.WaitGroup
 	var mainErr error
 	c := cron.New(cron.WithChain(
@@ -251,6 +261,9 @@ func (rootOpts *rootCmd) loadConf() error {
 	rcOpts := []regclient.Opt{
 		regclient.WithSlog(rootOpts.log),
 	}
+	if rootOpts.metrics != nil {
+		rcOpts = append(rcOpts, regclient.WithRegOpts(reg.WithMetrics(rootOpts.metrics)))
+	}
 	if rootOpts.conf.Defaults.BlobLimit != 0 {
 		rcOpts = append(rcOpts, regclient.WithRegOpts(reg.WithBlobLimit(rootOpts.conf.Defaults.BlobLimit)))
 	}
@@ -3,6 +3,7 @@ package sandbox
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"os"
 	"time"
@@ -12,6 +13,7 @@ import (
 	"github.com/regclient/regclient"
 	"github.com/regclient/regclient/cmd/regbot/internal/go2lua"
 	"github.com/regclient/regclient/types/blob"
+	"github.com/regclient/regclient/types/errs"
 	"github.com/regclient/regclient/types/manifest"
 	v1 "github.com/regclient/regclient/types/oci/v1"
 	"github.com/regclient/regclient/types/ref"
@@ -27,6 +29,7 @@ func setupImage(s *Sandbox) {
 	s.setupMod(
 		luaImageName,
 		map[string]lua.LGFunction{
+			"checkBase":     s.imageCheckBase,
 			"config":        s.configGet,
 			"copy":          s.imageCopy,
 			"exportTar":     s.imageExportTar,
@@ -169,6 +172,65 @@ func (s *Sandbox) configJSON(ls *lua.LState) int {
 	return 1
 }
 
+// imageCheckBase returns true if the base image of an image is unchanged
+func (s *Sandbox) imageCheckBase(ls *lua.LState) int {
+	err := s.ctx.Err()
+	if err != nil {
+		ls.RaiseError("Context error: %v", err)
+	}
+	r := s.checkReference(ls, 1)
+	opts := []regclient.ImageOpts{}
+	lOpts := struct {
+		Base       string `json:"base"`
+		Digest     string `json:"digest"`
+		Platform   string `json:"platform"`
+		SkipConfig bool   `json:"skipConfig"`
+	}{}
+	if ls.GetTop() == 2 {
+		err := go2lua.Import(ls, ls.Get(2), &lOpts, lOpts)
+		if err != nil {
+			ls.RaiseError("Failed to parse options: %v", err)
+		}
+		if lOpts.Base != "" {
+			opts = append(opts, regclient.ImageWithCheckBaseRef(lOpts.Base))
+		}
+		if lOpts.Digest != "" {
+			opts = append(opts, regclient.ImageWithCheckBaseDigest(lOpts.Digest))
+		}
+		if lOpts.Platform != "" {
+			opts = append(opts, regclient.ImageWithPlatform(lOpts.Platform))
+		}
+		if lOpts.SkipConfig {
+			opts = append(opts, regclient.ImageWithCheckSkipConfig())
+		}
+	}
+	if s.throttle != nil {
+		done, err := s.throttle.Acquire(s.ctx, struct{}{})
+		if err != nil {
+			ls.RaiseError("Failed to acquire throttle: %v", err)
+		}
+		defer done()
+	}
+	s.log.Debug("Check base image",
+		slog.String("script", s.name),
+		slog.String("image", r.r.CommonName()),
+		slog.String("base", lOpts.Base),
+	)
+	err = s.rc.ImageCheckBase(s.ctx, r.r, opts...)
+	if errors.Is(err, errs.ErrMismatch) {
+		s.log.Info("Base image changed",
+			slog.String("script", s.name),
+			slog.String("image", r.r.CommonName()),
+			slog.String("err", err.Error()))
+		ls.Push(lua.LFalse)
+		return 1
+	} else if err != nil {
+		ls.RaiseError("Failed checking base of \"%s\": %v", r.r.CommonName(), err)
+	}
+	ls.Push(lua.LTrue)
+	return 1
+}
+
 func (s *Sandbox) imageCopy(ls *lua.LState) int {
 	err := s.ctx.Err()
 	if err != nil {
@@ -6,10 +6,12 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"mime"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	// crypto libraries included for go-digest
 	_ "crypto/sha256"
@@ -36,7 +38,9 @@ import (
 
 const (
 	ociAnnotTitle     = "org.opencontainers.image.title"
+	orasAnnotUnpack   = "io.deis.oras.content.unpack"
 	defaultMTArtifact = "application/vnd.unknown.config+json"
+	defaultMTDir      = "application/vnd.unknown.artifact.v1"
 	defaultMTLayer    = "application/octet-stream"
 )
 
@@ -79,6 +83,8 @@ type artifactCmd struct {
 	latest           bool
 	outputDir        string
 	platform         string
+	pushDirSplit     bool
+	pushDirType      string
 	refers           string
 	sortAnnot        string
 	sortDesc         bool
@@ -162,6 +168,33 @@ regctl artifact put \
 		ValidArgs: []string{}, // do not auto complete repository/tag
 		RunE:      artifactOpts.runArtifactPut,
 	}
+	var artifactPushDirCmd = &cobra.Command{
+		Use:   "push-dir <dir> <reference>",
+		Short: "upload a directory as an artifact",
+		Long: `Upload the content of a directory as an artifact.
+By default the directory is pushed as a single tar+gzip layer.
+With --split, each file in the top of the directory is pushed as a separate layer
+with a media type from the file extension, and each subdirectory as a tar+gzip layer.
+Layers have a title annotation with the name, ending with a "/" for directories,
+and "regctl artifact get --output <dir>" extracts the directory layers.
+The artifact-type defaults to "` + defaultMTDir + `".`,
+		Example: `
+# push a directory of configuration files
+regctl artifact push-dir \
+  --artifact-type application/vnd.example.config.v1 \
+  ./config registry.example.com/repo:config
+
+# push each file and subdirectory of a model as a separate layer
+regctl artifact push-dir --split \
+  --annotation org.opencontainers.image.description="example model" \
+  ./model registry.example.com/models/example:v1
+
+# download the directory
+regctl artifact get --output ./out registry.example.com/repo:config`,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeArgList([]completeFunc{completeArgDefault, completeArgNone}),
+		RunE:              artifactOpts.runArtifactPushDir,
+	}
 	var artifactTreeCmd = &cobra.Command{
 		Use:     "tree <reference>",
 		Aliases: []string{},
@@ -242,6 +275,15 @@ regctl artifact tree --digest-tags ghcr.io/regclient/regsync:latest`,
 	artifactPutCmd.Flags().StringVar(&artifactOpts.refers, "refers", "", "EXPERIMENTAL: Set a referrer to the reference")
 	_ = artifactPutCmd.Flags().MarkHidden("refers")
 
+	artifactPushDirCmd.Flags().StringVar(&artifactOpts.pushDirType, "artifact-type", defaultMTDir, "Artifact type")
+	_ = artifactPushDirCmd.RegisterFlagCompletionFunc("artifact-type", completeArgNone)
+	artifactPushDirCmd.Flags().StringArrayVar(&artifactOpts.annotations, "annotation", []string{}, "Annotation to include on manifest")
+	_ = artifactPushDirCmd.RegisterFlagCompletionFunc("annotation", completeArgNone)
+	artifactPushDirCmd.Flags().BoolVar(&artifactOpts.byDigest, "by-digest", false, "Push manifest by digest instead of tag")
+	artifactPushDirCmd.Flags().StringVar(&artifactOpts.formatPut, "format", "", "Format output with go template syntax")
+	_ = artifactPushDirCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+	artifactPushDirCmd.Flags().BoolVar(&artifactOpts.pushDirSplit, "split", false, "Push each top level file and directory as a separate layer")
+
 	artifactTreeCmd.Flags().BoolVar(&artifactOpts.digestTags, "digest-tags", false, "Include digest tags")
 	artifactTreeCmd.Flags().StringVar(&artifactOpts.externalRepo, "external", "", "Query referrers from a separate source")
 	artifactTreeCmd.Flags().StringVar(&artifactOpts.filterAT, "filter-artifact-type", "", "Filter descriptors by artifactType")
@@ -250,6 +292,7 @@ regctl artifact tree --digest-tags ghcr.io/regclient/regsync:latest`,
 
 	artifactTopCmd.AddCommand(artifactGetCmd)
 	artifactTopCmd.AddCommand(artifactListCmd)
+	artifactTopCmd.AddCommand(artifactPushDirCmd)
 	artifactTopCmd.AddCommand(artifactPutCmd)
 	artifactTopCmd.AddCommand(artifactTreeCmd)
 	return artifactTopCmd
@@ -1049,6 +1092,188 @@ func (artifactOpts *artifactCmd) runArtifactPut(cmd *cobra.Command, args []strin
 	return template.Writer(cmd.OutOrStdout(), artifactOpts.formatPut, result)
 }
 
+func (artifactOpts *artifactCmd) runArtifactPushDir(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	dir := args[0]
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("%s is not a directory, use \"regctl artifact put\" for files%.0w", dir, ErrInvalidInput)
+	}
+	r, err := ref.New(args[1])
+	if err != nil {
+		return err
+	}
+	if !mediatype.Valid(artifactOpts.pushDirType) {
+		return fmt.Errorf("invalid media type: %s%.0w", artifactOpts.pushDirType, errs.ErrUnsupportedMediaType)
+	}
+	annotations := map[string]string{
+		types.AnnotationCreated: time.Now().UTC().Format(time.RFC3339),
+	}
+	for _, a := range artifactOpts.annotations {
+		aSplit := strings.SplitN(a, "=", 2)
+		if len(aSplit) == 1 {
+			annotations[aSplit[0]] = ""
+		} else {
+			annotations[aSplit[0]] = aSplit[1]
+		}
+	}
+
+	rc := artifactOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, r)
+
+	layers := []descriptor.Descriptor{}
+	if artifactOpts.pushDirSplit {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			var d descriptor.Descriptor
+			name := filepath.Join(dir, entry.Name())
+			switch {
+			case entry.IsDir():
+				d, err = artifactPushTar(ctx, rc, r, name)
+			case entry.Type().IsRegular():
+				d, err = artifactPushFile(ctx, rc, r, name, artifactFileMediaType(name))
+			default:
+				artifactOpts.rootOpts.log.Warn("Skipping file that is not a regular file or directory",
+					slog.String("file", name))
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			layers = append(layers, d)
+		}
+		if len(layers) == 0 {
+			return fmt.Errorf("no files found in %s%.0w", dir, ErrMissingInput)
+		}
+	} else {
+		d, err := artifactPushTar(ctx, rc, r, dir)
+		if err != nil {
+			return err
+		}
+		layers = append(layers, d)
+	}
+	artifactOpts.rootOpts.log.Debug("Pushed directory layers",
+		slog.String("dir", dir),
+		slog.Int("layers", len(layers)))
+
+	_, err = rc.BlobPut(ctx, r, descriptor.Descriptor{Digest: descriptor.EmptyDigest, Size: int64(len(descriptor.EmptyData))}, bytes.NewReader(descriptor.EmptyData))
+	if err != nil {
+		return err
+	}
+	mm, err := manifest.New(manifest.WithOrig(v1.Manifest{
+		Versioned:    v1.ManifestSchemaVersion,
+		MediaType:    mediatype.OCI1Manifest,
+		ArtifactType: artifactOpts.pushDirType,
+		Config: descriptor.Descriptor{
+			MediaType: mediatype.OCI1Empty,
+			Digest:    descriptor.EmptyDigest,
+			Size:      int64(len(descriptor.EmptyData)),
+		},
+		Layers:      layers,
+		Annotations: annotations,
+	}))
+	if err != nil {
+		return err
+	}
+	if artifactOpts.byDigest {
+		r = r.SetDigest(mm.GetDescriptor().Digest.String())
+	}
+	err = rc.ManifestPut(ctx, r, mm)
+	if err != nil {
+		return err
+	}
+	result := struct {
+		Manifest manifest.Manifest
+	}{
+		Manifest: mm,
+	}
+	if artifactOpts.byDigest && artifactOpts.formatPut == "" {
+		artifactOpts.formatPut = "{{ printf \"%s\\n\" .Manifest.GetDescriptor.Digest }}"
+	}
+	return template.Writer(cmd.OutOrStdout(), artifactOpts.formatPut, result)
+}
+
+// artifactPushTar pushes a directory as a tar+gzip layer.
+// The title annotation is the directory name with a trailing slash, which is extracted by artifact get.
+func artifactPushTar(ctx context.Context, rc *regclient.RegClient, r ref.Ref, dir string) (descriptor.Descriptor, error) {
+	// the digest is needed before the push, so the tar is written to a temp file
+	tf, err := os.CreateTemp("", "regctl-artifact-*.tgz")
+	if err != nil {
+		return descriptor.Descriptor{}, err
+	}
+	defer os.Remove(tf.Name())
+	err = archive.Tar(ctx, dir, tf, archive.TarCompressGzip)
+	if err == nil {
+		err = tf.Close()
+	} else {
+		_ = tf.Close()
+	}
+	if err != nil {
+		return descriptor.Descriptor{}, fmt.Errorf("failed to tar %s: %w", dir, err)
+	}
+	d, err := artifactPushFile(ctx, rc, r, tf.Name(), mediatype.OCI1LayerGzip)
+	if err != nil {
+		return d, err
+	}
+	d.Annotations = map[string]string{
+		ociAnnotTitle:   filepath.Base(filepath.Clean(dir)) + "/",
+		orasAnnotUnpack: "true",
+	}
+	return d, nil
+}
+
+// artifactPushFile pushes a file as a blob, skipping the push when the blob already exists.
+// The title annotation is the base name of the file.
+func artifactPushFile(ctx context.Context, rc *regclient.RegClient, r ref.Ref, filename, mt string) (descriptor.Descriptor, error) {
+	//#nosec G304 command is run by a user accessing their own files
+	fh, err := os.Open(filename)
+	if err != nil {
+		return descriptor.Descriptor{}, err
+	}
+	defer fh.Close()
+	d := descriptor.Descriptor{
+		MediaType: mt,
+	}
+	digester := d.DigestAlgo().Digester()
+	d.Size, err = io.Copy(digester.Hash(), fh)
+	if err != nil {
+		return d, err
+	}
+	d.Digest = digester.Digest()
+	d.Annotations = map[string]string{
+		ociAnnotTitle: filepath.Base(filename),
+	}
+	bRdr, err := rc.BlobHead(ctx, r, d)
+	if err == nil {
+		_ = bRdr.Close()
+		return d, nil
+	}
+	_, err = fh.Seek(0, io.SeekStart)
+	if err != nil {
+		return d, err
+	}
+	_, err = rc.BlobPut(ctx, r, d, fh)
+	if err != nil {
+		return d, fmt.Errorf("failed to push %s: %w", filename, err)
+	}
+	return d, nil
+}
+
+// artifactFileMediaType returns the media type for a file based on the extension.
+func artifactFileMediaType(filename string) string {
+	mt, _, err := mime.ParseMediaType(mime.TypeByExtension(filepath.Ext(filename)))
+	if err != nil || !mediatype.Valid(mt) {
+		return defaultMTLayer
+	}
+	return mt
+}
+
 func (artifactOpts *artifactCmd) runArtifactTree(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
 
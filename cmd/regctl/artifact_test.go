@@ -312,6 +312,79 @@ func TestArtifactPut(t *testing.T) {
 	}
 }
 
+func TestArtifactPushDir(t *testing.T) {
+	testDir := t.TempDir()
+	srcDir := filepath.Join(testDir, "src")
+	err := os.MkdirAll(filepath.Join(srcDir, "sub"), 0700)
+	if err != nil {
+		t.Fatalf("failed creating test dir: %v", err)
+	}
+	err = os.WriteFile(filepath.Join(srcDir, "conf.json"), []byte(`{"hello": "world"}`), 0600)
+	if err != nil {
+		t.Fatalf("failed creating test file: %v", err)
+	}
+	err = os.WriteFile(filepath.Join(srcDir, "sub", "data"), []byte(`example data`), 0600)
+	if err != nil {
+		t.Fatalf("failed creating test file: %v", err)
+	}
+	repo := "ocidir://" + testDir + "/repo"
+
+	t.Run("Not a dir", func(t *testing.T) {
+		_, err := cobraTest(t, nil, "artifact", "push-dir", filepath.Join(srcDir, "conf.json"), repo+":err")
+		if !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+	t.Run("Invalid artifact type", func(t *testing.T) {
+		_, err := cobraTest(t, nil, "artifact", "push-dir", "--artifact-type", "invalid", srcDir, repo+":err")
+		if !errors.Is(err, errs.ErrUnsupportedMediaType) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+	t.Run("Single layer", func(t *testing.T) {
+		_, err := cobraTest(t, nil, "artifact", "push-dir", "--artifact-type", "application/vnd.example.dir", "--annotation", "type=dir", srcDir, repo+":single")
+		if err != nil {
+			t.Fatalf("failed to push dir: %v", err)
+		}
+		out, err := cobraTest(t, nil, "manifest", "get", repo+":single", "--format", `{{.ArtifactType}} {{index .Annotations "type"}}{{range .Layers}} {{.MediaType}} {{index .Annotations "org.opencontainers.image.title"}}{{end}}`)
+		if err != nil {
+			t.Fatalf("failed to get manifest: %v", err)
+		}
+		if out != "application/vnd.example.dir dir application/vnd.oci.image.layer.v1.tar+gzip src/" {
+			t.Errorf("unexpected manifest: %s", out)
+		}
+		outDir := filepath.Join(testDir, "single")
+		err = os.MkdirAll(outDir, 0700)
+		if err != nil {
+			t.Fatalf("failed creating output dir: %v", err)
+		}
+		_, err = cobraTest(t, nil, "artifact", "get", "--output", outDir, repo+":single")
+		if err != nil {
+			t.Fatalf("failed to get artifact: %v", err)
+		}
+		b, err := os.ReadFile(filepath.Join(outDir, "src", "sub", "data"))
+		if err != nil || string(b) != "example data" {
+			t.Errorf("unexpected extracted data: %s, %v", string(b), err)
+		}
+	})
+	t.Run("Split", func(t *testing.T) {
+		out, err := cobraTest(t, nil, "artifact", "push-dir", "--split", "--by-digest", srcDir, repo+":split")
+		if err != nil {
+			t.Fatalf("failed to push dir: %v", err)
+		}
+		if !strings.HasPrefix(out, "sha256:") {
+			t.Fatalf("unexpected output: %s", out)
+		}
+		out, err = cobraTest(t, nil, "manifest", "get", repo+"@"+out, "--format", `{{.ArtifactType}}{{range .Layers}} {{.MediaType}} {{index .Annotations "org.opencontainers.image.title"}}{{end}}`)
+		if err != nil {
+			t.Fatalf("failed to get manifest: %v", err)
+		}
+		if out != "application/vnd.unknown.artifact.v1 application/json conf.json application/vnd.oci.image.layer.v1.tar+gzip sub/" {
+			t.Errorf("unexpected manifest: %s", out)
+		}
+	})
+}
+
 func TestArtifactTree(t *testing.T) {
 	tt := []struct {
 		name        string
@@ -2,6 +2,7 @@ package main
 
 import (
 	"archive/tar"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,7 +10,10 @@ import (
 	"log/slog"
 	"math"
 	"os"
+	"path"
+	"sort"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	// crypto libraries included for go-digest
@@ -19,9 +23,12 @@ import (
 	"github.com/opencontainers/go-digest"
 	"github.com/spf13/cobra"
 
+	"github.com/regclient/regclient"
 	"github.com/regclient/regclient/internal/diff"
+	"github.com/regclient/regclient/internal/units"
 	"github.com/regclient/regclient/pkg/template"
 	"github.com/regclient/regclient/types/descriptor"
+	"github.com/regclient/regclient/types/errs"
 	"github.com/regclient/regclient/types/ref"
 	"github.com/regclient/regclient/types/warning"
 )
@@ -31,12 +38,17 @@ type blobCmd struct {
 	diffCtx        int
 	diffFullCtx    bool
 	diffIgnoreTime bool
+	diffSort       string
+	formatDiff     string
 	formatGet      string
 	formatFile     string
 	formatHead     string
 	formatPut      string
 	mt             string
 	digest         string
+	file           string
+	output         string
+	skipVerify     bool
 }
 
 func NewBlobCmd(rootOpts *rootCmd) *cobra.Command {
@@ -65,6 +77,27 @@ regctl blob delete registry.example.org/repo \
 		ValidArgs: []string{}, // do not auto complete repository or digest
 		RunE:      blobOpts.runBlobDelete,
 	}
+	var blobDiffCmd = &cobra.Command{
+		Use:   "diff <repository>@<digest> <repository>@<digest>",
+		Short: "list the files changed between two tar layers",
+		Long: `This lists the files added, removed, and changed between two layers.
+Both layers are streamed and each file is compared by the content digest, size,
+mode, owner, and link target. This is useful to find the cause of unexpected
+growth in an image. Use "diff-layer" for a line based diff of every file.`,
+		Example: `
+# list the files changed between two versions of busybox
+regctl blob diff \
+  busybox@sha256:2354422721e449fa3fa83b84465b9d5bb65ac5415ec93c06f598854312e8957e \
+  busybox@sha256:9ad63333ebc97e32b987ae66aa3cff81300e4c2e6d2f2395cef8a3ae18b249fe
+
+# show the largest changes first
+regctl blob diff --sort size \
+  busybox@sha256:2354422721e449fa3fa83b84465b9d5bb65ac5415ec93c06f598854312e8957e \
+  busybox@sha256:9ad63333ebc97e32b987ae66aa3cff81300e4c2e6d2f2395cef8a3ae18b249fe`,
+		Args:      cobra.ExactArgs(2),
+		ValidArgs: []string{}, // do not auto complete repository or digest
+		RunE:      blobOpts.runBlobDiff,
+	}
 	var blobDiffConfigCmd = &cobra.Command{
 		Use:   "diff-config <repository> <digest> <repository> <digest>",
 		Short: "diff two image configs",
@@ -97,12 +130,19 @@ regctl blob diff-layer \
 		Short:   "download a blob/layer",
 		Long: `Download a blob from the registry. The output is the blob itself which may
 be a compressed tar file, a json config, or any other blob supported by the
-registry. The blob or layer digest can be found in the image manifest.`,
+registry. The blob or layer digest can be found in the image manifest.
+With --output, the blob is written to a file, which is removed if the digest
+does not match.`,
 		Example: `
 # inspect the layer contents of a busybox image
 regctl blob get busybox \
   sha256:a58ecd4f0c864650a4286c3c2d49c7219a3f2fc8d7a0bf478aa9834acfe14ae7 \
-  | tar -tvzf -`,
+  | tar -tvzf -
+
+# save a layer to a file
+regctl blob get busybox \
+  sha256:a58ecd4f0c864650a4286c3c2d49c7219a3f2fc8d7a0bf478aa9834acfe14ae7 \
+  --output layer.tgz`,
 		Args:      cobra.ExactArgs(2),
 		ValidArgs: []string{}, // do not auto complete repository or digest
 		RunE:      blobOpts.runBlobGet,
@@ -138,11 +178,16 @@ regctl blob head alpine \
 		Use:     "put <repository>",
 		Aliases: []string{"push"},
 		Short:   "upload a blob/layer",
-		Long: `Upload a blob to a repository. Stdin must be the blob contents. The output
-is the digest of the blob.`,
+		Long: `Upload a blob to a repository. The blob contents are read from stdin, or from
+a file with --file. The output is the digest of the blob, and the size is
+available with --format "{{.Size}}".`,
 		Example: `
 # push a blob
-regctl blob put registry.example.org/repo <layer.tgz`,
+regctl blob put registry.example.org/repo <layer.tgz
+
+# push a file and output the digest and size
+regctl blob put registry.example.org/repo --file layer.tgz \
+  --format '{{.Digest}} {{.Size}}'`,
 		Args:      cobra.ExactArgs(1),
 		ValidArgs: []string{}, // do not auto complete repository
 		RunE:      blobOpts.runBlobPut,
@@ -163,6 +208,13 @@ regctl blob copy alpine registry.example.org/library/alpine \
 		RunE:      blobOpts.runBlobCopy,
 	}
 
+	blobDiffCmd.Flags().StringVarP(&blobOpts.formatDiff, "format", "", "{{printPretty .}}", "Format output with go template syntax")
+	_ = blobDiffCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+	blobDiffCmd.Flags().StringVarP(&blobOpts.diffSort, "sort", "", "path", "Sort the files by path or size")
+	_ = blobDiffCmd.RegisterFlagCompletionFunc("sort", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"path", "size"}, cobra.ShellCompDirectiveNoFileComp
+	})
+
 	blobDiffConfigCmd.Flags().IntVarP(&blobOpts.diffCtx, "context", "", 3, "Lines of context")
 	blobDiffConfigCmd.Flags().BoolVarP(&blobOpts.diffFullCtx, "context-full", "", false, "Show all lines of context")
 
@@ -171,7 +223,9 @@ regctl blob copy alpine registry.example.org/library/alpine \
 	blobDiffLayerCmd.Flags().BoolVarP(&blobOpts.diffIgnoreTime, "ignore-timestamp", "", false, "Ignore timestamps on files")
 
 	blobGetCmd.Flags().StringVarP(&blobOpts.formatGet, "format", "", "{{printPretty .}}", "Format output with go template syntax")
+	blobGetCmd.Flags().StringVarP(&blobOpts.output, "output", "o", "", "Write the blob to a file")
 	blobGetCmd.Flags().StringVarP(&blobOpts.mt, "media-type", "", "", "Set the requested mediaType (deprecated)")
+	blobGetCmd.Flags().BoolVar(&blobOpts.skipVerify, "skip-verify", false, "Output the blob without verifying the digest")
 	_ = blobGetCmd.RegisterFlagCompletionFunc("format", completeArgNone)
 	_ = blobGetCmd.RegisterFlagCompletionFunc("media-type", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return []string{
@@ -187,6 +241,7 @@ regctl blob copy alpine registry.example.org/library/alpine \
 
 	blobPutCmd.Flags().StringVarP(&blobOpts.mt, "content-type", "", "", "Set the requested content type (deprecated)")
 	blobPutCmd.Flags().StringVarP(&blobOpts.digest, "digest", "", "", "Set the expected digest")
+	blobPutCmd.Flags().StringVarP(&blobOpts.file, "file", "f", "", "Read the blob from a file instead of stdin")
 	blobPutCmd.Flags().StringVarP(&blobOpts.formatPut, "format", "", "{{println .Digest}}", "Format output with go template syntax")
 	_ = blobPutCmd.RegisterFlagCompletionFunc("content-type", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return []string{
@@ -197,6 +252,7 @@ regctl blob copy alpine registry.example.org/library/alpine \
 	_ = blobPutCmd.Flags().MarkHidden("content-type")
 
 	blobTopCmd.AddCommand(blobDeleteCmd)
+	blobTopCmd.AddCommand(blobDiffCmd)
 	blobTopCmd.AddCommand(blobDiffConfigCmd)
 	blobTopCmd.AddCommand(blobDiffLayerCmd)
 	blobTopCmd.AddCommand(blobGetCmd)
@@ -228,6 +284,97 @@ func (blobOpts *blobCmd) runBlobDelete(cmd *cobra.Command, args []string) error
 	return rc.BlobDelete(ctx, r, descriptor.Descriptor{Digest: d})
 }
 
+func (blobOpts *blobCmd) runBlobDiff(cmd *cobra.Command, args []string) error {
+	if blobOpts.diffSort != "path" && blobOpts.diffSort != "size" {
+		return fmt.Errorf("unsupported sort %s%.0w", blobOpts.diffSort, ErrInvalidInput)
+	}
+	ctx := cmd.Context()
+	// dedup warnings
+	if w := warning.FromContext(ctx); w == nil {
+		ctx = warning.NewContext(ctx, &warning.Warning{Hook: warning.DefaultHook()})
+	}
+	refs := make([]ref.Ref, len(args))
+	for i, arg := range args {
+		r, err := ref.New(arg)
+		if err != nil {
+			return err
+		}
+		if r.Digest == "" {
+			return fmt.Errorf("layer digest is required in %s%.0w", arg, errs.ErrMissingDigest)
+		}
+		refs[i] = r
+	}
+	rc := blobOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, refs[0])
+
+	files := make([]map[string]blobDiffFile, len(refs))
+	for i, r := range refs {
+		d, err := digest.Parse(r.Digest)
+		if err != nil {
+			return err
+		}
+		blobOpts.rootOpts.log.Debug("Reading layer",
+			slog.String("ref", r.CommonName()))
+		b, err := rc.BlobGet(ctx, r, descriptor.Descriptor{Digest: d})
+		if err != nil {
+			return err
+		}
+		btr, err := b.ToTarReader()
+		if err != nil {
+			_ = b.Close()
+			return err
+		}
+		tr, err := btr.GetTarReader()
+		if err == nil {
+			files[i], err = blobDiffFiles(tr)
+		}
+		errC := btr.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read layer %s: %w", r.CommonName(), err)
+		}
+		if errC != nil {
+			return errC
+		}
+	}
+
+	result := blobDiffResult{
+		Ref1:    refs[0],
+		Ref2:    refs[1],
+		Added:   []blobDiffEntry{},
+		Removed: []blobDiffEntry{},
+		Changed: []blobDiffEntry{},
+	}
+	for name, f2 := range files[1] {
+		f1, ok := files[0][name]
+		if !ok {
+			result.Added = append(result.Added, blobDiffEntry{Path: name, Mode: f2.mode, Size: f2.size})
+			result.SizeAdded += f2.size
+		} else if f1 != f2 {
+			result.Changed = append(result.Changed, blobDiffEntry{Path: name, Mode: f2.mode, Size: f2.size, OldMode: f1.mode, OldSize: f1.size})
+			result.SizeChanged += f2.size - f1.size
+		}
+	}
+	for name, f1 := range files[0] {
+		if _, ok := files[1][name]; !ok {
+			result.Removed = append(result.Removed, blobDiffEntry{Path: name, OldMode: f1.mode, OldSize: f1.size})
+			result.SizeRemoved += f1.size
+		}
+	}
+	result.SizeDelta = result.SizeAdded - result.SizeRemoved + result.SizeChanged
+	for _, list := range [][]blobDiffEntry{result.Added, result.Removed, result.Changed} {
+		sort.Slice(list, func(i, j int) bool {
+			if blobOpts.diffSort == "size" {
+				si, sj := abs64(list[i].Size-list[i].OldSize), abs64(list[j].Size-list[j].OldSize)
+				if si != sj {
+					return si > sj
+				}
+			}
+			return list[i].Path < list[j].Path
+		})
+	}
+	return template.Writer(cmd.OutOrStdout(), blobOpts.formatDiff, result)
+}
+
 func (blobOpts *blobCmd) runBlobDiffConfig(cmd *cobra.Command, args []string) error {
 	diffOpts := []diff.Opt{}
 	if blobOpts.diffCtx > 0 {
@@ -389,10 +536,33 @@ func (blobOpts *blobCmd) runBlobGet(cmd *cobra.Command, args []string) error {
 		slog.String("host", r.Registry),
 		slog.String("repository", r.Repository),
 		slog.String("digest", args[1]))
-	blob, err := rc.BlobGet(ctx, r, descriptor.Descriptor{Digest: d})
+	bOpts := []regclient.BlobOpts{}
+	if blobOpts.skipVerify {
+		bOpts = append(bOpts, regclient.BlobWithSkipVerify())
+	}
+	blob, err := rc.BlobGet(ctx, r, descriptor.Descriptor{Digest: d}, bOpts...)
 	if err != nil {
 		return err
 	}
+	defer blob.Close()
+
+	if blobOpts.output != "" {
+		fh, err := os.Create(blobOpts.output)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(fh, blob)
+		errClose := fh.Close()
+		if err == nil {
+			err = errClose
+		}
+		if err != nil {
+			// do not leave a partial or unverified blob
+			_ = os.Remove(blobOpts.output)
+			return fmt.Errorf("failed to write blob to %s: %w", blobOpts.output, err)
+		}
+		return nil
+	}
 
 	switch blobOpts.formatGet {
 	case "raw":
@@ -521,7 +691,22 @@ func (blobOpts *blobCmd) runBlobPut(cmd *cobra.Command, args []string) error {
 		slog.String("host", r.Registry),
 		slog.String("repository", r.Repository),
 		slog.String("digest", blobOpts.digest))
-	dOut, err := rc.BlobPut(ctx, r, descriptor.Descriptor{Digest: digest.Digest(blobOpts.digest)}, cmd.InOrStdin())
+	rdr := cmd.InOrStdin()
+	d := descriptor.Descriptor{Digest: digest.Digest(blobOpts.digest)}
+	if blobOpts.file != "" {
+		fh, err := os.Open(blobOpts.file)
+		if err != nil {
+			return err
+		}
+		defer fh.Close()
+		fi, err := fh.Stat()
+		if err != nil {
+			return err
+		}
+		d.Size = fi.Size()
+		rdr = fh
+	}
+	dOut, err := rc.BlobPut(ctx, r, d, rdr)
 	if err != nil {
 		return err
 	}
@@ -601,3 +786,120 @@ func (blobOpts *blobCmd) blobReportLayer(tr *tar.Reader) ([]string, error) {
 	}
 	return report, nil
 }
+
+// blobDiffFile contains the compared fields of a file in a layer.
+type blobDiffFile struct {
+	mode     fs.FileMode
+	size     int64
+	uid, gid int
+	link     string
+	digest   digest.Digest
+}
+
+// blobDiffFiles returns the files in a tar, indexed by the cleaned path.
+func blobDiffFiles(tr *tar.Reader) (map[string]blobDiffFile, error) {
+	files := map[string]blobDiffFile{}
+	for {
+		th, err := tr.Next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return files, err
+		}
+		if th.Mode < 0 || th.Mode > math.MaxUint32 {
+			return files, fmt.Errorf("integer conversion overflow/underflow (file mode = %d)", th.Mode)
+		}
+		f := blobDiffFile{
+			mode: th.FileInfo().Mode(),
+			size: th.Size,
+			uid:  th.Uid,
+			gid:  th.Gid,
+			link: th.Linkname,
+		}
+		if th.Typeflag == tar.TypeReg && th.Size > 0 {
+			d := digest.Canonical.Digester()
+			size, err := io.Copy(d.Hash(), tr)
+			if err != nil {
+				return files, fmt.Errorf("failed to read %s: %w", th.Name, err)
+			}
+			if size != th.Size {
+				return files, fmt.Errorf("size mismatch for %s, expected %d, read %d", th.Name, th.Size, size)
+			}
+			f.digest = d.Digest()
+		}
+		name := strings.TrimPrefix(path.Clean("/"+th.Name), "/")
+		if name == "" {
+			continue
+		}
+		files[name] = f
+	}
+	return files, nil
+}
+
+func abs64(i int64) int64 {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+type blobDiffResult struct {
+	Ref1, Ref2  ref.Ref
+	Added       []blobDiffEntry
+	Removed     []blobDiffEntry
+	Changed     []blobDiffEntry
+	SizeAdded   int64
+	SizeRemoved int64
+	SizeChanged int64
+	SizeDelta   int64
+}
+
+type blobDiffEntry struct {
+	Path    string
+	Mode    fs.FileMode
+	Size    int64
+	OldMode fs.FileMode
+	OldSize int64
+}
+
+func (result blobDiffResult) MarshalPretty() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "Added:   %d files, %s\n", len(result.Added), units.HumanSize(float64(result.SizeAdded)))
+	fmt.Fprintf(buf, "Removed: %d files, %s\n", len(result.Removed), units.HumanSize(float64(result.SizeRemoved)))
+	fmt.Fprintf(buf, "Changed: %d files, %s\n", len(result.Changed), blobDiffSize(result.SizeChanged))
+	fmt.Fprintf(buf, "Total:   %s\n", blobDiffSize(result.SizeDelta))
+	if len(result.Added)+len(result.Removed)+len(result.Changed) == 0 {
+		return buf.Bytes(), nil
+	}
+	fmt.Fprintf(buf, "\n")
+	tw := tabwriter.NewWriter(buf, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(tw, "Change\tPath\tMode\tSize\tDelta\n")
+	for _, e := range result.Added {
+		fmt.Fprintf(tw, "added\t%s\t%s\t%d\t%s\n", e.Path, e.Mode.String(), e.Size, blobDiffSize(e.Size))
+	}
+	for _, e := range result.Removed {
+		fmt.Fprintf(tw, "removed\t%s\t%s\t%d\t%s\n", e.Path, e.OldMode.String(), e.OldSize, blobDiffSize(-e.OldSize))
+	}
+	for _, e := range result.Changed {
+		mode := e.Mode.String()
+		if e.Mode != e.OldMode {
+			mode = e.OldMode.String() + " -> " + mode
+		}
+		size := fmt.Sprintf("%d", e.Size)
+		if e.Size != e.OldSize {
+			size = fmt.Sprintf("%d -> %d", e.OldSize, e.Size)
+		}
+		fmt.Fprintf(tw, "changed\t%s\t%s\t%s\t%s\n", e.Path, mode, size, blobDiffSize(e.Size-e.OldSize))
+	}
+	err := tw.Flush()
+	return buf.Bytes(), err
+}
+
+// blobDiffSize formats a change in size with a sign.
+func blobDiffSize(size int64) string {
+	if size < 0 {
+		return "-" + units.HumanSize(float64(-size))
+	}
+	return "+" + units.HumanSize(float64(size))
+}
@@ -2,7 +2,14 @@ package main
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/regclient/regclient/types/errs"
 )
 
 func TestBlob(t *testing.T) {
@@ -82,6 +89,40 @@ func TestBlob(t *testing.T) {
 		}
 	})
 
+	t.Run("Put and Get File", func(t *testing.T) {
+		dir := t.TempDir()
+		bufStr := "hello file"
+		inFile := filepath.Join(dir, "in.txt")
+		outFile := filepath.Join(dir, "out.txt")
+		err := os.WriteFile(inFile, []byte(bufStr), 0644)
+		if err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+		out, err := cobraTest(t, nil, "blob", "put", "--file", inFile, "--format", "{{.Digest}} {{.Size}}", "ocidir://"+dir+"/repo")
+		if err != nil {
+			t.Fatalf("failed to put blob: %v", err)
+		}
+		dig, size, _ := strings.Cut(out, " ")
+		if size != fmt.Sprintf("%d", len(bufStr)) {
+			t.Errorf("unexpected size, expected %d, received %s", len(bufStr), size)
+		}
+		_, err = cobraTest(t, nil, "blob", "get", "--output", outFile, "ocidir://"+dir+"/repo", dig)
+		if err != nil {
+			t.Fatalf("failed to get blob: %v", err)
+		}
+		outBytes, err := os.ReadFile(outFile)
+		if err != nil {
+			t.Fatalf("failed to read output: %v", err)
+		}
+		if string(outBytes) != bufStr {
+			t.Errorf("unexpected blob content, expected %s, received %s", bufStr, string(outBytes))
+		}
+		_, err = cobraTest(t, nil, "blob", "put", "--file", filepath.Join(dir, "missing.txt"), "ocidir://"+dir+"/repo")
+		if err == nil {
+			t.Errorf("put of a missing file did not fail")
+		}
+	})
+
 	t.Run("Copy", func(t *testing.T) {
 		dir := t.TempDir()
 		// copy the blob to the tempdir
@@ -116,6 +157,30 @@ func TestBlob(t *testing.T) {
 		if out == "" {
 			t.Errorf("no output received from diff-config")
 		}
+		// list the files changed between two layers
+		out, err = cobraTest(t, nil, "blob", "diff", repo+"@"+digBaseA, repo+"@"+digBaseB,
+			"--format", "{{len .Added}} {{len .Removed}} {{range .Changed}}{{.Path}} {{.Size}}{{end}}")
+		if err != nil {
+			t.Fatalf("failed to diff layer files: %v", err)
+		}
+		if out != "0 0 base.txt 2" {
+			t.Errorf("unexpected diff output: %s", out)
+		}
+		digLayer1, err := cobraTest(t, nil, "manifest", "get", repo+":v1", "--platform", "linux/amd64", "--format", "{{(index .Layers 1).Digest}}")
+		if err != nil {
+			t.Fatalf("failed getting layer digest: %v", err)
+		}
+		out, err = cobraTest(t, nil, "blob", "diff", repo+"@"+digBaseA, repo+"@"+digLayer1)
+		if err != nil {
+			t.Fatalf("failed to diff layer files: %v", err)
+		}
+		if !strings.Contains(out, "added   layer1") || !strings.Contains(out, "removed base.txt") {
+			t.Errorf("unexpected diff output: %s", out)
+		}
+		_, err = cobraTest(t, nil, "blob", "diff", repo+":b1", repo+"@"+digLayer1)
+		if !errors.Is(err, errs.ErrMissingDigest) {
+			t.Errorf("unexpected error for missing digest: %v", err)
+		}
 	})
 
 }
@@ -0,0 +1,481 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/regclient/regclient"
+	"github.com/regclient/regclient/pkg/template"
+	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/ref"
+)
+
+const (
+	browseHeight = 24 // default terminal size when the output is not a terminal
+	browseWidth  = 80
+	browseHelp   = "up/down/j/k: move  enter/right: open  left/backspace: back  /: filter  q: quit"
+)
+
+type browseCmd struct {
+	rootOpts *rootCmd
+}
+
+func NewBrowseCmd(rootOpts *rootCmd) *cobra.Command {
+	browseOpts := browseCmd{
+		rootOpts: rootOpts,
+	}
+	var browseTopCmd = &cobra.Command{
+		Use:   "browse <registry>",
+		Short: "browse a registry in the terminal",
+		Long: `Browse the repositories, tags, platforms, and manifests of a registry with an
+interactive terminal UI. Use the arrow keys or j/k to move, enter to open the
+selected entry, left or backspace to go back, / to filter the list, and q to quit.
+Passing a repository instead of a registry starts with the list of tags, which
+is needed for registries that do not support the catalog API, like Docker Hub.`,
+		Example: `
+# browse a registry
+regctl browse registry.example.org
+
+# browse the tags of a repository
+regctl browse ghcr.io/regclient/regctl
+
+# browse an OCI Layout
+regctl browse ocidir://path/to/layout`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: rootOpts.completeArgRepo,
+		RunE:              browseOpts.runBrowse,
+	}
+	return browseTopCmd
+}
+
+// browseItem is a selectable entry in a list view.
+type browseItem struct {
+	label string
+	open  func(ctx context.Context) (*browseView, error)
+}
+
+// browseView is a single screen, either a list of items or lines of text.
+type browseView struct {
+	title   string
+	items   []browseItem
+	lines   []string
+	filter  string
+	visible []int // indexes of items matching the filter
+	cursor  int   // position in visible, or the first line of text
+	offset  int   // first visible entry in the window
+}
+
+type browser struct {
+	rc        *regclient.RegClient
+	in        *bufio.Reader
+	out       io.Writer
+	height    int
+	width     int
+	stack     []*browseView
+	status    string
+	filtering bool
+}
+
+func (browseOpts *browseCmd) runBrowse(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	rc := browseOpts.rootOpts.newRegClient()
+	b := &browser{
+		rc:     rc,
+		in:     bufio.NewReader(cmd.InOrStdin()),
+		out:    cmd.OutOrStdout(),
+		height: browseHeight,
+		width:  browseWidth,
+	}
+	var start *browseView
+	if strings.Contains(args[0], "/") {
+		r, err := ref.New(args[0])
+		if err != nil {
+			return err
+		}
+		defer rc.Close(ctx, r)
+		start, err = b.openRepo(ctx, r)
+		if err != nil {
+			return err
+		}
+	} else {
+		r, err := ref.NewHost(args[0])
+		if err != nil {
+			return err
+		}
+		defer rc.Close(ctx, r)
+		start, err = b.openRegistry(ctx, r)
+		if err != nil {
+			return err
+		}
+	}
+	b.stack = []*browseView{start}
+
+	// switch the terminal into raw mode to read individual key presses
+	if fIn, ok := cmd.InOrStdin().(*os.File); ok && term.IsTerminal(int(fIn.Fd())) {
+		state, err := term.MakeRaw(int(fIn.Fd()))
+		if err != nil {
+			return fmt.Errorf("failed to configure the terminal: %w", err)
+		}
+		defer func() { _ = term.Restore(int(fIn.Fd()), state) }()
+	}
+	if fOut, ok := cmd.OutOrStdout().(*os.File); ok && term.IsTerminal(int(fOut.Fd())) {
+		if w, h, err := term.GetSize(int(fOut.Fd())); err == nil && w > 0 && h > 3 {
+			b.width, b.height = w, h
+		}
+		// use the alternate screen to restore the terminal contents on exit
+		fmt.Fprint(b.out, "\x1b[?1049h")
+		defer fmt.Fprint(b.out, "\x1b[?1049l")
+	}
+	return b.run(ctx)
+}
+
+// run processes key presses until the user quits or the input is closed.
+func (b *browser) run(ctx context.Context) error {
+	for {
+		b.render()
+		key, err := b.readKey()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if done := b.handle(ctx, key); done {
+			return nil
+		}
+	}
+}
+
+// readKey returns the name of the key pressed, or the character typed.
+func (b *browser) readKey() (string, error) {
+	c, err := b.in.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	switch c {
+	case '\r', '\n':
+		return "enter", nil
+	case 0x7f, '\b':
+		return "backspace", nil
+	case 0x03, 0x04: // ctrl-c, ctrl-d
+		return "quit", nil
+	case 0x1b:
+		if b.in.Buffered() == 0 {
+			return "esc", nil
+		}
+		next, err := b.in.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		if next != '[' && next != 'O' {
+			return "esc", nil
+		}
+		seq := ""
+		for {
+			c, err := b.in.ReadByte()
+			if err != nil {
+				return "", err
+			}
+			seq += string(c)
+			if c >= 0x40 && c <= 0x7e {
+				break
+			}
+		}
+		switch seq {
+		case "A":
+			return "up", nil
+		case "B":
+			return "down", nil
+		case "C":
+			return "right", nil
+		case "D":
+			return "left", nil
+		case "5~":
+			return "pgup", nil
+		case "6~":
+			return "pgdn", nil
+		case "H", "1~":
+			return "home", nil
+		case "F", "4~":
+			return "end", nil
+		}
+		return "", nil
+	}
+	return string(c), nil
+}
+
+// handle updates the state for a key press, returning true to quit.
+func (b *browser) handle(ctx context.Context, key string) bool {
+	v := b.stack[len(b.stack)-1]
+	if b.filtering {
+		switch key {
+		case "enter", "esc":
+			b.filtering = false
+		case "backspace":
+			if v.filter != "" {
+				v.filter = v.filter[:len(v.filter)-1]
+			}
+		case "quit":
+			return true
+		default:
+			if len(key) == 1 && key[0] >= 0x20 {
+				v.filter += key
+			}
+		}
+		v.applyFilter()
+		return false
+	}
+	b.status = ""
+	page := b.pageSize()
+	switch key {
+	case "q", "quit":
+		return true
+	case "up", "k":
+		v.move(-1, page)
+	case "down", "j":
+		v.move(1, page)
+	case "pgup":
+		v.move(-page, page)
+	case "pgdn", " ":
+		v.move(page, page)
+	case "home", "g":
+		v.move(-v.size(), page)
+	case "end", "G":
+		v.move(v.size(), page)
+	case "/":
+		if v.items != nil {
+			b.filtering = true
+		}
+	case "esc":
+		if v.filter != "" {
+			v.filter = ""
+			v.applyFilter()
+		}
+	case "left", "backspace", "h":
+		if len(b.stack) > 1 {
+			b.stack = b.stack[:len(b.stack)-1]
+		}
+	case "enter", "right", "l":
+		if v.items == nil || len(v.visible) == 0 {
+			return false
+		}
+		item := v.items[v.visible[v.cursor]]
+		if item.open == nil {
+			return false
+		}
+		b.status = "loading " + item.label
+		b.render()
+		next, err := item.open(ctx)
+		if err != nil {
+			b.status = "error: " + err.Error()
+			return false
+		}
+		b.status = ""
+		b.stack = append(b.stack, next)
+	}
+	return false
+}
+
+// render draws the current view to the output.
+func (b *browser) render() {
+	v := b.stack[len(b.stack)-1]
+	page := b.pageSize()
+	lines := []string{b.trim(v.title), ""}
+	if v.items != nil {
+		if len(v.visible) == 0 {
+			lines = append(lines, "  (no entries)")
+		}
+		for i := v.offset; i < len(v.visible) && i < v.offset+page; i++ {
+			prefix := "  "
+			if i == v.cursor {
+				prefix = "> "
+			}
+			lines = append(lines, b.trim(prefix+v.items[v.visible[i]].label))
+		}
+	} else {
+		for i := v.cursor; i < len(v.lines) && i < v.cursor+page; i++ {
+			lines = append(lines, b.trim(v.lines[i]))
+		}
+	}
+	for len(lines) < page+2 {
+		lines = append(lines, "")
+	}
+	switch {
+	case b.filtering:
+		lines = append(lines, b.trim("filter: "+v.filter))
+	case b.status != "":
+		lines = append(lines, b.trim(b.status))
+	case v.filter != "":
+		lines = append(lines, b.trim(fmt.Sprintf("filter: %s (%d of %d, esc to clear)", v.filter, len(v.visible), len(v.items))))
+	default:
+		lines = append(lines, b.trim(browseHelp))
+	}
+	// the terminal is in raw mode, so each line needs a carriage return
+	fmt.Fprint(b.out, "\x1b[H\x1b[2J"+strings.Join(lines, "\r\n"))
+}
+
+func (b *browser) pageSize() int {
+	// leave space for the title, a blank line, and the status line
+	return b.height - 3
+}
+
+func (b *browser) trim(s string) string {
+	if len(s) > b.width {
+		return s[:b.width]
+	}
+	return s
+}
+
+func (v *browseView) size() int {
+	if v.items != nil {
+		return len(v.visible)
+	}
+	return len(v.lines)
+}
+
+// move shifts the cursor for lists, or scrolls text, keeping the cursor within the window.
+func (v *browseView) move(delta, page int) {
+	last := v.size() - 1
+	if v.items == nil {
+		last = v.size() - page
+	}
+	v.cursor += delta
+	if v.cursor > last {
+		v.cursor = last
+	}
+	if v.cursor < 0 {
+		v.cursor = 0
+	}
+	if v.cursor < v.offset {
+		v.offset = v.cursor
+	} else if v.cursor >= v.offset+page {
+		v.offset = v.cursor - page + 1
+	}
+}
+
+func (v *browseView) applyFilter() {
+	v.visible = []int{}
+	for i, item := range v.items {
+		if v.filter == "" || strings.Contains(item.label, v.filter) {
+			v.visible = append(v.visible, i)
+		}
+	}
+	v.cursor, v.offset = 0, 0
+}
+
+func newBrowseList(title string, items []browseItem) *browseView {
+	v := &browseView{title: title, items: items}
+	v.applyFilter()
+	return v
+}
+
+func (b *browser) openRegistry(ctx context.Context, r ref.Ref) (*browseView, error) {
+	rl, err := b.rc.RepoList(ctx, r.Registry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repositories on %s: %w", r.Registry, err)
+	}
+	repos, err := rl.GetRepos()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(repos)
+	items := make([]browseItem, len(repos))
+	for i, repo := range repos {
+		rRepo := r.SetTag("")
+		rRepo.Repository = repo
+		items[i] = browseItem{
+			label: repo,
+			open: func(ctx context.Context) (*browseView, error) {
+				return b.openRepo(ctx, rRepo)
+			},
+		}
+	}
+	return newBrowseList(fmt.Sprintf("Repositories on %s (%d)", r.Registry, len(repos)), items), nil
+}
+
+func (b *browser) openRepo(ctx context.Context, r ref.Ref) (*browseView, error) {
+	tl, err := b.rc.TagList(ctx, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags on %s: %w", r.CommonName(), err)
+	}
+	tags, err := tl.GetTags()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]browseItem, len(tags))
+	for i, t := range tags {
+		rTag := r.SetTag(t)
+		items[i] = browseItem{
+			label: t,
+			open: func(ctx context.Context) (*browseView, error) {
+				return b.openManifest(ctx, rTag)
+			},
+		}
+	}
+	return newBrowseList(fmt.Sprintf("Tags in %s (%d)", r.SetTag("").CommonName(), len(tags)), items), nil
+}
+
+// openManifest shows the platforms of an index, or the details of any other manifest.
+func (b *browser) openManifest(ctx context.Context, r ref.Ref) (*browseView, error) {
+	m, err := b.rc.ManifestGet(ctx, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get manifest %s: %w", r.CommonName(), err)
+	}
+	detail, err := browseDetail(r, m)
+	if err != nil {
+		return nil, err
+	}
+	mi, ok := m.(manifest.Indexer)
+	if !m.IsList() || !ok {
+		return detail, nil
+	}
+	dl, err := mi.GetManifestList()
+	if err != nil {
+		return nil, err
+	}
+	items := []browseItem{{
+		label: "[index details]",
+		open: func(ctx context.Context) (*browseView, error) {
+			return detail, nil
+		},
+	}}
+	for _, d := range dl {
+		label := d.MediaType
+		if d.Platform != nil {
+			label = d.Platform.String()
+		} else if d.ArtifactType != "" {
+			label = d.ArtifactType
+		}
+		rDig := r.SetDigest(d.Digest.String())
+		items = append(items, browseItem{
+			label: fmt.Sprintf("%-24s %s", label, d.Digest.String()),
+			open: func(ctx context.Context) (*browseView, error) {
+				return b.openManifest(ctx, rDig)
+			},
+		})
+	}
+	return newBrowseList(fmt.Sprintf("Platforms in %s (%d)", r.CommonName(), len(dl)), items), nil
+}
+
+func browseDetail(r ref.Ref, m manifest.Manifest) (*browseView, error) {
+	buf := &strings.Builder{}
+	err := template.Writer(buf, "{{printPretty .}}", m)
+	if err != nil {
+		return nil, err
+	}
+	return &browseView{
+		title: "Manifest " + r.CommonName(),
+		lines: strings.Split(strings.TrimRight(buf.String(), "\n"), "\n"),
+	}, nil
+}
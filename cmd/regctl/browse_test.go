@@ -0,0 +1,94 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/olareg/olareg"
+	oConfig "github.com/olareg/olareg/config"
+)
+
+func TestBrowse(t *testing.T) {
+	regHandler := olareg.New(oConfig.Config{
+		Storage: oConfig.ConfigStorage{
+			StoreType: oConfig.StoreMem,
+			RootDir:   "../../testdata",
+		},
+	})
+	// olareg does not implement the catalog API
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/_catalog" {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"repositories":["testrepo","missing"]}`))
+			return
+		}
+		if r.URL.Path == "/v2/missing/tags/list" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		regHandler.ServeHTTP(w, r)
+	}))
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	t.Cleanup(func() {
+		ts.Close()
+		_ = regHandler.Close()
+	})
+	t.Setenv(ConfigEnv, filepath.Join(t.TempDir(), "config.json"))
+	hostFlag := "reg=" + tsHost + ",tls=disabled"
+
+	tt := []struct {
+		name      string
+		args      []string
+		keys      string
+		expectErr bool
+		expectOut []string
+	}{
+		{
+			name:      "registry",
+			args:      []string{"browse", tsHost, "--host", hostFlag},
+			keys:      "j\r/v1\r\r\x1b[B\rq",
+			expectOut: []string{"Repositories on " + tsHost + " (2)", "> missing", "Tags in " + tsHost + "/testrepo", "filter: v1", "Platforms in " + tsHost + "/testrepo:v1", "linux/amd64", "Manifest " + tsHost + "/testrepo@sha256:", "Layers:"},
+		},
+		{
+			name:      "missing repo",
+			args:      []string{"browse", tsHost, "--host", hostFlag},
+			keys:      "\rq",
+			expectOut: []string{"> missing", "error: failed to list tags"},
+		},
+		{
+			name:      "back to the tag list",
+			args:      []string{"browse", "ocidir://../../testdata/testrepo"},
+			keys:      "G\rhk",
+			expectOut: []string{"Tags in ocidir://../../testdata/testrepo", "> v3", "> v2"},
+		},
+		{
+			name:      "invalid registry",
+			args:      []string{"browse", "ocidir://../../testdata/missing"},
+			expectErr: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := cobraTest(t, &cobraTestOpts{stdin: strings.NewReader(tc.keys)}, tc.args...)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("did not receive expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("returned unexpected error: %v", err)
+			}
+			for _, expect := range tc.expectOut {
+				if !strings.Contains(out, expect) {
+					t.Errorf("output missing %s:\n%s", expect, out)
+				}
+			}
+		})
+	}
+}
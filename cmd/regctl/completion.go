@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/spf13/cobra"
 
+	"github.com/regclient/regclient/internal/intoto"
 	"github.com/regclient/regclient/types/mediatype"
 	"github.com/regclient/regclient/types/ref"
 )
@@ -117,9 +119,42 @@ func completeArgMediaTypeManifest(cmd *cobra.Command, args []string, toComplete
 	}, cobra.ShellCompDirectiveNoFileComp
 }
 
+func completeArgPredicateType(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	result := []string{}
+	for name := range intoto.PredicateTypes {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeArgRepo completes a registry from the configuration, then a repository from the catalog API.
+func (rootOpts *rootCmd) completeArgRepo(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if strings.Contains(toComplete, "://") {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	if !strings.Contains(toComplete, "/") {
+		return completeRegistries(toComplete), cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+	}
+	return rootOpts.completeRepos(toComplete), cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+// completeArgTag completes a registry, a repository, and then the tag.
+// Registries and repositories are only listed when they match, otherwise the input is completed as a repository with tags.
 func (rootOpts *rootCmd) completeArgTag(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 	result := []string{}
-	// TODO: is it possible to expand registry, then repo, then tag?
+	if !strings.Contains(toComplete, "://") {
+		i := strings.LastIndex(toComplete, "/")
+		if i < 0 {
+			if regs := completeRegistries(toComplete); len(regs) > 0 {
+				return regs, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+			}
+		} else if !strings.ContainsAny(toComplete[i:], ":@") {
+			if repos := rootOpts.completeRepos(toComplete); len(repos) > 0 {
+				return repos, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+			}
+		}
+	}
 	input := strings.TrimRight(toComplete, ":")
 	r, err := ref.New(input)
 	if err != nil || r.Digest != "" {
@@ -144,3 +179,47 @@ func (rootOpts *rootCmd) completeArgTag(cmd *cobra.Command, args []string, toCom
 	}
 	return result, cobra.ShellCompDirectiveNoFileComp
 }
+
+// completeRegistries returns the configured registries matching the prefix, with a trailing slash for the repository.
+func completeRegistries(toComplete string) []string {
+	result := []string{}
+	c, err := ConfigLoadDefault()
+	if err != nil {
+		return result
+	}
+	for host := range c.Hosts {
+		if strings.HasPrefix(host, toComplete) {
+			result = append(result, host+"/")
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// completeRepos returns the repositories matching the prefix, using the catalog API of the registry in the prefix.
+// Registries without the catalog API, like Docker Hub, return an empty list.
+func (rootOpts *rootCmd) completeRepos(toComplete string) []string {
+	result := []string{}
+	host, _, ok := strings.Cut(toComplete, "/")
+	// a Docker Hub repository like "library/alpine" does not include a registry
+	if !ok || (!strings.ContainsAny(host, ".:") && host != "localhost") {
+		return result
+	}
+	rc := rootOpts.newRegClient()
+	rl, err := rc.RepoList(context.Background(), host)
+	if err != nil {
+		return result
+	}
+	repos, err := rl.GetRepos()
+	if err != nil {
+		return result
+	}
+	for _, repo := range repos {
+		name := host + "/" + repo
+		if strings.HasPrefix(name, toComplete) {
+			result = append(result, name)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
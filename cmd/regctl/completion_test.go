@@ -0,0 +1,101 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/olareg/olareg"
+	oConfig "github.com/olareg/olareg/config"
+)
+
+func TestCompletion(t *testing.T) {
+	tempDir := t.TempDir()
+	regHandler := olareg.New(oConfig.Config{
+		Storage: oConfig.ConfigStorage{
+			StoreType: oConfig.StoreMem,
+			RootDir:   "../../testdata",
+		},
+	})
+	// olareg does not implement the catalog API
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/_catalog" {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"repositories":["testrepo","testrepo-other","external"]}`))
+			return
+		}
+		regHandler.ServeHTTP(w, r)
+	}))
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	t.Cleanup(func() {
+		ts.Close()
+		_ = regHandler.Close()
+	})
+	t.Setenv(ConfigEnv, filepath.Join(tempDir, "config.json"))
+	_, err := cobraTest(t, nil, "registry", "set", tsHost, "--tls", "disabled")
+	if err != nil {
+		t.Fatalf("failed to disable TLS for internal registry")
+	}
+	tt := []struct {
+		name      string
+		args      []string
+		expect    []string
+		notExpect []string
+	}{
+		{
+			name:   "registry",
+			args:   []string{"__complete", "image", "inspect", tsHost[:3]},
+			expect: []string{tsHost + "/"},
+		},
+		{
+			name:      "repository",
+			args:      []string{"__complete", "image", "inspect", tsHost + "/test"},
+			expect:    []string{tsHost + "/testrepo", tsHost + "/testrepo-other"},
+			notExpect: []string{tsHost + "/external"},
+		},
+		{
+			name:      "tag",
+			args:      []string{"__complete", "image", "inspect", tsHost + "/testrepo:v"},
+			expect:    []string{tsHost + "/testrepo:v1", tsHost + "/testrepo:v2"},
+			notExpect: []string{tsHost + "/testrepo:b1"},
+		},
+		{
+			name:      "tag ls repository",
+			args:      []string{"__complete", "tag", "ls", tsHost + "/ext"},
+			expect:    []string{tsHost + "/external"},
+			notExpect: []string{tsHost + "/testrepo"},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := cobraTest(t, nil, tc.args...)
+			if err != nil {
+				t.Fatalf("failed to complete: %v", err)
+			}
+			lines := strings.Split(out, "\n")
+			for _, e := range tc.expect {
+				found := false
+				for _, l := range lines {
+					if l == e {
+						found = true
+						break
+					}
+				}
+				if !found {
+					t.Errorf("missing completion %s, received %s", e, out)
+				}
+			}
+			for _, e := range tc.notExpect {
+				for _, l := range lines {
+					if l == e {
+						t.Errorf("unexpected completion %s", e)
+					}
+				}
+			}
+		})
+	}
+}
@@ -22,6 +22,8 @@ var (
 	ConfigDir = ".regctl"
 	// ConfigEnv is the environment variable to override the config filename
 	ConfigEnv = "REGCTL_CONFIG"
+	// TokenCacheFilename is the filename for cached auth tokens, saved in the same directory as the config
+	TokenCacheFilename = "token-cache.json"
 )
 
 // Config struct contains contents loaded from / saved to a config file
@@ -33,6 +35,7 @@ type Config struct {
 	BlobLimit     int64                   `json:"blobLimit,omitempty"`
 	IncDockerCert *bool                   `json:"incDockerCert,omitempty"`
 	IncDockerCred *bool                   `json:"incDockerCred,omitempty"`
+	TokenCache    *bool                   `json:"tokenCache,omitempty"`
 }
 
 type configCmd struct {
@@ -42,6 +45,7 @@ type configCmd struct {
 	dockerCert    bool
 	dockerCred    bool
 	format        string
+	tokenCache    bool
 }
 
 func NewConfigCmd(rootOpts *rootCmd) *cobra.Command {
@@ -74,7 +78,10 @@ regctl config get --format '{{.Filename}}'`,
 regctl config set --docker-cred=false
 
 # enable loading credentials from docker
-regctl config set --docker-cred`,
+regctl config set --docker-cred
+
+# save auth tokens between commands
+regctl config set --token-cache`,
 		Args: cobra.ExactArgs(0),
 		RunE: configOpts.runConfigSet,
 	}
@@ -85,6 +92,7 @@ regctl config set --docker-cred`,
 	configSetCmd.Flags().BoolVar(&configOpts.dockerCert, "docker-cert", false, "load certificates from docker")
 	configSetCmd.Flags().BoolVar(&configOpts.dockerCred, "docker-cred", false, "load credentials from docker")
 	configSetCmd.Flags().StringVar(&configOpts.defCredHelper, "default-cred-helper", "", "default credential helper")
+	configSetCmd.Flags().BoolVar(&configOpts.tokenCache, "token-cache", false, "save auth tokens between commands in "+TokenCacheFilename+", readable only by the current user")
 
 	configTopCmd.AddCommand(configGetCmd)
 	configTopCmd.AddCommand(configSetCmd)
@@ -138,6 +146,14 @@ func (configOpts *configCmd) runConfigSet(cmd *cobra.Command, args []string) err
 		}
 	}
 
+	if flagChanged(cmd, "token-cache") {
+		if configOpts.tokenCache {
+			c.TokenCache = &configOpts.tokenCache
+		} else {
+			c.TokenCache = nil
+		}
+	}
+
 	if c.HostDefault != nil && c.HostDefault.IsZero() {
 		c.HostDefault = nil
 	}
@@ -21,7 +21,7 @@ func TestConfig(t *testing.T) {
 
 	// set options
 	testLimit := "420000000"
-	out, err = cobraTest(t, nil, "config", "set", "--blob-limit", testLimit, "--docker-cert=false", "--docker-cred=false")
+	out, err = cobraTest(t, nil, "config", "set", "--blob-limit", testLimit, "--docker-cert=false", "--docker-cred=false", "--token-cache")
 	if err != nil {
 		t.Errorf("failed to set config: %v", err)
 	}
@@ -52,6 +52,14 @@ func TestConfig(t *testing.T) {
 		t.Errorf("unexpected output for docker-cred, expected: false, received: %s", out)
 	}
 
+	out, err = cobraTest(t, nil, "config", "get", "--format", "{{ .TokenCache }}")
+	if err != nil {
+		t.Errorf("failed to run config get on token-cache: %v", err)
+	}
+	if out != "true" {
+		t.Errorf("unexpected output for token-cache, expected: true, received: %s", out)
+	}
+
 	// set a default credential helper
 	out, err = cobraTest(t, nil, "config", "set", "--default-cred-helper", "test-helper")
 	if err != nil {
@@ -70,7 +78,7 @@ func TestConfig(t *testing.T) {
 	}
 
 	// reset back to zero values
-	out, err = cobraTest(t, nil, "config", "set", "--blob-limit", "0", "--docker-cert", "--docker-cred", "--default-cred-helper", "")
+	out, err = cobraTest(t, nil, "config", "set", "--blob-limit", "0", "--docker-cert", "--docker-cred", "--token-cache=false", "--default-cred-helper", "")
 	if err != nil {
 		t.Errorf("failed to set default values: %v", err)
 	}
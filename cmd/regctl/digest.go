@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"io"
+	"os"
 
 	// crypto libraries included for go-digest
 	_ "crypto/sha256"
@@ -12,6 +13,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/regclient/regclient/pkg/template"
+	"github.com/regclient/regclient/types/errs"
 )
 
 type digestCmd struct {
@@ -24,22 +26,29 @@ func NewDigestCmd(rootOpts *rootCmd) *cobra.Command {
 	digestOpts := digestCmd{
 		rootOpts: rootOpts,
 	}
-	// TODO(bmitch): consider if this should be moved out of hidden/experimental
 	var digestCmd = &cobra.Command{
-		Hidden: true,
-		Use:    "digest",
-		Short:  "compute digest on stdin",
-		Long: `Output the digest from content provided on stdin.
-This command is EXPERIMENTAL and could be removed in the future.`,
+		Use:   "digest [file]",
+		Short: "compute digest of a file or stdin",
+		Long: `Output the digest of a file, or of the content provided on stdin when the
+file is not provided or is "-". The digest matches the value used by registries
+for a blob with the same content.`,
 		Example: `
 # compute the digest of hello world
-echo hello world | regctl digest`,
-		Args: cobra.RangeArgs(0, 0),
-		RunE: digestOpts.runDigest,
+echo hello world | regctl digest
+
+# compute the sha512 digest of a layer
+regctl digest --algorithm sha512 layer.tgz`,
+		Args:              cobra.RangeArgs(0, 1),
+		ValidArgsFunction: completeArgDefault,
+		RunE:              digestOpts.runDigest,
 	}
 
 	digestCmd.Flags().StringVar(&digestOpts.algo, "algorithm", "sha256", "Digest algorithm")
+	_ = digestCmd.RegisterFlagCompletionFunc("algorithm", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"sha256", "sha512"}, cobra.ShellCompDirectiveNoFileComp
+	})
 	digestCmd.Flags().StringVar(&digestOpts.format, "format", "{{.String}}", "Go template to output the digest result")
+	_ = digestCmd.RegisterFlagCompletionFunc("format", completeArgNone)
 
 	return digestCmd
 }
@@ -47,11 +56,20 @@ echo hello world | regctl digest`,
 func (digestOpts *digestCmd) runDigest(cmd *cobra.Command, args []string) error {
 	algo := digest.Algorithm(digestOpts.algo)
 	if !algo.Available() {
-		return fmt.Errorf("digest algorithm %s is not available", digestOpts.algo)
+		return fmt.Errorf("digest algorithm %s is not available%.0w", digestOpts.algo, errs.ErrUnsupported)
 	}
 	digester := algo.Digester()
 
-	_, err := io.Copy(digester.Hash(), cmd.InOrStdin())
+	rdr := cmd.InOrStdin()
+	if len(args) > 0 && args[0] != "-" {
+		fh, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer fh.Close()
+		rdr = fh
+	}
+	_, err := io.Copy(digester.Hash(), rdr)
 	if err != nil {
 		return err
 	}
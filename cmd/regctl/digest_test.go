@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/regclient/regclient/types/errs"
+)
+
+func TestDigest(t *testing.T) {
+	tempDir := t.TempDir()
+	file := filepath.Join(tempDir, "hello.txt")
+	err := os.WriteFile(file, []byte("hello world\n"), 0644)
+	if err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	digSHA256 := "sha256:a948904f2f0f479b8f8197694b30184b0d2ed1c1cd2a1ec0fb85d299a192a447"
+	tt := []struct {
+		name      string
+		args      []string
+		stdin     string
+		expectErr error
+		expectOut string
+	}{
+		{
+			name:      "stdin",
+			args:      []string{"digest"},
+			stdin:     "hello world\n",
+			expectOut: digSHA256,
+		},
+		{
+			name:      "stdin dash",
+			args:      []string{"digest", "-"},
+			stdin:     "hello world\n",
+			expectOut: digSHA256,
+		},
+		{
+			name:      "file",
+			args:      []string{"digest", file},
+			expectOut: digSHA256,
+		},
+		{
+			name:      "sha512",
+			args:      []string{"digest", "--algorithm", "sha512", "--format", "{{.Algorithm}}", file},
+			expectOut: "sha512",
+		},
+		{
+			name:      "missing file",
+			args:      []string{"digest", filepath.Join(tempDir, "missing.txt")},
+			expectErr: os.ErrNotExist,
+		},
+		{
+			name:      "unknown algorithm",
+			args:      []string{"digest", "--algorithm", "md5", file},
+			expectErr: errs.ErrUnsupported,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := cobraTest(t, &cobraTestOpts{stdin: strings.NewReader(tc.stdin)}, tc.args...)
+			if tc.expectErr != nil {
+				if err == nil {
+					t.Errorf("command did not fail")
+				} else if !errors.Is(err, tc.expectErr) {
+					t.Errorf("unexpected error, expected %v, received %v", tc.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("returned unexpected error: %v", err)
+			}
+			if out != tc.expectOut {
+				t.Errorf("unexpected output, expected %s, received %s", tc.expectOut, out)
+			}
+		})
+	}
+}
@@ -2,8 +2,11 @@ package main
 
 import (
 	"archive/tar"
+	"bufio"
 	"bytes"
 	"context"
+	"crypto"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -15,6 +18,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"text/tabwriter"
 	"time"
 
 	"github.com/opencontainers/go-digest"
@@ -22,11 +26,14 @@ import (
 
 	"github.com/regclient/regclient"
 	"github.com/regclient/regclient/internal/ascii"
+	"github.com/regclient/regclient/internal/cosign"
+	"github.com/regclient/regclient/internal/intoto"
 	"github.com/regclient/regclient/internal/strparse"
 	"github.com/regclient/regclient/internal/units"
 	"github.com/regclient/regclient/mod"
 	"github.com/regclient/regclient/pkg/archive"
 	"github.com/regclient/regclient/pkg/template"
+	"github.com/regclient/regclient/scheme"
 	"github.com/regclient/regclient/types"
 	"github.com/regclient/regclient/types/blob"
 	"github.com/regclient/regclient/types/descriptor"
@@ -43,15 +50,22 @@ import (
 type imageCmd struct {
 	rootOpts        *rootCmd
 	annotations     []string
+	attestPredicate string
+	attestType      string
+	blobConcurrent  int
 	byDigest        bool
 	checkBaseRef    string
 	checkBaseDigest string
 	checkSkipConfig bool
+	copyFile        string
+	copyParallel    int
+	copyPlatforms   []string
 	create          string
 	created         string
 	digestTags      bool
 	exportCompress  bool
 	exportRef       string
+	externalFlatten bool
 	fastCheck       bool
 	forceRecursive  bool
 	format          string
@@ -61,13 +75,19 @@ type imageCmd struct {
 	includeExternal bool
 	labels          []string
 	mediaType       string
+	minRemain       int
 	modOpts         []mod.Opts
 	platform        string
+	policy          string
 	platforms       []string
+	progress        string
 	referrers       bool
 	referrerSrc     string
 	referrerTgt     string
 	replace         bool
+	signKey         string
+	signPassStdin   bool
+	signReferrers   bool
 }
 
 var imageKnownTypes = []string{
@@ -87,6 +107,27 @@ func NewImageCmd(rootOpts *rootCmd) *cobra.Command {
 		Use:   "image <cmd>",
 		Short: "manage images",
 	}
+	var imageAttestCmd = &cobra.Command{
+		Use:   "attest <image_ref>",
+		Short: "attach an in-toto attestation to an image",
+		Long: `Attach an in-toto attestation, such as provenance or an SBOM, to an image.
+The predicate is wrapped in an in-toto statement with the image digest as the subject,
+and pushed as a referrer of the image.
+With --key, the statement is signed and pushed in a DSSE envelope.
+The predicate type may be a URI or one of the short names:
+custom, cyclonedx, slsaprovenance, slsaprovenance1, spdx, spdxjson, vuln.`,
+		Example: `
+# attach SLSA provenance to an image
+regctl image attest --predicate provenance.json --type slsaprovenance1 \
+  registry.example.org/repo:v1
+
+# attach a signed SPDX SBOM
+regctl image attest --predicate sbom.spdx.json --type spdx --key signing.key \
+  registry.example.org/repo:v1`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: rootOpts.completeArgTag,
+		RunE:              imageOpts.runImageAttest,
+	}
 	var imageCheckBaseCmd = &cobra.Command{
 		Use:     "check-base <image_ref>",
 		Aliases: []string{},
@@ -100,7 +141,11 @@ If the base image does not match, the command exits with a non-zero status.
 Use "-v info" to see more details.`,
 		Example: `
 # report if base image has changed using annotations
-regctl image check-base ghcr.io/regclient/regctl:alpine -v info`,
+regctl image check-base ghcr.io/regclient/regctl:alpine -v info
+
+# rebuild when the base image has changed, e.g. from a cron job
+regctl image check-base registry.example.org/app:latest \
+  --base docker.io/library/alpine:3 || make rebuild`,
 		Args:              cobra.ExactArgs(1),
 		ValidArgsFunction: rootOpts.completeArgTag,
 		RunE:              imageOpts.runImageCheckBase,
@@ -112,12 +157,23 @@ regctl image check-base ghcr.io/regclient/regctl:alpine -v info`,
 		Long: `Copy or retag an image. This works between registries and only pulls layers
 that do not exist at the target. In the same registry it attempts to mount
 the layers between repositories. And within the same repository it only
-sends the manifest with the new tag.`,
+sends the manifest with the new tag.
+A single --platform copies only the matching image. Repeating --platform
+copies a reduced index with only the matching platforms, changing the digest.
+With --file, each line of the file contains a source and target image ref
+separated by whitespace, blank lines and lines starting with # are ignored.
+The images are copied with up to --parallel copies running at once, and a
+summary of each copy is output. Use "-" to read the list from stdin.
+A failed copy does not stop the other copies, the command fails after the summary.`,
 		Example: `
 # copy an image
 regctl image copy \
   ghcr.io/regclient/regctl:edge registry.example.org/regclient/regctl:edge
 
+# copy an image with newline delimited json progress events for CI logs
+regctl image copy --progress json \
+  ghcr.io/regclient/regctl:edge registry.example.org/regclient/regctl:edge
+
 # copy an image with signatures
 regctl image copy --digest-tags \
   ghcr.io/regclient/regctl:edge registry.example.org/regclient/regctl:edge
@@ -126,6 +182,10 @@ regctl image copy --digest-tags \
 regctl image copy --platform local \
   ghcr.io/regclient/regctl:edge registry.example.org/regclient/regctl:edge
 
+# mirror a reduced index with only the linux/amd64 and linux/arm64 platforms
+regctl image copy --platform linux/amd64 --platform linux/arm64 \
+  ghcr.io/regclient/regctl:edge registry.example.org/regclient/regctl:edge
+
 # retag an image
 regctl image copy registry.example.org/repo:v1.2.3 registry.example.org/repo:v1
 
@@ -133,10 +193,21 @@ regctl image copy registry.example.org/repo:v1.2.3 registry.example.org/repo:v1
 regctl image copy --referrers \
   ghcr.io/regclient/regctl:edge ocidir://regctl:edge
 
+# copy an image into the local Docker engine
+regctl image copy \
+  ghcr.io/regclient/regctl:edge docker-daemon://regclient/regctl:edge
+
 # copy a windows image, including foreign layers
 regctl image copy --platform windows/amd64,osver=10.0.17763.4974 --include-external \
-  golang:latest registry.example.org/library/golang:windows`,
-		Args:              cobra.ExactArgs(2),
+  golang:latest registry.example.org/library/golang:windows
+
+# copy a windows image, converting foreign layers to regular layers
+regctl image copy --platform windows/amd64,osver=10.0.17763.4974 --external-flatten \
+  golang:latest registry.example.org/library/golang:windows
+
+# copy a list of images, 4 at a time
+regctl image copy --file images.txt --parallel 4 --digest-tags`,
+		Args:              cobra.RangeArgs(0, 2),
 		ValidArgsFunction: rootOpts.completeArgTag,
 		RunE:              imageOpts.runImageCopy,
 	}
@@ -183,19 +254,60 @@ regctl image digest ghcr.io/regclient/regctl`,
 		ValidArgsFunction: rootOpts.completeArgTag,
 		RunE:              manifestOpts.runManifestHead,
 	}
+	var imageDiffCmd = &cobra.Command{
+		Use:   "diff <image_ref> <image_ref>",
+		Short: "compare images",
+		Long: `Show the differences between two images, including the layers added and
+removed, changes to the config (environment, labels, entrypoint, command, user,
+and working directory), and the change in size. When either reference is a
+manifest list, the platform is selected with --platform, defaulting to local.`,
+		Example: `
+# compare a rebuild of an image
+regctl image diff registry.example.org/repo:v1 registry.example.org/repo:v1-rebuild
+
+# compare the arm64 platform of two images with JSON output
+regctl image diff --platform linux/arm64 --format '{{jsonPretty .}}' \
+  registry.example.org/repo:v1 registry.example.org/repo:v2`,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: rootOpts.completeArgTag,
+		RunE:              imageOpts.runImageDiff,
+	}
 	var imageExportCmd = &cobra.Command{
 		Use:   "export <image_ref> [filename]",
 		Short: "export image",
 		Long: `Exports an image into a tar file that can be later loaded into a docker
 engine with "docker load". The tar file is output to stdout by default.
-Compression is typically not useful since layers are already compressed.`,
+Compression is typically not useful since layers are already compressed.
+The tar file is also an OCI Layout that can be pushed with "regctl image import",
+including referrers when both commands are run with --referrers.`,
 		Example: `
 # export an image
-regctl image export registry.example.org/repo:v1 >image-v1.tar`,
+regctl image export registry.example.org/repo:v1 >image-v1.tar
+
+# export an image with signatures and other referrers for an air-gapped network
+regctl image export --referrers registry.example.org/repo:v1 image-v1.tar`,
 		Args:              cobra.RangeArgs(1, 2),
 		ValidArgsFunction: rootOpts.completeArgTag,
 		RunE:              imageOpts.runImageExport,
 	}
+	var imageGetAttestationCmd = &cobra.Command{
+		Use:   "get-attestation <image_ref>",
+		Short: "get the in-toto attestations of an image",
+		Long: `Get the in-toto attestations attached to an image as referrers.
+Use --type to only return a single predicate type.
+With --key, only attestations signed by the key are returned, and unsigned attestations are skipped.
+The command fails when no matching attestations are found.`,
+		Example: `
+# list the attestations of an image
+regctl image get-attestation registry.example.org/repo:v1
+
+# output the predicate of a verified SBOM
+regctl image get-attestation --type spdx --key signing.pub registry.example.org/repo:v1 \
+  --format '{{range .Attestations}}{{printf "%s\n" .Statement.Predicate}}{{end}}'`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: rootOpts.completeArgTag,
+		RunE:              imageOpts.runImageGetAttestation,
+	}
 	var imageGetFileCmd = &cobra.Command{
 		Use:     "get-file <image_ref> <filename> [out-file]",
 		Aliases: []string{"cat"},
@@ -213,10 +325,14 @@ regctl image get-file --platform local alpine /etc/alpine-release`,
 		Short: "import image",
 		Long: `Imports an image from a tar file. This must be either a docker formatted tar
 from "docker save" or an OCI Layout compatible tar. The output from
-"regctl image export" can be used. Stdin is not permitted for the tar file.`,
+"regctl image export" can be used. Use "-" to read the tar file from stdin,
+which is buffered to a temporary file.`,
 		Example: `
 # import an image saved from docker
-regctl image import registry.example.org/repo:v1 image-v1.tar`,
+regctl image import registry.example.org/repo:v1 image-v1.tar
+
+# import an image with referrers from an export on stdin
+regctl image import --referrers registry.example.org/repo:v1 - <image-v1.tar`,
 		Args:              cobra.ExactArgs(2),
 		ValidArgsFunction: completeArgList([]completeFunc{rootOpts.completeArgTag, completeArgDefault}),
 		RunE:              imageOpts.runImageImport,
@@ -226,10 +342,16 @@ regctl image import registry.example.org/repo:v1 image-v1.tar`,
 		Aliases: []string{"config"},
 		Short:   "inspect image",
 		Long: `Shows the config json for an image and is equivalent to pulling the image
-in docker, and inspecting it, but without pulling any of the image layers.`,
+in docker, and inspecting it, but without pulling any of the image layers.
+For a multi-platform image, the config of the local platform is shown unless
+--platform is set. The command fails when the platform is not in the index,
+listing the available platforms.`,
 		Example: `
 # return the image config for the nginx image
-regctl image inspect --platform local nginx`,
+regctl image inspect --platform local nginx
+
+# return the image config for the arm64 platform
+regctl image inspect --platform linux/arm64 nginx`,
 		Args:              cobra.ExactArgs(1),
 		ValidArgsFunction: rootOpts.completeArgTag,
 		RunE:              imageOpts.runImageInspect,
@@ -266,6 +388,41 @@ regctl image mod registry.example.org/repo:v1 \
 # convert an image to the OCI media types, copying to local registry
 regctl image mod alpine:3.5 --to-oci --create registry.example.org/alpine:3.5
 
+# recompress the layers with zstd while copying to another registry
+regctl image mod alpine:3.5 --to-oci --layer-compress zstd \
+  --create registry.example.org/alpine:3.5-zstd
+
+# mirror only the linux/amd64 and linux/arm64 platforms of a multi-platform image
+regctl image mod alpine:3 --create registry.example.org/alpine:3 \
+  --platform-keep linux/amd64,linux/arm64
+
+# copy an image with its SBOM to another registry, leaving the signatures behind
+regctl image mod registry.example.org/repo:v1 --create registry.example.org/mirror:v1 \
+  --annotation org.example.mirror=true --referrers \
+  --referrers-skip application/vnd.dev.cosign.artifact.sig.v1+json
+
+# normalize the layer tar files with fixed permissions and timestamps for a stable digest
+regctl image mod registry.example.org/repo:v1 --create v1-normalized \
+  --layer-normalize="fileMode=644,execMode=755,dirMode=755" \
+  --time "set=${SOURCE_DATE_EPOCH}"
+
+# migrate an artifact that uses the config media type to the OCI artifactType
+regctl image mod registry.example.org/artifact:v1 --replace \
+  --artifact-type application/vnd.example.data \
+  --config-media-type application/vnd.oci.empty.v1+json
+
+# remove build args and ENV steps from the history of an image
+regctl image mod registry.example.org/repo:v1 --create v1-clean \
+  --buildarg-rm-all --history-rm "^ENV "
+
+# scrub private keys and npm credentials from every layer
+regctl image mod registry.example.org/repo:v1 --create v1-scrubbed \
+  --layer-strip-glob "*.pem" --layer-strip-glob "/root/.npmrc"
+
+# recompress a single layer with the highest gzip level
+regctl image mod registry.example.org/repo:v1 --create v1-small \
+  --layer-compress "algo=gzip,level=9,digest=sha256:..."
+
 # append a layer to only the linux/amd64 image using the file.tar contents
 regctl image mod registry.example.org/repo:v1 --create v1-extended \
   --layer-add "tar=file.tar,platform=linux/amd64"
@@ -274,14 +431,30 @@ regctl image mod registry.example.org/repo:v1 --create v1-extended \
 regctl image mod registry.example.org/repo:v1 --create v1-extended \
   --layer-add "dir=path/to/directory"
 
+# append a layer with a custom history entry
+regctl image mod registry.example.org/repo:v1 --create v1-extended \
+  --layer-add "dir=path/to/directory,createdBy=COPY directory /"
+
 # set the timestamp on the config and layers, ignoring the alpine base image layers
 regctl image mod registry.example.org/repo:v1 --create v1-time \
   --time "set=2021-02-03T04:05:06Z,base-ref=alpine:3"
 
+# reproducibly set all timestamps from the SOURCE_DATE_EPOCH variable
+regctl image mod registry.example.org/repo:v1 --create v1-repro \
+  --time "set=${SOURCE_DATE_EPOCH}" --reproducible
+
 # set the entrypoint to be bash and unset the default command
 regctl image mod registry.example.org/repo:v1 --create v1-bash \
   --config-entrypoint '["bash"]' --config-cmd ""
 
+# squash the top 3 layers into a single layer
+regctl image mod registry.example.org/repo:v1 --create v1-squash \
+  --layer-squash 3
+
+# run as a non-root user from the /app directory
+regctl image mod registry.example.org/repo:v1 --create v1-user \
+  --config-user "1000:1000" --config-workdir "/app"
+
 # delete an environment variable from only the linux/arm64 image
 regctl image mod registry.example.org/repo:v1 --create v1-env \
   --env "[linux/arm64]LD_PRELOAD="
@@ -300,34 +473,131 @@ regctl image mod registry.example.org/regctl:v0.5.1-alpine \
 		Short:   "show the current rate limit",
 		Long: `Shows the rate limit using an http head request against the image manifest.
 If Set is false, the Remain value was not provided.
-The other values may be 0 if not provided by the registry.`,
+The other values may be 0 if not provided by the registry.
+Window and Reset are in seconds.
+With --min-remain, the command fails when fewer pulls remain, which may be used
+to delay a CI job until the limit resets.`,
 		Example: `
 # return the current rate limit for pulling the alpine image
 regctl image ratelimit alpine
 
 # return the number of pulls remaining
-regctl image ratelimit alpine --format '{{.Remain}}'`,
+regctl image ratelimit alpine --format '{{.Remain}}'
+
+# output the rate limit as json
+regctl image ratelimit alpine --format '{{jsonPretty .}}'
+
+# fail when fewer than 10 pulls remain
+regctl image ratelimit alpine --min-remain 10`,
 		Args:              cobra.ExactArgs(1),
 		ValidArgsFunction: rootOpts.completeArgTag,
 		RunE:              imageOpts.runImageRateLimit,
 	}
+	var imageSignCmd = &cobra.Command{
+		Use:   "sign <image_ref>",
+		Short: "sign an image with a cosign compatible signature",
+		Long: `Sign an image with a private key, creating a signature that can be verified
+with "regctl image verify" or "cosign verify --key".
+The signature is pushed to the "sha256-<digest>.sig" tag used by cosign,
+adding to any existing signatures, or as a referrer with --referrers.
+The key may be a PEM encoded ECDSA, RSA, or Ed25519 private key, or a key
+created by "cosign generate-key-pair". The password of an encrypted cosign key
+is read from stdin with --key-pass-stdin or from the COSIGN_PASSWORD variable.`,
+		Example: `
+# create a key pair
+openssl ecparam -name prime256v1 -genkey | openssl pkcs8 -topk8 -nocrypt -out signing.key
+openssl ec -in signing.key -pubout -out signing.pub
+
+# sign an image
+regctl image sign --key signing.key registry.example.org/repo:v1
+
+# sign an image with an encrypted cosign key
+echo "${password}" | regctl image sign --key cosign.key --key-pass-stdin \
+  registry.example.org/repo:v1
+
+# sign an image with an annotation, pushing the signature as a referrer
+regctl image sign --key signing.key --annotation env=prod --referrers \
+  registry.example.org/repo:v1`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: rootOpts.completeArgTag,
+		RunE:              imageOpts.runImageSign,
+	}
+	var imageVerifyCmd = &cobra.Command{
+		Use:   "verify <image_ref>",
+		Short: "verify the signatures or policy of an image",
+		Long: `Verify the signatures of an image with a public key.
+Signatures are found in the "sha256-<digest>.sig" tag used by cosign and in the
+referrers of the image. The command fails when no signature is valid for the
+image digest. Signatures created by "cosign sign --key" are supported.
+
+With --policy, the image is checked against a yaml policy file, and the command
+fails when any check is violated. The policy may contain:
+
+  requireDigest: true                # the reference must include a digest
+  allowedRegistries:                 # glob patterns of the registry or registry/repository
+  - registry.example.org
+  - "*.example.com"
+  maxAge: 90d                        # maximum time since the image was created
+  signatures:                        # each key must have a valid signature
+  - key: signing.pub
+  attestations:                      # each predicate type must be attached
+  - predicateType: slsaprovenance1
+    key: signing.pub                 # optional key that signed the attestation
+
+Key files are relative to the policy file.`,
+		Example: `
+# verify an image
+regctl image verify --key signing.pub registry.example.org/repo:v1
+
+# gate a deployment on a policy
+regctl image verify --policy policy.yaml registry.example.org/repo@sha256:...
+
+# verify an image signed by cosign
+regctl image verify --key cosign.pub registry.example.org/repo:v1
+
+# output the annotations from each signature
+regctl image verify --key signing.pub registry.example.org/repo:v1 \
+  --format '{{range .Signatures}}{{json .Payload.Optional}}{{end}}'`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: rootOpts.completeArgTag,
+		RunE:              imageOpts.runImageVerify,
+	}
 
 	imageOpts.modOpts = []mod.Opts{}
 
+	imageAttestCmd.Flags().StringVar(&imageOpts.format, "format", "", "Format output with go template syntax")
+	_ = imageAttestCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+	imageAttestCmd.Flags().StringVar(&imageOpts.signKey, "key", "", "File containing the PEM encoded private key to sign the attestation")
+	imageAttestCmd.Flags().BoolVar(&imageOpts.signPassStdin, "key-pass-stdin", false, "Read the password for an encrypted --key from stdin (env "+CosignPassEnv+")")
+	imageAttestCmd.Flags().StringVarP(&imageOpts.platform, "platform", "p", "", "Specify platform to attest (e.g. linux/amd64 or local)")
+	_ = imageAttestCmd.RegisterFlagCompletionFunc("platform", completeArgPlatform)
+	imageAttestCmd.Flags().StringVar(&imageOpts.attestPredicate, "predicate", "", "File containing the json predicate, use \"-\" for stdin")
+	imageAttestCmd.Flags().StringVar(&imageOpts.attestType, "type", "", "Predicate type URI or short name")
+	_ = imageAttestCmd.RegisterFlagCompletionFunc("type", completeArgPredicateType)
+
 	imageCheckBaseCmd.Flags().StringVar(&imageOpts.checkBaseRef, "base", "", "Base image reference (including tag)")
 	imageCheckBaseCmd.Flags().StringVar(&imageOpts.checkBaseDigest, "digest", "", "Base image digest (checks if digest matches base)")
 	imageCheckBaseCmd.Flags().BoolVar(&imageOpts.checkSkipConfig, "no-config", false, "Skip check of config history")
 	imageCheckBaseCmd.Flags().StringVarP(&imageOpts.platform, "platform", "p", "", "Specify platform (e.g. linux/amd64 or local)")
 
+	imageCopyCmd.Flags().IntVar(&imageOpts.blobConcurrent, "blob-concurrency", 0, "Limit the number of blobs copied in parallel, 0 for no limit")
+	imageCopyCmd.Flags().BoolVar(&imageOpts.externalFlatten, "external-flatten", false, "Convert external layers to regular layers, changing the digest of the image")
 	imageCopyCmd.Flags().BoolVar(&imageOpts.fastCheck, "fast", false, "Fast check, skip referrers and digest tag checks when image exists, overrides force-recursive")
 	imageCopyCmd.Flags().BoolVar(&imageOpts.forceRecursive, "force-recursive", false, "Force recursive copy of image, repairs missing nested blobs and manifests")
+	imageCopyCmd.Flags().StringVar(&imageOpts.copyFile, "file", "", "File with a source and target image ref on each line to copy, use \"-\" for stdin")
 	imageCopyCmd.Flags().StringVar(&imageOpts.format, "format", "", "Format output with go template syntax")
 	imageCopyCmd.Flags().BoolVar(&imageOpts.includeExternal, "include-external", false, "Include external layers")
-	imageCopyCmd.Flags().StringVarP(&imageOpts.platform, "platform", "p", "", "Specify platform (e.g. linux/amd64 or local)")
+	imageCopyCmd.Flags().IntVar(&imageOpts.copyParallel, "parallel", 1, "Number of images to copy in parallel with --file")
+	_ = imageCopyCmd.RegisterFlagCompletionFunc("parallel", completeArgNone)
+	imageCopyCmd.Flags().StringArrayVarP(&imageOpts.copyPlatforms, "platform", "p", []string{}, "Specify platform (e.g. linux/amd64 or local), repeat to copy a reduced index")
 	_ = imageCopyCmd.RegisterFlagCompletionFunc("platform", completeArgPlatform)
 	imageCopyCmd.Flags().StringArrayVar(&imageOpts.platforms, "platforms", []string{}, "Copy only specific platforms, registry validation must be disabled")
 	// platforms should be treated as experimental since it will break many registries
 	_ = imageCopyCmd.Flags().MarkHidden("platforms")
+	imageCopyCmd.Flags().StringVar(&imageOpts.progress, "progress", "auto", "Progress output (auto, tty, json, none), auto shows progress bars when stderr is a terminal")
+	_ = imageCopyCmd.RegisterFlagCompletionFunc("progress", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"auto", "tty", "json", "none"}, cobra.ShellCompDirectiveNoFileComp
+	})
 	imageCopyCmd.Flags().BoolVar(&imageOpts.digestTags, "digest-tags", false, "Include digest tags (\"sha256-<digest>.*\") when copying manifests")
 	imageCopyCmd.Flags().BoolVar(&imageOpts.referrers, "referrers", false, "Include referrers")
 	imageCopyCmd.Flags().StringVar(&imageOpts.referrerSrc, "referrers-src", "", "External source for referrers")
@@ -347,22 +617,40 @@ regctl image ratelimit alpine --format '{{.Remain}}'`,
 
 	imageDeleteCmd.Flags().BoolVar(&manifestOpts.forceTagDeref, "force-tag-dereference", false, "Dereference the a tag to a digest, this is unsafe")
 
+	imageDigestCmd.Flags().StringVar(&manifestOpts.formatHead, "format", "", "Format output with go template syntax (use \"raw-body\" for the original manifest)")
+	_ = imageDigestCmd.RegisterFlagCompletionFunc("format", completeArgNone)
 	imageDigestCmd.Flags().BoolVar(&manifestOpts.list, "list", true, "Do not resolve platform from manifest list (enabled by default)")
 	_ = imageDigestCmd.Flags().MarkHidden("list")
 	imageDigestCmd.Flags().StringVarP(&manifestOpts.platform, "platform", "p", "", "Specify platform (e.g. linux/amd64 or local, requires a get request)")
 	_ = imageDigestCmd.RegisterFlagCompletionFunc("platform", completeArgPlatform)
 	imageDigestCmd.Flags().BoolVar(&manifestOpts.requireList, "require-list", false, "Fail if manifest list is not received")
 
+	imageGetAttestationCmd.Flags().StringVar(&imageOpts.format, "format", "{{printPretty .}}", "Format output with go template syntax")
+	_ = imageGetAttestationCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+	imageGetAttestationCmd.Flags().StringVar(&imageOpts.signKey, "key", "", "File containing the PEM encoded public key to verify attestations")
+	imageGetAttestationCmd.Flags().StringVarP(&imageOpts.platform, "platform", "p", "", "Specify platform (e.g. linux/amd64 or local)")
+	_ = imageGetAttestationCmd.RegisterFlagCompletionFunc("platform", completeArgPlatform)
+	imageGetAttestationCmd.Flags().StringVar(&imageOpts.attestType, "type", "", "Only return attestations with this predicate type URI or short name")
+	_ = imageGetAttestationCmd.RegisterFlagCompletionFunc("type", completeArgPredicateType)
+
 	imageGetFileCmd.Flags().StringVar(&imageOpts.formatFile, "format", "", "Format output with go template syntax")
 	imageGetFileCmd.Flags().StringVarP(&imageOpts.platform, "platform", "p", "", "Specify platform (e.g. linux/amd64 or local)")
 	_ = imageGetFileCmd.RegisterFlagCompletionFunc("platform", completeArgPlatform)
 
+	imageDiffCmd.Flags().StringVar(&imageOpts.format, "format", "{{printPretty .}}", "Format output with go template syntax")
+	_ = imageDiffCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+	imageDiffCmd.Flags().StringVarP(&imageOpts.platform, "platform", "p", "", "Specify platform (e.g. linux/amd64 or local)")
+	_ = imageDiffCmd.RegisterFlagCompletionFunc("platform", completeArgPlatform)
+
 	imageExportCmd.Flags().BoolVar(&imageOpts.exportCompress, "compress", false, "Compress output with gzip")
 	imageExportCmd.Flags().StringVar(&imageOpts.exportRef, "name", "", "Name of image to embed for docker load")
 	imageExportCmd.Flags().StringVarP(&imageOpts.platform, "platform", "p", "", "Specify platform (e.g. linux/amd64 or local)")
 	_ = imageExportCmd.RegisterFlagCompletionFunc("platform", completeArgPlatform)
+	imageExportCmd.Flags().BoolVar(&imageOpts.referrers, "referrers", false, "Include referrers")
+	imageExportCmd.Flags().StringVar(&imageOpts.referrerSrc, "referrers-src", "", "External source for referrers")
 
 	imageImportCmd.Flags().StringVar(&imageOpts.importName, "name", "", "Name of image or tag to import when multiple images are packaged in the tar")
+	imageImportCmd.Flags().BoolVar(&imageOpts.referrers, "referrers", false, "Include referrers")
 
 	imageInspectCmd.Flags().StringVarP(&imageOpts.platform, "platform", "p", "", "Specify platform (e.g. linux/amd64 or local)")
 	_ = imageInspectCmd.RegisterFlagCompletionFunc("platform", completeArgPlatform)
@@ -378,6 +666,8 @@ regctl image ratelimit alpine --format '{{.Remain}}'`,
 	_ = imageManifestCmd.RegisterFlagCompletionFunc("format", completeArgNone)
 
 	imageModCmd.Flags().StringVar(&imageOpts.create, "create", "", "Create image or tag")
+	imageModCmd.Flags().StringVar(&imageOpts.format, "format", "", "Format output with go template syntax")
+	_ = imageModCmd.RegisterFlagCompletionFunc("format", completeArgNone)
 	imageModCmd.Flags().BoolVar(&imageOpts.replace, "replace", false, "Replace tag (ignored when \"create\" is used)")
 	// most image mod flags are order dependent, so they are added using VarP/VarPF to append to modOpts
 	imageModCmd.Flags().Var(&modFlagFunc{
@@ -441,6 +731,13 @@ regctl image ratelimit alpine --format '{{.Remain}}'`,
 		},
 	}, "annotation-promote", "", `promote common annotations from child images to index`)
 	flagAnnotationPromote.NoOptDefVal = "true"
+	imageModCmd.Flags().Var(&modFlagFunc{
+		t: "string",
+		f: func(val string) error {
+			imageOpts.modOpts = append(imageOpts.modOpts, mod.WithArtifactType(val))
+			return nil
+		},
+	}, "artifact-type", `set the artifactType of an OCI manifest or index (empty string to delete)`)
 	imageModCmd.Flags().Var(&modFlagFunc{
 		t: "string",
 		f: func(val string) error {
@@ -469,6 +766,20 @@ regctl image ratelimit alpine --format '{{.Remain}}'`,
 			return nil
 		},
 	}, "buildarg-rm-regex", `delete a build arg with a regex value`)
+	flagBuildArgRmAll := imageModCmd.Flags().VarPF(&modFlagFunc{
+		t: "bool",
+		f: func(val string) error {
+			b, err := strconv.ParseBool(val)
+			if err != nil {
+				return fmt.Errorf("unable to parse value %s: %w", val, err)
+			}
+			if b {
+				imageOpts.modOpts = append(imageOpts.modOpts, mod.WithBuildArgRmAll())
+			}
+			return nil
+		},
+	}, "buildarg-rm-all", "", `delete all build args from the history`)
+	flagBuildArgRmAll.NoOptDefVal = "true"
 	imageModCmd.Flags().Var(&modFlagFunc{
 		t: "string",
 		f: func(val string) error {
@@ -497,6 +808,13 @@ regctl image ratelimit alpine --format '{{.Remain}}'`,
 			return nil
 		},
 	}, "config-entrypoint", `set entrypoint in the config (json array or string, empty string to delete)`)
+	imageModCmd.Flags().Var(&modFlagFunc{
+		t: "string",
+		f: func(val string) error {
+			imageOpts.modOpts = append(imageOpts.modOpts, mod.WithConfigMediaType(val))
+			return nil
+		},
+	}, "config-media-type", `set the media type of the config descriptor in an OCI manifest`)
 	imageModCmd.Flags().Var(&modFlagFunc{
 		t: "string",
 		f: func(val string) error {
@@ -546,6 +864,24 @@ regctl image ratelimit alpine --format '{{.Remain}}'`,
 		},
 	}, "config-time-max", `max timestamp for a config`)
 	_ = imageModCmd.Flags().MarkHidden("config-time-max") // TODO: deprecate config-time-max in favor of config-time
+	imageModCmd.Flags().Var(&modFlagFunc{
+		t: "string",
+		f: func(val string) error {
+			imageOpts.modOpts = append(imageOpts.modOpts,
+				mod.WithConfigUser(val),
+			)
+			return nil
+		},
+	}, "config-user", `set user in the config (empty string to delete)`)
+	imageModCmd.Flags().Var(&modFlagFunc{
+		t: "string",
+		f: func(val string) error {
+			imageOpts.modOpts = append(imageOpts.modOpts,
+				mod.WithConfigWorkingDir(val),
+			)
+			return nil
+		},
+	}, "config-workdir", `set working directory in the config (empty string to delete)`)
 	imageModCmd.Flags().Var(&modFlagFunc{
 		t: "stringArray",
 		f: func(val string) error {
@@ -592,6 +928,32 @@ regctl image ratelimit alpine --format '{{.Remain}}'`,
 			return nil
 		},
 	}, "expose-rm", `delete an exposed port`)
+	imageModCmd.Flags().Var(&modFlagFunc{
+		t: "stringArray",
+		f: func(val string) error {
+			re, err := regexp.Compile(val)
+			if err != nil {
+				return fmt.Errorf("regexp value is invalid: %w", err)
+			}
+			imageOpts.modOpts = append(imageOpts.modOpts, mod.WithHistoryCreatedBy(re, ""))
+			return nil
+		},
+	}, "history-created-by-rm", `delete text matching a regex from the created by field of the history`)
+	imageModCmd.Flags().Var(&modFlagFunc{
+		t: "stringArray",
+		f: func(val string) error {
+			var re *regexp.Regexp
+			if val != "" {
+				var err error
+				re, err = regexp.Compile(val)
+				if err != nil {
+					return fmt.Errorf("regexp value is invalid: %w", err)
+				}
+			}
+			imageOpts.modOpts = append(imageOpts.modOpts, mod.WithHistoryRm(re))
+			return nil
+		},
+	}, "history-rm", `delete history entries with a created by field matching a regex (empty matches all), entries for layers are cleared`)
 	flagExtURLsRm := imageModCmd.Flags().VarPF(&modFlagFunc{
 		t: "bool",
 		f: func(val string) error {
@@ -604,7 +966,7 @@ regctl image ratelimit alpine --format '{{.Remain}}'`,
 			}
 			return nil
 		},
-	}, "external-urls-rm", "", `remove external url references from layers (first copy image with "--include-external")`)
+	}, "external-urls-rm", "", `remove external url references from layers, pulling the external content when needed`)
 	flagExtURLsRm.NoOptDefVal = "true"
 	imageModCmd.Flags().Var(&modFlagFunc{
 		t: "stringArray",
@@ -727,24 +1089,111 @@ regctl image ratelimit alpine --format '{{.Remain}}'`,
 				}
 				platforms = append(platforms, p)
 			}
+			history := v1.History{
+				Author:    kvSplit["author"],
+				Comment:   kvSplit["comment"],
+				CreatedBy: kvSplit["createdBy"],
+			}
+			if history.Author != "" || history.Comment != "" || history.CreatedBy != "" {
+				imageOpts.modOpts = append(imageOpts.modOpts,
+					mod.WithLayerAddTarHistory(rdr, mt, platforms, history))
+				return nil
+			}
 			imageOpts.modOpts = append(imageOpts.modOpts,
 				mod.WithLayerAddTar(rdr, mt, platforms))
 			return nil
 		},
-	}, "layer-add", `add a new layer (tar=file,dir=directory,platform=val)`)
+	}, "layer-add", `add a new layer (tar=file,dir=directory,platform=val,createdBy=val,comment=val,author=val)`)
 	imageModCmd.Flags().Var(&modFlagFunc{
 		t: "string",
 		f: func(val string) error {
+			// the value is either the algorithm or a list of options
+			kvSplit := map[string]string{"algo": val}
+			if strings.Contains(val, "=") {
+				var err error
+				kvSplit, err = strparse.SplitCSKV(val)
+				if err != nil {
+					return fmt.Errorf("failed to parse layer-compress options %s", val)
+				}
+			}
 			var algo archive.CompressType
-			err := algo.UnmarshalText([]byte(val))
+			err := algo.UnmarshalText([]byte(kvSplit["algo"]))
 			if err != nil {
-				return fmt.Errorf("unknown layer compression %s", val)
+				return fmt.Errorf("unknown layer compression %s", kvSplit["algo"])
+			}
+			cOpts := []archive.CompressOpts{}
+			if levelStr, ok := kvSplit["level"]; ok {
+				level, err := strconv.Atoi(levelStr)
+				if err != nil {
+					return fmt.Errorf("invalid compression level %s: %w", levelStr, err)
+				}
+				cOpts = append(cOpts, archive.CompressWithLevel(level))
+			}
+			if digStr, ok := kvSplit["digest"]; ok {
+				d, err := digest.Parse(digStr)
+				if err != nil {
+					return fmt.Errorf("digest invalid: %w", err)
+				}
+				imageOpts.modOpts = append(imageOpts.modOpts,
+					mod.WithLayerCompressionDigest(d, algo, cOpts...))
+				return nil
 			}
 			imageOpts.modOpts = append(imageOpts.modOpts,
-				mod.WithLayerCompression(algo))
+				mod.WithLayerCompression(algo, cOpts...))
+			return nil
+		},
+	}, "layer-compress", `change layer compression (gzip, none, zstd), or set options (algo=zstd,level=19,digest=sha256:...)`)
+	flagLayerNormalize := imageModCmd.Flags().VarPF(&modFlagFunc{
+		t: "string",
+		f: func(val string) error {
+			on := mod.OptNormalize{}
+			if b, err := strconv.ParseBool(val); err == nil {
+				if b {
+					imageOpts.modOpts = append(imageOpts.modOpts, mod.WithLayerNormalize(on))
+				}
+				return nil
+			}
+			kvSplit, err := strparse.SplitCSKV(val)
+			if err != nil {
+				return fmt.Errorf("failed to parse layer-normalize options %s", val)
+			}
+			for k, v := range kvSplit {
+				switch k {
+				case "dirMode", "execMode", "fileMode":
+					mode, err := strconv.ParseInt(v, 8, 64)
+					if err != nil {
+						return fmt.Errorf("invalid mode %s for %s: %w", v, k, err)
+					}
+					switch k {
+					case "dirMode":
+						on.DirMode = mode
+					case "execMode":
+						on.ExecMode = mode
+					case "fileMode":
+						on.FileMode = mode
+					}
+				case "keepOrder", "keepOwner":
+					b := true
+					if v != "" {
+						b, err = strconv.ParseBool(v)
+						if err != nil {
+							return fmt.Errorf("invalid value %s for %s: %w", v, k, err)
+						}
+					}
+					if k == "keepOrder" {
+						on.KeepOrder = b
+					} else {
+						on.KeepOwner = b
+					}
+				default:
+					return fmt.Errorf("unknown layer-normalize option %s", k)
+				}
+			}
+			imageOpts.modOpts = append(imageOpts.modOpts, mod.WithLayerNormalize(on))
 			return nil
 		},
-	}, "layer-compress", `change layer compression (gzip, none, zstd)`)
+	}, "layer-normalize", "", `sort tar entries and reset owners, optionally set modes (fileMode=644,execMode=755,dirMode=755,keepOrder,keepOwner)`)
+	flagLayerNormalize.NoOptDefVal = "true"
 	imageModCmd.Flags().Var(&modFlagFunc{
 		t: "string",
 		f: func(val string) error {
@@ -768,6 +1217,28 @@ regctl image ratelimit alpine --format '{{.Remain}}'`,
 			return nil
 		},
 	}, "layer-rm-index", `delete a layer from an image (index begins at 0)`)
+	imageModCmd.Flags().Var(&modFlagFunc{
+		t: "string",
+		f: func(val string) error {
+			d, err := digest.Parse(val)
+			if err != nil {
+				return fmt.Errorf("digest invalid: %w", err)
+			}
+			imageOpts.modOpts = append(imageOpts.modOpts, mod.WithLayerRmDigest(d))
+			return nil
+		},
+	}, "layer-rm-digest", `delete a layer from all images by the layer digest or diff id`)
+	imageModCmd.Flags().Var(&modFlagFunc{
+		t: "uint",
+		f: func(val string) error {
+			i, err := strconv.Atoi(val)
+			if err != nil {
+				return fmt.Errorf("count invalid: %w", err)
+			}
+			imageOpts.modOpts = append(imageOpts.modOpts, mod.WithLayerSquash(i))
+			return nil
+		},
+	}, "layer-squash", `squash the top layers of an image into a single layer (0 for all layers)`)
 	imageModCmd.Flags().Var(&modFlagFunc{
 		t: "string",
 		f: func(val string) error {
@@ -775,6 +1246,13 @@ regctl image ratelimit alpine --format '{{.Remain}}'`,
 			return nil
 		},
 	}, "layer-strip-file", `delete a file or directory from all layers`)
+	imageModCmd.Flags().Var(&modFlagFunc{
+		t: "string",
+		f: func(val string) error {
+			imageOpts.modOpts = append(imageOpts.modOpts, mod.WithLayerStripGlob(val))
+			return nil
+		},
+	}, "layer-strip-glob", `delete files matching a glob pattern from all layers (e.g. "*.pem" or "/root/.npmrc")`)
 	imageModCmd.Flags().Var(&modFlagFunc{
 		t: "string",
 		f: func(val string) error {
@@ -811,6 +1289,26 @@ regctl image ratelimit alpine --format '{{.Remain}}'`,
 		},
 	}, "layer-time-max", `max timestamp for a layer`)
 	_ = imageModCmd.Flags().MarkHidden("layer-time-max") // TODO: deprecate in favor of layer-time
+	platformKeepSet := false
+	imageModCmd.Flags().Var(&modFlagFunc{
+		t: "string",
+		f: func(val string) error {
+			if platformKeepSet {
+				return fmt.Errorf("platform-keep may only be specified once, use a comma separated list")
+			}
+			platformKeepSet = true
+			platforms := []platform.Platform{}
+			for _, pStr := range strings.Split(val, ",") {
+				p, err := platform.Parse(strings.TrimSpace(pStr))
+				if err != nil {
+					return fmt.Errorf("failed to parse platform %s: %w", pStr, err)
+				}
+				platforms = append(platforms, p)
+			}
+			imageOpts.modOpts = append(imageOpts.modOpts, mod.WithPlatformsKeep(platforms))
+			return nil
+		},
+	}, "platform-keep", `remove other platforms from an index (comma separated, e.g. linux/amd64,linux/arm64)`)
 	flagRebase := imageModCmd.Flags().VarPF(&modFlagFunc{
 		t: "bool",
 		f: func(val string) error {
@@ -847,6 +1345,27 @@ regctl image ratelimit alpine --format '{{.Remain}}'`,
 			return nil
 		},
 	}, "rebase-ref", `rebase an image with base references (base:old,base:new)`)
+	flagReferrers := imageModCmd.Flags().VarPF(&modFlagFunc{
+		t: "bool",
+		f: func(val string) error {
+			b, err := strconv.ParseBool(val)
+			if err != nil {
+				return fmt.Errorf("unable to parse value %s: %w", val, err)
+			}
+			if b {
+				imageOpts.modOpts = append(imageOpts.modOpts, mod.WithReferrersCopy())
+			}
+			return nil
+		},
+	}, "referrers", "", `copy referrers to the target repository and attach them to the modified image`)
+	flagReferrers.NoOptDefVal = "true"
+	imageModCmd.Flags().Var(&modFlagFunc{
+		t: "string",
+		f: func(val string) error {
+			imageOpts.modOpts = append(imageOpts.modOpts, mod.WithReferrersSkip(val))
+			return nil
+		},
+	}, "referrers-skip", `artifact type of referrers to leave on the original image (e.g. signatures)`)
 	flagReproducible := imageModCmd.Flags().VarPF(&modFlagFunc{
 		t: "bool",
 		f: func(val string) error {
@@ -961,19 +1480,43 @@ regctl image ratelimit alpine --format '{{.Remain}}'`,
 
 	imageRateLimitCmd.Flags().StringVar(&imageOpts.format, "format", "{{printPretty .}}", "Format output with go template syntax")
 	_ = imageRateLimitCmd.RegisterFlagCompletionFunc("format", completeArgNone)
-
+	imageRateLimitCmd.Flags().IntVar(&imageOpts.minRemain, "min-remain", 0, "Fail when the remaining pulls are below this value")
+	_ = imageRateLimitCmd.RegisterFlagCompletionFunc("min-remain", completeArgNone)
+
+	imageSignCmd.Flags().StringArrayVarP(&imageOpts.annotations, "annotation", "a", []string{}, "Annotation to include in the signed payload (key=value)")
+	_ = imageSignCmd.RegisterFlagCompletionFunc("annotation", completeArgNone)
+	imageSignCmd.Flags().StringVar(&imageOpts.format, "format", "", "Format output with go template syntax")
+	_ = imageSignCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+	imageSignCmd.Flags().StringVar(&imageOpts.signKey, "key", "", "File containing the PEM encoded private key")
+	imageSignCmd.Flags().BoolVar(&imageOpts.signPassStdin, "key-pass-stdin", false, "Read the password for an encrypted --key from stdin (env "+CosignPassEnv+")")
+	imageSignCmd.Flags().StringVarP(&imageOpts.platform, "platform", "p", "", "Specify platform to sign (e.g. linux/amd64 or local)")
+	_ = imageSignCmd.RegisterFlagCompletionFunc("platform", completeArgPlatform)
+	imageSignCmd.Flags().BoolVar(&imageOpts.signReferrers, "referrers", false, "Push the signature as a referrer instead of the signature tag")
+
+	imageVerifyCmd.Flags().StringVar(&imageOpts.format, "format", "{{printPretty .}}", "Format output with go template syntax")
+	_ = imageVerifyCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+	imageVerifyCmd.Flags().StringVar(&imageOpts.signKey, "key", "", "File containing the PEM encoded public key")
+	imageVerifyCmd.Flags().StringVarP(&imageOpts.platform, "platform", "p", "", "Specify platform to verify (e.g. linux/amd64 or local)")
+	_ = imageVerifyCmd.RegisterFlagCompletionFunc("platform", completeArgPlatform)
+	imageVerifyCmd.Flags().StringVar(&imageOpts.policy, "policy", "", "Policy file with the checks to run on the image")
+
+	imageTopCmd.AddCommand(imageAttestCmd)
 	imageTopCmd.AddCommand(imageCheckBaseCmd)
 	imageTopCmd.AddCommand(imageCopyCmd)
 	imageTopCmd.AddCommand(imageCreateCmd)
 	imageTopCmd.AddCommand(imageDeleteCmd)
 	imageTopCmd.AddCommand(imageDigestCmd)
+	imageTopCmd.AddCommand(imageDiffCmd)
 	imageTopCmd.AddCommand(imageExportCmd)
+	imageTopCmd.AddCommand(imageGetAttestationCmd)
 	imageTopCmd.AddCommand(imageGetFileCmd)
 	imageTopCmd.AddCommand(imageImportCmd)
 	imageTopCmd.AddCommand(imageInspectCmd)
 	imageTopCmd.AddCommand(imageManifestCmd)
 	imageTopCmd.AddCommand(imageModCmd)
 	imageTopCmd.AddCommand(imageRateLimitCmd)
+	imageTopCmd.AddCommand(imageSignCmd)
+	imageTopCmd.AddCommand(imageVerifyCmd)
 	return imageTopCmd
 }
 
@@ -987,15 +1530,15 @@ func imageParseOptTime(s string) (mod.OptTime, map[string]string, error) {
 		}
 		switch kv[0] {
 		case "set":
-			t, err := time.Parse(time.RFC3339, kv[1])
+			t, err := imageParseTime(kv[1])
 			if err != nil {
-				return ot, otherFields, fmt.Errorf("set time must be formatted %s: %w", time.RFC3339, err)
+				return ot, otherFields, fmt.Errorf("set time must be formatted %s or seconds since the epoch: %w", time.RFC3339, err)
 			}
 			ot.Set = t
 		case "after":
-			t, err := time.Parse(time.RFC3339, kv[1])
+			t, err := imageParseTime(kv[1])
 			if err != nil {
-				return ot, otherFields, fmt.Errorf("after time must be formatted %s: %w", time.RFC3339, err)
+				return ot, otherFields, fmt.Errorf("after time must be formatted %s or seconds since the epoch: %w", time.RFC3339, err)
 			}
 			ot.After = t
 		case "from-label":
@@ -1019,112 +1562,366 @@ func imageParseOptTime(s string) (mod.OptTime, map[string]string, error) {
 	return ot, otherFields, nil
 }
 
-func (imageOpts *imageCmd) runImageCheckBase(cmd *cobra.Command, args []string) error {
+// imageParseTime parses a time in RFC3339 or as seconds since the epoch, e.g. from SOURCE_DATE_EPOCH.
+func imageParseTime(s string) (time.Time, error) {
+	if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(sec, 0).UTC(), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func (imageOpts *imageCmd) runImageAttest(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
 	r, err := ref.New(args[0])
 	if err != nil {
 		return err
 	}
+	if imageOpts.attestPredicate == "" || imageOpts.attestType == "" {
+		return fmt.Errorf("a predicate file and type are required with --predicate and --type%.0w", ErrMissingInput)
+	}
+	if imageOpts.attestPredicate == "-" && imageOpts.signPassStdin {
+		return fmt.Errorf("stdin cannot be used for both --predicate and --key-pass-stdin%.0w", ErrInvalidInput)
+	}
+	predType := imageAttestType(imageOpts.attestType)
+	var predicate []byte
+	if imageOpts.attestPredicate == "-" {
+		predicate, err = io.ReadAll(cmd.InOrStdin())
+	} else {
+		//#nosec G304 command is run by a user accessing their own files
+		predicate, err = os.ReadFile(imageOpts.attestPredicate)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read predicate %s: %w", imageOpts.attestPredicate, err)
+	}
+	var signer crypto.Signer
+	if imageOpts.signKey != "" {
+		signer, err = imageOpts.loadSignKey(cmd)
+		if err != nil {
+			return err
+		}
+	}
 	rc := imageOpts.rootOpts.newRegClient()
 	defer rc.Close(ctx, r)
+	mDesc, err := resolveDesc(ctx, rc, r, imageOpts.platform)
+	if err != nil {
+		return err
+	}
+	rDig := r.SetDigest(mDesc.Digest.String())
 
-	opts := []regclient.ImageOpts{}
-	if imageOpts.checkBaseDigest != "" {
-		opts = append(opts, regclient.ImageWithCheckBaseDigest(imageOpts.checkBaseDigest))
+	// create the statement, signing it when a key is provided
+	content, err := intoto.NewStatement(cosign.DockerReference(r.Registry, r.Repository), mDesc.Digest, predType, predicate)
+	if err != nil {
+		return fmt.Errorf("failed to create statement from %s: %w", imageOpts.attestPredicate, err)
 	}
-	if imageOpts.checkBaseRef != "" {
-		opts = append(opts, regclient.ImageWithCheckBaseRef(imageOpts.checkBaseRef))
+	mt := intoto.MediaTypeStatement
+	if signer != nil {
+		content, err = intoto.Sign(signer, content)
+		if err != nil {
+			return fmt.Errorf("failed to sign attestation: %w", err)
+		}
+		mt = intoto.MediaTypeDSSE
 	}
-	if imageOpts.checkSkipConfig {
-		opts = append(opts, regclient.ImageWithCheckSkipConfig())
+	annotations := map[string]string{
+		intoto.AnnotationPredicateType: predType,
 	}
-	if imageOpts.platform != "" {
-		opts = append(opts, regclient.ImageWithPlatform(imageOpts.platform))
+	layer, err := rc.BlobPut(ctx, rDig, descriptor.Descriptor{}, bytes.NewReader(content))
+	if err != nil {
+		return fmt.Errorf("failed to push attestation: %w", err)
 	}
-
-	err = rc.ImageCheckBase(ctx, r, opts...)
-	if err == nil {
-		imageOpts.rootOpts.log.Info("base image matches")
-		return nil
-	} else if errors.Is(err, errs.ErrMismatch) {
-		imageOpts.rootOpts.log.Info("base image mismatch",
-			slog.String("err", err.Error()))
-		// return empty error message
-		return fmt.Errorf("%.0w", err)
-	} else {
+	layer.MediaType = mt
+	layer.Annotations = annotations
+	conf, err := rc.BlobPut(ctx, rDig, descriptor.Descriptor{}, bytes.NewReader(descriptor.EmptyData))
+	if err != nil {
+		return fmt.Errorf("failed to push attestation config: %w", err)
+	}
+	conf.MediaType = mediatype.OCI1Empty
+	mm, err := manifest.New(manifest.WithOrig(v1.Manifest{
+		Versioned:    v1.ManifestSchemaVersion,
+		MediaType:    mediatype.OCI1Manifest,
+		ArtifactType: mt,
+		Config:       conf,
+		Layers:       []descriptor.Descriptor{layer},
+		Subject: &descriptor.Descriptor{
+			MediaType: mDesc.MediaType,
+			Digest:    mDesc.Digest,
+			Size:      mDesc.Size,
+		},
+		Annotations: annotations,
+	}))
+	if err != nil {
 		return err
 	}
+	rAtt := r.SetDigest(mm.GetDescriptor().Digest.String())
+	imageOpts.rootOpts.log.Debug("Attaching attestation",
+		slog.String("ref", rDig.CommonName()),
+		slog.String("attestation", rAtt.CommonName()),
+		slog.String("predicateType", predType),
+		slog.Bool("signed", signer != nil))
+	err = rc.ManifestPut(ctx, rAtt, mm)
+	if err != nil {
+		return fmt.Errorf("failed to push attestation %s: %w", rAtt.CommonName(), err)
+	}
+	if !flagChanged(cmd, "format") {
+		imageOpts.format = "{{ .CommonName }}\n"
+	}
+	return template.Writer(cmd.OutOrStdout(), imageOpts.format, rAtt)
 }
 
-func (imageOpts *imageCmd) runImageCopy(cmd *cobra.Command, args []string) error {
+func (imageOpts *imageCmd) runImageGetAttestation(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
-	rSrc, err := ref.New(args[0])
-	if err != nil {
-		return err
-	}
-	rTgt, err := ref.New(args[1])
+	r, err := ref.New(args[0])
 	if err != nil {
 		return err
 	}
-	if (imageOpts.referrerSrc != "" || imageOpts.referrerTgt != "") && !imageOpts.referrers {
-		return fmt.Errorf("referrers must be enabled to specify an external referrers source or target%.0w", errs.ErrUnsupported)
-	}
-	rc := imageOpts.rootOpts.newRegClient()
-	defer rc.Close(ctx, rSrc)
-	defer rc.Close(ctx, rTgt)
-	if imageOpts.platform != "" {
-		p, err := platform.Parse(imageOpts.platform)
+	predType := imageAttestType(imageOpts.attestType)
+	var pub crypto.PublicKey
+	if imageOpts.signKey != "" {
+		//#nosec G304 command is run by a user accessing their own files
+		keyBytes, err := os.ReadFile(imageOpts.signKey)
 		if err != nil {
-			return err
+			return fmt.Errorf("failed to read key %s: %w", imageOpts.signKey, err)
 		}
-		m, err := rc.ManifestGet(ctx, rSrc, regclient.WithManifestPlatform(p))
+		pub, err = cosign.LoadPublicKey(keyBytes)
 		if err != nil {
 			return err
 		}
-		rSrc = rSrc.SetDigest(m.GetDescriptor().Digest.String())
 	}
-	imageOpts.rootOpts.log.Debug("Image copy",
-		slog.String("source", rSrc.CommonName()),
-		slog.String("target", rTgt.CommonName()),
-		slog.Bool("recursive", imageOpts.forceRecursive),
-		slog.Bool("digest-tags", imageOpts.digestTags))
-	opts := []regclient.ImageOpts{}
-	if imageOpts.fastCheck {
-		opts = append(opts, regclient.ImageWithFastCheck())
+	rc := imageOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, r)
+	mDesc, err := resolveDesc(ctx, rc, r, imageOpts.platform)
+	if err != nil {
+		return err
 	}
-	if imageOpts.forceRecursive {
-		opts = append(opts, regclient.ImageWithForceRecursive())
+	rDig := r.SetDigest(mDesc.Digest.String())
+	rl, err := rc.ReferrerList(ctx, rDig)
+	if err != nil {
+		return fmt.Errorf("failed to list referrers of %s: %w", rDig.CommonName(), err)
 	}
-	if imageOpts.includeExternal {
-		opts = append(opts, regclient.ImageWithIncludeExternal())
+
+	result := imageAttestationResult{
+		Ref:          rDig,
+		Attestations: []imageAttestation{},
 	}
-	if imageOpts.digestTags {
-		opts = append(opts, regclient.ImageWithDigestTags())
+	for _, d := range rl.Descriptors {
+		if d.ArtifactType != intoto.MediaTypeStatement && d.ArtifactType != intoto.MediaTypeDSSE {
+			continue
+		}
+		if pt, ok := d.Annotations[intoto.AnnotationPredicateType]; ok && predType != "" && pt != predType {
+			continue
+		}
+		rAtt := r.SetDigest(d.Digest.String())
+		mAtt, err := rc.ManifestGet(ctx, rAtt)
+		if err != nil {
+			return fmt.Errorf("failed to get attestation %s: %w", rAtt.CommonName(), err)
+		}
+		mi, ok := mAtt.(manifest.Imager)
+		if !ok {
+			continue
+		}
+		layers, err := mi.GetLayers()
+		if err != nil {
+			return err
+		}
+		for _, l := range layers {
+			if l.MediaType != intoto.MediaTypeStatement && l.MediaType != intoto.MediaTypeDSSE {
+				continue
+			}
+			att, err := getAttestation(ctx, rc, rAtt, l, pub)
+			if err == nil && !att.Statement.HasSubject(mDesc.Digest) {
+				err = fmt.Errorf("statement subject does not include %s%.0w", mDesc.Digest.String(), errs.ErrDigestMismatch)
+			}
+			if err != nil {
+				imageOpts.rootOpts.log.Info("Skipping attestation",
+					slog.String("attestation", rAtt.CommonName()),
+					slog.String("layer", l.Digest.String()),
+					slog.String("err", err.Error()))
+				continue
+			}
+			if predType != "" && att.Statement.PredicateType != predType {
+				continue
+			}
+			result.Attestations = append(result.Attestations, att)
+		}
 	}
-	if imageOpts.referrers {
-		opts = append(opts, regclient.ImageWithReferrers())
+	if len(result.Attestations) == 0 {
+		return fmt.Errorf("no attestations found for %s%.0w", rDig.CommonName(), errs.ErrNotFound)
 	}
-	if imageOpts.referrerSrc != "" {
-		referrerSrc, err := ref.New(imageOpts.referrerSrc)
+	return template.Writer(cmd.OutOrStdout(), imageOpts.format, result)
+}
+
+// getAttestation parses a statement or DSSE envelope, verifying the signature when a public key is provided.
+func getAttestation(ctx context.Context, rc *regclient.RegClient, r ref.Ref, l descriptor.Descriptor, pub crypto.PublicKey) (imageAttestation, error) {
+	att := imageAttestation{
+		Source:    r,
+		MediaType: l.MediaType,
+	}
+	if l.Size > imageAttestLimit {
+		return att, fmt.Errorf("attestation size %d exceeds %d%.0w", l.Size, imageAttestLimit, errs.ErrSizeLimitExceeded)
+	}
+	rdr, err := rc.BlobGet(ctx, r, l)
+	if err != nil {
+		return att, err
+	}
+	defer rdr.Close()
+	content, err := io.ReadAll(rdr)
+	if err != nil {
+		return att, err
+	}
+	if l.MediaType == intoto.MediaTypeDSSE {
+		env, err := intoto.ParseEnvelope(content)
 		if err != nil {
-			return fmt.Errorf("failed parsing referrer external source: %w", err)
+			return att, err
 		}
-		opts = append(opts, regclient.ImageWithReferrerSrc(referrerSrc))
+		if pub != nil {
+			err = env.Verify(pub)
+			if err != nil {
+				return att, err
+			}
+			att.Verified = true
+		}
+		content = env.Payload
+	} else if pub != nil {
+		return att, fmt.Errorf("attestation is not signed%.0w", errs.ErrNotFound)
 	}
-	if imageOpts.referrerTgt != "" {
-		referrerTgt, err := ref.New(imageOpts.referrerTgt)
+	att.Statement, err = intoto.ParseStatement(content)
+	return att, err
+}
+
+// imageAttestType expands the short name of a predicate type.
+func imageAttestType(t string) string {
+	if long, ok := intoto.PredicateTypes[t]; ok {
+		return long
+	}
+	return t
+}
+
+// imageAttestLimit is the largest attestation that will be pulled.
+const imageAttestLimit = 64 * 1024 * 1024
+
+type imageAttestationResult struct {
+	Ref          ref.Ref
+	Attestations []imageAttestation
+}
+
+type imageAttestation struct {
+	Source    ref.Ref
+	MediaType string
+	Verified  bool
+	Statement intoto.Statement
+}
+
+func (result imageAttestationResult) MarshalPretty() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	tw := tabwriter.NewWriter(buf, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(tw, "Attestations for %s\n", result.Ref.CommonName())
+	fmt.Fprintf(tw, "\t\n")
+	fmt.Fprintf(tw, "Attestation\tPredicate Type\tVerified\n")
+	for _, a := range result.Attestations {
+		fmt.Fprintf(tw, "%s\t%s\t%t\n", a.Source.CommonName(), a.Statement.PredicateType, a.Verified)
+	}
+	err := tw.Flush()
+	return buf.Bytes(), err
+}
+
+func (imageOpts *imageCmd) runImageCheckBase(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	r, err := ref.New(args[0])
+	if err != nil {
+		return err
+	}
+	rc := imageOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, r)
+
+	opts := []regclient.ImageOpts{}
+	if imageOpts.checkBaseDigest != "" {
+		opts = append(opts, regclient.ImageWithCheckBaseDigest(imageOpts.checkBaseDigest))
+	}
+	if imageOpts.checkBaseRef != "" {
+		opts = append(opts, regclient.ImageWithCheckBaseRef(imageOpts.checkBaseRef))
+	}
+	if imageOpts.checkSkipConfig {
+		opts = append(opts, regclient.ImageWithCheckSkipConfig())
+	}
+	if imageOpts.platform != "" {
+		opts = append(opts, regclient.ImageWithPlatform(imageOpts.platform))
+	}
+
+	err = rc.ImageCheckBase(ctx, r, opts...)
+	if err == nil {
+		imageOpts.rootOpts.log.Info("base image matches")
+		return nil
+	} else if errors.Is(err, errs.ErrMismatch) {
+		imageOpts.rootOpts.log.Info("base image mismatch",
+			slog.String("err", err.Error()))
+		// return empty error message
+		return fmt.Errorf("%.0w", err)
+	} else {
+		return err
+	}
+}
+
+func (imageOpts *imageCmd) runImageCopy(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	if imageOpts.copyFile != "" {
+		if len(args) != 0 {
+			return fmt.Errorf("image refs cannot be combined with --file%.0w", ErrInvalidInput)
+		}
+		return imageOpts.runImageCopyBatch(cmd)
+	}
+	if len(args) != 2 {
+		return fmt.Errorf("accepts 2 arg(s), received %d", len(args))
+	}
+	rSrc, err := ref.New(args[0])
+	if err != nil {
+		return err
+	}
+	rTgt, err := ref.New(args[1])
+	if err != nil {
+		return err
+	}
+	err = imageOpts.imageCopyValidate()
+	if err != nil {
+		return err
+	}
+	rc := imageOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, rSrc)
+	defer rc.Close(ctx, rTgt)
+	rSrc, keepPlatforms, opts, err := imageOpts.imageCopyPlatform(ctx, rc, rSrc)
+	if err != nil {
+		return err
+	}
+	if imageOpts.externalFlatten || len(keepPlatforms) > 0 {
+		rOut, err := imageOpts.imageCopyMod(ctx, rc, rSrc, rTgt, keepPlatforms)
 		if err != nil {
-			return fmt.Errorf("failed parsing referrer external target: %w", err)
+			return err
 		}
-		opts = append(opts, regclient.ImageWithReferrerTgt(referrerTgt))
+		if !flagChanged(cmd, "format") {
+			imageOpts.format = "{{ .CommonName }}\n"
+		}
+		return template.Writer(cmd.OutOrStdout(), imageOpts.format, rOut)
 	}
-	if len(imageOpts.platforms) > 0 {
-		opts = append(opts, regclient.ImageWithPlatforms(imageOpts.platforms))
+	imageOpts.rootOpts.log.Debug("Image copy",
+		slog.String("source", rSrc.CommonName()),
+		slog.String("target", rTgt.CommonName()),
+		slog.Bool("recursive", imageOpts.forceRecursive),
+		slog.Bool("digest-tags", imageOpts.digestTags))
+	copyOpts, err := imageOpts.imageCopyOpts()
+	if err != nil {
+		return err
 	}
+	opts = append(opts, copyOpts...)
 	// check for a tty and attach progress reporter
 	done := make(chan bool)
 	var progress *imageProgress
-	if !flagChanged(cmd, "verbosity") && ascii.IsWriterTerminal(cmd.ErrOrStderr()) {
+	var progressJSON *imageProgressJSON
+	if imageOpts.progress == "json" {
+		progressJSON = &imageProgressJSON{
+			start: time.Now(),
+			enc:   json.NewEncoder(cmd.ErrOrStderr()),
+			last:  map[string]time.Time{},
+		}
+		opts = append(opts, regclient.ImageWithCallback(progressJSON.callback))
+	} else if imageOpts.progress == "tty" || (imageOpts.progress == "auto" && !flagChanged(cmd, "verbosity") && ascii.IsWriterTerminal(cmd.ErrOrStderr())) {
 		progress = &imageProgress{
 			start:    time.Now(),
 			entries:  map[string]*imageProgressEntry{},
@@ -1151,6 +1948,9 @@ func (imageOpts *imageCmd) runImageCopy(cmd *cobra.Command, args []string) error
 		close(done)
 		progress.display(true)
 	}
+	if progressJSON != nil {
+		progressJSON.summary(err)
+	}
 	if err != nil {
 		return err
 	}
@@ -1160,6 +1960,306 @@ func (imageOpts *imageCmd) runImageCopy(cmd *cobra.Command, args []string) error
 	return template.Writer(cmd.OutOrStdout(), imageOpts.format, rTgt)
 }
 
+// imageCopyValidate checks the copy flags that apply to every image.
+func (imageOpts *imageCmd) imageCopyValidate() error {
+	if (imageOpts.referrerSrc != "" || imageOpts.referrerTgt != "") && !imageOpts.referrers {
+		return fmt.Errorf("referrers must be enabled to specify an external referrers source or target%.0w", errs.ErrUnsupported)
+	}
+	switch imageOpts.progress {
+	case "auto", "tty", "json", "none":
+	default:
+		return fmt.Errorf("unsupported progress %s, expected auto, tty, json, or none%.0w", imageOpts.progress, ErrInvalidInput)
+	}
+	return nil
+}
+
+// imageCopyPlatform resolves the --platform flags for a source image.
+// A single platform returns the source ref with the digest of the platform image,
+// and multiple platforms are returned to copy a reduced index.
+func (imageOpts *imageCmd) imageCopyPlatform(ctx context.Context, rc *regclient.RegClient, rSrc ref.Ref) (ref.Ref, []platform.Platform, []regclient.ImageOpts, error) {
+	opts := []regclient.ImageOpts{}
+	keepPlatforms := []platform.Platform{}
+	if len(imageOpts.copyPlatforms) > 1 {
+		if rSrc.Scheme == "docker-daemon" {
+			return rSrc, nil, nil, fmt.Errorf("only a single platform may be copied from the docker engine%.0w", errs.ErrUnsupported)
+		}
+		for _, pStr := range imageOpts.copyPlatforms {
+			p, err := platform.Parse(pStr)
+			if err != nil {
+				return rSrc, nil, nil, fmt.Errorf("failed to parse platform %s: %w", pStr, err)
+			}
+			keepPlatforms = append(keepPlatforms, p)
+		}
+	} else if len(imageOpts.copyPlatforms) == 1 && rSrc.Scheme == "docker-daemon" {
+		// the engine only stores a single platform
+		opts = append(opts, regclient.ImageWithPlatform(imageOpts.copyPlatforms[0]))
+	} else if len(imageOpts.copyPlatforms) == 1 {
+		p, err := platform.Parse(imageOpts.copyPlatforms[0])
+		if err != nil {
+			return rSrc, nil, nil, err
+		}
+		m, err := rc.ManifestGet(ctx, rSrc, regclient.WithManifestPlatform(p))
+		if err != nil {
+			return rSrc, nil, nil, err
+		}
+		rSrc = rSrc.SetDigest(m.GetDescriptor().Digest.String())
+	}
+	return rSrc, keepPlatforms, opts, nil
+}
+
+// imageCopyOpts returns the options for ImageCopy from the flags.
+func (imageOpts *imageCmd) imageCopyOpts() ([]regclient.ImageOpts, error) {
+	opts := []regclient.ImageOpts{}
+	if imageOpts.blobConcurrent > 0 {
+		opts = append(opts, regclient.ImageWithBlobConcurrency(imageOpts.blobConcurrent))
+	}
+	if imageOpts.fastCheck {
+		opts = append(opts, regclient.ImageWithFastCheck())
+	}
+	if imageOpts.forceRecursive {
+		opts = append(opts, regclient.ImageWithForceRecursive())
+	}
+	if imageOpts.includeExternal {
+		opts = append(opts, regclient.ImageWithIncludeExternal())
+	}
+	if imageOpts.digestTags {
+		opts = append(opts, regclient.ImageWithDigestTags())
+	}
+	if imageOpts.referrers {
+		opts = append(opts, regclient.ImageWithReferrers())
+	}
+	if imageOpts.referrerSrc != "" {
+		referrerSrc, err := ref.New(imageOpts.referrerSrc)
+		if err != nil {
+			return nil, fmt.Errorf("failed parsing referrer external source: %w", err)
+		}
+		opts = append(opts, regclient.ImageWithReferrerSrc(referrerSrc))
+	}
+	if imageOpts.referrerTgt != "" {
+		referrerTgt, err := ref.New(imageOpts.referrerTgt)
+		if err != nil {
+			return nil, fmt.Errorf("failed parsing referrer external target: %w", err)
+		}
+		opts = append(opts, regclient.ImageWithReferrerTgt(referrerTgt))
+	}
+	if len(imageOpts.platforms) > 0 {
+		opts = append(opts, regclient.ImageWithPlatforms(imageOpts.platforms))
+	}
+	return opts, nil
+}
+
+func (imageOpts *imageCmd) imageCopyMod(ctx context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, keepPlatforms []platform.Platform) (ref.Ref, error) {
+	if imageOpts.digestTags || imageOpts.fastCheck || imageOpts.referrerSrc != "" || imageOpts.referrerTgt != "" || len(imageOpts.platforms) > 0 {
+		return rTgt, fmt.Errorf("external-flatten and multiple platforms cannot be combined with digest-tags, fast, platforms, or external referrers%.0w", errs.ErrUnsupported)
+	}
+	imageOpts.rootOpts.log.Debug("Image copy with mod",
+		slog.String("source", rSrc.CommonName()),
+		slog.String("target", rTgt.CommonName()),
+		slog.Bool("external-flatten", imageOpts.externalFlatten),
+		slog.Int("platforms", len(keepPlatforms)),
+		slog.Bool("referrers", imageOpts.referrers))
+	mOpts := []mod.Opts{
+		mod.WithRefTgt(rTgt),
+	}
+	if imageOpts.externalFlatten {
+		mOpts = append(mOpts, mod.WithExternalURLsRm())
+	}
+	if len(keepPlatforms) > 0 {
+		m, err := rc.ManifestHead(ctx, rSrc)
+		if err != nil {
+			return rTgt, err
+		}
+		if !m.IsList() {
+			return rTgt, fmt.Errorf("multiple platforms require a source index, %s is a %s%.0w", rSrc.CommonName(), m.GetDescriptor().MediaType, errs.ErrUnsupportedMediaType)
+		}
+		mOpts = append(mOpts, mod.WithPlatformsKeep(keepPlatforms))
+	}
+	if imageOpts.referrers {
+		mOpts = append(mOpts, mod.WithReferrersCopy())
+	}
+	return mod.Apply(ctx, rc, rSrc, mOpts...)
+}
+
+type imageCopyBatchResult struct {
+	Entries []imageCopyBatchEntry `json:"entries"`
+	Copied  int                   `json:"copied"`
+	Failed  int                   `json:"failed"`
+}
+
+type imageCopyBatchEntry struct {
+	Source  string  `json:"source"`
+	Target  string  `json:"target"`
+	Status  string  `json:"status"` // copied or failed
+	Error   string  `json:"error,omitempty"`
+	Elapsed float64 `json:"elapsed"` // seconds
+}
+
+// runImageCopyBatch copies each source and target pair from a file.
+func (imageOpts *imageCmd) runImageCopyBatch(cmd *cobra.Command) error {
+	ctx := cmd.Context()
+	if imageOpts.copyParallel < 1 {
+		return fmt.Errorf("parallel must be at least 1: %d%.0w", imageOpts.copyParallel, ErrInvalidInput)
+	}
+	err := imageOpts.imageCopyValidate()
+	if err != nil {
+		return err
+	}
+	pairs, err := imageCopyReadFile(cmd.InOrStdin(), imageOpts.copyFile)
+	if err != nil {
+		return err
+	}
+	if len(pairs) == 0 {
+		return fmt.Errorf("no images to copy in %s%.0w", imageOpts.copyFile, ErrMissingInput)
+	}
+	copyOpts, err := imageOpts.imageCopyOpts()
+	if err != nil {
+		return err
+	}
+	// the tty progress bar is not supported with parallel copies
+	var progressJSON *imageProgressJSON
+	if imageOpts.progress == "json" {
+		progressJSON = &imageProgressJSON{
+			start: time.Now(),
+			enc:   json.NewEncoder(cmd.ErrOrStderr()),
+			last:  map[string]time.Time{},
+		}
+		copyOpts = append(copyOpts, regclient.ImageWithCallback(progressJSON.callback))
+	}
+	rc := imageOpts.rootOpts.newRegClient()
+	result := imageCopyBatchResult{
+		Entries: make([]imageCopyBatchEntry, len(pairs)),
+	}
+	limit := make(chan struct{}, imageOpts.copyParallel)
+	var wg sync.WaitGroup
+	for i, pair := range pairs {
+		limit <- struct{}{}
+		wg.Add(1)
+		go func(i int, rSrc, rTgt ref.Ref) {
+			defer wg.Done()
+			defer func() { <-limit }()
+			start := time.Now()
+			entry := imageCopyBatchEntry{
+				Source: rSrc.CommonName(),
+				Target: rTgt.CommonName(),
+				Status: "copied",
+			}
+			imageOpts.rootOpts.log.Debug("Image copy",
+				slog.String("source", entry.Source),
+				slog.String("target", entry.Target))
+			err := imageOpts.imageCopyEntry(ctx, rc, rSrc, rTgt, copyOpts)
+			if err != nil {
+				imageOpts.rootOpts.log.Warn("Failed to copy image",
+					slog.String("source", entry.Source),
+					slog.String("target", entry.Target),
+					slog.String("err", err.Error()))
+				entry.Status = "failed"
+				entry.Error = err.Error()
+			}
+			entry.Elapsed = time.Since(start).Seconds()
+			result.Entries[i] = entry
+		}(i, pair[0], pair[1])
+	}
+	wg.Wait()
+	for _, pair := range pairs {
+		_ = rc.Close(ctx, pair[0])
+		_ = rc.Close(ctx, pair[1])
+	}
+	for _, entry := range result.Entries {
+		if entry.Status == "failed" {
+			result.Failed++
+		} else {
+			result.Copied++
+		}
+	}
+	if result.Failed > 0 {
+		err = fmt.Errorf("%d of %d images failed to copy", result.Failed, len(result.Entries))
+	}
+	if progressJSON != nil {
+		progressJSON.summary(err)
+	}
+	if !flagChanged(cmd, "format") {
+		imageOpts.format = "{{printPretty .}}"
+	}
+	tErr := template.Writer(cmd.OutOrStdout(), imageOpts.format, result)
+	if err != nil {
+		return err
+	}
+	return tErr
+}
+
+// imageCopyEntry copies a single image from a batch.
+func (imageOpts *imageCmd) imageCopyEntry(ctx context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, copyOpts []regclient.ImageOpts) error {
+	rSrc, keepPlatforms, opts, err := imageOpts.imageCopyPlatform(ctx, rc, rSrc)
+	if err != nil {
+		return err
+	}
+	if imageOpts.externalFlatten || len(keepPlatforms) > 0 {
+		_, err = imageOpts.imageCopyMod(ctx, rc, rSrc, rTgt, keepPlatforms)
+		return err
+	}
+	opts = append(opts, copyOpts...)
+	return rc.ImageCopy(ctx, rSrc, rTgt, opts...)
+}
+
+// imageCopyReadFile parses the source and target pairs from a file, or stdin when the filename is "-".
+func imageCopyReadFile(stdin io.Reader, filename string) ([][2]ref.Ref, error) {
+	rdr := stdin
+	if filename != "-" {
+		//#nosec G304 command is run by a user accessing their own files
+		fh, err := os.Open(filename)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", filename, err)
+		}
+		defer fh.Close()
+		rdr = fh
+	}
+	pairs := [][2]ref.Ref{}
+	scanner := bufio.NewScanner(rdr)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d of %s must contain a source and target image: %s%.0w", line, filename, text, ErrInvalidInput)
+		}
+		rSrc, err := ref.New(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d of %s: %w", line, filename, err)
+		}
+		rTgt, err := ref.New(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d of %s: %w", line, filename, err)
+		}
+		pairs = append(pairs, [2]ref.Ref{rSrc, rTgt})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	return pairs, nil
+}
+
+func (result imageCopyBatchResult) MarshalPretty() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	tw := tabwriter.NewWriter(buf, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(tw, "Source\tTarget\tStatus\tElapsed\n")
+	for _, entry := range result.Entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%.1fs\n", entry.Source, entry.Target, entry.Status, entry.Elapsed)
+	}
+	err := tw.Flush()
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(buf, "\nCopied: %d, Failed: %d\n", result.Copied, result.Failed)
+	for _, entry := range result.Entries {
+		if entry.Error != "" {
+			fmt.Fprintf(buf, "%s: %s\n", entry.Source, entry.Error)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
 type imageProgress struct {
 	mu       sync.Mutex
 	start    time.Time
@@ -1167,6 +2267,7 @@ type imageProgress struct {
 	asciiOut *ascii.Lines
 	bar      *ascii.ProgressBar
 	changed  bool
+	shared   int64
 }
 
 type imageProgressEntry struct {
@@ -1183,6 +2284,11 @@ func (ip *imageProgress) callback(kind types.CallbackKind, instance string, stat
 	ip.mu.Lock()
 	defer ip.mu.Unlock()
 	ip.changed = true
+	if state == types.CallbackShared {
+		// shared blobs are tracked with the first copy
+		ip.shared += total
+		return
+	}
 	now := time.Now()
 	if e, ok := ip.entries[kind.String()+":"+instance]; ok {
 		e.state = state
@@ -1280,17 +2386,118 @@ func (ip *imageProgress) display(final bool) {
 		manifestFinished, manifestTotal,
 		units.HumanSize(float64(sum)),
 		units.HumanSize(float64(skipped)))))
+	if ip.shared > 0 {
+		ip.asciiOut.Add([]byte(fmt.Sprintf(", %s shared",
+			units.HumanSize(float64(ip.shared)))))
+	}
 	if queued > 0 {
 		ip.asciiOut.Add([]byte(fmt.Sprintf(", %s queued",
 			units.HumanSize(float64(queued)))))
 	}
-	ip.asciiOut.Add([]byte(fmt.Sprintf(" | Elapsed: %ds\n", int64(time.Since(ip.start).Seconds()))))
+	elapsed := time.Since(ip.start)
+	ip.asciiOut.Add([]byte(fmt.Sprintf(" | Elapsed: %ds", int64(elapsed.Seconds()))))
+	// estimate the remaining time from the average rate of the copy
+	if !final && queued > 0 && sum > 0 {
+		eta := time.Duration(float64(elapsed) * float64(queued) / float64(sum))
+		ip.asciiOut.Add([]byte(fmt.Sprintf(" | ETA: %ds", int64(eta.Seconds()))))
+	}
+	ip.asciiOut.Add([]byte("\n"))
 	ip.asciiOut.Flush()
 	if !final {
 		ip.asciiOut.Return()
 	}
 }
 
+// imageProgressJSON outputs newline delimited json events for each change in the copy progress.
+type imageProgressJSON struct {
+	mu                      sync.Mutex
+	start                   time.Time
+	enc                     *json.Encoder
+	last                    map[string]time.Time
+	copied, skipped, shared int64
+	manifests               int64
+}
+
+type imageProgressEvent struct {
+	Time     time.Time `json:"time"`
+	Kind     string    `json:"kind"`
+	Instance string    `json:"instance,omitempty"`
+	State    string    `json:"state"`
+	Current  int64     `json:"current"`
+	Total    int64     `json:"total"`
+}
+
+type imageProgressSummary struct {
+	Time      time.Time `json:"time"`
+	Kind      string    `json:"kind"`
+	State     string    `json:"state"`
+	Manifests int64     `json:"manifests"`
+	Copied    int64     `json:"copied"`
+	Skipped   int64     `json:"skipped"`
+	Shared    int64     `json:"shared"`
+	Elapsed   float64   `json:"elapsed"`
+	Error     string    `json:"error,omitempty"`
+}
+
+func (ipj *imageProgressJSON) callback(kind types.CallbackKind, instance string, state types.CallbackState, cur, total int64) {
+	ipj.mu.Lock()
+	defer ipj.mu.Unlock()
+	now := time.Now()
+	key := kind.String() + ":" + instance
+	// limit the active events for each entry to the progress frequency
+	if state == types.CallbackActive {
+		if last, ok := ipj.last[key]; ok && now.Sub(last) < progressFreq {
+			return
+		}
+	}
+	ipj.last[key] = now
+	switch state {
+	case types.CallbackFinished:
+		if kind == types.CallbackManifest {
+			ipj.manifests++
+		} else {
+			ipj.copied += total
+		}
+	case types.CallbackSkipped:
+		if kind == types.CallbackManifest {
+			ipj.manifests++
+		} else {
+			ipj.skipped += total
+		}
+	case types.CallbackShared:
+		ipj.shared += total
+	}
+	_ = ipj.enc.Encode(imageProgressEvent{
+		Time:     now.UTC(),
+		Kind:     kind.String(),
+		Instance: instance,
+		State:    state.String(),
+		Current:  cur,
+		Total:    total,
+	})
+}
+
+// summary outputs the totals after the copy completes.
+func (ipj *imageProgressJSON) summary(err error) {
+	ipj.mu.Lock()
+	defer ipj.mu.Unlock()
+	s := imageProgressSummary{
+		Time:      time.Now().UTC(),
+		Kind:      "summary",
+		State:     "finished",
+		Manifests: ipj.manifests,
+		Copied:    ipj.copied,
+		Skipped:   ipj.skipped,
+		Shared:    ipj.shared,
+		Elapsed:   time.Since(ipj.start).Seconds(),
+	}
+	if err != nil {
+		s.State = "failed"
+		s.Error = err.Error()
+	}
+	_ = ipj.enc.Encode(s)
+}
+
 func (imageOpts *imageCmd) runImageCreate(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
 
@@ -1404,26 +2611,287 @@ func (imageOpts *imageCmd) runImageCreate(cmd *cobra.Command, args []string) err
 		return err
 	}
 
-	// push the image
-	if imageOpts.byDigest {
-		r.Tag = ""
-		r.Digest = mm.GetDescriptor().Digest.String()
+	// push the image
+	if imageOpts.byDigest {
+		r.Tag = ""
+		r.Digest = mm.GetDescriptor().Digest.String()
+	}
+	err = rc.ManifestPut(ctx, r, mm)
+	if err != nil {
+		return err
+	}
+
+	// format output
+	result := struct {
+		Manifest manifest.Manifest
+	}{
+		Manifest: mm,
+	}
+	if imageOpts.byDigest && imageOpts.formatCreate == "" {
+		imageOpts.formatCreate = "{{ printf \"%s\\n\" .Manifest.GetDescriptor.Digest }}"
+	}
+	return template.Writer(cmd.OutOrStdout(), imageOpts.formatCreate, result)
+}
+
+func (imageOpts *imageCmd) runImageDiff(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	// dedup warnings
+	if w := warning.FromContext(ctx); w == nil {
+		ctx = warning.NewContext(ctx, &warning.Warning{Hook: warning.DefaultHook()})
+	}
+	r1, err := ref.New(args[0])
+	if err != nil {
+		return err
+	}
+	r2, err := ref.New(args[1])
+	if err != nil {
+		return err
+	}
+	if imageOpts.platform == "" {
+		imageOpts.platform = "local"
+	}
+	p, err := platform.Parse(imageOpts.platform)
+	if err != nil {
+		return fmt.Errorf("failed to parse platform %s: %w", imageOpts.platform, err)
+	}
+	rc := imageOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, r1)
+	defer rc.Close(ctx, r2)
+
+	imageOpts.rootOpts.log.Debug("Image diff",
+		slog.String("ref1", r1.CommonName()),
+		slog.String("ref2", r2.CommonName()),
+		slog.String("platform", p.String()))
+
+	result := imageDiffResult{
+		Ref1:   r1,
+		Ref2:   r2,
+		Layers: []imageDiffLayer{},
+		Config: []imageDiffConfig{},
+	}
+	img1, err := imageDiffGet(ctx, rc, r1, p)
+	if err != nil {
+		return err
+	}
+	img2, err := imageDiffGet(ctx, rc, r2, p)
+	if err != nil {
+		return err
+	}
+	result.Digest1 = img1.m.GetDescriptor().Digest
+	result.Digest2 = img2.m.GetDescriptor().Digest
+	result.Size1 = img1.size
+	result.Size2 = img2.size
+	result.SizeDelta = img2.size - img1.size
+
+	// layers are compared by digest, keeping the order of each image
+	inLayers1 := map[digest.Digest]bool{}
+	for _, d := range img1.layers {
+		inLayers1[d.Digest] = true
+	}
+	inLayers2 := map[digest.Digest]bool{}
+	for _, d := range img2.layers {
+		inLayers2[d.Digest] = true
+	}
+	for _, d := range img1.layers {
+		if !inLayers2[d.Digest] {
+			result.Layers = append(result.Layers, imageDiffLayer{Change: "removed", Digest: d.Digest, Size: d.Size})
+		}
+	}
+	for _, d := range img2.layers {
+		if !inLayers1[d.Digest] {
+			result.Layers = append(result.Layers, imageDiffLayer{Change: "added", Digest: d.Digest, Size: d.Size})
+		}
+	}
+	for _, d := range img2.layers {
+		if inLayers1[d.Digest] {
+			result.LayersSame++
+		}
+	}
+
+	// config fields
+	c1, c2 := img1.conf.Config, img2.conf.Config
+	env1, env2 := imageDiffEnv(c1.Env), imageDiffEnv(c2.Env)
+	result.Config = append(result.Config, imageDiffMap("env", env1, env2)...)
+	result.Config = append(result.Config, imageDiffMap("label", c1.Labels, c2.Labels)...)
+	for _, field := range []struct {
+		name       string
+		val1, val2 string
+	}{
+		{name: "entrypoint", val1: imageDiffList(c1.Entrypoint), val2: imageDiffList(c2.Entrypoint)},
+		{name: "cmd", val1: imageDiffList(c1.Cmd), val2: imageDiffList(c2.Cmd)},
+		{name: "user", val1: c1.User, val2: c2.User},
+		{name: "workdir", val1: c1.WorkingDir, val2: c2.WorkingDir},
+	} {
+		if field.val1 != field.val2 {
+			result.Config = append(result.Config, imageDiffConfig{Field: field.name, Old: field.val1, New: field.val2})
+		}
+	}
+
+	if !flagChanged(cmd, "format") {
+		imageOpts.format = "{{printPretty .}}"
+	}
+	return template.Writer(cmd.OutOrStdout(), imageOpts.format, result)
+}
+
+type imageDiffImage struct {
+	m      manifest.Manifest
+	conf   v1.Image
+	layers []descriptor.Descriptor
+	size   int64
+}
+
+// imageDiffGet returns the manifest, config, and layers of an image, selecting the platform from a manifest list.
+func imageDiffGet(ctx context.Context, rc *regclient.RegClient, r ref.Ref, p platform.Platform) (imageDiffImage, error) {
+	img := imageDiffImage{}
+	m, err := rc.ManifestGet(ctx, r)
+	if err != nil {
+		return img, err
+	}
+	if m.IsList() {
+		d, err := manifest.GetPlatformDesc(m, &p)
+		if err != nil {
+			return img, fmt.Errorf("failed to find platform %s in %s: %w", p.String(), r.CommonName(), err)
+		}
+		m, err = rc.ManifestGet(ctx, r, regclient.WithManifestDesc(*d))
+		if err != nil {
+			return img, err
+		}
+	}
+	mi, ok := m.(manifest.Imager)
+	if !ok {
+		return img, fmt.Errorf("manifest does not support image methods %s%.0w", r.CommonName(), errs.ErrUnsupportedMediaType)
+	}
+	cd, err := mi.GetConfig()
+	if err != nil {
+		return img, err
+	}
+	conf, err := rc.BlobGetOCIConfig(ctx, r, cd)
+	if err != nil {
+		return img, err
+	}
+	img.layers, err = mi.GetLayers()
+	if err != nil {
+		return img, err
+	}
+	img.m = m
+	img.conf = conf.GetConfig()
+	img.size = cd.Size
+	for _, d := range img.layers {
+		img.size += d.Size
+	}
+	return img, nil
+}
+
+func imageDiffEnv(env []string) map[string]string {
+	result := map[string]string{}
+	for _, e := range env {
+		k, v, _ := strings.Cut(e, "=")
+		result[k] = v
+	}
+	return result
+}
+
+func imageDiffList(list []string) string {
+	if len(list) == 0 {
+		return ""
 	}
-	err = rc.ManifestPut(ctx, r, mm)
+	b, err := json.Marshal(list)
 	if err != nil {
-		return err
+		return strings.Join(list, " ")
 	}
+	return string(b)
+}
 
-	// format output
-	result := struct {
-		Manifest manifest.Manifest
-	}{
-		Manifest: mm,
+// imageDiffMap returns the changes between two maps sorted by key.
+func imageDiffMap(field string, m1, m2 map[string]string) []imageDiffConfig {
+	keys := []string{}
+	for k := range m1 {
+		keys = append(keys, k)
 	}
-	if imageOpts.byDigest && imageOpts.formatCreate == "" {
-		imageOpts.formatCreate = "{{ printf \"%s\\n\" .Manifest.GetDescriptor.Digest }}"
+	for k := range m2 {
+		if _, ok := m1[k]; !ok {
+			keys = append(keys, k)
+		}
 	}
-	return template.Writer(cmd.OutOrStdout(), imageOpts.formatCreate, result)
+	sort.Strings(keys)
+	result := []imageDiffConfig{}
+	for _, k := range keys {
+		val1, ok1 := m1[k]
+		val2, ok2 := m2[k]
+		if ok1 && ok2 && val1 == val2 {
+			continue
+		}
+		result = append(result, imageDiffConfig{Field: field, Key: k, Old: val1, New: val2, Added: !ok1, Removed: !ok2})
+	}
+	return result
+}
+
+type imageDiffResult struct {
+	Ref1       ref.Ref           `json:"ref1"`
+	Ref2       ref.Ref           `json:"ref2"`
+	Digest1    digest.Digest     `json:"digest1"`
+	Digest2    digest.Digest     `json:"digest2"`
+	Layers     []imageDiffLayer  `json:"layers"`
+	LayersSame int               `json:"layersSame"`
+	Config     []imageDiffConfig `json:"config"`
+	Size1      int64             `json:"size1"`
+	Size2      int64             `json:"size2"`
+	SizeDelta  int64             `json:"sizeDelta"`
+}
+
+type imageDiffLayer struct {
+	Change string        `json:"change"`
+	Digest digest.Digest `json:"digest"`
+	Size   int64         `json:"size"`
+}
+
+type imageDiffConfig struct {
+	Field   string `json:"field"`
+	Key     string `json:"key,omitempty"`
+	Old     string `json:"old,omitempty"`
+	New     string `json:"new,omitempty"`
+	Added   bool   `json:"added,omitempty"`
+	Removed bool   `json:"removed,omitempty"`
+}
+
+// MarshalPretty is used for printPretty template formatting
+func (result imageDiffResult) MarshalPretty() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	tw := tabwriter.NewWriter(buf, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(tw, "Image 1:\t%s\t%s\n", result.Ref1.CommonName(), result.Digest1.String())
+	fmt.Fprintf(tw, "Image 2:\t%s\t%s\n", result.Ref2.CommonName(), result.Digest2.String())
+	sign := "+"
+	if result.SizeDelta < 0 {
+		sign = "-"
+	}
+	delta := result.SizeDelta
+	if delta < 0 {
+		delta = -delta
+	}
+	fmt.Fprintf(tw, "Size:\t%s -> %s (%s%s)\n", units.HumanSize(float64(result.Size1)), units.HumanSize(float64(result.Size2)), sign, units.HumanSize(float64(delta)))
+	fmt.Fprintf(tw, "\t\n")
+	fmt.Fprintf(tw, "Layers:\t%d unchanged, %d changed\n", result.LayersSame, len(result.Layers))
+	for _, l := range result.Layers {
+		fmt.Fprintf(tw, "  %s\t%s\t%s\n", l.Change, l.Digest.String(), units.HumanSize(float64(l.Size)))
+	}
+	fmt.Fprintf(tw, "\t\n")
+	fmt.Fprintf(tw, "Config:\t%d changed\n", len(result.Config))
+	for _, c := range result.Config {
+		name := c.Field
+		if c.Key != "" {
+			name = c.Field + " " + c.Key
+		}
+		switch {
+		case c.Added:
+			fmt.Fprintf(tw, "  added\t%s\t%s\n", name, c.New)
+		case c.Removed:
+			fmt.Fprintf(tw, "  removed\t%s\t%s\n", name, c.Old)
+		default:
+			fmt.Fprintf(tw, "  changed\t%s\t%s -> %s\n", name, c.Old, c.New)
+		}
+	}
+	err := tw.Flush()
+	return buf.Bytes(), err
 }
 
 func (imageOpts *imageCmd) runImageExport(cmd *cobra.Command, args []string) error {
@@ -1436,12 +2904,17 @@ func (imageOpts *imageCmd) runImageExport(cmd *cobra.Command, args []string) err
 	if err != nil {
 		return err
 	}
+	if imageOpts.referrerSrc != "" && !imageOpts.referrers {
+		return fmt.Errorf("referrers must be enabled to specify an external referrers source%.0w", errs.ErrUnsupported)
+	}
 	var w io.Writer
 	if len(args) == 2 {
-		w, err = os.Create(args[1])
+		fh, err := os.Create(args[1])
 		if err != nil {
 			return err
 		}
+		defer fh.Close()
+		w = fh
 	} else {
 		w = cmd.OutOrStdout()
 	}
@@ -1449,15 +2922,17 @@ func (imageOpts *imageCmd) runImageExport(cmd *cobra.Command, args []string) err
 	defer rc.Close(ctx, r)
 	opts := []regclient.ImageOpts{}
 	if imageOpts.platform != "" {
-		p, err := platform.Parse(imageOpts.platform)
-		if err != nil {
-			return err
-		}
-		m, err := rc.ManifestGet(ctx, r, regclient.WithManifestPlatform(p))
+		opts = append(opts, regclient.ImageWithPlatform(imageOpts.platform))
+	}
+	if imageOpts.referrers {
+		opts = append(opts, regclient.ImageWithReferrers())
+	}
+	if imageOpts.referrerSrc != "" {
+		referrerSrc, err := ref.New(imageOpts.referrerSrc)
 		if err != nil {
-			return err
+			return fmt.Errorf("failed parsing referrer external source: %w", err)
 		}
-		r = r.SetDigest(m.GetDescriptor().Digest.String())
+		opts = append(opts, regclient.ImageWithReferrerSrc(referrerSrc))
 	}
 	if imageOpts.exportCompress {
 		opts = append(opts, regclient.ImageWithExportCompress())
@@ -1583,11 +3058,29 @@ func (imageOpts *imageCmd) runImageImport(cmd *cobra.Command, args []string) err
 	if imageOpts.importName != "" {
 		opts = append(opts, regclient.ImageWithImportName(imageOpts.importName))
 	}
-	rs, err := os.Open(args[1])
-	if err != nil {
-		return err
+	if imageOpts.referrers {
+		opts = append(opts, regclient.ImageWithReferrers())
+	}
+	var rs *os.File
+	if args[1] == "-" {
+		// the import seeks over the tar multiple times, buffer stdin to a temp file
+		rs, err = os.CreateTemp("", "regctl-import-*.tar")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(rs.Name())
+		defer rs.Close()
+		_, err = io.Copy(rs, cmd.InOrStdin())
+		if err != nil {
+			return fmt.Errorf("failed to read stdin: %w", err)
+		}
+	} else {
+		rs, err = os.Open(args[1])
+		if err != nil {
+			return err
+		}
+		defer rs.Close()
 	}
-	defer rs.Close()
 	rc := imageOpts.rootOpts.newRegClient()
 	defer rc.Close(ctx, r)
 	imageOpts.rootOpts.log.Debug("Image import",
@@ -1615,6 +3108,9 @@ func (imageOpts *imageCmd) runImageInspect(cmd *cobra.Command, args []string) er
 	opts := []regclient.ImageOpts{}
 	if imageOpts.platform != "" {
 		opts = append(opts, regclient.ImageWithPlatform(imageOpts.platform))
+	} else {
+		imageOpts.rootOpts.log.Info("Platform not specified, the local platform is used for multi-platform images",
+			slog.String("platform", platform.Local().String()))
 	}
 	blobConfig, err := rc.ImageConfig(ctx, r, opts...)
 	if err != nil {
@@ -1674,12 +3170,14 @@ func (imageOpts *imageCmd) runImageMod(cmd *cobra.Command, args []string) error
 	if err != nil {
 		return err
 	}
-	fmt.Fprintf(cmd.OutOrStdout(), "%s\n", rOut.CommonName())
 	err = rc.Close(ctx, rOut)
 	if err != nil {
 		return fmt.Errorf("failed to close ref: %w", err)
 	}
-	return nil
+	if !flagChanged(cmd, "format") {
+		imageOpts.format = "{{ .CommonName }}\n"
+	}
+	return template.Writer(cmd.OutOrStdout(), imageOpts.format, rOut)
 }
 
 func (imageOpts *imageCmd) runImageRateLimit(cmd *cobra.Command, args []string) error {
@@ -1700,8 +3198,439 @@ func (imageOpts *imageCmd) runImageRateLimit(cmd *cobra.Command, args []string)
 	if err != nil {
 		return err
 	}
+	rl := imageRateLimit{RateLimit: manifest.GetRateLimit(m)}
+	if !flagChanged(cmd, "format") {
+		imageOpts.format = "{{printPretty .}}"
+	}
+	err = template.Writer(cmd.OutOrStdout(), imageOpts.format, rl)
+	if err != nil {
+		return err
+	}
+	if imageOpts.minRemain > 0 && rl.Set && rl.Remain < imageOpts.minRemain {
+		return fmt.Errorf("%d pulls remaining on %s, below the minimum of %d%.0w", rl.Remain, r.Registry, imageOpts.minRemain, errs.ErrHTTPRateLimit)
+	}
+	return nil
+}
+
+// imageRateLimit adds a table output to the rate limit.
+type imageRateLimit struct {
+	types.RateLimit
+}
+
+func (rl imageRateLimit) MarshalPretty() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	tw := tabwriter.NewWriter(buf, 0, 0, 1, ' ', 0)
+	if !rl.Set {
+		fmt.Fprintf(tw, "Rate limit not provided by the registry\n")
+		return buf.Bytes(), tw.Flush()
+	}
+	fmt.Fprintf(tw, "Remain:\t%d\n", rl.Remain)
+	if rl.Limit > 0 {
+		fmt.Fprintf(tw, "Limit:\t%d\n", rl.Limit)
+	}
+	if rl.Window > 0 {
+		fmt.Fprintf(tw, "Window:\t%s\n", (time.Duration(rl.Window) * time.Second).String())
+	}
+	if rl.Reset > 0 {
+		fmt.Fprintf(tw, "Reset:\t%s\n", (time.Duration(rl.Reset) * time.Second).String())
+	}
+	for _, policy := range rl.Policies {
+		fmt.Fprintf(tw, "Policy:\t%s\n", strings.TrimSpace(policy))
+	}
+	err := tw.Flush()
+	return buf.Bytes(), err
+}
+
+func (imageOpts *imageCmd) runImageSign(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	r, err := ref.New(args[0])
+	if err != nil {
+		return err
+	}
+	if imageOpts.signKey == "" {
+		return fmt.Errorf("a private key is required with --key%.0w", ErrMissingInput)
+	}
+	signer, err := imageOpts.loadSignKey(cmd)
+	if err != nil {
+		return err
+	}
+	annotations := map[string]string{}
+	for _, a := range imageOpts.annotations {
+		aSplit := strings.SplitN(a, "=", 2)
+		if len(aSplit) == 1 {
+			annotations[aSplit[0]] = ""
+		} else {
+			annotations[aSplit[0]] = aSplit[1]
+		}
+	}
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	rc := imageOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, r)
+	mDesc, err := resolveDesc(ctx, rc, r, imageOpts.platform)
+	if err != nil {
+		return err
+	}
+	rDig := r.SetDigest(mDesc.Digest.String())
+
+	// sign the payload and push it as a layer
+	payload, err := cosign.NewPayload(cosign.DockerReference(r.Registry, r.Repository), mDesc.Digest, annotations)
+	if err != nil {
+		return err
+	}
+	sig, err := cosign.Sign(signer, payload)
+	if err != nil {
+		return fmt.Errorf("failed to sign %s: %w", rDig.CommonName(), err)
+	}
+	layer, err := rc.BlobPut(ctx, rDig, descriptor.Descriptor{}, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to push signature payload: %w", err)
+	}
+	layer.MediaType = cosign.MediaTypeSimpleSigning
+	layer.Annotations = map[string]string{
+		cosign.AnnotationSignature: base64.StdEncoding.EncodeToString(sig),
+	}
+	imageOpts.rootOpts.log.Debug("Signing image",
+		slog.String("ref", rDig.CommonName()),
+		slog.Bool("referrers", imageOpts.signReferrers))
+
+	var rSig ref.Ref
+	var mm manifest.Manifest
+	if imageOpts.signReferrers {
+		conf, err := rc.BlobPut(ctx, rDig, descriptor.Descriptor{}, bytes.NewReader(descriptor.EmptyData))
+		if err != nil {
+			return fmt.Errorf("failed to push signature config: %w", err)
+		}
+		conf.MediaType = mediatype.OCI1Empty
+		mm, err = manifest.New(manifest.WithOrig(v1.Manifest{
+			Versioned:    v1.ManifestSchemaVersion,
+			MediaType:    mediatype.OCI1Manifest,
+			ArtifactType: cosign.ArtifactTypeSignature,
+			Config:       conf,
+			Layers:       []descriptor.Descriptor{layer},
+			Subject: &descriptor.Descriptor{
+				MediaType: mDesc.MediaType,
+				Digest:    mDesc.Digest,
+				Size:      mDesc.Size,
+			},
+		}))
+		if err != nil {
+			return err
+		}
+		rSig = r.SetDigest(mm.GetDescriptor().Digest.String())
+	} else {
+		// add to the existing signatures in the tag
+		rSig = r.SetTag(cosign.SignatureTag(mDesc.Digest))
+		layers := []descriptor.Descriptor{}
+		mExisting, err := rc.ManifestGet(ctx, rSig)
+		if err != nil && !errors.Is(err, errs.ErrNotFound) {
+			return fmt.Errorf("failed to get existing signatures %s: %w", rSig.CommonName(), err)
+		}
+		if mi, ok := mExisting.(manifest.Imager); err == nil && ok {
+			existing, err := mi.GetLayers()
+			if err != nil {
+				return err
+			}
+			for _, l := range existing {
+				if l.Digest != layer.Digest || l.Annotations[cosign.AnnotationSignature] != layer.Annotations[cosign.AnnotationSignature] {
+					layers = append(layers, l)
+				}
+			}
+		}
+		layers = append(layers, layer)
+		conf := v1.Image{
+			RootFS: v1.RootFS{
+				Type:    "layers",
+				DiffIDs: []digest.Digest{},
+			},
+		}
+		for _, l := range layers {
+			conf.RootFS.DiffIDs = append(conf.RootFS.DiffIDs, l.Digest)
+		}
+		confBytes, err := json.Marshal(conf)
+		if err != nil {
+			return err
+		}
+		confDesc, err := rc.BlobPut(ctx, rDig, descriptor.Descriptor{}, bytes.NewReader(confBytes))
+		if err != nil {
+			return fmt.Errorf("failed to push signature config: %w", err)
+		}
+		confDesc.MediaType = mediatype.OCI1ImageConfig
+		mm, err = manifest.New(manifest.WithOrig(v1.Manifest{
+			Versioned: v1.ManifestSchemaVersion,
+			MediaType: mediatype.OCI1Manifest,
+			Config:    confDesc,
+			Layers:    layers,
+		}))
+		if err != nil {
+			return err
+		}
+	}
+	err = rc.ManifestPut(ctx, rSig, mm)
+	if err != nil {
+		return fmt.Errorf("failed to push signature %s: %w", rSig.CommonName(), err)
+	}
+	if !flagChanged(cmd, "format") {
+		imageOpts.format = "{{ .CommonName }}\n"
+	}
+	return template.Writer(cmd.OutOrStdout(), imageOpts.format, rSig)
+}
+
+// loadSignKey reads the private key from --key, with the password of an encrypted cosign key from stdin or the environment.
+func (imageOpts *imageCmd) loadSignKey(cmd *cobra.Command) (crypto.Signer, error) {
+	//#nosec G304 command is run by a user accessing their own files
+	keyBytes, err := os.ReadFile(imageOpts.signKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %s: %w", imageOpts.signKey, err)
+	}
+	pass := func() ([]byte, error) {
+		if imageOpts.signPassStdin {
+			passIn, err := readLine(cmd.InOrStdin())
+			if err != nil {
+				return nil, fmt.Errorf("failed to read password from stdin: %w", err)
+			}
+			return []byte(strings.TrimRight(passIn, "\r\n")), nil
+		}
+		// cosign allows an empty password, so only an unset variable is an error
+		if pass, ok := os.LookupEnv(CosignPassEnv); ok {
+			return []byte(pass), nil
+		}
+		return nil, fmt.Errorf("key %s is encrypted, provide the password with --key-pass-stdin or %s%.0w", imageOpts.signKey, CosignPassEnv, ErrMissingInput)
+	}
+	return cosign.LoadPrivateKey(keyBytes, pass)
+}
+
+func (imageOpts *imageCmd) runImageVerify(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	r, err := ref.New(args[0])
+	if err != nil {
+		return err
+	}
+	if imageOpts.signKey == "" && imageOpts.policy == "" {
+		return fmt.Errorf("a public key or policy is required with --key or --policy%.0w", ErrMissingInput)
+	}
+	var policy *imagePolicy
+	if imageOpts.policy != "" {
+		policy, err = loadImagePolicy(imageOpts.policy)
+		if err != nil {
+			return err
+		}
+		if imageOpts.signKey != "" {
+			policy.Signatures = append(policy.Signatures, imagePolicySignature{Key: imageOpts.signKey})
+		}
+	}
+	rc := imageOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, r)
+	mDesc, err := resolveDesc(ctx, rc, r, imageOpts.platform)
+	if err != nil {
+		return err
+	}
+	rDig := r.SetDigest(mDesc.Digest.String())
+	result := imageVerifyResult{
+		Ref:        rDig,
+		Signatures: []imageVerifySignature{},
+	}
+
+	if policy != nil {
+		err = imageOpts.verifyPolicy(ctx, rc, r, mDesc, *policy, &result)
+		if err != nil {
+			return err
+		}
+		err = template.Writer(cmd.OutOrStdout(), imageOpts.format, result)
+		if err != nil {
+			return err
+		}
+		failed := 0
+		for _, c := range result.Checks {
+			if !c.Pass {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d policy checks failed for %s%.0w", failed, len(result.Checks), rDig.CommonName(), ErrPolicyViolation)
+		}
+		return nil
+	}
+
+	pub, err := loadPublicKey(imageOpts.signKey)
+	if err != nil {
+		return err
+	}
+	result.Signatures, err = imageOpts.findSignatures(ctx, rc, r, mDesc, pub)
+	if err != nil {
+		return err
+	}
+	if len(result.Signatures) == 0 {
+		return fmt.Errorf("no valid signatures found for %s%.0w", rDig.CommonName(), errs.ErrNotFound)
+	}
+	return template.Writer(cmd.OutOrStdout(), imageOpts.format, result)
+}
+
+// findSignatures returns the valid signatures for the public key from the signature tag and referrers.
+func (imageOpts *imageCmd) findSignatures(ctx context.Context, rc *regclient.RegClient, r ref.Ref, mDesc descriptor.Descriptor, pub crypto.PublicKey) ([]imageVerifySignature, error) {
+	rDig := r.SetDigest(mDesc.Digest.String())
+	rSigs := []ref.Ref{}
+	rSigTag := r.SetTag(cosign.SignatureTag(mDesc.Digest))
+	_, err := rc.ManifestHead(ctx, rSigTag)
+	if err == nil {
+		rSigs = append(rSigs, rSigTag)
+	} else if !errors.Is(err, errs.ErrNotFound) {
+		return nil, fmt.Errorf("failed to check signature tag %s: %w", rSigTag.CommonName(), err)
+	}
+	rl, err := rc.ReferrerList(ctx, rDig, scheme.WithReferrerMatchOpt(descriptor.MatchOpt{ArtifactType: cosign.ArtifactTypeSignature}))
+	if err != nil {
+		imageOpts.rootOpts.log.Warn("Failed to list referrers",
+			slog.String("ref", rDig.CommonName()),
+			slog.String("err", err.Error()))
+	} else {
+		for _, d := range rl.Descriptors {
+			rSigs = append(rSigs, r.SetDigest(d.Digest.String()))
+		}
+	}
+
+	sigs := []imageVerifySignature{}
+	for _, rSig := range rSigs {
+		mSig, err := rc.ManifestGet(ctx, rSig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get signature %s: %w", rSig.CommonName(), err)
+		}
+		mi, ok := mSig.(manifest.Imager)
+		if !ok {
+			continue
+		}
+		layers, err := mi.GetLayers()
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range layers {
+			if l.MediaType != cosign.MediaTypeSimpleSigning {
+				continue
+			}
+			p, err := imageOpts.verifyLayer(ctx, rc, rSig, l, pub, mDesc.Digest)
+			if err != nil {
+				imageOpts.rootOpts.log.Info("Skipping signature",
+					slog.String("signature", rSig.CommonName()),
+					slog.String("layer", l.Digest.String()),
+					slog.String("err", err.Error()))
+				continue
+			}
+			sigs = append(sigs, imageVerifySignature{
+				Source:  rSig,
+				Digest:  l.Digest,
+				Payload: p,
+			})
+		}
+	}
+	return sigs, nil
+}
+
+// resolveDesc returns the descriptor of the manifest for a reference, resolving the platform when one is provided.
+func resolveDesc(ctx context.Context, rc *regclient.RegClient, r ref.Ref, platStr string) (descriptor.Descriptor, error) {
+	mOpts := []regclient.ManifestOpts{}
+	if platStr != "" {
+		p, err := platform.Parse(platStr)
+		if err != nil {
+			return descriptor.Descriptor{}, fmt.Errorf("failed to parse platform %s: %w", platStr, err)
+		}
+		mOpts = append(mOpts, regclient.WithManifestPlatform(p))
+	}
+	m, err := rc.ManifestHead(ctx, r, mOpts...)
+	if err != nil || platStr != "" || m.GetDescriptor().Digest == "" {
+		m, err = rc.ManifestGet(ctx, r, mOpts...)
+	}
+	if err != nil {
+		return descriptor.Descriptor{}, err
+	}
+	return m.GetDescriptor(), nil
+}
+
+// verifyLayer verifies the signature of a single payload is valid for the digest.
+func (imageOpts *imageCmd) verifyLayer(ctx context.Context, rc *regclient.RegClient, r ref.Ref, l descriptor.Descriptor, pub crypto.PublicKey, d digest.Digest) (cosign.Payload, error) {
+	sig, err := base64.StdEncoding.DecodeString(l.Annotations[cosign.AnnotationSignature])
+	if err != nil || len(sig) == 0 {
+		return cosign.Payload{}, fmt.Errorf("signature annotation is missing or invalid%.0w", errs.ErrMissingAnnotation)
+	}
+	if l.Size > imageSignPayloadLimit {
+		return cosign.Payload{}, fmt.Errorf("payload size %d exceeds %d%.0w", l.Size, imageSignPayloadLimit, errs.ErrSizeLimitExceeded)
+	}
+	rdr, err := rc.BlobGet(ctx, r, l)
+	if err != nil {
+		return cosign.Payload{}, err
+	}
+	defer rdr.Close()
+	payload, err := io.ReadAll(rdr)
+	if err != nil {
+		return cosign.Payload{}, err
+	}
+	err = cosign.Verify(pub, payload, sig)
+	if err != nil {
+		return cosign.Payload{}, err
+	}
+	p, err := cosign.ParsePayload(payload)
+	if err != nil {
+		return p, err
+	}
+	if p.Critical.Image.DockerManifestDigest != d {
+		return p, fmt.Errorf("payload is for %s%.0w", p.Critical.Image.DockerManifestDigest.String(), errs.ErrDigestMismatch)
+	}
+	return p, nil
+}
+
+// imageSignPayloadLimit is the largest signature payload that will be pulled.
+const imageSignPayloadLimit = 1024 * 1024
+
+type imageVerifyResult struct {
+	Ref        ref.Ref
+	Checks     []imagePolicyCheck `json:",omitempty"`
+	Signatures []imageVerifySignature
+}
 
-	return template.Writer(cmd.OutOrStdout(), imageOpts.format, manifest.GetRateLimit(m))
+type imageVerifySignature struct {
+	Source  ref.Ref
+	Digest  digest.Digest
+	Payload cosign.Payload
+}
+
+func (result imageVerifyResult) MarshalPretty() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	tw := tabwriter.NewWriter(buf, 0, 0, 1, ' ', 0)
+	if len(result.Checks) > 0 {
+		passed := 0
+		for _, c := range result.Checks {
+			if c.Pass {
+				passed++
+			}
+		}
+		fmt.Fprintf(tw, "Passed %d of %d policy checks for %s\n", passed, len(result.Checks), result.Ref.CommonName())
+		fmt.Fprintf(tw, "\t\n")
+		fmt.Fprintf(tw, "Check\tResult\tDetails\n")
+		for _, c := range result.Checks {
+			status := "pass"
+			if !c.Pass {
+				status = "FAIL"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", c.Name, status, c.Message)
+		}
+		if len(result.Signatures) == 0 {
+			err := tw.Flush()
+			return buf.Bytes(), err
+		}
+		fmt.Fprintf(tw, "\t\n")
+	}
+	fmt.Fprintf(tw, "Verified %d signatures for %s\n", len(result.Signatures), result.Ref.CommonName())
+	fmt.Fprintf(tw, "\t\n")
+	fmt.Fprintf(tw, "Signature\tIdentity\tAnnotations\n")
+	for _, s := range result.Signatures {
+		annotations := []string{}
+		for k, v := range s.Payload.Optional {
+			annotations = append(annotations, k+"="+v)
+		}
+		sort.Strings(annotations)
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Source.CommonName(), s.Payload.Critical.Identity.DockerReference, strings.Join(annotations, ","))
+	}
+	err := tw.Flush()
+	return buf.Bytes(), err
 }
 
 type modFlagFunc struct {
@@ -1,10 +1,19 @@
 package main
 
 import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"fmt"
+	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -62,11 +71,36 @@ func TestImageCopy(t *testing.T) {
 			args:      []string{"image", "copy", "--platform", "linux/amd64", tsHost + "/testrepo:v3", tsHost + "/newrepo:v3"},
 			expectOut: tsHost + "/newrepo:v3",
 		},
+		{
+			name:      "reg-to-reg-platforms",
+			args:      []string{"image", "copy", "--platform", "linux/amd64", "--platform", "linux/arm64", tsHost + "/testrepo:v3", tsHost + "/newrepo:v3-reduced"},
+			expectOut: tsHost + "/newrepo:v3-reduced",
+		},
+		{
+			name:      "platforms-digest-tags",
+			args:      []string{"image", "copy", "--platform", "linux/amd64", "--platform", "linux/arm64", "--digest-tags", tsHost + "/testrepo:v3", tsHost + "/newrepo:v3-digest-tags"},
+			expectErr: errs.ErrUnsupported,
+		},
 		{
 			name:      "ocidir-to-reg-external-referrers",
 			args:      []string{"image", "copy", srcRef, tsHost + "/newrepo:v4", "--referrers", "--referrers-src", "ocidir://../../testdata/external", "--referrers-tgt", tsHost + "/external"},
 			expectOut: tsHost + "/newrepo:v4",
 		},
+		{
+			name:      "ocidir-to-reg-external-flatten",
+			args:      []string{"image", "copy", "--external-flatten", "--referrers", srcRef, tsHost + "/newrepo:v5"},
+			expectOut: tsHost + "/newrepo:v5",
+		},
+		{
+			name:      "external-flatten-digest-tags",
+			args:      []string{"image", "copy", "--external-flatten", "--digest-tags", srcRef, tsHost + "/newrepo:v6"},
+			expectErr: errs.ErrUnsupported,
+		},
+		{
+			name:      "progress-invalid",
+			args:      []string{"image", "copy", "--progress", "bar", srcRef, tsHost + "/newrepo:v7"},
+			expectErr: ErrInvalidInput,
+		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
@@ -87,6 +121,107 @@ func TestImageCopy(t *testing.T) {
 			}
 		})
 	}
+	t.Run("reduced-index", func(t *testing.T) {
+		out, err := cobraTest(t, nil, "manifest", "get", tsHost+"/newrepo:v3-reduced", "--format", "{{range .Manifests}}{{.Platform}} {{end}}")
+		if err != nil {
+			t.Fatalf("failed to get reduced index: %v", err)
+		}
+		if out != "linux/amd64 linux/arm64" {
+			t.Errorf("unexpected platforms in reduced index: %s", out)
+		}
+	})
+	t.Run("progress-json", func(t *testing.T) {
+		tgtRef := "ocidir://" + tempDir + "/progress:v8"
+		out, err := cobraTest(t, nil, "image", "copy", "--progress", "json", srcRef, tgtRef)
+		if err != nil {
+			t.Fatalf("failed to copy: %v", err)
+		}
+		lines := strings.Split(out, "\n")
+		if len(lines) < 3 || lines[len(lines)-1] != tgtRef {
+			t.Fatalf("unexpected output: %s", out)
+		}
+		blobs := 0
+		for _, line := range lines[:len(lines)-2] {
+			event := struct {
+				Kind  string `json:"kind"`
+				State string `json:"state"`
+			}{}
+			err = json.Unmarshal([]byte(line), &event)
+			if err != nil {
+				t.Fatalf("failed to parse event %s: %v", line, err)
+			}
+			if event.Kind == "blob" && event.State != "" {
+				blobs++
+			}
+		}
+		if blobs == 0 {
+			t.Errorf("no blob events: %s", out)
+		}
+		summary := struct {
+			Kind      string `json:"kind"`
+			State     string `json:"state"`
+			Manifests int    `json:"manifests"`
+		}{}
+		err = json.Unmarshal([]byte(lines[len(lines)-2]), &summary)
+		if err != nil {
+			t.Fatalf("failed to parse summary %s: %v", lines[len(lines)-2], err)
+		}
+		if summary.Kind != "summary" || summary.State != "finished" || summary.Manifests == 0 {
+			t.Errorf("unexpected summary: %s", lines[len(lines)-2])
+		}
+	})
+	t.Run("file", func(t *testing.T) {
+		listFile := filepath.Join(tempDir, "list.txt")
+		list := "# images to copy\n" +
+			srcRef + " " + tsHost + "/batch:v2\n" +
+			"\n" +
+			"ocidir://../../testdata/testrepo:v3\t" + tsHost + "/batch:v3\n"
+		err := os.WriteFile(listFile, []byte(list), 0600)
+		if err != nil {
+			t.Fatalf("failed to write list: %v", err)
+		}
+		out, err := cobraTest(t, nil, "image", "copy", "--file", listFile, "--parallel", "2", "--format", "{{range .Entries}}{{.Target}} {{.Status}}\n{{end}}{{.Copied}}")
+		if err != nil {
+			t.Fatalf("failed to copy: %v", err)
+		}
+		expect := tsHost + "/batch:v2 copied\n" + tsHost + "/batch:v3 copied\n2"
+		if out != expect {
+			t.Errorf("unexpected output, expected %s, received %s", expect, out)
+		}
+		_, err = cobraTest(t, nil, "manifest", "head", tsHost+"/batch:v3")
+		if err != nil {
+			t.Errorf("copied image not found: %v", err)
+		}
+	})
+	t.Run("file-stdin-failure", func(t *testing.T) {
+		list := srcRef + " " + tsHost + "/batch:v2-stdin\n" +
+			"ocidir://../../testdata/testrepo:missing " + tsHost + "/batch:missing\n"
+		out, err := cobraTest(t, &cobraTestOpts{stdin: strings.NewReader(list)}, "image", "copy", "--file", "-")
+		if err == nil {
+			t.Errorf("failed copy did not return an error")
+		}
+		if !strings.Contains(out, "Copied: 1, Failed: 1") || !strings.Contains(out, "batch:missing") {
+			t.Errorf("unexpected output: %s", out)
+		}
+	})
+	t.Run("file-invalid", func(t *testing.T) {
+		_, err := cobraTest(t, &cobraTestOpts{stdin: strings.NewReader(srcRef + "\n")}, "image", "copy", "--file", "-")
+		if !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("unexpected error for a missing target: %v", err)
+		}
+		_, err = cobraTest(t, &cobraTestOpts{stdin: strings.NewReader("# empty\n")}, "image", "copy", "--file", "-")
+		if !errors.Is(err, ErrMissingInput) {
+			t.Errorf("unexpected error for an empty list: %v", err)
+		}
+		_, err = cobraTest(t, &cobraTestOpts{stdin: strings.NewReader("")}, "image", "copy", "--file", "-", srcRef)
+		if !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("unexpected error for args with a file: %v", err)
+		}
+		_, err = cobraTest(t, nil, "image", "copy", srcRef)
+		if err == nil || err.Error() != "accepts 2 arg(s), received 1" {
+			t.Errorf("unexpected error for a missing arg: %v", err)
+		}
+	})
 }
 
 func TestImageCreate(t *testing.T) {
@@ -102,6 +237,59 @@ func TestImageCreate(t *testing.T) {
 	}
 }
 
+func TestImageDiff(t *testing.T) {
+	ref1 := "ocidir://../../testdata/testrepo:v1"
+	ref3 := "ocidir://../../testdata/testrepo:v3"
+	tt := []struct {
+		name        string
+		cmd         []string
+		expectErr   error
+		expectOut   string
+		outContains bool
+	}{
+		{
+			name:        "pretty",
+			cmd:         []string{"image", "diff", "--platform", "linux/amd64", ref1, ref3},
+			expectOut:   "changed label version 1 -> 3",
+			outContains: true,
+		},
+		{
+			name:      "same",
+			cmd:       []string{"image", "diff", "--platform", "linux/amd64", ref1, ref1, "--format", "{{len .Layers}} {{len .Config}} {{.SizeDelta}}"},
+			expectOut: "0 0 0",
+		},
+		{
+			name:      "layers",
+			cmd:       []string{"image", "diff", "--platform", "linux/amd64", ref1, ref3, "--format", "{{range .Layers}}{{.Change}} {{end}}"},
+			expectOut: "added added added",
+		},
+		{
+			name:      "missing platform",
+			cmd:       []string{"image", "diff", "--platform", "linux/s390x", ref1, ref3},
+			expectErr: errs.ErrNotFound,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := cobraTest(t, nil, tc.cmd...)
+			if tc.expectErr != nil {
+				if err == nil {
+					t.Errorf("did not receive expected error: %v", tc.expectErr)
+				} else if !errors.Is(err, tc.expectErr) && err.Error() != tc.expectErr.Error() {
+					t.Errorf("unexpected error, received %v, expected %v", err, tc.expectErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("returned unexpected error: %v", err)
+			}
+			if (!tc.outContains && out != tc.expectOut) || (tc.outContains && !strings.Contains(out, tc.expectOut)) {
+				t.Errorf("unexpected output, expected %s, received %s", tc.expectOut, out)
+			}
+		})
+	}
+}
+
 func TestImageExportImport(t *testing.T) {
 	tmpDir := t.TempDir()
 	srcRef := "ocidir://../../testdata/testrepo:v2"
@@ -132,6 +320,194 @@ func TestImageExportImport(t *testing.T) {
 	if out != "" {
 		t.Errorf("unexpected output: %v", out)
 	}
+
+	// export with referrers and import from stdin
+	exportRefFile := tmpDir + "/export-referrers.tar"
+	importRefB := fmt.Sprintf("ocidir://%s/repo-referrers:v2", tmpDir)
+	_, err = cobraTest(t, nil, "image", "export", "--referrers", srcRef, exportRefFile)
+	if err != nil {
+		t.Fatalf("failed to run image export with referrers: %v", err)
+	}
+	exportRefBytes, err := os.ReadFile(exportRefFile)
+	if err != nil {
+		t.Fatalf("failed to read export: %v", err)
+	}
+	_, err = cobraTest(t, &cobraTestOpts{stdin: bytes.NewReader(exportRefBytes)}, "image", "import", "--referrers", importRefB, "-")
+	if err != nil {
+		t.Fatalf("failed to run image import from stdin: %v", err)
+	}
+	outSrc, err := cobraTest(t, nil, "artifact", "list", srcRef, "--format", "{{len .Descriptors}}")
+	if err != nil {
+		t.Fatalf("failed to list source referrers: %v", err)
+	}
+	out, err = cobraTest(t, nil, "artifact", "list", importRefB, "--format", "{{len .Descriptors}}")
+	if err != nil {
+		t.Fatalf("failed to list imported referrers: %v", err)
+	}
+	if out != outSrc || out == "0" {
+		t.Errorf("unexpected referrers after import, expected %s, received %s", outSrc, out)
+	}
+
+	_, err = cobraTest(t, nil, "image", "export", "--referrers-src", "ocidir://../../testdata/external", srcRef, exportRefFile)
+	if !errors.Is(err, errs.ErrUnsupported) {
+		t.Errorf("unexpected error for referrers-src without referrers: %v", err)
+	}
+}
+
+func TestImageAttest(t *testing.T) {
+	tempDir := t.TempDir()
+	testWriteKeyPair(t, tempDir, "signing")
+	testWriteKeyPair(t, tempDir, "other")
+	signKey := filepath.Join(tempDir, "signing.key")
+	signPub := filepath.Join(tempDir, "signing.pub")
+	otherPub := filepath.Join(tempDir, "other.pub")
+	predFile := filepath.Join(tempDir, "provenance.json")
+	err := os.WriteFile(predFile, []byte(`{"builder":{"id":"test"}}`), 0600)
+	if err != nil {
+		t.Fatalf("failed to write predicate: %v", err)
+	}
+	imgRef := "ocidir://" + tempDir + "/repo:v1"
+	_, err = cobraTest(t, nil, "image", "copy", "ocidir://../../testdata/testrepo:v1", imgRef)
+	if err != nil {
+		t.Fatalf("failed to copy image: %v", err)
+	}
+	tt := []struct {
+		name        string
+		args        []string
+		stdin       string
+		expectErr   error
+		expectOut   string
+		outContains bool
+	}{
+		{
+			name:      "get missing",
+			args:      []string{"image", "get-attestation", imgRef},
+			expectErr: errs.ErrNotFound,
+		},
+		{
+			name:      "attest missing predicate",
+			args:      []string{"image", "attest", "--type", "spdx", imgRef},
+			expectErr: ErrMissingInput,
+		},
+		{
+			name:      "attest invalid predicate",
+			args:      []string{"image", "attest", "--type", "spdx", "--predicate", "-", imgRef},
+			stdin:     "not json",
+			expectErr: errs.ErrParsingFailed,
+		},
+		{
+			name:        "attest unsigned",
+			args:        []string{"image", "attest", "--type", "spdx", "--predicate", "-", imgRef},
+			stdin:       `{"spdxVersion":"SPDX-2.3"}`,
+			expectOut:   "ocidir://" + tempDir + "/repo@sha256:",
+			outContains: true,
+		},
+		{
+			name:        "attest signed",
+			args:        []string{"image", "attest", "--type", "slsaprovenance1", "--predicate", predFile, "--key", signKey, imgRef},
+			expectOut:   "ocidir://" + tempDir + "/repo@sha256:",
+			outContains: true,
+		},
+		{
+			name:      "get all",
+			args:      []string{"image", "get-attestation", imgRef, "--format", "{{len .Attestations}}"},
+			expectOut: "2",
+		},
+		{
+			name:        "get table",
+			args:        []string{"image", "get-attestation", imgRef},
+			expectOut:   "https://spdx.dev/Document",
+			outContains: true,
+		},
+		{
+			name:      "get type",
+			args:      []string{"image", "get-attestation", imgRef, "--type", "spdx", "--format", "{{range .Attestations}}{{printf \"%s\" .Statement.Predicate}}{{end}}"},
+			expectOut: `{"spdxVersion":"SPDX-2.3"}`,
+		},
+		{
+			name:      "get verified",
+			args:      []string{"image", "get-attestation", imgRef, "--key", signPub, "--format", "{{range .Attestations}}{{.Statement.PredicateType}} {{.Verified}}{{end}}"},
+			expectOut: "https://slsa.dev/provenance/v1 true",
+		},
+		{
+			name:      "get verified type mismatch",
+			args:      []string{"image", "get-attestation", imgRef, "--key", signPub, "--type", "spdx"},
+			expectErr: errs.ErrNotFound,
+		},
+		{
+			name:      "get wrong key",
+			args:      []string{"image", "get-attestation", imgRef, "--key", otherPub},
+			expectErr: errs.ErrNotFound,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := cobraTest(t, &cobraTestOpts{stdin: strings.NewReader(tc.stdin)}, tc.args...)
+			if tc.expectErr != nil {
+				if err == nil {
+					t.Errorf("did not receive expected error: %v", tc.expectErr)
+				} else if !errors.Is(err, tc.expectErr) && err.Error() != tc.expectErr.Error() {
+					t.Errorf("unexpected error, received %v, expected %v", err, tc.expectErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("returned unexpected error: %v", err)
+			}
+			if (!tc.outContains && out != tc.expectOut) || (tc.outContains && !strings.Contains(out, tc.expectOut)) {
+				t.Errorf("unexpected output, expected %s, received %s", tc.expectOut, out)
+			}
+		})
+	}
+}
+
+func TestImageCheckBase(t *testing.T) {
+	repo := "ocidir://../../testdata/testrepo"
+	tt := []struct {
+		name      string
+		args      []string
+		expectErr error
+	}{
+		{
+			name:      "missing annotation",
+			args:      []string{"image", "check-base", repo + ":v1"},
+			expectErr: errs.ErrMissingAnnotation,
+		},
+		{
+			name: "base matches",
+			args: []string{"image", "check-base", repo + ":v2", "--base", repo + ":b1"},
+		},
+		{
+			name: "base matches platform",
+			args: []string{"image", "check-base", repo + ":v2", "--base", repo + ":b1", "--platform", "linux/amd64"},
+		},
+		{
+			name:      "base changed",
+			args:      []string{"image", "check-base", repo + ":v2", "--base", repo + ":b2"},
+			expectErr: errs.ErrMismatch,
+		},
+		{
+			name:      "base digest changed",
+			args:      []string{"image", "check-base", repo + ":v2", "--base", repo + ":b1", "--digest", "sha256:0000000000000000000000000000000000000000000000000000000000000000"},
+			expectErr: errs.ErrMismatch,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := cobraTest(t, nil, tc.args...)
+			if tc.expectErr != nil {
+				if err == nil {
+					t.Errorf("command did not fail")
+				} else if !errors.Is(err, tc.expectErr) {
+					t.Errorf("unexpected error, expected %v, received %v", tc.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error: %v", err)
+			}
+		})
+	}
 }
 
 func TestImageInspect(t *testing.T) {
@@ -179,6 +555,16 @@ func TestImageInspect(t *testing.T) {
 			expectOut:   "linux",
 			outContains: false,
 		},
+		{
+			name:      "platform arch",
+			cmd:       []string{"image", "inspect", srcRef, "--platform", "linux/arm64", "--format", `{{ .GetConfig.Architecture }}`},
+			expectOut: "arm64",
+		},
+		{
+			name:      "missing platform",
+			cmd:       []string{"image", "inspect", srcRef, "--platform", "linux/riscv64"},
+			expectErr: errs.ErrNotFound,
+		},
 		{
 			name:      "invalid ref",
 			cmd:       []string{"image", "inspect", "invalid://ref*format"},
@@ -238,11 +624,26 @@ func TestImageMod(t *testing.T) {
 			cmd:       []string{"image", "mod", srcRef, "--create", modRef, "--layer-add", "tar=../../testdata/layer.tar,dir=../../cmd,platform=linux/amd64"},
 			expectErr: fmt.Errorf(`invalid argument "tar=../../testdata/layer.tar,dir=../../cmd,platform=linux/amd64" for "--layer-add" flag: cannot use dir and tar options together in layer-add`),
 		},
+		{
+			name:      "layer-normalize",
+			cmd:       []string{"image", "mod", srcRef, "--create", modRef, "--layer-normalize=fileMode=644,execMode=755,dirMode=755"},
+			expectOut: modRef,
+		},
+		{
+			name:      "layer-normalize-invalid",
+			cmd:       []string{"image", "mod", srcRef, "--create", modRef, "--layer-normalize=fileMode=999"},
+			expectErr: fmt.Errorf(`invalid argument "fileMode=999" for "--layer-normalize" flag: invalid mode 999 for fileMode: strconv.ParseInt: parsing "999": invalid syntax`),
+		},
 		{
 			name:      "timestamps",
 			cmd:       []string{"image", "mod", srcRef, "--create", modRef, "--time", "set=2000-01-01T00:00:00Z,base-ref=" + baseRef},
 			expectOut: modRef,
 		},
+		{
+			name:      "format",
+			cmd:       []string{"image", "mod", srcRef, "--create", modRef, "--label", "test=format", "--format", "{{.Tag}}"},
+			expectOut: "mod",
+		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
@@ -265,3 +666,225 @@ func TestImageMod(t *testing.T) {
 		})
 	}
 }
+
+func TestImageRateLimit(t *testing.T) {
+	tempDir := t.TempDir()
+	regHandler := olareg.New(oConfig.Config{
+		Storage: oConfig.ConfigStorage{
+			StoreType: oConfig.StoreMem,
+			RootDir:   "../../testdata",
+		},
+	})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("RateLimit-Limit", "100;w=21600")
+		w.Header().Set("RateLimit-Remaining", "5;w=21600")
+		regHandler.ServeHTTP(w, r)
+	}))
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	t.Cleanup(func() {
+		ts.Close()
+		_ = regHandler.Close()
+	})
+	t.Setenv(ConfigEnv, filepath.Join(tempDir, "config.json"))
+	_, err := cobraTest(t, nil, "registry", "set", tsHost, "--tls", "disabled")
+	if err != nil {
+		t.Fatalf("failed to disable TLS for internal registry")
+	}
+	imgRef := tsHost + "/testrepo:v1"
+	tt := []struct {
+		name        string
+		args        []string
+		expectErr   error
+		expectOut   string
+		outContains bool
+	}{
+		{
+			name:        "table",
+			args:        []string{"image", "ratelimit", imgRef},
+			expectOut:   "Window: 6h0m0s",
+			outContains: true,
+		},
+		{
+			name:      "remain",
+			args:      []string{"image", "ratelimit", imgRef, "--format", "{{.Remain}}/{{.Limit}}/{{.Window}}"},
+			expectOut: "5/100/21600",
+		},
+		{
+			name:        "json",
+			args:        []string{"image", "ratelimit", imgRef, "--format", "{{jsonPretty .}}"},
+			expectOut:   `"Remain": 5`,
+			outContains: true,
+		},
+		{
+			name:        "min remain",
+			args:        []string{"image", "ratelimit", imgRef, "--min-remain", "5"},
+			expectOut:   "Remain: 5",
+			outContains: true,
+		},
+		{
+			name:      "below min remain",
+			args:      []string{"image", "ratelimit", imgRef, "--min-remain", "10"},
+			expectErr: errs.ErrHTTPRateLimit,
+		},
+		{
+			name:      "not set",
+			args:      []string{"image", "ratelimit", "ocidir://../../testdata/testrepo:v1", "--min-remain", "10"},
+			expectOut: "Rate limit not provided by the registry",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := cobraTest(t, nil, tc.args...)
+			if tc.expectErr != nil {
+				if err == nil {
+					t.Errorf("did not receive expected error: %v", tc.expectErr)
+				} else if !errors.Is(err, tc.expectErr) && err.Error() != tc.expectErr.Error() {
+					t.Errorf("unexpected error, received %v, expected %v", err, tc.expectErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("returned unexpected error: %v", err)
+			}
+			if (!tc.outContains && out != tc.expectOut) || (tc.outContains && !strings.Contains(out, tc.expectOut)) {
+				t.Errorf("unexpected output, expected %s, received %s", tc.expectOut, out)
+			}
+		})
+	}
+}
+
+func TestImageSign(t *testing.T) {
+	tempDir := t.TempDir()
+	testWriteKeyPair(t, tempDir, "signing")
+	testWriteKeyPair(t, tempDir, "other")
+	signKey := filepath.Join(tempDir, "signing.key")
+	signPub := filepath.Join(tempDir, "signing.pub")
+	otherPub := filepath.Join(tempDir, "other.pub")
+	// the password is requested before the encrypted key is parsed
+	encKey := filepath.Join(tempDir, "cosign.key")
+	err := os.WriteFile(encKey, pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED SIGSTORE PRIVATE KEY", Bytes: []byte("{}")}), 0600)
+	if err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	t.Setenv(CosignPassEnv, "")
+	err = os.Unsetenv(CosignPassEnv)
+	if err != nil {
+		t.Fatalf("failed to unset %s: %v", CosignPassEnv, err)
+	}
+	imgRef := "ocidir://" + tempDir + "/repo:v1"
+	_, err = cobraTest(t, nil, "image", "copy", "ocidir://../../testdata/testrepo:v1", imgRef)
+	if err != nil {
+		t.Fatalf("failed to copy image: %v", err)
+	}
+	dig, err := cobraTest(t, nil, "image", "digest", imgRef)
+	if err != nil {
+		t.Fatalf("failed to get digest: %v", err)
+	}
+	sigTag := "sha256-" + strings.TrimPrefix(dig, "sha256:") + ".sig"
+
+	tt := []struct {
+		name        string
+		args        []string
+		expectErr   error
+		expectOut   string
+		outContains bool
+	}{
+		{
+			name:      "verify unsigned",
+			args:      []string{"image", "verify", "--key", signPub, imgRef},
+			expectErr: errs.ErrNotFound,
+		},
+		{
+			name:      "sign missing key",
+			args:      []string{"image", "sign", imgRef},
+			expectErr: ErrMissingInput,
+		},
+		{
+			name:      "sign encrypted key without password",
+			args:      []string{"image", "sign", "--key", encKey, imgRef},
+			expectErr: ErrMissingInput,
+		},
+		{
+			name:      "sign tag",
+			args:      []string{"image", "sign", "--key", signKey, imgRef},
+			expectOut: "ocidir://" + tempDir + "/repo:" + sigTag,
+		},
+		{
+			name:      "verify tag",
+			args:      []string{"image", "verify", "--key", signPub, imgRef, "--format", "{{len .Signatures}}"},
+			expectOut: "1",
+		},
+		{
+			name:        "sign referrers",
+			args:        []string{"image", "sign", "--key", signKey, "--annotation", "env=prod", "--referrers", imgRef},
+			expectOut:   "ocidir://" + tempDir + "/repo@sha256:",
+			outContains: true,
+		},
+		{
+			name:      "verify both",
+			args:      []string{"image", "verify", "--key", signPub, imgRef, "--format", "{{len .Signatures}}"},
+			expectOut: "2",
+		},
+		{
+			name:      "verify annotation",
+			args:      []string{"image", "verify", "--key", signPub, imgRef, "--format", "{{range .Signatures}}{{index .Payload.Optional \"env\"}}{{end}}"},
+			expectOut: "prod",
+		},
+		{
+			name:        "verify table",
+			args:        []string{"image", "verify", "--key", signPub, imgRef},
+			expectOut:   "Verified 2 signatures",
+			outContains: true,
+		},
+		{
+			name:      "verify wrong key",
+			args:      []string{"image", "verify", "--key", otherPub, imgRef},
+			expectErr: errs.ErrNotFound,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := cobraTest(t, nil, tc.args...)
+			if tc.expectErr != nil {
+				if err == nil {
+					t.Errorf("did not receive expected error: %v", tc.expectErr)
+				} else if !errors.Is(err, tc.expectErr) && err.Error() != tc.expectErr.Error() {
+					t.Errorf("unexpected error, received %v, expected %v", err, tc.expectErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("returned unexpected error: %v", err)
+			}
+			if (!tc.outContains && out != tc.expectOut) || (tc.outContains && !strings.Contains(out, tc.expectOut)) {
+				t.Errorf("unexpected output, expected %s, received %s", tc.expectOut, out)
+			}
+		})
+	}
+}
+
+// testWriteKeyPair generates an ECDSA key, writing the PEM encoded name.key and name.pub files to dir.
+func testWriteKeyPair(t *testing.T, dir, name string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	privDer, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal private key: %v", err)
+	}
+	pubDer, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %v", err)
+	}
+	err = os.WriteFile(filepath.Join(dir, name+".key"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDer}), 0600)
+	if err != nil {
+		t.Fatalf("failed to write private key: %v", err)
+	}
+	err = os.WriteFile(filepath.Join(dir, name+".pub"), pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDer}), 0600)
+	if err != nil {
+		t.Fatalf("failed to write public key: %v", err)
+	}
+}
@@ -40,6 +40,7 @@ type indexCmd struct {
 	mediaType       string
 	platforms       []string
 	refs            []string
+	replace         bool
 	subject         string
 }
 
@@ -59,7 +60,11 @@ func NewIndexCmd(rootOpts *rootCmd) *cobra.Command {
 		Long:    `Add an entry to a manifest list or OCI Index.`,
 		Example: `
 # add arm64 to the v1 image
-regctl index add registry.example.org/repo:v1 --ref registry.example.org/repo:arm64`,
+regctl index add registry.example.org/repo:v1 --ref registry.example.org/repo:arm64
+
+# replace the amd64 image with a new build from another registry
+regctl index add registry.example.org/repo:v1 --replace \
+  --ref build.example.org/repo:amd64-build42`,
 		Args:      cobra.ExactArgs(1),
 		ValidArgs: []string{}, // do not auto complete digests
 		RunE:      indexOpts.runIndexAdd,
@@ -112,8 +117,10 @@ regctl index delete registry.example.org/repo:v1 \
 	indexAddCmd.Flags().StringVar(&indexOpts.descPlatform, "desc-platform", "", "Platform to set in descriptors of new entries")
 	indexAddCmd.Flags().StringArrayVar(&indexOpts.digests, "digest", []string{}, "Digest to add")
 	indexAddCmd.Flags().BoolVar(&indexOpts.incDigestTags, "digest-tags", false, "Include digest tags")
+	indexAddCmd.Flags().StringVar(&indexOpts.format, "format", "", "Format output with go template syntax")
 	indexAddCmd.Flags().BoolVar(&indexOpts.incReferrers, "referrers", false, "Include referrers")
 	indexAddCmd.Flags().StringArrayVar(&indexOpts.refs, "ref", []string{}, "References to add")
+	indexAddCmd.Flags().BoolVar(&indexOpts.replace, "replace", false, "Replace existing entries with the same platform")
 	indexAddCmd.Flags().StringArrayVar(&indexOpts.platforms, "platform", []string{}, "Platforms to include from ref")
 	_ = indexAddCmd.RegisterFlagCompletionFunc("platform", completeArgPlatform)
 
@@ -136,6 +143,7 @@ regctl index delete registry.example.org/repo:v1 \
 	_ = indexCreateCmd.RegisterFlagCompletionFunc("platform", completeArgPlatform)
 
 	indexDeleteCmd.Flags().StringArrayVar(&indexOpts.digests, "digest", []string{}, "Digest to delete")
+	indexDeleteCmd.Flags().StringVar(&indexOpts.format, "format", "", "Format output with go template syntax")
 	indexDeleteCmd.Flags().StringArrayVar(&indexOpts.platforms, "platform", []string{}, "Platform to delete")
 	_ = indexDeleteCmd.RegisterFlagCompletionFunc("platform", completeArgPlatform)
 
@@ -182,6 +190,24 @@ func (indexOpts *indexCmd) runIndexAdd(cmd *cobra.Command, args []string) error
 		return err
 	}
 
+	// remove entries with the same platform
+	if indexOpts.replace {
+		keep := make([]descriptor.Descriptor, 0, len(curDesc))
+		for _, cur := range curDesc {
+			found := false
+			for _, d := range descList {
+				if cur.Platform != nil && d.Platform != nil && platform.Match(*cur.Platform, *d.Platform) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				keep = append(keep, cur)
+			}
+		}
+		curDesc = keep
+	}
+
 	// append list
 	curDesc = append(curDesc, descList...)
 	curDesc = indexDescListRmDup(curDesc)
@@ -338,63 +364,21 @@ func (indexOpts *indexCmd) runIndexDelete(cmd *cobra.Command, args []string) err
 	rc := indexOpts.rootOpts.newRegClient()
 	defer rc.Close(ctx, r)
 
-	// pull existing index
-	m, err := rc.ManifestGet(ctx, r)
-	if err != nil {
-		return err
-	}
-	mi, ok := m.(manifest.Indexer)
-	if !ok {
-		return fmt.Errorf("current manifest is not an index/manifest list, \"%s\": %w", m.GetDescriptor().MediaType, errs.ErrUnsupportedMediaType)
-	}
-	curDesc, err := mi.GetManifestList()
-	if err != nil {
-		return err
-	}
-
-	// for each CLI arg, find and delete matching entries
+	// for each CLI arg, delete matching entries
+	iOpts := []regclient.IndexOpts{}
 	for _, dig := range indexOpts.digests {
-		i := len(curDesc) - 1
-		for i >= 0 {
-			if curDesc[i].Digest.String() == dig {
-				if i < len(curDesc)-1 {
-					curDesc = append(curDesc[:i], curDesc[i+1:]...)
-				} else {
-					curDesc = curDesc[:i]
-				}
-			}
-			i--
-		}
+		iOpts = append(iOpts, regclient.IndexWithoutDigest(digest.Digest(dig)))
 	}
 	for _, platStr := range indexOpts.platforms {
 		plat, err := platform.Parse(platStr)
 		if err != nil {
 			return err
 		}
-		i := len(curDesc) - 1
-		for i >= 0 {
-			if curDesc[i].Platform != nil && platform.Match(plat, *curDesc[i].Platform) {
-				if i < len(curDesc)-1 {
-					curDesc = append(curDesc[:i], curDesc[i+1:]...)
-				} else {
-					curDesc = curDesc[:i]
-				}
-			}
-			i--
-		}
+		iOpts = append(iOpts, regclient.IndexWithoutPlatform(plat))
 	}
 
-	// update manifest
-	err = mi.SetManifestList(curDesc)
-	if err != nil {
-		return err
-	}
-
-	// push the index
-	if r.Tag == "" && r.Digest != "" {
-		r.Digest = m.GetDescriptor().Digest.String()
-	}
-	err = rc.ManifestPut(ctx, r, m)
+	// update and push the index
+	m, err := rc.IndexUpdate(ctx, r, iOpts...)
 	if err != nil {
 		return err
 	}
@@ -58,6 +58,48 @@ func TestIndex(t *testing.T) {
 		t.Errorf("unexpected artifact content, expected: %s, received: %s", artifact64Out, out)
 	}
 
+	// replace the amd64 entry with the image from another tag
+	countBefore, err := cobraTest(t, nil, "manifest", "get", latestRef, "--format", "{{len .Manifests}}")
+	if err != nil {
+		t.Fatalf("failed to get manifest: %v", err)
+	}
+	replaceRef := "ocidir://../../testdata/testrepo:v1"
+	_, err = cobraTest(t, nil, "index", "add", "--ref", replaceRef, "--platform", "linux/amd64", "--replace", latestRef)
+	if err != nil {
+		t.Fatalf("failed to run index add: %v", err)
+	}
+	countAfter, err := cobraTest(t, nil, "manifest", "get", latestRef, "--format", "{{len .Manifests}}")
+	if err != nil {
+		t.Fatalf("failed to get manifest: %v", err)
+	}
+	if countBefore != countAfter {
+		t.Errorf("entry count changed after replace, before %s, after %s", countBefore, countAfter)
+	}
+	digReplace, err := cobraTest(t, nil, "manifest", "head", "--platform", "linux/amd64", replaceRef)
+	if err != nil {
+		t.Fatalf("failed to head manifest: %v", err)
+	}
+	digLatest, err := cobraTest(t, nil, "manifest", "head", "--platform", "linux/amd64", latestRef)
+	if err != nil {
+		t.Fatalf("failed to head manifest: %v", err)
+	}
+	if digReplace != digLatest {
+		t.Errorf("amd64 entry not replaced, expected %s, received %s", digReplace, digLatest)
+	}
+
+	// delete an entry and output the media type of the updated index
+	out, err = cobraTest(t, nil, "index", "delete", "--platform", "linux/arm64", "--format", "{{.Manifest.GetDescriptor.MediaType}}", latestRef)
+	if err != nil {
+		t.Fatalf("failed to run index delete: %v", err)
+	}
+	if out != "application/vnd.oci.image.index.v1+json" {
+		t.Errorf("unexpected output: %s", out)
+	}
+	_, err = cobraTest(t, nil, "manifest", "get", "--platform", "linux/arm64", latestRef)
+	if err == nil {
+		t.Errorf("found deleted linux/arm64 entry")
+	}
+
 	// create an index that itself is an artifact
 	testArtifactType := "application/example.test"
 	out, err = cobraTest(t, nil, "index", "create", artifactRef, "--subject", "latest", "--artifact-type", testArtifactType, "--ref", srcRef)
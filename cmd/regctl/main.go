@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -26,13 +27,21 @@ func main() {
 	godbg.SignalTrace()
 
 	if err := rootTopCmd.ExecuteContext(ctx); err != nil {
+		code := 1
+		var ece *exitCodeError
+		if errors.As(err, &ece) {
+			code = ece.code
+			if ece.err == nil {
+				os.Exit(code)
+			}
+		}
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		// provide tips for common error messages
 		switch {
 		case strings.Contains(err.Error(), "http: server gave HTTP response to HTTPS client"):
 			fmt.Fprintf(os.Stderr, "Try updating your registry with \"regctl registry set --tls disabled <registry>\"\n")
 		}
-		os.Exit(1)
+		os.Exit(code)
 	}
 	os.Exit(0)
 }
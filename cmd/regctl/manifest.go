@@ -1,19 +1,27 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"strconv"
 	"strings"
+	"text/tabwriter"
 
+	"github.com/opencontainers/go-digest"
 	"github.com/spf13/cobra"
 
 	"github.com/regclient/regclient"
 	"github.com/regclient/regclient/internal/diff"
 	"github.com/regclient/regclient/pkg/template"
 	"github.com/regclient/regclient/types/descriptor"
+	"github.com/regclient/regclient/types/errs"
 	"github.com/regclient/regclient/types/manifest"
+	v1 "github.com/regclient/regclient/types/oci/v1"
 	"github.com/regclient/regclient/types/platform"
 	"github.com/regclient/regclient/types/ref"
 	"github.com/regclient/regclient/types/warning"
@@ -25,15 +33,20 @@ type manifestCmd struct {
 	contentType   string
 	diffCtx       int
 	diffFullCtx   bool
+	diffPlatforms []string
+	diffStruct    bool
 	forceTagDeref bool
+	formatDiff    string
 	formatGet     string
 	formatHead    string
 	formatPut     string
 	list          bool
 	platform      string
+	quiet         bool
 	referrers     bool
 	requireDigest bool
 	requireList   bool
+	validate      bool
 }
 
 func NewManifestCmd(rootOpts *rootCmd) *cobra.Command {
@@ -70,9 +83,14 @@ regctl manifest delete --referrers \
 	}
 
 	var manifestDiffCmd = &cobra.Command{
-		Use:   "diff <image_ref> <image_ref>",
+		Use:   "diff <image_ref> [image_ref]",
 		Short: "compare manifests",
-		Long:  `Show the differences between two image manifests`,
+		Long: `Show the differences between two image manifests.
+By default, a line diff of the JSON is output. With --structured, the media
+types, annotations, and descriptors are compared, showing the descriptors
+added, removed, and changed. A single --platform is resolved on both images,
+and a second --platform is resolved on the second image. When both platforms
+are given, a single reference compares two platforms within one index.`,
 		Example: `
 # compare the scratch and alpine images
 regctl manifest diff \
@@ -82,8 +100,16 @@ regctl manifest diff \
 # compare two digests and show the full context
 regctl manifest diff --context-full \
   ghcr.io/regclient/regctl@sha256:9b7057d06ce061cefc7a0b7cb28cad626164e6629a1a4f09cee4b4d400c9aef0 \
-  ghcr.io/regclient/regctl@sha256:4d113b278bd425d094848ba5d7b4d6baca13a2a9d20d265b32bc12020d501002`,
-		Args:              cobra.ExactArgs(2),
+  ghcr.io/regclient/regctl@sha256:4d113b278bd425d094848ba5d7b4d6baca13a2a9d20d265b32bc12020d501002
+
+# compare the descriptors and annotations of two indexes
+regctl manifest diff --structured \
+  ghcr.io/regclient/regctl:latest ghcr.io/regclient/regctl:edge
+
+# compare two platforms within an index
+regctl manifest diff --structured --platform linux/amd64 --platform linux/arm64 \
+  ghcr.io/regclient/regctl:latest`,
+		Args:              cobra.RangeArgs(1, 2),
 		ValidArgsFunction: rootOpts.completeArgTag,
 		RunE:              manifestOpts.runManifestDiff,
 	}
@@ -111,7 +137,9 @@ regctl manifest get golang --platform windows/amd64,osver=10.0.17763.4974`,
 		Use:     "head <image_ref>",
 		Aliases: []string{"digest"},
 		Short:   "http head request for manifest",
-		Long:    `Shows the digest or headers from an http manifest head request.`,
+		Long: `Shows the digest or headers from an http manifest head request.
+With --quiet, nothing is output and the exit code reports if the manifest exists:
+0 when found, 2 when not found, and 1 for any other error.`,
 		Example: `
 # show the digest for an image
 regctl manifest head alpine
@@ -120,7 +148,12 @@ regctl manifest head alpine
 regctl manifest head alpine --platform linux/arm64
 
 # show all headers for the request
-regctl manifest head alpine --format raw-headers`,
+regctl manifest head alpine --format raw-headers
+
+# check if a tag exists, exits 0 if found, 2 if not found, and 1 on other errors
+if regctl manifest head registry.example.org/repo:v1 --quiet; then
+  echo "tag already pushed"
+fi`,
 		Args:              cobra.ExactArgs(1),
 		ValidArgsFunction: rootOpts.completeArgTag,
 		RunE:              manifestOpts.runManifestHead,
@@ -130,12 +163,17 @@ regctl manifest head alpine --format raw-headers`,
 		Use:     "put <image_ref>",
 		Aliases: []string{"push"},
 		Short:   "push manifest or manifest list",
-		Long:    `Pushes a manifest or manifest list to a repository.`,
+		Long: `Pushes a manifest or manifest list to a repository.
+The manifest is read from stdin.
+The media type is detected from the content when --content-type is not set.`,
 		Example: `
 # push an image manifest
 regctl manifest put \
   --content-type application/vnd.oci.image.manifest.v1+json \
-  registry.example.org/repo:v1 <manifest.json`,
+  registry.example.org/repo:v1 <manifest.json
+
+# push a manifest after verifying the referenced blobs and manifests exist
+regctl manifest put --validate registry.example.org/repo:v1 <manifest.json`,
 		Args:              cobra.ExactArgs(1),
 		ValidArgsFunction: rootOpts.completeArgTag,
 		RunE:              manifestOpts.runManifestPut,
@@ -146,6 +184,11 @@ regctl manifest put \
 
 	manifestDiffCmd.Flags().IntVarP(&manifestOpts.diffCtx, "context", "", 3, "Lines of context")
 	manifestDiffCmd.Flags().BoolVarP(&manifestOpts.diffFullCtx, "context-full", "", false, "Show all lines of context")
+	manifestDiffCmd.Flags().StringVar(&manifestOpts.formatDiff, "format", "{{printPretty .}}", "Format structured output with go template syntax, implies --structured")
+	_ = manifestDiffCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+	manifestDiffCmd.Flags().StringArrayVarP(&manifestOpts.diffPlatforms, "platform", "p", []string{}, "Specify platform, repeat to use a different platform for the second image")
+	_ = manifestDiffCmd.RegisterFlagCompletionFunc("platform", completeArgPlatform)
+	manifestDiffCmd.Flags().BoolVar(&manifestOpts.diffStruct, "structured", false, "Compare media types, annotations, and descriptors")
 
 	manifestHeadCmd.Flags().StringVarP(&manifestOpts.formatHead, "format", "", "", "Format output with go template syntax (use \"raw-body\" for the original manifest)")
 	manifestHeadCmd.Flags().BoolVarP(&manifestOpts.list, "list", "", true, "Do not resolve platform from manifest list (enabled by default)")
@@ -153,6 +196,7 @@ regctl manifest put \
 	manifestHeadCmd.Flags().StringVarP(&manifestOpts.platform, "platform", "p", "", "Specify platform (e.g. linux/amd64 or local, requires a get request)")
 	_ = manifestHeadCmd.RegisterFlagCompletionFunc("platform", completeArgPlatform)
 	manifestHeadCmd.Flags().BoolVarP(&manifestOpts.requireDigest, "require-digest", "", false, "Fallback to get request if digest is not received")
+	manifestHeadCmd.Flags().BoolVarP(&manifestOpts.quiet, "quiet", "q", false, "Do not output the manifest, exit 2 if the manifest is not found")
 	manifestHeadCmd.Flags().BoolVarP(&manifestOpts.requireList, "require-list", "", false, "Fail if manifest list is not received")
 
 	manifestGetCmd.Flags().BoolVarP(&manifestOpts.list, "list", "", true, "Deprecated: Output manifest list if available")
@@ -168,6 +212,7 @@ regctl manifest put \
 	_ = manifestPutCmd.RegisterFlagCompletionFunc("content-type", completeArgMediaTypeManifest)
 	manifestPutCmd.Flags().StringVarP(&manifestOpts.formatPut, "format", "", "", "Format output with go template syntax")
 	_ = manifestPutCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+	manifestPutCmd.Flags().BoolVarP(&manifestOpts.validate, "validate", "", false, "Verify the referenced blobs and manifests exist in the repository before pushing")
 
 	manifestTopCmd.AddCommand(manifestDeleteCmd)
 	manifestTopCmd.AddCommand(manifestDiffCmd)
@@ -230,30 +275,62 @@ func (manifestOpts *manifestCmd) runManifestDiff(cmd *cobra.Command, args []stri
 	if w := warning.FromContext(ctx); w == nil {
 		ctx = warning.NewContext(ctx, &warning.Warning{Hook: warning.DefaultHook()})
 	}
+	if len(manifestOpts.diffPlatforms) > 2 {
+		return fmt.Errorf("at most two platforms may be compared")
+	}
+	if len(args) == 1 && len(manifestOpts.diffPlatforms) != 2 {
+		return fmt.Errorf("two platforms are required to compare a single reference")
+	}
 	r1, err := ref.New(args[0])
 	if err != nil {
 		return err
 	}
-	r2, err := ref.New(args[1])
-	if err != nil {
-		return err
+	r2 := r1
+	if len(args) == 2 {
+		r2, err = ref.New(args[1])
+		if err != nil {
+			return err
+		}
+	}
+	mOpts1 := []regclient.ManifestOpts{}
+	mOpts2 := []regclient.ManifestOpts{}
+	for i, pStr := range manifestOpts.diffPlatforms {
+		p, err := platform.Parse(pStr)
+		if err != nil {
+			return fmt.Errorf("failed to parse platform %s: %w", pStr, err)
+		}
+		if i == 0 {
+			mOpts1 = append(mOpts1, regclient.WithManifestPlatform(p))
+		}
+		if i == len(manifestOpts.diffPlatforms)-1 {
+			mOpts2 = append(mOpts2, regclient.WithManifestPlatform(p))
+		}
 	}
 
 	rc := manifestOpts.rootOpts.newRegClient()
 
 	manifestOpts.rootOpts.log.Debug("Manifest diff",
 		slog.String("ref1", r1.CommonName()),
-		slog.String("ref2", r2.CommonName()))
+		slog.String("ref2", r2.CommonName()),
+		slog.Any("platforms", manifestOpts.diffPlatforms))
 
-	m1, err := rc.ManifestGet(ctx, r1)
+	m1, err := rc.ManifestGet(ctx, r1, mOpts1...)
 	if err != nil {
 		return err
 	}
-	m2, err := rc.ManifestGet(ctx, r2)
+	m2, err := rc.ManifestGet(ctx, r2, mOpts2...)
 	if err != nil {
 		return err
 	}
 
+	if manifestOpts.diffStruct || flagChanged(cmd, "format") {
+		result, err := manifestDiffStruct(r1, r2, m1, m2)
+		if err != nil {
+			return err
+		}
+		return template.Writer(cmd.OutOrStdout(), manifestOpts.formatDiff, result)
+	}
+
 	m1Json, err := json.MarshalIndent(m1, "", "  ")
 	if err != nil {
 		return err
@@ -267,8 +344,279 @@ func (manifestOpts *manifestCmd) runManifestDiff(cmd *cobra.Command, args []stri
 
 	_, err = fmt.Fprintln(cmd.OutOrStdout(), strings.Join(mDiff, "\n"))
 	return err
-	// TODO: support templating
-	// return template.Writer(cmd.OutOrStdout(), manifestOpts.format, mDiff)
+}
+
+type manifestDiffResult struct {
+	Ref1        ref.Ref             `json:"ref1"`
+	Ref2        ref.Ref             `json:"ref2"`
+	Digest1     digest.Digest       `json:"digest1"`
+	Digest2     digest.Digest       `json:"digest2"`
+	Fields      []manifestDiffField `json:"fields"`
+	Annotations []manifestDiffField `json:"annotations"`
+	Descriptors []manifestDiffDesc  `json:"descriptors"`
+}
+
+type manifestDiffField struct {
+	Name    string `json:"name"`
+	Old     string `json:"old,omitempty"`
+	New     string `json:"new,omitempty"`
+	Added   bool   `json:"added,omitempty"`
+	Removed bool   `json:"removed,omitempty"`
+}
+
+type manifestDiffDesc struct {
+	Section string                 `json:"section"`
+	Change  string                 `json:"change"`
+	Old     *descriptor.Descriptor `json:"old,omitempty"`
+	New     *descriptor.Descriptor `json:"new,omitempty"`
+	Fields  []manifestDiffField    `json:"fields,omitempty"`
+}
+
+// manifestDiffFields contains the values compared from each manifest.
+type manifestDiffFields struct {
+	mediaType    string
+	artifactType string
+	annotations  map[string]string
+	config       *descriptor.Descriptor
+	subject      *descriptor.Descriptor
+	layers       []descriptor.Descriptor
+	manifests    []descriptor.Descriptor
+}
+
+func manifestDiffGetFields(m manifest.Manifest) (manifestDiffFields, error) {
+	mf := manifestDiffFields{
+		mediaType: m.GetDescriptor().MediaType,
+	}
+	if ma, ok := m.(manifest.Annotator); ok {
+		annotations, err := ma.GetAnnotations()
+		if err != nil {
+			return mf, err
+		}
+		mf.annotations = annotations
+	}
+	if ms, ok := m.(manifest.Subjecter); ok {
+		subject, err := ms.GetSubject()
+		if err != nil {
+			return mf, err
+		}
+		mf.subject = subject
+	}
+	switch orig := m.GetOrig().(type) {
+	case v1.Manifest:
+		mf.artifactType = orig.ArtifactType
+	case v1.Index:
+		mf.artifactType = orig.ArtifactType
+	}
+	if mi, ok := m.(manifest.Indexer); ok {
+		dl, err := mi.GetManifestList()
+		if err != nil {
+			return mf, err
+		}
+		mf.manifests = dl
+	}
+	if mi, ok := m.(manifest.Imager); ok {
+		cd, err := mi.GetConfig()
+		if err == nil {
+			mf.config = &cd
+		}
+		dl, err := mi.GetLayers()
+		if err == nil {
+			mf.layers = dl
+		}
+	}
+	return mf, nil
+}
+
+// manifestDiffStruct compares the structure of two manifests.
+func manifestDiffStruct(r1, r2 ref.Ref, m1, m2 manifest.Manifest) (manifestDiffResult, error) {
+	result := manifestDiffResult{
+		Ref1:        r1,
+		Ref2:        r2,
+		Digest1:     m1.GetDescriptor().Digest,
+		Digest2:     m2.GetDescriptor().Digest,
+		Fields:      []manifestDiffField{},
+		Annotations: []manifestDiffField{},
+		Descriptors: []manifestDiffDesc{},
+	}
+	mf1, err := manifestDiffGetFields(m1)
+	if err != nil {
+		return result, err
+	}
+	mf2, err := manifestDiffGetFields(m2)
+	if err != nil {
+		return result, err
+	}
+	result.Fields = append(result.Fields, manifestDiffValue("mediaType", mf1.mediaType, mf2.mediaType)...)
+	result.Fields = append(result.Fields, manifestDiffValue("artifactType", mf1.artifactType, mf2.artifactType)...)
+	result.Annotations = manifestDiffMap(mf1.annotations, mf2.annotations)
+	for _, single := range []struct {
+		section      string
+		desc1, desc2 *descriptor.Descriptor
+	}{
+		{section: "config", desc1: mf1.config, desc2: mf2.config},
+		{section: "subject", desc1: mf1.subject, desc2: mf2.subject},
+	} {
+		if dd, ok := manifestDiffDescPair(single.section, single.desc1, single.desc2); ok {
+			result.Descriptors = append(result.Descriptors, dd)
+		}
+	}
+	result.Descriptors = append(result.Descriptors, manifestDiffDescList("layers", mf1.layers, mf2.layers, false)...)
+	result.Descriptors = append(result.Descriptors, manifestDiffDescList("manifests", mf1.manifests, mf2.manifests, true)...)
+	return result, nil
+}
+
+func manifestDiffValue(name, val1, val2 string) []manifestDiffField {
+	if val1 == val2 {
+		return []manifestDiffField{}
+	}
+	return []manifestDiffField{{Name: name, Old: val1, New: val2, Added: val1 == "", Removed: val2 == ""}}
+}
+
+// manifestDiffMap returns the changes between two maps sorted by key, using the same comparison as image diff.
+func manifestDiffMap(m1, m2 map[string]string) []manifestDiffField {
+	result := []manifestDiffField{}
+	for _, c := range imageDiffMap("", m1, m2) {
+		result = append(result, manifestDiffField{Name: c.Key, Old: c.Old, New: c.New, Added: c.Added, Removed: c.Removed})
+	}
+	return result
+}
+
+// manifestDiffDescPair compares two descriptors in the same position, returning false when they match.
+func manifestDiffDescPair(section string, d1, d2 *descriptor.Descriptor) (manifestDiffDesc, bool) {
+	dd := manifestDiffDesc{Section: section, Old: d1, New: d2}
+	switch {
+	case d1 == nil && d2 == nil:
+		return dd, false
+	case d1 == nil:
+		dd.Change = "added"
+		return dd, true
+	case d2 == nil:
+		dd.Change = "removed"
+		return dd, true
+	}
+	fields := []manifestDiffField{}
+	fields = append(fields, manifestDiffValue("mediaType", d1.MediaType, d2.MediaType)...)
+	fields = append(fields, manifestDiffValue("digest", d1.Digest.String(), d2.Digest.String())...)
+	fields = append(fields, manifestDiffValue("size", strconv.FormatInt(d1.Size, 10), strconv.FormatInt(d2.Size, 10))...)
+	fields = append(fields, manifestDiffValue("artifactType", d1.ArtifactType, d2.ArtifactType)...)
+	p1, p2 := "", ""
+	if d1.Platform != nil {
+		p1 = d1.Platform.String()
+	}
+	if d2.Platform != nil {
+		p2 = d2.Platform.String()
+	}
+	fields = append(fields, manifestDiffValue("platform", p1, p2)...)
+	for _, f := range manifestDiffMap(d1.Annotations, d2.Annotations) {
+		f.Name = "annotation " + f.Name
+		fields = append(fields, f)
+	}
+	if len(fields) == 0 {
+		return dd, false
+	}
+	dd.Change = "changed"
+	dd.Fields = fields
+	return dd, true
+}
+
+// manifestDiffDescList compares lists of descriptors.
+// Entries are matched by digest, and entries in a manifest list are also matched by platform.
+func manifestDiffDescList(section string, dl1, dl2 []descriptor.Descriptor, byPlatform bool) []manifestDiffDesc {
+	result := []manifestDiffDesc{}
+	matched2 := make([]bool, len(dl2))
+	unmatched1 := []int{}
+	for i := range dl1 {
+		found := false
+		for j := range dl2 {
+			if !matched2[j] && dl1[i].Digest == dl2[j].Digest {
+				matched2[j] = true
+				found = true
+				if dd, ok := manifestDiffDescPair(section, &dl1[i], &dl2[j]); ok {
+					result = append(result, dd)
+				}
+				break
+			}
+		}
+		if !found {
+			unmatched1 = append(unmatched1, i)
+		}
+	}
+	for _, i := range unmatched1 {
+		found := false
+		// attestations use an unknown platform and are only matched by digest
+		if byPlatform && dl1[i].Platform != nil && dl1[i].Platform.OS != "unknown" {
+			for j := range dl2 {
+				if !matched2[j] && dl2[j].Platform != nil && dl1[i].Platform.String() == dl2[j].Platform.String() {
+					matched2[j] = true
+					found = true
+					if dd, ok := manifestDiffDescPair(section, &dl1[i], &dl2[j]); ok {
+						result = append(result, dd)
+					}
+					break
+				}
+			}
+		}
+		if !found {
+			result = append(result, manifestDiffDesc{Section: section, Change: "removed", Old: &dl1[i]})
+		}
+	}
+	for j := range dl2 {
+		if !matched2[j] {
+			result = append(result, manifestDiffDesc{Section: section, Change: "added", New: &dl2[j]})
+		}
+	}
+	return result
+}
+
+// MarshalPretty is used for printPretty template formatting
+func (result manifestDiffResult) MarshalPretty() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	tw := tabwriter.NewWriter(buf, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(tw, "Manifest 1:\t%s\t%s\n", result.Ref1.CommonName(), result.Digest1.String())
+	fmt.Fprintf(tw, "Manifest 2:\t%s\t%s\n", result.Ref2.CommonName(), result.Digest2.String())
+	if len(result.Fields) == 0 && len(result.Annotations) == 0 && len(result.Descriptors) == 0 {
+		fmt.Fprintf(tw, "\t\n")
+		fmt.Fprintf(tw, "No differences\t\n")
+	}
+	for _, f := range result.Fields {
+		fmt.Fprintf(tw, "\t\n")
+		fmt.Fprintf(tw, "%s:\t%s\n", f.Name, manifestDiffFieldPretty(f))
+	}
+	if len(result.Annotations) > 0 {
+		fmt.Fprintf(tw, "\t\n")
+		fmt.Fprintf(tw, "Annotations:\t\n")
+		for _, f := range result.Annotations {
+			fmt.Fprintf(tw, "  %s:\t%s\n", f.Name, manifestDiffFieldPretty(f))
+		}
+	}
+	for _, dd := range result.Descriptors {
+		fmt.Fprintf(tw, "\t\n")
+		d := dd.New
+		if d == nil {
+			d = dd.Old
+		}
+		name := d.Digest.String()
+		if d.Platform != nil {
+			name = name + " [" + d.Platform.String() + "]"
+		}
+		fmt.Fprintf(tw, "%s %s:\t%s\n", dd.Section, dd.Change, name)
+		for _, f := range dd.Fields {
+			fmt.Fprintf(tw, "  %s:\t%s\n", f.Name, manifestDiffFieldPretty(f))
+		}
+	}
+	err := tw.Flush()
+	return buf.Bytes(), err
+}
+
+func manifestDiffFieldPretty(f manifestDiffField) string {
+	switch {
+	case f.Added:
+		return "added " + f.New
+	case f.Removed:
+		return "removed " + f.Old
+	default:
+		return f.Old + " -> " + f.New
+	}
 }
 
 func (manifestOpts *manifestCmd) runManifestHead(cmd *cobra.Command, args []string) error {
@@ -305,6 +653,12 @@ func (manifestOpts *manifestCmd) runManifestHead(cmd *cobra.Command, args []stri
 	}
 
 	m, err := rc.ManifestHead(ctx, r, mOpts...)
+	if manifestOpts.quiet {
+		if err != nil && errors.Is(err, errs.ErrNotFound) {
+			return &exitCodeError{code: 2}
+		}
+		return err
+	}
 	if err != nil {
 		return err
 	}
@@ -388,12 +742,21 @@ func (manifestOpts *manifestCmd) runManifestPut(cmd *cobra.Command, args []strin
 	}
 	rcM, err := manifest.New(opts...)
 	if err != nil {
+		if manifestOpts.contentType == "" && errors.Is(err, errs.ErrUnsupportedMediaType) {
+			return fmt.Errorf("unable to detect the media type, use --content-type to specify it: %w", err)
+		}
 		return err
 	}
 	if manifestOpts.byDigest {
 		r.Tag = ""
 		r.Digest = rcM.GetDescriptor().Digest.String()
 	}
+	if manifestOpts.validate {
+		err = manifestOpts.putValidate(ctx, rc, r, rcM)
+		if err != nil {
+			return err
+		}
+	}
 
 	err = rc.ManifestPut(ctx, r, rcM)
 	if err != nil {
@@ -410,3 +773,41 @@ func (manifestOpts *manifestCmd) runManifestPut(cmd *cobra.Command, args []strin
 	}
 	return template.Writer(cmd.OutOrStdout(), manifestOpts.formatPut, result)
 }
+
+// putValidate verifies the descriptors in a manifest exist in the target repository.
+func (manifestOpts *manifestCmd) putValidate(ctx context.Context, rc *regclient.RegClient, r ref.Ref, m manifest.Manifest) error {
+	if mi, ok := m.(manifest.Indexer); ok {
+		dl, err := mi.GetManifestList()
+		if err != nil {
+			return err
+		}
+		for _, d := range dl {
+			_, err = rc.ManifestHead(ctx, r.SetDigest(d.Digest.String()))
+			if err != nil {
+				return fmt.Errorf("referenced manifest %s not found in %s: %w", d.Digest.String(), r.CommonName(), err)
+			}
+		}
+	}
+	if mi, ok := m.(manifest.Imager); ok {
+		dl := []descriptor.Descriptor{}
+		cd, err := mi.GetConfig()
+		if err == nil {
+			dl = append(dl, cd)
+		} else if !errors.Is(err, errs.ErrUnsupportedMediaType) {
+			return err
+		}
+		ld, err := mi.GetLayers()
+		if err != nil {
+			return err
+		}
+		dl = append(dl, ld...)
+		for _, d := range dl {
+			rdr, err := rc.BlobHead(ctx, r, d)
+			if err != nil {
+				return fmt.Errorf("referenced blob %s not found in %s: %w", d.Digest.String(), r.CommonName(), err)
+			}
+			_ = rdr.Close()
+		}
+	}
+	return nil
+}
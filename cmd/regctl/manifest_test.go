@@ -1,11 +1,15 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
 	"strings"
 	"testing"
 
+	"github.com/regclient/regclient/internal/copyfs"
 	"github.com/regclient/regclient/types/errs"
 )
 
@@ -49,6 +53,100 @@ func TestManifestHead(t *testing.T) {
 			args:      []string{"manifest", "head", "ocidir://../../testdata/testrepo:v1", "--platform", "linux/unknown"},
 			expectErr: errs.ErrNotFound,
 		},
+		{
+			name:      "Quiet found",
+			args:      []string{"manifest", "head", "ocidir://../../testdata/testrepo:v1", "--quiet"},
+			expectOut: "",
+		},
+		{
+			name:      "Quiet missing",
+			args:      []string{"manifest", "head", "ocidir://../../testdata/testrepo:missing", "--quiet"},
+			expectErr: &exitCodeError{code: 2},
+		},
+		{
+			name:      "Quiet invalid ref",
+			args:      []string{"manifest", "head", "invalid*ref", "--quiet"},
+			expectErr: errs.ErrInvalidReference,
+		},
+		{
+			name:      "Image digest format",
+			args:      []string{"image", "digest", "ocidir://../../testdata/testrepo:v1", "--format", "{{.GetDescriptor.MediaType}}"},
+			expectOut: "application/vnd.oci.image.index.v1+json",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := cobraTest(t, nil, tc.args...)
+			if tc.expectErr != nil {
+				if err == nil {
+					t.Errorf("did not receive expected error: %v", tc.expectErr)
+				} else if !errors.Is(err, tc.expectErr) && err.Error() != tc.expectErr.Error() {
+					t.Errorf("unexpected error, received %v, expected %v", err, tc.expectErr)
+				}
+				var expectECE, ece *exitCodeError
+				if errors.As(tc.expectErr, &expectECE) && (!errors.As(err, &ece) || ece.code != expectECE.code) {
+					t.Errorf("unexpected exit code, received %v, expected %d", err, expectECE.code)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("returned unexpected error: %v", err)
+			}
+			if (!tc.outContains && out != tc.expectOut) || (tc.outContains && !strings.Contains(out, tc.expectOut)) {
+				t.Errorf("unexpected output, expected %s, received %s", tc.expectOut, out)
+			}
+		})
+	}
+
+}
+
+func TestManifestDiff(t *testing.T) {
+	ref1 := "ocidir://../../testdata/testrepo:v1"
+	ref3 := "ocidir://../../testdata/testrepo:v3"
+	tt := []struct {
+		name        string
+		args        []string
+		expectErr   error
+		expectOut   string
+		outContains bool
+	}{
+		{
+			name:        "Line diff",
+			args:        []string{"manifest", "diff", ref1, ref3},
+			expectOut:   `+     "org.example.version": "v3"`,
+			outContains: true,
+		},
+		{
+			name:        "Structured",
+			args:        []string{"manifest", "diff", "--structured", ref1, ref3},
+			expectOut:   "manifests added:",
+			outContains: true,
+		},
+		{
+			name:      "Structured annotations",
+			args:      []string{"manifest", "diff", ref1, ref3, "--format", `{{range .Annotations}}{{if eq .Name "org.example.version"}}{{.Old}} {{.New}}{{end}}{{end}}`},
+			expectOut: "v1 v3",
+		},
+		{
+			name:      "Structured same",
+			args:      []string{"manifest", "diff", ref1, ref1, "--format", "{{len .Fields}} {{len .Annotations}} {{len .Descriptors}}"},
+			expectOut: "0 0 0",
+		},
+		{
+			name:      "Structured added platforms",
+			args:      []string{"manifest", "diff", ref1, ref3, "--format", `{{range .Descriptors}}{{if eq .Change "added"}}{{.New.Platform}} {{end}}{{end}}`},
+			expectOut: "linux/arm/v7 linux/arm/v6",
+		},
+		{
+			name:      "Two platforms",
+			args:      []string{"manifest", "diff", ref1, "--platform", "linux/amd64", "--platform", "linux/arm64", "--format", "{{range .Descriptors}}{{.Section}} {{.Change}}{{end}}"},
+			expectOut: "config changed",
+		},
+		{
+			name:      "Single ref without platforms",
+			args:      []string{"manifest", "diff", ref1},
+			expectErr: fmt.Errorf("two platforms are required to compare a single reference"),
+		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
@@ -69,5 +167,102 @@ func TestManifestHead(t *testing.T) {
 			}
 		})
 	}
+}
+
+func TestManifestPut(t *testing.T) {
+	tmpDir := t.TempDir()
+	err := copyfs.Copy(tmpDir+"/testrepo", "../../testdata/testrepo")
+	if err != nil {
+		t.Fatalf("failed to copy testrepo: %v", err)
+	}
+	repo := "ocidir://" + tmpDir + "/testrepo"
+	raw, err := cobraTest(t, nil, "manifest", "get", "--platform", "linux/amd64", "--format", "raw-body", repo+":v1")
+	if err != nil {
+		t.Fatalf("failed to get manifest: %v", err)
+	}
+	// remove the media type to verify it is detected from the content
+	mMap := map[string]interface{}{}
+	err = json.Unmarshal([]byte(raw), &mMap)
+	if err != nil {
+		t.Fatalf("failed to parse manifest: %v", err)
+	}
+	delete(mMap, "mediaType")
+	rawDuck, err := json.Marshal(mMap)
+	if err != nil {
+		t.Fatalf("failed to marshal manifest: %v", err)
+	}
+	mMap["layers"] = []interface{}{
+		map[string]interface{}{
+			"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip",
+			"size":      1234,
+			"digest":    "sha256:0000000000000000000000000000000000000000000000000000000000000000",
+		},
+	}
+	rawMissing, err := json.Marshal(mMap)
+	if err != nil {
+		t.Fatalf("failed to marshal manifest: %v", err)
+	}
 
+	tt := []struct {
+		name        string
+		args        []string
+		stdin       []byte
+		expectErr   error
+		expectOut   string
+		outContains bool
+	}{
+		{
+			name:  "Put",
+			args:  []string{"manifest", "put", repo + ":put1"},
+			stdin: []byte(raw),
+		},
+		{
+			name:      "Detect media type",
+			args:      []string{"manifest", "put", "--validate", "--format", "{{.Manifest.GetDescriptor.MediaType}}", repo + ":put2"},
+			stdin:     rawDuck,
+			expectOut: "application/vnd.oci.image.manifest.v1+json",
+		},
+		{
+			name:        "By digest",
+			args:        []string{"manifest", "put", "--by-digest", repo},
+			stdin:       rawDuck,
+			expectOut:   "sha256:",
+			outContains: true,
+		},
+		{
+			name:      "Missing layer",
+			args:      []string{"manifest", "put", "--validate", repo + ":put3"},
+			stdin:     rawMissing,
+			expectErr: fs.ErrNotExist,
+		},
+		{
+			name:      "Unknown media type",
+			args:      []string{"manifest", "put", repo + ":put4"},
+			stdin:     []byte(`{"schemaVersion": 2}`),
+			expectErr: errs.ErrUnsupportedMediaType,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := cobraTest(t, &cobraTestOpts{stdin: bytes.NewReader(tc.stdin)}, tc.args...)
+			if tc.expectErr != nil {
+				if err == nil {
+					t.Errorf("did not receive expected error: %v", tc.expectErr)
+				} else if !errors.Is(err, tc.expectErr) && err.Error() != tc.expectErr.Error() {
+					t.Errorf("unexpected error, received %v, expected %v", err, tc.expectErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("returned unexpected error: %v", err)
+			}
+			if (!tc.outContains && out != tc.expectOut) || (tc.outContains && !strings.Contains(out, tc.expectOut)) {
+				t.Errorf("unexpected output, expected %s, received %s", tc.expectOut, out)
+			}
+		})
+	}
+	_, err = cobraTest(t, nil, "manifest", "head", repo+":put3")
+	if !errors.Is(err, errs.ErrNotFound) {
+		t.Errorf("manifest with a missing layer was pushed, err: %v", err)
+	}
 }
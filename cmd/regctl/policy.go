@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/regclient/regclient"
+	"github.com/regclient/regclient/internal/cosign"
+	"github.com/regclient/regclient/internal/intoto"
+	"github.com/regclient/regclient/types/descriptor"
+	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/ref"
+)
+
+// imagePolicy is the policy file used by "regctl image verify --policy".
+type imagePolicy struct {
+	// RequireDigest requires the image reference to include a digest.
+	RequireDigest bool `yaml:"requireDigest" json:"requireDigest"`
+	// AllowedRegistries limits the registry, or registry/repository, to a list of glob patterns.
+	AllowedRegistries []string `yaml:"allowedRegistries" json:"allowedRegistries"`
+	// MaxAge is the maximum time since the image was created, e.g. "90d" or "12h".
+	MaxAge string `yaml:"maxAge" json:"maxAge"`
+	// Signatures must each have a valid signature.
+	Signatures []imagePolicySignature `yaml:"signatures" json:"signatures"`
+	// Attestations must each have a matching attestation.
+	Attestations []imagePolicyAttestation `yaml:"attestations" json:"attestations"`
+}
+
+type imagePolicySignature struct {
+	// Key is a file with the PEM encoded public key, relative to the policy file.
+	Key string `yaml:"key" json:"key"`
+}
+
+type imagePolicyAttestation struct {
+	// PredicateType is a URI or short name of the predicate type.
+	PredicateType string `yaml:"predicateType" json:"predicateType"`
+	// Key is an optional public key that must have signed the attestation, relative to the policy file.
+	Key string `yaml:"key" json:"key"`
+}
+
+type imagePolicyCheck struct {
+	Name    string
+	Pass    bool
+	Message string
+}
+
+// loadImagePolicy reads and validates a policy file, resolving key files relative to the policy.
+func loadImagePolicy(filename string) (*imagePolicy, error) {
+	//#nosec G304 command is run by a user accessing their own files
+	fh, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open policy %s: %w", filename, err)
+	}
+	defer fh.Close()
+	policy := imagePolicy{}
+	// yaml also parses json policies, unknown fields in either are rejected
+	dec := yaml.NewDecoder(fh)
+	dec.KnownFields(true)
+	err = dec.Decode(&policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse policy %s: %w%.0w", filename, err, ErrInvalidInput)
+	}
+	// an empty policy would pass every image
+	if !policy.RequireDigest && len(policy.AllowedRegistries) == 0 && policy.MaxAge == "" && len(policy.Signatures) == 0 && len(policy.Attestations) == 0 {
+		return nil, fmt.Errorf("policy %s does not contain any checks%.0w", filename, ErrMissingInput)
+	}
+	if policy.MaxAge != "" {
+		var age ageFlag
+		err = age.Set(policy.MaxAge)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maxAge in policy %s: %w%.0w", filename, err, ErrInvalidInput)
+		}
+	}
+	dir := filepath.Dir(filename)
+	for i := range policy.Signatures {
+		if policy.Signatures[i].Key == "" {
+			return nil, fmt.Errorf("signature %d in policy %s is missing a key%.0w", i, filename, ErrMissingInput)
+		}
+		if !filepath.IsAbs(policy.Signatures[i].Key) {
+			policy.Signatures[i].Key = filepath.Join(dir, policy.Signatures[i].Key)
+		}
+	}
+	for i := range policy.Attestations {
+		if policy.Attestations[i].PredicateType == "" {
+			return nil, fmt.Errorf("attestation %d in policy %s is missing a predicateType%.0w", i, filename, ErrMissingInput)
+		}
+		if policy.Attestations[i].Key != "" && !filepath.IsAbs(policy.Attestations[i].Key) {
+			policy.Attestations[i].Key = filepath.Join(dir, policy.Attestations[i].Key)
+		}
+	}
+	return &policy, nil
+}
+
+// loadPublicKey reads a PEM encoded public key from a file.
+func loadPublicKey(filename string) (crypto.PublicKey, error) {
+	//#nosec G304 command is run by a user accessing their own files
+	keyBytes, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %s: %w", filename, err)
+	}
+	return cosign.LoadPublicKey(keyBytes)
+}
+
+// verifyPolicy adds the result of each policy check to the result.
+// Errors are only returned when a check cannot be run, a failed check is reported in the result.
+func (imageOpts *imageCmd) verifyPolicy(ctx context.Context, rc *regclient.RegClient, r ref.Ref, mDesc descriptor.Descriptor, policy imagePolicy, result *imageVerifyResult) error {
+	if policy.RequireDigest {
+		c := imagePolicyCheck{Name: "digest", Pass: r.Digest != ""}
+		if c.Pass {
+			c.Message = "reference is pinned to " + r.Digest
+		} else {
+			c.Message = "reference is not pinned to a digest"
+		}
+		result.Checks = append(result.Checks, c)
+	}
+	if len(policy.AllowedRegistries) > 0 {
+		c := imagePolicyCheck{Name: "registry", Message: r.Registry + " is not an allowed registry"}
+		for _, pattern := range policy.AllowedRegistries {
+			match, err := path.Match(pattern, r.Registry)
+			if !match && err == nil && strings.Contains(pattern, "/") {
+				match, err = path.Match(pattern, r.Registry+"/"+r.Repository)
+			}
+			if err != nil {
+				return fmt.Errorf("invalid allowed registry %s: %w", pattern, err)
+			}
+			if match {
+				c.Pass = true
+				c.Message = "matched " + pattern
+				break
+			}
+		}
+		result.Checks = append(result.Checks, c)
+	}
+	if policy.MaxAge != "" {
+		var maxAge ageFlag
+		_ = maxAge.Set(policy.MaxAge)
+		c := imagePolicyCheck{Name: "age"}
+		iOpts := []regclient.ImageOpts{}
+		if imageOpts.platform != "" {
+			iOpts = append(iOpts, regclient.ImageWithPlatform(imageOpts.platform))
+		}
+		conf, err := rc.ImageConfig(ctx, r.SetDigest(mDesc.Digest.String()), iOpts...)
+		if err != nil {
+			return fmt.Errorf("failed to get image config: %w", err)
+		}
+		created := conf.GetConfig().Created
+		switch {
+		case created == nil || created.IsZero():
+			c.Message = "image created time is not set"
+		case time.Since(*created) > time.Duration(maxAge):
+			c.Message = fmt.Sprintf("image was created %s, older than %s", created.UTC().Format(time.RFC3339), policy.MaxAge)
+		default:
+			c.Pass = true
+			c.Message = fmt.Sprintf("image was created %s", created.UTC().Format(time.RFC3339))
+		}
+		result.Checks = append(result.Checks, c)
+	}
+	for _, ps := range policy.Signatures {
+		pub, err := loadPublicKey(ps.Key)
+		if err != nil {
+			return err
+		}
+		sigs, err := imageOpts.findSignatures(ctx, rc, r, mDesc, pub)
+		if err != nil {
+			return err
+		}
+		c := imagePolicyCheck{Name: "signature", Pass: len(sigs) > 0}
+		if c.Pass {
+			c.Message = fmt.Sprintf("%d valid signatures for %s", len(sigs), ps.Key)
+		} else {
+			c.Message = "no valid signatures for " + ps.Key
+		}
+		result.Checks = append(result.Checks, c)
+		result.Signatures = append(result.Signatures, sigs...)
+	}
+	if len(policy.Attestations) > 0 {
+		rDig := r.SetDigest(mDesc.Digest.String())
+		rl, err := rc.ReferrerList(ctx, rDig)
+		if err != nil {
+			return fmt.Errorf("failed to list referrers of %s: %w", rDig.CommonName(), err)
+		}
+		for _, pa := range policy.Attestations {
+			predType := imageAttestType(pa.PredicateType)
+			var pub crypto.PublicKey
+			if pa.Key != "" {
+				pub, err = loadPublicKey(pa.Key)
+				if err != nil {
+					return err
+				}
+			}
+			c := imagePolicyCheck{Name: "attestation", Message: "no attestation found for " + predType}
+			if pub != nil {
+				c.Message = "no attestation signed by " + pa.Key + " found for " + predType
+			}
+			for _, d := range rl.Descriptors {
+				if d.ArtifactType != intoto.MediaTypeStatement && d.ArtifactType != intoto.MediaTypeDSSE {
+					continue
+				}
+				if pt, ok := d.Annotations[intoto.AnnotationPredicateType]; ok && pt != predType {
+					continue
+				}
+				if imageOpts.policyAttestation(ctx, rc, r.SetDigest(d.Digest.String()), mDesc, predType, pub) {
+					c.Pass = true
+					c.Message = "found " + predType
+					break
+				}
+			}
+			result.Checks = append(result.Checks, c)
+		}
+	}
+	return nil
+}
+
+// policyAttestation returns true when the referrer contains a matching attestation for the image.
+func (imageOpts *imageCmd) policyAttestation(ctx context.Context, rc *regclient.RegClient, rAtt ref.Ref, mDesc descriptor.Descriptor, predType string, pub crypto.PublicKey) bool {
+	m, err := rc.ManifestGet(ctx, rAtt)
+	if err != nil {
+		imageOpts.rootOpts.log.Warn("Failed to get attestation",
+			slog.String("attestation", rAtt.CommonName()),
+			slog.String("err", err.Error()))
+		return false
+	}
+	mi, ok := m.(manifest.Imager)
+	if !ok {
+		return false
+	}
+	layers, err := mi.GetLayers()
+	if err != nil {
+		return false
+	}
+	for _, l := range layers {
+		if l.MediaType != intoto.MediaTypeStatement && l.MediaType != intoto.MediaTypeDSSE {
+			continue
+		}
+		att, err := getAttestation(ctx, rc, rAtt, l, pub)
+		if err != nil {
+			imageOpts.rootOpts.log.Info("Skipping attestation",
+				slog.String("attestation", rAtt.CommonName()),
+				slog.String("layer", l.Digest.String()),
+				slog.String("err", err.Error()))
+			continue
+		}
+		if att.Statement.PredicateType == predType && att.Statement.HasSubject(mDesc.Digest) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImageVerifyPolicy(t *testing.T) {
+	tempDir := t.TempDir()
+	testWriteKeyPair(t, tempDir, "signing")
+	testWriteKeyPair(t, tempDir, "other")
+	predFile := filepath.Join(tempDir, "provenance.json")
+	err := os.WriteFile(predFile, []byte(`{"builder":{"id":"test"}}`), 0600)
+	if err != nil {
+		t.Fatalf("failed to write predicate: %v", err)
+	}
+	imgRef := "ocidir://" + tempDir + "/repo:v1"
+	_, err = cobraTest(t, nil, "image", "copy", "ocidir://../../testdata/testrepo:v1", imgRef)
+	if err != nil {
+		t.Fatalf("failed to copy image: %v", err)
+	}
+	_, err = cobraTest(t, nil, "image", "sign", "--key", filepath.Join(tempDir, "signing.key"), imgRef)
+	if err != nil {
+		t.Fatalf("failed to sign image: %v", err)
+	}
+	_, err = cobraTest(t, nil, "image", "attest", "--key", filepath.Join(tempDir, "signing.key"), "--type", "slsaprovenance1", "--predicate", predFile, imgRef)
+	if err != nil {
+		t.Fatalf("failed to attest image: %v", err)
+	}
+	dig, err := cobraTest(t, nil, "image", "digest", imgRef)
+	if err != nil {
+		t.Fatalf("failed to get digest: %v", err)
+	}
+	digRef := "ocidir://" + tempDir + "/repo@" + dig
+	writePolicy := func(name, content string) string {
+		filename := filepath.Join(tempDir, name+".yaml")
+		err := os.WriteFile(filename, []byte(content), 0600)
+		if err != nil {
+			t.Fatalf("failed to write policy: %v", err)
+		}
+		return filename
+	}
+	policyPass := writePolicy("pass", `
+requireDigest: true
+maxAge: 100000d
+signatures:
+- key: signing.pub
+attestations:
+- predicateType: slsaprovenance1
+  key: signing.pub
+`)
+	policyFail := writePolicy("fail", `
+requireDigest: true
+allowedRegistries:
+- registry.example.org
+maxAge: 90d
+signatures:
+- key: other.pub
+attestations:
+- predicateType: spdx
+`)
+	policyInvalid := writePolicy("invalid", `
+requireDigests: true
+`)
+	policyInvalidJSON := writePolicy("invalid-json", `{"requireDigests": true}`)
+	policyEmpty := writePolicy("empty", `{}`)
+
+	tt := []struct {
+		name        string
+		args        []string
+		expectErr   error
+		expectOut   string
+		outContains bool
+	}{
+		{
+			name:      "missing key and policy",
+			args:      []string{"image", "verify", imgRef},
+			expectErr: ErrMissingInput,
+		},
+		{
+			name:      "invalid policy",
+			args:      []string{"image", "verify", "--policy", policyInvalid, imgRef},
+			expectErr: ErrInvalidInput,
+		},
+		{
+			name:      "invalid json policy",
+			args:      []string{"image", "verify", "--policy", policyInvalidJSON, imgRef},
+			expectErr: ErrInvalidInput,
+		},
+		{
+			name:      "empty policy",
+			args:      []string{"image", "verify", "--policy", policyEmpty, imgRef},
+			expectErr: ErrMissingInput,
+		},
+		{
+			name:      "pass",
+			args:      []string{"image", "verify", "--policy", policyPass, digRef, "--format", "{{range .Checks}}{{.Name}}={{.Pass}} {{end}}"},
+			expectOut: "digest=true age=true signature=true attestation=true",
+		},
+		{
+			name:        "pass table",
+			args:        []string{"image", "verify", "--policy", policyPass, digRef},
+			expectOut:   "Passed 4 of 4 policy checks",
+			outContains: true,
+		},
+		{
+			name:        "fail",
+			args:        []string{"image", "verify", "--policy", policyFail, imgRef, "--format", "{{range .Checks}}{{.Name}}={{.Pass}} {{end}}"},
+			expectErr:   ErrPolicyViolation,
+			expectOut:   "digest=false registry=false age=false signature=false attestation=false",
+			outContains: true,
+		},
+		{
+			name:        "tag with key",
+			args:        []string{"image", "verify", "--policy", policyPass, "--key", filepath.Join(tempDir, "other.pub"), imgRef, "--format", "{{range .Checks}}{{.Name}}={{.Pass}} {{end}}"},
+			expectErr:   ErrPolicyViolation,
+			expectOut:   "digest=false age=true signature=true signature=false attestation=true",
+			outContains: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := cobraTest(t, nil, tc.args...)
+			if tc.expectErr != nil {
+				if err == nil {
+					t.Errorf("did not receive expected error: %v", tc.expectErr)
+				} else if !errors.Is(err, tc.expectErr) && err.Error() != tc.expectErr.Error() {
+					t.Errorf("unexpected error, received %v, expected %v", err, tc.expectErr)
+				}
+			} else if err != nil {
+				t.Fatalf("returned unexpected error: %v", err)
+			}
+			if (!tc.outContains && out != tc.expectOut) || (tc.outContains && !strings.Contains(out, tc.expectOut)) {
+				t.Errorf("unexpected output, expected %s, received %s", tc.expectOut, out)
+			}
+		})
+	}
+}
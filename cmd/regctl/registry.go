@@ -2,41 +2,63 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
 	"os/signal"
+	"slices"
 	"strings"
 	"syscall"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 
 	"github.com/regclient/regclient"
 	"github.com/regclient/regclient/config"
+	"github.com/regclient/regclient/internal/strparse"
+	"github.com/regclient/regclient/internal/timejson"
 	"github.com/regclient/regclient/pkg/template"
+	"github.com/regclient/regclient/types/ping"
 	"github.com/regclient/regclient/types/ref"
 )
 
+// registryCredProviders are the values accepted by "registry set --cred-provider".
+var registryCredProviders = []string{config.CredProviderECR, config.CredProviderGAR, config.CredProviderACR}
+
 type registryCmd struct {
 	rootOpts             *rootCmd
 	formatConf           string
+	formatWhoami         string
 	user, pass           string // login opts
 	passStdin            bool
+	token                string
+	tokenStdin           bool
 	credHelper           string
+	credProvider         string
 	hostname, pathPrefix string
+	proxy                string
 	cacert, tls          string // set opts
 	clientCert           string
 	clientKey            string
 	mirrors              []string
 	priority             uint
 	repoAuth             bool
+	anonFallback         bool
 	blobChunk, blobMax   int64
 	reqPerSec            float64
 	reqConcurrent        int64
+	retryLimit           int
+	retryDelay           time.Duration
+	retryDelayMax        time.Duration
+	retryJitter          time.Duration
 	skipCheck            bool
 	apiOpts              []string
+	headers              []string
 	scheme               string   // TODO: remove
 	dns                  []string // TODO: remove
 }
@@ -74,7 +96,11 @@ regctl registry config docker.io --format '{{.User}}'`,
 		Use:   "login <registry>",
 		Short: "login to a registry",
 		Long: `Provide login credentials for a registry. This may not be necessary if you
-have already logged in with docker.`,
+have already logged in with docker.
+Credentials are saved in the regctl configuration, separate from the docker
+configuration, so docker does not need to be installed.
+An identity token may be saved with --token or --token-stdin instead of a
+username and password.`,
 		Example: `
 # login to Docker Hub
 regctl registry login
@@ -83,7 +109,10 @@ regctl registry login
 regctl registry login registry.example.org
 
 # login to GHCR with a provided password
-echo "${token}" | regctl registry login ghcr.io -u "${username}" --pass-stdin`,
+echo "${token}" | regctl registry login ghcr.io -u "${username}" --pass-stdin
+
+# save an identity token for a registry
+echo "${identity_token}" | regctl registry login registry.example.org --token-stdin`,
 		Args:              cobra.RangeArgs(0, 1),
 		ValidArgsFunction: registryArgListReg,
 		RunE:              registryOpts.runRegistryLogin,
@@ -117,37 +146,78 @@ regctl registry set registry.example.org --cacert "$(cat reg-ca.crt)"
 regctl registry set docker.io --mirror hub-mirror.example.org
 
 # specify the requests per sec throttle
-regctl registry set quay.io --req-per-sec 10`,
+regctl registry set quay.io --req-per-sec 10
+
+# include tag metadata from the Docker Hub API in tag listings
+regctl registry set docker.io --api-opts hubAPI=true
+
+# query the Harbor API for the tag metadata in "regctl tag inspect"
+regctl registry set harbor.example.org --api-opts harborAPI=true
+
+# add an API key header to every request for a registry behind a gateway
+regctl registry set registry.example.org --header "X-Api-Key=$(cat api.key)"`,
 		Args:              cobra.RangeArgs(0, 1),
 		ValidArgsFunction: registryArgListReg,
 		RunE:              registryOpts.runRegistrySet,
 	}
 
+	var registryWhoamiCmd = &cobra.Command{
+		Use:   "whoami [registry]",
+		Short: "show the credentials used for a registry",
+		Long: `Sends an authenticated request to a registry and reports where the credentials
+were found (command line, regctl config, docker config, or a credential helper),
+the authenticated user, and for registries that return a JWT, the token issuer,
+scopes, and expiration. Secrets are not included in the output.`,
+		Example: `
+# show the credentials used for Docker Hub
+regctl registry whoami
+
+# show the credentials used for a registry
+regctl registry whoami registry.example.org
+
+# show when the token expires
+regctl registry whoami ghcr.io --format '{{.Auth.Expires}}'`,
+		Args:              cobra.RangeArgs(0, 1),
+		ValidArgsFunction: registryArgListReg,
+		RunE:              registryOpts.runRegistryWhoami,
+	}
+
 	registryConfigCmd.Flags().StringVar(&registryOpts.formatConf, "format", "{{jsonPretty .}}", "Format output with go template syntax")
 
 	registryLoginCmd.Flags().StringVarP(&registryOpts.user, "user", "u", "", "Username")
 	registryLoginCmd.Flags().StringVarP(&registryOpts.pass, "pass", "p", "", "Password")
 	registryLoginCmd.Flags().BoolVar(&registryOpts.passStdin, "pass-stdin", false, "Read password from stdin")
 	registryLoginCmd.Flags().BoolVar(&registryOpts.skipCheck, "skip-check", false, "Skip checking connectivity to the registry")
+	registryLoginCmd.Flags().StringVar(&registryOpts.token, "token", "", "Identity token")
+	registryLoginCmd.Flags().BoolVar(&registryOpts.tokenStdin, "token-stdin", false, "Read identity token from stdin")
 	_ = registryLoginCmd.RegisterFlagCompletionFunc("user", completeArgNone)
 	_ = registryLoginCmd.RegisterFlagCompletionFunc("pass", completeArgNone)
+	_ = registryLoginCmd.RegisterFlagCompletionFunc("token", completeArgNone)
 
 	registrySetCmd.Flags().StringVar(&registryOpts.credHelper, "cred-helper", "", "Credential helper (full binary name, including docker-credential- prefix)")
+	registrySetCmd.Flags().StringVar(&registryOpts.credProvider, "cred-provider", "", "Built-in credential provider using the ambient cloud credentials (ecr, gar, acr)")
 	registrySetCmd.Flags().StringVar(&registryOpts.cacert, "cacert", "", "CA Certificate (not a filename, use \"$(cat ca.pem)\" to use a file)")
-	registrySetCmd.Flags().StringVar(&registryOpts.clientCert, "client-cert", "", "Client certificate for mTLS (not a filename, use \"$(cat client.pem)\" to use a file)")
-	registrySetCmd.Flags().StringVar(&registryOpts.clientKey, "client-key", "", "Client key for mTLS (not a filename, use \"$(cat client.key)\" to use a file)")
+	registrySetCmd.Flags().StringVar(&registryOpts.clientCert, "client-cert", "", "Client certificate for mTLS (PEM content or filename)")
+	registrySetCmd.Flags().StringVar(&registryOpts.clientKey, "client-key", "", "Client key for mTLS (PEM content or filename)")
 	registrySetCmd.Flags().StringVar(&registryOpts.tls, "tls", "", "TLS (enabled, insecure, disabled)")
 	registrySetCmd.Flags().StringVar(&registryOpts.hostname, "hostname", "", "Hostname or ip with port")
 	registrySetCmd.Flags().StringVar(&registryOpts.pathPrefix, "path-prefix", "", "Prefix to all repositories")
+	registrySetCmd.Flags().StringVar(&registryOpts.proxy, "proxy", "", "Proxy URL for requests to the registry (defaults to HTTP_PROXY/HTTPS_PROXY)")
 	registrySetCmd.Flags().StringArrayVar(&registryOpts.mirrors, "mirror", nil, "List of mirrors (registry names)")
 	registrySetCmd.Flags().UintVar(&registryOpts.priority, "priority", 0, "Priority (for sorting mirrors)")
 	registrySetCmd.Flags().BoolVar(&registryOpts.repoAuth, "repo-auth", false, "Separate auth requests per repository instead of per registry")
+	registrySetCmd.Flags().BoolVar(&registryOpts.anonFallback, "anon-fallback", false, "Retry pulls anonymously when credentials are rejected")
 	registrySetCmd.Flags().Int64Var(&registryOpts.blobChunk, "blob-chunk", 0, "Blob chunk size")
 	registrySetCmd.Flags().Int64Var(&registryOpts.blobMax, "blob-max", 0, "Blob size before switching to chunked push, -1 to disable")
 	registrySetCmd.Flags().Float64Var(&registryOpts.reqPerSec, "req-per-sec", 0, "Requests per second")
 	registrySetCmd.Flags().Int64Var(&registryOpts.reqConcurrent, "req-concurrent", 0, "Concurrent requests")
+	registrySetCmd.Flags().IntVar(&registryOpts.retryLimit, "retry-limit", 0, "Number of retries for each request")
+	registrySetCmd.Flags().DurationVar(&registryOpts.retryDelay, "retry-delay", 0, "Initial delay between retries")
+	registrySetCmd.Flags().DurationVar(&registryOpts.retryDelayMax, "retry-delay-max", 0, "Maximum delay between retries")
+	registrySetCmd.Flags().DurationVar(&registryOpts.retryJitter, "retry-jitter", 0, "Maximum random delay added to each retry")
 	registrySetCmd.Flags().BoolVar(&registryOpts.skipCheck, "skip-check", false, "Skip checking connectivity to the registry")
 	registrySetCmd.Flags().StringArrayVar(&registryOpts.apiOpts, "api-opts", nil, "List of options (key=value))")
+	registrySetCmd.Flags().StringArrayVar(&registryOpts.headers, "header", nil, "Headers added to every request (name=value), an empty value removes the header")
 	_ = registrySetCmd.RegisterFlagCompletionFunc("cacert", completeArgNone)
 	_ = registrySetCmd.RegisterFlagCompletionFunc("tls", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return []string{
@@ -156,13 +226,19 @@ regctl registry set quay.io --req-per-sec 10`,
 			"disabled",
 		}, cobra.ShellCompDirectiveNoFileComp
 	})
+	_ = registrySetCmd.RegisterFlagCompletionFunc("cred-provider", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return registryCredProviders, cobra.ShellCompDirectiveNoFileComp
+	})
 	_ = registrySetCmd.RegisterFlagCompletionFunc("hostname", completeArgNone)
 	_ = registrySetCmd.RegisterFlagCompletionFunc("path-prefix", completeArgNone)
+	_ = registrySetCmd.RegisterFlagCompletionFunc("proxy", completeArgNone)
 	_ = registrySetCmd.RegisterFlagCompletionFunc("mirror", completeArgNone)
 	_ = registrySetCmd.RegisterFlagCompletionFunc("priority", completeArgNone)
 	_ = registrySetCmd.RegisterFlagCompletionFunc("blob-chunk", completeArgNone)
 	_ = registrySetCmd.RegisterFlagCompletionFunc("blob-max", completeArgNone)
 
+	registryWhoamiCmd.Flags().StringVar(&registryOpts.formatWhoami, "format", "{{printPretty .}}", "Format output with go template syntax")
+
 	// TODO: eventually remove
 	registrySetCmd.Flags().StringVar(&registryOpts.scheme, "scheme", "", "[Deprecated] Scheme (http, https)")
 	registrySetCmd.Flags().StringArrayVar(&registryOpts.dns, "dns", nil, "[Deprecated] DNS hostname or ip with port")
@@ -173,6 +249,7 @@ regctl registry set quay.io --req-per-sec 10`,
 	registryTopCmd.AddCommand(registryLoginCmd)
 	registryTopCmd.AddCommand(registryLogoutCmd)
 	registryTopCmd.AddCommand(registrySetCmd)
+	registryTopCmd.AddCommand(registryWhoamiCmd)
 	return registryTopCmd
 }
 
@@ -200,6 +277,9 @@ func (registryOpts *registryCmd) runRegistryConfig(cmd *cobra.Command, args []st
 		c.Hosts[i].Pass = ""
 		c.Hosts[i].Token = ""
 		c.Hosts[i].ClientKey = ""
+		for k := range c.Hosts[i].Headers {
+			c.Hosts[i].Headers[k] = "[censored]"
+		}
 	}
 	if len(args) > 0 {
 		h, ok := c.Hosts[args[0]]
@@ -231,6 +311,28 @@ func (registryOpts *registryCmd) runRegistryLogin(cmd *cobra.Command, args []str
 	} else {
 		c.Hosts[h.Name] = h
 	}
+	if flagChanged(cmd, "token") || registryOpts.tokenStdin {
+		if flagChanged(cmd, "user") || flagChanged(cmd, "pass") || registryOpts.passStdin {
+			return fmt.Errorf("a token cannot be combined with a user or password%.0w", ErrInvalidInput)
+		}
+		token := registryOpts.token
+		if registryOpts.tokenStdin {
+			tokenIn, err := io.ReadAll(cmd.InOrStdin())
+			if err != nil {
+				return fmt.Errorf("failed to read token from stdin: %w", err)
+			}
+			token = strings.TrimSpace(string(tokenIn))
+		}
+		if token == "" {
+			registryOpts.rootOpts.log.Error("Token is required")
+
+			return ErrMissingInput
+		}
+		h.Token = token
+		h.User = ""
+		h.Pass = ""
+		return registryOpts.registryLoginSave(ctx, c, args[0])
+	}
 	if flagChanged(cmd, "user") {
 		h.User = registryOpts.user
 	} else if registryOpts.passStdin {
@@ -299,12 +401,17 @@ func (registryOpts *registryCmd) runRegistryLogin(cmd *cobra.Command, args []str
 	} else {
 		h.Token = ""
 	}
-	err = c.ConfigSave()
+	return registryOpts.registryLoginSave(ctx, c, args[0])
+}
+
+// registryLoginSave saves the credentials and verifies them with a ping unless the check is skipped.
+func (registryOpts *registryCmd) registryLoginSave(ctx context.Context, c *Config, registry string) error {
+	err := c.ConfigSave()
 	if err != nil {
 		return err
 	}
 	if !registryOpts.skipCheck {
-		r, err := ref.NewHost(args[0])
+		r, err := ref.NewHost(registry)
 		if err != nil {
 			return err
 		}
@@ -317,7 +424,7 @@ func (registryOpts *registryCmd) runRegistryLogin(cmd *cobra.Command, args []str
 		}
 	}
 	registryOpts.rootOpts.log.Info("Credentials set",
-		slog.String("registry", args[0]))
+		slog.String("registry", registry))
 	return nil
 }
 
@@ -379,6 +486,12 @@ func (registryOpts *registryCmd) runRegistrySet(cmd *cobra.Command, args []strin
 	if flagChanged(cmd, "cred-helper") {
 		h.CredHelper = registryOpts.credHelper
 	}
+	if flagChanged(cmd, "cred-provider") {
+		if registryOpts.credProvider != "" && !slices.Contains(registryCredProviders, registryOpts.credProvider) {
+			return fmt.Errorf("unknown credential provider %s, expected one of %s%.0w", registryOpts.credProvider, strings.Join(registryCredProviders, ", "), ErrInvalidInput)
+		}
+		h.CredProvider = registryOpts.credProvider
+	}
 	if flagChanged(cmd, "tls") {
 		if err := h.TLS.UnmarshalText([]byte(registryOpts.tls)); err != nil {
 			return err
@@ -399,12 +512,18 @@ func (registryOpts *registryCmd) runRegistrySet(cmd *cobra.Command, args []strin
 	if flagChanged(cmd, "path-prefix") {
 		h.PathPrefix = registryOpts.pathPrefix
 	}
+	if flagChanged(cmd, "proxy") {
+		h.Proxy = registryOpts.proxy
+	}
 	if flagChanged(cmd, "mirror") {
 		h.Mirrors = registryOpts.mirrors
 	}
 	if flagChanged(cmd, "priority") {
 		h.Priority = registryOpts.priority
 	}
+	if flagChanged(cmd, "anon-fallback") {
+		h.AnonFallback = registryOpts.anonFallback
+	}
 	if flagChanged(cmd, "repo-auth") {
 		h.RepoAuth = registryOpts.repoAuth
 	}
@@ -420,6 +539,18 @@ func (registryOpts *registryCmd) runRegistrySet(cmd *cobra.Command, args []strin
 	if flagChanged(cmd, "req-concurrent") {
 		h.ReqConcurrent = registryOpts.reqConcurrent
 	}
+	if flagChanged(cmd, "retry-limit") {
+		h.RetryLimit = registryOpts.retryLimit
+	}
+	if flagChanged(cmd, "retry-delay") {
+		h.RetryDelay = timejson.Duration(registryOpts.retryDelay)
+	}
+	if flagChanged(cmd, "retry-delay-max") {
+		h.RetryDelayMax = timejson.Duration(registryOpts.retryDelayMax)
+	}
+	if flagChanged(cmd, "retry-jitter") {
+		h.RetryJitter = timejson.Duration(registryOpts.retryJitter)
+	}
 	if flagChanged(cmd, "api-opts") {
 		if h.APIOpts == nil {
 			h.APIOpts = map[string]string{}
@@ -435,6 +566,19 @@ func (registryOpts *registryCmd) runRegistrySet(cmd *cobra.Command, args []strin
 			}
 		}
 	}
+	if flagChanged(cmd, "header") {
+		if h.Headers == nil {
+			h.Headers = map[string]string{}
+		}
+		for _, kv := range registryOpts.headers {
+			kvArr := strings.SplitN(kv, "=", 2)
+			if len(kvArr) == 2 && kvArr[1] != "" {
+				h.Headers[kvArr[0]] = kvArr[1]
+			} else {
+				delete(h.Headers, kvArr[0])
+			}
+		}
+	}
 
 	err = c.ConfigSave()
 	if err != nil {
@@ -459,3 +603,124 @@ func (registryOpts *registryCmd) runRegistrySet(cmd *cobra.Command, args []strin
 		slog.String("name", h.Name))
 	return nil
 }
+
+type registryWhoamiResult struct {
+	Registry   string     `json:"registry"`
+	Source     string     `json:"source"`               // source of the credentials
+	CredHelper string     `json:"credHelper,omitempty"` // credential helper or provider used to retrieve the credentials
+	User       string     `json:"user,omitempty"`       // configured login
+	Auth       *ping.Auth `json:"auth,omitempty"`       // authorization sent to the registry
+}
+
+func (r registryWhoamiResult) MarshalPretty() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	tw := tabwriter.NewWriter(buf, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(tw, "Registry:\t%s\n", r.Registry)
+	fmt.Fprintf(tw, "Source:\t%s\n", r.Source)
+	if r.CredHelper != "" {
+		fmt.Fprintf(tw, "Credential Helper:\t%s\n", r.CredHelper)
+	}
+	user := r.User
+	if r.Auth != nil && r.Auth.User != "" {
+		user = r.Auth.User
+	}
+	if user != "" {
+		fmt.Fprintf(tw, "User:\t%s\n", user)
+	}
+	if r.Auth != nil {
+		authType := r.Auth.Type
+		if authType == "" {
+			authType = "anonymous"
+		}
+		fmt.Fprintf(tw, "Auth Type:\t%s\n", authType)
+		if r.Auth.Issuer != "" {
+			fmt.Fprintf(tw, "Issuer:\t%s\n", r.Auth.Issuer)
+		}
+		if !r.Auth.IssuedAt.IsZero() {
+			fmt.Fprintf(tw, "Issued:\t%s\n", r.Auth.IssuedAt.UTC().Format(time.RFC3339))
+		}
+		if !r.Auth.Expires.IsZero() {
+			fmt.Fprintf(tw, "Expires:\t%s (%s)\n", r.Auth.Expires.UTC().Format(time.RFC3339), time.Until(r.Auth.Expires).Round(time.Second))
+		}
+		if len(r.Auth.Scopes) > 0 {
+			fmt.Fprintf(tw, "Scopes:\t%s\n", strings.Join(r.Auth.Scopes, ", "))
+		}
+	}
+	err := tw.Flush()
+	return buf.Bytes(), err
+}
+
+func (registryOpts *registryCmd) runRegistryWhoami(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	if len(args) < 1 {
+		args = []string{regclient.DockerRegistry}
+	}
+	r, err := ref.NewHost(args[0])
+	if err != nil {
+		return err
+	}
+	result := registryWhoamiResult{
+		Registry: r.Registry,
+	}
+	result.Source, result.CredHelper, result.User = registryOpts.whoamiSource(r.Registry)
+	rc := registryOpts.rootOpts.newRegClient()
+	pingResult, pingErr := rc.Ping(ctx, r)
+	result.Auth = pingResult.Auth
+	err = template.Writer(cmd.OutOrStdout(), registryOpts.formatWhoami, result)
+	if pingErr != nil {
+		return fmt.Errorf("failed to ping %s: %w", r.Registry, pingErr)
+	}
+	return err
+}
+
+// whoamiSource returns the source of the credentials for a registry, the credential helper, and the user.
+// This follows the precedence of newRegClient, where the --user flag or environment overrides the --host flags,
+// which override the regctl config, which overrides the docker config.
+func (registryOpts *registryCmd) whoamiSource(registry string) (string, string, string) {
+	if ch := registryOpts.rootOpts.credHost; ch != nil && ch.Name == registry {
+		return registryOpts.rootOpts.credSrc, "", ch.User
+	}
+	for i := len(registryOpts.rootOpts.hosts) - 1; i >= 0; i-- {
+		hKV, err := strparse.SplitCSKV(registryOpts.rootOpts.hosts[i])
+		if err != nil || config.HostNewName(hKV["reg"]).Name != registry {
+			continue
+		}
+		if hKV["user"] != "" || hKV["pass"] != "" {
+			return "command line", "", hKV["user"]
+		}
+	}
+	conf, err := ConfigLoadDefault()
+	if err != nil || conf == nil {
+		conf = ConfigNew()
+	}
+	if h, ok := conf.Hosts[registry]; ok {
+		if h.CredProvider != "" {
+			return "regctl config credential provider", h.CredProvider, ""
+		}
+		if h.CredHelper != "" {
+			return "regctl config credential helper", h.CredHelper, ""
+		}
+		if h.User != "" || h.Token != "" {
+			return "regctl config " + conf.Filename, "", h.User
+		}
+	}
+	if conf.IncDockerCred == nil || *conf.IncDockerCred {
+		dockerHosts, err := config.DockerLoad()
+		if err != nil {
+			registryOpts.rootOpts.log.Warn("Failed to load docker config",
+				slog.String("err", err.Error()))
+		}
+		for _, h := range dockerHosts {
+			if h.Name != registry {
+				continue
+			}
+			if h.CredHelper != "" {
+				return "docker credential helper", h.CredHelper, ""
+			}
+			if h.User != "" || h.Token != "" {
+				return "docker config", "", h.User
+			}
+		}
+	}
+	return "anonymous", "", ""
+}
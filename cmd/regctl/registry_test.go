@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -70,7 +71,24 @@ func TestRegistry(t *testing.T) {
 			expectOut:   "",
 			outContains: false,
 		},
+		{
+			name:      "set unknown cred provider",
+			args:      []string{"registry", "set", "provider.example.org", "--cred-provider", "unknown", "--skip-check"},
+			expectErr: ErrInvalidInput,
+		},
+		{
+			name:        "set cred provider",
+			args:        []string{"registry", "set", "provider.example.org", "--cred-provider", "ecr", "--skip-check"},
+			expectOut:   "",
+			outContains: false,
+		},
 		// query the config change
+		{
+			name:        "query cred provider",
+			args:        []string{"registry", "config", "provider.example.org", "--format", "{{.CredProvider}}"},
+			expectOut:   "ecr",
+			outContains: false,
+		},
 		{
 			name:        "query good host",
 			args:        []string{"registry", "config", tsGoodHost},
@@ -107,6 +125,22 @@ func TestRegistry(t *testing.T) {
 			expectOut:   "",
 			outContains: false,
 		},
+		{
+			name:        "login token",
+			args:        []string{"registry", "login", "token.example.org", "--token", "testtoken", "--skip-check"},
+			expectOut:   "",
+			outContains: false,
+		},
+		{
+			name:      "login token with user",
+			args:      []string{"registry", "login", "token.example.org", "--token", "testtoken", "-u", "testuser", "--skip-check"},
+			expectErr: ErrInvalidInput,
+		},
+		{
+			name:      "login empty token",
+			args:      []string{"registry", "login", "token.example.org", "--token", "", "--skip-check"},
+			expectErr: ErrMissingInput,
+		},
 		// query for user
 		{
 			name:      "query good host",
@@ -123,6 +157,18 @@ func TestRegistry(t *testing.T) {
 			args:      []string{"registry", "config", tsBadHost, "--format", "{{.User}}"},
 			expectOut: `testbaduser`,
 		},
+		// whoami
+		{
+			name:        "whoami good host",
+			args:        []string{"registry", "whoami", tsGoodHost, "--format", "{{.Source}} {{.User}}"},
+			expectOut:   "testgooduser",
+			outContains: true,
+		},
+		{
+			name:      "whoami unauth host",
+			args:      []string{"registry", "whoami", tsUnauthHost},
+			expectErr: errs.ErrHTTPUnauthorized,
+		},
 		// logout
 		{
 			name:        "logout good host",
@@ -143,6 +189,11 @@ func TestRegistry(t *testing.T) {
 			outContains: false,
 		},
 		// verify logout
+		{
+			name:      "whoami after logout",
+			args:      []string{"registry", "whoami", tsGoodHost, "--format", "{{.Source}}"},
+			expectOut: "anonymous",
+		},
 		{
 			name:      "check logout on good host",
 			args:      []string{"registry", "config", tsGoodHost, "--format", "{{.User}}"},
@@ -178,4 +229,53 @@ func TestRegistry(t *testing.T) {
 			}
 		})
 	}
+	// the token is hidden from the config command, verify the saved config
+	confBytes, err := os.ReadFile(filepath.Join(tempDir, "config.json"))
+	if err != nil {
+		t.Fatalf("failed to read config: %v", err)
+	}
+	if !strings.Contains(string(confBytes), `"token": "testtoken"`) {
+		t.Errorf("token was not saved: %s", string(confBytes))
+	}
+}
+
+func TestRegistryWhoami(t *testing.T) {
+	tsBasic := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "testuser" || pass != "testpass" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(tsBasic.Close)
+	tsBasicURL, _ := url.Parse(tsBasic.URL)
+	tsBasicHost := tsBasicURL.Host
+	tempDir := t.TempDir()
+	t.Setenv(ConfigEnv, filepath.Join(tempDir, "config.json"))
+
+	out, err := cobraTest(t, nil, "registry", "whoami", tsBasicHost,
+		"--host", "reg="+tsBasicHost+",user=testuser,pass=testpass,tls=disabled",
+		"--format", "{{.Source}}|{{.Auth.Type}}|{{.Auth.User}}")
+	if err != nil {
+		t.Fatalf("failed to run whoami: %v", err)
+	}
+	if out != "command line|Basic|testuser" {
+		t.Errorf("unexpected output: %s", out)
+	}
+	out, err = cobraTest(t, nil, "registry", "whoami", tsBasicHost,
+		"--host", "reg="+tsBasicHost+",user=testuser,pass=testpass,tls=disabled")
+	if err != nil {
+		t.Fatalf("failed to run whoami: %v", err)
+	}
+	for _, expect := range []string{"Source:", "command line", "User:", "testuser", "Auth Type:", "Basic"} {
+		if !strings.Contains(out, expect) {
+			t.Errorf("output missing %s: %s", expect, out)
+		}
+	}
+	if strings.Contains(out, "testpass") {
+		t.Errorf("password included in output: %s", out)
+	}
 }
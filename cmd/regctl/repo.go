@@ -1,20 +1,37 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"fmt"
 	"log/slog"
+	"regexp"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/regclient/regclient"
+	"github.com/regclient/regclient/internal/units"
 	"github.com/regclient/regclient/pkg/template"
 	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/types/descriptor"
+	"github.com/regclient/regclient/types/errs"
+	"github.com/regclient/regclient/types/ref"
 )
 
 type repoCmd struct {
-	rootOpts *rootCmd
-	last     string
-	limit    int
-	format   string
+	rootOpts   *rootCmd
+	blobs      bool
+	dryRun     bool
+	filter     string
+	force      bool
+	keepLatest int
+	last       string
+	limit      int
+	format     string
+	olderThan  time.Duration
 }
 
 func NewRepoCmd(rootOpts *rootCmd) *cobra.Command {
@@ -30,18 +47,25 @@ func NewRepoCmd(rootOpts *rootCmd) *cobra.Command {
 		Aliases: []string{"list"},
 		Short:   "list repositories in a registry",
 		Long: `List repositories in a registry.
-Note: Docker Hub does not support this API request.`,
+Note: Docker Hub does not support this API request.
+When repositories are filtered, the limit is applied to the filtered result
+instead of the request to the registry.`,
 		Example: `
 # list all repositories
 regctl repo ls registry.example.org
 
 # list the next 5 repositories after repo1
-regctl repo ls --last repo1 --limit 5 registry.example.org`,
+regctl repo ls --last repo1 --limit 5 registry.example.org
+
+# list repositories in the library namespace as json
+regctl repo ls --filter '^library/' --format '{{jsonPretty .Repositories}}' registry.example.org`,
 		Args:              cobra.ExactArgs(1),
 		ValidArgsFunction: registryArgListReg,
 		RunE:              repoOpts.runRepoLs,
 	}
 
+	repoLsCmd.Flags().StringVar(&repoOpts.filter, "filter", "", "Regexp of repositories to include (expression matches any part of the name)")
+	_ = repoLsCmd.RegisterFlagCompletionFunc("filter", completeArgNone)
 	repoLsCmd.Flags().StringVarP(&repoOpts.last, "last", "", "", "Specify the last repo from a previous request for pagination")
 	_ = repoLsCmd.RegisterFlagCompletionFunc("last", completeArgNone)
 	repoLsCmd.Flags().IntVarP(&repoOpts.limit, "limit", "", 0, "Specify the number of repos to retrieve")
@@ -49,7 +73,46 @@ regctl repo ls --last repo1 --limit 5 registry.example.org`,
 	repoLsCmd.Flags().StringVarP(&repoOpts.format, "format", "", "{{printPretty .}}", "Format output with go template syntax")
 	_ = repoLsCmd.RegisterFlagCompletionFunc("format", completeArgNone)
 
+	var repoPruneCmd = &cobra.Command{
+		Use:   "prune <repository>",
+		Short: "delete old images from a repository",
+		Long: `Delete the tags and manifests in a repository selected by a retention policy.
+Tags are selected with --filter and --older-than, and the --keep-latest most
+recently created of the selected images are kept, along with every selected tag
+of those images. Tags without a created time are not selected by --older-than
+or --keep-latest. Manifests still referenced by a
+remaining tag are not deleted. Blobs are left for the registry garbage
+collection unless --blobs is set. The deletion is confirmed on stdin unless
+--force is set.`,
+		Example: `
+# show the pr tags older than 90 days that would be deleted
+regctl repo prune registry.example.org/repo --filter '^pr-' --older-than 90d --dry-run
+
+# keep the 10 most recent images, deleting all other tags
+regctl repo prune registry.example.org/repo --keep-latest 10 --force
+
+# output the deleted digests
+regctl repo prune registry.example.org/repo --older-than 90d --force \
+  --format '{{range .Manifests}}{{println .Digest}}{{end}}'`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: rootOpts.completeArgRepo,
+		RunE:              repoOpts.runRepoPrune,
+	}
+
+	repoPruneCmd.Flags().BoolVar(&repoOpts.blobs, "blobs", false, "Delete blobs only referenced by the deleted manifests, requires the registry to support deleting blobs")
+	repoPruneCmd.Flags().BoolVar(&repoOpts.dryRun, "dry-run", false, "Output the report without deleting anything")
+	repoPruneCmd.Flags().StringVar(&repoOpts.filter, "filter", "", "Regexp of tags to select (expression matches any part of the tag)")
+	_ = repoPruneCmd.RegisterFlagCompletionFunc("filter", completeArgNone)
+	repoPruneCmd.Flags().BoolVar(&repoOpts.force, "force", false, "Delete without confirmation")
+	repoPruneCmd.Flags().StringVar(&repoOpts.format, "format", "{{printPretty .}}", "Format output with go template syntax")
+	_ = repoPruneCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+	repoPruneCmd.Flags().IntVar(&repoOpts.keepLatest, "keep-latest", 0, "Keep the number of most recently created images from the selected tags")
+	_ = repoPruneCmd.RegisterFlagCompletionFunc("keep-latest", completeArgNone)
+	repoPruneCmd.Flags().Var((*ageFlag)(&repoOpts.olderThan), "older-than", "Select tags on images created longer ago than the duration, e.g. 720h or 90d")
+	_ = repoPruneCmd.RegisterFlagCompletionFunc("older-than", completeArgNone)
+
 	repoTopCmd.AddCommand(repoLsCmd)
+	repoTopCmd.AddCommand(repoPruneCmd)
 	return repoTopCmd
 }
 
@@ -63,22 +126,44 @@ func (repoOpts *repoCmd) runRepoLs(cmd *cobra.Command, args []string) error {
 			slog.String("host", host))
 		return ErrInvalidInput
 	}
+	var reFilter *regexp.Regexp
+	if repoOpts.filter != "" {
+		re, err := regexp.Compile(repoOpts.filter)
+		if err != nil {
+			return fmt.Errorf("failed to parse regexp \"%s\": %w", repoOpts.filter, err)
+		}
+		reFilter = re
+	}
 	rc := repoOpts.rootOpts.newRegClient()
 	repoOpts.rootOpts.log.Debug("Listing repositories",
 		slog.String("host", host),
+		slog.String("filter", repoOpts.filter),
 		slog.String("last", repoOpts.last),
 		slog.Int("limit", repoOpts.limit))
 	opts := []scheme.RepoOpts{}
 	if repoOpts.last != "" {
 		opts = append(opts, scheme.WithRepoLast(repoOpts.last))
 	}
-	if repoOpts.limit != 0 {
+	// filtering requires the full list, the limit is applied to the result
+	if repoOpts.limit != 0 && reFilter == nil {
 		opts = append(opts, scheme.WithRepoLimit(repoOpts.limit))
 	}
 	rl, err := rc.RepoList(ctx, host, opts...)
 	if err != nil {
 		return err
 	}
+	if reFilter != nil {
+		filtered := []string{}
+		for _, name := range rl.Repositories {
+			if reFilter.MatchString(name) {
+				filtered = append(filtered, name)
+			}
+		}
+		if repoOpts.limit > 0 && len(filtered) > repoOpts.limit {
+			filtered = filtered[:repoOpts.limit]
+		}
+		rl.Repositories = filtered
+	}
 	switch repoOpts.format {
 	case "raw":
 		repoOpts.format = "{{ range $key,$vals := .RawHeaders}}{{range $val := $vals}}{{printf \"%s: %s\\n\" $key $val }}{{end}}{{end}}{{printf \"\\n%s\" .RawBody}}"
@@ -89,3 +174,114 @@ func (repoOpts *repoCmd) runRepoLs(cmd *cobra.Command, args []string) error {
 	}
 	return template.Writer(cmd.OutOrStdout(), repoOpts.format, rl)
 }
+
+func (repoOpts *repoCmd) runRepoPrune(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	r, err := ref.New(args[0])
+	if err != nil {
+		return err
+	}
+	if r.Digest != "" || (r.Tag != "" && r.Tag != "latest") {
+		return fmt.Errorf("prune requires a repository without a tag or digest: %s%.0w", args[0], errs.ErrInvalidReference)
+	}
+	r = r.SetTag("")
+	if repoOpts.filter == "" && repoOpts.olderThan == 0 && repoOpts.keepLatest == 0 {
+		return fmt.Errorf("prune requires --filter, --older-than, or --keep-latest%.0w", ErrMissingInput)
+	}
+	if repoOpts.olderThan < 0 || repoOpts.keepLatest < 0 {
+		return fmt.Errorf("older-than and keep-latest must not be negative%.0w", ErrInvalidInput)
+	}
+	opts := []regclient.CleanupOpts{}
+	if repoOpts.filter != "" {
+		if _, err := regexp.Compile(repoOpts.filter); err != nil {
+			return fmt.Errorf("failed to parse regexp \"%s\": %w", repoOpts.filter, err)
+		}
+		// cleanup expressions are anchored, allow the filter to match any part of the tag
+		opts = append(opts, regclient.CleanupWithInclude(".*(?:"+repoOpts.filter+").*"))
+	}
+	if repoOpts.olderThan > 0 {
+		opts = append(opts, regclient.CleanupWithOlderThan(time.Now().Add(-1*repoOpts.olderThan)))
+	}
+	if repoOpts.keepLatest > 0 {
+		opts = append(opts, regclient.CleanupWithKeepLatest(repoOpts.keepLatest))
+	}
+	rc := repoOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, r)
+	repoOpts.rootOpts.log.Debug("Planning repository prune",
+		slog.String("repository", r.CommonName()),
+		slog.String("filter", repoOpts.filter),
+		slog.String("older-than", repoOpts.olderThan.String()),
+		slog.Int("keep-latest", repoOpts.keepLatest))
+	plan, err := rc.CleanupPlan(ctx, r, opts...)
+	if err != nil {
+		return err
+	}
+	if !repoOpts.blobs {
+		plan.Blobs = []descriptor.Descriptor{}
+	}
+	report := repoPruneReport{
+		Repository: r.CommonName(),
+		DryRun:     repoOpts.dryRun,
+		Tags:       plan.Tags,
+		Manifests:  plan.Manifests,
+		Blobs:      plan.Blobs,
+	}
+	if !repoOpts.dryRun && len(plan.Tags) > 0 {
+		if !repoOpts.force {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Delete %d tags and %d manifests from %s [y/N]: ", len(plan.Tags), len(plan.Manifests), r.CommonName())
+			resp, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+			resp = strings.ToLower(strings.TrimSpace(resp))
+			if resp != "y" && resp != "yes" {
+				repoOpts.rootOpts.log.Info("Skipping prune",
+					slog.String("repository", r.CommonName()))
+				return nil
+			}
+		}
+		err = rc.CleanupApply(ctx, r, plan)
+		if err != nil {
+			return err
+		}
+	}
+	return template.Writer(cmd.OutOrStdout(), repoOpts.format, report)
+}
+
+// repoPruneReport lists the content deleted by a prune, or that would be deleted in a dry run.
+type repoPruneReport struct {
+	Repository string
+	DryRun     bool
+	Tags       []regclient.CleanupTag
+	Manifests  []descriptor.Descriptor
+	Blobs      []descriptor.Descriptor
+}
+
+// Size returns the total size of the manifests and blobs.
+func (report repoPruneReport) Size() int64 {
+	var size int64
+	for _, d := range report.Manifests {
+		size += d.Size
+	}
+	for _, d := range report.Blobs {
+		size += d.Size
+	}
+	return size
+}
+
+func (report repoPruneReport) MarshalPretty() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	tw := tabwriter.NewWriter(buf, 0, 0, 1, ' ', 0)
+	if len(report.Tags) > 0 {
+		fmt.Fprintf(tw, "Tag\tDigest\n")
+		for _, t := range report.Tags {
+			fmt.Fprintf(tw, "%s\t%s\n", t.Tag, t.Digest.String())
+		}
+		fmt.Fprintf(tw, "\t\n")
+	}
+	action, reclaimed := "Deleted", "Reclaimed"
+	if report.DryRun {
+		action, reclaimed = "Would delete", "Reclaimable"
+	}
+	fmt.Fprintf(tw, "%s:\t%d tags, %d manifests, %d blobs from %s\n", action, len(report.Tags), len(report.Manifests), len(report.Blobs), report.Repository)
+	fmt.Fprintf(tw, "%s:\t%s\n", reclaimed, strings.TrimSpace(units.HumanSize(float64(report.Size()))))
+	err := tw.Flush()
+	return buf.Bytes(), err
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/regclient/regclient/internal/copyfs"
+)
+
+func TestRepoList(t *testing.T) {
+	tempDir := t.TempDir()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/":
+			w.WriteHeader(http.StatusOK)
+		case "/v2/_catalog":
+			w.Header().Set("Content-Type", "application/json")
+			if r.URL.Query().Get("last") == "" {
+				w.Header().Set("Link", `</v2/_catalog?last=library/busybox>; rel="next"`)
+				_, _ = w.Write([]byte(`{"repositories":["alpha","library/alpine","library/busybox"]}`))
+			} else {
+				_, _ = w.Write([]byte(`{"repositories":["library/golang","zeta"]}`))
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	t.Cleanup(ts.Close)
+	t.Setenv(ConfigEnv, filepath.Join(tempDir, "config.json"))
+	_, err := cobraTest(t, nil, "registry", "set", tsHost, "--tls", "disabled")
+	if err != nil {
+		t.Fatalf("failed to disable TLS for internal registry")
+	}
+	tt := []struct {
+		name      string
+		args      []string
+		expectErr bool
+		expectOut string
+	}{
+		{
+			name:      "all pages",
+			args:      []string{"repo", "ls", tsHost},
+			expectOut: "alpha\nlibrary/alpine\nlibrary/busybox\nlibrary/golang\nzeta",
+		},
+		{
+			name:      "filter",
+			args:      []string{"repo", "ls", "--filter", "^library/", tsHost},
+			expectOut: "library/alpine\nlibrary/busybox\nlibrary/golang",
+		},
+		{
+			name:      "filter limit",
+			args:      []string{"repo", "ls", "--filter", "^library/", "--limit", "1", tsHost},
+			expectOut: "library/alpine",
+		},
+		{
+			name:      "filter json",
+			args:      []string{"repo", "ls", "--filter", "a$", "--format", "{{json .Repositories}}", tsHost},
+			expectOut: `["alpha","zeta"]`,
+		},
+		{
+			name:      "invalid filter",
+			args:      []string{"repo", "ls", "--filter", "[", tsHost},
+			expectErr: true,
+		},
+		{
+			name:      "invalid host",
+			args:      []string{"repo", "ls", tsHost + "/repo"},
+			expectErr: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := cobraTest(t, nil, tc.args...)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("command did not fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("returned unexpected error: %v", err)
+			}
+			if out != tc.expectOut {
+				t.Errorf("unexpected output, expected %s, received %s", tc.expectOut, out)
+			}
+		})
+	}
+}
+
+func TestRepoPrune(t *testing.T) {
+	tmpDir := t.TempDir()
+	err := copyfs.Copy(tmpDir+"/testrepo", "../../testdata/testrepo")
+	if err != nil {
+		t.Fatalf("failed to copy testrepo: %v", err)
+	}
+	repo := "ocidir://" + tmpDir + "/testrepo"
+	tagFormat := "{{range .Tags}}{{println .Tag}}{{end}}"
+
+	t.Run("Missing criteria", func(t *testing.T) {
+		_, err := cobraTest(t, nil, "repo", "prune", repo)
+		if !errors.Is(err, ErrMissingInput) {
+			t.Errorf("unexpected error, expected %v, received %v", ErrMissingInput, err)
+		}
+		_, err = cobraTest(t, nil, "repo", "prune", "--keep-latest", "-1", repo)
+		if !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("unexpected error, expected %v, received %v", ErrInvalidInput, err)
+		}
+	})
+	t.Run("Dry run", func(t *testing.T) {
+		// the b* images have the same created time, the first tag is kept
+		out, err := cobraTest(t, nil, "repo", "prune", "--filter", "^b", "--keep-latest", "1", "--dry-run", "--format", tagFormat, repo)
+		if err != nil {
+			t.Fatalf("failed to run dry run: %v", err)
+		}
+		if out != "b2\nb3" {
+			t.Errorf("unexpected output, expected b2 b3, received %s", out)
+		}
+		out, err = cobraTest(t, nil, "repo", "prune", "--filter", "^b", "--older-than", "1d", "--dry-run", repo)
+		if err != nil {
+			t.Fatalf("failed to run dry run: %v", err)
+		}
+		if !strings.Contains(out, "Would delete:") || !strings.Contains(out, "3 tags") {
+			t.Errorf("unexpected report: %s", out)
+		}
+		out, err = cobraTest(t, nil, "tag", "ls", "--include", "b.*", repo)
+		if err != nil {
+			t.Fatalf("failed to list tags: %v", err)
+		}
+		if out != "b1\nb2\nb3" {
+			t.Errorf("dry run deleted tags, remaining: %s", out)
+		}
+	})
+	t.Run("Declined", func(t *testing.T) {
+		_, err := cobraTest(t, &cobraTestOpts{stdin: bytes.NewBufferString("n\n")}, "repo", "prune", "--filter", "^b", repo)
+		if err != nil {
+			t.Fatalf("failed to run prune: %v", err)
+		}
+		out, err := cobraTest(t, nil, "tag", "ls", "--include", "b.*", repo)
+		if err != nil {
+			t.Fatalf("failed to list tags: %v", err)
+		}
+		if out != "b1\nb2\nb3" {
+			t.Errorf("declined prune deleted tags, remaining: %s", out)
+		}
+	})
+	t.Run("Force", func(t *testing.T) {
+		out, err := cobraTest(t, nil, "repo", "prune", "--filter", "^b", "--keep-latest", "1", "--blobs", "--force", repo)
+		if err != nil {
+			t.Fatalf("failed to run prune: %v", err)
+		}
+		if !strings.Contains(out, "Deleted:") || !strings.Contains(out, "b2 ") || !strings.Contains(out, "b3 ") {
+			t.Errorf("unexpected report: %s", out)
+		}
+		out, err = cobraTest(t, nil, "tag", "ls", "--include", "b.*", repo)
+		if err != nil {
+			t.Fatalf("failed to list tags: %v", err)
+		}
+		if out != "b1" {
+			t.Errorf("unexpected remaining tags: %s", out)
+		}
+		_, err = cobraTest(t, nil, "image", "export", repo+":b1", tmpDir+"/b1.tar")
+		if err != nil {
+			t.Errorf("kept image is not complete: %v", err)
+		}
+	})
+}
@@ -1,8 +1,13 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,12 +20,21 @@ import (
 	"github.com/regclient/regclient/pkg/template"
 	"github.com/regclient/regclient/scheme/reg"
 	"github.com/regclient/regclient/types"
+	"github.com/regclient/regclient/types/ref"
 )
 
 const (
 	progressFreq = time.Millisecond * 250
 	// UserAgent sets the header on http requests
 	UserAgent = "regclient/regctl"
+	// UserEnv is the environment variable for the username used with a single command
+	UserEnv = "REGCTL_USER"
+	// PassEnv is the environment variable for the password used with a single command
+	PassEnv = "REGCTL_PASS"
+	// CredRegistryEnv is the environment variable for the registry that receives the credentials from UserEnv and PassEnv
+	CredRegistryEnv = "REGCTL_CRED_REGISTRY"
+	// CosignPassEnv is the environment variable for the password of an encrypted cosign private key
+	CosignPassEnv = "COSIGN_PASSWORD"
 )
 
 type rootCmd struct {
@@ -31,6 +45,11 @@ type rootCmd struct {
 	format    string // for Go template formatting of various commands
 	hosts     []string
 	userAgent string
+	user      string       // ad-hoc login for a single command
+	passStdin bool         // read the ad-hoc password from stdin
+	credReg   string       // registry for the ad-hoc login
+	credHost  *config.Host // ad-hoc credentials, set by rootPreRun
+	credSrc   string       // source of the ad-hoc credentials, "command line" or "environment"
 }
 
 func NewRootCmd() (*cobra.Command, *rootCmd) {
@@ -57,7 +76,10 @@ regctl tag ls ghcr.io/regclient/regctl -v debug
 regctl image ratelimit --logopt json alpine
 
 # override registry config for a single command
-regctl image digest --host reg=localhost:5000,tls=disabled localhost:5000/repo:v1`,
+regctl image digest --host reg=localhost:5000,tls=disabled localhost:5000/repo:v1
+
+# login for a single command without saving the credentials
+echo "${token}" | regctl tag ls --user "${username}" --pass-stdin ghcr.io/regclient/regctl`,
 		SilenceUsage:  true,
 		SilenceErrors: true,
 	}
@@ -88,6 +110,11 @@ regctl version --format '{{.VCSTag}}'`,
 	_ = rootTopCmd.RegisterFlagCompletionFunc("host", completeArgNone)
 	rootTopCmd.PersistentFlags().StringVarP(&rootOpts.userAgent, "user-agent", "", "", "Override user agent")
 	_ = rootTopCmd.RegisterFlagCompletionFunc("user-agent", completeArgNone)
+	rootTopCmd.PersistentFlags().StringVarP(&rootOpts.user, "user", "", "", "Username for a single command, not saved to the config (env "+UserEnv+")")
+	_ = rootTopCmd.RegisterFlagCompletionFunc("user", completeArgNone)
+	rootTopCmd.PersistentFlags().BoolVarP(&rootOpts.passStdin, "pass-stdin", "", false, "Read the password for --user from stdin (env "+PassEnv+")")
+	rootTopCmd.PersistentFlags().StringVarP(&rootOpts.credReg, "cred-registry", "", "", "Registry for --user, defaults to the registry of the first argument (env "+CredRegistryEnv+")")
+	_ = rootTopCmd.RegisterFlagCompletionFunc("cred-registry", completeArgNone)
 
 	versionCmd.Flags().StringVarP(&rootOpts.format, "format", "", "{{printPretty .}}", "Format output with go template syntax")
 	_ = versionCmd.RegisterFlagCompletionFunc("format", completeArgNone)
@@ -97,6 +124,7 @@ regctl version --format '{{.VCSTag}}'`,
 	rootTopCmd.AddCommand(
 		NewArtifactCmd(&rootOpts),
 		NewBlobCmd(&rootOpts),
+		NewBrowseCmd(&rootOpts),
 		NewCompletionCmd(&rootOpts),
 		NewConfigCmd(&rootOpts),
 		NewDigestCmd(&rootOpts),
@@ -106,6 +134,7 @@ regctl version --format '{{.VCSTag}}'`,
 		NewRefCmd(&rootOpts),
 		NewRegistryCmd(&rootOpts),
 		NewRepoCmd(&rootOpts),
+		NewSBOMCmd(&rootOpts),
 		NewTagCmd(&rootOpts),
 	)
 	return rootTopCmd, &rootOpts
@@ -133,9 +162,96 @@ func (rootOpts *rootCmd) rootPreRun(cmd *cobra.Command, args []string) error {
 	} else {
 		rootOpts.log = slog.New(slog.NewTextHandler(cmd.ErrOrStderr(), &slog.HandlerOptions{Level: lvl}))
 	}
+	return rootOpts.credSetup(cmd, args)
+}
+
+// credSetup prepares the ad-hoc credentials from the --user and --pass-stdin flags or the environment.
+func (rootOpts *rootCmd) credSetup(cmd *cobra.Command, args []string) error {
+	rootOpts.credHost = nil
+	// commands like "registry login" define their own flags with the same name
+	rootFlags := cmd.Root().PersistentFlags()
+	fromFlags := rootFlags.Lookup("user").Changed || rootFlags.Lookup("pass-stdin").Changed
+	user, pass, credReg := rootOpts.user, "", rootOpts.credReg
+	if !fromFlags {
+		user, pass = os.Getenv(UserEnv), os.Getenv(PassEnv)
+		if user == "" && pass == "" {
+			return nil
+		}
+		rootOpts.credSrc = "environment"
+	} else {
+		rootOpts.credSrc = "command line"
+	}
+	if credReg == "" {
+		credReg = os.Getenv(CredRegistryEnv)
+	}
+	if rootOpts.passStdin {
+		passIn, err := readLine(cmd.InOrStdin())
+		if err != nil {
+			return fmt.Errorf("failed to read password from stdin: %w", err)
+		}
+		pass = strings.TrimRight(passIn, "\r\n")
+	} else if fromFlags && pass == "" {
+		pass = os.Getenv(PassEnv)
+	}
+	if user == "" || pass == "" {
+		if !fromFlags {
+			rootOpts.log.Warn("Both " + UserEnv + " and " + PassEnv + " are needed to login, ignoring the credentials from the environment")
+			return nil
+		}
+		return fmt.Errorf("a user and password are required, use --user with --pass-stdin or %s%.0w", PassEnv, ErrMissingInput)
+	}
+	if credReg == "" {
+		// default to the registry of the first argument, ignoring commands that do not take a reference
+		if len(args) > 0 {
+			parse := ref.New
+			if strings.Contains(cmd.Use, "<registry>") || strings.Contains(cmd.Use, "[registry]") {
+				parse = ref.NewHost
+			}
+			if r, err := parse(args[0]); err == nil && r.Scheme == "reg" {
+				credReg = r.Registry
+			}
+		}
+		if credReg == "" {
+			if !fromFlags {
+				rootOpts.log.Debug("No registry found for the credentials from the environment, set " + CredRegistryEnv)
+				return nil
+			}
+			return fmt.Errorf("unable to determine the registry for --user, use --cred-registry%.0w", ErrMissingInput)
+		}
+	}
+	rootOpts.credHost = &config.Host{
+		Name: config.HostNewName(credReg).Name,
+		User: user,
+		Pass: pass,
+	}
+	rootOpts.log.Debug("Using ad-hoc credentials",
+		slog.String("registry", rootOpts.credHost.Name),
+		slog.String("user", user),
+		slog.String("source", rootOpts.credSrc))
 	return nil
 }
 
+// readLine returns the first line from the reader, including the newline.
+// It reads a byte at a time, leaving the remaining input for commands that also read stdin.
+func readLine(rdr io.Reader) (string, error) {
+	line := []byte{}
+	b := make([]byte, 1)
+	for {
+		n, err := rdr.Read(b)
+		if n > 0 {
+			line = append(line, b[0])
+			if b[0] == '\n' {
+				return string(line), nil
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return string(line), nil
+		} else if err != nil {
+			return "", err
+		}
+	}
+}
+
 func (rootOpts *rootCmd) runVersion(cmd *cobra.Command, args []string) error {
 	info := version.GetInfo()
 	return template.Writer(cmd.OutOrStdout(), rootOpts.format, info)
@@ -174,6 +290,9 @@ func (rootOpts *rootCmd) newRegClient() *regclient.RegClient {
 	if conf.IncDockerCert == nil || *conf.IncDockerCert {
 		rcOpts = append(rcOpts, regclient.WithDockerCerts())
 	}
+	if conf.TokenCache != nil && *conf.TokenCache && conf.Filename != "" {
+		rcOpts = append(rcOpts, regclient.WithRegOpts(reg.WithTokenCacheFile(filepath.Join(filepath.Dir(conf.Filename), TokenCacheFilename))))
+	}
 	if conf.HostDefault != nil {
 		rcOpts = append(rcOpts, regclient.WithConfigHostDefault(*conf.HostDefault))
 	}
@@ -209,6 +328,9 @@ func (rootOpts *rootCmd) newRegClient() *regclient.RegClient {
 		}
 		rcHosts = append(rcHosts, host)
 	}
+	if rootOpts.credHost != nil {
+		rcHosts = append(rcHosts, *rootOpts.credHost)
+	}
 	if len(rcHosts) > 0 {
 		rcOpts = append(rcOpts, regclient.WithConfigHost(rcHosts...))
 	}
@@ -223,3 +345,34 @@ func flagChanged(cmd *cobra.Command, name string) bool {
 	}
 	return flag.Changed
 }
+
+// ageFlag is a duration flag that also accepts a number of days, e.g. "90d".
+type ageFlag time.Duration
+
+func (a *ageFlag) String() string {
+	if *a == 0 {
+		return ""
+	}
+	return time.Duration(*a).String()
+}
+
+func (a *ageFlag) Set(val string) error {
+	if days, ok := strings.CutSuffix(val, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return fmt.Errorf("invalid number of days %s: %w", val, err)
+		}
+		*a = ageFlag(time.Duration(n) * 24 * time.Hour)
+		return nil
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return err
+	}
+	*a = ageFlag(d)
+	return nil
+}
+
+func (a *ageFlag) Type() string {
+	return "duration"
+}
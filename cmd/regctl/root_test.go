@@ -1,7 +1,15 @@
 package main
 
 import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/opencontainers/go-digest"
 )
 
 func TestRootConfigDir(t *testing.T) {
@@ -18,3 +26,108 @@ func TestRootConfigDir(t *testing.T) {
 		t.Errorf("missing output")
 	}
 }
+
+func TestRootCreds(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "testuser" || pass != "testpass" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+		switch r.URL.Path {
+		case "/v2/":
+			w.WriteHeader(http.StatusOK)
+		case "/v2/testrepo/tags/list":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"name":"testrepo","tags":["v1","v2"]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	t.Setenv(ConfigEnv, filepath.Join(t.TempDir(), "config.json"))
+	hostFlag := "reg=" + tsHost + ",tls=disabled"
+
+	t.Run("flags", func(t *testing.T) {
+		out, err := cobraTest(t, &cobraTestOpts{stdin: strings.NewReader("testpass\n")},
+			"tag", "ls", tsHost+"/testrepo", "--host", hostFlag, "--user", "testuser", "--pass-stdin")
+		if err != nil {
+			t.Fatalf("failed to list tags: %v", err)
+		}
+		if out != "v1\nv2" {
+			t.Errorf("unexpected output: %s", out)
+		}
+	})
+	t.Run("stdin command", func(t *testing.T) {
+		// only the first line is the password, the remaining input is left for the command
+		out, err := cobraTest(t, &cobraTestOpts{stdin: strings.NewReader("testpass\nhello world")},
+			"digest", "--user", "testuser", "--pass-stdin", "--cred-registry", tsHost)
+		if err != nil {
+			t.Fatalf("failed to run digest: %v", err)
+		}
+		if out != digest.FromString("hello world").String() {
+			t.Errorf("unexpected output: %s", out)
+		}
+	})
+	t.Run("cred-registry", func(t *testing.T) {
+		out, err := cobraTest(t, &cobraTestOpts{stdin: strings.NewReader("testpass")},
+			"registry", "whoami", tsHost, "--host", hostFlag, "--user", "testuser", "--pass-stdin",
+			"--format", "{{.Source}}|{{.Auth.User}}")
+		if err != nil {
+			t.Fatalf("failed to run whoami: %v", err)
+		}
+		if out != "command line|testuser" {
+			t.Errorf("unexpected output: %s", out)
+		}
+	})
+	t.Run("env", func(t *testing.T) {
+		t.Setenv(UserEnv, "testuser")
+		t.Setenv(PassEnv, "testpass")
+		out, err := cobraTest(t, nil, "tag", "ls", tsHost+"/testrepo", "--host", hostFlag)
+		if err != nil {
+			t.Fatalf("failed to list tags: %v", err)
+		}
+		if out != "v1\nv2" {
+			t.Errorf("unexpected output: %s", out)
+		}
+		out, err = cobraTest(t, nil, "registry", "whoami", tsHost, "--host", hostFlag, "--format", "{{.Source}}")
+		if err != nil {
+			t.Fatalf("failed to run whoami: %v", err)
+		}
+		if out != "environment" {
+			t.Errorf("unexpected output: %s", out)
+		}
+	})
+	t.Run("env without registry", func(t *testing.T) {
+		t.Setenv(UserEnv, "testuser")
+		t.Setenv(PassEnv, "testpass")
+		_, err := cobraTest(t, nil, "tag", "ls", "ocidir://../../testdata/testrepo")
+		if err != nil {
+			t.Fatalf("failed to list tags: %v", err)
+		}
+	})
+	t.Run("missing pass", func(t *testing.T) {
+		_, err := cobraTest(t, nil, "tag", "ls", tsHost+"/testrepo", "--host", hostFlag, "--user", "testuser")
+		if !errors.Is(err, ErrMissingInput) {
+			t.Errorf("unexpected error, expected %v, received %v", ErrMissingInput, err)
+		}
+	})
+	t.Run("missing registry", func(t *testing.T) {
+		_, err := cobraTest(t, &cobraTestOpts{stdin: strings.NewReader("testpass")},
+			"tag", "ls", "ocidir://../../testdata/testrepo", "--user", "testuser", "--pass-stdin")
+		if !errors.Is(err, ErrMissingInput) {
+			t.Errorf("unexpected error, expected %v, received %v", ErrMissingInput, err)
+		}
+	})
+	t.Run("wrong pass", func(t *testing.T) {
+		_, err := cobraTest(t, &cobraTestOpts{stdin: strings.NewReader("badpass")},
+			"tag", "ls", tsHost+"/testrepo", "--host", hostFlag, "--user", "testuser", "--pass-stdin")
+		if err == nil {
+			t.Errorf("did not fail with the wrong password")
+		}
+	})
+}
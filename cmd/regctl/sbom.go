@@ -0,0 +1,347 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/regclient/regclient"
+	"github.com/regclient/regclient/internal/intoto"
+	"github.com/regclient/regclient/pkg/template"
+	"github.com/regclient/regclient/types/descriptor"
+	"github.com/regclient/regclient/types/errs"
+	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/ref"
+)
+
+type sbomCmd struct {
+	rootOpts *rootCmd
+	format   string
+	platform string
+	sbomType string
+	summary  bool
+}
+
+const (
+	sbomTypeSPDX      = "spdx"
+	sbomTypeCycloneDX = "cyclonedx"
+)
+
+// sbomMediaTypes maps the media types of SBOM artifacts and layers to the SBOM type.
+var sbomMediaTypes = map[string]string{
+	"application/spdx+json":          sbomTypeSPDX,
+	"application/vnd.spdx+json":      sbomTypeSPDX,
+	"text/spdx":                      sbomTypeSPDX,
+	"text/spdx+json":                 sbomTypeSPDX,
+	"application/vnd.cyclonedx+json": sbomTypeCycloneDX,
+	"application/vnd.cyclonedx+xml":  sbomTypeCycloneDX,
+	"application/vnd.cyclonedx":      sbomTypeCycloneDX,
+}
+
+// sbomPredicateTypes maps the in-toto predicate types of SBOM attestations to the SBOM type.
+var sbomPredicateTypes = map[string]string{
+	"https://spdx.dev/Document": sbomTypeSPDX,
+	"https://cyclonedx.org/bom": sbomTypeCycloneDX,
+}
+
+func NewSBOMCmd(rootOpts *rootCmd) *cobra.Command {
+	sbomOpts := sbomCmd{
+		rootOpts: rootOpts,
+	}
+	var sbomTopCmd = &cobra.Command{
+		Use:   "sbom <cmd>",
+		Short: "manage SBOMs",
+	}
+	var sbomGetCmd = &cobra.Command{
+		Use:   "get <image_ref>",
+		Short: "get the SBOM of an image",
+		Long: `Get the SBOM attached to an image.
+The referrers of the image are searched for SPDX and CycloneDX artifacts, including
+in-toto attestations with an SBOM predicate. The first SBOM found is output.
+Use --summary to show a table of the packages from a json SBOM instead of the document.`,
+		Example: `
+# output the SBOM of an image
+regctl sbom get registry.example.org/repo:v1
+
+# show the packages in the SPDX SBOM of the arm64 image
+regctl sbom get --type spdx --platform linux/arm64 --summary \
+  registry.example.org/repo:v1
+
+# list the packages as json
+regctl sbom get registry.example.org/repo:v1 --format '{{json .Packages}}'`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: rootOpts.completeArgTag,
+		RunE:              sbomOpts.runSBOMGet,
+	}
+
+	sbomGetCmd.Flags().StringVar(&sbomOpts.format, "format", "", "Format output with go template syntax")
+	_ = sbomGetCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+	sbomGetCmd.Flags().StringVarP(&sbomOpts.platform, "platform", "p", "", "Specify platform (e.g. linux/amd64 or local)")
+	_ = sbomGetCmd.RegisterFlagCompletionFunc("platform", completeArgPlatform)
+	sbomGetCmd.Flags().BoolVar(&sbomOpts.summary, "summary", false, "Show a summary table of the packages")
+	sbomGetCmd.Flags().StringVar(&sbomOpts.sbomType, "type", "", "Only return an SBOM of this type (spdx or cyclonedx)")
+	_ = sbomGetCmd.RegisterFlagCompletionFunc("type", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{sbomTypeSPDX, sbomTypeCycloneDX}, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	sbomTopCmd.AddCommand(sbomGetCmd)
+	return sbomTopCmd
+}
+
+func (sbomOpts *sbomCmd) runSBOMGet(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	r, err := ref.New(args[0])
+	if err != nil {
+		return err
+	}
+	if sbomOpts.sbomType != "" && sbomOpts.sbomType != sbomTypeSPDX && sbomOpts.sbomType != sbomTypeCycloneDX {
+		return fmt.Errorf("unsupported SBOM type %s%.0w", sbomOpts.sbomType, ErrInvalidInput)
+	}
+	rc := sbomOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, r)
+	mDesc, err := resolveDesc(ctx, rc, r, sbomOpts.platform)
+	if err != nil {
+		return err
+	}
+	rDig := r.SetDigest(mDesc.Digest.String())
+	rl, err := rc.ReferrerList(ctx, rDig)
+	if err != nil {
+		return fmt.Errorf("failed to list referrers of %s: %w", rDig.CommonName(), err)
+	}
+	var result *sbomResult
+	for _, d := range rl.Descriptors {
+		rSBOM := r.SetDigest(d.Digest.String())
+		result, err = sbomOpts.getReferrer(ctx, rc, rSBOM, d)
+		if err != nil {
+			sbomOpts.rootOpts.log.Info("Skipping referrer",
+				slog.String("referrer", rSBOM.CommonName()),
+				slog.String("err", err.Error()))
+			continue
+		}
+		if result != nil {
+			break
+		}
+	}
+	if result == nil {
+		return fmt.Errorf("no SBOM found for %s%.0w", rDig.CommonName(), errs.ErrNotFound)
+	}
+	result.Ref = rDig
+	sbomOpts.rootOpts.log.Debug("Found SBOM",
+		slog.String("ref", rDig.CommonName()),
+		slog.String("sbom", result.Source.CommonName()),
+		slog.String("type", result.Type))
+
+	// packages are only required for the summary, other formats may use a document that cannot be parsed
+	result.Packages, err = sbomPackages(result.Type, result.Content)
+	if err != nil && sbomOpts.summary {
+		return err
+	}
+	if !flagChanged(cmd, "format") {
+		if sbomOpts.summary {
+			sbomOpts.format = "{{printPretty .}}"
+		} else {
+			sbomOpts.format = "{{printf \"%s\" .Content}}"
+		}
+	}
+	return template.Writer(cmd.OutOrStdout(), sbomOpts.format, result)
+}
+
+// getReferrer returns the SBOM from a referrer, or nil if the referrer is not a matching SBOM.
+func (sbomOpts *sbomCmd) getReferrer(ctx context.Context, rc *regclient.RegClient, r ref.Ref, d descriptor.Descriptor) (*sbomResult, error) {
+	isAttestation := d.ArtifactType == intoto.MediaTypeStatement || d.ArtifactType == intoto.MediaTypeDSSE
+	if _, ok := sbomMediaTypes[d.ArtifactType]; !ok && !isAttestation {
+		return nil, nil
+	}
+	if pt, ok := d.Annotations[intoto.AnnotationPredicateType]; isAttestation && ok && !sbomOpts.matchType(sbomPredicateTypes[pt]) {
+		return nil, nil
+	}
+	m, err := rc.ManifestGet(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	mi, ok := m.(manifest.Imager)
+	if !ok {
+		return nil, nil
+	}
+	layers, err := mi.GetLayers()
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range layers {
+		if isAttestation && (l.MediaType == intoto.MediaTypeStatement || l.MediaType == intoto.MediaTypeDSSE) {
+			att, err := getAttestation(ctx, rc, r, l, nil)
+			if err != nil {
+				return nil, err
+			}
+			t := sbomPredicateTypes[att.Statement.PredicateType]
+			if t == "" || !sbomOpts.matchType(t) {
+				continue
+			}
+			return &sbomResult{
+				Source:    r,
+				MediaType: l.MediaType,
+				Type:      t,
+				Content:   att.Statement.Predicate,
+			}, nil
+		}
+		t := sbomMediaTypes[l.MediaType]
+		if t == "" && len(layers) == 1 {
+			// the artifact type describes the content of a single layer
+			t = sbomMediaTypes[d.ArtifactType]
+		}
+		if t == "" || !sbomOpts.matchType(t) {
+			continue
+		}
+		if l.Size > imageAttestLimit {
+			return nil, fmt.Errorf("SBOM size %d exceeds %d%.0w", l.Size, imageAttestLimit, errs.ErrSizeLimitExceeded)
+		}
+		rdr, err := rc.BlobGet(ctx, r, l)
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(rdr)
+		_ = rdr.Close()
+		if err != nil {
+			return nil, err
+		}
+		return &sbomResult{
+			Source:    r,
+			MediaType: l.MediaType,
+			Type:      t,
+			Content:   content,
+		}, nil
+	}
+	return nil, nil
+}
+
+func (sbomOpts *sbomCmd) matchType(t string) bool {
+	return sbomOpts.sbomType == "" || sbomOpts.sbomType == t
+}
+
+type sbomResult struct {
+	Ref       ref.Ref
+	Source    ref.Ref
+	MediaType string
+	Type      string
+	Content   []byte
+	Packages  []sbomPackage
+}
+
+type sbomPackage struct {
+	Name    string
+	Version string
+	Type    string
+	License string
+	PURL    string
+}
+
+func (result sbomResult) MarshalPretty() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "SBOM:     %s\n", result.Source.CommonName())
+	fmt.Fprintf(buf, "Type:     %s\n", result.Type)
+	fmt.Fprintf(buf, "Packages: %d\n", len(result.Packages))
+	fmt.Fprintf(buf, "\n")
+	tw := tabwriter.NewWriter(buf, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(tw, "Name\tVersion\tType\tLicense\n")
+	for _, p := range result.Packages {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", p.Name, p.Version, p.Type, p.License)
+	}
+	err := tw.Flush()
+	return buf.Bytes(), err
+}
+
+// sbomPackages parses the packages from a json SPDX or CycloneDX document.
+func sbomPackages(t string, content []byte) ([]sbomPackage, error) {
+	if !json.Valid(content) {
+		return nil, fmt.Errorf("package summary requires a json SBOM%.0w", errs.ErrUnsupportedMediaType)
+	}
+	pkgs := []sbomPackage{}
+	switch t {
+	case sbomTypeSPDX:
+		doc := struct {
+			Packages []struct {
+				Name             string `json:"name"`
+				VersionInfo      string `json:"versionInfo"`
+				PrimaryPurpose   string `json:"primaryPackagePurpose"`
+				LicenseConcluded string `json:"licenseConcluded"`
+				LicenseDeclared  string `json:"licenseDeclared"`
+				ExternalRefs     []struct {
+					ReferenceType    string `json:"referenceType"`
+					ReferenceLocator string `json:"referenceLocator"`
+				} `json:"externalRefs"`
+			} `json:"packages"`
+		}{}
+		err := json.Unmarshal(content, &doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse SPDX document: %w", err)
+		}
+		for _, p := range doc.Packages {
+			pkg := sbomPackage{
+				Name:    p.Name,
+				Version: p.VersionInfo,
+				Type:    strings.ToLower(p.PrimaryPurpose),
+				License: p.LicenseConcluded,
+			}
+			if pkg.License == "" || pkg.License == "NOASSERTION" {
+				pkg.License = p.LicenseDeclared
+			}
+			for _, er := range p.ExternalRefs {
+				if er.ReferenceType == "purl" {
+					pkg.PURL = er.ReferenceLocator
+					break
+				}
+			}
+			pkgs = append(pkgs, pkg)
+		}
+	case sbomTypeCycloneDX:
+		doc := struct {
+			Components []struct {
+				Type     string `json:"type"`
+				Name     string `json:"name"`
+				Version  string `json:"version"`
+				PURL     string `json:"purl"`
+				Licenses []struct {
+					License struct {
+						ID   string `json:"id"`
+						Name string `json:"name"`
+					} `json:"license"`
+					Expression string `json:"expression"`
+				} `json:"licenses"`
+			} `json:"components"`
+		}{}
+		err := json.Unmarshal(content, &doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CycloneDX document: %w", err)
+		}
+		for _, c := range doc.Components {
+			licenses := []string{}
+			for _, l := range c.Licenses {
+				switch {
+				case l.Expression != "":
+					licenses = append(licenses, l.Expression)
+				case l.License.ID != "":
+					licenses = append(licenses, l.License.ID)
+				case l.License.Name != "":
+					licenses = append(licenses, l.License.Name)
+				}
+			}
+			pkgs = append(pkgs, sbomPackage{
+				Name:    c.Name,
+				Version: c.Version,
+				Type:    c.Type,
+				License: strings.Join(licenses, " AND "),
+				PURL:    c.PURL,
+			})
+		}
+	default:
+		return nil, fmt.Errorf("unsupported SBOM type %s%.0w", t, errs.ErrUnsupported)
+	}
+	return pkgs, nil
+}
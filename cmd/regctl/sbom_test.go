@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/regclient/regclient/types/errs"
+)
+
+func TestSBOMGet(t *testing.T) {
+	tempDir := t.TempDir()
+	spdxDoc := `{"spdxVersion":"SPDX-2.3","packages":[{"name":"busybox","versionInfo":"1.36.1","licenseConcluded":"GPL-2.0-only","primaryPackagePurpose":"APPLICATION"}]}`
+	cdxDoc := `{"bomFormat":"CycloneDX","components":[{"type":"library","name":"musl","version":"1.2.4","licenses":[{"license":{"id":"MIT"}}]},{"type":"library","name":"zlib","version":"1.3","licenses":[{"expression":"Zlib"}]}]}`
+	cdxFile := filepath.Join(tempDir, "bom.json")
+	err := os.WriteFile(cdxFile, []byte(cdxDoc), 0600)
+	if err != nil {
+		t.Fatalf("failed to write SBOM: %v", err)
+	}
+	imgRef := "ocidir://" + tempDir + "/repo:v1"
+	otherRef := "ocidir://" + tempDir + "/repo:v2"
+	_, err = cobraTest(t, nil, "image", "copy", "ocidir://../../testdata/testrepo:v1", imgRef)
+	if err != nil {
+		t.Fatalf("failed to copy image: %v", err)
+	}
+	_, err = cobraTest(t, nil, "image", "copy", "ocidir://../../testdata/testrepo:v2", otherRef)
+	if err != nil {
+		t.Fatalf("failed to copy image: %v", err)
+	}
+	_, err = cobraTest(t, &cobraTestOpts{stdin: strings.NewReader(spdxDoc)}, "artifact", "put", "--subject", imgRef,
+		"--artifact-type", "application/spdx+json", "--file-media-type", "application/spdx+json")
+	if err != nil {
+		t.Fatalf("failed to push SBOM: %v", err)
+	}
+	_, err = cobraTest(t, nil, "image", "attest", "--type", "cyclonedx", "--predicate", cdxFile, otherRef)
+	if err != nil {
+		t.Fatalf("failed to attest SBOM: %v", err)
+	}
+
+	tt := []struct {
+		name        string
+		args        []string
+		expectErr   error
+		expectOut   string
+		outContains bool
+	}{
+		{
+			name:      "document",
+			args:      []string{"sbom", "get", imgRef},
+			expectOut: spdxDoc,
+		},
+		{
+			name:        "summary",
+			args:        []string{"sbom", "get", imgRef, "--summary"},
+			expectOut:   "busybox 1.36.1  application GPL-2.0-only",
+			outContains: true,
+		},
+		{
+			name:      "type mismatch",
+			args:      []string{"sbom", "get", imgRef, "--type", "cyclonedx"},
+			expectErr: errs.ErrNotFound,
+		},
+		{
+			name:      "invalid type",
+			args:      []string{"sbom", "get", imgRef, "--type", "unknown"},
+			expectErr: ErrInvalidInput,
+		},
+		{
+			name:      "attestation",
+			args:      []string{"sbom", "get", otherRef, "--type", "cyclonedx"},
+			expectOut: cdxDoc,
+		},
+		{
+			name:      "attestation packages",
+			args:      []string{"sbom", "get", otherRef, "--format", "{{range .Packages}}{{.Name}}={{.License}} {{end}}"},
+			expectOut: "musl=MIT zlib=Zlib",
+		},
+		{
+			name:      "missing",
+			args:      []string{"sbom", "get", "ocidir://../../testdata/testrepo:v3"},
+			expectErr: errs.ErrNotFound,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := cobraTest(t, nil, tc.args...)
+			if tc.expectErr != nil {
+				if err == nil {
+					t.Errorf("did not receive expected error: %v", tc.expectErr)
+				} else if !errors.Is(err, tc.expectErr) && err.Error() != tc.expectErr.Error() {
+					t.Errorf("unexpected error, received %v, expected %v", err, tc.expectErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("returned unexpected error: %v", err)
+			}
+			if (!tc.outContains && out != tc.expectOut) || (tc.outContains && !strings.Contains(out, tc.expectOut)) {
+				t.Errorf("unexpected output, expected %s, received %s", tc.expectOut, out)
+			}
+		})
+	}
+}
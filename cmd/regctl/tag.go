@@ -1,24 +1,41 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
 	"log/slog"
 	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
 
+	"github.com/opencontainers/go-digest"
 	"github.com/spf13/cobra"
 
+	"github.com/regclient/regclient"
+	"github.com/regclient/regclient/internal/semver"
 	"github.com/regclient/regclient/pkg/template"
 	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/types/errs"
 	"github.com/regclient/regclient/types/ref"
+	"github.com/regclient/regclient/types/tag"
 )
 
 type tagCmd struct {
-	rootOpts *rootCmd
-	limit    int
-	last     string
-	include  []string
-	exclude  []string
-	format   string
+	rootOpts  *rootCmd
+	limit     int
+	last      string
+	include   []string
+	exclude   []string
+	filter    string
+	format    string
+	reverse   bool
+	sort      string
+	dryRun    bool
+	force     bool
+	olderThan time.Duration
 }
 
 func NewTagCmd(rootOpts *rootCmd) *cobra.Command {
@@ -30,39 +47,90 @@ func NewTagCmd(rootOpts *rootCmd) *cobra.Command {
 		Short: "manage tags",
 	}
 	var tagDeleteCmd = &cobra.Command{
-		Use:     "delete <image_ref>",
+		Use:     "delete <image_ref | repository>",
 		Aliases: []string{"del", "rm", "remove"},
 		Short:   "delete a tag in a repo",
 		Long: `Delete a tag in a repository.
 This avoids deleting the manifest when multiple tags reference the same image.
 For registries that do not support the OCI tag delete API, this is implemented
 by pushing a unique dummy manifest and deleting that by digest.
-If the registry does not support the delete API, the dummy manifest will remain.`,
+If the registry does not support the delete API, the dummy manifest will remain.
+With --filter or --older-than, every matching tag in the repository is deleted.
+Each tag is confirmed before it is deleted unless --force is set.
+The age of an image is read from the created annotation or the image config,
+tags without a created time are not selected by --older-than.
+Use --dry-run to list the matching tags without deleting them.`,
 		Example: `
 # delete a tag
-regctl tag delete registry.example.org/repo:v42`,
+regctl tag delete registry.example.org/repo:v42
+
+# list the pr tags created more than 30 days ago
+regctl tag rm registry.example.org/repo --filter '^pr-' --older-than 720h --dry-run
+
+# delete those tags without prompting
+regctl tag rm registry.example.org/repo --filter '^pr-' --older-than 720h --force`,
 		Args:              cobra.ExactArgs(1),
 		ValidArgsFunction: rootOpts.completeArgTag,
 		RunE:              tagOpts.runTagDelete,
 	}
+	var tagInspectCmd = &cobra.Command{
+		Use:   "inspect <image_ref>",
+		Short: "show metadata for a tag",
+		Long: `Show the metadata for a tag.
+The digest and media type are read from the registry.
+For Docker Hub, Quay, and Harbor, the vendor API is queried for the push time,
+last pull time, pull count of the repository, and the user that pushed the tag.
+Docker Hub and quay.io are detected automatically, other registries need the
+"hubAPI", "quayAPI", or "harborAPI" option set with "regctl registry set --api-opts".
+When the vendor API is not available, only the registry data is output.`,
+		Example: `
+# show the metadata for a tag on Docker Hub
+regctl tag inspect alpine:latest
+
+# enable the Harbor API and show a tag
+regctl registry set harbor.example.org --api-opts harborAPI=true
+regctl tag inspect harbor.example.org/project/repo:v1
+
+# output the time the tag was pushed
+regctl tag inspect quay.io/org/repo:v1 --format '{{.Vendor.Pushed}}'`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: rootOpts.completeArgTag,
+		RunE:              tagOpts.runTagInspect,
+	}
 	var tagLsCmd = &cobra.Command{
 		Use:     "ls <repository>",
 		Aliases: []string{"list"},
 		Short:   "list tags in a repo",
 		Long: `List tags in a repository.
 Note: many registries ignore the pagination options.
+When tags are filtered or sorted, every page of tags is retrieved from the
+registry and --limit is applied to the result.
+Sorting by semver excludes tags that are not a version.
 For an OCI Layout, the index is available as Index (--format "{{.Index}}").`,
 		Example: `
 # list all tags in a repository
 regctl tag ls registry.example.org/repo
 
 # exclude tags starting with sha256- from the listing
-regctl tag ls registry.example.org/repo --exclude 'sha256-.*'`,
-		Args:      cobra.ExactArgs(1),
-		ValidArgs: []string{},
-		RunE:      tagOpts.runTagLs,
+regctl tag ls registry.example.org/repo --exclude 'sha256-.*'
+
+# show the latest 1.2.x tag
+regctl tag ls registry.example.org/repo --filter '^v?1\.2\.' --sort semver --reverse --limit 1`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: rootOpts.completeArgRepo,
+		RunE:              tagOpts.runTagLs,
 	}
 
+	tagDeleteCmd.Flags().BoolVar(&tagOpts.dryRun, "dry-run", false, "Output the tags that would be deleted without deleting them")
+	tagDeleteCmd.Flags().StringVar(&tagOpts.filter, "filter", "", "Regexp of tags to delete (expression matches any part of the tag)")
+	_ = tagDeleteCmd.RegisterFlagCompletionFunc("filter", completeArgNone)
+	tagDeleteCmd.Flags().BoolVar(&tagOpts.force, "force", false, "Delete matching tags without confirmation")
+	tagDeleteCmd.Flags().Var((*ageFlag)(&tagOpts.olderThan), "older-than", "Delete tags on images created longer ago than the duration, e.g. 720h or 30d")
+	_ = tagDeleteCmd.RegisterFlagCompletionFunc("older-than", completeArgNone)
+
+	tagInspectCmd.Flags().StringVarP(&tagOpts.format, "format", "", "{{printPretty .}}", "Format output with go template syntax")
+	_ = tagInspectCmd.RegisterFlagCompletionFunc("format", completeArgNone)
+
 	tagLsCmd.Flags().StringVarP(&tagOpts.last, "last", "", "", "Specify the last tag from a previous request for pagination (depends on registry support)")
 	_ = tagLsCmd.RegisterFlagCompletionFunc("last", completeArgNone)
 	tagLsCmd.Flags().IntVarP(&tagOpts.limit, "limit", "", 0, "Specify the number of tags to retrieve (depends on registry support)")
@@ -71,10 +139,18 @@ regctl tag ls registry.example.org/repo --exclude 'sha256-.*'`,
 	_ = tagLsCmd.RegisterFlagCompletionFunc("include", completeArgNone)
 	tagLsCmd.Flags().StringArrayVar(&tagOpts.exclude, "exclude", []string{}, "Regexp of tags to exclude (expression is bound to beginning and ending of tag)")
 	_ = tagLsCmd.RegisterFlagCompletionFunc("exclude", completeArgNone)
+	tagLsCmd.Flags().StringVar(&tagOpts.filter, "filter", "", "Regexp of tags to include (expression matches any part of the tag)")
+	_ = tagLsCmd.RegisterFlagCompletionFunc("filter", completeArgNone)
+	tagLsCmd.Flags().BoolVar(&tagOpts.reverse, "reverse", false, "Reverse the sort order")
+	tagLsCmd.Flags().StringVar(&tagOpts.sort, "sort", "none", "Sort tags: none, alphabetical, or semver")
+	_ = tagLsCmd.RegisterFlagCompletionFunc("sort", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"none", "alphabetical", "semver"}, cobra.ShellCompDirectiveNoFileComp
+	})
 	tagLsCmd.Flags().StringVarP(&tagOpts.format, "format", "", "{{printPretty .}}", "Format output with go template syntax")
 	_ = tagLsCmd.RegisterFlagCompletionFunc("format", completeArgNone)
 
 	tagTopCmd.AddCommand(tagDeleteCmd)
+	tagTopCmd.AddCommand(tagInspectCmd)
 	tagTopCmd.AddCommand(tagLsCmd)
 	return tagTopCmd
 }
@@ -87,6 +163,13 @@ func (tagOpts *tagCmd) runTagDelete(cmd *cobra.Command, args []string) error {
 	}
 	rc := tagOpts.rootOpts.newRegClient()
 	defer rc.Close(ctx, r)
+	if tagOpts.filter != "" || tagOpts.olderThan != 0 {
+		return tagOpts.runTagDeleteBulk(cmd, rc, r)
+	}
+	if tagOpts.dryRun {
+		fmt.Fprintln(cmd.OutOrStdout(), r.Tag)
+		return nil
+	}
 	tagOpts.rootOpts.log.Debug("Delete tag",
 		slog.String("host", r.Registry),
 		slog.String("repository", r.Repository),
@@ -98,6 +181,132 @@ func (tagOpts *tagCmd) runTagDelete(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// runTagDeleteBulk deletes every tag in the repository selected by the filter and age.
+// Each deleted tag is output, and tags are confirmed on stdin unless forced.
+func (tagOpts *tagCmd) runTagDeleteBulk(cmd *cobra.Command, rc *regclient.RegClient, r ref.Ref) error {
+	ctx := cmd.Context()
+	if r.Digest != "" {
+		return fmt.Errorf("digest is not supported when deleting tags by filter or age: %s%.0w", r.CommonName(), errs.ErrInvalidReference)
+	}
+	if tagOpts.olderThan < 0 {
+		return fmt.Errorf("older-than must be positive: %s", tagOpts.olderThan.String())
+	}
+	opts := []regclient.CleanupOpts{}
+	if tagOpts.filter != "" {
+		if _, err := regexp.Compile(tagOpts.filter); err != nil {
+			return fmt.Errorf("failed to parse regexp \"%s\": %w", tagOpts.filter, err)
+		}
+		// cleanup expressions are anchored, allow the filter to match any part of the tag
+		opts = append(opts, regclient.CleanupWithInclude(".*(?:"+tagOpts.filter+").*"))
+	}
+	if tagOpts.olderThan > 0 {
+		opts = append(opts, regclient.CleanupWithOlderThan(time.Now().Add(-1*tagOpts.olderThan)))
+	}
+	tagOpts.rootOpts.log.Debug("Selecting tags to delete",
+		slog.String("host", r.Registry),
+		slog.String("repository", r.Repository),
+		slog.String("filter", tagOpts.filter),
+		slog.String("older-than", tagOpts.olderThan.String()))
+	plan, err := rc.CleanupPlan(ctx, r, opts...)
+	if err != nil {
+		return err
+	}
+	if tagOpts.dryRun {
+		for _, t := range plan.Tags {
+			fmt.Fprintln(cmd.OutOrStdout(), t.Tag)
+		}
+		return nil
+	}
+	reader := bufio.NewReader(cmd.InOrStdin())
+	for _, t := range plan.Tags {
+		rTag := r.SetTag(t.Tag)
+		if !tagOpts.force {
+			fmt.Fprintf(cmd.OutOrStdout(), "Delete tag %s [y/N]: ", rTag.CommonName())
+			resp, _ := reader.ReadString('\n')
+			resp = strings.ToLower(strings.TrimSpace(resp))
+			if resp != "y" && resp != "yes" {
+				tagOpts.rootOpts.log.Info("Skipping tag",
+					slog.String("tag", rTag.CommonName()))
+				continue
+			}
+		}
+		tagOpts.rootOpts.log.Debug("Delete tag",
+			slog.String("host", rTag.Registry),
+			slog.String("repository", rTag.Repository),
+			slog.String("tag", rTag.Tag))
+		err = rc.TagDelete(ctx, rTag)
+		if err != nil {
+			return fmt.Errorf("failed to delete tag %s: %w", rTag.CommonName(), err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), t.Tag)
+	}
+	return nil
+}
+
+type tagInspectResult struct {
+	Ref       string        `json:"ref"`
+	Digest    digest.Digest `json:"digest"`
+	MediaType string        `json:"mediaType"`
+	Vendor    *tag.Info     `json:"vendor,omitempty"`
+}
+
+func (tagOpts *tagCmd) runTagInspect(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	r, err := ref.New(args[0])
+	if err != nil {
+		return err
+	}
+	if r.Tag == "" {
+		return fmt.Errorf("tag is required: %s%.0w", r.CommonName(), errs.ErrMissingTag)
+	}
+	rc := tagOpts.rootOpts.newRegClient()
+	defer rc.Close(ctx, r)
+	tagOpts.rootOpts.log.Debug("Inspect tag",
+		slog.String("host", r.Registry),
+		slog.String("repository", r.Repository),
+		slog.String("tag", r.Tag))
+	m, err := rc.ManifestHead(ctx, r, regclient.WithManifestRequireDigest())
+	if err != nil {
+		return err
+	}
+	result := tagInspectResult{
+		Ref:       r.CommonName(),
+		Digest:    m.GetDescriptor().Digest,
+		MediaType: m.GetDescriptor().MediaType,
+	}
+	info, err := rc.TagInfo(ctx, r)
+	if err != nil {
+		// vendor data is optional, the registry data is still output
+		tagOpts.rootOpts.log.Info("Vendor metadata is not available",
+			slog.String("ref", r.CommonName()),
+			slog.String("err", err.Error()))
+	} else {
+		result.Vendor = &info
+	}
+	return template.Writer(cmd.OutOrStdout(), tagOpts.format, result)
+}
+
+func (tir tagInspectResult) MarshalPretty() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	tw := tabwriter.NewWriter(buf, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(tw, "Name:\t%s\n", tir.Ref)
+	fmt.Fprintf(tw, "Digest:\t%s\n", tir.Digest.String())
+	fmt.Fprintf(tw, "MediaType:\t%s\n", tir.MediaType)
+	err := tw.Flush()
+	if err != nil {
+		return nil, err
+	}
+	if tir.Vendor != nil {
+		vb, err := tir.Vendor.MarshalPretty()
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString("\n")
+		buf.Write(vb)
+	}
+	return buf.Bytes(), nil
+}
+
 func (tagOpts *tagCmd) runTagLs(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
 	r, err := ref.New(args[0])
@@ -120,22 +329,56 @@ func (tagOpts *tagCmd) runTagLs(cmd *cobra.Command, args []string) error {
 		}
 		reExclude = append(reExclude, re)
 	}
+	var reFilter *regexp.Regexp
+	if tagOpts.filter != "" {
+		reFilter, err = regexp.Compile(tagOpts.filter)
+		if err != nil {
+			return fmt.Errorf("failed to parse regexp \"%s\": %w", tagOpts.filter, err)
+		}
+	}
+	switch tagOpts.sort {
+	case "none", "alphabetical", "semver":
+	default:
+		return fmt.Errorf("unknown sort %s, use none, alphabetical, or semver%.0w", tagOpts.sort, errs.ErrUnsupported)
+	}
+	// filtering and sorting requires the full list, the limit is applied to the result
+	local := len(reInclude) > 0 || len(reExclude) > 0 || reFilter != nil || tagOpts.sort != "none" || tagOpts.reverse
 	rc := tagOpts.rootOpts.newRegClient()
 	defer rc.Close(ctx, r)
 	tagOpts.rootOpts.log.Debug("Listing tags",
 		slog.String("host", r.Registry),
 		slog.String("repository", r.Repository))
 	opts := []scheme.TagOpts{}
-	if tagOpts.limit != 0 {
+	if tagOpts.limit != 0 && !local {
 		opts = append(opts, scheme.WithTagLimit(tagOpts.limit))
 	}
 	if tagOpts.last != "" {
 		opts = append(opts, scheme.WithTagLast(tagOpts.last))
 	}
-	tl, err := rc.TagList(ctx, r, opts...)
+	var tl *tag.List
+	if local {
+		err = rc.TagListWalk(ctx, r, func(page *tag.List) error {
+			if tl == nil {
+				tl = page
+				return nil
+			}
+			return tl.Append(page)
+		}, opts...)
+	} else {
+		tl, err = rc.TagList(ctx, r, opts...)
+	}
 	if err != nil {
 		return err
 	}
+	if reFilter != nil {
+		filtered := []string{}
+		for _, t := range tl.Tags {
+			if reFilter.MatchString(t) {
+				filtered = append(filtered, t)
+			}
+		}
+		tl.Tags = filtered
+	}
 	if len(reInclude) > 0 || len(reExclude) > 0 {
 		filtered := []string{}
 		var included, excluded bool
@@ -161,6 +404,24 @@ func (tagOpts *tagCmd) runTagLs(cmd *cobra.Command, args []string) error {
 		}
 		tl.Tags = filtered
 	}
+	switch tagOpts.sort {
+	case "alphabetical":
+		sort.Strings(tl.Tags)
+	case "semver":
+		tl.Tags = tagSortSemver(tl.Tags)
+	}
+	if tagOpts.reverse {
+		for i, j := 0, len(tl.Tags)-1; i < j; i, j = i+1, j-1 {
+			tl.Tags[i], tl.Tags[j] = tl.Tags[j], tl.Tags[i]
+		}
+	}
+	if tagOpts.limit > 0 && len(tl.Tags) > tagOpts.limit {
+		tl.Tags = tl.Tags[:tagOpts.limit]
+	}
+	// the pretty output sorts alphabetically, output the sorted tags instead
+	if (tagOpts.sort != "none" || tagOpts.reverse) && tagOpts.format == "{{printPretty .}}" {
+		tagOpts.format = "{{range .Tags}}{{println .}}{{end}}"
+	}
 	switch tagOpts.format {
 	case "raw":
 		tagOpts.format = "{{ range $key,$vals := .RawHeaders}}{{range $val := $vals}}{{printf \"%s: %s\\n\" $key $val }}{{end}}{{end}}{{printf \"\\n%s\" .RawBody}}"
@@ -171,3 +432,31 @@ func (tagOpts *tagCmd) runTagLs(cmd *cobra.Command, args []string) error {
 	}
 	return template.Writer(cmd.OutOrStdout(), tagOpts.format, tl)
 }
+
+// tagSortSemver returns the tags that parse as a version, sorted in ascending order.
+// Tags with the same precedence, e.g. "1.2" and "1.2.0", are sorted alphabetically.
+func tagSortSemver(tags []string) []string {
+	type tagVer struct {
+		tag string
+		ver semver.Version
+	}
+	tvs := []tagVer{}
+	for _, t := range tags {
+		v, err := semver.Parse(t)
+		if err != nil {
+			continue
+		}
+		tvs = append(tvs, tagVer{tag: t, ver: v})
+	}
+	sort.Slice(tvs, func(i, j int) bool {
+		if c := semver.Compare(tvs[i].ver, tvs[j].ver); c != 0 {
+			return c < 0
+		}
+		return tvs[i].tag < tvs[j].tag
+	})
+	result := make([]string, len(tvs))
+	for i, tv := range tvs {
+		result[i] = tv.tag
+	}
+	return result
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/regclient/regclient/internal/copyfs"
 	"github.com/regclient/regclient/types/errs"
 )
 
@@ -51,6 +52,31 @@ func TestTagList(t *testing.T) {
 			expectOut:   "v1\nv2\nv3",
 			outContains: true,
 		},
+		{
+			name:      "List tags regexp filter",
+			args:      []string{"tag", "ls", "--filter", "^b", "ocidir://../../testdata/testrepo"},
+			expectOut: "b1\nb2\nb3",
+		},
+		{
+			name:      "List tags semver",
+			args:      []string{"tag", "ls", "--sort", "semver", "ocidir://../../testdata/testrepo"},
+			expectOut: "v1\nv2\nv3",
+		},
+		{
+			name:      "List tags latest semver",
+			args:      []string{"tag", "ls", "--sort", "semver", "--reverse", "--limit", "1", "ocidir://../../testdata/testrepo"},
+			expectOut: "v3",
+		},
+		{
+			name:      "List tags reverse alphabetical",
+			args:      []string{"tag", "ls", "--filter", "^a", "--sort", "alphabetical", "--reverse", "ocidir://../../testdata/testrepo"},
+			expectOut: "ai\na2\na1\na-example\na-docker",
+		},
+		{
+			name:      "List tags unknown sort",
+			args:      []string{"tag", "ls", "--sort", "random", "ocidir://../../testdata/testrepo"},
+			expectErr: errs.ErrUnsupported,
+		},
 		{
 			name:        "List tags formatted",
 			args:        []string{"tag", "ls", "--format", "raw", "ocidir://../../testdata/testrepo"},
@@ -78,3 +104,120 @@ func TestTagList(t *testing.T) {
 		})
 	}
 }
+
+func TestTagDelete(t *testing.T) {
+	tmpDir := t.TempDir()
+	err := copyfs.Copy(tmpDir+"/testrepo", "../../testdata/testrepo")
+	if err != nil {
+		t.Fatalf("failed to copy testrepo: %v", err)
+	}
+	repo := "ocidir://" + tmpDir + "/testrepo"
+
+	t.Run("Dry run", func(t *testing.T) {
+		out, err := cobraTest(t, nil, "tag", "rm", "--filter", "^b", "--dry-run", repo)
+		if err != nil {
+			t.Fatalf("failed to run dry run: %v", err)
+		}
+		if out != "b1\nb2\nb3" {
+			t.Errorf("unexpected output, expected b1 b2 b3, received %s", out)
+		}
+		out, err = cobraTest(t, nil, "tag", "ls", "--filter", "^b", repo)
+		if err != nil {
+			t.Fatalf("failed to list tags: %v", err)
+		}
+		if out != "b1\nb2\nb3" {
+			t.Errorf("dry run deleted tags, remaining: %s", out)
+		}
+	})
+	t.Run("Invalid filter", func(t *testing.T) {
+		_, err := cobraTest(t, nil, "tag", "rm", "--filter", "[", "--force", repo)
+		if err == nil {
+			t.Errorf("invalid filter did not fail")
+		}
+	})
+	t.Run("Not older", func(t *testing.T) {
+		out, err := cobraTest(t, nil, "tag", "rm", "--filter", "^b", "--older-than", "876000h", "--dry-run", repo)
+		if err != nil {
+			t.Fatalf("failed to run dry run: %v", err)
+		}
+		if out != "" {
+			t.Errorf("unexpected tags selected: %s", out)
+		}
+	})
+	t.Run("Confirm", func(t *testing.T) {
+		out, err := cobraTest(t, &cobraTestOpts{stdin: strings.NewReader("y\nn\n")}, "tag", "rm", "--filter", "^b[12]$", repo)
+		if err != nil {
+			t.Fatalf("failed to delete tags: %v", err)
+		}
+		if !strings.Contains(out, "Delete tag") {
+			t.Errorf("confirmation prompt missing: %s", out)
+		}
+		out, err = cobraTest(t, nil, "tag", "ls", "--filter", "^b", repo)
+		if err != nil {
+			t.Fatalf("failed to list tags: %v", err)
+		}
+		if out != "b2\nb3" {
+			t.Errorf("unexpected remaining tags, expected b2 b3, received %s", out)
+		}
+	})
+	t.Run("Force", func(t *testing.T) {
+		out, err := cobraTest(t, nil, "tag", "rm", "--filter", "^b", "--force", repo)
+		if err != nil {
+			t.Fatalf("failed to delete tags: %v", err)
+		}
+		if out != "b2\nb3" {
+			t.Errorf("unexpected output, expected b2 b3, received %s", out)
+		}
+		out, err = cobraTest(t, nil, "tag", "ls", "--filter", "^b", repo)
+		if err != nil {
+			t.Fatalf("failed to list tags: %v", err)
+		}
+		if out != "" {
+			t.Errorf("tags were not deleted: %s", out)
+		}
+		out, err = cobraTest(t, nil, "tag", "ls", "--filter", "^v[0-9]$", repo)
+		if err != nil {
+			t.Fatalf("failed to list tags: %v", err)
+		}
+		if out != "v1\nv2\nv3" {
+			t.Errorf("unexpected tags deleted, remaining: %s", out)
+		}
+	})
+}
+
+func TestTagInspect(t *testing.T) {
+	repo := "ocidir://../../testdata/testrepo"
+	t.Run("Missing tag", func(t *testing.T) {
+		_, err := cobraTest(t, nil, "tag", "inspect", repo+"@sha256:190c9253f7a319f0d7f7b8cdd8c63894051be55aeb0c319555e5d075b229cf09")
+		if !errors.Is(err, errs.ErrMissingTag) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+	t.Run("Missing manifest", func(t *testing.T) {
+		_, err := cobraTest(t, nil, "tag", "inspect", repo+":missing")
+		if !errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+	t.Run("Registry data", func(t *testing.T) {
+		out, err := cobraTest(t, nil, "tag", "inspect", repo+":v1")
+		if err != nil {
+			t.Fatalf("failed to inspect tag: %v", err)
+		}
+		if !strings.Contains(out, "Digest:    sha256:") || !strings.Contains(out, "MediaType: application/vnd.oci.image.index.v1+json") {
+			t.Errorf("unexpected output: %s", out)
+		}
+		if strings.Contains(out, "Vendor:") {
+			t.Errorf("unexpected vendor data for an ocidir: %s", out)
+		}
+	})
+	t.Run("Format", func(t *testing.T) {
+		out, err := cobraTest(t, nil, "tag", "inspect", "--format", "{{.MediaType}} {{if .Vendor}}vendor{{else}}none{{end}}", repo+":v1")
+		if err != nil {
+			t.Fatalf("failed to inspect tag: %v", err)
+		}
+		if out != "application/vnd.oci.image.index.v1+json none" {
+			t.Errorf("unexpected output: %s", out)
+		}
+	})
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/regclient/regclient/pkg/template"
+	"github.com/regclient/regclient/types/ref"
+)
+
+const (
+	// placeholders used to generate a pattern matching previous backups
+	backupMarkDate   = "\x01"
+	backupMarkDigest = "\x02"
+	backupMarkTag    = "\x03"
+)
+
+// backupData is passed to the backup template
+type backupData struct {
+	Ref      ref.Ref      // target about to be overwritten
+	Step     ConfigSync   // same as Sync
+	Sync     ConfigSync   // current sync step
+	Tag      string       // tag of the target
+	Digest   backupDigest // digest of the target
+	Date     string       // current date in UTC, e.g. 20240131
+	DateTime string       // current date and time in UTC, e.g. 20240131-150405
+}
+
+// backupDigest adds a Short method to the digest for templates
+type backupDigest struct {
+	digest.Digest
+}
+
+// Short returns the first 12 characters of the encoded digest.
+func (d backupDigest) Short() string {
+	if d.Digest == "" {
+		return ""
+	}
+	enc := d.Encoded()
+	if len(enc) > 12 {
+		return enc[:12]
+	}
+	return enc
+}
+
+// backupPattern returns a regexp for the backup tags generated by the template with any date and digest.
+// Each date in the tag is returned as a submatch for sorting.
+func backupPattern(tmpl string, data backupData) (*regexp.Regexp, error) {
+	data.Digest = backupDigest{Digest: digest.Digest("sha256:" + backupMarkDigest)}
+	data.Date = backupMarkDate
+	data.DateTime = backupMarkDate
+	str, err := template.String(tmpl, data)
+	if err != nil {
+		return nil, err
+	}
+	str = strings.TrimSpace(str)
+	if strings.ContainsAny(str, ":/") {
+		i := strings.LastIndex(str, ":")
+		if strings.ContainsAny(str[:i+1], backupMarkDate+backupMarkDigest) {
+			return nil, fmt.Errorf("backup retention requires the date and digest to only be used in the tag of %s%.0w", tmpl, ErrInvalidInput)
+		}
+		str = str[i+1:]
+	}
+	if !strings.Contains(str, backupMarkDate) {
+		return nil, fmt.Errorf("backup retention requires .Date or .DateTime in the tag of %s%.0w", tmpl, ErrInvalidInput)
+	}
+	return backupCompile(str)
+}
+
+// backupTagPattern returns a regexp for backup tags of any target tag in the target repository.
+// Nil is returned when the backups are pushed to a different repository or the template cannot be matched.
+func backupTagPattern(s ConfigSync, tgtRepo ref.Ref) *regexp.Regexp {
+	data := backupData{
+		Ref:      tgtRepo.SetTag(backupMarkTag),
+		Step:     s,
+		Sync:     s,
+		Tag:      backupMarkTag,
+		Digest:   backupDigest{Digest: digest.Digest("sha256:" + backupMarkDigest)},
+		Date:     backupMarkDate,
+		DateTime: backupMarkDate,
+	}
+	str, err := template.String(s.Backup, data)
+	if err != nil {
+		return nil
+	}
+	str = strings.TrimSpace(str)
+	if strings.ContainsAny(str, ":/") {
+		return nil
+	}
+	exp, err := backupCompile(str)
+	if err != nil {
+		return nil
+	}
+	return exp
+}
+
+// backupCompile converts an expanded template with placeholders to a regexp.
+func backupCompile(str string) (*regexp.Regexp, error) {
+	exp := regexp.QuoteMeta(str)
+	exp = strings.ReplaceAll(exp, backupMarkDate, `([0-9]{8}(?:-[0-9]{6})?)`)
+	exp = strings.ReplaceAll(exp, backupMarkDigest, `[0-9a-f]+`)
+	exp = strings.ReplaceAll(exp, backupMarkTag, `[A-Za-z0-9_.-]+`)
+	return regexp.Compile("^" + exp + "$")
+}
+
+// backupPrune deletes the oldest backups beyond the number to keep, always keeping the backup just created.
+func (rootOpts *rootCmd) backupPrune(ctx context.Context, s ConfigSync, data backupData, backupRef ref.Ref) error {
+	exp, err := backupPattern(s.Backup, data)
+	if err != nil {
+		return err
+	}
+	tl, err := rootOpts.rc.TagList(ctx, backupRef)
+	if err != nil {
+		return fmt.Errorf("failed to list backups in %s: %w", backupRef.CommonName(), err)
+	}
+	tags, err := tl.GetTags()
+	if err != nil {
+		return err
+	}
+	type backupTag struct {
+		tag, date string
+	}
+	backups := []backupTag{}
+	for _, tag := range tags {
+		if tag == backupRef.Tag {
+			continue
+		}
+		match := exp.FindStringSubmatch(tag)
+		if match == nil {
+			continue
+		}
+		backups = append(backups, backupTag{tag: tag, date: strings.Join(match[1:], "-")})
+	}
+	if len(backups) < s.BackupKeep {
+		return nil
+	}
+	// newest first
+	sort.Slice(backups, func(i, j int) bool {
+		if backups[i].date != backups[j].date {
+			return backups[i].date > backups[j].date
+		}
+		return backups[i].tag > backups[j].tag
+	})
+	for _, b := range backups[s.BackupKeep-1:] {
+		rDel := backupRef.SetTag(b.tag)
+		rootOpts.log.Info("Removing backup",
+			slog.String("backup", rDel.CommonName()),
+			slog.Int("keep", s.BackupKeep))
+		err = rootOpts.rc.TagDelete(ctx, rDel)
+		if err != nil {
+			return fmt.Errorf("failed to delete backup %s: %w", rDel.CommonName(), err)
+		}
+	}
+	return nil
+}
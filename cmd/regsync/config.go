@@ -33,6 +33,7 @@ type Config struct {
 // ConfigDefaults is uses for general options and defaults for ConfigSync entries
 type ConfigDefaults struct {
 	Backup          string                 `yaml:"backup" json:"backup"`
+	BackupKeep      int                    `yaml:"backupKeep" json:"backupKeep"`
 	Interval        time.Duration          `yaml:"interval" json:"interval"`
 	Schedule        string                 `yaml:"schedule" json:"schedule"`
 	RateLimit       ConfigRateLimit        `yaml:"ratelimit" json:"ratelimit"`
@@ -45,12 +46,15 @@ type ConfigDefaults struct {
 	FastCheck       *bool                  `yaml:"fastCheck" json:"fastCheck"`
 	ForceRecursive  *bool                  `yaml:"forceRecursive" json:"forceRecursive"`
 	IncludeExternal *bool                  `yaml:"includeExternal" json:"includeExternal"`
+	Locked          *bool                  `yaml:"locked" json:"locked"`
 	MediaTypes      []string               `yaml:"mediaTypes" json:"mediaTypes"`
 	Hooks           ConfigHooks            `yaml:"hooks" json:"hooks"`
 	// general options
+	BlobCache      string        `yaml:"blobCache" json:"blobCache"`
 	BlobLimit      int64         `yaml:"blobLimit" json:"blobLimit"`
 	CacheCount     int           `yaml:"cacheCount" json:"cacheCount"`
 	CacheTime      time.Duration `yaml:"cacheTime" json:"cacheTime"`
+	LockFile       string        `yaml:"lockFile" json:"lockFile"`
 	SkipDockerConf bool          `yaml:"skipDockerConfig" json:"skipDockerConfig"`
 	UserAgent      string        `yaml:"userAgent" json:"userAgent"`
 }
@@ -78,7 +82,10 @@ type ConfigSync struct {
 	FastCheck       *bool                  `yaml:"fastCheck" json:"fastCheck"`
 	ForceRecursive  *bool                  `yaml:"forceRecursive" json:"forceRecursive"`
 	IncludeExternal *bool                  `yaml:"includeExternal" json:"includeExternal"`
+	Locked          *bool                  `yaml:"locked" json:"locked"`
+	Prune           *bool                  `yaml:"prune" json:"prune"`
 	Backup          string                 `yaml:"backup" json:"backup"`
+	BackupKeep      int                    `yaml:"backupKeep" json:"backupKeep"`
 	Interval        time.Duration          `yaml:"interval" json:"interval"`
 	Schedule        string                 `yaml:"schedule" json:"schedule"`
 	RateLimit       ConfigRateLimit        `yaml:"ratelimit" json:"ratelimit"`
@@ -88,8 +95,18 @@ type ConfigSync struct {
 
 // AllowDeny is an allow and deny list of regex strings
 type AllowDeny struct {
-	Allow []string `yaml:"allow" json:"allow"`
-	Deny  []string `yaml:"deny" json:"deny"`
+	Allow  []string      `yaml:"allow" json:"allow"`
+	Deny   []string      `yaml:"deny" json:"deny"`
+	Semver *SemverFilter `yaml:"semver" json:"semver"` // only used for tags
+}
+
+// SemverFilter selects tags by their semantic version, tags that are not a version are skipped
+type SemverFilter struct {
+	Constraint   string `yaml:"constraint" json:"constraint"`     // version ranges, e.g. ">=1.20 <2.0"
+	LatestMajors int    `yaml:"latestMajors" json:"latestMajors"` // number of the most recent major versions to include
+	LatestMinors int    `yaml:"latestMinors" json:"latestMinors"` // number of the most recent minor versions to include
+	LatestPatch  bool   `yaml:"latestPatch" json:"latestPatch"`   // only include the most recent patch of each minor version
+	Prerelease   bool   `yaml:"prerelease" json:"prerelease"`     // include tags with a pre-release, e.g. "1.2.3-rc.1" or "1.25-alpine"
 }
 
 type ConfigReferrerFilter struct {
@@ -204,6 +221,13 @@ func configExpandTemplates(c *Config) error {
 			return err
 		}
 		c.Creds[i].ClientKey = val
+		for k, v := range c.Creds[i].Headers {
+			val, err = template.String(v, nil)
+			if err != nil {
+				return err
+			}
+			c.Creds[i].Headers[k] = val
+		}
 	}
 	for i := range c.Sync {
 		dataSync.Sync = c.Sync[i]
@@ -240,6 +264,9 @@ func syncSetDefaults(s *ConfigSync, d ConfigDefaults) {
 	if s.Backup == "" && d.Backup != "" {
 		s.Backup = d.Backup
 	}
+	if s.BackupKeep == 0 && d.BackupKeep != 0 {
+		s.BackupKeep = d.BackupKeep
+	}
 	if s.Schedule == "" && d.Schedule != "" {
 		s.Schedule = d.Schedule
 	}
@@ -290,6 +317,10 @@ func syncSetDefaults(s *ConfigSync, d ConfigDefaults) {
 		b := (d.IncludeExternal != nil && *d.IncludeExternal)
 		s.IncludeExternal = &b
 	}
+	if s.Locked == nil {
+		b := (d.Locked != nil && *d.Locked)
+		s.Locked = &b
+	}
 	if s.Hooks.Pre == nil && d.Hooks.Pre != nil {
 		s.Hooks.Pre = d.Hooks.Pre
 	}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"gopkg.in/yaml.v3"
+)
+
+// Lock is the content of the lock file, recording the source of each synced target
+type Lock struct {
+	Version int                  `yaml:"version" json:"version"`
+	Images  map[string]LockEntry `yaml:"images" json:"images"` // key is the target reference
+}
+
+// LockEntry records the source image synced to a target
+type LockEntry struct {
+	Source       string        `yaml:"source" json:"source"`             // source reference
+	Digest       digest.Digest `yaml:"digest" json:"digest"`             // digest of the source manifest
+	TargetDigest digest.Digest `yaml:"targetDigest" json:"targetDigest"` // digest on the target, this differs from the source digest when a platform is selected
+	Synced       time.Time     `yaml:"synced" json:"synced"`             // time the entry was last changed
+}
+
+// lockFile manages concurrent access to the lock file
+type lockFile struct {
+	mu       sync.Mutex
+	filename string
+	lock     Lock
+}
+
+// lockLoad reads the lock file, a missing file returns an empty lock.
+func lockLoad(filename string) (*lockFile, error) {
+	lf := &lockFile{
+		filename: filename,
+		lock: Lock{
+			Version: 1,
+			Images:  map[string]LockEntry{},
+		},
+	}
+	//#nosec G304 command is run by a user accessing their own files
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return lf, nil
+		}
+		return nil, err
+	}
+	err = yaml.Unmarshal(b, &lf.lock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse lock file %s: %w", filename, err)
+	}
+	if lf.lock.Version > 1 {
+		return nil, fmt.Errorf("lock file %s version %d%.0w", filename, lf.lock.Version, ErrUnsupportedConfigVersion)
+	}
+	if lf.lock.Images == nil {
+		lf.lock.Images = map[string]LockEntry{}
+	}
+	return lf, nil
+}
+
+// get returns the entry for a target.
+func (lf *lockFile) get(tgt string) (LockEntry, bool) {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+	e, ok := lf.lock.Images[tgt]
+	return e, ok
+}
+
+// set updates the entry for a target and saves the lock file.
+func (lf *lockFile) set(tgt string, e LockEntry) error {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+	lf.lock.Images[tgt] = e
+	return lf.save()
+}
+
+// save writes the lock file, replacing the previous file only after the write succeeds.
+func (lf *lockFile) save() error {
+	b, err := yaml.Marshal(lf.lock)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(lf.filename), filepath.Base(lf.filename)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(b)
+	errC := tmp.Close()
+	if err == nil {
+		err = errC
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), lf.filename)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write lock file %s: %w", lf.filename, err)
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// syncMetrics tracks the results of each sync step.
+// All methods are safe to call concurrently and on a nil value.
+type syncMetrics struct {
+	mu    sync.Mutex
+	steps map[syncKey]*syncStats
+}
+
+type syncKey struct {
+	source, target, typ string
+}
+
+type syncStats struct {
+	copied      uint64
+	bytes       uint64
+	failures    uint64
+	lastSuccess time.Time
+	queued      int64
+	rlSet       bool
+	rlRemain    int
+	rlLimit     int
+}
+
+func newSyncMetrics() *syncMetrics {
+	return &syncMetrics{
+		steps: map[syncKey]*syncStats{},
+	}
+}
+
+// getStep returns the stats for a sync step, the caller must hold the lock.
+func (sm *syncMetrics) getStep(s ConfigSync) *syncStats {
+	key := syncKey{source: s.Source, target: s.Target, typ: s.Type}
+	ss, ok := sm.steps[key]
+	if !ok {
+		ss = &syncStats{}
+		sm.steps[key] = ss
+	}
+	return ss
+}
+
+// Add includes a sync step in the output before it has run.
+func (sm *syncMetrics) Add(s ConfigSync) {
+	if sm == nil {
+		return
+	}
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.getStep(s)
+}
+
+// Copied records an image copied by the sync step and the bytes pushed to the target.
+func (sm *syncMetrics) Copied(s ConfigSync, bytes int64) {
+	if sm == nil {
+		return
+	}
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	ss := sm.getStep(s)
+	ss.copied++
+	if bytes > 0 {
+		ss.bytes += uint64(bytes)
+	}
+}
+
+// Result records the completion of a sync step.
+func (sm *syncMetrics) Result(s ConfigSync, err error) {
+	if sm == nil {
+		return
+	}
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	ss := sm.getStep(s)
+	if err != nil {
+		ss.failures++
+	} else {
+		ss.lastSuccess = time.Now()
+	}
+}
+
+// Queued adjusts the number of images waiting to be copied by the sync step.
+func (sm *syncMetrics) Queued(s ConfigSync, delta int64) {
+	if sm == nil {
+		return
+	}
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.getStep(s).queued += delta
+}
+
+// RateLimit records the last rate limit seen on the source of the sync step.
+func (sm *syncMetrics) RateLimit(s ConfigSync, remain, limit int) {
+	if sm == nil {
+		return
+	}
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	ss := sm.getStep(s)
+	ss.rlSet = true
+	ss.rlRemain = remain
+	ss.rlLimit = limit
+}
+
+// Write outputs the metrics in the Prometheus text format.
+func (sm *syncMetrics) Write(w io.Writer) error {
+	if sm == nil {
+		return nil
+	}
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	keys := make([]syncKey, 0, len(sm.steps))
+	for k := range sm.steps {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].source != keys[j].source {
+			return keys[i].source < keys[j].source
+		}
+		if keys[i].target != keys[j].target {
+			return keys[i].target < keys[j].target
+		}
+		return keys[i].typ < keys[j].typ
+	})
+	sb := &strings.Builder{}
+	for _, m := range []struct {
+		name, kind, help string
+		val              func(*syncStats) (string, bool)
+	}{
+		{name: "regsync_images_copied_total", kind: "counter", help: "Count of images copied to the target.",
+			val: func(ss *syncStats) (string, bool) { return strconv.FormatUint(ss.copied, 10), true }},
+		{name: "regsync_copied_bytes_total", kind: "counter", help: "Bytes of manifests and blobs pushed to the target.",
+			val: func(ss *syncStats) (string, bool) { return strconv.FormatUint(ss.bytes, 10), true }},
+		{name: "regsync_failures_total", kind: "counter", help: "Count of sync runs that failed.",
+			val: func(ss *syncStats) (string, bool) { return strconv.FormatUint(ss.failures, 10), true }},
+		{name: "regsync_last_success_timestamp_seconds", kind: "gauge", help: "Unix time of the last sync run without an error.",
+			val: func(ss *syncStats) (string, bool) {
+				return strconv.FormatInt(ss.lastSuccess.Unix(), 10), !ss.lastSuccess.IsZero()
+			}},
+		{name: "regsync_queued_images", kind: "gauge", help: "Count of images waiting for the parallel limit to copy.",
+			val: func(ss *syncStats) (string, bool) { return strconv.FormatInt(ss.queued, 10), true }},
+		{name: "regsync_rate_limit_remaining", kind: "gauge", help: "Pulls remaining in the rate limit reported by the source.",
+			val: func(ss *syncStats) (string, bool) { return strconv.Itoa(ss.rlRemain), ss.rlSet }},
+		{name: "regsync_rate_limit", kind: "gauge", help: "Pull limit in the rate limit reported by the source.",
+			val: func(ss *syncStats) (string, bool) { return strconv.Itoa(ss.rlLimit), ss.rlSet }},
+	} {
+		fmt.Fprintf(sb, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.kind)
+		for _, k := range keys {
+			if val, ok := m.val(sm.steps[k]); ok {
+				fmt.Fprintf(sb, "%s{source=%s,target=%s,type=%s} %s\n", m.name, metricsQuote(k.source), metricsQuote(k.target), metricsQuote(k.typ), val)
+			}
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// metricsQuote returns a label value escaped for the Prometheus text format.
+func metricsQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// serveMetrics outputs the sync step and registry metrics.
+func (rootOpts *rootCmd) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := rootOpts.syncMetrics.Write(w); err != nil {
+		return
+	}
+	_ = rootOpts.metrics.Write(w)
+}
+
+// serveHTTP runs an http server for the Prometheus metrics and webhooks until the context is done.
+func (rootOpts *rootCmd) serveHTTP(ctx context.Context, addr string, handler http.Handler) {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		sctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(sctx)
+	}()
+	rootOpts.log.Info("Serving http",
+		slog.String("addr", addr))
+	err := srv.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		rootOpts.log.Error("HTTP server failed",
+			slog.String("addr", addr),
+			slog.String("err", err.Error()))
+	}
+}
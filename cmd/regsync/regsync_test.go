@@ -3,13 +3,19 @@ package main
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"log/slog"
+	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -21,6 +27,7 @@ import (
 	"github.com/regclient/regclient/config"
 	"github.com/regclient/regclient/internal/copyfs"
 	"github.com/regclient/regclient/internal/pqueue"
+	"github.com/regclient/regclient/pkg/metrics"
 	"github.com/regclient/regclient/scheme"
 	"github.com/regclient/regclient/scheme/reg"
 	"github.com/regclient/regclient/types/descriptor"
@@ -351,6 +358,30 @@ defaults:
 			},
 			expErr: nil,
 		},
+		{
+			name: "RepoTagFilterSemver",
+			sync: ConfigSync{
+				Source: tsHost + "/testrepo",
+				Target: tsHost + "/test-semver",
+				Type:   "repository",
+				Tags: AllowDeny{
+					Semver: &SemverFilter{
+						Constraint: ">=2",
+					},
+				},
+			},
+			action: actionCopy,
+			expect: map[string]digest.Digest{
+				tsHost + "/test-semver:v2": d2,
+				tsHost + "/test-semver:v3": d3,
+			},
+			exists: []string{},
+			missing: []string{
+				tsHost + "/test-semver:v1",
+				tsHost + "/test-semver@" + d1.String(),
+			},
+			expErr: nil,
+		},
 		{
 			name: "Missing Setup v1",
 			sync: ConfigSync{
@@ -753,6 +784,294 @@ defaults:
 	}
 }
 
+func TestProcessNamespace(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	regHandler := olareg.New(oConfig.Config{
+		Storage: oConfig.ConfigStorage{
+			StoreType: oConfig.StoreMem,
+			RootDir:   "../../testdata",
+		},
+	})
+	// olareg does not implement the catalog API
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/_catalog" {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"repositories":["other/app1","team/app1","team/app2","teamb/app1"]}`))
+			return
+		}
+		regHandler.ServeHTTP(w, r)
+	}))
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	t.Cleanup(func() {
+		ts.Close()
+		_ = regHandler.Close()
+	})
+	rc := regclient.New(
+		regclient.WithConfigHost(config.Host{
+			Name:     tsHost,
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+		}),
+	)
+	for _, repo := range []string{"other/app1", "team/app1", "team/app2", "teamb/app1"} {
+		rSrc, err := ref.New(tsHost + "/testrepo:v1")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		rTgt, err := ref.New(tsHost + "/" + repo + ":v1")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		err = rc.ImageCopy(ctx, rSrc, rTgt)
+		if err != nil {
+			t.Fatalf("failed to setup %s: %v", repo, err)
+		}
+	}
+	rootOpts := rootCmd{
+		conf:     ConfigNew(),
+		rc:       rc,
+		throttle: pqueue.New(pqueue.Opts[throttle]{Max: 1}),
+		log:      slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo})),
+	}
+	tt := []struct {
+		name    string
+		sync    ConfigSync
+		exists  []string
+		missing []string
+		expErr  error
+	}{
+		{
+			name: "namespace",
+			sync: ConfigSync{
+				Source: tsHost + "/team",
+				Target: tsHost + "/mirror",
+				Type:   "namespace",
+				Repos: AllowDeny{
+					Deny: []string{"app2"},
+				},
+			},
+			exists: []string{
+				tsHost + "/mirror/app1:v1",
+			},
+			missing: []string{
+				tsHost + "/mirror/app2:v1",
+			},
+		},
+		{
+			name: "missing namespace",
+			sync: ConfigSync{
+				Source: tsHost,
+				Target: tsHost + "/mirror",
+				Type:   "namespace",
+			},
+			expErr: ErrInvalidInput,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			syncSetDefaults(&tc.sync, rootOpts.conf.Defaults)
+			err := rootOpts.process(ctx, tc.sync, actionCopy)
+			if tc.expErr != nil {
+				if !errors.Is(err, tc.expErr) {
+					t.Errorf("unexpected error, expected %v, received %v", tc.expErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error on process: %v", err)
+			}
+			for _, exist := range tc.exists {
+				r, err := ref.New(exist)
+				if err != nil {
+					t.Fatalf("cannot parse ref %s: %v", exist, err)
+				}
+				_, err = rc.ManifestHead(ctx, r)
+				if err != nil {
+					t.Errorf("ref does not exist: %s", exist)
+				}
+			}
+			for _, missing := range tc.missing {
+				r, err := ref.New(missing)
+				if err != nil {
+					t.Fatalf("cannot parse ref %s: %v", missing, err)
+				}
+				_, err = rc.ManifestHead(ctx, r)
+				if err == nil {
+					t.Errorf("ref exists that should be missing: %s", missing)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessLock(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	boolT := true
+	regHandler := olareg.New(oConfig.Config{
+		Storage: oConfig.ConfigStorage{
+			StoreType: oConfig.StoreMem,
+			RootDir:   "../../testdata",
+		},
+	})
+	ts := httptest.NewServer(regHandler)
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	t.Cleanup(func() {
+		ts.Close()
+		_ = regHandler.Close()
+	})
+	rc := regclient.New(
+		regclient.WithConfigHost(config.Host{
+			Name:     tsHost,
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+		}),
+	)
+	lockFilename := t.TempDir() + "/regsync.lock"
+	lf, err := lockLoad(lockFilename)
+	if err != nil {
+		t.Fatalf("failed to load missing lock file: %v", err)
+	}
+	rootOpts := rootCmd{
+		conf:     ConfigNew(),
+		rc:       rc,
+		throttle: pqueue.New(pqueue.Opts[throttle]{Max: 1}),
+		lock:     lf,
+		log:      slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo})),
+	}
+	getRef := func(t *testing.T, s string) ref.Ref {
+		t.Helper()
+		r, err := ref.New(s)
+		if err != nil {
+			t.Fatalf("failed to parse ref %s: %v", s, err)
+		}
+		return r
+	}
+	getDigest := func(t *testing.T, r ref.Ref) digest.Digest {
+		t.Helper()
+		m, err := rc.ManifestHead(ctx, r, regclient.WithManifestRequireDigest())
+		if err != nil {
+			t.Fatalf("failed to head %s: %v", r.CommonName(), err)
+		}
+		return manifest.GetDigest(m)
+	}
+	rV1 := getRef(t, tsHost+"/testrepo:v1")
+	rV2 := getRef(t, tsHost+"/testrepo:v2")
+	rSrc := getRef(t, tsHost+"/lock-src:latest")
+	rTgt := getRef(t, tsHost+"/lock-tgt:latest")
+	d1 := getDigest(t, rV1)
+	d2 := getDigest(t, rV2)
+	err = rc.ImageCopy(ctx, rV1, rSrc)
+	if err != nil {
+		t.Fatalf("failed to setup source: %v", err)
+	}
+	syncStep := ConfigSync{
+		Source: rSrc.CommonName(),
+		Target: rTgt.CommonName(),
+		Type:   "image",
+	}
+	syncSetDefaults(&syncStep, rootOpts.conf.Defaults)
+	syncLocked := syncStep
+	syncLocked.Locked = &boolT
+
+	// a check does not update the lock file
+	err = rootOpts.process(ctx, syncStep, actionCheck)
+	if err != nil {
+		t.Fatalf("failed to check: %v", err)
+	}
+	if _, err := os.Stat(lockFilename); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("lock file created by check: %v", err)
+	}
+
+	// the first copy records the digest
+	err = rootOpts.process(ctx, syncLocked, actionCopy)
+	if err != nil {
+		t.Fatalf("failed to copy: %v", err)
+	}
+	if dig := getDigest(t, rTgt); dig != d1 {
+		t.Errorf("unexpected target digest, expected %s, received %s", d1, dig)
+	}
+	lf, err = lockLoad(lockFilename)
+	if err != nil {
+		t.Fatalf("failed to load lock file: %v", err)
+	}
+	entry, ok := lf.get(rTgt.CommonName())
+	if !ok {
+		t.Fatalf("lock entry missing for %s", rTgt.CommonName())
+	}
+	if entry.Source != rSrc.CommonName() || entry.Digest != d1 || entry.TargetDigest != d1 || entry.Synced.IsZero() {
+		t.Errorf("unexpected lock entry: %v", entry)
+	}
+
+	// a locked sync keeps the recorded digest when the source tag changes
+	err = rc.ImageCopy(ctx, rV2, rSrc)
+	if err != nil {
+		t.Fatalf("failed to update source: %v", err)
+	}
+	err = rc.ImageCopy(ctx, rV2, rTgt)
+	if err != nil {
+		t.Fatalf("failed to update target: %v", err)
+	}
+	err = rootOpts.process(ctx, syncLocked, actionCopy)
+	if err != nil {
+		t.Fatalf("failed to copy: %v", err)
+	}
+	if dig := getDigest(t, rTgt); dig != d1 {
+		t.Errorf("locked sync did not restore digest, expected %s, received %s", d1, dig)
+	}
+	if entry, _ := rootOpts.lock.get(rTgt.CommonName()); entry.Digest != d1 {
+		t.Errorf("locked sync changed the lock entry: %v", entry)
+	}
+
+	// an unlocked sync copies and records the new digest
+	err = rootOpts.process(ctx, syncStep, actionCopy)
+	if err != nil {
+		t.Fatalf("failed to copy: %v", err)
+	}
+	if dig := getDigest(t, rTgt); dig != d2 {
+		t.Errorf("unexpected target digest, expected %s, received %s", d2, dig)
+	}
+	lf, err = lockLoad(lockFilename)
+	if err != nil {
+		t.Fatalf("failed to load lock file: %v", err)
+	}
+	if entry, _ := lf.get(rTgt.CommonName()); entry.Digest != d2 || entry.TargetDigest != d2 {
+		t.Errorf("lock entry not updated: %v", entry)
+	}
+
+	// referrers added to an unchanged source are still synced when the image matches the lock
+	err = rc.ImageCopy(ctx, rV2, rSrc, regclient.ImageWithReferrers())
+	if err != nil {
+		t.Fatalf("failed to add referrers to source: %v", err)
+	}
+	syncReferrers := syncStep
+	syncReferrers.Referrers = &boolT
+	err = rootOpts.process(ctx, syncReferrers, actionCopy)
+	if err != nil {
+		t.Fatalf("failed to copy: %v", err)
+	}
+	rl, err := rc.ReferrerList(ctx, rTgt)
+	if err != nil {
+		t.Fatalf("failed to list referrers: %v", err)
+	}
+	if len(rl.Descriptors) == 0 {
+		t.Errorf("referrers were not synced to the locked target")
+	}
+
+	// an invalid lock file fails to load
+	err = os.WriteFile(lockFilename, []byte("version: 2\n"), 0600)
+	if err != nil {
+		t.Fatalf("failed to write lock file: %v", err)
+	}
+	_, err = lockLoad(lockFilename)
+	if !errors.Is(err, ErrUnsupportedConfigVersion) {
+		t.Errorf("unexpected error, expected %v, received %v", ErrUnsupportedConfigVersion, err)
+	}
+}
+
 func TestProcessRef(t *testing.T) {
 	t.Parallel()
 	ctx := context.Background()
@@ -863,6 +1182,616 @@ func TestProcessRef(t *testing.T) {
 	}
 }
 
+func TestBackupKeep(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	tempDir := t.TempDir()
+	err := copyfs.Copy(tempDir+"/testrepo", "../../testdata/testrepo")
+	if err != nil {
+		t.Fatalf("failed to copy testrepo to tempdir: %v", err)
+	}
+	rc := regclient.New()
+	rootOpts := rootCmd{
+		conf:     ConfigNew(),
+		rc:       rc,
+		throttle: pqueue.New(pqueue.Opts[throttle]{Max: 1}),
+		log:      slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo})),
+	}
+	rV1, err := ref.New("ocidir://" + tempDir + "/testrepo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	m, err := rc.ManifestHead(ctx, rV1, regclient.WithManifestRequireDigest())
+	if err != nil {
+		t.Fatalf("failed to head v1: %v", err)
+	}
+	short := backupDigest{Digest: manifest.GetDigest(m)}.Short()
+	if len(short) != 12 {
+		t.Errorf("unexpected short digest: %s", short)
+	}
+	today := time.Now().UTC().Format("20060102")
+	tt := []struct {
+		name     string
+		repo     string
+		tag      string
+		backup   string
+		existing []string
+		expect   []string
+	}{
+		{
+			name:     "date and digest",
+			repo:     "backup",
+			tag:      "latest",
+			backup:   "{{.Tag}}-{{.Date}}-{{.Digest.Short}}",
+			existing: []string{"latest-20240101-0123456789ab", "latest-20240102-0123456789ab", "latest-20240103-0123456789ab", "latest-rc", "other-20240101-0123456789ab"},
+			expect:   []string{"latest-" + today + "-" + short, "latest-20240103-0123456789ab", "latest-rc", "other-20240101-0123456789ab"},
+		},
+		{
+			// revision tags of the mirrored image are not backups
+			name:     "revision tags",
+			repo:     "backup-rev",
+			tag:      "1.2",
+			backup:   "{{.Tag}}-{{.Date}}",
+			existing: []string{"1.2-1", "1.2-2", "1.2-20240101", "1.2-20240102"},
+			expect:   []string{"1.2-1", "1.2-2", "1.2-" + today, "1.2-20240102"},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := "ocidir://" + tempDir + "/" + tc.repo
+			// setup the target with previous backups and unrelated tags
+			for _, tag := range append([]string{tc.tag}, tc.existing...) {
+				rTgt, err := ref.New(repo + ":" + tag)
+				if err != nil {
+					t.Fatalf("failed to parse ref: %v", err)
+				}
+				err = rc.ImageCopy(ctx, rV1, rTgt)
+				if err != nil {
+					t.Fatalf("failed to setup %s: %v", tag, err)
+				}
+			}
+			s := ConfigSync{
+				Source:     "ocidir://" + tempDir + "/testrepo:v2",
+				Target:     repo + ":" + tc.tag,
+				Type:       "image",
+				Backup:     tc.backup,
+				BackupKeep: 2,
+			}
+			syncSetDefaults(&s, rootOpts.conf.Defaults)
+			err = rootOpts.process(ctx, s, actionCopy)
+			if err != nil {
+				t.Fatalf("failed to process: %v", err)
+			}
+			rRepo, err := ref.New(repo)
+			if err != nil {
+				t.Fatalf("failed to parse ref: %v", err)
+			}
+			tl, err := rc.TagList(ctx, rRepo)
+			if err != nil {
+				t.Fatalf("failed to list tags: %v", err)
+			}
+			tags, err := tl.GetTags()
+			if err != nil {
+				t.Fatalf("failed to get tags: %v", err)
+			}
+			expect := append([]string{tc.tag}, tc.expect...)
+			if len(tags) != len(expect) {
+				t.Errorf("unexpected tags, expected %v, received %v", expect, tags)
+			}
+			for _, tag := range expect {
+				found := false
+				for _, cur := range tags {
+					if cur == tag {
+						found = true
+						break
+					}
+				}
+				if !found {
+					t.Errorf("missing tag %s, received %v", tag, tags)
+				}
+			}
+		})
+	}
+
+	// templates that cannot be matched are rejected
+	for _, tmpl := range []string{"{{.Tag}}-backup", "{{.Tag}}-{{.Digest.Short}}", "registry.example.com/{{.Date}}:{{.Tag}}-{{.Date}}"} {
+		_, err = backupPattern(tmpl, backupData{Tag: "latest"})
+		if !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("unexpected error for %s, expected %v, received %v", tmpl, ErrInvalidInput, err)
+		}
+	}
+}
+
+func TestProcessPrune(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	boolT := true
+	tempDir := t.TempDir()
+	err := copyfs.Copy(tempDir+"/testrepo", "../../testdata/testrepo")
+	if err != nil {
+		t.Fatalf("failed to copy testrepo to tempdir: %v", err)
+	}
+	rc := regclient.New()
+	rootOpts := rootCmd{
+		conf:     ConfigNew(),
+		rc:       rc,
+		throttle: pqueue.New(pqueue.Opts[throttle]{Max: 1}),
+		log:      slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo})),
+	}
+	src := "ocidir://" + tempDir + "/testrepo"
+	tgt := "ocidir://" + tempDir + "/prune"
+	rV1, err := ref.New(src + ":v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	digestTag := "sha256-" + strings.Repeat("0", 64)
+	for _, tag := range []string{"v1", "v0", "old", "keep-me", "v1-20240101-0123456789ab", digestTag} {
+		rTgt, err := ref.New(tgt + ":" + tag)
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		err = rc.ImageCopy(ctx, rV1, rTgt)
+		if err != nil {
+			t.Fatalf("failed to setup %s: %v", tag, err)
+		}
+	}
+	s := ConfigSync{
+		Source: src,
+		Target: tgt,
+		Type:   "repository",
+		Tags: AllowDeny{
+			Allow: []string{"v.*", "old"},
+		},
+		Backup: "{{.Tag}}-{{.Date}}-{{.Digest.Short}}",
+		Prune:  &boolT,
+	}
+	syncSetDefaults(&s, rootOpts.conf.Defaults)
+	getTags := func(t *testing.T) []string {
+		t.Helper()
+		rRepo, err := ref.New(tgt)
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		tl, err := rc.TagList(ctx, rRepo)
+		if err != nil {
+			t.Fatalf("failed to list tags: %v", err)
+		}
+		tags, err := tl.GetTags()
+		if err != nil {
+			t.Fatalf("failed to get tags: %v", err)
+		}
+		return tags
+	}
+	// check does not delete anything
+	err = rootOpts.process(ctx, s, actionCheck)
+	if err != nil {
+		t.Fatalf("failed to check: %v", err)
+	}
+	if tags := getTags(t); len(tags) != 6 {
+		t.Errorf("check modified tags: %v", tags)
+	}
+	err = rootOpts.process(ctx, s, actionCopy)
+	if err != nil {
+		t.Fatalf("failed to process: %v", err)
+	}
+	tags := getTags(t)
+	expect := []string{"keep-me", digestTag, "v1", "v1-20240101-0123456789ab", "v2", "v3"}
+	if len(tags) != len(expect) {
+		t.Errorf("unexpected tags, expected %v, received %v", expect, tags)
+	}
+	for _, tag := range expect {
+		found := false
+		for _, cur := range tags {
+			if cur == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("missing tag %s, received %v", tag, tags)
+		}
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	regHandler := olareg.New(oConfig.Config{
+		Storage: oConfig.ConfigStorage{
+			StoreType: oConfig.StoreMem,
+			RootDir:   "../../testdata",
+		},
+	})
+	ts := httptest.NewServer(regHandler)
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	t.Cleanup(func() {
+		ts.Close()
+		_ = regHandler.Close()
+	})
+	rc := regclient.New(
+		regclient.WithConfigHost(config.Host{
+			Name:     tsHost,
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+		}),
+	)
+	conf := ConfigNew()
+	conf.Sync = []ConfigSync{
+		{Source: tsHost + "/testrepo", Target: tsHost + "/webhook-mirror", Type: "repository", Tags: AllowDeny{Allow: []string{"v1"}}},
+		{Source: "docker.io/library/alpine:3", Target: tsHost + "/alpine:3", Type: "image"},
+		{Source: "registry.example.org", Target: tsHost + "/example", Type: "registry", Repos: AllowDeny{Deny: []string{"private/.*"}}},
+		{Source: "quay.io/team", Target: tsHost + "/team", Type: "namespace"},
+	}
+	for i := range conf.Sync {
+		syncSetDefaults(&conf.Sync[i], conf.Defaults)
+	}
+	rootOpts := rootCmd{
+		conf:     conf,
+		rc:       rc,
+		throttle: pqueue.New(pqueue.Opts[throttle]{Max: 1}),
+		log:      slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo})),
+	}
+
+	t.Run("parse", func(t *testing.T) {
+		tt := []struct {
+			name   string
+			query  string
+			body   string
+			expect []string
+			expErr error
+		}{
+			{
+				name:   "query",
+				query:  "?ref=ghcr.io/org/app:v1&ref=ghcr.io/org/lib",
+				expect: []string{"ghcr.io/org/app:v1", "ghcr.io/org/lib"},
+			},
+			{
+				name:   "hub",
+				body:   `{"push_data":{"tag":"v2"},"repository":{"repo_name":"user/app"}}`,
+				expect: []string{"docker.io/user/app:v2"},
+			},
+			{
+				name:   "harbor",
+				body:   `{"type":"PUSH_ARTIFACT","event_data":{"resources":[{"tag":"v3","resource_url":"harbor.example.org/proj/app:v3"}]}}`,
+				expect: []string{"harbor.example.org/proj/app:v3"},
+			},
+			{
+				name:   "github",
+				body:   `{"action":"published","registry_package":{"package_version":{"package_url":"ghcr.io/org/app:v4"}}}`,
+				expect: []string{"ghcr.io/org/app:v4"},
+			},
+			{
+				name:   "distribution",
+				body:   `{"events":[{"action":"pull","target":{"repository":"app","tag":"v1"},"request":{"host":"registry.example.org"}},{"action":"push","target":{"repository":"app","tag":"v5"},"request":{"host":"registry.example.org"}}]}`,
+				expect: []string{"registry.example.org/app:v5"},
+			},
+			{
+				name:   "github ping",
+				body:   `{"zen":"Keep it logically awesome.","hook_id":1}`,
+				expect: []string{},
+			},
+			{
+				name:   "empty",
+				expErr: ErrMissingInput,
+			},
+			{
+				name:   "invalid ref",
+				query:  "?ref=ocidir://testrepo",
+				expErr: ErrInvalidInput,
+			},
+		}
+		for _, tc := range tt {
+			t.Run(tc.name, func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodPost, "/webhook"+tc.query, strings.NewReader(tc.body))
+				refs, err := webhookParse(req, []byte(tc.body))
+				if tc.expErr != nil {
+					if !errors.Is(err, tc.expErr) {
+						t.Errorf("unexpected error, expected %v, received %v", tc.expErr, err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("failed to parse: %v", err)
+				}
+				if len(refs) != len(tc.expect) {
+					t.Fatalf("unexpected refs, expected %v, received %v", tc.expect, refs)
+				}
+				for i := range refs {
+					if refs[i].CommonName() != tc.expect[i] {
+						t.Errorf("unexpected ref %d, expected %s, received %s", i, tc.expect[i], refs[i].CommonName())
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("match", func(t *testing.T) {
+		tt := []struct {
+			pushed string
+			expect []string
+		}{
+			{pushed: tsHost + "/testrepo:v2", expect: []string{tsHost + "/webhook-mirror"}},
+			{pushed: "alpine:3", expect: []string{tsHost + "/alpine:3"}},
+			{pushed: "alpine:edge", expect: []string{}},
+			{pushed: "registry.example.org/app/web:v1", expect: []string{tsHost + "/example/app/web"}},
+			{pushed: "registry.example.org/private/db:v1", expect: []string{}},
+			{pushed: "quay.io/team/app:v1", expect: []string{tsHost + "/team/app"}},
+			{pushed: "quay.io/other/app:v1", expect: []string{}},
+		}
+		for _, tc := range tt {
+			t.Run(tc.pushed, func(t *testing.T) {
+				pushed, err := ref.New(tc.pushed)
+				if err != nil {
+					t.Fatalf("failed to parse ref: %v", err)
+				}
+				matches := webhookMatch(conf.Sync, pushed)
+				if len(matches) != len(tc.expect) {
+					t.Fatalf("unexpected matches, expected %v, received %v", tc.expect, matches)
+				}
+				for i := range matches {
+					if matches[i].Target != tc.expect[i] {
+						t.Errorf("unexpected target, expected %s, received %s", tc.expect[i], matches[i].Target)
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("handler", func(t *testing.T) {
+		wh := newWebhook(ctx, &rootOpts, "secret")
+		body := `{"events":[{"action":"push","target":{"repository":"testrepo","tag":"v1"},"request":{"host":"` + tsHost + `"}}]}`
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte(body))
+		tt := []struct {
+			name      string
+			method    string
+			query     string
+			headers   map[string]string
+			expStatus int
+		}{
+			{name: "get", method: http.MethodGet, expStatus: http.StatusMethodNotAllowed},
+			{name: "unauthorized", method: http.MethodPost, expStatus: http.StatusUnauthorized},
+			{name: "bad token", method: http.MethodPost, headers: map[string]string{"Authorization": "Bearer wrong"}, expStatus: http.StatusUnauthorized},
+			{name: "bad signature", method: http.MethodPost, headers: map[string]string{"X-Hub-Signature-256": "sha256=0000"}, expStatus: http.StatusUnauthorized},
+			{name: "bearer", method: http.MethodPost, headers: map[string]string{"Authorization": "Bearer secret"}, expStatus: http.StatusAccepted},
+			{name: "query token", method: http.MethodPost, query: "?token=secret", expStatus: http.StatusAccepted},
+			{name: "signature", method: http.MethodPost, headers: map[string]string{"X-Hub-Signature-256": "sha256=" + hex.EncodeToString(mac.Sum(nil))}, expStatus: http.StatusAccepted},
+		}
+		for _, tc := range tt {
+			t.Run(tc.name, func(t *testing.T) {
+				req := httptest.NewRequest(tc.method, "/webhook"+tc.query, strings.NewReader(body))
+				for k, v := range tc.headers {
+					req.Header.Set(k, v)
+				}
+				resp := httptest.NewRecorder()
+				wh.ServeHTTP(resp, req)
+				if resp.Code != tc.expStatus {
+					t.Errorf("unexpected status, expected %d, received %d: %s", tc.expStatus, resp.Code, resp.Body.String())
+				}
+			})
+		}
+		wh.wait()
+		rTgt, err := ref.New(tsHost + "/webhook-mirror:v1")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		_, err = rc.ManifestHead(ctx, rTgt)
+		if err != nil {
+			t.Errorf("webhook did not sync %s: %v", rTgt.CommonName(), err)
+		}
+	})
+}
+
+func TestWebhookSecret(t *testing.T) {
+	t.Setenv(WebhookSecretEnv, "")
+	confFile := t.TempDir() + "/missing.yml"
+	tt := []struct {
+		name   string
+		args   []string
+		expect error
+	}{
+		{
+			name:   "missing secret",
+			args:   []string{"server", "-c", confFile, "--webhook", "127.0.0.1:0"},
+			expect: ErrMissingInput,
+		},
+		{
+			name:   "insecure",
+			args:   []string{"server", "-c", confFile, "--webhook", "127.0.0.1:0", "--webhook-insecure"},
+			expect: fs.ErrNotExist,
+		},
+		{
+			name:   "secret",
+			args:   []string{"server", "-c", confFile, "--webhook", "127.0.0.1:0", "--webhook-secret", "secret"},
+			expect: fs.ErrNotExist,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rootTopCmd, _ := NewRootCmd()
+			rootTopCmd.SetOut(io.Discard)
+			rootTopCmd.SetErr(io.Discard)
+			rootTopCmd.SetArgs(tc.args)
+			err := rootTopCmd.Execute()
+			if !errors.Is(err, tc.expect) {
+				t.Errorf("unexpected error, expected %v, received %v", tc.expect, err)
+			}
+		})
+	}
+}
+
+func TestSyncMetrics(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	regHandler := olareg.New(oConfig.Config{
+		Storage: oConfig.ConfigStorage{
+			StoreType: oConfig.StoreMem,
+			RootDir:   "../../testdata",
+		},
+	})
+	ts := httptest.NewServer(regHandler)
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	t.Cleanup(func() {
+		ts.Close()
+		_ = regHandler.Close()
+	})
+	m := metrics.New()
+	rc := regclient.New(
+		regclient.WithConfigHost(config.Host{
+			Name:     tsHost,
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+		}),
+		regclient.WithRegOpts(reg.WithMetrics(m)),
+	)
+	rootOpts := rootCmd{
+		conf:        ConfigNew(),
+		rc:          rc,
+		throttle:    pqueue.New(pqueue.Opts[throttle]{Max: 1}),
+		log:         slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo})),
+		metrics:     m,
+		syncMetrics: newSyncMetrics(),
+	}
+	// nil metrics are ignored
+	var smNil *syncMetrics
+	smNil.Copied(ConfigSync{}, 1)
+	smNil.Result(ConfigSync{}, nil)
+	if err := smNil.Write(io.Discard); err != nil {
+		t.Errorf("failed to write nil metrics: %v", err)
+	}
+
+	sGood := ConfigSync{Source: tsHost + "/testrepo:v1", Target: tsHost + "/metrics-copy:v1", Type: "image"}
+	sBad := ConfigSync{Source: tsHost + "/missing:v1", Target: tsHost + "/metrics-missing:v1", Type: "image"}
+	sIdle := ConfigSync{Source: tsHost + "/testrepo:v2", Target: tsHost + "/metrics-idle:v2", Type: "image"}
+	for _, s := range []*ConfigSync{&sGood, &sBad, &sIdle} {
+		syncSetDefaults(s, rootOpts.conf.Defaults)
+	}
+	rootOpts.syncMetrics.Add(sIdle)
+	err := rootOpts.process(ctx, sGood, actionCopy)
+	if err != nil {
+		t.Fatalf("failed to process: %v", err)
+	}
+	// a second run does not copy the image again
+	err = rootOpts.process(ctx, sGood, actionCopy)
+	if err != nil {
+		t.Fatalf("failed to process: %v", err)
+	}
+	err = rootOpts.process(ctx, sBad, actionCopy)
+	if err == nil {
+		t.Fatalf("process of missing image did not fail")
+	}
+
+	resp := httptest.NewRecorder()
+	rootOpts.serveMetrics(resp, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	out := resp.Body.String()
+	labels := func(s ConfigSync) string {
+		return fmt.Sprintf(`{source="%s",target="%s",type="image"}`, s.Source, s.Target)
+	}
+	for _, expect := range []string{
+		"# TYPE regsync_images_copied_total counter\n",
+		"regsync_images_copied_total" + labels(sGood) + " 1\n",
+		"regsync_images_copied_total" + labels(sIdle) + " 0\n",
+		"regsync_failures_total" + labels(sGood) + " 0\n",
+		"regsync_failures_total" + labels(sBad) + " 1\n",
+		"regsync_queued_images" + labels(sGood) + " 0\n",
+		"regsync_last_success_timestamp_seconds" + labels(sGood) + " ",
+		"regsync_copied_bytes_total" + labels(sGood) + " ",
+		"regclient_requests_total{host=",
+	} {
+		if !strings.Contains(out, expect) {
+			t.Errorf("output missing %q", expect)
+		}
+	}
+	for _, unexpected := range []string{
+		"regsync_last_success_timestamp_seconds" + labels(sBad),
+		"regsync_copied_bytes_total" + labels(sGood) + " 0\n",
+		"regsync_rate_limit_remaining" + labels(sGood),
+	} {
+		if strings.Contains(out, unexpected) {
+			t.Errorf("output includes %q", unexpected)
+		}
+	}
+	if t.Failed() {
+		t.Logf("output:\n%s", out)
+	}
+}
+
+func TestFilterSemver(t *testing.T) {
+	t.Parallel()
+	tags := []string{"latest", "1.19.0", "1.19.4", "1.20", "1.20.0", "1.20.1", "1.21.0-rc.1", "1.21.0", "1.21.2", "1.21.2-alpine", "v2.0.0", "2.1.0", "nightly-20240101", "sha-abc123"}
+	tt := []struct {
+		name   string
+		filter SemverFilter
+		expect []string
+		expErr bool
+	}{
+		{
+			name:   "all versions",
+			filter: SemverFilter{},
+			expect: []string{"1.19.0", "1.19.4", "1.20", "1.20.0", "1.20.1", "1.21.0", "1.21.2", "v2.0.0", "2.1.0"},
+		},
+		{
+			name:   "constraint",
+			filter: SemverFilter{Constraint: ">=1.20 <2.0"},
+			expect: []string{"1.20", "1.20.0", "1.20.1", "1.21.0", "1.21.2"},
+		},
+		{
+			name:   "prerelease",
+			filter: SemverFilter{Constraint: "1.21", Prerelease: true},
+			expect: []string{"1.21.0-rc.1", "1.21.0", "1.21.2", "1.21.2-alpine"},
+		},
+		{
+			name:   "latest patch of each minor",
+			filter: SemverFilter{Constraint: "<2", LatestPatch: true},
+			expect: []string{"1.19.4", "1.20.1", "1.21.2"},
+		},
+		{
+			name:   "latest minors",
+			filter: SemverFilter{LatestMinors: 3},
+			expect: []string{"1.21.0", "1.21.2", "v2.0.0", "2.1.0"},
+		},
+		{
+			name:   "latest 2 minors of the latest major 1",
+			filter: SemverFilter{Constraint: "1", LatestMinors: 2, LatestPatch: true},
+			expect: []string{"1.20.1", "1.21.2"},
+		},
+		{
+			name:   "latest major",
+			filter: SemverFilter{LatestMajors: 1},
+			expect: []string{"v2.0.0", "2.1.0"},
+		},
+		{
+			name:   "no match",
+			filter: SemverFilter{Constraint: ">=3"},
+			expect: []string{},
+		},
+		{
+			name:   "invalid constraint",
+			filter: SemverFilter{Constraint: ">=latest"},
+			expErr: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := filterSemver(tc.filter, tags)
+			if tc.expErr {
+				if err == nil {
+					t.Errorf("filter did not fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Join(result, ",") != strings.Join(tc.expect, ",") {
+				t.Errorf("unexpected result, expected %v, received %v", tc.expect, result)
+			}
+		})
+	}
+}
+
 func TestConfigRead(t *testing.T) {
 	t.Parallel()
 	// CAUTION: the below yaml is space indented and will not parse with tabs
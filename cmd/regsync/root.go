@@ -5,10 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	// crypto libraries included for go-digest
@@ -22,7 +25,9 @@ import (
 	"github.com/regclient/regclient"
 	"github.com/regclient/regclient/config"
 	"github.com/regclient/regclient/internal/pqueue"
+	"github.com/regclient/regclient/internal/semver"
 	"github.com/regclient/regclient/internal/version"
+	"github.com/regclient/regclient/pkg/metrics"
 	"github.com/regclient/regclient/pkg/template"
 	"github.com/regclient/regclient/scheme"
 	"github.com/regclient/regclient/scheme/reg"
@@ -52,15 +57,23 @@ const (
 type throttle struct{}
 
 type rootCmd struct {
-	confFile  string
-	verbosity string
-	logopts   []string
-	log       *slog.Logger
-	format    string // for Go template formatting of various commands
-	missing   bool
-	conf      *Config
-	rc        *regclient.RegClient
-	throttle  *pqueue.Queue[throttle]
+	confFile    string
+	verbosity   string
+	logopts     []string
+	log         *slog.Logger
+	format      string // for Go template formatting of various commands
+	missing     bool
+	conf        *Config
+	rc          *regclient.RegClient
+	throttle    *pqueue.Queue[throttle]
+	lock        *lockFile
+	metricsAddr string
+	metrics     *metrics.Metrics
+	syncMetrics *syncMetrics
+	webhookAddr string
+	webhookSec  string
+	webhookIns  bool
+	webhook     *webhook
 }
 
 func NewRootCmd() (*cobra.Command, *rootCmd) {
@@ -123,6 +136,10 @@ sync step is finished.`,
 	rootTopCmd.PersistentFlags().StringArrayVar(&rootOpts.logopts, "logopt", []string{}, "Log options")
 	versionCmd.Flags().StringVar(&rootOpts.format, "format", "{{printPretty .}}", "Format output with go template syntax")
 	onceCmd.Flags().BoolVar(&rootOpts.missing, "missing", false, "Only copy tags that are missing on target")
+	serverCmd.Flags().StringVar(&rootOpts.metricsAddr, "metrics", "", "Listen address to serve Prometheus metrics on /metrics, e.g. :9090")
+	serverCmd.Flags().StringVar(&rootOpts.webhookAddr, "webhook", "", "Listen address to receive push notifications on /webhook, e.g. :8080")
+	serverCmd.Flags().StringVar(&rootOpts.webhookSec, "webhook-secret", "", "Secret required for webhook requests, defaults to $"+WebhookSecretEnv)
+	serverCmd.Flags().BoolVar(&rootOpts.webhookIns, "webhook-insecure", false, "Allow webhook requests without a secret")
 
 	_ = rootTopCmd.MarkPersistentFlagFilename("config")
 	_ = serverCmd.MarkPersistentFlagRequired("config")
@@ -222,11 +239,44 @@ func (rootOpts *rootCmd) runOnce(cmd *cobra.Command, args []string) error {
 
 // runServer stays running with cron scheduled tasks
 func (rootOpts *rootCmd) runServer(cmd *cobra.Command, args []string) error {
+	secret := rootOpts.webhookSec
+	if secret == "" {
+		secret = os.Getenv(WebhookSecretEnv)
+	}
+	if rootOpts.webhookAddr != "" && secret == "" && !rootOpts.webhookIns {
+		return fmt.Errorf("webhook requires --webhook-secret or $%s, use --webhook-insecure to accept requests from anyone%.0w", WebhookSecretEnv, ErrMissingInput)
+	}
+	if rootOpts.metricsAddr != "" {
+		rootOpts.metrics = metrics.New()
+		rootOpts.syncMetrics = newSyncMetrics()
+	}
 	err := rootOpts.loadConf()
 	if err != nil {
 		return err
 	}
+	for _, s := range rootOpts.conf.Sync {
+		rootOpts.syncMetrics.Add(s)
+	}
 	ctx := cmd.Context()
+	// the metrics and webhook may share an address
+	muxes := map[string]*http.ServeMux{}
+	if rootOpts.metrics != nil {
+		muxes[rootOpts.metricsAddr] = http.NewServeMux()
+		muxes[rootOpts.metricsAddr].HandleFunc("/metrics", rootOpts.serveMetrics)
+	}
+	if rootOpts.webhookAddr != "" {
+		if secret == "" {
+			rootOpts.log.Warn("Webhook secret is not set, any request can trigger a sync")
+		}
+		rootOpts.webhook = newWebhook(ctx, rootOpts, secret)
+		if muxes[rootOpts.webhookAddr] == nil {
+			muxes[rootOpts.webhookAddr] = http.NewServeMux()
+		}
+		muxes[rootOpts.webhookAddr].Handle("/webhook", rootOpts.webhook)
+	}
+	for addr, mux := range muxes {
+		go rootOpts.serveHTTP(ctx, addr, mux)
+	}
 	var wg sync.WaitGroup
 	// TODO: switch to joining array of errors once 1.20 is the minimum version
 	var mainErr error
@@ -308,6 +358,9 @@ func (rootOpts *rootCmd) runServer(cmd *cobra.Command, args []string) error {
 	c.Stop()
 	rootOpts.log.Debug("Waiting on running tasks")
 	wg.Wait()
+	if rootOpts.webhook != nil {
+		rootOpts.webhook.wait()
+	}
 	return mainErr
 }
 
@@ -358,10 +411,22 @@ func (rootOpts *rootCmd) loadConf() error {
 	rootOpts.log.Debug("Configuring parallel settings",
 		slog.Int("concurrent", concurrent))
 	rootOpts.throttle = pqueue.New(pqueue.Opts[throttle]{Max: concurrent})
+	if rootOpts.conf.Defaults.LockFile != "" {
+		rootOpts.lock, err = lockLoad(rootOpts.conf.Defaults.LockFile)
+		if err != nil {
+			return err
+		}
+	}
 	// set the regclient, loading docker creds unless disabled, and inject logins from config file
 	rcOpts := []regclient.Opt{
 		regclient.WithSlog(rootOpts.log),
 	}
+	if rootOpts.metrics != nil {
+		rcOpts = append(rcOpts, regclient.WithRegOpts(reg.WithMetrics(rootOpts.metrics)))
+	}
+	if rootOpts.conf.Defaults.BlobCache != "" {
+		rcOpts = append(rcOpts, regclient.WithBlobCache(rootOpts.conf.Defaults.BlobCache))
+	}
 	if rootOpts.conf.Defaults.BlobLimit != 0 {
 		rcOpts = append(rcOpts, regclient.WithRegOpts(reg.WithBlobLimit(rootOpts.conf.Defaults.BlobLimit)))
 	}
@@ -397,12 +462,21 @@ func (rootOpts *rootCmd) loadConf() error {
 }
 
 // process a sync step
-func (rootOpts *rootCmd) process(ctx context.Context, s ConfigSync, action actionType) error {
+func (rootOpts *rootCmd) process(ctx context.Context, s ConfigSync, action actionType) (err error) {
+	if action != actionCheck {
+		defer func() {
+			rootOpts.syncMetrics.Result(s, err)
+		}()
+	}
 	switch s.Type {
 	case "registry":
 		if err := rootOpts.processRegistry(ctx, s, s.Source, s.Target, action); err != nil {
 			return err
 		}
+	case "namespace":
+		if err := rootOpts.processNamespace(ctx, s, s.Source, s.Target, action); err != nil {
+			return err
+		}
 	case "repository":
 		if err := rootOpts.processRepo(ctx, s, s.Source, s.Target, action); err != nil {
 			return err
@@ -412,7 +486,7 @@ func (rootOpts *rootCmd) process(ctx context.Context, s ConfigSync, action actio
 			return err
 		}
 	default:
-		rootOpts.log.Error("Type not recognized, must be one of: registry, repository, or image",
+		rootOpts.log.Error("Type not recognized, must be one of: registry, namespace, repository, or image",
 			slog.Any("step", s),
 			slog.String("type", s.Type))
 		return ErrInvalidInput
@@ -465,6 +539,54 @@ func (rootOpts *rootCmd) processRegistry(ctx context.Context, s ConfigSync, src,
 	return retErr
 }
 
+// processNamespace syncs every repository within a namespace of the source registry, e.g. "docker.io/myorg".
+// The repository filters are applied to the name within the namespace.
+func (rootOpts *rootCmd) processNamespace(ctx context.Context, s ConfigSync, src, tgt string, action actionType) error {
+	host, ns, ok := strings.Cut(strings.TrimSuffix(src, "/"), "/")
+	if !ok || ns == "" {
+		rootOpts.log.Error("Namespace source must include a registry and namespace",
+			slog.String("source", src))
+		return fmt.Errorf("namespace missing from source %s%.0w", src, ErrInvalidInput)
+	}
+	repos, err := rootOpts.rc.RepoListNamespace(ctx, host, ns)
+	if err != nil {
+		rootOpts.log.Error("Failed to list source repositories",
+			slog.String("source", src),
+			slog.String("error", err.Error()))
+		return err
+	}
+	names := make([]string, 0, len(repos))
+	for _, repo := range repos {
+		if name, ok := strings.CutPrefix(repo, ns+"/"); ok && name != "" {
+			names = append(names, name)
+		}
+	}
+	names, err = filterList(s.Repos, names)
+	if err != nil {
+		rootOpts.log.Error("Failed processing repo filters",
+			slog.String("source", src),
+			slog.Any("allow", s.Repos.Allow),
+			slog.Any("deny", s.Repos.Deny),
+			slog.String("error", err.Error()))
+		return err
+	}
+	if len(names) == 0 {
+		rootOpts.log.Warn("No matching repositories found",
+			slog.String("source", src),
+			slog.Any("allow", s.Repos.Allow),
+			slog.Any("deny", s.Repos.Deny),
+			slog.Any("available", repos))
+		return nil
+	}
+	var retErr error
+	for _, name := range names {
+		if err := rootOpts.processRepo(ctx, s, host+"/"+ns+"/"+name, strings.TrimSuffix(tgt, "/")+"/"+name, action); err != nil {
+			retErr = err
+		}
+	}
+	return retErr
+}
+
 func (rootOpts *rootCmd) processRepo(ctx context.Context, s ConfigSync, src, tgt string, action actionType) error {
 	sRepoRef, err := ref.New(src)
 	if err != nil {
@@ -488,11 +610,15 @@ func (rootOpts *rootCmd) processRepo(ctx context.Context, s ConfigSync, src, tgt
 		return err
 	}
 	sTagList, err := filterList(s.Tags, sTagsList)
+	if err == nil && s.Tags.Semver != nil {
+		sTagList, err = filterSemver(*s.Tags.Semver, sTagList)
+	}
 	if err != nil {
 		rootOpts.log.Error("Failed processing tag filters",
 			slog.String("source", sRepoRef.CommonName()),
 			slog.Any("allow", s.Tags.Allow),
 			slog.Any("deny", s.Tags.Deny),
+			slog.Any("semver", s.Tags.Semver),
 			slog.String("error", err.Error()))
 		return err
 	}
@@ -501,6 +627,7 @@ func (rootOpts *rootCmd) processRepo(ctx context.Context, s ConfigSync, src, tgt
 			slog.String("source", sRepoRef.CommonName()),
 			slog.Any("allow", s.Tags.Allow),
 			slog.Any("deny", s.Tags.Deny),
+			slog.Any("semver", s.Tags.Semver),
 			slog.Any("available", sTagsList))
 		return nil
 	}
@@ -556,6 +683,86 @@ func (rootOpts *rootCmd) processRepo(ctx context.Context, s ConfigSync, src, tgt
 			retErr = err
 		}
 	}
+	if s.Prune != nil && *s.Prune && action != actionMissing {
+		if err := rootOpts.processPrune(ctx, s, sTagsList, tgt, action); err != nil {
+			retErr = err
+		}
+	}
+	return retErr
+}
+
+// processPrune deletes tags from the target repository that are no longer in the source and match the tag filters.
+// Backups in the target repository and digest tags are not deleted.
+func (rootOpts *rootCmd) processPrune(ctx context.Context, s ConfigSync, srcTags []string, tgt string, action actionType) error {
+	tRepoRef, err := ref.New(tgt)
+	if err != nil {
+		rootOpts.log.Error("Failed parsing target",
+			slog.String("target", tgt),
+			slog.String("error", err.Error()))
+		return err
+	}
+	defer rootOpts.rc.Close(ctx, tRepoRef)
+	tTags, err := rootOpts.rc.TagList(ctx, tRepoRef)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return nil
+		}
+		rootOpts.log.Error("Failed getting target tags",
+			slog.String("target", tRepoRef.CommonName()),
+			slog.String("error", err.Error()))
+		return err
+	}
+	tTagList, err := tTags.GetTags()
+	if err != nil {
+		rootOpts.log.Error("Failed getting target tags",
+			slog.String("target", tRepoRef.CommonName()),
+			slog.String("error", err.Error()))
+		return err
+	}
+	srcFound := map[string]bool{}
+	for _, tag := range srcTags {
+		srcFound[tag] = true
+	}
+	var backupExp *regexp.Regexp
+	if s.Backup != "" {
+		backupExp = backupTagPattern(s, tRepoRef)
+	}
+	pruneList := []string{}
+	for _, tag := range tTagList {
+		if srcFound[tag] || digestTagRE.MatchString(tag) || (backupExp != nil && backupExp.MatchString(tag)) {
+			continue
+		}
+		pruneList = append(pruneList, tag)
+	}
+	pruneList, err = filterList(s.Tags, pruneList)
+	if err == nil && s.Tags.Semver != nil {
+		// the latest version limits are not applied, older versions are still managed by this step
+		pruneList, err = filterSemver(SemverFilter{Constraint: s.Tags.Semver.Constraint, Prerelease: s.Tags.Semver.Prerelease}, pruneList)
+	}
+	if err != nil {
+		rootOpts.log.Error("Failed processing tag filters",
+			slog.String("target", tRepoRef.CommonName()),
+			slog.String("error", err.Error()))
+		return err
+	}
+	var retErr error
+	for _, tag := range pruneList {
+		tRef := tRepoRef.SetTag(tag)
+		if action == actionCheck {
+			rootOpts.log.Info("Tag prune needed",
+				slog.String("target", tRef.CommonName()))
+			continue
+		}
+		rootOpts.log.Info("Pruning tag",
+			slog.String("target", tRef.CommonName()))
+		err = rootOpts.rc.TagDelete(ctx, tRef)
+		if err != nil {
+			rootOpts.log.Error("Failed to prune tag",
+				slog.String("target", tRef.CommonName()),
+				slog.String("error", err.Error()))
+			retErr = err
+		}
+	}
 	return retErr
 }
 
@@ -591,6 +798,21 @@ func (rootOpts *rootCmd) processImage(ctx context.Context, s ConfigSync, src, tg
 
 // process a sync step
 func (rootOpts *rootCmd) processRef(ctx context.Context, s ConfigSync, src, tgt ref.Ref, action actionType) error {
+	// lookup the previous sync of the target, ignoring entries from a different source
+	srcName := src.CommonName()
+	lockEntry, lockFound := LockEntry{}, false
+	if rootOpts.lock != nil {
+		lockEntry, lockFound = rootOpts.lock.get(tgt.CommonName())
+		if lockFound && lockEntry.Source != srcName {
+			lockFound = false
+		}
+		if lockFound && s.Locked != nil && *s.Locked && src.Digest == "" {
+			rootOpts.log.Debug("Using locked digest",
+				slog.String("source", srcName),
+				slog.String("digest", lockEntry.Digest.String()))
+			src.Digest = lockEntry.Digest.String()
+		}
+	}
 	mSrc, err := rootOpts.rc.ManifestHead(ctx, src, regclient.WithManifestRequireDigest())
 	if err != nil && errors.Is(err, errs.ErrUnsupportedAPI) {
 		mSrc, err = rootOpts.rc.ManifestGet(ctx, src)
@@ -601,6 +823,30 @@ func (rootOpts *rootCmd) processRef(ctx context.Context, s ConfigSync, src, tgt
 			slog.String("error", err.Error()))
 		return err
 	}
+	srcDig := manifest.GetDigest(mSrc)
+	tgtDig := srcDig
+	if rl := manifest.GetRateLimit(mSrc); rl.Set {
+		rootOpts.syncMetrics.RateLimit(s, rl.Remain, rl.Limit)
+	}
+	// lockSet records the synced digests when they differ from the lock file
+	lockSet := func() error {
+		if rootOpts.lock == nil || action == actionCheck ||
+			(lockFound && lockEntry.Digest == srcDig && lockEntry.TargetDigest == tgtDig) {
+			return nil
+		}
+		err := rootOpts.lock.set(tgt.CommonName(), LockEntry{
+			Source:       srcName,
+			Digest:       srcDig,
+			TargetDigest: tgtDig,
+			Synced:       time.Now().UTC(),
+		})
+		if err != nil {
+			rootOpts.log.Error("Failed to update lock file",
+				slog.String("target", tgt.CommonName()),
+				slog.String("error", err.Error()))
+		}
+		return err
+	}
 	fastCheck := (s.FastCheck != nil && *s.FastCheck)
 	forceRecursive := (s.ForceRecursive != nil && *s.ForceRecursive)
 	referrers := (s.Referrers != nil && *s.Referrers)
@@ -611,11 +857,19 @@ func (rootOpts *rootCmd) processRef(ctx context.Context, s ConfigSync, src, tgt
 	if err == nil && manifest.GetDigest(mSrc).String() == manifest.GetDigest(mTgt).String() {
 		tgtMatches = true
 	}
+	if lockFound && tgtExists && lockEntry.Digest == srcDig && lockEntry.TargetDigest == manifest.GetDigest(mTgt) &&
+		(fastCheck || (!forceRecursive && !referrers && !digestTags)) {
+		rootOpts.log.Debug("Image matches lock",
+			slog.String("source", src.CommonName()),
+			slog.String("target", tgt.CommonName()),
+			slog.Time("synced", lockEntry.Synced))
+		return nil
+	}
 	if tgtMatches && (fastCheck || (!forceRecursive && !referrers && !digestTags)) {
 		rootOpts.log.Debug("Image matches",
 			slog.String("source", src.CommonName()),
 			slog.String("target", tgt.CommonName()))
-		return nil
+		return lockSet()
 	}
 	if tgtExists && action == actionMissing {
 		rootOpts.log.Debug("target exists",
@@ -648,6 +902,7 @@ func (rootOpts *rootCmd) processRef(ctx context.Context, s ConfigSync, src, tgt
 			return err
 		}
 		src.Digest = platDigest.String()
+		tgtDig = platDigest
 		if tgtExists && platDigest.String() == manifest.GetDigest(mTgt).String() {
 			tgtMatches = true
 		}
@@ -656,7 +911,7 @@ func (rootOpts *rootCmd) processRef(ctx context.Context, s ConfigSync, src, tgt
 				slog.String("source", src.CommonName()),
 				slog.String("platform", s.Platform),
 				slog.String("target", tgt.CommonName()))
-			return nil
+			return lockSet()
 		}
 	}
 	if tgtMatches {
@@ -676,7 +931,9 @@ func (rootOpts *rootCmd) processRef(ctx context.Context, s ConfigSync, src, tgt
 	}
 
 	// wait for parallel tasks
+	rootOpts.syncMetrics.Queued(s, 1)
 	throttleDone, err := rootOpts.throttle.Acquire(ctx, throttle{})
+	rootOpts.syncMetrics.Queued(s, -1)
 	if err != nil {
 		return fmt.Errorf("failed to acquire throttle: %w", err)
 	}
@@ -719,6 +976,7 @@ func (rootOpts *rootCmd) processRef(ctx context.Context, s ConfigSync, src, tgt
 				return err
 			}
 			rlSrc = manifest.GetRateLimit(mSrc)
+			rootOpts.syncMetrics.RateLimit(s, rlSrc.Remain, rlSrc.Limit)
 		}
 		rootOpts.log.Debug("Rate limit passed",
 			slog.String("source", src.CommonName()),
@@ -737,11 +995,16 @@ func (rootOpts *rootCmd) processRef(ctx context.Context, s ConfigSync, src, tgt
 	// run backup
 	if tgtExists && !tgtMatches && s.Backup != "" {
 		// expand template
-		data := struct {
-			Ref  ref.Ref
-			Step ConfigSync
-			Sync ConfigSync
-		}{Ref: tgt, Step: s, Sync: s}
+		now := time.Now().UTC()
+		data := backupData{
+			Ref:      tgt,
+			Step:     s,
+			Sync:     s,
+			Tag:      tgt.Tag,
+			Digest:   backupDigest{Digest: manifest.GetDigest(mTgt)},
+			Date:     now.Format("20060102"),
+			DateTime: now.Format("20060102-150405"),
+		}
 		backupStr, err := template.String(s.Backup, data)
 		if err != nil {
 			rootOpts.log.Error("Failed to expand backup template",
@@ -780,6 +1043,15 @@ func (rootOpts *rootCmd) processRef(ctx context.Context, s ConfigSync, src, tgt
 				slog.String("template", s.Backup),
 				slog.String("backup", backupRef.CommonName()),
 				slog.String("error", err.Error()))
+		} else if s.BackupKeep > 0 {
+			err = rootOpts.backupPrune(ctx, s, data, backupRef)
+			if err != nil {
+				rootOpts.log.Warn("Failed to remove old backups",
+					slog.String("original", tgt.CommonName()),
+					slog.String("template", s.Backup),
+					slog.String("backup", backupRef.CommonName()),
+					slog.String("error", err.Error()))
+			}
 		}
 	}
 
@@ -833,6 +1105,14 @@ func (rootOpts *rootCmd) processRef(ctx context.Context, s ConfigSync, src, tgt
 	if len(s.Platforms) > 0 {
 		opts = append(opts, regclient.ImageWithPlatforms(s.Platforms))
 	}
+	var copiedBytes atomic.Int64
+	if rootOpts.syncMetrics != nil {
+		opts = append(opts, regclient.ImageWithCallback(func(kind types.CallbackKind, instance string, state types.CallbackState, cur, total int64) {
+			if state == types.CallbackFinished {
+				copiedBytes.Add(total)
+			}
+		}))
+	}
 
 	// Copy the image
 	rootOpts.log.Debug("Image sync running",
@@ -846,9 +1126,13 @@ func (rootOpts *rootCmd) processRef(ctx context.Context, s ConfigSync, src, tgt
 			slog.String("error", err.Error()))
 		return err
 	}
-	return nil
+	rootOpts.syncMetrics.Copied(s, copiedBytes.Load())
+	return lockSet()
 }
 
+// digestTagRE matches tags for artifacts associated with a digest, e.g. signatures or the referrers fallback tag
+var digestTagRE = regexp.MustCompile(`^[a-z0-9]+-[a-f0-9]{32,}(?:\..*)?$`)
+
 func filterList(ad AllowDeny, in []string) ([]string, error) {
 	var result []string
 	// apply allow list
@@ -896,6 +1180,93 @@ func filterList(ad AllowDeny, in []string) ([]string, error) {
 	return compressed, nil
 }
 
+// filterSemver returns the tags matching the semver filter, preserving the order of the input.
+func filterSemver(sf SemverFilter, in []string) ([]string, error) {
+	var con *semver.Constraint
+	if sf.Constraint != "" {
+		c, err := semver.ParseConstraint(sf.Constraint)
+		if err != nil {
+			return nil, err
+		}
+		con = &c
+	}
+	vers := map[string]semver.Version{}
+	for _, t := range in {
+		v, err := semver.Parse(t)
+		if err != nil || (len(v.Pre) > 0 && !sf.Prerelease) || (con != nil && !con.Check(v)) {
+			continue
+		}
+		vers[t] = v
+	}
+	// limit to the most recent major and minor versions
+	type minor struct{ major, minor uint64 }
+	if sf.LatestMajors > 0 {
+		keep := map[uint64]bool{}
+		majors := []uint64{}
+		for _, v := range vers {
+			if !keep[v.Major] {
+				keep[v.Major] = true
+				majors = append(majors, v.Major)
+			}
+		}
+		sort.Slice(majors, func(i, j int) bool { return majors[i] > majors[j] })
+		for _, m := range majors[min(sf.LatestMajors, len(majors)):] {
+			delete(keep, m)
+		}
+		for t, v := range vers {
+			if !keep[v.Major] {
+				delete(vers, t)
+			}
+		}
+	}
+	if sf.LatestMinors > 0 {
+		keep := map[minor]bool{}
+		minors := []minor{}
+		for _, v := range vers {
+			m := minor{v.Major, v.Minor}
+			if !keep[m] {
+				keep[m] = true
+				minors = append(minors, m)
+			}
+		}
+		sort.Slice(minors, func(i, j int) bool {
+			if minors[i].major != minors[j].major {
+				return minors[i].major > minors[j].major
+			}
+			return minors[i].minor > minors[j].minor
+		})
+		for _, m := range minors[min(sf.LatestMinors, len(minors)):] {
+			delete(keep, m)
+		}
+		for t, v := range vers {
+			if !keep[minor{v.Major, v.Minor}] {
+				delete(vers, t)
+			}
+		}
+	}
+	if sf.LatestPatch {
+		latest := map[minor]semver.Version{}
+		for _, v := range vers {
+			m := minor{v.Major, v.Minor}
+			if cur, ok := latest[m]; !ok || semver.Compare(v, cur) > 0 {
+				latest[m] = v
+			}
+		}
+		for t, v := range vers {
+			if semver.Compare(v, latest[minor{v.Major, v.Minor}]) < 0 {
+				delete(vers, t)
+			}
+		}
+	}
+	result := make([]string, 0, len(vers))
+	for _, t := range in {
+		if _, ok := vers[t]; ok {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
 var manifestCache struct {
 	mu        sync.Mutex
 	manifests map[string]manifest.Manifest
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/regclient/regclient/types/ref"
+)
+
+const (
+	// WebhookSecretEnv is used to set the webhook secret without a command line flag
+	WebhookSecretEnv = "REGSYNC_WEBHOOK_SECRET"
+	// webhookBodyMax limits the size of the webhook request body
+	webhookBodyMax = 1024 * 1024
+)
+
+// webhook triggers sync steps in server mode when a push to the source is received
+type webhook struct {
+	rootOpts *rootCmd
+	ctx      context.Context
+	secret   string
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	steps    map[string]*webhookStep
+}
+
+// webhookStep tracks a running step, rerunning it when triggered again before finishing
+type webhookStep struct {
+	running, pending bool
+}
+
+// webhookResponse is returned to the webhook sender
+type webhookResponse struct {
+	Triggered []webhookTriggered `json:"triggered"`
+}
+
+type webhookTriggered struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Type   string `json:"type"`
+}
+
+// webhookPayload includes the fields used from the supported webhook formats
+type webhookPayload struct {
+	// Docker Hub
+	PushData *struct {
+		Tag string `json:"tag"`
+	} `json:"push_data"`
+	Repository *struct {
+		RepoName string `json:"repo_name"`
+	} `json:"repository"`
+	// Harbor
+	EventData *struct {
+		Resources []struct {
+			ResourceURL string `json:"resource_url"`
+		} `json:"resources"`
+	} `json:"event_data"`
+	// GitHub package events
+	RegistryPackage *struct {
+		PackageVersion struct {
+			PackageURL string `json:"package_url"`
+		} `json:"package_version"`
+	} `json:"registry_package"`
+	// distribution registry notifications
+	Events []struct {
+		Action string `json:"action"`
+		Target struct {
+			Repository string `json:"repository"`
+			Tag        string `json:"tag"`
+		} `json:"target"`
+		Request struct {
+			Host string `json:"host"`
+		} `json:"request"`
+	} `json:"events"`
+}
+
+func newWebhook(ctx context.Context, rootOpts *rootCmd, secret string) *webhook {
+	return &webhook{
+		rootOpts: rootOpts,
+		ctx:      ctx,
+		secret:   secret,
+		steps:    map[string]*webhookStep{},
+	}
+}
+
+// ServeHTTP parses the pushed references from the request and triggers the matching sync steps.
+func (wh *webhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, webhookBodyMax))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	if !wh.authorized(r, body) {
+		wh.rootOpts.log.Warn("Unauthorized webhook request",
+			slog.String("remote", r.RemoteAddr))
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	refs, err := webhookParse(r, body)
+	if err != nil {
+		wh.rootOpts.log.Warn("Failed to parse webhook request",
+			slog.String("remote", r.RemoteAddr),
+			slog.String("error", err.Error()))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	resp := webhookResponse{Triggered: []webhookTriggered{}}
+	found := map[string]bool{}
+	for _, pushed := range refs {
+		for _, s := range webhookMatch(wh.rootOpts.conf.Sync, pushed) {
+			key := s.Type + "|" + s.Source + "|" + s.Target
+			if found[key] {
+				continue
+			}
+			found[key] = true
+			wh.rootOpts.log.Info("Webhook triggered sync",
+				slog.String("pushed", pushed.CommonName()),
+				slog.String("source", s.Source),
+				slog.String("target", s.Target),
+				slog.String("type", s.Type))
+			wh.trigger(key, s)
+			resp.Triggered = append(resp.Triggered, webhookTriggered{Source: s.Source, Target: s.Target, Type: s.Type})
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if len(resp.Triggered) > 0 {
+		w.WriteHeader(http.StatusAccepted)
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// authorized verifies the secret from a bearer token, the "token" query parameter, or a GitHub signature.
+// The query parameter is supported for senders that cannot set headers, but may leave the secret in proxy logs.
+func (wh *webhook) authorized(r *http.Request, body []byte) bool {
+	if wh.secret == "" {
+		return true
+	}
+	if sig, ok := strings.CutPrefix(r.Header.Get("X-Hub-Signature-256"), "sha256="); ok {
+		mac := hmac.New(sha256.New, []byte(wh.secret))
+		mac.Write(body)
+		expect := hex.EncodeToString(mac.Sum(nil))
+		return hmac.Equal([]byte(sig), []byte(expect))
+	}
+	token := r.URL.Query().Get("token")
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	}
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(wh.secret)) == 1
+}
+
+// trigger runs a sync step in the background, a step that is already running is run again after it finishes.
+func (wh *webhook) trigger(key string, s ConfigSync) {
+	wh.mu.Lock()
+	ws, ok := wh.steps[key]
+	if !ok {
+		ws = &webhookStep{}
+		wh.steps[key] = ws
+	}
+	if ws.running {
+		ws.pending = true
+		wh.mu.Unlock()
+		return
+	}
+	ws.running = true
+	wh.mu.Unlock()
+	wh.wg.Add(1)
+	go func() {
+		defer wh.wg.Done()
+		for {
+			err := wh.rootOpts.process(wh.ctx, s, actionCopy)
+			if err != nil {
+				wh.rootOpts.log.Error("Webhook sync failed",
+					slog.String("source", s.Source),
+					slog.String("target", s.Target),
+					slog.String("error", err.Error()))
+			}
+			wh.mu.Lock()
+			if !ws.pending || wh.ctx.Err() != nil {
+				ws.running = false
+				wh.mu.Unlock()
+				return
+			}
+			ws.pending = false
+			wh.mu.Unlock()
+		}
+	}()
+}
+
+// wait returns after all triggered steps finish.
+func (wh *webhook) wait() {
+	wh.wg.Wait()
+}
+
+// webhookParse returns the pushed references from the "ref" query parameters or the request body.
+func webhookParse(r *http.Request, body []byte) ([]ref.Ref, error) {
+	refStrs := r.URL.Query()["ref"]
+	if len(refStrs) == 0 {
+		if len(body) == 0 {
+			return nil, fmt.Errorf("request body or ref parameter required%.0w", ErrMissingInput)
+		}
+		payload := webhookPayload{}
+		err := json.Unmarshal(body, &payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse body: %w", err)
+		}
+		if payload.Repository != nil && payload.Repository.RepoName != "" {
+			s := payload.Repository.RepoName
+			if payload.PushData != nil && payload.PushData.Tag != "" {
+				s = s + ":" + payload.PushData.Tag
+			}
+			refStrs = append(refStrs, s)
+		}
+		if payload.EventData != nil {
+			for _, res := range payload.EventData.Resources {
+				if res.ResourceURL != "" {
+					refStrs = append(refStrs, res.ResourceURL)
+				}
+			}
+		}
+		if payload.RegistryPackage != nil && payload.RegistryPackage.PackageVersion.PackageURL != "" {
+			refStrs = append(refStrs, payload.RegistryPackage.PackageVersion.PackageURL)
+		}
+		for _, event := range payload.Events {
+			if event.Action != "push" || event.Target.Repository == "" || event.Request.Host == "" {
+				continue
+			}
+			s := event.Request.Host + "/" + event.Target.Repository
+			if event.Target.Tag != "" {
+				s = s + ":" + event.Target.Tag
+			}
+			refStrs = append(refStrs, s)
+		}
+	}
+	refs := make([]ref.Ref, 0, len(refStrs))
+	for _, s := range refStrs {
+		pushed, err := ref.New(s)
+		if err != nil || pushed.Scheme != "reg" {
+			return nil, fmt.Errorf("invalid reference %s%.0w", s, ErrInvalidInput)
+		}
+		// the tag is only known when explicitly included
+		if !strings.Contains(s[strings.LastIndex(s, "/")+1:], ":") {
+			pushed.Tag = ""
+		}
+		refs = append(refs, pushed)
+	}
+	return refs, nil
+}
+
+// webhookMatch returns the sync steps to run for a pushed reference.
+// Steps for a registry or namespace are limited to the pushed repository.
+func webhookMatch(syncs []ConfigSync, pushed ref.Ref) []ConfigSync {
+	matches := []ConfigSync{}
+	for _, s := range syncs {
+		switch s.Type {
+		case "image", "repository":
+			sRef, err := ref.New(s.Source)
+			if err != nil || sRef.Registry != pushed.Registry || sRef.Repository != pushed.Repository {
+				continue
+			}
+			if s.Type == "image" && (sRef.Digest != "" || (pushed.Tag != "" && sRef.Tag != pushed.Tag)) {
+				continue
+			}
+			matches = append(matches, s)
+		case "registry", "namespace":
+			host, ns, _ := strings.Cut(strings.TrimSuffix(s.Source, "/"), "/")
+			hRef, err := ref.NewHost(host)
+			if err != nil || hRef.Registry != pushed.Registry {
+				continue
+			}
+			name := pushed.Repository
+			if s.Type == "namespace" {
+				var ok bool
+				name, ok = strings.CutPrefix(pushed.Repository, ns+"/")
+				if ns == "" || !ok {
+					continue
+				}
+			}
+			names, err := filterList(s.Repos, []string{name})
+			if err != nil || len(names) == 0 {
+				continue
+			}
+			s.Type = "repository"
+			s.Source = strings.TrimSuffix(s.Source, "/") + "/" + name
+			s.Target = strings.TrimSuffix(s.Target, "/") + "/" + name
+			matches = append(matches, s)
+		}
+	}
+	return matches
+}
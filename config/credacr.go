@@ -0,0 +1,152 @@
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/regclient/regclient/types/errs"
+)
+
+const (
+	azureAuthorityURL = "https://login.microsoftonline.com"
+	azureIMDSURL      = "http://169.254.169.254"
+	azureResource     = "https://management.azure.com/"
+	acrUser           = "00000000-0000-0000-0000-000000000000"
+)
+
+// credACR exchanges an Azure AD access token from the ambient Azure credentials for an ACR refresh token.
+// Credentials are resolved from a workload identity token, a service principal secret, and the managed identity,
+// using the AZURE_TENANT_ID, AZURE_CLIENT_ID, AZURE_FEDERATED_TOKEN_FILE, and AZURE_CLIENT_SECRET variables.
+type credACR struct {
+	client  *http.Client
+	getenv  func(string) string
+	now     func() time.Time
+	imdsURL string
+}
+
+func newCredACR() *credACR {
+	return &credACR{
+		client:  credProviderClient(),
+		now:     time.Now,
+		imdsURL: azureIMDSURL,
+	}
+}
+
+func (p *credACR) get(ctx context.Context, host *Host) (credToken, error) {
+	hostname := host.Hostname
+	if host.CredHost != "" {
+		hostname = host.CredHost
+	}
+	aadToken, err := p.aadToken(ctx)
+	if err != nil {
+		return credToken{}, err
+	}
+	form := url.Values{
+		"grant_type":   {"access_token"},
+		"service":      {hostname},
+		"access_token": {aadToken},
+	}
+	if tenant := credEnv(p.getenv)("AZURE_TENANT_ID"); tenant != "" {
+		form.Set("tenant", tenant)
+	}
+	scheme := "https"
+	if host.TLS == TLSDisabled {
+		scheme = "http"
+	}
+	req, err := credFormPost(ctx, scheme+"://"+hostname+"/oauth2/exchange", form)
+	if err != nil {
+		return credToken{}, err
+	}
+	resp := struct {
+		RefreshToken string `json:"refresh_token"`
+	}{}
+	err = credProviderDo(p.client, req, &resp)
+	if err != nil {
+		return credToken{}, fmt.Errorf("failed to exchange the Azure token for an ACR token: %w", err)
+	}
+	if resp.RefreshToken == "" {
+		return credToken{}, fmt.Errorf("ACR refresh token missing from response%.0w", errs.ErrNotFound)
+	}
+	return credToken{user: acrUser, pass: resp.RefreshToken, expires: jwtExpires(resp.RefreshToken)}, nil
+}
+
+// aadToken returns an Azure AD access token for the Azure resource manager.
+func (p *credACR) aadToken(ctx context.Context) (string, error) {
+	getenv := credEnv(p.getenv)
+	tenant, clientID := getenv("AZURE_TENANT_ID"), getenv("AZURE_CLIENT_ID")
+	var form url.Values
+	if tokenFile := getenv("AZURE_FEDERATED_TOKEN_FILE"); tokenFile != "" && tenant != "" && clientID != "" {
+		//#nosec G304 the token file is configured by the environment
+		assertion, err := os.ReadFile(tokenFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read federated token: %w", err)
+		}
+		form = url.Values{
+			"grant_type":            {"client_credentials"},
+			"client_id":             {clientID},
+			"scope":                 {azureResource + ".default"},
+			"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+			"client_assertion":      {strings.TrimSpace(string(assertion))},
+		}
+	} else if secret := getenv("AZURE_CLIENT_SECRET"); secret != "" && tenant != "" && clientID != "" {
+		form = url.Values{
+			"grant_type":    {"client_credentials"},
+			"client_id":     {clientID},
+			"client_secret": {secret},
+			"scope":         {azureResource + ".default"},
+		}
+	}
+	var req *http.Request
+	var err error
+	if form != nil {
+		authority := strings.TrimSuffix(getenv("AZURE_AUTHORITY_HOST"), "/")
+		if authority == "" {
+			authority = azureAuthorityURL
+		}
+		req, err = credFormPost(ctx, authority+"/"+url.PathEscape(tenant)+"/oauth2/v2.0/token", form)
+	} else {
+		// the managed identity of the VM or AKS node, with a client id selecting a user assigned identity
+		query := url.Values{"api-version": {"2018-02-01"}, "resource": {azureResource}}
+		if clientID != "" {
+			query.Set("client_id", clientID)
+		}
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, p.imdsURL+"/metadata/identity/oauth2/token?"+query.Encode(), nil)
+		if err == nil {
+			req.Header.Set("Metadata", "true")
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	token, _, err := credOAuthToken(p.client, req, p.now())
+	if err != nil {
+		return "", fmt.Errorf("failed to get Azure access token: %w", err)
+	}
+	return token, nil
+}
+
+// jwtExpires returns the expiration of a JWT, or the zero time when it cannot be parsed.
+func jwtExpires(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}
+	}
+	claims := struct {
+		Exp int64 `json:"exp"`
+	}{}
+	if json.Unmarshal(payload, &claims) != nil || claims.Exp <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(claims.Exp, 0)
+}
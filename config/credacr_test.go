@@ -0,0 +1,121 @@
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCredACR(t *testing.T) {
+	t.Parallel()
+	tempDir := t.TempDir()
+	expires := time.Now().Add(3 * time.Hour).Truncate(time.Second)
+	// the refresh token is a JWT with the expiration in the claims
+	refreshToken := "header." + base64.RawURLEncoding.EncodeToString([]byte(`{"exp":`+strconv.FormatInt(expires.Unix(), 10)+`}`)) + ".sig"
+	mux := http.NewServeMux()
+	mux.HandleFunc("/tenant-id/oauth2/v2.0/token", func(w http.ResponseWriter, r *http.Request) {
+		token := ""
+		switch {
+		case r.FormValue("client_assertion") == "federated-token" && r.FormValue("client_id") == "client-id":
+			token = "federated-aad"
+		case r.FormValue("client_secret") == "client-secret" && r.FormValue("client_id") == "client-id":
+			token = "secret-aad"
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"token_type":"Bearer","access_token":"` + token + `","expires_in":3599}`))
+	})
+	mux.HandleFunc("/metadata/identity/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata") != "true" || r.URL.Query().Get("resource") != azureResource {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		// IMDS returns the expiration as a string
+		_, _ = w.Write([]byte(`{"access_token":"imds-aad` + r.URL.Query().Get("client_id") + `","expires_in":"86399","token_type":"Bearer"}`))
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	hostname := strings.TrimPrefix(ts.URL, "http://")
+	mux.HandleFunc("/oauth2/exchange", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("grant_type") != "access_token" || r.FormValue("service") != hostname {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		// the refresh token identifies the AAD token that was exchanged
+		_, _ = w.Write([]byte(`{"refresh_token":"` + refreshToken + r.FormValue("access_token") + `"}`))
+	})
+
+	tokenFile := filepath.Join(tempDir, "federated-token")
+	err := os.WriteFile(tokenFile, []byte("federated-token\n"), 0600)
+	if err != nil {
+		t.Fatalf("failed to write token: %v", err)
+	}
+
+	tt := []struct {
+		name      string
+		env       map[string]string
+		expectAAD string
+	}{
+		{
+			name: "workload identity",
+			env: map[string]string{
+				"AZURE_AUTHORITY_HOST":       ts.URL + "/",
+				"AZURE_TENANT_ID":            "tenant-id",
+				"AZURE_CLIENT_ID":            "client-id",
+				"AZURE_FEDERATED_TOKEN_FILE": tokenFile,
+			},
+			expectAAD: "federated-aad",
+		},
+		{
+			name: "client secret",
+			env: map[string]string{
+				"AZURE_AUTHORITY_HOST": ts.URL,
+				"AZURE_TENANT_ID":      "tenant-id",
+				"AZURE_CLIENT_ID":      "client-id",
+				"AZURE_CLIENT_SECRET":  "client-secret",
+			},
+			expectAAD: "secret-aad",
+		},
+		{
+			name:      "managed identity",
+			env:       map[string]string{},
+			expectAAD: "imds-aad",
+		},
+		{
+			name:      "user assigned identity",
+			env:       map[string]string{"AZURE_CLIENT_ID": "client-id"},
+			expectAAD: "imds-aadclient-id",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newCredACR()
+			p.client = ts.Client()
+			p.getenv = testGetenv(tc.env)
+			p.imdsURL = ts.URL
+			ct, err := p.get(context.Background(), &Host{Hostname: hostname, TLS: TLSDisabled})
+			if err != nil {
+				t.Fatalf("failed to get credential: %v", err)
+			}
+			if ct.user != acrUser || ct.pass != refreshToken+tc.expectAAD {
+				t.Errorf("unexpected credential, expected %s:%s, received %s:%s", acrUser, refreshToken+tc.expectAAD, ct.user, ct.pass)
+			}
+		})
+	}
+	t.Run("expires", func(t *testing.T) {
+		if !jwtExpires(refreshToken).Equal(expires) {
+			t.Errorf("unexpected expiration, expected %v, received %v", expires, jwtExpires(refreshToken))
+		}
+		if !jwtExpires("not-a-jwt").IsZero() {
+			t.Errorf("expiration returned for an invalid token")
+		}
+	})
+}
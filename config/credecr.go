@@ -0,0 +1,392 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/regclient/regclient/types/errs"
+)
+
+const (
+	awsECSURL  = "http://169.254.170.2"
+	awsIMDSURL = "http://169.254.169.254"
+)
+
+// ecrHostRe matches the private ECR registry hostnames, capturing the account, fips suffix, region, and domain.
+var ecrHostRe = regexp.MustCompile(`^([0-9]{12})\.dkr\.ecr(-fips)?\.([a-z0-9-]+)\.(amazonaws\.com(?:\.cn)?)$`)
+
+// credECR gets an ECR login from the GetAuthorizationToken API, signed with the ambient AWS credentials.
+// Credentials are resolved in the same order as the AWS SDK: environment variables, a web identity token,
+// the shared credentials file, the container credentials endpoint, and the EC2 instance metadata service.
+type credECR struct {
+	client      *http.Client
+	getenv      func(string) string
+	now         func() time.Time
+	ecrEndpoint string // overrides the regional ECR API endpoint
+	stsEndpoint string // overrides the regional STS endpoint
+	ecsURL      string
+	imdsURL     string
+}
+
+// awsCred is an AWS access key with an optional session token.
+type awsCred struct {
+	keyID, secret, session string
+}
+
+func newCredECR() *credECR {
+	return &credECR{
+		client:  credProviderClient(),
+		now:     time.Now,
+		ecsURL:  awsECSURL,
+		imdsURL: awsIMDSURL,
+	}
+}
+
+func (p *credECR) get(ctx context.Context, host *Host) (credToken, error) {
+	hostname := host.Hostname
+	if host.CredHost != "" {
+		hostname = host.CredHost
+	}
+	match := ecrHostRe.FindStringSubmatch(hostname)
+	if match == nil {
+		return credToken{}, fmt.Errorf("hostname %s is not an ECR registry%.0w", hostname, errs.ErrUnsupported)
+	}
+	account, fips, region, domain := match[1], match[2], match[3], match[4]
+	ecrEndpoint, stsEndpoint := p.ecrEndpoint, p.stsEndpoint
+	if ecrEndpoint == "" {
+		ecrEndpoint = "https://api.ecr." + region + "." + domain
+		if fips != "" {
+			ecrEndpoint = "https://ecr-fips." + region + "." + domain
+		}
+	}
+	if stsEndpoint == "" {
+		stsEndpoint = "https://sts." + region + "." + domain
+	}
+	cred, err := p.awsCred(ctx, stsEndpoint)
+	if err != nil {
+		return credToken{}, err
+	}
+	body, err := json.Marshal(map[string][]string{"registryIds": {account}})
+	if err != nil {
+		return credToken{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ecrEndpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return credToken{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "AmazonEC2ContainerRegistry_V20150921.GetAuthorizationToken")
+	awsSign(req, body, cred, region, "ecr", p.now())
+	resp := struct {
+		AuthorizationData []struct {
+			AuthorizationToken string  `json:"authorizationToken"`
+			ExpiresAt          float64 `json:"expiresAt"`
+		} `json:"authorizationData"`
+	}{}
+	err = credProviderDo(p.client, req, &resp)
+	if err != nil {
+		return credToken{}, fmt.Errorf("failed to get ECR authorization token: %w", err)
+	}
+	if len(resp.AuthorizationData) == 0 {
+		return credToken{}, fmt.Errorf("ECR authorization token missing from response%.0w", errs.ErrNotFound)
+	}
+	login, err := base64.StdEncoding.DecodeString(resp.AuthorizationData[0].AuthorizationToken)
+	if err != nil {
+		return credToken{}, fmt.Errorf("failed to decode ECR authorization token: %w", err)
+	}
+	user, pass, ok := strings.Cut(string(login), ":")
+	if !ok {
+		return credToken{}, fmt.Errorf("ECR authorization token is not a user and password%.0w", errs.ErrParsingFailed)
+	}
+	ct := credToken{user: user, pass: pass}
+	if resp.AuthorizationData[0].ExpiresAt > 0 {
+		ct.expires = time.Unix(int64(resp.AuthorizationData[0].ExpiresAt), 0)
+	}
+	return ct, nil
+}
+
+// awsCred resolves the ambient AWS credentials.
+func (p *credECR) awsCred(ctx context.Context, stsEndpoint string) (awsCred, error) {
+	getenv := credEnv(p.getenv)
+	if keyID, secret := getenv("AWS_ACCESS_KEY_ID"), getenv("AWS_SECRET_ACCESS_KEY"); keyID != "" && secret != "" {
+		return awsCred{keyID: keyID, secret: secret, session: getenv("AWS_SESSION_TOKEN")}, nil
+	}
+	if tokenFile, role := getenv("AWS_WEB_IDENTITY_TOKEN_FILE"), getenv("AWS_ROLE_ARN"); tokenFile != "" && role != "" {
+		return p.awsWebIdentityCred(ctx, stsEndpoint, tokenFile, role)
+	}
+	credFile := getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if credFile == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			credFile = filepath.Join(home, ".aws", "credentials")
+		}
+	}
+	profile := getenv("AWS_PROFILE")
+	if profile == "" {
+		profile = "default"
+	}
+	if credFile != "" {
+		cred, err := awsSharedCred(credFile, profile)
+		if err == nil {
+			return cred, nil
+		} else if !errors.Is(err, errs.ErrNotFound) {
+			return awsCred{}, err
+		}
+	}
+	if getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI") != "" || getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI") != "" {
+		return p.awsContainerCred(ctx)
+	}
+	if strings.EqualFold(getenv("AWS_EC2_METADATA_DISABLED"), "true") {
+		return awsCred{}, fmt.Errorf("AWS credentials not found%.0w", errs.ErrNotFound)
+	}
+	return p.awsIMDSCred(ctx)
+}
+
+// awsWebIdentityCred exchanges a web identity token for credentials, e.g. an EKS service account token.
+func (p *credECR) awsWebIdentityCred(ctx context.Context, stsEndpoint, tokenFile, role string) (awsCred, error) {
+	//#nosec G304 the token file is configured by the user
+	token, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return awsCred{}, fmt.Errorf("failed to read web identity token: %w", err)
+	}
+	session := credEnv(p.getenv)("AWS_ROLE_SESSION_NAME")
+	if session == "" {
+		session = "regclient"
+	}
+	form := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {"2011-06-15"},
+		"RoleArn":          {role},
+		"RoleSessionName":  {session},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+	}
+	req, err := credFormPost(ctx, stsEndpoint+"/", form)
+	if err != nil {
+		return awsCred{}, err
+	}
+	body, err := credProviderRead(p.client, req)
+	if err != nil {
+		return awsCred{}, fmt.Errorf("failed to assume role %s: %w", role, err)
+	}
+	resp := struct {
+		Credentials struct {
+			AccessKeyID     string `xml:"AccessKeyId"`
+			SecretAccessKey string `xml:"SecretAccessKey"`
+			SessionToken    string `xml:"SessionToken"`
+		} `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+	}{}
+	err = xml.Unmarshal(body, &resp)
+	if err != nil {
+		return awsCred{}, fmt.Errorf("failed to parse assume role response: %w", err)
+	}
+	if resp.Credentials.AccessKeyID == "" {
+		return awsCred{}, fmt.Errorf("assume role response is missing credentials%.0w", errs.ErrNotFound)
+	}
+	return awsCred{keyID: resp.Credentials.AccessKeyID, secret: resp.Credentials.SecretAccessKey, session: resp.Credentials.SessionToken}, nil
+}
+
+// awsContainerCred gets credentials from the ECS or EKS pod identity endpoint.
+func (p *credECR) awsContainerCred(ctx context.Context) (awsCred, error) {
+	getenv := credEnv(p.getenv)
+	u := getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	if rel := getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); rel != "" {
+		u = p.ecsURL + rel
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return awsCred{}, err
+	}
+	auth := getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	if authFile := getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); authFile != "" {
+		//#nosec G304 the token file is configured by the environment
+		authB, err := os.ReadFile(authFile)
+		if err != nil {
+			return awsCred{}, fmt.Errorf("failed to read container authorization token: %w", err)
+		}
+		auth = strings.TrimSpace(string(authB))
+	}
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	return awsCredRequest(p.client, req)
+}
+
+// awsIMDSCred gets the credentials of the instance role from IMDSv2.
+func (p *credECR) awsIMDSCred(ctx context.Context) (awsCred, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, p.imdsURL+"/latest/api/token", nil)
+	if err != nil {
+		return awsCred{}, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "21600")
+	token, err := credProviderRead(p.client, req)
+	if err != nil {
+		return awsCred{}, fmt.Errorf("failed to get instance metadata token: %w", err)
+	}
+	credURL := p.imdsURL + "/latest/meta-data/iam/security-credentials/"
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, credURL, nil)
+	if err != nil {
+		return awsCred{}, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", string(token))
+	roles, err := credProviderRead(p.client, req)
+	if err != nil {
+		return awsCred{}, fmt.Errorf("failed to get instance role: %w", err)
+	}
+	role, _, _ := strings.Cut(strings.TrimSpace(string(roles)), "\n")
+	if role == "" {
+		return awsCred{}, fmt.Errorf("instance does not have a role%.0w", errs.ErrNotFound)
+	}
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, credURL+url.PathEscape(role), nil)
+	if err != nil {
+		return awsCred{}, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", string(token))
+	return awsCredRequest(p.client, req)
+}
+
+// awsCredRequest parses the credentials returned by the container and instance metadata endpoints.
+func awsCredRequest(client *http.Client, req *http.Request) (awsCred, error) {
+	resp := struct {
+		AccessKeyID     string `json:"AccessKeyId"`
+		SecretAccessKey string `json:"SecretAccessKey"`
+		Token           string `json:"Token"`
+	}{}
+	err := credProviderDo(client, req, &resp)
+	if err != nil {
+		return awsCred{}, fmt.Errorf("failed to get AWS credentials: %w", err)
+	}
+	if resp.AccessKeyID == "" || resp.SecretAccessKey == "" {
+		return awsCred{}, fmt.Errorf("AWS credentials missing from %s%.0w", req.URL.Host, errs.ErrNotFound)
+	}
+	return awsCred{keyID: resp.AccessKeyID, secret: resp.SecretAccessKey, session: resp.Token}, nil
+}
+
+// awsSharedCred reads the static keys of a profile from the shared credentials file.
+// Profiles without keys, e.g. those configured for SSO or role chaining, return [errs.ErrNotFound].
+func awsSharedCred(file, profile string) (awsCred, error) {
+	//#nosec G304 the credentials file is configured by the user
+	b, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return awsCred{}, fmt.Errorf("AWS credentials file not found%.0w", errs.ErrNotFound)
+	} else if err != nil {
+		return awsCred{}, fmt.Errorf("failed to read AWS credentials: %w", err)
+	}
+	cred := awsCred{}
+	section := ""
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		if section != profile {
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(k)) {
+		case "aws_access_key_id":
+			cred.keyID = strings.TrimSpace(v)
+		case "aws_secret_access_key":
+			cred.secret = strings.TrimSpace(v)
+		case "aws_session_token":
+			cred.session = strings.TrimSpace(v)
+		}
+	}
+	if cred.keyID == "" || cred.secret == "" {
+		return awsCred{}, fmt.Errorf("AWS profile %s does not have an access key%.0w", profile, errs.ErrNotFound)
+	}
+	return cred, nil
+}
+
+// awsSign adds an AWS signature version 4 to a request.
+func awsSign(req *http.Request, body []byte, cred awsCred, region, service string, t time.Time) {
+	amzDate := t.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	if cred.session != "" {
+		req.Header.Set("X-Amz-Security-Token", cred.session)
+	}
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	canonHeaders := ""
+	for _, k := range names {
+		canonHeaders += k + ":" + headers[k] + "\n"
+	}
+	signedHeaders := strings.Join(names, ";")
+	query := req.URL.Query()
+	queryKeys := make([]string, 0, len(query))
+	for k := range query {
+		queryKeys = append(queryKeys, k)
+	}
+	sort.Strings(queryKeys)
+	queryParts := []string{}
+	for _, k := range queryKeys {
+		vals := query[k]
+		sort.Strings(vals)
+		for _, v := range vals {
+			queryParts = append(queryParts, awsEscape(k)+"="+awsEscape(v))
+		}
+	}
+	canonURI := req.URL.EscapedPath()
+	if canonURI == "" {
+		canonURI = "/"
+	}
+	canonReq := strings.Join([]string{req.Method, canonURI, strings.Join(queryParts, "&"), canonHeaders, signedHeaders, sha256Hex(body)}, "\n")
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonReq))
+	key := hmacSHA256([]byte("AWS4"+cred.secret), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	sig := hex.EncodeToString(hmacSHA256(key, toSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", cred.keyID, scope, signedHeaders, sig))
+}
+
+// awsEscape encodes a query value with the RFC 3986 rules required by the signature.
+func awsEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,211 @@
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/regclient/regclient/types/errs"
+)
+
+func TestAWSSign(t *testing.T) {
+	t.Parallel()
+	// get-vanilla from the AWS signature version 4 test suite
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	ts := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	awsSign(req, nil, awsCred{keyID: "AKIDEXAMPLE", secret: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}, "us-east-1", "service", ts)
+	expect := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if auth := req.Header.Get("Authorization"); auth != expect {
+		t.Errorf("unexpected authorization, expected %s, received %s", expect, auth)
+	}
+}
+
+func TestCredECR(t *testing.T) {
+	t.Parallel()
+	tempDir := t.TempDir()
+	expires := time.Now().Add(12 * time.Hour).Truncate(time.Second)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ecr/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Target") != "AmazonEC2ContainerRegistry_V20150921.GetAuthorizationToken" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		auth := r.Header.Get("Authorization")
+		if !strings.Contains(auth, "/us-west-2/ecr/aws4_request") || !strings.Contains(auth, "x-amz-target") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		// the password identifies the access key that signed the request
+		cred := strings.TrimPrefix(strings.SplitN(auth, "/", 2)[0], "AWS4-HMAC-SHA256 Credential=")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"authorizationData": []map[string]interface{}{{
+				"authorizationToken": base64.StdEncoding.EncodeToString([]byte("AWS:" + cred + r.Header.Get("X-Amz-Security-Token"))),
+				"expiresAt":          float64(expires.Unix()),
+			}},
+		})
+	})
+	mux.HandleFunc("/sts/", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("Action") != "AssumeRoleWithWebIdentity" || r.FormValue("WebIdentityToken") != "web-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`<AssumeRoleWithWebIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleWithWebIdentityResult>
+    <Credentials>
+      <AccessKeyId>AKIDWEB</AccessKeyId>
+      <SecretAccessKey>secret</SecretAccessKey>
+      <SessionToken>web-session</SessionToken>
+    </Credentials>
+  </AssumeRoleWithWebIdentityResult>
+</AssumeRoleWithWebIdentityResponse>`))
+	})
+	mux.HandleFunc("/container/creds", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "container-auth" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"AccessKeyId":"AKIDCONTAINER","SecretAccessKey":"secret","Token":"container-session"}`))
+	})
+	mux.HandleFunc("/latest/api/token", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("imds-token"))
+	})
+	mux.HandleFunc("/latest/meta-data/iam/security-credentials/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-aws-ec2-metadata-token") != "imds-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("instance-role"))
+	})
+	mux.HandleFunc("/latest/meta-data/iam/security-credentials/instance-role", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"Code":"Success","AccessKeyId":"AKIDINSTANCE","SecretAccessKey":"secret","Token":"instance-session"}`))
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	webTokenFile := filepath.Join(tempDir, "web-token")
+	credFile := filepath.Join(tempDir, "credentials")
+	err := os.WriteFile(webTokenFile, []byte("web-token\n"), 0600)
+	if err != nil {
+		t.Fatalf("failed to write token: %v", err)
+	}
+	err = os.WriteFile(credFile, []byte("[default]\naws_access_key_id = AKIDDEFAULT\naws_secret_access_key = secret\n\n[other]\naws_access_key_id=AKIDOTHER\naws_secret_access_key=secret\naws_session_token=other-session\n"), 0600)
+	if err != nil {
+		t.Fatalf("failed to write credentials: %v", err)
+	}
+	missingFile := filepath.Join(tempDir, "missing")
+
+	tt := []struct {
+		name      string
+		hostname  string
+		env       map[string]string
+		expectErr error
+		expectPW  string
+	}{
+		{
+			name:     "environment",
+			hostname: "123456789012.dkr.ecr.us-west-2.amazonaws.com",
+			env: map[string]string{
+				"AWS_ACCESS_KEY_ID":     "AKIDENV",
+				"AWS_SECRET_ACCESS_KEY": "secret",
+				"AWS_SESSION_TOKEN":     "env-session",
+			},
+			expectPW: "AKIDENVenv-session",
+		},
+		{
+			name:     "web identity",
+			hostname: "123456789012.dkr.ecr.us-west-2.amazonaws.com",
+			env: map[string]string{
+				"AWS_WEB_IDENTITY_TOKEN_FILE": webTokenFile,
+				"AWS_ROLE_ARN":                "arn:aws:iam::123456789012:role/test",
+			},
+			expectPW: "AKIDWEBweb-session",
+		},
+		{
+			name:     "shared credentials",
+			hostname: "123456789012.dkr.ecr.us-west-2.amazonaws.com",
+			env: map[string]string{
+				"AWS_SHARED_CREDENTIALS_FILE": credFile,
+			},
+			expectPW: "AKIDDEFAULT",
+		},
+		{
+			name:     "shared credentials profile",
+			hostname: "123456789012.dkr.ecr.us-west-2.amazonaws.com",
+			env: map[string]string{
+				"AWS_SHARED_CREDENTIALS_FILE": credFile,
+				"AWS_PROFILE":                 "other",
+			},
+			expectPW: "AKIDOTHERother-session",
+		},
+		{
+			name:     "container",
+			hostname: "123456789012.dkr.ecr.us-west-2.amazonaws.com",
+			env: map[string]string{
+				"AWS_SHARED_CREDENTIALS_FILE":            missingFile,
+				"AWS_CONTAINER_CREDENTIALS_RELATIVE_URI": "/container/creds",
+				"AWS_CONTAINER_AUTHORIZATION_TOKEN":      "container-auth",
+			},
+			expectPW: "AKIDCONTAINERcontainer-session",
+		},
+		{
+			name:     "instance",
+			hostname: "123456789012.dkr.ecr.us-west-2.amazonaws.com",
+			env: map[string]string{
+				"AWS_SHARED_CREDENTIALS_FILE": missingFile,
+			},
+			expectPW: "AKIDINSTANCEinstance-session",
+		},
+		{
+			name:     "no credentials",
+			hostname: "123456789012.dkr.ecr.us-west-2.amazonaws.com",
+			env: map[string]string{
+				"AWS_SHARED_CREDENTIALS_FILE": missingFile,
+				"AWS_EC2_METADATA_DISABLED":   "true",
+			},
+			expectErr: errs.ErrNotFound,
+		},
+		{
+			name:      "not ecr",
+			hostname:  "registry.example.org",
+			expectErr: errs.ErrUnsupported,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newCredECR()
+			p.client = ts.Client()
+			p.getenv = testGetenv(tc.env)
+			p.ecrEndpoint = ts.URL + "/ecr"
+			p.stsEndpoint = ts.URL + "/sts"
+			p.ecsURL = ts.URL
+			p.imdsURL = ts.URL
+			ct, err := p.get(context.Background(), &Host{Hostname: tc.hostname})
+			if tc.expectErr != nil {
+				if !errors.Is(err, tc.expectErr) {
+					t.Errorf("unexpected error, expected %v, received %v", tc.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to get credential: %v", err)
+			}
+			if ct.user != "AWS" || ct.pass != tc.expectPW {
+				t.Errorf("unexpected credential, expected AWS:%s, received %s:%s", tc.expectPW, ct.user, ct.pass)
+			}
+			if !ct.expires.Equal(expires) {
+				t.Errorf("unexpected expiration, expected %v, received %v", expires, ct.expires)
+			}
+		})
+	}
+}
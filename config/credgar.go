@@ -0,0 +1,203 @@
+package config
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/regclient/regclient/types/errs"
+)
+
+const (
+	gcpMetadataURL = "http://metadata.google.internal"
+	gcpTokenURL    = "https://oauth2.googleapis.com/token"
+	gcpScope       = "https://www.googleapis.com/auth/cloud-platform"
+	garUser        = "oauth2accesstoken"
+)
+
+// credGAR gets an access token for Google Artifact Registry and GCR from the ambient Google credentials.
+// Credentials are resolved like the Google application default credentials: the GOOGLE_APPLICATION_CREDENTIALS file,
+// the gcloud application default credentials file, and the metadata server.
+type credGAR struct {
+	client      *http.Client
+	getenv      func(string) string
+	now         func() time.Time
+	metadataURL string
+}
+
+// gcpCredFile is a service account key or gcloud user credential file.
+type gcpCredFile struct {
+	Type         string `json:"type"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyID string `json:"private_key_id"`
+	TokenURI     string `json:"token_uri"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+func newCredGAR() *credGAR {
+	return &credGAR{
+		client:      credProviderClient(),
+		now:         time.Now,
+		metadataURL: gcpMetadataURL,
+	}
+}
+
+func (p *credGAR) get(ctx context.Context, host *Host) (credToken, error) {
+	getenv := credEnv(p.getenv)
+	file := getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if file == "" {
+		dir := getenv("CLOUDSDK_CONFIG")
+		if dir == "" && runtime.GOOS == "windows" {
+			if appData := getenv("APPDATA"); appData != "" {
+				dir = filepath.Join(appData, "gcloud")
+			}
+		} else if dir == "" {
+			if home, err := os.UserHomeDir(); err == nil {
+				dir = filepath.Join(home, ".config", "gcloud")
+			}
+		}
+		if dir != "" {
+			file = filepath.Join(dir, "application_default_credentials.json")
+			if _, err := os.Stat(file); errors.Is(err, fs.ErrNotExist) {
+				file = ""
+			}
+		}
+	}
+	var token string
+	var expires time.Time
+	var err error
+	if file != "" {
+		token, expires, err = p.fileToken(ctx, file)
+	} else {
+		token, expires, err = p.metadataToken(ctx)
+	}
+	if err != nil {
+		return credToken{}, err
+	}
+	return credToken{user: garUser, pass: token, expires: expires}, nil
+}
+
+// fileToken gets an access token with a service account key or the refresh token of a gcloud login.
+func (p *credGAR) fileToken(ctx context.Context, file string) (string, time.Time, error) {
+	//#nosec G304 the credential file is configured by the user
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to read Google credentials: %w", err)
+	}
+	cf := gcpCredFile{}
+	err = json.Unmarshal(b, &cf)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to parse Google credentials %s: %w", file, err)
+	}
+	tokenURL := cf.TokenURI
+	if tokenURL == "" {
+		tokenURL = gcpTokenURL
+	}
+	var form url.Values
+	switch cf.Type {
+	case "service_account":
+		assertion, err := p.serviceAccountJWT(cf, tokenURL)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		form = url.Values{
+			"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+			"assertion":  {assertion},
+		}
+	case "authorized_user":
+		form = url.Values{
+			"grant_type":    {"refresh_token"},
+			"client_id":     {cf.ClientID},
+			"client_secret": {cf.ClientSecret},
+			"refresh_token": {cf.RefreshToken},
+		}
+	default:
+		return "", time.Time{}, fmt.Errorf("unsupported Google credential type %q in %s%.0w", cf.Type, file, errs.ErrUnsupported)
+	}
+	req, err := credFormPost(ctx, tokenURL, form)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	token, expires, err := credOAuthToken(p.client, req, p.now())
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to get Google access token: %w", err)
+	}
+	return token, expires, nil
+}
+
+// serviceAccountJWT creates the signed assertion exchanged for a service account access token.
+func (p *credGAR) serviceAccountJWT(cf gcpCredFile, tokenURL string) (string, error) {
+	block, _ := pem.Decode([]byte(cf.PrivateKey))
+	if block == nil {
+		return "", fmt.Errorf("service account private key is not PEM encoded%.0w", errs.ErrParsingFailed)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to parse service account private key: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return "", fmt.Errorf("service account private key is not an RSA key%.0w", errs.ErrUnsupported)
+	}
+	now := p.now()
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": cf.PrivateKeyID})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss":   cf.ClientEmail,
+		"scope": gcpScope,
+		"aud":   tokenURL,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign service account assertion: %w", err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// metadataToken gets the access token of the default service account from the metadata server.
+func (p *credGAR) metadataToken(ctx context.Context) (string, time.Time, error) {
+	u := p.metadataURL
+	if mdHost := credEnv(p.getenv)("GCE_METADATA_HOST"); mdHost != "" {
+		u = "http://" + mdHost
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u+"/computeMetadata/v1/instance/service-accounts/default/token", nil)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	token, expires, err := credOAuthToken(p.client, req, p.now())
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to get access token from the Google metadata server: %w", err)
+	}
+	return token, expires, nil
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/regclient/regclient/types/errs"
+)
+
+func TestCredGAR(t *testing.T) {
+	t.Parallel()
+	tempDir := t.TempDir()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	keyDer, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		token := ""
+		switch r.FormValue("grant_type") {
+		case "urn:ietf:params:oauth:grant-type:jwt-bearer":
+			// verify the assertion is signed by the service account key
+			parts := strings.Split(r.FormValue("assertion"), ".")
+			if len(parts) != 3 {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+			hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+			if rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hash[:], sig) != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			claimsJSON, _ := base64.RawURLEncoding.DecodeString(parts[1])
+			claims := map[string]interface{}{}
+			_ = json.Unmarshal(claimsJSON, &claims)
+			if claims["iss"] != "sa@example.iam.gserviceaccount.com" || claims["scope"] != gcpScope {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			token = "sa-token"
+		case "refresh_token":
+			if r.FormValue("refresh_token") != "user-refresh" || r.FormValue("client_secret") != "user-secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			token = "user-token"
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"` + token + `","expires_in":3600,"token_type":"Bearer"}`))
+	})
+	mux.HandleFunc("/computeMetadata/v1/instance/service-accounts/default/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"metadata-token","expires_in":3600,"token_type":"Bearer"}`))
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	saFile := filepath.Join(tempDir, "sa.json")
+	saJSON, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"client_email":   "sa@example.iam.gserviceaccount.com",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer})),
+		"private_key_id": "key-id",
+		"token_uri":      ts.URL + "/token",
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal service account: %v", err)
+	}
+	err = os.WriteFile(saFile, saJSON, 0600)
+	if err != nil {
+		t.Fatalf("failed to write service account: %v", err)
+	}
+	gcloudDir := filepath.Join(tempDir, "gcloud")
+	err = os.MkdirAll(gcloudDir, 0700)
+	if err != nil {
+		t.Fatalf("failed to create gcloud dir: %v", err)
+	}
+	err = os.WriteFile(filepath.Join(gcloudDir, "application_default_credentials.json"),
+		[]byte(`{"type":"authorized_user","client_id":"user-id","client_secret":"user-secret","refresh_token":"user-refresh","token_uri":"`+ts.URL+`/token"}`), 0600)
+	if err != nil {
+		t.Fatalf("failed to write user credentials: %v", err)
+	}
+	extFile := filepath.Join(tempDir, "external.json")
+	err = os.WriteFile(extFile, []byte(`{"type":"external_account"}`), 0600)
+	if err != nil {
+		t.Fatalf("failed to write external account: %v", err)
+	}
+
+	tt := []struct {
+		name      string
+		env       map[string]string
+		expectErr error
+		expectPW  string
+	}{
+		{
+			name:     "service account",
+			env:      map[string]string{"GOOGLE_APPLICATION_CREDENTIALS": saFile},
+			expectPW: "sa-token",
+		},
+		{
+			name:     "gcloud user",
+			env:      map[string]string{"CLOUDSDK_CONFIG": gcloudDir},
+			expectPW: "user-token",
+		},
+		{
+			name:     "metadata",
+			env:      map[string]string{"CLOUDSDK_CONFIG": filepath.Join(tempDir, "missing")},
+			expectPW: "metadata-token",
+		},
+		{
+			name:      "unsupported type",
+			env:       map[string]string{"GOOGLE_APPLICATION_CREDENTIALS": extFile},
+			expectErr: errs.ErrUnsupported,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newCredGAR()
+			p.client = ts.Client()
+			p.getenv = testGetenv(tc.env)
+			p.metadataURL = ts.URL
+			start := time.Now()
+			ct, err := p.get(context.Background(), &Host{Hostname: "us-docker.pkg.dev"})
+			if tc.expectErr != nil {
+				if !errors.Is(err, tc.expectErr) {
+					t.Errorf("unexpected error, expected %v, received %v", tc.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to get credential: %v", err)
+			}
+			if ct.user != garUser || ct.pass != tc.expectPW {
+				t.Errorf("unexpected credential, expected %s:%s, received %s:%s", garUser, tc.expectPW, ct.user, ct.pass)
+			}
+			if ct.expires.Before(start.Add(time.Hour)) || ct.expires.After(time.Now().Add(time.Hour)) {
+				t.Errorf("unexpected expiration: %v", ct.expires)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/regclient/regclient/types/errs"
+)
+
+const (
+	// CredProviderECR gets a token for AWS ECR from the ambient AWS credentials.
+	CredProviderECR = "ecr"
+	// CredProviderGAR gets a token for Google Artifact Registry and GCR from the ambient Google credentials.
+	CredProviderGAR = "gar"
+	// CredProviderACR gets a token for Azure ACR from the ambient Azure credentials.
+	CredProviderACR = "acr"
+	// credProviderTimeout limits the time spent requesting a credential from a provider.
+	credProviderTimeout = time.Second * 30
+	// credProviderMargin refreshes a provider credential before it expires.
+	credProviderMargin = time.Minute * 5
+	// credProviderRespMax limits the size of responses from the cloud APIs.
+	credProviderRespMax = 1024 * 1024
+)
+
+// credProvider obtains a registry credential from ambient cloud credentials.
+type credProvider interface {
+	get(ctx context.Context, host *Host) (credToken, error)
+}
+
+// credToken is a registry login from a provider, with the time it expires if known.
+type credToken struct {
+	user, pass string
+	expires    time.Time
+}
+
+// credProviders are the built-in providers selectable with [Host.CredProvider].
+var credProviders = map[string]func() credProvider{
+	CredProviderECR: func() credProvider { return newCredECR() },
+	CredProviderGAR: func() credProvider { return newCredGAR() },
+	CredProviderACR: func() credProvider { return newCredACR() },
+}
+
+// credProviderClient is the default http client for the cloud APIs.
+func credProviderClient() *http.Client {
+	return &http.Client{Timeout: credProviderTimeout}
+}
+
+// credProviderDo sends a request and decodes the json response, returning an error for any non-200 status.
+func credProviderDo(client *http.Client, req *http.Request, out interface{}) error {
+	body, err := credProviderRead(client, req)
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(body, out)
+	if err != nil {
+		return fmt.Errorf("failed to parse response from %s: %w", req.URL.Host, err)
+	}
+	return nil
+}
+
+// credProviderRead sends a request and returns the body, returning an error for any non-200 status.
+func credProviderRead(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, credProviderRespMax))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response from %s: %w", req.URL.Host, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("request to %s failed, status %d: %s%.0w", req.URL.Host, resp.StatusCode, strings.TrimSpace(string(body)), errs.ErrHTTPStatus)
+	}
+	return body, nil
+}
+
+// credOAuthToken sends an OAuth2 token request, returning the access token and when it expires.
+func credOAuthToken(client *http.Client, req *http.Request, now time.Time) (string, time.Time, error) {
+	resp := struct {
+		AccessToken string      `json:"access_token"`
+		ExpiresIn   credSeconds `json:"expires_in"`
+	}{}
+	err := credProviderDo(client, req, &resp)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if resp.AccessToken == "" {
+		return "", time.Time{}, fmt.Errorf("access token missing from %s response%.0w", req.URL.Host, errs.ErrNotFound)
+	}
+	expires := time.Time{}
+	if resp.ExpiresIn > 0 {
+		expires = now.Add(time.Duration(resp.ExpiresIn) * time.Second)
+	}
+	return resp.AccessToken, expires, nil
+}
+
+// credFormPost creates a POST request with a form encoded body.
+func credFormPost(ctx context.Context, u string, form url.Values) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req, nil
+}
+
+// credEnv returns the getenv function, defaulting to the process environment.
+func credEnv(getenv func(string) string) func(string) string {
+	if getenv != nil {
+		return getenv
+	}
+	return os.Getenv
+}
+
+// credSeconds is a number of seconds that may be encoded as a json number or string.
+type credSeconds int64
+
+func (s *credSeconds) UnmarshalJSON(b []byte) error {
+	str := strings.Trim(string(b), `"`)
+	if str == "" {
+		*s = 0
+		return nil
+	}
+	i, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return fmt.Errorf("failed to parse seconds %s: %w", string(b), err)
+	}
+	*s = credSeconds(i)
+	return nil
+}
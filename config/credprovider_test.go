@@ -0,0 +1,119 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/regclient/regclient/internal/timejson"
+)
+
+// testGetenv returns a getenv function for a fixed environment.
+func testGetenv(env map[string]string) func(string) string {
+	return func(k string) string {
+		return env[k]
+	}
+}
+
+type testCredProvider struct {
+	calls   int
+	err     error
+	expires time.Duration
+}
+
+func (p *testCredProvider) get(ctx context.Context, host *Host) (credToken, error) {
+	p.calls++
+	if p.err != nil {
+		return credToken{}, p.err
+	}
+	ct := credToken{user: "user-" + host.Hostname, pass: "pass"}
+	if p.expires != 0 {
+		ct.expires = time.Now().Add(p.expires)
+	}
+	return ct, nil
+}
+
+func TestCredProvider(t *testing.T) {
+	// the provider list is shared by all tests, so this test is not run in parallel
+	p := &testCredProvider{}
+	credProviders["test"] = func() credProvider { return p }
+	t.Cleanup(func() { delete(credProviders, "test") })
+
+	t.Run("get", func(t *testing.T) {
+		h := HostNewName("registry.example.org")
+		h.CredProvider = "test"
+		p.calls = 0
+		cred := h.GetCred()
+		if cred.User != "user-registry.example.org" || cred.Password != "pass" || cred.Token != "" {
+			t.Errorf("unexpected credential: %v", cred)
+		}
+		_ = h.GetCred()
+		if p.calls != 1 {
+			t.Errorf("provider called %d times, expected 1", p.calls)
+		}
+		if !h.ExpireCred() {
+			t.Errorf("ExpireCred returned false for a provider")
+		}
+		_ = h.GetCred()
+		if p.calls != 2 {
+			t.Errorf("provider called %d times after ExpireCred, expected 2", p.calls)
+		}
+	})
+	t.Run("expires", func(t *testing.T) {
+		h := HostNewName("registry.example.org")
+		h.CredProvider = "test"
+		h.CredExpire = timejson.Duration(time.Hour * 24)
+		p.calls = 0
+		p.expires = credProviderMargin + time.Minute
+		t.Cleanup(func() { p.expires = 0 })
+		_ = h.GetCred()
+		if h.credRefresh.After(time.Now().Add(time.Minute)) {
+			t.Errorf("refresh is not before the token expires: %v", h.credRefresh)
+		}
+		p.expires = time.Hour * 48
+		h.ExpireCred()
+		_ = h.GetCred()
+		if h.credRefresh.After(time.Now().Add(time.Hour * 24)) {
+			t.Errorf("refresh is after credExpire: %v", h.credRefresh)
+		}
+	})
+	t.Run("error", func(t *testing.T) {
+		h := HostNewName("registry.example.org")
+		h.CredProvider = "test"
+		h.User = "orig"
+		p.calls = 0
+		p.err = errors.New("provider failure")
+		t.Cleanup(func() { p.err = nil })
+		cred := h.GetCred()
+		if cred.User != "orig" {
+			t.Errorf("credential changed after a failure: %v", cred)
+		}
+		_ = h.GetCred()
+		if p.calls != 1 {
+			t.Errorf("provider called %d times during the retry delay, expected 1", p.calls)
+		}
+	})
+	t.Run("merge", func(t *testing.T) {
+		h := HostNewName("registry.example.org")
+		h.User = "user"
+		h.Pass = "pass"
+		err := h.Merge(Host{Name: "registry.example.org", CredProvider: CredProviderECR}, nil)
+		if err != nil {
+			t.Fatalf("failed to merge: %v", err)
+		}
+		if h.CredProvider != CredProviderECR || h.User != "" || h.Pass != "" {
+			t.Errorf("unexpected merge with provider: %v", h)
+		}
+		err = h.Merge(Host{Name: "registry.example.org", User: "user", Pass: "pass"}, nil)
+		if err != nil {
+			t.Fatalf("failed to merge: %v", err)
+		}
+		if h.CredProvider != "" || h.User != "user" {
+			t.Errorf("unexpected merge with password: %v", h)
+		}
+		if h.IsZero() {
+			t.Errorf("host is zero")
+		}
+	})
+}
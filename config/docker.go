@@ -106,6 +106,20 @@ func dockerParse(cf *conffile.File) ([]Host, error) {
 		csHosts, err := ch.list()
 		if err == nil {
 			hosts = append(hosts, csHosts...)
+		} else {
+			// some helpers do not support list, fall back to the auth entries saved without credentials
+			for name, auth := range dc.AuthConfigs {
+				if auth.Auth != "" || auth.Username != "" || auth.IdentityToken != "" || dc.CredentialHelpers[name] != "" ||
+					strings.HasSuffix(name, "/access-token") || strings.HasSuffix(name, "/refresh-token") {
+					continue
+				}
+				h := HostNewName(name)
+				if h.Name != DockerRegistry && !strings.HasSuffix(strings.TrimSuffix(name, "/"), h.Name) {
+					continue
+				}
+				h.CredHelper = ch.prog
+				hosts = append(hosts, *h)
+			}
 		}
 	}
 	return hosts, nil
@@ -135,3 +135,55 @@ func TestLoadMissing(t *testing.T) {
 		t.Errorf("hosts returned from missing file")
 	}
 }
+
+func TestDockerStoreNoList(t *testing.T) {
+	// cannot run cred helper in parallel because of OS working directory race conditions
+	pwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working dir: %v", err)
+	}
+	curPath := os.Getenv("PATH")
+	t.Setenv("PATH", filepath.Join(pwd, "testdata")+string(os.PathListSeparator)+curPath)
+	confFile := filepath.Join(t.TempDir(), "config.json")
+	err = os.WriteFile(confFile, []byte(`{
+		"auths": {
+			"nolist.example.com": {},
+			"static.example.com": {"auth": "aGVsbG86ZG9ja2Vy"},
+			"https://index.docker.io/v1/access-token": {}
+		},
+		"credsStore": "testnolist"
+	}`), 0600)
+	if err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	hosts, err := DockerLoadFile(confFile)
+	if err != nil {
+		t.Fatalf("error loading docker credentials: %v", err)
+	}
+	hostMap := map[string]*Host{}
+	for _, h := range hosts {
+		h := h
+		hostMap[h.Name] = &h
+	}
+	if len(hostMap) != 2 {
+		t.Errorf("unexpected hosts: %v", hostMap)
+	}
+	h, ok := hostMap["nolist.example.com"]
+	if !ok {
+		t.Fatalf("credential store host not found")
+	}
+	if h.CredHelper != "docker-credential-testnolist" {
+		t.Errorf("cred helper mismatch, expect docker-credential-testnolist, received %s", h.CredHelper)
+	}
+	cred := h.GetCred()
+	if cred.User != "hello" || cred.Password != "nolist" {
+		t.Errorf("unexpected credential: %s/%s", cred.User, cred.Password)
+	}
+	h, ok = hostMap["static.example.com"]
+	if !ok {
+		t.Fatalf("static host not found")
+	}
+	if h.CredHelper != "" || h.User != "hello" {
+		t.Errorf("unexpected static host: %v", h)
+	}
+}
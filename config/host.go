@@ -2,6 +2,7 @@
 package config
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -104,22 +105,30 @@ type Host struct {
 	ClientCert    string            `json:"clientCert,omitempty" yaml:"clientCert"`       // public pem cert for client (mTLS)
 	ClientKey     string            `json:"clientKey,omitempty" yaml:"clientKey"`         // private pem cert for client (mTLS)
 	Hostname      string            `json:"hostname,omitempty" yaml:"hostname"`           // hostname of registry, default is the registry name
+	Proxy         string            `json:"proxy,omitempty" yaml:"proxy"`                 // proxy url for requests to the registry, default uses the HTTP_PROXY, HTTPS_PROXY, and NO_PROXY environment variables
 	User          string            `json:"user,omitempty" yaml:"user"`                   // username, not used with credHelper
 	Pass          string            `json:"pass,omitempty" yaml:"pass"`                   // password, not used with credHelper
 	Token         string            `json:"token,omitempty" yaml:"token"`                 // token, experimental for specific APIs
 	CredHelper    string            `json:"credHelper,omitempty" yaml:"credHelper"`       // credential helper command for requesting logins
+	CredProvider  string            `json:"credProvider,omitempty" yaml:"credProvider"`   // built-in provider of cloud registry logins: ecr, gar, or acr
 	CredExpire    timejson.Duration `json:"credExpire,omitempty" yaml:"credExpire"`       // time until credential expires
 	CredHost      string            `json:"credHost,omitempty" yaml:"credHost"`           // used when a helper hostname doesn't match Hostname
 	PathPrefix    string            `json:"pathPrefix,omitempty" yaml:"pathPrefix"`       // used for mirrors defined within a repository namespace
 	Mirrors       []string          `json:"mirrors,omitempty" yaml:"mirrors"`             // list of other Host Names to use as mirrors
 	Priority      uint              `json:"priority,omitempty" yaml:"priority"`           // priority when sorting mirrors, higher priority attempted first
 	RepoAuth      bool              `json:"repoAuth,omitempty" yaml:"repoAuth"`           // tracks a separate auth per repo
+	AnonFallback  bool              `json:"anonFallback,omitempty" yaml:"anonFallback"`   // retry pull requests anonymously when credentials are rejected
 	API           string            `json:"api,omitempty" yaml:"api"`                     // Deprecated: registry API to use
 	APIOpts       map[string]string `json:"apiOpts,omitempty" yaml:"apiOpts"`             // options for APIs
+	Headers       map[string]string `json:"headers,omitempty" yaml:"headers"`             // headers added to every request to the registry
 	BlobChunk     int64             `json:"blobChunk,omitempty" yaml:"blobChunk"`         // size of each blob chunk
 	BlobMax       int64             `json:"blobMax,omitempty" yaml:"blobMax"`             // threshold to switch to chunked upload, -1 to disable, 0 for regclient.blobMaxPut
 	ReqPerSec     float64           `json:"reqPerSec,omitempty" yaml:"reqPerSec"`         // requests per second
 	ReqConcurrent int64             `json:"reqConcurrent,omitempty" yaml:"reqConcurrent"` // concurrent requests, default is defaultConcurrent(3)
+	RetryLimit    int               `json:"retryLimit,omitempty" yaml:"retryLimit"`       // number of retries for each request, 0 for the client default
+	RetryDelay    timejson.Duration `json:"retryDelay,omitempty" yaml:"retryDelay"`       // initial delay after a failure, doubled on each backoff, 0 for the client default
+	RetryDelayMax timejson.Duration `json:"retryDelayMax,omitempty" yaml:"retryDelayMax"` // maximum delay between retries, 0 for the client default
+	RetryJitter   timejson.Duration `json:"retryJitter,omitempty" yaml:"retryJitter"`     // random delay up to this value added to each backoff
 	Scheme        string            `json:"scheme,omitempty" yaml:"scheme"`               // Deprecated: use TLS instead
 	credRefresh   time.Time         `json:"-" yaml:"-"`                                   // internal use, when to refresh credentials
 }
@@ -168,6 +177,9 @@ func HostNewDefName(def *Host, name string) *Host {
 				h.APIOpts[k] = v
 			}
 		}
+		if len(h.Headers) > 0 {
+			h.Headers = copyMapString(h.Headers)
+		}
 		if h.Mirrors != nil {
 			orig := h.Mirrors
 			h.Mirrors = make([]string, len(orig))
@@ -210,15 +222,57 @@ func HostNewName(name string) *Host {
 	return HostNewDefName(nil, name)
 }
 
-// GetCred returns the credential, fetching from a credential helper if needed.
+// GetCred returns the credential, fetching from a credential provider or helper if needed.
 func (host *Host) GetCred() Cred {
-	// refresh from credHelper if needed
-	if host.CredHelper != "" && (host.credRefresh.IsZero() || time.Now().After(host.credRefresh)) {
-		host.refreshHelper()
+	// refresh from credProvider or credHelper if needed
+	if host.credRefresh.IsZero() || time.Now().After(host.credRefresh) {
+		if host.CredProvider != "" {
+			host.refreshProvider()
+		} else if host.CredHelper != "" {
+			host.refreshHelper()
+		}
 	}
 	return Cred{User: host.User, Password: host.Pass, Token: host.Token}
 }
 
+// ExpireCred forces the credential provider or helper to run on the next call to [Host.GetCred].
+// This is used when the registry rejects a credential before it expires.
+// It returns false when the host does not use a credential provider or helper.
+func (host *Host) ExpireCred() bool {
+	if host.CredProvider == "" && host.CredHelper == "" {
+		return false
+	}
+	host.credRefresh = time.Time{}
+	return true
+}
+
+func (host *Host) refreshProvider() {
+	newProvider, ok := credProviders[host.CredProvider]
+	if !ok {
+		host.credRefresh = time.Now().Add(defaultCredHelperRetry)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), credProviderTimeout)
+	defer cancel()
+	ct, err := newProvider().get(ctx, host)
+	if err != nil {
+		host.credRefresh = time.Now().Add(defaultCredHelperRetry)
+		return
+	}
+	host.User = ct.user
+	host.Pass = ct.pass
+	host.Token = ""
+	// refresh before the token expires, or after credExpire when that is sooner
+	refresh := time.Now().Add(defaultExpire)
+	if host.CredExpire > 0 {
+		refresh = time.Now().Add(time.Duration(host.CredExpire))
+	}
+	if !ct.expires.IsZero() && ct.expires.Add(-credProviderMargin).Before(refresh) {
+		refresh = ct.expires.Add(-credProviderMargin)
+	}
+	host.credRefresh = refresh
+}
+
 func (host *Host) refreshHelper() {
 	if host.CredHelper == "" {
 		return
@@ -244,21 +298,29 @@ func (host Host) IsZero() bool {
 		host.ClientCert != "" ||
 		host.ClientKey != "" ||
 		host.Hostname != "" ||
+		host.Proxy != "" ||
 		host.User != "" ||
 		host.Pass != "" ||
 		host.Token != "" ||
 		host.CredHelper != "" ||
+		host.CredProvider != "" ||
 		host.CredExpire != 0 ||
 		host.CredHost != "" ||
 		host.PathPrefix != "" ||
 		len(host.Mirrors) != 0 ||
 		host.Priority != 0 ||
 		host.RepoAuth ||
+		host.AnonFallback ||
 		len(host.APIOpts) != 0 ||
+		len(host.Headers) != 0 ||
 		host.BlobChunk != 0 ||
 		host.BlobMax != 0 ||
 		(host.ReqPerSec != 0 && host.ReqPerSec != float64(defaultReqPerSec)) ||
 		(host.ReqConcurrent != 0 && host.ReqConcurrent != int64(defaultConcurrent)) ||
+		host.RetryLimit != 0 ||
+		host.RetryDelay != 0 ||
+		host.RetryDelayMax != 0 ||
+		host.RetryJitter != 0 ||
 		!host.credRefresh.IsZero() {
 		return false
 	}
@@ -281,13 +343,14 @@ func (host *Host) Merge(newHost Host, log *slog.Logger) error {
 		host.Name = newHost.Name
 	}
 
-	if newHost.CredHelper == "" && (newHost.Pass != "" || host.Token != "") {
-		// unset existing cred helper for user/pass or token
+	if newHost.CredHelper == "" && newHost.CredProvider == "" && (newHost.Pass != "" || host.Token != "") {
+		// unset existing cred helper and provider for user/pass or token
 		host.CredHelper = ""
+		host.CredProvider = ""
 		host.CredExpire = 0
 	}
-	if newHost.CredHelper != "" && newHost.User == "" && newHost.Pass == "" && newHost.Token == "" {
-		// unset existing user/pass/token for cred helper
+	if (newHost.CredHelper != "" || newHost.CredProvider != "") && newHost.User == "" && newHost.Pass == "" && newHost.Token == "" {
+		// unset existing user/pass/token for cred helper or provider
 		host.User = ""
 		host.Pass = ""
 		host.Token = ""
@@ -329,6 +392,21 @@ func (host *Host) Merge(newHost Host, log *slog.Logger) error {
 		host.CredHelper = newHost.CredHelper
 	}
 
+	if newHost.CredProvider != "" {
+		if _, ok := credProviders[newHost.CredProvider]; !ok {
+			log.Warn("Unknown credential provider for registry",
+				slog.String("host", name),
+				slog.String("credProvider", newHost.CredProvider))
+		}
+		if host.CredProvider != "" && host.CredProvider != newHost.CredProvider {
+			log.Warn("Changing credential provider for registry",
+				slog.String("host", name),
+				slog.String("orig", host.CredProvider),
+				slog.String("new", newHost.CredProvider))
+		}
+		host.CredProvider = newHost.CredProvider
+	}
+
 	if newHost.CredExpire != 0 {
 		if host.CredExpire != 0 && host.CredExpire != newHost.CredExpire {
 			log.Warn("Changing credential expire for registry",
@@ -399,6 +477,14 @@ func (host *Host) Merge(newHost Host, log *slog.Logger) error {
 		host.Hostname = newHost.Hostname
 	}
 
+	if newHost.Proxy != "" {
+		if host.Proxy != "" && host.Proxy != newHost.Proxy {
+			log.Warn("Changing proxy settings for registry",
+				slog.String("host", name))
+		}
+		host.Proxy = newHost.Proxy
+	}
+
 	if newHost.PathPrefix != "" {
 		newHost.PathPrefix = strings.Trim(newHost.PathPrefix, "/") // leading and trailing / are not needed
 		if host.PathPrefix != "" && host.PathPrefix != newHost.PathPrefix {
@@ -434,6 +520,10 @@ func (host *Host) Merge(newHost Host, log *slog.Logger) error {
 		host.RepoAuth = newHost.RepoAuth
 	}
 
+	if newHost.AnonFallback {
+		host.AnonFallback = newHost.AnonFallback
+	}
+
 	// TODO: eventually delete
 	if newHost.API != "" {
 		log.Warn("API field has been deprecated",
@@ -460,6 +550,20 @@ func (host *Host) Merge(newHost Host, log *slog.Logger) error {
 		}
 	}
 
+	if len(newHost.Headers) > 0 {
+		merged := copyMapString(host.Headers)
+		for k, v := range newHost.Headers {
+			if host.Headers[k] != "" && host.Headers[k] != v {
+				// header values are not logged since they may contain secrets
+				log.Warn("Changing header setting for registry",
+					slog.String("header", k),
+					slog.String("host", name))
+			}
+			merged[k] = v
+		}
+		host.Headers = merged
+	}
+
 	if newHost.BlobChunk > 0 {
 		if host.BlobChunk != 0 && host.BlobChunk != newHost.BlobChunk {
 			log.Warn("Changing blobChunk settings for registry",
@@ -500,6 +604,46 @@ func (host *Host) Merge(newHost Host, log *slog.Logger) error {
 		host.ReqConcurrent = newHost.ReqConcurrent
 	}
 
+	if newHost.RetryLimit > 0 {
+		if host.RetryLimit != 0 && host.RetryLimit != newHost.RetryLimit {
+			log.Warn("Changing retryLimit settings for registry",
+				slog.Int("orig", host.RetryLimit),
+				slog.Int("new", newHost.RetryLimit),
+				slog.String("host", name))
+		}
+		host.RetryLimit = newHost.RetryLimit
+	}
+
+	if newHost.RetryDelay > 0 {
+		if host.RetryDelay != 0 && host.RetryDelay != newHost.RetryDelay {
+			log.Warn("Changing retryDelay settings for registry",
+				slog.Duration("orig", time.Duration(host.RetryDelay)),
+				slog.Duration("new", time.Duration(newHost.RetryDelay)),
+				slog.String("host", name))
+		}
+		host.RetryDelay = newHost.RetryDelay
+	}
+
+	if newHost.RetryDelayMax > 0 {
+		if host.RetryDelayMax != 0 && host.RetryDelayMax != newHost.RetryDelayMax {
+			log.Warn("Changing retryDelayMax settings for registry",
+				slog.Duration("orig", time.Duration(host.RetryDelayMax)),
+				slog.Duration("new", time.Duration(newHost.RetryDelayMax)),
+				slog.String("host", name))
+		}
+		host.RetryDelayMax = newHost.RetryDelayMax
+	}
+
+	if newHost.RetryJitter > 0 {
+		if host.RetryJitter != 0 && host.RetryJitter != newHost.RetryJitter {
+			log.Warn("Changing retryJitter settings for registry",
+				slog.Duration("orig", time.Duration(host.RetryJitter)),
+				slog.Duration("new", time.Duration(newHost.RetryJitter)),
+				slog.String("host", name))
+		}
+		host.RetryJitter = newHost.RetryJitter
+	}
+
 	return nil
 }
 
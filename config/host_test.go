@@ -126,6 +126,7 @@ func TestConfig(t *testing.T) {
 	{
 	  "tls": "disabled",
 		"hostname": "host2.example.com",
+		"proxy": "http://proxy.example.com:3128",
 		"user": "user-ex3",
 		"pass": "secret3",
 		"regcert": "` + strings.ReplaceAll(caCert, "\n", "\\n") + `",
@@ -135,8 +136,13 @@ func TestConfig(t *testing.T) {
 		"mirrors": ["testhost.example.com"],
 		"priority": 42,
 		"apiOpts": {"disableHead": "false", "unknownOpt": "3"},
+		"headers": {"X-Api-Key": "key3"},
 		"blobChunk": 333333,
-		"blobMax": 333333
+		"blobMax": 333333,
+		"retryLimit": 7,
+		"retryDelay": "2s",
+		"retryDelayMax": "1m",
+		"retryJitter": "500ms"
 	}
 	`
 	exJSONCredHelper := `
@@ -282,19 +288,25 @@ func TestConfig(t *testing.T) {
 			name: "exHost2",
 			host: exHost2,
 			hostExpect: Host{
-				TLS:        TLSDisabled,
-				Hostname:   "host2.example.com",
-				User:       "user-ex3",
-				Pass:       "secret3",
-				RegCert:    caCert,
-				ClientCert: clientCert,
-				ClientKey:  clientKey,
-				PathPrefix: "hub3",
-				Mirrors:    []string{"testhost.example.com"},
-				Priority:   42,
-				APIOpts:    map[string]string{"disableHead": "false", "unknownOpt": "3"},
-				BlobChunk:  333333,
-				BlobMax:    333333,
+				TLS:           TLSDisabled,
+				Hostname:      "host2.example.com",
+				Proxy:         "http://proxy.example.com:3128",
+				User:          "user-ex3",
+				Pass:          "secret3",
+				RegCert:       caCert,
+				ClientCert:    clientCert,
+				ClientKey:     clientKey,
+				PathPrefix:    "hub3",
+				Mirrors:       []string{"testhost.example.com"},
+				Priority:      42,
+				APIOpts:       map[string]string{"disableHead": "false", "unknownOpt": "3"},
+				Headers:       map[string]string{"X-Api-Key": "key3"},
+				BlobChunk:     333333,
+				BlobMax:       333333,
+				RetryLimit:    7,
+				RetryDelay:    timejson.Duration(2 * time.Second),
+				RetryDelayMax: timejson.Duration(time.Minute),
+				RetryJitter:   timejson.Duration(500 * time.Millisecond),
 			},
 			credExpect: Cred{
 				User:     "user-ex3",
@@ -340,19 +352,25 @@ func TestConfig(t *testing.T) {
 			name: "mergeHost2",
 			host: exMergeHost2,
 			hostExpect: Host{
-				TLS:        TLSDisabled,
-				Hostname:   "host2.example.com",
-				User:       "user-ex3",
-				Pass:       "secret3",
-				RegCert:    caCert,
-				ClientCert: clientCert,
-				ClientKey:  clientKey,
-				PathPrefix: "hub3",
-				Mirrors:    []string{"testhost.example.com"},
-				Priority:   42,
-				APIOpts:    map[string]string{"disableHead": "false", "unknownOpt": "3"},
-				BlobChunk:  333333,
-				BlobMax:    333333,
+				TLS:           TLSDisabled,
+				Hostname:      "host2.example.com",
+				Proxy:         "http://proxy.example.com:3128",
+				User:          "user-ex3",
+				Pass:          "secret3",
+				RegCert:       caCert,
+				ClientCert:    clientCert,
+				ClientKey:     clientKey,
+				PathPrefix:    "hub3",
+				Mirrors:       []string{"testhost.example.com"},
+				Priority:      42,
+				APIOpts:       map[string]string{"disableHead": "false", "unknownOpt": "3"},
+				Headers:       map[string]string{"X-Api-Key": "key3"},
+				BlobChunk:     333333,
+				BlobMax:       333333,
+				RetryLimit:    7,
+				RetryDelay:    timejson.Duration(2 * time.Second),
+				RetryDelayMax: timejson.Duration(time.Minute),
+				RetryJitter:   timejson.Duration(500 * time.Millisecond),
 			},
 			credExpect: Cred{
 				User:     "user-ex3",
@@ -439,6 +457,9 @@ func TestConfig(t *testing.T) {
 			if tc.host.Hostname != tc.hostExpect.Hostname {
 				t.Errorf("hostname field mismatch, expected %s, found %s", tc.hostExpect.Hostname, tc.host.Hostname)
 			}
+			if tc.host.Proxy != tc.hostExpect.Proxy {
+				t.Errorf("proxy field mismatch, expected %s, found %s", tc.hostExpect.Proxy, tc.host.Proxy)
+			}
 			if tc.host.User != tc.hostExpect.User {
 				t.Errorf("user field mismatch, expected %s, found %s", tc.hostExpect.User, tc.host.User)
 			}
@@ -466,6 +487,18 @@ func TestConfig(t *testing.T) {
 			if tc.host.BlobMax != tc.hostExpect.BlobMax {
 				t.Errorf("blobMax field mismatch, expected %d, found %d", tc.hostExpect.BlobMax, tc.host.BlobMax)
 			}
+			if tc.host.RetryLimit != tc.hostExpect.RetryLimit {
+				t.Errorf("retryLimit field mismatch, expected %d, found %d", tc.hostExpect.RetryLimit, tc.host.RetryLimit)
+			}
+			if tc.host.RetryDelay != tc.hostExpect.RetryDelay {
+				t.Errorf("retryDelay field mismatch, expected %s, found %s", time.Duration(tc.hostExpect.RetryDelay).String(), time.Duration(tc.host.RetryDelay).String())
+			}
+			if tc.host.RetryDelayMax != tc.hostExpect.RetryDelayMax {
+				t.Errorf("retryDelayMax field mismatch, expected %s, found %s", time.Duration(tc.hostExpect.RetryDelayMax).String(), time.Duration(tc.host.RetryDelayMax).String())
+			}
+			if tc.host.RetryJitter != tc.hostExpect.RetryJitter {
+				t.Errorf("retryJitter field mismatch, expected %s, found %s", time.Duration(tc.hostExpect.RetryJitter).String(), time.Duration(tc.host.RetryJitter).String())
+			}
 			if len(tc.host.Mirrors) != len(tc.hostExpect.Mirrors) {
 				t.Errorf("mirrors length mismatch, expected %v, found %v", tc.hostExpect.Mirrors, tc.host.Mirrors)
 			} else {
@@ -484,6 +517,15 @@ func TestConfig(t *testing.T) {
 					}
 				}
 			}
+			if len(tc.host.Headers) != len(tc.hostExpect.Headers) {
+				t.Errorf("headers length mismatch, expected %v, found %v", tc.hostExpect.Headers, tc.host.Headers)
+			} else {
+				for k := range tc.host.Headers {
+					if tc.host.Headers[k] != tc.hostExpect.Headers[k] {
+						t.Errorf("headers field %s mismatch, expected %s, found %s", k, tc.hostExpect.Headers[k], tc.host.Headers[k])
+					}
+				}
+			}
 			cred := tc.host.GetCred()
 			if tc.credExpect.User != cred.User {
 				t.Errorf("cred user field mismatch, expected %s, found %s", tc.credExpect.User, cred.User)
@@ -0,0 +1,80 @@
+package regclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/regclient/regclient/internal/dockerd"
+	"github.com/regclient/regclient/types/errs"
+	"github.com/regclient/regclient/types/ref"
+)
+
+// daemonScheme is the ref scheme for images in a local Docker engine.
+const daemonScheme = "docker-daemon"
+
+// imageCopyDaemon copies an image to or from a Docker engine using the save and load APIs.
+// The engine only stores a single platform, so multi-platform sources default to the local platform.
+func (rc *RegClient) imageCopyDaemon(ctx context.Context, refSrc ref.Ref, refTgt ref.Ref, opt *imageOpt) error {
+	if refSrc.Scheme == daemonScheme && refTgt.Scheme == daemonScheme {
+		return fmt.Errorf("copy between docker-daemon references is not supported%.0w", errs.ErrUnsupported)
+	}
+	dc, err := dockerd.New(rc.dockerHost)
+	if err != nil {
+		return err
+	}
+	if refTgt.Scheme == daemonScheme {
+		if refTgt.Tag == "" {
+			return fmt.Errorf("docker-daemon target requires a tag: %s%.0w", refTgt.CommonName(), errs.ErrInvalidReference)
+		}
+		p := opt.platform
+		if p == "" {
+			p = "local"
+		}
+		rc.slog.Info("Loading image into docker",
+			slog.String("source", refSrc.CommonName()),
+			slog.String("target", refTgt.CommonName()),
+			slog.String("platform", p))
+		pr, pw := io.Pipe()
+		go func() {
+			err := rc.ImageExport(ctx, refSrc, pw, ImageWithExportRef(refTgt.ToReg()), ImageWithPlatform(p))
+			pw.CloseWithError(err)
+		}()
+		err = dc.ImageLoad(ctx, pr)
+		_ = pr.CloseWithError(err)
+		return err
+	}
+
+	// save the image to a temp file since the import needs to seek
+	rSave := refSrc.ToReg()
+	if rSave.Digest != "" {
+		rSave.Tag = ""
+	}
+	rc.slog.Info("Saving image from docker",
+		slog.String("source", refSrc.CommonName()),
+		slog.String("target", refTgt.CommonName()))
+	rdr, err := dc.ImageSave(ctx, rSave.CommonName())
+	if err != nil {
+		return err
+	}
+	defer rdr.Close()
+	fh, err := os.CreateTemp("", "regclient-docker-*.tar")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = fh.Close()
+		_ = os.Remove(fh.Name())
+	}()
+	_, err = io.Copy(fh, rdr)
+	if err != nil {
+		return fmt.Errorf("failed to save image %s: %w", refSrc.CommonName(), err)
+	}
+	_, err = fh.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	return rc.ImageImport(ctx, refTgt, fh)
+}
@@ -0,0 +1,114 @@
+package regclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/regclient/regclient/internal/copyfs"
+	"github.com/regclient/regclient/types/errs"
+	"github.com/regclient/regclient/types/platform"
+	"github.com/regclient/regclient/types/ref"
+)
+
+func TestImageCopyDaemon(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	// fake engine that returns the last loaded tar from a save
+	var mu sync.Mutex
+	images := map[string][]byte{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/images/load", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		images["docker.io/library/loaded:v1"] = body
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"stream":"Loaded image: loaded:v1\n"}`))
+	})
+	mux.HandleFunc("/images/get", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		body, ok := images[r.URL.Query().Get("names")]
+		mu.Unlock()
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"reference does not exist"}`))
+			return
+		}
+		_, _ = w.Write(body)
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	rc := New(WithDockerHost("tcp://" + ts.Listener.Addr().String()))
+	tempDir := t.TempDir()
+	err := copyfs.Copy(tempDir+"/testrepo", "./testdata/testrepo")
+	if err != nil {
+		t.Fatalf("failed to copy testrepo to tempDir: %v", err)
+	}
+	rSrc, err := ref.New("ocidir://" + tempDir + "/testrepo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	rDaemon, err := ref.New("docker-daemon://loaded:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	rMissing, err := ref.New("docker-daemon://missing:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	rOut, err := ref.New("ocidir://" + tempDir + "/out:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+
+	t.Run("load", func(t *testing.T) {
+		err := rc.ImageCopy(ctx, rSrc, rDaemon, ImageWithPlatform("linux/amd64"))
+		if err != nil {
+			t.Fatalf("failed to copy to daemon: %v", err)
+		}
+	})
+	t.Run("save", func(t *testing.T) {
+		err := rc.ImageCopy(ctx, rDaemon, rOut)
+		if err != nil {
+			t.Fatalf("failed to copy from daemon: %v", err)
+		}
+		m, err := rc.ManifestGet(ctx, rOut)
+		if err != nil {
+			t.Fatalf("failed to get manifest: %v", err)
+		}
+		if m.IsList() {
+			t.Errorf("manifest list copied from daemon")
+		}
+		p, err := platform.Parse("linux/amd64")
+		if err != nil {
+			t.Fatalf("failed to parse platform: %v", err)
+		}
+		mSrc, err := rc.ManifestGet(ctx, rSrc, WithManifestPlatform(p))
+		if err != nil {
+			t.Fatalf("failed to get source manifest: %v", err)
+		}
+		if m.GetDescriptor().Digest != mSrc.GetDescriptor().Digest {
+			t.Errorf("digest mismatch, expected %s, received %s", mSrc.GetDescriptor().Digest, m.GetDescriptor().Digest)
+		}
+	})
+	t.Run("missing", func(t *testing.T) {
+		err := rc.ImageCopy(ctx, rMissing, rOut)
+		if !errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+	t.Run("daemon to daemon", func(t *testing.T) {
+		err := rc.ImageCopy(ctx, rDaemon, rMissing)
+		if !errors.Is(err, errs.ErrUnsupported) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
@@ -58,6 +58,7 @@ type tarFileHandler func(header *tar.Header, trd *tarReadData) error
 type tarReadData struct {
 	tr          *tar.Reader
 	name        string
+	referrers   bool
 	handleAdded bool
 	handlers    map[string]tarFileHandler
 	links       map[string][]string
@@ -81,6 +82,8 @@ type tarWriteData struct {
 }
 
 type imageOpt struct {
+	blobConcurrent  int
+	blobSem         chan struct{}
 	callback        func(kind types.CallbackKind, instance string, state types.CallbackState, cur, total int64)
 	checkBaseDigest string
 	checkBaseRef    string
@@ -101,6 +104,8 @@ type imageOpt struct {
 	tagList         []string
 	mu              sync.Mutex
 	seen            map[string]*imageSeen
+	sharedBlobs     int
+	sharedBytes     int64
 	finalFn         []func(context.Context) error
 }
 
@@ -112,7 +117,19 @@ type imageSeen struct {
 // ImageOpts define options for the Image* commands.
 type ImageOpts func(*imageOpt)
 
-// ImageWithCallback provides progress data to a callback function.
+// ImageWithBlobConcurrency limits the number of blobs copied at the same time in ImageCopy.
+// Blobs are otherwise copied in parallel, bounded only by the per-host request limits.
+func ImageWithBlobConcurrency(n int) ImageOpts {
+	return func(opts *imageOpt) {
+		opts.blobConcurrent = n
+	}
+}
+
+// ImageWithCallback provides progress data to a callback function in ImageCopy.
+// The instance is the digest of the manifest or blob.
+// Each instance is reported as started, followed by active updates for blob transfers,
+// and ends as finished when the content was pushed or skipped when it already existed on the target.
+// The callback may be run concurrently, and should return quickly, e.g. by sending to a buffered channel.
 func ImageWithCallback(callback func(kind types.CallbackKind, instance string, state types.CallbackState, cur, total int64)) ImageOpts {
 	return func(opts *imageOpt) {
 		opts.callback = callback
@@ -197,7 +214,7 @@ func ImageWithDigestTags() ImageOpts {
 	}
 }
 
-// ImageWithPlatform requests specific platforms from a manifest list in ImageCheckBase.
+// ImageWithPlatform requests specific platforms from a manifest list in ImageCheckBase, ImageConfig, and ImageExport.
 func ImageWithPlatform(p string) ImageOpts {
 	return func(opts *imageOpt) {
 		opts.platform = p
@@ -213,7 +230,7 @@ func ImageWithPlatforms(p []string) ImageOpts {
 	}
 }
 
-// ImageWithReferrers recursively recursively includes referrer images in ImageCopy.
+// ImageWithReferrers recursively includes referrer images in ImageCopy, ImageExport, and ImageImport.
 func ImageWithReferrers(rOpts ...scheme.ReferrerOpts) ImageOpts {
 	return func(opts *imageOpt) {
 		if opts.referrerConfs == nil {
@@ -344,6 +361,10 @@ func (rc *RegClient) ImageCheckBase(ctx context.Context, r ref.Ref, opts ...Imag
 		}
 		rp := r
 		for _, d := range dl {
+			// skip attestations and other entries without a platform
+			if d.Platform == nil || d.Platform.OS == "unknown" {
+				continue
+			}
 			rp.Digest = d.Digest.String()
 			optP := append(opts, ImageWithPlatform(d.Platform.String()))
 			err = rc.ImageCheckBase(ctx, rp, optP...)
@@ -509,7 +530,12 @@ func (rc *RegClient) ImageConfig(ctx context.Context, r ref.Ref, opts ...ImageOp
 // This will retag an image in the same repository, only pushing and pulling the top level manifest.
 // On the same registry, it will attempt to use cross-repository blob mounts to avoid pulling blobs.
 // Blobs are only pulled when they don't exist on the target and a blob mount fails.
+// Manifests and blobs that already exist on the target are skipped, so rerunning an interrupted copy resumes it.
+// Blobs shared between platforms of an index are only copied once, and the savings are reported
+// to the callback with [types.CallbackShared].
 // Referrers are optionally copied recursively.
+// A "docker-daemon://" source or target is copied through the local Docker engine, see [WithDockerHost].
+// Only a single platform is copied to the engine, set with [ImageWithPlatform] and defaulting to the local platform.
 func (rc *RegClient) ImageCopy(ctx context.Context, refSrc ref.Ref, refTgt ref.Ref, opts ...ImageOpts) error {
 	opt := imageOpt{
 		seen:    map[string]*imageSeen{},
@@ -518,10 +544,16 @@ func (rc *RegClient) ImageCopy(ctx context.Context, refSrc ref.Ref, refTgt ref.R
 	for _, optFn := range opts {
 		optFn(&opt)
 	}
+	if opt.blobConcurrent > 0 {
+		opt.blobSem = make(chan struct{}, opt.blobConcurrent)
+	}
 	// dedup warnings
 	if w := warning.FromContext(ctx); w == nil {
 		ctx = warning.NewContext(ctx, &warning.Warning{Hook: warning.DefaultHook()})
 	}
+	if refSrc.Scheme == daemonScheme || refTgt.Scheme == daemonScheme {
+		return rc.imageCopyDaemon(ctx, refSrc, refTgt, &opt)
+	}
 	// block GC from running (in OCIDir) during the copy
 	schemeTgtAPI, err := rc.schemeGet(refTgt.Scheme)
 	if err != nil {
@@ -543,6 +575,12 @@ func (rc *RegClient) ImageCopy(ctx context.Context, refSrc ref.Ref, refTgt ref.R
 			return err
 		}
 	}
+	if opt.sharedBlobs > 0 {
+		rc.slog.Info("Shared blobs copied once",
+			slog.String("target", refTgt.CommonName()),
+			slog.Int("blobs", opt.sharedBlobs),
+			slog.Int64("bytes", opt.sharedBytes))
+	}
 	return nil
 }
 
@@ -588,7 +626,7 @@ func (rc *RegClient) imageCopyOpt(ctx context.Context, refSrc ref.Ref, refTgt re
 		}
 		if sDig == mTgt.GetDescriptor().Digest {
 			if opt.callback != nil {
-				opt.callback(types.CallbackManifest, d.Digest.String(), types.CallbackSkipped, mTgt.GetDescriptor().Size, mTgt.GetDescriptor().Size)
+				opt.callback(types.CallbackManifest, sDig.String(), types.CallbackSkipped, mTgt.GetDescriptor().Size, mTgt.GetDescriptor().Size)
 			}
 			return nil
 		}
@@ -633,8 +671,15 @@ func (rc *RegClient) imageCopyOpt(ctx context.Context, refSrc ref.Ref, refTgt re
 	parentsNew := make([]digest.Digest, len(parents)+1)
 	copy(parentsNew, parents)
 	parentsNew[len(parentsNew)-1] = sDig
+	// the top level manifest is only known after the source is resolved
+	mSize := d.Size
+	if mSize == 0 && mSrc != nil {
+		mSize = mSrc.GetDescriptor().Size
+	} else if mSize == 0 && mTgt != nil {
+		mSize = mTgt.GetDescriptor().Size
+	}
 	if opt.callback != nil {
-		opt.callback(types.CallbackManifest, d.Digest.String(), types.CallbackStarted, 0, d.Size)
+		opt.callback(types.CallbackManifest, sDig.String(), types.CallbackStarted, 0, mSize)
 	}
 	// process entries in an index
 	if mSrcIndex, ok := mSrc.(manifest.Indexer); ok && mSrc.IsSet() && !ref.EqualRepository(refSrc, refTgt) {
@@ -958,11 +1003,11 @@ func (rc *RegClient) imageCopyOpt(ctx context.Context, refSrc ref.Ref, refTgt re
 			return err
 		}
 		if opt.callback != nil {
-			opt.callback(types.CallbackManifest, d.Digest.String(), types.CallbackFinished, d.Size, d.Size)
+			opt.callback(types.CallbackManifest, sDig.String(), types.CallbackFinished, mSize, mSize)
 		}
 	} else {
 		if opt.callback != nil {
-			opt.callback(types.CallbackManifest, d.Digest.String(), types.CallbackSkipped, d.Size, d.Size)
+			opt.callback(types.CallbackManifest, sDig.String(), types.CallbackSkipped, mSize, mSize)
 		}
 	}
 	if seenCB != nil {
@@ -976,8 +1021,28 @@ func (rc *RegClient) imageCopyOpt(ctx context.Context, refSrc ref.Ref, refTgt re
 func (rc *RegClient) imageCopyBlob(ctx context.Context, refSrc ref.Ref, refTgt ref.Ref, d descriptor.Descriptor, opt *imageOpt, bOpt ...BlobOpts) error {
 	seenCB, err := imageSeenOrWait(ctx, opt, refTgt.SetTag("").CommonName(), "", d.Digest, []digest.Digest{})
 	if seenCB == nil {
+		if err == nil {
+			// the blob was copied for another manifest
+			opt.mu.Lock()
+			opt.sharedBlobs++
+			opt.sharedBytes += d.Size
+			opt.mu.Unlock()
+			if opt.callback != nil {
+				opt.callback(types.CallbackBlob, d.Digest.String(), types.CallbackShared, d.Size, d.Size)
+			}
+		}
 		return err
 	}
+	// only a blob copy holds a slot, manifests may wait on their children without blocking them
+	if opt.blobSem != nil {
+		select {
+		case opt.blobSem <- struct{}{}:
+		case <-ctx.Done():
+			seenCB(ctx.Err())
+			return ctx.Err()
+		}
+		defer func() { <-opt.blobSem }()
+	}
 	err = rc.BlobCopy(ctx, refSrc, refTgt, d, bOpt...)
 	seenCB(err)
 	return err
@@ -1034,8 +1099,10 @@ func imageSeenOrWait(ctx context.Context, opt *imageOpt, repo, tag string, dig d
 
 // ImageExport exports an image to an output stream.
 // The format is compatible with "docker load" if a single image is selected and not a manifest list.
+// Use [ImageWithPlatform] to export a single platform from a manifest list, keeping the tag from the ref.
 // The ref must include a tag for exporting to docker (defaults to latest), and may also include a digest.
 // The export is also formatted according to [OCI Layout] which supports multi-platform images.
+// With [ImageWithReferrers], referrers are included as untagged entries in the index.json.
 // A tar file will be sent to outStream.
 //
 // Resulting filesystem:
@@ -1087,6 +1154,21 @@ func (rc *RegClient) ImageExport(ctx context.Context, r ref.Ref, outStream io.Wr
 			slog.String("err", err.Error()))
 		return err
 	}
+	// select a single platform from an index, keeping the tag for the exported name
+	if m.IsList() && opt.platform != "" {
+		p, err := platform.Parse(opt.platform)
+		if err != nil {
+			return fmt.Errorf("failed to parse platform %s: %w", opt.platform, err)
+		}
+		d, err := manifest.GetPlatformDesc(m, &p)
+		if err != nil {
+			return fmt.Errorf("failed to find platform %s in %s: %w", opt.platform, r.CommonName(), err)
+		}
+		m, err = rc.ManifestGet(ctx, r, WithManifestDesc(*d))
+		if err != nil {
+			return err
+		}
+	}
 
 	// build/write oci-layout
 	ociLayout := v1.ImageLayout{Version: ociLayoutVersion}
@@ -1103,14 +1185,6 @@ func (rc *RegClient) ImageExport(ctx context.Context, r ref.Ref, outStream io.Wr
 	mDesc.Annotations[annotationImageName] = opt.exportRef.CommonName()
 	mDesc.Annotations[annotationRefName] = opt.exportRef.Tag
 
-	// generate/write an OCI index
-	ociIndex.Versioned = v1.IndexSchemaVersion
-	ociIndex.Manifests = []descriptor.Descriptor{mDesc} // initialize with the descriptor to the manifest list
-	err = twd.tarWriteFileJSON(ociIndexFilename, ociIndex)
-	if err != nil {
-		return err
-	}
-
 	// append to docker manifest with tag, config filename, each layer filename, and layer descriptors
 	if mi, ok := m.(manifest.Imager); ok {
 		conf, err := mi.GetConfig()
@@ -1158,9 +1232,86 @@ func (rc *RegClient) ImageExport(ctx context.Context, r ref.Ref, outStream io.Wr
 		return err
 	}
 
+	// generate/write an OCI index, referrers are included as untagged entries
+	ociIndex.Versioned = v1.IndexSchemaVersion
+	ociIndex.Manifests = []descriptor.Descriptor{mDesc} // initialize with the descriptor to the manifest list
+	if opt.referrerConfs != nil {
+		rl, err := rc.imageExportReferrers(ctx, r, mDesc, twd, &opt)
+		if err != nil {
+			return err
+		}
+		ociIndex.Manifests = append(ociIndex.Manifests, rl...)
+	}
+	err = twd.tarWriteFileJSON(ociIndexFilename, ociIndex)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// imageExportReferrers adds the referrers of a manifest and any child manifests to the tar, returning the referrer descriptors.
+func (rc *RegClient) imageExportReferrers(ctx context.Context, r ref.Ref, desc descriptor.Descriptor, twd *tarWriteData, opt *imageOpt) ([]descriptor.Descriptor, error) {
+	descList := []descriptor.Descriptor{}
+	if desc.MediaType == mediatype.Docker2ManifestList || desc.MediaType == mediatype.OCI1ManifestList {
+		m, err := rc.ManifestGet(ctx, r, WithManifestDesc(desc))
+		if err != nil {
+			return nil, err
+		}
+		mi, ok := m.(manifest.Indexer)
+		if !ok {
+			return nil, fmt.Errorf("manifest doesn't support index methods%.0w", errs.ErrUnsupportedMediaType)
+		}
+		mdl, err := mi.GetManifestList()
+		if err != nil {
+			return nil, err
+		}
+		for _, md := range mdl {
+			rl, err := rc.imageExportReferrers(ctx, r, md, twd, opt)
+			if err != nil {
+				return nil, err
+			}
+			descList = append(descList, rl...)
+		}
+	}
+	referrerOpts := []scheme.ReferrerOpts{}
+	if opt.referrerSrc.IsSet() {
+		referrerOpts = append(referrerOpts, scheme.WithReferrerSource(opt.referrerSrc))
+	}
+	rl, err := rc.ReferrerList(ctx, r.SetDigest(desc.Digest.String()), referrerOpts...)
+	if err != nil {
+		return nil, err
+	}
+	rlDesc := rl.Descriptors
+	if len(opt.referrerConfs) > 0 {
+		rlDesc = []descriptor.Descriptor{}
+		for _, rConf := range opt.referrerConfs {
+			rlDesc = append(rlDesc, scheme.ReferrerFilter(rConf, rl).Descriptors...)
+		}
+	}
+	rSrc := r
+	if rl.Source.IsSet() {
+		rSrc = rl.Source
+	}
+	for _, rDesc := range rlDesc {
+		if twd.files[tarOCILayoutDescPath(rDesc)] {
+			continue // skip referrers already included
+		}
+		err = rc.imageExportDescriptor(ctx, rSrc, rDesc, twd)
+		if err != nil {
+			return nil, err
+		}
+		descList = append(descList, rDesc)
+		// include referrers of referrers, e.g. signatures on an SBOM
+		nested, err := rc.imageExportReferrers(ctx, rSrc, rDesc, twd, opt)
+		if err != nil {
+			return nil, err
+		}
+		descList = append(descList, nested...)
+	}
+	return descList, nil
+}
+
 // imageExportDescriptor pulls a manifest or blob, outputs to a tar file, and recursively processes any nested manifests or blobs
 func (rc *RegClient) imageExportDescriptor(ctx context.Context, r ref.Ref, desc descriptor.Descriptor, twd *tarWriteData) error {
 	if err := desc.Digest.Validate(); err != nil {
@@ -1286,6 +1437,7 @@ func (rc *RegClient) imageExportDescriptor(ctx context.Context, r ref.Ref, desc
 }
 
 // ImageImport pushes an image from a tar file (ImageExport) to a registry.
+// With [ImageWithReferrers], untagged entries in the index.json of an OCI Layout are also pushed, restoring referrers included by ImageExport.
 func (rc *RegClient) ImageImport(ctx context.Context, r ref.Ref, rs io.ReadSeeker, opts ...ImageOpts) error {
 	if !r.IsSetRepo() {
 		return fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), errs.ErrInvalidReference)
@@ -1301,6 +1453,7 @@ func (rc *RegClient) ImageImport(ctx context.Context, r ref.Ref, rs io.ReadSeeke
 	}
 	trd := &tarReadData{
 		name:      opt.importName,
+		referrers: opt.referrerConfs != nil,
 		handlers:  map[string]tarFileHandler{},
 		links:     map[string][]string{},
 		processed: map[string]bool{},
@@ -1599,6 +1752,18 @@ func (rc *RegClient) imageImportOCIHandleManifest(ctx context.Context, r ref.Ref
 					break
 				}
 			}
+			// referrers are exported without a tag, select the only tagged entry
+			if d.Digest.String() == "" {
+				tagged := []descriptor.Descriptor{}
+				for _, cur := range dl {
+					if cur.Annotations[annotationRefName] != "" {
+						tagged = append(tagged, cur)
+					}
+				}
+				if len(tagged) == 1 {
+					d = tagged[0]
+				}
+			}
 			if d.Digest.String() == "" {
 				return fmt.Errorf("could not find requested tag in index.json, %s", r.Tag)
 			}
@@ -1607,6 +1772,17 @@ func (rc *RegClient) imageImportOCIHandleManifest(ctx context.Context, r ref.Ref
 		if err != nil {
 			return err
 		}
+		if trd.referrers {
+			for _, cur := range dl {
+				if cur.Digest == d.Digest || cur.Annotations[annotationRefName] != "" {
+					continue
+				}
+				err = handleManifest(cur, false)
+				if err != nil {
+					return err
+				}
+			}
+		}
 		// add a finish step to tag the selected digest
 		trd.finish = append(trd.finish, func() error {
 			mRef, ok := trd.manifests[d.Digest]
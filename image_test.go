@@ -2,14 +2,19 @@ package regclient
 
 import (
 	"archive/tar"
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
 	"log/slog"
+	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -19,7 +24,9 @@ import (
 	"github.com/regclient/regclient/config"
 	"github.com/regclient/regclient/internal/copyfs"
 	"github.com/regclient/regclient/scheme/reg"
+	"github.com/regclient/regclient/types"
 	"github.com/regclient/regclient/types/errs"
+	"github.com/regclient/regclient/types/platform"
 	"github.com/regclient/regclient/types/ref"
 )
 
@@ -127,6 +134,11 @@ func TestImageCheckBase(t *testing.T) {
 			opts:      []ImageOpts{ImageWithCheckBaseRef(rb3.CommonName())},
 			expectErr: errs.ErrMismatch,
 		},
+		{
+			name: "manual v1 with attestations, b1",
+			r:    r1,
+			opts: []ImageOpts{ImageWithCheckBaseRef(rb1.CommonName())},
+		},
 		{
 			name: "manual v3, b1",
 			r:    r3,
@@ -387,6 +399,18 @@ func TestCopy(t *testing.T) {
 			tgt:  tsHost + "/dest-reg:child",
 			opts: []ImageOpts{ImageWithReferrers(), ImageWithDigestTags()},
 		},
+		{
+			name: "ocidir to registry with blob concurrency",
+			src:  "ocidir://./testdata/testrepo:v1",
+			tgt:  tsHost + "/dest-concurrent:v1",
+			opts: []ImageOpts{ImageWithBlobConcurrency(1)},
+		},
+		{
+			name: "ocidir to registry child/loop with blob concurrency",
+			src:  "ocidir://./testdata/testrepo:child",
+			tgt:  tsHost + "/dest-concurrent:child",
+			opts: []ImageOpts{ImageWithReferrers(), ImageWithDigestTags(), ImageWithBlobConcurrency(1)},
+		},
 		{
 			name: "ocidir to registry mirror digest tag",
 			src:  "ocidir://./testdata/testrepo:mirror",
@@ -428,6 +452,258 @@ func TestCopy(t *testing.T) {
 	}
 }
 
+func TestCopyCallback(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	regHandler := olareg.New(oConfig.Config{
+		Storage: oConfig.ConfigStorage{
+			StoreType: oConfig.StoreMem,
+		},
+	})
+	ts := httptest.NewServer(regHandler)
+	t.Cleanup(func() {
+		ts.Close()
+		_ = regHandler.Close()
+	})
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	rc := New(
+		WithConfigHost(config.Host{
+			Name:     tsHost,
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+		}),
+	)
+	rSrc, err := ref.New("ocidir://./testdata/testrepo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	rTgt, err := ref.New(tsHost + "/testrepo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	mSrc, err := rc.ManifestHead(ctx, rSrc, WithManifestRequireDigest())
+	if err != nil {
+		t.Fatalf("failed to get source manifest: %v", err)
+	}
+	srcDig := mSrc.GetDescriptor().Digest.String()
+	var mu sync.Mutex
+	states := map[string]types.CallbackState{}
+	shared := 0
+	callback := func(kind types.CallbackKind, instance string, state types.CallbackState, cur, total int64) {
+		mu.Lock()
+		defer mu.Unlock()
+		if state == types.CallbackShared {
+			// reported after the first copy of the blob finishes
+			shared++
+			return
+		}
+		states[kind.String()+":"+instance] = state
+	}
+
+	err = rc.ImageCopy(ctx, rSrc, rTgt, ImageWithCallback(callback))
+	if err != nil {
+		t.Fatalf("failed to copy: %v", err)
+	}
+	if states["manifest:"+srcDig] != types.CallbackFinished {
+		t.Errorf("top level manifest not finished: %v", states)
+	}
+	blobs := 0
+	for k, state := range states {
+		if k == "manifest:" {
+			t.Errorf("manifest reported without a digest")
+		}
+		if strings.HasPrefix(k, "blob:") {
+			blobs++
+		}
+		if state != types.CallbackFinished && state != types.CallbackSkipped {
+			t.Errorf("unexpected final state for %s: %d", k, state)
+		}
+	}
+	if blobs == 0 {
+		t.Errorf("no blobs reported")
+	}
+	// platforms in testrepo:v1 share blobs that are only copied once
+	if shared == 0 {
+		t.Errorf("no shared blobs reported")
+	}
+
+	// a second copy skips the existing manifest
+	states = map[string]types.CallbackState{}
+	err = rc.ImageCopy(ctx, rSrc, rTgt, ImageWithCallback(callback))
+	if err != nil {
+		t.Fatalf("failed to copy: %v", err)
+	}
+	if states["manifest:"+srcDig] != types.CallbackSkipped {
+		t.Errorf("top level manifest not skipped: %v", states)
+	}
+}
+
+func TestCopyMount(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	regHandler := olareg.New(oConfig.Config{
+		Storage: oConfig.ConfigStorage{
+			StoreType: oConfig.StoreMem,
+			RootDir:   "./testdata",
+		},
+	})
+	// track the blob requests sent to the registry
+	var mu sync.Mutex
+	blobGets, blobMounts := 0, 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		if req.Method == http.MethodGet && strings.Contains(req.URL.Path, "/blobs/") {
+			blobGets++
+		}
+		if req.Method == http.MethodPost && req.URL.Query().Get("mount") != "" && req.URL.Query().Get("from") != "" {
+			blobMounts++
+		}
+		mu.Unlock()
+		regHandler.ServeHTTP(w, req)
+	}))
+	t.Cleanup(func() {
+		ts.Close()
+		_ = regHandler.Close()
+	})
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	rc := New(
+		WithConfigHost(config.Host{
+			Name:     tsHost,
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+		}),
+		WithSlog(log),
+	)
+	rSrc, err := ref.New(tsHost + "/testrepo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	rTgt, err := ref.New(tsHost + "/dest-mount:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	err = rc.ImageCopy(ctx, rSrc, rTgt)
+	if err != nil {
+		t.Fatalf("copy failed: %v", err)
+	}
+	_, err = rc.ManifestHead(ctx, rTgt)
+	if err != nil {
+		t.Errorf("copied manifest not found: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if blobMounts == 0 {
+		t.Errorf("no blob mounts attempted")
+	}
+	if blobGets != 0 {
+		t.Errorf("blobs were pulled for a same registry copy: %d", blobGets)
+	}
+}
+
+func TestCopyResume(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	regHandler := olareg.New(oConfig.Config{
+		Storage: oConfig.ConfigStorage{
+			StoreType: oConfig.StoreMem,
+		},
+	})
+	// track the content pushed to the registry
+	var mu sync.Mutex
+	blobPuts, manifestPuts := 0, 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		if req.Method == http.MethodPost && strings.Contains(req.URL.Path, "/blobs/uploads") {
+			blobPuts++
+		}
+		if req.Method == http.MethodPut && strings.Contains(req.URL.Path, "/manifests/") {
+			manifestPuts++
+		}
+		mu.Unlock()
+		regHandler.ServeHTTP(w, req)
+	}))
+	t.Cleanup(func() {
+		ts.Close()
+		_ = regHandler.Close()
+	})
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	rc := New(
+		WithConfigHost(config.Host{
+			Name:     tsHost,
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+		}),
+		WithSlog(log),
+	)
+	counts := func() (int, int) {
+		mu.Lock()
+		defer mu.Unlock()
+		b, m := blobPuts, manifestPuts
+		blobPuts, manifestPuts = 0, 0
+		return b, m
+	}
+	rSrc, err := ref.New("ocidir://./testdata/testrepo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	rFull, err := ref.New(tsHost + "/full:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	rResume, err := ref.New(tsHost + "/resume:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	p, err := platform.Parse("linux/amd64")
+	if err != nil {
+		t.Fatalf("failed to parse platform: %v", err)
+	}
+	mChild, err := rc.ManifestGet(ctx, rSrc, WithManifestPlatform(p))
+	if err != nil {
+		t.Fatalf("failed to get platform manifest: %v", err)
+	}
+	childDig := mChild.GetDescriptor().Digest.String()
+
+	// baseline copy of the full image
+	err = rc.ImageCopy(ctx, rSrc, rFull)
+	if err != nil {
+		t.Fatalf("copy failed: %v", err)
+	}
+	fullBlobs, fullManifests := counts()
+	// simulate an interrupted copy that only finished one platform
+	err = rc.ImageCopy(ctx, rSrc.SetDigest(childDig), rResume.SetDigest(childDig))
+	if err != nil {
+		t.Fatalf("copy failed: %v", err)
+	}
+	childBlobs, _ := counts()
+	// resume copies only the missing content
+	err = rc.ImageCopy(ctx, rSrc, rResume)
+	if err != nil {
+		t.Fatalf("copy failed: %v", err)
+	}
+	resumeBlobs, resumeManifests := counts()
+	if childBlobs == 0 || resumeBlobs+childBlobs > fullBlobs {
+		t.Errorf("blobs were pushed again, full %d, child %d, resume %d", fullBlobs, childBlobs, resumeBlobs)
+	}
+	if resumeManifests >= fullManifests {
+		t.Errorf("manifests were pushed again, full %d, resume %d", fullManifests, resumeManifests)
+	}
+	// a completed copy pushes nothing
+	err = rc.ImageCopy(ctx, rSrc, rResume)
+	if err != nil {
+		t.Fatalf("copy failed: %v", err)
+	}
+	doneBlobs, doneManifests := counts()
+	if doneBlobs != 0 || doneManifests != 0 {
+		t.Errorf("content pushed after the copy completed, blobs %d, manifests %d", doneBlobs, doneManifests)
+	}
+}
+
 func TestExportImport(t *testing.T) {
 	t.Parallel()
 	ctx := context.Background()
@@ -476,6 +752,43 @@ func TestExportImport(t *testing.T) {
 		t.Errorf("failed to export: %v", err)
 	}
 
+	// export a single platform for docker load
+	bufPlat := &bytes.Buffer{}
+	err = rc.ImageExport(ctx, rIn1, bufPlat, ImageWithPlatform("linux/amd64"))
+	if err != nil {
+		t.Errorf("failed to export platform: %v", err)
+	}
+	trPlat := tar.NewReader(bufPlat)
+	foundDocker := false
+	for {
+		th, err := trPlat.Next()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				t.Errorf("failed to read tar header: %v", err)
+			}
+			break
+		}
+		if th.Name != dockerManifestFilename {
+			continue
+		}
+		foundDocker = true
+		dtm := []dockerTarManifest{}
+		err = json.NewDecoder(trPlat).Decode(&dtm)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", dockerManifestFilename, err)
+		}
+		if len(dtm) != 1 || len(dtm[0].RepoTags) != 1 || !strings.HasSuffix(dtm[0].RepoTags[0], ":v1") || len(dtm[0].Layers) == 0 {
+			t.Errorf("unexpected docker manifest: %v", dtm)
+		}
+	}
+	if !foundDocker {
+		t.Errorf("%s missing from platform export", dockerManifestFilename)
+	}
+	err = rc.ImageExport(ctx, rIn1, io.Discard, ImageWithPlatform("linux/unknown"))
+	if err == nil {
+		t.Errorf("export of a missing platform did not fail")
+	}
+
 	// modify tar for tests
 	fileR, err := os.Open(filepath.Join(tempDir, "test1.tar"))
 	if err != nil {
@@ -530,4 +843,57 @@ func TestExportImport(t *testing.T) {
 	if err != nil {
 		t.Errorf("failed to import: %v", err)
 	}
+
+	// export and import with referrers
+	rIn2, err := ref.New("ocidir://" + tempDir + "/testrepo:v2")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	rOut2, err := ref.New("ocidir://" + tempDir + "/testreferrers:v2-copy")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	mIn2, err := rc.ManifestHead(ctx, rIn2, WithManifestRequireDigest())
+	if err != nil {
+		t.Fatalf("failed to head manifest: %v", err)
+	}
+	rlIn, err := rc.ReferrerList(ctx, rIn2)
+	if err != nil {
+		t.Fatalf("failed to list referrers: %v", err)
+	}
+	if len(rlIn.Descriptors) == 0 {
+		t.Fatalf("no referrers found on %s", rIn2.CommonName())
+	}
+	fileOutR, err := os.Create(filepath.Join(tempDir, "test-referrers.tar"))
+	if err != nil {
+		t.Fatalf("failed to create output tar: %v", err)
+	}
+	err = rc.ImageExport(ctx, rIn2, fileOutR, ImageWithReferrers())
+	fileOutR.Close()
+	if err != nil {
+		t.Fatalf("failed to export with referrers: %v", err)
+	}
+	fileInR, err := os.Open(filepath.Join(tempDir, "test-referrers.tar"))
+	if err != nil {
+		t.Fatalf("failed to open tar: %v", err)
+	}
+	defer fileInR.Close()
+	err = rc.ImageImport(ctx, rOut2, fileInR, ImageWithReferrers())
+	if err != nil {
+		t.Fatalf("failed to import with referrers: %v", err)
+	}
+	mOut2, err := rc.ManifestHead(ctx, rOut2, WithManifestRequireDigest())
+	if err != nil {
+		t.Fatalf("failed to head imported manifest: %v", err)
+	}
+	if mOut2.GetDescriptor().Digest != mIn2.GetDescriptor().Digest {
+		t.Errorf("digest mismatch, expected %s, received %s", mIn2.GetDescriptor().Digest, mOut2.GetDescriptor().Digest)
+	}
+	rlOut, err := rc.ReferrerList(ctx, rOut2)
+	if err != nil {
+		t.Fatalf("failed to list referrers: %v", err)
+	}
+	if len(rlOut.Descriptors) != len(rlIn.Descriptors) {
+		t.Errorf("referrers mismatch, expected %d, received %d", len(rlIn.Descriptors), len(rlOut.Descriptors))
+	}
 }
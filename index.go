@@ -0,0 +1,398 @@
+package regclient
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/regclient/regclient/types/descriptor"
+	"github.com/regclient/regclient/types/docker/schema2"
+	"github.com/regclient/regclient/types/errs"
+	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/mediatype"
+	v1 "github.com/regclient/regclient/types/oci/v1"
+	"github.com/regclient/regclient/types/platform"
+	"github.com/regclient/regclient/types/ref"
+	"github.com/regclient/regclient/types/warning"
+)
+
+type indexOpt struct {
+	mediaType    string
+	artifactType string
+	annotations  map[string]string
+	subject      *descriptor.Descriptor
+	add          []descriptor.Descriptor
+	images       []indexImage
+	rmDigests    []digest.Digest
+	rmPlatforms  []platform.Platform
+	mOpts        []ManifestOpts
+}
+
+type indexImage struct {
+	r    ref.Ref
+	opts []ImageOpts
+}
+
+// IndexOpts define options for the Index* commands.
+type IndexOpts func(*indexOpt)
+
+// IndexWithAnnotations sets annotations on the index.
+func IndexWithAnnotations(annotations map[string]string) IndexOpts {
+	return func(opts *indexOpt) {
+		if opts.annotations == nil {
+			opts.annotations = map[string]string{}
+		}
+		for k, v := range annotations {
+			opts.annotations[k] = v
+		}
+	}
+}
+
+// IndexWithArtifactType sets the artifactType of an OCI index.
+func IndexWithArtifactType(artifactType string) IndexOpts {
+	return func(opts *indexOpt) {
+		opts.artifactType = artifactType
+	}
+}
+
+// IndexWithDesc adds entries to the index.
+// Each descriptor must reference a manifest that already exists in the repository.
+// When the platform is not set on an image, it is resolved from the image config.
+// Entries are added after any entries are removed, and duplicates are skipped.
+func IndexWithDesc(dl ...descriptor.Descriptor) IndexOpts {
+	return func(opts *indexOpt) {
+		opts.add = append(opts.add, dl...)
+	}
+}
+
+// IndexWithImage copies an image from another reference into the repository of the index and adds it as an entry.
+// When the source is an index, the [ImageWithPlatform] option selects the image to add.
+// Other image options are passed to [RegClient.ImageCopy].
+// Existing entries with the same platform are replaced.
+func IndexWithImage(r ref.Ref, opts ...ImageOpts) IndexOpts {
+	return func(opts2 *indexOpt) {
+		opts2.images = append(opts2.images, indexImage{r: r, opts: opts})
+	}
+}
+
+// IndexWithManifestOpts passes options to the ManifestPut of the index.
+func IndexWithManifestOpts(mOpts ...ManifestOpts) IndexOpts {
+	return func(opts *indexOpt) {
+		opts.mOpts = append(opts.mOpts, mOpts...)
+	}
+}
+
+// IndexWithMediaType sets the media type for IndexCreate.
+// This may be [mediatype.OCI1ManifestList] (the default) or [mediatype.Docker2ManifestList].
+func IndexWithMediaType(mt string) IndexOpts {
+	return func(opts *indexOpt) {
+		opts.mediaType = mt
+	}
+}
+
+// IndexWithSubject sets the subject of an OCI index.
+func IndexWithSubject(d descriptor.Descriptor) IndexOpts {
+	return func(opts *indexOpt) {
+		dCopy := descriptor.Descriptor{
+			MediaType: d.MediaType,
+			Digest:    d.Digest,
+			Size:      d.Size,
+		}
+		opts.subject = &dCopy
+	}
+}
+
+// IndexWithoutDigest removes entries matching the digest from the index.
+func IndexWithoutDigest(dig digest.Digest) IndexOpts {
+	return func(opts *indexOpt) {
+		opts.rmDigests = append(opts.rmDigests, dig)
+	}
+}
+
+// IndexWithoutPlatform removes entries matching the platform from the index.
+func IndexWithoutPlatform(p platform.Platform) IndexOpts {
+	return func(opts *indexOpt) {
+		opts.rmPlatforms = append(opts.rmPlatforms, p)
+	}
+}
+
+// IndexCreate builds a new index from a list of descriptors and pushes it.
+// If the reference does not include a tag or digest, the index is pushed by digest.
+// The pushed index is returned.
+func (rc *RegClient) IndexCreate(ctx context.Context, r ref.Ref, opts ...IndexOpts) (manifest.Manifest, error) {
+	if !r.IsSetRepo() {
+		return nil, fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), errs.ErrInvalidReference)
+	}
+	opt := indexOpt{
+		mediaType: mediatype.OCI1ManifestList,
+	}
+	for _, optFn := range opts {
+		optFn(&opt)
+	}
+	// dedup warnings
+	if w := warning.FromContext(ctx); w == nil {
+		ctx = warning.NewContext(ctx, &warning.Warning{Hook: warning.DefaultHook()})
+	}
+	dl, err := rc.indexDescList(ctx, r, []descriptor.Descriptor{}, opt)
+	if err != nil {
+		return nil, err
+	}
+	var mOrig any
+	switch opt.mediaType {
+	case mediatype.OCI1ManifestList:
+		mOrig = v1.Index{
+			Versioned:    v1.IndexSchemaVersion,
+			MediaType:    mediatype.OCI1ManifestList,
+			ArtifactType: opt.artifactType,
+			Manifests:    dl,
+			Annotations:  opt.annotations,
+			Subject:      opt.subject,
+		}
+	case mediatype.Docker2ManifestList:
+		if opt.artifactType != "" || opt.subject != nil {
+			return nil, fmt.Errorf("artifact type and subject are not supported by %s%.0w", opt.mediaType, errs.ErrUnsupportedMediaType)
+		}
+		mOrig = schema2.ManifestList{
+			Versioned:   schema2.ManifestListSchemaVersion,
+			Manifests:   dl,
+			Annotations: opt.annotations,
+		}
+	default:
+		return nil, fmt.Errorf("unsupported index media type: %s%.0w", opt.mediaType, errs.ErrUnsupportedMediaType)
+	}
+	m, err := manifest.New(manifest.WithOrig(mOrig))
+	if err != nil {
+		return nil, err
+	}
+	if r.Tag == "" && r.Digest == "" {
+		r = r.SetDigest(m.GetDescriptor().Digest.String())
+	}
+	err = rc.ManifestPut(ctx, r, m, opt.mOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// IndexUpdate pulls an existing index, removes and adds entries, and pushes the result.
+// When the reference is a digest without a tag, the result is pushed by the new digest.
+// The media type option is ignored, and the artifact type of an existing index cannot be changed.
+// The pushed index is returned.
+func (rc *RegClient) IndexUpdate(ctx context.Context, r ref.Ref, opts ...IndexOpts) (manifest.Manifest, error) {
+	if !r.IsSet() {
+		return nil, fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), errs.ErrInvalidReference)
+	}
+	opt := indexOpt{}
+	for _, optFn := range opts {
+		optFn(&opt)
+	}
+	if opt.artifactType != "" {
+		return nil, fmt.Errorf("artifact type cannot be changed on an existing index%.0w", errs.ErrUnsupportedMediaType)
+	}
+	// dedup warnings
+	if w := warning.FromContext(ctx); w == nil {
+		ctx = warning.NewContext(ctx, &warning.Warning{Hook: warning.DefaultHook()})
+	}
+	m, err := rc.ManifestGet(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	mi, ok := m.(manifest.Indexer)
+	if !ok {
+		return nil, fmt.Errorf("manifest is not an index: %s%.0w", m.GetDescriptor().MediaType, errs.ErrUnsupportedMediaType)
+	}
+	curDesc, err := mi.GetManifestList()
+	if err != nil {
+		return nil, err
+	}
+	dl, err := rc.indexDescList(ctx, r, curDesc, opt)
+	if err != nil {
+		return nil, err
+	}
+	err = mi.SetManifestList(dl)
+	if err != nil {
+		return nil, err
+	}
+	if len(opt.annotations) > 0 {
+		ma, ok := m.(manifest.Annotator)
+		if !ok {
+			return nil, fmt.Errorf("annotations are not supported by %s%.0w", m.GetDescriptor().MediaType, errs.ErrUnsupportedMediaType)
+		}
+		for k, v := range opt.annotations {
+			err = ma.SetAnnotation(k, v)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if opt.subject != nil {
+		ms, ok := m.(manifest.Subjecter)
+		if !ok {
+			return nil, fmt.Errorf("subject is not supported by %s%.0w", m.GetDescriptor().MediaType, errs.ErrUnsupportedMediaType)
+		}
+		err = ms.SetSubject(opt.subject)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if r.Tag == "" {
+		r = r.SetDigest(m.GetDescriptor().Digest.String())
+	}
+	err = rc.ManifestPut(ctx, r, m, opt.mOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// indexDescList applies the removals and additions to a descriptor list.
+func (rc *RegClient) indexDescList(ctx context.Context, r ref.Ref, dl []descriptor.Descriptor, opt indexOpt) ([]descriptor.Descriptor, error) {
+	result := make([]descriptor.Descriptor, 0, len(dl)+len(opt.add))
+	for _, d := range dl {
+		if indexDescRemove(d, opt) {
+			continue
+		}
+		result = append(result, d)
+	}
+	for _, img := range opt.images {
+		d, err := rc.indexImageCopy(ctx, r, img)
+		if err != nil {
+			return nil, err
+		}
+		if d.Platform != nil {
+			keep := make([]descriptor.Descriptor, 0, len(result))
+			for _, cur := range result {
+				if cur.Platform == nil || !platform.Match(*cur.Platform, *d.Platform) {
+					keep = append(keep, cur)
+				}
+			}
+			result = keep
+		}
+		result = append(result, d)
+	}
+	for _, d := range opt.add {
+		if d.Digest == "" || !mediatype.Valid(d.MediaType) {
+			return nil, fmt.Errorf("descriptor requires a media type and digest: %v%.0w", d, errs.ErrUnsupportedMediaType)
+		}
+		if d.Platform == nil {
+			p, err := rc.indexPlatform(ctx, r.SetDigest(d.Digest.String()), d)
+			if err != nil {
+				return nil, err
+			}
+			d.Platform = p
+		}
+		found := false
+		for _, cur := range result {
+			if cur.Equal(d) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, d)
+		}
+	}
+	return result, nil
+}
+
+// indexImageCopy copies an image into the repository of the index and returns the descriptor with the platform.
+func (rc *RegClient) indexImageCopy(ctx context.Context, r ref.Ref, img indexImage) (descriptor.Descriptor, error) {
+	var opt imageOpt
+	for _, optFn := range img.opts {
+		optFn(&opt)
+	}
+	m, err := rc.ManifestHead(ctx, img.r, WithManifestRequireDigest())
+	if err != nil {
+		return descriptor.Descriptor{}, err
+	}
+	rSrc := img.r
+	if m.IsList() {
+		if opt.platform == "" {
+			return descriptor.Descriptor{}, fmt.Errorf("platform is required to add an image from index %s%.0w", img.r.CommonName(), errs.ErrMissingInput)
+		}
+		p, err := platform.Parse(opt.platform)
+		if err != nil {
+			return descriptor.Descriptor{}, err
+		}
+		m, err = rc.ManifestGet(ctx, img.r)
+		if err != nil {
+			return descriptor.Descriptor{}, err
+		}
+		d, err := manifest.GetPlatformDesc(m, &p)
+		if err != nil {
+			return descriptor.Descriptor{}, err
+		}
+		rSrc = img.r.SetDigest(d.Digest.String())
+		m, err = rc.ManifestHead(ctx, rSrc, WithManifestRequireDigest())
+		if err != nil {
+			return descriptor.Descriptor{}, err
+		}
+	}
+	d := m.GetDescriptor()
+	rTgt := r.SetDigest(d.Digest.String())
+	err = rc.ImageCopy(ctx, rSrc, rTgt, append([]ImageOpts{ImageWithChild()}, img.opts...)...)
+	if err != nil {
+		return descriptor.Descriptor{}, fmt.Errorf("failed to copy %s: %w", rSrc.CommonName(), err)
+	}
+	d = descriptor.Descriptor{
+		MediaType: d.MediaType,
+		Digest:    d.Digest,
+		Size:      d.Size,
+	}
+	d.Platform, err = rc.indexPlatform(ctx, rTgt, d)
+	if err != nil {
+		return descriptor.Descriptor{}, err
+	}
+	return d, nil
+}
+
+// indexDescRemove returns true when the descriptor matches a removal option.
+func indexDescRemove(d descriptor.Descriptor, opt indexOpt) bool {
+	for _, dig := range opt.rmDigests {
+		if d.Digest == dig {
+			return true
+		}
+	}
+	if d.Platform != nil {
+		for _, p := range opt.rmPlatforms {
+			if platform.Match(p, *d.Platform) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// indexPlatform returns the platform from the config of an image, or nil for other manifests.
+func (rc *RegClient) indexPlatform(ctx context.Context, r ref.Ref, d descriptor.Descriptor) (*platform.Platform, error) {
+	if d.MediaType != mediatype.OCI1Manifest && d.MediaType != mediatype.Docker2Manifest {
+		return nil, nil
+	}
+	m, err := rc.ManifestGet(ctx, r, WithManifestDesc(d))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get manifest %s: %w", r.CommonName(), err)
+	}
+	mi, ok := m.(manifest.Imager)
+	if !ok {
+		return nil, nil
+	}
+	cd, err := mi.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	if cd.MediaType != mediatype.OCI1ImageConfig && cd.MediaType != mediatype.Docker2ImageConfig {
+		// artifacts do not have a platform
+		return nil, nil
+	}
+	blobConfig, err := rc.BlobGetOCIConfig(ctx, r, cd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config %s: %w", r.CommonName(), err)
+	}
+	conf := blobConfig.GetConfig()
+	if conf.OS == "" {
+		return nil, nil
+	}
+	p := conf.Platform
+	return &p, nil
+}
@@ -0,0 +1,217 @@
+package regclient
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/olareg/olareg"
+	oConfig "github.com/olareg/olareg/config"
+
+	"github.com/regclient/regclient/config"
+	"github.com/regclient/regclient/types/descriptor"
+	"github.com/regclient/regclient/types/errs"
+	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/mediatype"
+	"github.com/regclient/regclient/types/platform"
+	"github.com/regclient/regclient/types/ref"
+)
+
+func TestIndex(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	regHandler := olareg.New(oConfig.Config{
+		Storage: oConfig.ConfigStorage{
+			StoreType: oConfig.StoreMem,
+			RootDir:   "./testdata",
+		},
+	})
+	ts := httptest.NewServer(regHandler)
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	t.Cleanup(func() {
+		ts.Close()
+		_ = regHandler.Close()
+	})
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	rc := New(
+		WithConfigHost(config.Host{
+			Name:     tsHost,
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+		}),
+		WithSlog(log),
+	)
+	rSrc, err := ref.New(tsHost + "/testrepo:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	pAMD, err := platform.Parse("linux/amd64")
+	if err != nil {
+		t.Fatalf("failed to parse platform: %v", err)
+	}
+	pARM, err := platform.Parse("linux/arm64")
+	if err != nil {
+		t.Fatalf("failed to parse platform: %v", err)
+	}
+	mSrc, err := rc.ManifestGet(ctx, rSrc)
+	if err != nil {
+		t.Fatalf("failed to get index: %v", err)
+	}
+	dAMD, err := manifest.GetPlatformDesc(mSrc, &pAMD)
+	if err != nil {
+		t.Fatalf("failed to get platform: %v", err)
+	}
+	dARM, err := manifest.GetPlatformDesc(mSrc, &pARM)
+	if err != nil {
+		t.Fatalf("failed to get platform: %v", err)
+	}
+	// the platform should be resolved from the config when missing
+	dARMNoPlat := *dARM
+	dARMNoPlat.Platform = nil
+	rTgt := rSrc.SetTag("index-new")
+
+	t.Run("create", func(t *testing.T) {
+		m, err := rc.IndexCreate(ctx, rTgt,
+			IndexWithDesc(*dAMD, dARMNoPlat, *dAMD),
+			IndexWithAnnotations(map[string]string{"org.example.key": "value"}),
+		)
+		if err != nil {
+			t.Fatalf("failed to create index: %v", err)
+		}
+		mGet, err := rc.ManifestGet(ctx, rTgt)
+		if err != nil {
+			t.Fatalf("failed to get index: %v", err)
+		}
+		if mGet.GetDescriptor().Digest != m.GetDescriptor().Digest {
+			t.Errorf("digest mismatch, expected %s, received %s", m.GetDescriptor().Digest, mGet.GetDescriptor().Digest)
+		}
+		if mGet.GetDescriptor().MediaType != mediatype.OCI1ManifestList {
+			t.Errorf("unexpected media type: %s", mGet.GetDescriptor().MediaType)
+		}
+		dl, err := mGet.(manifest.Indexer).GetManifestList()
+		if err != nil {
+			t.Fatalf("failed to get manifest list: %v", err)
+		}
+		if len(dl) != 2 {
+			t.Fatalf("unexpected number of entries, expected 2, received %d", len(dl))
+		}
+		if dl[1].Platform == nil || !platform.Match(*dl[1].Platform, pARM) {
+			t.Errorf("platform was not resolved: %v", dl[1].Platform)
+		}
+		annotations, err := mGet.(manifest.Annotator).GetAnnotations()
+		if err != nil || annotations["org.example.key"] != "value" {
+			t.Errorf("missing annotation: %v, %v", annotations, err)
+		}
+	})
+	t.Run("create by digest", func(t *testing.T) {
+		m, err := rc.IndexCreate(ctx, rSrc.SetTag(""),
+			IndexWithMediaType(mediatype.Docker2ManifestList),
+			IndexWithDesc(*dAMD),
+		)
+		if err != nil {
+			t.Fatalf("failed to create index: %v", err)
+		}
+		if m.GetDescriptor().MediaType != mediatype.Docker2ManifestList {
+			t.Errorf("unexpected media type: %s", m.GetDescriptor().MediaType)
+		}
+		_, err = rc.ManifestHead(ctx, rSrc.SetDigest(m.GetDescriptor().Digest.String()))
+		if err != nil {
+			t.Errorf("index was not pushed by digest: %v", err)
+		}
+	})
+	t.Run("update", func(t *testing.T) {
+		m, err := rc.IndexUpdate(ctx, rTgt, IndexWithoutPlatform(pARM))
+		if err != nil {
+			t.Fatalf("failed to update index: %v", err)
+		}
+		dl, err := m.(manifest.Indexer).GetManifestList()
+		if err != nil {
+			t.Fatalf("failed to get manifest list: %v", err)
+		}
+		if len(dl) != 1 || dl[0].Digest != dAMD.Digest {
+			t.Errorf("unexpected entries after removing platform: %v", dl)
+		}
+		m, err = rc.IndexUpdate(ctx, rTgt, IndexWithoutDigest(dAMD.Digest), IndexWithDesc(*dARM))
+		if err != nil {
+			t.Fatalf("failed to update index: %v", err)
+		}
+		mGet, err := rc.ManifestGet(ctx, rTgt)
+		if err != nil {
+			t.Fatalf("failed to get index: %v", err)
+		}
+		if mGet.GetDescriptor().Digest != m.GetDescriptor().Digest {
+			t.Errorf("digest mismatch, expected %s, received %s", m.GetDescriptor().Digest, mGet.GetDescriptor().Digest)
+		}
+		dl, err = mGet.(manifest.Indexer).GetManifestList()
+		if err != nil {
+			t.Fatalf("failed to get manifest list: %v", err)
+		}
+		if len(dl) != 1 || dl[0].Digest != dARM.Digest {
+			t.Errorf("unexpected entries after replacing digest: %v", dl)
+		}
+	})
+	t.Run("image", func(t *testing.T) {
+		rMerge, err := ref.New(tsHost + "/testmerge:v1")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		_, err = rc.IndexCreate(ctx, rMerge, IndexWithImage(rSrc.SetDigest(dAMD.Digest.String())))
+		if err != nil {
+			t.Fatalf("failed to create index: %v", err)
+		}
+		// add another platform from an index, and replace the existing platform
+		m, err := rc.IndexUpdate(ctx, rMerge,
+			IndexWithImage(rSrc, ImageWithPlatform("linux/arm64")),
+			IndexWithImage(rSrc, ImageWithPlatform("linux/amd64")),
+		)
+		if err != nil {
+			t.Fatalf("failed to update index: %v", err)
+		}
+		dl, err := m.(manifest.Indexer).GetManifestList()
+		if err != nil {
+			t.Fatalf("failed to get manifest list: %v", err)
+		}
+		if len(dl) != 2 || dl[0].Digest != dARM.Digest || dl[1].Digest != dAMD.Digest {
+			t.Errorf("unexpected entries after adding images: %v", dl)
+		}
+		for _, d := range dl {
+			if d.Platform == nil {
+				t.Errorf("platform missing on %s", d.Digest)
+			}
+			_, err = rc.ImageConfig(ctx, rMerge.SetDigest(d.Digest.String()))
+			if err != nil {
+				t.Errorf("failed to get config of copied image %s: %v", d.Digest, err)
+			}
+		}
+		_, err = rc.IndexUpdate(ctx, rMerge, IndexWithImage(rSrc))
+		if err == nil || !errors.Is(err, errs.ErrMissingInput) || errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("adding an index without a platform did not fail: %v", err)
+		}
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, err := rc.IndexCreate(ctx, rTgt, IndexWithMediaType(mediatype.OCI1Manifest))
+		if err == nil || !errors.Is(err, errs.ErrUnsupportedMediaType) {
+			t.Errorf("invalid media type did not fail: %v", err)
+		}
+		_, err = rc.IndexCreate(ctx, rTgt,
+			IndexWithMediaType(mediatype.Docker2ManifestList),
+			IndexWithSubject(*dAMD),
+		)
+		if err == nil || !errors.Is(err, errs.ErrUnsupportedMediaType) {
+			t.Errorf("subject on docker manifest list did not fail: %v", err)
+		}
+		_, err = rc.IndexCreate(ctx, rTgt, IndexWithDesc(descriptor.Descriptor{MediaType: mediatype.OCI1Manifest}))
+		if err == nil || !errors.Is(err, errs.ErrUnsupportedMediaType) {
+			t.Errorf("descriptor without a digest did not fail: %v", err)
+		}
+		_, err = rc.IndexUpdate(ctx, rSrc.SetDigest(dAMD.Digest.String()), IndexWithoutPlatform(pARM))
+		if err == nil || !errors.Is(err, errs.ErrUnsupportedMediaType) {
+			t.Errorf("update of an image did not fail: %v", err)
+		}
+	})
+}
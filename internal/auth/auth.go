@@ -3,7 +3,9 @@ package auth
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -72,6 +74,16 @@ type handler interface {
 	GenerateAuth() (string, error)
 }
 
+// cacheHandler is implemented by handlers that can reuse tokens from a Cache
+type cacheHandler interface {
+	setCache(Cache)
+}
+
+// anonHandler is implemented by handlers that can retry anonymously when credentials are rejected
+type anonHandler interface {
+	setAnonFallback(bool)
+}
+
 // handlerBuild is used to make a new handler for a specific authType and URL
 type handlerBuild func(client *http.Client, clientID, host string, credFn CredsFn, slog *slog.Logger) handler
 
@@ -83,6 +95,8 @@ type Auth struct {
 	httpClient *http.Client
 	clientID   string
 	credsFn    CredsFn
+	cache      Cache
+	anon       bool
 	hbs        map[string]handlerBuild       // handler builders based on authType
 	hs         map[string]map[string]handler // handlers based on url and authType
 	authTypes  []string
@@ -122,6 +136,20 @@ func WithCreds(f CredsFn) Opts {
 	}
 }
 
+// WithAnonFallback retries pull requests anonymously when credentials are rejected
+func WithAnonFallback() Opts {
+	return func(a *Auth) {
+		a.anon = true
+	}
+}
+
+// WithCache saves bearer tokens to a cache for reuse by other processes
+func WithCache(c Cache) Opts {
+	return func(a *Auth) {
+		a.cache = c
+	}
+}
+
 // WithHTTPClient uses a specific http client with requests
 func WithHTTPClient(h *http.Client) Opts {
 	return func(a *Auth) {
@@ -229,6 +257,12 @@ func (a *Auth) HandleResponse(resp *http.Response) error {
 			if h == nil {
 				continue
 			}
+			if hc, ok := h.(cacheHandler); ok && a.cache != nil {
+				hc.setCache(a.cache)
+			}
+			if ha, ok := h.(anonHandler); ok && a.anon {
+				ha.setAnonFallback(true)
+			}
 			a.hs[host][c.authType] = h
 		}
 		// process the challenge with that handler
@@ -488,6 +522,10 @@ type bearerHandler struct {
 	credsFn        CredsFn
 	scopes         []string
 	token          bearerToken
+	cache          Cache
+	cacheUsed      bool // current token was loaded from the cache
+	cacheSkip      bool // a token from the cache was rejected
+	anonFallback   bool // retry pull requests without credentials
 	slog           *slog.Logger
 }
 
@@ -598,7 +636,14 @@ func (b *bearerHandler) ProcessChallenge(c challenge) error {
 	existingScope := b.scopeExists(c.params["scope"])
 
 	if b.realm == c.params["realm"] && b.service == c.params["service"] && existingScope && (b.token.Token == "" || !b.isExpired()) {
-		return ErrNoNewChallenge
+		if !b.cacheUsed {
+			return ErrNoNewChallenge
+		}
+		// the token from the cache was rejected, request a new token
+		b.cacheUsed = false
+		b.cacheSkip = true
+		b.token.Token = ""
+		return nil
 	}
 
 	if b.realm == "" {
@@ -624,9 +669,24 @@ func (b *bearerHandler) GenerateAuth() (string, error) {
 		return fmt.Sprintf("Bearer %s", b.token.Token), nil
 	}
 
-	// attempt to post if a refresh token is available
-	if b.token.RefreshToken != "" {
-		if err := b.tryPost(); err == nil {
+	// reuse a token saved by another process
+	if b.cacheLoad() {
+		return fmt.Sprintf("Bearer %s", b.token.Token), nil
+	}
+
+	// attempt to post if a refresh token or identity token is available
+	cred := b.credsFn(b.host)
+	if b.token.RefreshToken != "" || cred.Token != "" {
+		err := b.tryPost()
+		if err == ErrUnauthorized && b.token.RefreshToken != "" {
+			// the refresh token was rejected, discard it and retry with the identity token
+			b.token.RefreshToken = ""
+			if cred.Token != "" {
+				err = b.tryPost()
+			}
+		}
+		if err == nil {
+			b.cacheStore()
 			return fmt.Sprintf("Bearer %s", b.token.Token), nil
 		} else if err != ErrUnauthorized {
 			return "", fmt.Errorf("failed to request auth token (post): %w%.0w", err, errs.ErrHTTPUnauthorized)
@@ -634,15 +694,109 @@ func (b *bearerHandler) GenerateAuth() (string, error) {
 	}
 
 	// attempt a get (with basic auth if user/pass available)
-	if err := b.tryGet(); err == nil {
+	if err := b.tryGet(cred); err == nil {
+		b.cacheStore()
 		return fmt.Sprintf("Bearer %s", b.token.Token), nil
 	} else if err != ErrUnauthorized {
 		return "", fmt.Errorf("failed to request auth token (get): %w%.0w", err, errs.ErrHTTPUnauthorized)
 	}
 
+	// attempt an anonymous get when credentials are rejected for a pull
+	if b.anonFallback && cred.User != "" && cred.Password != "" && b.pullOnly() {
+		b.slog.Warn("Credentials rejected, retrying anonymously",
+			slog.String("host", b.host),
+			slog.String("user", cred.User))
+		if err := b.tryGet(Cred{}); err == nil {
+			// anonymous tokens are not cached to avoid replacing the token for the credential
+			b.cacheUsed = false
+			return fmt.Sprintf("Bearer %s", b.token.Token), nil
+		} else if err != ErrUnauthorized {
+			return "", fmt.Errorf("failed to request anonymous auth token (get): %w%.0w", err, errs.ErrHTTPUnauthorized)
+		}
+	}
+
 	return "", ErrUnauthorized
 }
 
+func (b *bearerHandler) setAnonFallback(enabled bool) {
+	b.anonFallback = enabled
+}
+
+// pullOnly returns true when every scope is limited to pulling a repository
+func (b *bearerHandler) pullOnly() bool {
+	if len(b.scopes) == 0 {
+		return false
+	}
+	for _, s := range b.scopes {
+		_, actions, ok := parseScope(s)
+		if !ok || len(actions) != 1 || actions[0] != "pull" {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *bearerHandler) setCache(c Cache) {
+	b.cache = c
+}
+
+// cacheKey returns a hash of the request parameters that would return the same token
+func (b *bearerHandler) cacheKey() string {
+	cred := b.credsFn(b.host)
+	scopes := slices.Clone(b.scopes)
+	slices.Sort(scopes)
+	h := sha256.New()
+	for _, s := range []string{b.host, b.realm, b.service, cred.User, cred.Token, strings.Join(scopes, " ")} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cacheLoad sets the token from the cache, returning true on success
+func (b *bearerHandler) cacheLoad() bool {
+	if b.cache == nil || b.cacheSkip {
+		return false
+	}
+	entry, ok := b.cache.Load(b.cacheKey())
+	if !ok || entry.Token == "" {
+		return false
+	}
+	token := bearerToken{
+		Token:        entry.Token,
+		ExpiresIn:    int(time.Until(entry.Expires) / time.Second),
+		IssuedAt:     time.Now().UTC(),
+		RefreshToken: b.token.RefreshToken,
+	}
+	prev := b.token
+	b.token = token
+	if b.isExpired() {
+		b.token = prev
+		return false
+	}
+	b.cacheUsed = true
+	b.slog.Debug("Auth token loaded from cache",
+		slog.String("host", b.host))
+	return true
+}
+
+// cacheStore saves the current token to the cache
+func (b *bearerHandler) cacheStore() {
+	b.cacheUsed = false
+	if b.cache == nil {
+		return
+	}
+	err := b.cache.Store(b.cacheKey(), CacheEntry{
+		Token:   b.token.Token,
+		Expires: b.token.IssuedAt.Add(time.Duration(b.token.ExpiresIn) * time.Second),
+	})
+	if err != nil {
+		b.slog.Debug("Failed to save auth token to cache",
+			slog.String("host", b.host),
+			slog.String("err", err.Error()))
+	}
+}
+
 // isExpired returns true when token issue date is either 0, token has expired,
 // or will expire within buffer time
 func (b *bearerHandler) isExpired() bool {
@@ -655,8 +809,7 @@ func (b *bearerHandler) isExpired() bool {
 }
 
 // tryGet requests a new token with a GET request
-func (b *bearerHandler) tryGet() error {
-	cred := b.credsFn(b.host)
+func (b *bearerHandler) tryGet(cred Cred) error {
 	req, err := http.NewRequest("GET", b.realm, nil)
 	if err != nil {
 		return err
@@ -774,6 +927,10 @@ func (b *bearerHandler) validateResponse(resp *http.Response) error {
 	if err := decoder.Decode(&decoded); err != nil {
 		return err
 	}
+	// keep the previous refresh token when the server does not rotate it
+	if decoded.RefreshToken == "" {
+		decoded.RefreshToken = b.token.RefreshToken
+	}
 	b.token = decoded
 
 	if b.token.ExpiresIn < minTokenLife {
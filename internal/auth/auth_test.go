@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"sync"
 	"testing"
 	"time"
 
@@ -653,3 +654,180 @@ func TestBearer(t *testing.T) {
 		t.Errorf("token5 (rerun) is already expired")
 	}
 }
+
+func TestBearerAnonFallback(t *testing.T) {
+	t.Parallel()
+	useragent := "regclient/test"
+	user := "user"
+	pass := "expiredpass"
+	userPassEnc := base64.StdEncoding.EncodeToString([]byte(user + ":" + pass))
+	anonResp, _ := json.Marshal(bearerToken{
+		Token:     "anon-token",
+		ExpiresIn: 900,
+		IssuedAt:  time.Now(),
+	})
+	rrs := []reqresp.ReqResp{
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "req token with creds",
+				Method: "GET",
+				Path:   "/tokens",
+				Headers: http.Header{
+					"Authorization": {"Basic " + userPassEnc},
+				},
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusUnauthorized,
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "req token anonymous",
+				Method: "GET",
+				Path:   "/tokens",
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Body:   anonResp,
+			},
+		},
+	}
+	ts := httptest.NewServer(reqresp.NewHandler(t, rrs))
+	defer ts.Close()
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	newBearer := func(anon bool, scope string) handler {
+		opts := []Opts{
+			WithCreds(func(h string) Cred { return Cred{User: user, Password: pass} }),
+			WithClientID(useragent),
+		}
+		if anon {
+			opts = append(opts, WithAnonFallback())
+		}
+		a := NewAuth(opts...)
+		resp := &http.Response{
+			StatusCode: http.StatusUnauthorized,
+			Request:    &http.Request{URL: tsURL, Header: http.Header{}},
+			Header: http.Header{
+				"Www-Authenticate": {`Bearer realm="` + tsURL.String() + `/tokens",service="test",scope="` + scope + `"`},
+			},
+		}
+		err := a.HandleResponse(resp)
+		if err != nil {
+			t.Fatalf("failed to handle response: %v", err)
+		}
+		return a.hs[tsHost]["bearer"]
+	}
+
+	t.Run("pull", func(t *testing.T) {
+		b := newBearer(true, "repository:reponame:pull")
+		resp, err := b.GenerateAuth()
+		if err != nil {
+			t.Fatalf("failed to generate auth: %v", err)
+		}
+		if resp != "Bearer anon-token" {
+			t.Errorf("unexpected auth, expected %s, received %s", "Bearer anon-token", resp)
+		}
+	})
+	t.Run("push", func(t *testing.T) {
+		b := newBearer(true, "repository:reponame:pull,push")
+		_, err := b.GenerateAuth()
+		if err == nil {
+			t.Errorf("anonymous fallback used for a push")
+		}
+	})
+	t.Run("disabled", func(t *testing.T) {
+		b := newBearer(false, "repository:reponame:pull")
+		_, err := b.GenerateAuth()
+		if err == nil {
+			t.Errorf("anonymous fallback used when disabled")
+		}
+	})
+}
+
+func TestBearerIdentityToken(t *testing.T) {
+	t.Parallel()
+	useragent := "regclient/test"
+	identityToken := "identity-token"
+	var mu sync.Mutex
+	refreshValid := true
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/oauth2/token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		err := r.ParseForm()
+		if err != nil || r.PostForm.Get("grant_type") != "refresh_token" || r.PostForm.Get("service") != "test" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		var resp bearerToken
+		switch r.PostForm.Get("refresh_token") {
+		case identityToken:
+			resp = bearerToken{AccessToken: "access-identity", RefreshToken: "refresh-token", ExpiresIn: 900}
+		case "refresh-token":
+			if !refreshValid {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			resp = bearerToken{AccessToken: "access-refresh", ExpiresIn: 900}
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(ts.Close)
+	tsURL, _ := url.Parse(ts.URL)
+	a := NewAuth(
+		WithCreds(func(h string) Cred { return Cred{Token: identityToken} }),
+		WithClientID(useragent),
+	)
+	err := a.HandleResponse(&http.Response{
+		StatusCode: http.StatusUnauthorized,
+		Request:    &http.Request{URL: tsURL, Header: http.Header{}},
+		Header: http.Header{
+			"Www-Authenticate": {`Bearer realm="` + tsURL.String() + `/oauth2/token",service="test",scope="repository:reponame:pull"`},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to handle response: %v", err)
+	}
+	b, ok := a.hs[tsURL.Host]["bearer"].(*bearerHandler)
+	if !ok {
+		t.Fatalf("bearer handler not found")
+	}
+	expect := func(expect string) {
+		t.Helper()
+		resp, err := b.GenerateAuth()
+		if err != nil {
+			t.Fatalf("failed to generate auth: %v", err)
+		}
+		if resp != "Bearer "+expect {
+			t.Errorf("unexpected auth, expected %s, received %s", expect, resp)
+		}
+	}
+	expire := func() {
+		b.token.IssuedAt = time.Now().Add(time.Hour * -1)
+	}
+
+	// identity token is exchanged for an access and refresh token
+	expect("access-identity")
+	if b.token.RefreshToken != "refresh-token" {
+		t.Errorf("refresh token not saved: %s", b.token.RefreshToken)
+	}
+	// expired token is refreshed and the refresh token is kept
+	expire()
+	expect("access-refresh")
+	if b.token.RefreshToken != "refresh-token" {
+		t.Errorf("refresh token not kept: %s", b.token.RefreshToken)
+	}
+	// rejected refresh token falls back to the identity token
+	mu.Lock()
+	refreshValid = false
+	mu.Unlock()
+	expire()
+	expect("access-identity")
+}
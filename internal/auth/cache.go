@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/regclient/regclient/internal/conffile"
+)
+
+const (
+	// cacheFilePerms are the only permissions allowed on a token cache file
+	cacheFilePerms = 0600
+	// cacheLockWait is the maximum time to wait for another process to release the lock
+	cacheLockWait = 5 * time.Second
+	// cacheLockPoll is the interval to check the lock
+	cacheLockPoll = 10 * time.Millisecond
+	// cacheLockStale is the age when a lock is assumed to be left by a process that did not exit cleanly
+	cacheLockStale = 30 * time.Second
+)
+
+// Cache stores bearer tokens to be reused between processes.
+type Cache interface {
+	Load(key string) (CacheEntry, bool)
+	Store(key string, entry CacheEntry) error
+}
+
+// CacheEntry is a token saved in the Cache.
+type CacheEntry struct {
+	Token   string    `json:"token"`
+	Expires time.Time `json:"expires"`
+}
+
+// FileCache is a Cache saved to a json file.
+// The file is only readable by the owner, and writes are protected by a lock file to support concurrent processes.
+type FileCache struct {
+	filename string
+	mu       sync.Mutex
+}
+
+type fileCacheData struct {
+	Tokens map[string]CacheEntry `json:"tokens"`
+}
+
+// NewFileCache returns a Cache saved to the filename.
+// The file and parent directory are created on the first Store.
+func NewFileCache(filename string) *FileCache {
+	return &FileCache{
+		filename: filename,
+	}
+}
+
+// Load returns an unexpired token from the cache file.
+func (fc *FileCache) Load(key string) (CacheEntry, bool) {
+	data, err := fc.read()
+	if err != nil {
+		return CacheEntry{}, false
+	}
+	entry, ok := data.Tokens[key]
+	if !ok || !entry.Expires.After(time.Now()) {
+		return CacheEntry{}, false
+	}
+	return entry, true
+}
+
+// Store saves a token to the cache file, pruning any expired tokens.
+func (fc *FileCache) Store(key string, entry CacheEntry) error {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	unlock, err := fc.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	data, err := fc.read()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		// replace an unreadable or unsafe file
+		data = fileCacheData{}
+	}
+	if data.Tokens == nil {
+		data.Tokens = map[string]CacheEntry{}
+	}
+	now := time.Now()
+	for k, e := range data.Tokens {
+		if !e.Expires.After(now) {
+			delete(data.Tokens, k)
+		}
+	}
+	data.Tokens[key] = entry
+	out, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	cf := conffile.New(conffile.WithFullname(fc.filename), conffile.WithPerms(cacheFilePerms))
+	if cf == nil {
+		return fmt.Errorf("token cache filename is not defined")
+	}
+	err = cf.Write(bytes.NewReader(out))
+	if err != nil {
+		return err
+	}
+	// the write preserves the mode of an existing file, reset the mode in case it was changed
+	return os.Chmod(fc.filename, cacheFilePerms)
+}
+
+// read parses the cache file, rejecting files that are readable by other users.
+func (fc *FileCache) read() (fileCacheData, error) {
+	data := fileCacheData{}
+	fi, err := os.Stat(fc.filename)
+	if err != nil {
+		return data, err
+	}
+	if runtime.GOOS != "windows" && fi.Mode().Perm()&^cacheFilePerms != 0 {
+		return data, fmt.Errorf("token cache %s has unsafe permissions %s", fc.filename, fi.Mode().Perm().String())
+	}
+	b, err := os.ReadFile(fc.filename)
+	if err != nil {
+		return data, err
+	}
+	err = json.Unmarshal(b, &data)
+	if err != nil {
+		return data, fmt.Errorf("failed to parse token cache %s: %w", fc.filename, err)
+	}
+	return data, nil
+}
+
+// lock creates a lock file next to the cache, returning a function to release the lock.
+func (fc *FileCache) lock() (func(), error) {
+	lockName := fc.filename + ".lock"
+	if err := os.MkdirAll(filepath.Dir(lockName), 0700); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	for {
+		f, err := os.OpenFile(lockName, os.O_CREATE|os.O_EXCL|os.O_WRONLY, cacheFilePerms)
+		if err == nil {
+			_ = f.Close()
+			return func() { _ = os.Remove(lockName) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+		if fi, err := os.Stat(lockName); err == nil && time.Since(fi.ModTime()) > cacheLockStale {
+			_ = os.Remove(lockName)
+			continue
+		}
+		if time.Since(start) > cacheLockWait {
+			return nil, fmt.Errorf("timeout waiting for token cache lock %s", lockName)
+		}
+		time.Sleep(cacheLockPoll)
+	}
+}
@@ -0,0 +1,209 @@
+package auth
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFileCache(t *testing.T) {
+	t.Parallel()
+	tempDir := t.TempDir()
+	filename := filepath.Join(tempDir, "sub", "cache.json")
+	fc := NewFileCache(filename)
+
+	t.Run("missing", func(t *testing.T) {
+		if _, ok := fc.Load("missing"); ok {
+			t.Errorf("load succeeded without a file")
+		}
+	})
+	t.Run("store-load", func(t *testing.T) {
+		err := fc.Store("a", CacheEntry{Token: "token-a", Expires: time.Now().Add(time.Hour)})
+		if err != nil {
+			t.Fatalf("failed to store: %v", err)
+		}
+		err = fc.Store("expired", CacheEntry{Token: "token-expired", Expires: time.Now().Add(-1 * time.Second)})
+		if err != nil {
+			t.Fatalf("failed to store: %v", err)
+		}
+		e, ok := fc.Load("a")
+		if !ok || e.Token != "token-a" {
+			t.Errorf("unexpected entry: %v, %t", e, ok)
+		}
+		if _, ok := fc.Load("expired"); ok {
+			t.Errorf("expired entry was loaded")
+		}
+		// a new cache on the same file sees the entry
+		e, ok = NewFileCache(filename).Load("a")
+		if !ok || e.Token != "token-a" {
+			t.Errorf("unexpected entry from second cache: %v, %t", e, ok)
+		}
+		if _, err := os.Stat(filename + ".lock"); err == nil {
+			t.Errorf("lock file was not removed")
+		}
+		if runtime.GOOS != "windows" {
+			fi, err := os.Stat(filename)
+			if err != nil {
+				t.Fatalf("failed to stat cache: %v", err)
+			}
+			if fi.Mode().Perm() != cacheFilePerms {
+				t.Errorf("unexpected permissions on cache: %s", fi.Mode().Perm().String())
+			}
+		}
+	})
+	t.Run("concurrent", func(t *testing.T) {
+		keys := []string{"c1", "c2", "c3", "c4", "c5"}
+		var wg sync.WaitGroup
+		for _, k := range keys {
+			wg.Add(1)
+			go func(k string) {
+				defer wg.Done()
+				// separate caches simulate separate processes
+				err := NewFileCache(filename).Store(k, CacheEntry{Token: "token-" + k, Expires: time.Now().Add(time.Hour)})
+				if err != nil {
+					t.Errorf("failed to store %s: %v", k, err)
+				}
+			}(k)
+		}
+		wg.Wait()
+		for _, k := range keys {
+			if e, ok := fc.Load(k); !ok || e.Token != "token-"+k {
+				t.Errorf("unexpected entry for %s: %v, %t", k, e, ok)
+			}
+		}
+	})
+	t.Run("stale-lock", func(t *testing.T) {
+		lockName := filename + ".lock"
+		err := os.WriteFile(lockName, []byte{}, 0600)
+		if err != nil {
+			t.Fatalf("failed to create lock: %v", err)
+		}
+		old := time.Now().Add(-2 * cacheLockStale)
+		err = os.Chtimes(lockName, old, old)
+		if err != nil {
+			t.Fatalf("failed to set lock time: %v", err)
+		}
+		err = fc.Store("b", CacheEntry{Token: "token-b", Expires: time.Now().Add(time.Hour)})
+		if err != nil {
+			t.Fatalf("failed to store with stale lock: %v", err)
+		}
+	})
+	t.Run("unsafe-perms", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("permissions are not checked on windows")
+		}
+		err := os.Chmod(filename, 0644)
+		if err != nil {
+			t.Fatalf("failed to chmod: %v", err)
+		}
+		if _, ok := fc.Load("a"); ok {
+			t.Errorf("load succeeded with unsafe permissions")
+		}
+		err = fc.Store("c", CacheEntry{Token: "token-c", Expires: time.Now().Add(time.Hour)})
+		if err != nil {
+			t.Fatalf("failed to store: %v", err)
+		}
+		if _, ok := fc.Load("a"); ok {
+			t.Errorf("entries from an unsafe file were kept")
+		}
+		if e, ok := fc.Load("c"); !ok || e.Token != "token-c" {
+			t.Errorf("unexpected entry: %v, %t", e, ok)
+		}
+	})
+}
+
+func TestBearerCache(t *testing.T) {
+	t.Parallel()
+	useragent := "regclient/test"
+	var mu sync.Mutex
+	tokenReqs := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		tokenReqs++
+		count := tokenReqs
+		mu.Unlock()
+		_ = json.NewEncoder(w).Encode(bearerToken{
+			Token:     "token" + string(rune('0'+count)),
+			ExpiresIn: 900,
+			IssuedAt:  time.Now(),
+		})
+	}))
+	defer ts.Close()
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	fc := NewFileCache(filepath.Join(t.TempDir(), "cache.json"))
+	newBearer := func() *bearerHandler {
+		b := NewBearerHandler(&http.Client{}, useragent, tsHost,
+			func(h string) Cred { return Cred{} },
+			slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})),
+		).(*bearerHandler)
+		b.setCache(fc)
+		return b
+	}
+	c, err := parseAuthHeader(`Bearer realm="` + tsURL.String() + `/tokens",service="test",scope="repository:reponame:pull"`)
+	if err != nil {
+		t.Fatalf("failed to parse challenge: %v", err)
+	}
+
+	b1 := newBearer()
+	if err := b1.ProcessChallenge(c[0]); err != nil {
+		t.Fatalf("failed to process challenge: %v", err)
+	}
+	resp, err := b1.GenerateAuth()
+	if err != nil || resp != "Bearer token1" {
+		t.Fatalf("unexpected first auth: %s, %v", resp, err)
+	}
+	// a second handler, like a second process, reuses the token
+	b2 := newBearer()
+	if err := b2.ProcessChallenge(c[0]); err != nil {
+		t.Fatalf("failed to process challenge: %v", err)
+	}
+	resp, err = b2.GenerateAuth()
+	if err != nil || resp != "Bearer token1" {
+		t.Errorf("token was not loaded from cache: %s, %v", resp, err)
+	}
+	mu.Lock()
+	if tokenReqs != 1 {
+		t.Errorf("unexpected token requests, expected 1, received %d", tokenReqs)
+	}
+	mu.Unlock()
+	// a rejected cached token is replaced
+	if err := b2.ProcessChallenge(c[0]); err != nil {
+		t.Fatalf("rejected token from cache did not allow a new challenge: %v", err)
+	}
+	resp, err = b2.GenerateAuth()
+	if err != nil || resp != "Bearer token2" {
+		t.Errorf("unexpected auth after rejected token: %s, %v", resp, err)
+	}
+	// the new token is saved for the next handler
+	b3 := newBearer()
+	if err := b3.ProcessChallenge(c[0]); err != nil {
+		t.Fatalf("failed to process challenge: %v", err)
+	}
+	resp, err = b3.GenerateAuth()
+	if err != nil || resp != "Bearer token2" {
+		t.Errorf("updated token was not loaded from cache: %s, %v", resp, err)
+	}
+	// a different scope does not use the cached token
+	c2, err := parseAuthHeader(`Bearer realm="` + tsURL.String() + `/tokens",service="test",scope="repository:other:pull"`)
+	if err != nil {
+		t.Fatalf("failed to parse challenge: %v", err)
+	}
+	b4 := newBearer()
+	if err := b4.ProcessChallenge(c2[0]); err != nil {
+		t.Fatalf("failed to process challenge: %v", err)
+	}
+	resp, err = b4.GenerateAuth()
+	if err != nil || resp != "Bearer token3" {
+		t.Errorf("unexpected auth for a different scope: %s, %v", resp, err)
+	}
+}
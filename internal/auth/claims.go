@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/regclient/regclient/types/errs"
+)
+
+// Claims are the fields of a JWT bearer token used to describe the login.
+// The signature of the token is not verified, these are informational only.
+type Claims struct {
+	Subject  string
+	Issuer   string
+	IssuedAt time.Time
+	Expires  time.Time
+	Scopes   []string
+}
+
+type jwtClaims struct {
+	Subject  string      `json:"sub"`
+	Issuer   string      `json:"iss"`
+	IssuedAt json.Number `json:"iat"`
+	Expires  json.Number `json:"exp"`
+	Access   []struct {
+		Type    string   `json:"type"`
+		Name    string   `json:"name"`
+		Actions []string `json:"actions"`
+	} `json:"access"`
+	Scope string `json:"scope"`
+}
+
+// ParseClaims returns the claims from a JWT bearer token.
+// An error is returned for tokens that are not a JWT.
+func ParseClaims(token string) (Claims, error) {
+	c := Claims{}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return c, fmt.Errorf("token is not a JWT%.0w", errs.ErrParsingFailed)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return c, fmt.Errorf("failed to decode JWT payload: %w%.0w", err, errs.ErrParsingFailed)
+	}
+	jc := jwtClaims{}
+	err = json.Unmarshal(payload, &jc)
+	if err != nil {
+		return c, fmt.Errorf("failed to parse JWT payload: %w%.0w", err, errs.ErrParsingFailed)
+	}
+	c.Subject = jc.Subject
+	c.Issuer = jc.Issuer
+	c.IssuedAt = claimTime(jc.IssuedAt)
+	c.Expires = claimTime(jc.Expires)
+	// distribution tokens list each repository in the access claim, OAuth tokens use a space separated scope
+	for _, a := range jc.Access {
+		c.Scopes = append(c.Scopes, a.Type+":"+a.Name+":"+strings.Join(a.Actions, ","))
+	}
+	if jc.Scope != "" {
+		c.Scopes = append(c.Scopes, strings.Fields(jc.Scope)...)
+	}
+	return c, nil
+}
+
+// claimTime converts a JWT NumericDate, seconds since the epoch, to a time.
+func claimTime(n json.Number) time.Time {
+	if n == "" {
+		return time.Time{}
+	}
+	f, err := n.Float64()
+	if err != nil || f <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(f), 0).UTC()
+}
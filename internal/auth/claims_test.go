@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/regclient/regclient/types/errs"
+)
+
+func TestParseClaims(t *testing.T) {
+	t.Parallel()
+	enc := base64.RawURLEncoding
+	header := enc.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	sig := enc.EncodeToString([]byte("signature"))
+	tt := []struct {
+		name      string
+		payload   string
+		expect    Claims
+		expectErr error
+	}{
+		{
+			name:    "distribution",
+			payload: `{"sub":"testuser","iss":"auth.example.org","iat":1716400800,"exp":1716401100,"access":[{"type":"repository","name":"project/repo","actions":["pull","push"]}]}`,
+			expect: Claims{
+				Subject:  "testuser",
+				Issuer:   "auth.example.org",
+				IssuedAt: time.Unix(1716400800, 0).UTC(),
+				Expires:  time.Unix(1716401100, 0).UTC(),
+				Scopes:   []string{"repository:project/repo:pull,push"},
+			},
+		},
+		{
+			name:    "oauth scope",
+			payload: `{"sub":"robot","exp":1716401100.5,"scope":"repository:a:pull repository:b:pull"}`,
+			expect: Claims{
+				Subject: "robot",
+				Expires: time.Unix(1716401100, 0).UTC(),
+				Scopes:  []string{"repository:a:pull", "repository:b:pull"},
+			},
+		},
+		{
+			name:      "invalid json",
+			payload:   `not json`,
+			expectErr: errs.ErrParsingFailed,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := ParseClaims(header + "." + enc.EncodeToString([]byte(tc.payload)) + "." + sig)
+			if tc.expectErr != nil {
+				if !errors.Is(err, tc.expectErr) {
+					t.Errorf("unexpected error, expected %v, received %v", tc.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to parse claims: %v", err)
+			}
+			if c.Subject != tc.expect.Subject || c.Issuer != tc.expect.Issuer || !c.IssuedAt.Equal(tc.expect.IssuedAt) || !c.Expires.Equal(tc.expect.Expires) {
+				t.Errorf("unexpected claims, expected %v, received %v", tc.expect, c)
+			}
+			if len(c.Scopes) != len(tc.expect.Scopes) {
+				t.Fatalf("unexpected scopes, expected %v, received %v", tc.expect.Scopes, c.Scopes)
+			}
+			for i := range c.Scopes {
+				if c.Scopes[i] != tc.expect.Scopes[i] {
+					t.Errorf("unexpected scope %d, expected %s, received %s", i, tc.expect.Scopes[i], c.Scopes[i])
+				}
+			}
+		})
+	}
+	t.Run("opaque", func(t *testing.T) {
+		_, err := ParseClaims("opaque-token")
+		if !errors.Is(err, errs.ErrParsingFailed) {
+			t.Errorf("unexpected error for an opaque token: %v", err)
+		}
+	})
+}
@@ -0,0 +1,194 @@
+// Package cosign creates and verifies signatures compatible with the sigstore cosign key-pair format.
+//
+// A signature is a simple signing payload pushed as a layer of a signature manifest.
+// The base64 encoded signature of the payload is stored in the layer annotations.
+// Private keys may be unencrypted PEM keys or keys encrypted by "cosign generate-key-pair".
+package cosign
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/regclient/regclient/types/errs"
+)
+
+const (
+	// MediaTypeSimpleSigning is the media type of the layer containing the payload.
+	MediaTypeSimpleSigning = "application/vnd.dev.cosign.simplesigning.v1+json"
+	// ArtifactTypeSignature is the artifact type of signatures pushed as referrers.
+	ArtifactTypeSignature = "application/vnd.dev.cosign.artifact.sig.v1+json"
+	// AnnotationSignature contains the base64 encoded signature on each layer.
+	AnnotationSignature = "dev.cosignproject.cosign/signature"
+	// PayloadType is the type of an image signature payload.
+	PayloadType = "cosign container image signature"
+)
+
+// Payload is the simple signing payload of a container image signature.
+type Payload struct {
+	Critical PayloadCritical   `json:"critical"`
+	Optional map[string]string `json:"optional"`
+}
+
+// PayloadCritical contains the signed identity of the image.
+type PayloadCritical struct {
+	Identity PayloadIdentity `json:"identity"`
+	Image    PayloadImage    `json:"image"`
+	Type     string          `json:"type"`
+}
+
+// PayloadIdentity is the repository that was signed.
+type PayloadIdentity struct {
+	DockerReference string `json:"docker-reference"`
+}
+
+// PayloadImage is the digest of the manifest that was signed.
+type PayloadImage struct {
+	DockerManifestDigest digest.Digest `json:"docker-manifest-digest"`
+}
+
+// NewPayload returns the payload bytes to sign a manifest digest in a repository.
+// The optional annotations are included in the signed payload.
+func NewPayload(dockerRef string, d digest.Digest, annotations map[string]string) ([]byte, error) {
+	p := Payload{
+		Critical: PayloadCritical{
+			Identity: PayloadIdentity{DockerReference: dockerRef},
+			Image:    PayloadImage{DockerManifestDigest: d},
+			Type:     PayloadType,
+		},
+		Optional: annotations,
+	}
+	return json.Marshal(p)
+}
+
+// ParsePayload parses and validates the type of a payload.
+func ParsePayload(b []byte) (Payload, error) {
+	p := Payload{}
+	err := json.Unmarshal(b, &p)
+	if err != nil {
+		return p, fmt.Errorf("failed to parse signature payload: %w", err)
+	}
+	if p.Critical.Type != PayloadType {
+		return p, fmt.Errorf("unsupported signature payload type %q%.0w", p.Critical.Type, errs.ErrUnsupported)
+	}
+	return p, nil
+}
+
+// SignatureTag returns the tag used by cosign to store signatures of a manifest, e.g. "sha256-<hex>.sig".
+func SignatureTag(d digest.Digest) string {
+	return fmt.Sprintf("%s-%s.sig", d.Algorithm().String(), d.Encoded())
+}
+
+// LoadPrivateKey parses a PEM encoded ECDSA, RSA, or Ed25519 private key.
+// Encrypted cosign keys are decrypted with the password returned by pass, which is only called for those keys.
+func LoadPrivateKey(b []byte, pass func() ([]byte, error)) (crypto.Signer, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("private key is not PEM encoded%.0w", errs.ErrParsingFailed)
+	}
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "ENCRYPTED SIGSTORE PRIVATE KEY", "ENCRYPTED COSIGN PRIVATE KEY":
+		if pass == nil {
+			return nil, fmt.Errorf("a password is required for an encrypted private key%.0w", errs.ErrMissingInput)
+		}
+		p, err := pass()
+		if err != nil {
+			return nil, err
+		}
+		der, err := decryptKey(block.Bytes, p)
+		if err != nil {
+			return nil, err
+		}
+		key, err = x509.ParsePKCS8PrivateKey(der)
+	default:
+		return nil, fmt.Errorf("unsupported private key type %q%.0w", block.Type, errs.ErrUnsupported)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		return k, nil
+	case *rsa.PrivateKey:
+		return k, nil
+	case ed25519.PrivateKey:
+		return k, nil
+	}
+	return nil, fmt.Errorf("unsupported private key %T%.0w", key, errs.ErrUnsupported)
+}
+
+// LoadPublicKey parses a PEM encoded ECDSA, RSA, or Ed25519 public key, e.g. a "cosign.pub" file.
+func LoadPublicKey(b []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("public key is not PEM encoded%.0w", errs.ErrParsingFailed)
+	}
+	if block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("unsupported public key type %q%.0w", block.Type, errs.ErrUnsupported)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	switch key.(type) {
+	case *ecdsa.PublicKey, *rsa.PublicKey, ed25519.PublicKey:
+		return key, nil
+	}
+	return nil, fmt.Errorf("unsupported public key %T%.0w", key, errs.ErrUnsupported)
+}
+
+// Sign returns the signature of the payload.
+// ECDSA and RSA keys sign the SHA-256 hash of the payload, Ed25519 keys sign the payload directly.
+func Sign(signer crypto.Signer, payload []byte) ([]byte, error) {
+	if _, ok := signer.(ed25519.PrivateKey); ok {
+		return signer.Sign(rand.Reader, payload, crypto.Hash(0))
+	}
+	h := sha256.Sum256(payload)
+	return signer.Sign(rand.Reader, h[:], crypto.SHA256)
+}
+
+// Verify checks the signature of the payload with a public key.
+func Verify(pub crypto.PublicKey, payload, sig []byte) error {
+	h := sha256.Sum256(payload)
+	valid := false
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		valid = ecdsa.VerifyASN1(k, h[:], sig)
+	case *rsa.PublicKey:
+		valid = rsa.VerifyPKCS1v15(k, crypto.SHA256, h[:], sig) == nil
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(k, payload, sig)
+	default:
+		return fmt.Errorf("unsupported public key %T%.0w", pub, errs.ErrUnsupported)
+	}
+	if !valid {
+		return fmt.Errorf("invalid signature%.0w", errs.ErrMismatch)
+	}
+	return nil
+}
+
+// DockerReference returns the identity for a registry and repository, matching the value used by cosign.
+func DockerReference(registry, repository string) string {
+	if registry == "docker.io" {
+		registry = "index.docker.io"
+	}
+	return strings.TrimSuffix(registry+"/"+repository, "/")
+}
@@ -0,0 +1,125 @@
+package cosign
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/regclient/regclient/types/errs"
+)
+
+func TestPayload(t *testing.T) {
+	t.Parallel()
+	d := digest.FromString("test")
+	b, err := NewPayload("registry.example.org/repo", d, nil)
+	if err != nil {
+		t.Fatalf("failed to create payload: %v", err)
+	}
+	expect := `{"critical":{"identity":{"docker-reference":"registry.example.org/repo"},"image":{"docker-manifest-digest":"` + d.String() + `"},"type":"cosign container image signature"},"optional":null}`
+	if string(b) != expect {
+		t.Errorf("unexpected payload, expected %s, received %s", expect, string(b))
+	}
+	p, err := ParsePayload(b)
+	if err != nil {
+		t.Fatalf("failed to parse payload: %v", err)
+	}
+	if p.Critical.Image.DockerManifestDigest != d {
+		t.Errorf("unexpected digest, expected %s, received %s", d, p.Critical.Image.DockerManifestDigest)
+	}
+	_, err = ParsePayload([]byte(`{"critical":{"type":"unknown"}}`))
+	if !errors.Is(err, errs.ErrUnsupported) {
+		t.Errorf("unexpected error for unknown type: %v", err)
+	}
+	if tag := SignatureTag(d); tag != "sha256-"+d.Encoded()+".sig" {
+		t.Errorf("unexpected tag: %s", tag)
+	}
+	if ref := DockerReference("docker.io", "library/alpine"); ref != "index.docker.io/library/alpine" {
+		t.Errorf("unexpected docker reference: %s", ref)
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	t.Parallel()
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	payload, err := NewPayload("registry.example.org/repo", digest.FromString("test"), map[string]string{"env": "test"})
+	if err != nil {
+		t.Fatalf("failed to create payload: %v", err)
+	}
+	tt := []struct {
+		name string
+		key  crypto.Signer
+	}{
+		{name: "ecdsa", key: ecKey},
+		{name: "rsa", key: rsaKey},
+		{name: "ed25519", key: edKey},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			privDer, err := x509.MarshalPKCS8PrivateKey(tc.key)
+			if err != nil {
+				t.Fatalf("failed to marshal private key: %v", err)
+			}
+			pubDer, err := x509.MarshalPKIXPublicKey(tc.key.Public())
+			if err != nil {
+				t.Fatalf("failed to marshal public key: %v", err)
+			}
+			signer, err := LoadPrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDer}), nil)
+			if err != nil {
+				t.Fatalf("failed to load private key: %v", err)
+			}
+			pub, err := LoadPublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDer}))
+			if err != nil {
+				t.Fatalf("failed to load public key: %v", err)
+			}
+			sig, err := Sign(signer, payload)
+			if err != nil {
+				t.Fatalf("failed to sign: %v", err)
+			}
+			err = Verify(pub, payload, sig)
+			if err != nil {
+				t.Errorf("failed to verify: %v", err)
+			}
+			err = Verify(pub, append(payload, ' '), sig)
+			if !errors.Is(err, errs.ErrMismatch) {
+				t.Errorf("modified payload did not fail verification: %v", err)
+			}
+		})
+	}
+	t.Run("encrypted", func(t *testing.T) {
+		pass := func() ([]byte, error) { return []byte("password"), nil }
+		_, err := LoadPrivateKey(pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED SIGSTORE PRIVATE KEY", Bytes: []byte("{}")}), pass)
+		if !errors.Is(err, errs.ErrUnsupported) {
+			t.Errorf("unexpected error for encrypted key: %v", err)
+		}
+	})
+	t.Run("wrong key", func(t *testing.T) {
+		sig, err := Sign(ecKey, payload)
+		if err != nil {
+			t.Fatalf("failed to sign: %v", err)
+		}
+		err = Verify(rsaKey.Public(), payload, sig)
+		if !errors.Is(err, errs.ErrMismatch) {
+			t.Errorf("wrong key did not fail verification: %v", err)
+		}
+	})
+}
@@ -0,0 +1,280 @@
+package cosign
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"math/bits"
+
+	"github.com/regclient/regclient/types/errs"
+)
+
+// Encrypted cosign keys are a JSON document with the scrypt parameters and nacl/secretbox ciphertext of a PKCS#8 key.
+// The key derivation and cipher are implemented here to avoid a dependency on golang.org/x/crypto.
+const (
+	encKDFScrypt     = "scrypt"
+	encCipherSecbox  = "nacl/secretbox"
+	encKeyLen        = 32
+	encNonceLen      = 24
+	encTagLen        = 16
+	scryptMaxMem     = 1 << 30
+	scryptMaxFactors = 1 << 30
+)
+
+type encryptedKey struct {
+	KDF struct {
+		Name   string `json:"name"`
+		Params struct {
+			N int `json:"N"`
+			R int `json:"r"`
+			P int `json:"p"`
+		} `json:"params"`
+		Salt []byte `json:"salt"`
+	} `json:"kdf"`
+	Cipher struct {
+		Name  string `json:"name"`
+		Nonce []byte `json:"nonce"`
+	} `json:"cipher"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// decryptKey returns the PKCS#8 DER key from the contents of an encrypted cosign key.
+func decryptKey(b, pass []byte) ([]byte, error) {
+	ek := encryptedKey{}
+	err := json.Unmarshal(b, &ek)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse encrypted private key: %w", err)
+	}
+	if ek.KDF.Name != encKDFScrypt {
+		return nil, fmt.Errorf("unsupported key derivation %q%.0w", ek.KDF.Name, errs.ErrUnsupported)
+	}
+	if ek.Cipher.Name != encCipherSecbox {
+		return nil, fmt.Errorf("unsupported cipher %q%.0w", ek.Cipher.Name, errs.ErrUnsupported)
+	}
+	if len(ek.Cipher.Nonce) != encNonceLen {
+		return nil, fmt.Errorf("invalid nonce length %d%.0w", len(ek.Cipher.Nonce), errs.ErrParsingFailed)
+	}
+	key, err := scryptKey(pass, ek.KDF.Salt, ek.KDF.Params.N, ek.KDF.Params.R, ek.KDF.Params.P, encKeyLen)
+	if err != nil {
+		return nil, err
+	}
+	out, ok := secretboxOpen(ek.Ciphertext, ek.Cipher.Nonce, key)
+	if !ok {
+		return nil, fmt.Errorf("failed to decrypt private key, verify the password%.0w", errs.ErrMismatch)
+	}
+	return out, nil
+}
+
+// scryptKey derives a key from a password with scrypt, see RFC 7914.
+func scryptKey(pass, salt []byte, n, r, p, keyLen int) ([]byte, error) {
+	if n <= 1 || n&(n-1) != 0 {
+		return nil, fmt.Errorf("scrypt N must be a power of 2 greater than 1%.0w", errs.ErrParsingFailed)
+	}
+	if r <= 0 || p <= 0 || uint64(r)*uint64(p) >= scryptMaxFactors {
+		return nil, fmt.Errorf("scrypt parameters r=%d p=%d are invalid%.0w", r, p, errs.ErrParsingFailed)
+	}
+	if uint64(n)*uint64(r)*128 > scryptMaxMem {
+		return nil, fmt.Errorf("scrypt parameters N=%d r=%d exceed the memory limit%.0w", n, r, errs.ErrUnsupported)
+	}
+	blockLen := 128 * r
+	b := pbkdf2SHA256(pass, salt, p*blockLen)
+	x := make([]uint32, 32*r)
+	y := make([]uint32, 32*r)
+	v := make([]uint32, 32*r*n)
+	for i := 0; i < p; i++ {
+		scryptROMix(b[i*blockLen:(i+1)*blockLen], x, y, v, n, r)
+	}
+	return pbkdf2SHA256(pass, b, keyLen), nil
+}
+
+// scryptROMix mixes a block in place, using x and y as scratch space and v for the N copies of the block.
+func scryptROMix(b []byte, x, y, v []uint32, n, r int) {
+	words := 32 * r
+	for i := range x {
+		x[i] = binary.LittleEndian.Uint32(b[i*4:])
+	}
+	for i := 0; i < n; i++ {
+		copy(v[i*words:], x)
+		scryptBlockMix(x, y, r)
+	}
+	for i := 0; i < n; i++ {
+		// integerify uses the first word of the last block, n is limited well below 2^32
+		j := int(x[(2*r-1)*16] & uint32(n-1))
+		for k, w := range v[j*words : (j+1)*words] {
+			x[k] ^= w
+		}
+		scryptBlockMix(x, y, r)
+	}
+	for i, w := range x {
+		binary.LittleEndian.PutUint32(b[i*4:], w)
+	}
+}
+
+// scryptBlockMix runs salsa20/8 over the 2r 64-byte blocks of b, using y as scratch space.
+func scryptBlockMix(b, y []uint32, r int) {
+	var t [16]uint32
+	copy(t[:], b[(2*r-1)*16:])
+	for i := 0; i < 2*r; i++ {
+		for k := range t {
+			t[k] ^= b[i*16+k]
+		}
+		salsaCore(&t, &t, 8)
+		// even blocks are placed in the first half, odd blocks in the second half
+		copy(y[((i&1)*r+i/2)*16:], t[:])
+	}
+	copy(b, y)
+}
+
+// pbkdf2SHA256 is PBKDF2 with HMAC-SHA256 and the single iteration used by scrypt.
+func pbkdf2SHA256(pass, salt []byte, keyLen int) []byte {
+	mac := hmac.New(sha256.New, pass)
+	out := make([]byte, 0, keyLen+sha256.Size)
+	var ctr [4]byte
+	for i := uint32(1); len(out) < keyLen; i++ {
+		binary.BigEndian.PutUint32(ctr[:], i)
+		mac.Reset()
+		mac.Write(salt)
+		mac.Write(ctr[:])
+		out = mac.Sum(out)
+	}
+	return out[:keyLen]
+}
+
+// secretboxOpen authenticates and decrypts a nacl/secretbox message, the 16 byte tag followed by the ciphertext.
+func secretboxOpen(box, nonce, key []byte) ([]byte, bool) {
+	if len(box) < encTagLen || len(nonce) != encNonceLen || len(key) != encKeyLen {
+		return nil, false
+	}
+	// the first 32 bytes of the xsalsa20 stream are the poly1305 key, the remainder encrypts the message
+	stream := xsalsa20Stream(key, nonce, 32+len(box)-encTagLen)
+	tag := poly1305Sum(box[encTagLen:], stream[:32])
+	if subtle.ConstantTimeCompare(tag, box[:encTagLen]) != 1 {
+		return nil, false
+	}
+	out := make([]byte, len(box)-encTagLen)
+	subtle.XORBytes(out, box[encTagLen:], stream[32:])
+	return out, true
+}
+
+// salsaSigma is the "expand 32-byte k" constant.
+var salsaSigma = [4]uint32{0x61707865, 0x3320646e, 0x79622d32, 0x6b206574}
+
+// salsaState returns the salsa20 input for a 32 byte key and 16 bytes of nonce and counter.
+func salsaState(key, in []byte) [16]uint32 {
+	var s [16]uint32
+	s[0], s[5], s[10], s[15] = salsaSigma[0], salsaSigma[1], salsaSigma[2], salsaSigma[3]
+	for i := 0; i < 4; i++ {
+		s[1+i] = binary.LittleEndian.Uint32(key[i*4:])
+		s[11+i] = binary.LittleEndian.Uint32(key[16+i*4:])
+		s[6+i] = binary.LittleEndian.Uint32(in[i*4:])
+	}
+	return s
+}
+
+// xsalsa20Stream returns the key stream for a 32 byte key and 24 byte nonce.
+func xsalsa20Stream(key, nonce []byte, l int) []byte {
+	// hsalsa20 derives a subkey from the first 16 bytes of the nonce
+	s := salsaState(key, nonce[:16])
+	var h [16]uint32
+	salsaRounds(&h, &s, 20)
+	subKey := make([]byte, 32)
+	for i, w := range []uint32{h[0], h[5], h[10], h[15], h[6], h[7], h[8], h[9]} {
+		binary.LittleEndian.PutUint32(subKey[i*4:], w)
+	}
+	in := make([]byte, 16)
+	copy(in, nonce[16:])
+	out := make([]byte, 0, l+64)
+	var block [16]uint32
+	for ctr := uint64(0); len(out) < l; ctr++ {
+		binary.LittleEndian.PutUint64(in[8:], ctr)
+		s = salsaState(subKey, in)
+		salsaCore(&block, &s, 20)
+		for _, w := range block {
+			out = binary.LittleEndian.AppendUint32(out, w)
+		}
+	}
+	return out[:l]
+}
+
+// salsaCore is the salsa20 hash function, the rounds added to the input.
+func salsaCore(out, in *[16]uint32, rounds int) {
+	var x [16]uint32
+	salsaRounds(&x, in, rounds)
+	for i := range x {
+		out[i] = x[i] + in[i]
+	}
+}
+
+// salsaRounds applies the salsa20 double rounds to the input without the final addition.
+func salsaRounds(out, in *[16]uint32, rounds int) {
+	x := *in
+	qr := func(a, b, c, d int) {
+		x[b] ^= bits.RotateLeft32(x[a]+x[d], 7)
+		x[c] ^= bits.RotateLeft32(x[b]+x[a], 9)
+		x[d] ^= bits.RotateLeft32(x[c]+x[b], 13)
+		x[a] ^= bits.RotateLeft32(x[d]+x[c], 18)
+	}
+	for i := 0; i < rounds; i += 2 {
+		// column round
+		qr(0, 4, 8, 12)
+		qr(5, 9, 13, 1)
+		qr(10, 14, 2, 6)
+		qr(15, 3, 7, 11)
+		// row round
+		qr(0, 1, 2, 3)
+		qr(5, 6, 7, 4)
+		qr(10, 11, 8, 9)
+		qr(15, 12, 13, 14)
+	}
+	*out = x
+}
+
+// poly1305P is the poly1305 prime, 2^130-5.
+var poly1305P = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 130), big.NewInt(5))
+
+// poly1305Sum returns the poly1305 tag of a message with a one-time 32 byte key.
+// The messages are small keys, so the arithmetic is done with big integers rather than optimized limbs.
+func poly1305Sum(msg, key []byte) []byte {
+	rb := make([]byte, 16)
+	copy(rb, key[:16])
+	rb[3] &= 15
+	rb[7] &= 15
+	rb[11] &= 15
+	rb[15] &= 15
+	rb[4] &= 252
+	rb[8] &= 252
+	rb[12] &= 252
+	r := leInt(rb)
+	acc := new(big.Int)
+	for len(msg) > 0 {
+		l := min(len(msg), 16)
+		chunk := make([]byte, l+1)
+		copy(chunk, msg[:l])
+		chunk[l] = 1
+		acc.Add(acc, leInt(chunk))
+		acc.Mul(acc, r)
+		acc.Mod(acc, poly1305P)
+		msg = msg[l:]
+	}
+	acc.Add(acc, leInt(key[16:32]))
+	be := acc.Bytes()
+	tag := make([]byte, 16)
+	// the tag is the low 128 bits in little endian
+	for i := 0; i < 16 && i < len(be); i++ {
+		tag[i] = be[len(be)-1-i]
+	}
+	return tag
+}
+
+// leInt converts little endian bytes to a big integer.
+func leInt(b []byte) *big.Int {
+	be := make([]byte, len(b))
+	for i := range b {
+		be[len(b)-1-i] = b[i]
+	}
+	return new(big.Int).SetBytes(be)
+}
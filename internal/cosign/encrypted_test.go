@@ -0,0 +1,149 @@
+package cosign
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"testing"
+
+	"github.com/regclient/regclient/types/errs"
+)
+
+func TestScrypt(t *testing.T) {
+	t.Parallel()
+	// test vectors from RFC 7914
+	tt := []struct {
+		name    string
+		pass    string
+		salt    string
+		n, r, p int
+		expect  string
+	}{
+		{
+			name:   "empty",
+			n:      16,
+			r:      1,
+			p:      1,
+			expect: "77d6576238657b203b19ca42c18a0497f16b4844e3074ae8dfdffa3fede21442fcd0069ded0948f8326a753a0fc81f17e8d3e0fb2e0d3628cf35e20c38d18906",
+		},
+		{
+			name:   "password",
+			pass:   "password",
+			salt:   "NaCl",
+			n:      1024,
+			r:      8,
+			p:      16,
+			expect: "fdbabe1c9d3472007856e7190d01e9fe7c6ad7cbc8237830e77376634b3731622eaf30d92e22a3886ff109279d9830dac727afb94a83ee6d8360cbdfa2cc0640",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := scryptKey([]byte(tc.pass), []byte(tc.salt), tc.n, tc.r, tc.p, 64)
+			if err != nil {
+				t.Fatalf("failed to derive key: %v", err)
+			}
+			if hex.EncodeToString(out) != tc.expect {
+				t.Errorf("unexpected key: %x", out)
+			}
+		})
+	}
+	t.Run("invalid", func(t *testing.T) {
+		_, err := scryptKey([]byte("password"), []byte("salt"), 1000, 8, 1, 32)
+		if !errors.Is(err, errs.ErrParsingFailed) {
+			t.Errorf("N that is not a power of 2 did not fail: %v", err)
+		}
+		_, err = scryptKey([]byte("password"), []byte("salt"), 1<<24, 8, 1, 32)
+		if !errors.Is(err, errs.ErrUnsupported) {
+			t.Errorf("N exceeding the memory limit did not fail: %v", err)
+		}
+	})
+}
+
+func TestPoly1305(t *testing.T) {
+	t.Parallel()
+	// test vector from RFC 8439 section 2.5.2
+	key, _ := hex.DecodeString("85d6be7857556d337f4452fe42d506a80103808afb0db2fd4abff6af4149f51b")
+	tag := poly1305Sum([]byte("Cryptographic Forum Research Group"), key)
+	if hex.EncodeToString(tag) != "a8061dc1305136c6c22b8baf0c0127a9" {
+		t.Errorf("unexpected tag: %x", tag)
+	}
+}
+
+func TestSecretbox(t *testing.T) {
+	t.Parallel()
+	// test vector from the NaCl secretbox tests
+	key, _ := hex.DecodeString("1b27556473e985d462cd51197a9a46c76009549eac6474f206c4ee0844f68389")
+	nonce, _ := hex.DecodeString("69696ee955b62b73cd62bda875fc73d68219e0036b7a0b37")
+	msg, _ := hex.DecodeString("be075fc53c81f2d5cf141316ebeb0c7b5228c52a4c62cbd44b66849b64244ffce5ecbaaf33bd751a1ac728d45e6c61296cdc3c01233561f41db66cce314adb310e3be8250c46f06dceea3a7fa1348057e2f6556ad6b1318a024a838f21af1fde048977eb48f59ffd4924ca1c60902e52f0a089bc76897040e082f937763848645e0705")
+	box, _ := hex.DecodeString("f3ffc7703f9400e52a7dfb4b3d3305d98e993b9f48681273c29650ba32fc76ce48332ea7164d96a4476fb8c531a1186ac0dfc17c98dce87b4da7f011ec48c97271d2c20f9b928fe2270d6fb863d51738b48eeee314a7cc8ab932164548e526ae90224368517acfeabd6bb3732bc0e9da99832b61ca01b6de56244a9e88d5f9b37973f622a43d14a6599b1f654cb45a74e355a5")
+	out, ok := secretboxOpen(box, nonce, key)
+	if !ok {
+		t.Fatalf("failed to open box")
+	}
+	if !bytes.Equal(out, msg) {
+		t.Errorf("unexpected message: %x", out)
+	}
+	box[len(box)-1] ^= 1
+	_, ok = secretboxOpen(box, nonce, key)
+	if ok {
+		t.Errorf("modified box was opened")
+	}
+}
+
+func TestLoadEncryptedKey(t *testing.T) {
+	t.Parallel()
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	// encrypt the key the same way as cosign, with a smaller N to keep the test fast
+	ek := encryptedKey{}
+	ek.KDF.Name = encKDFScrypt
+	ek.KDF.Params.N, ek.KDF.Params.R, ek.KDF.Params.P = 1024, 8, 1
+	ek.KDF.Salt = []byte("0123456789abcdef0123456789abcdef")
+	ek.Cipher.Name = encCipherSecbox
+	ek.Cipher.Nonce = []byte("0123456789abcdef01234567")
+	secret, err := scryptKey([]byte("hunter2"), ek.KDF.Salt, ek.KDF.Params.N, ek.KDF.Params.R, ek.KDF.Params.P, encKeyLen)
+	if err != nil {
+		t.Fatalf("failed to derive key: %v", err)
+	}
+	stream := xsalsa20Stream(secret, ek.Cipher.Nonce, 32+len(der))
+	ct := make([]byte, len(der))
+	subtle.XORBytes(ct, der, stream[32:])
+	ek.Ciphertext = append(poly1305Sum(ct, stream[:32]), ct...)
+	ekJSON, err := json.Marshal(ek)
+	if err != nil {
+		t.Fatalf("failed to marshal encrypted key: %v", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED SIGSTORE PRIVATE KEY", Bytes: ekJSON})
+	passFn := func(p string) func() ([]byte, error) {
+		return func() ([]byte, error) { return []byte(p), nil }
+	}
+
+	signer, err := LoadPrivateKey(keyPEM, passFn("hunter2"))
+	if err != nil {
+		t.Fatalf("failed to load encrypted key: %v", err)
+	}
+	if !ecKey.Equal(signer) {
+		t.Errorf("decrypted key does not match")
+	}
+	_, err = LoadPrivateKey(keyPEM, passFn("wrong"))
+	if !errors.Is(err, errs.ErrMismatch) {
+		t.Errorf("wrong password did not fail: %v", err)
+	}
+	_, err = LoadPrivateKey(keyPEM, nil)
+	if !errors.Is(err, errs.ErrMissingInput) {
+		t.Errorf("missing password did not fail: %v", err)
+	}
+}
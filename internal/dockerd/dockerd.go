@@ -0,0 +1,147 @@
+// Package dockerd is a minimal client for the Docker Engine API used to save and load images
+package dockerd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/regclient/regclient/types/errs"
+)
+
+const (
+	// EnvHost is the environment variable used by docker to set the engine socket.
+	EnvHost = "DOCKER_HOST"
+	// DefaultHost is the engine socket used when DOCKER_HOST is not set.
+	DefaultHost = "unix:///var/run/docker.sock"
+)
+
+// Client connects to a Docker engine.
+type Client struct {
+	base   *url.URL
+	client *http.Client
+}
+
+type apiError struct {
+	Message string `json:"message"`
+}
+
+type loadMessage struct {
+	Stream      string `json:"stream"`
+	Error       string `json:"error"`
+	ErrorDetail struct {
+		Message string `json:"message"`
+	} `json:"errorDetail"`
+}
+
+// New returns a client for the engine at host, e.g. "unix:///var/run/docker.sock" or "tcp://127.0.0.1:2375".
+// When host is empty, DOCKER_HOST or [DefaultHost] is used.
+func New(host string) (*Client, error) {
+	if host == "" {
+		host = os.Getenv(EnvHost)
+	}
+	if host == "" {
+		host = DefaultHost
+	}
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse docker host %s: %w", host, err)
+	}
+	c := Client{
+		base: &url.URL{Scheme: "http", Host: u.Host},
+	}
+	switch u.Scheme {
+	case "unix":
+		sock := u.Path
+		c.base.Host = "docker"
+		c.client = &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", sock)
+				},
+			},
+		}
+	case "tcp", "http":
+		c.client = &http.Client{}
+	default:
+		return nil, fmt.Errorf("unsupported docker host %s%.0w", host, errs.ErrUnsupported)
+	}
+	return &c, nil
+}
+
+// ImageSave returns a docker save tar of the named image.
+// The caller must close the returned reader.
+func (c *Client) ImageSave(ctx context.Context, name string) (io.ReadCloser, error) {
+	u := c.base.JoinPath("images", "get")
+	u.RawQuery = url.Values{"names": []string{name}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save image %s: %w", name, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, fmt.Errorf("failed to save image %s: %w", name, respError(resp))
+	}
+	return resp.Body, nil
+}
+
+// ImageLoad sends a docker save or OCI Layout tar to the engine.
+func (c *Client) ImageLoad(ctx context.Context, rdr io.Reader) error {
+	u := c.base.JoinPath("images", "load")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), rdr)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-tar")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to load image: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to load image: %w", respError(resp))
+	}
+	// the engine reports failures in the stream of progress messages
+	dec := json.NewDecoder(resp.Body)
+	for {
+		msg := loadMessage{}
+		err = dec.Decode(&msg)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to parse load response: %w", err)
+		}
+		if msg.ErrorDetail.Message != "" {
+			return fmt.Errorf("failed to load image: %s", msg.ErrorDetail.Message)
+		}
+		if msg.Error != "" {
+			return fmt.Errorf("failed to load image: %s", msg.Error)
+		}
+	}
+}
+
+// respError converts an engine error response to an error.
+func respError(resp *http.Response) error {
+	ae := apiError{}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err := json.Unmarshal(body, &ae); err != nil || ae.Message == "" {
+		ae.Message = strings.TrimSpace(string(body))
+	}
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return fmt.Errorf("%s%.0w", ae.Message, errs.ErrNotFound)
+	default:
+		return fmt.Errorf("%s [http %d]", ae.Message, resp.StatusCode)
+	}
+}
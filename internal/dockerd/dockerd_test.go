@@ -0,0 +1,106 @@
+package dockerd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/regclient/regclient/types/errs"
+)
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	saveBody := []byte("tar content")
+	mux := http.NewServeMux()
+	mux.HandleFunc("/images/get", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		switch r.URL.Query().Get("names") {
+		case "docker.io/library/alpine:latest":
+			_, _ = w.Write(saveBody)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"reference does not exist"}`))
+		}
+	})
+	mux.HandleFunc("/images/load", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/x-tar" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) == "bad" {
+			_, _ = w.Write([]byte(`{"errorDetail":{"message":"invalid tar"},"error":"invalid tar"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"stream":"Loaded image: alpine:latest\n"}`))
+	})
+	sockFile := filepath.Join(t.TempDir(), "docker.sock")
+	lis, err := net.Listen("unix", sockFile)
+	if err != nil {
+		t.Fatalf("failed to listen on socket: %v", err)
+	}
+	tsUnix := httptest.NewUnstartedServer(mux)
+	tsUnix.Listener = lis
+	tsUnix.Start()
+	t.Cleanup(tsUnix.Close)
+	tsTCP := httptest.NewServer(mux)
+	t.Cleanup(tsTCP.Close)
+
+	_, err = New("ssh://example.com")
+	if !errors.Is(err, errs.ErrUnsupported) {
+		t.Errorf("unexpected error for unsupported host: %v", err)
+	}
+
+	tt := []struct {
+		name string
+		host string
+	}{
+		{
+			name: "unix",
+			host: "unix://" + sockFile,
+		},
+		{
+			name: "tcp",
+			host: "tcp://" + tsTCP.Listener.Addr().String(),
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := New(tc.host)
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+			rdr, err := c.ImageSave(ctx, "docker.io/library/alpine:latest")
+			if err != nil {
+				t.Fatalf("failed to save: %v", err)
+			}
+			body, err := io.ReadAll(rdr)
+			_ = rdr.Close()
+			if err != nil || !bytes.Equal(body, saveBody) {
+				t.Errorf("unexpected save body: %s, %v", body, err)
+			}
+			_, err = c.ImageSave(ctx, "docker.io/library/missing:latest")
+			if !errors.Is(err, errs.ErrNotFound) {
+				t.Errorf("unexpected error for missing image: %v", err)
+			}
+			err = c.ImageLoad(ctx, bytes.NewReader([]byte("bad")))
+			if err == nil {
+				t.Errorf("load of invalid content did not fail")
+			}
+			err = c.ImageLoad(ctx, bytes.NewReader(saveBody))
+			if err != nil {
+				t.Errorf("failed to load: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+// Package intoto creates and parses in-toto attestation statements and DSSE envelopes.
+//
+// Statements are pushed to a registry as referrers of the image they describe.
+// A signed statement is wrapped in a DSSE envelope using the keys supported by the cosign package.
+package intoto
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/regclient/regclient/internal/cosign"
+	"github.com/regclient/regclient/types/errs"
+)
+
+const (
+	// StatementType is the type of an in-toto v1 statement.
+	StatementType = "https://in-toto.io/Statement/v1"
+	// MediaTypeStatement is the media type of an unsigned statement.
+	MediaTypeStatement = "application/vnd.in-toto+json"
+	// MediaTypeDSSE is the media type of a DSSE envelope containing a signed statement.
+	MediaTypeDSSE = "application/vnd.dsse.envelope.v1+json"
+	// AnnotationPredicateType contains the predicate type of the statement.
+	AnnotationPredicateType = "in-toto.io/predicate-type"
+)
+
+// PredicateTypes maps short names to commonly used predicate types.
+var PredicateTypes = map[string]string{
+	"custom":          "https://cosign.sigstore.dev/attestation/v1",
+	"cyclonedx":       "https://cyclonedx.org/bom",
+	"slsaprovenance":  "https://slsa.dev/provenance/v0.2",
+	"slsaprovenance1": "https://slsa.dev/provenance/v1",
+	"spdx":            "https://spdx.dev/Document",
+	"spdxjson":        "https://spdx.dev/Document",
+	"vuln":            "https://cosign.sigstore.dev/attestation/vuln/v1",
+}
+
+// Statement is an in-toto attestation statement.
+type Statement struct {
+	Type          string          `json:"_type"`
+	Subject       []Subject       `json:"subject"`
+	PredicateType string          `json:"predicateType"`
+	Predicate     json.RawMessage `json:"predicate"`
+}
+
+// Subject is an artifact described by the statement.
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// Envelope is a DSSE envelope.
+// The payload and signatures are base64 encoded in the json.
+type Envelope struct {
+	PayloadType string      `json:"payloadType"`
+	Payload     []byte      `json:"payload"`
+	Signatures  []Signature `json:"signatures"`
+}
+
+// Signature is a single signature in a DSSE envelope.
+type Signature struct {
+	KeyID string `json:"keyid"`
+	Sig   []byte `json:"sig"`
+}
+
+// NewStatement returns a statement for a manifest digest with the provided predicate.
+// The predicate must be valid json.
+func NewStatement(name string, d digest.Digest, predicateType string, predicate []byte) ([]byte, error) {
+	if !json.Valid(predicate) {
+		return nil, fmt.Errorf("predicate is not valid json%.0w", errs.ErrParsingFailed)
+	}
+	s := Statement{
+		Type: StatementType,
+		Subject: []Subject{
+			{Name: name, Digest: map[string]string{d.Algorithm().String(): d.Encoded()}},
+		},
+		PredicateType: predicateType,
+		Predicate:     json.RawMessage(bytes.TrimSpace(predicate)),
+	}
+	return json.Marshal(s)
+}
+
+// ParseStatement parses and validates the type of a statement.
+func ParseStatement(b []byte) (Statement, error) {
+	s := Statement{}
+	err := json.Unmarshal(b, &s)
+	if err != nil {
+		return s, fmt.Errorf("failed to parse statement: %w", err)
+	}
+	if !strings.HasPrefix(s.Type, "https://in-toto.io/Statement/") {
+		return s, fmt.Errorf("unsupported statement type %q%.0w", s.Type, errs.ErrUnsupported)
+	}
+	return s, nil
+}
+
+// HasSubject returns true if the digest is one of the subjects of the statement.
+func (s Statement) HasSubject(d digest.Digest) bool {
+	for _, subj := range s.Subject {
+		if subj.Digest[d.Algorithm().String()] == d.Encoded() {
+			return true
+		}
+	}
+	return false
+}
+
+// Sign returns a DSSE envelope containing the signed statement.
+func Sign(signer crypto.Signer, statement []byte) ([]byte, error) {
+	sig, err := cosign.Sign(signer, PAE(MediaTypeStatement, statement))
+	if err != nil {
+		return nil, err
+	}
+	env := Envelope{
+		PayloadType: MediaTypeStatement,
+		Payload:     statement,
+		Signatures:  []Signature{{Sig: sig}},
+	}
+	return json.Marshal(env)
+}
+
+// ParseEnvelope parses a DSSE envelope.
+func ParseEnvelope(b []byte) (Envelope, error) {
+	env := Envelope{}
+	err := json.Unmarshal(b, &env)
+	if err != nil {
+		return env, fmt.Errorf("failed to parse envelope: %w", err)
+	}
+	if env.PayloadType != MediaTypeStatement {
+		return env, fmt.Errorf("unsupported envelope payload type %q%.0w", env.PayloadType, errs.ErrUnsupported)
+	}
+	return env, nil
+}
+
+// Verify returns nil when any signature in the envelope is valid for the public key.
+func (env Envelope) Verify(pub crypto.PublicKey) error {
+	if len(env.Signatures) == 0 {
+		return fmt.Errorf("envelope is not signed%.0w", errs.ErrNotFound)
+	}
+	pae := PAE(env.PayloadType, env.Payload)
+	var errList []error
+	for _, sig := range env.Signatures {
+		err := cosign.Verify(pub, pae, sig.Sig)
+		if err == nil {
+			return nil
+		}
+		errList = append(errList, err)
+	}
+	return errors.Join(errList...)
+}
+
+// PAE returns the DSSE pre-authentication encoding of a payload, which is the value that is signed.
+func PAE(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload))
+}
@@ -0,0 +1,87 @@
+package intoto
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/regclient/regclient/types/errs"
+)
+
+func TestStatement(t *testing.T) {
+	t.Parallel()
+	d := digest.FromString("test")
+	b, err := NewStatement("registry.example.org/repo", d, PredicateTypes["slsaprovenance1"], []byte(`{"buildDefinition": {}}`+"\n"))
+	if err != nil {
+		t.Fatalf("failed to create statement: %v", err)
+	}
+	expect := `{"_type":"https://in-toto.io/Statement/v1","subject":[{"name":"registry.example.org/repo","digest":{"sha256":"` + d.Encoded() + `"}}],"predicateType":"https://slsa.dev/provenance/v1","predicate":{"buildDefinition":{}}}`
+	if string(b) != expect {
+		t.Errorf("unexpected statement, expected %s, received %s", expect, string(b))
+	}
+	s, err := ParseStatement(b)
+	if err != nil {
+		t.Fatalf("failed to parse statement: %v", err)
+	}
+	if !s.HasSubject(d) {
+		t.Errorf("subject not found")
+	}
+	if s.HasSubject(digest.FromString("other")) {
+		t.Errorf("unexpected subject match")
+	}
+	_, err = NewStatement("registry.example.org/repo", d, "https://example.com/test", []byte(`not json`))
+	if !errors.Is(err, errs.ErrParsingFailed) {
+		t.Errorf("unexpected error for invalid predicate: %v", err)
+	}
+	_, err = ParseStatement([]byte(`{"_type":"unknown"}`))
+	if !errors.Is(err, errs.ErrUnsupported) {
+		t.Errorf("unexpected error for unknown type: %v", err)
+	}
+}
+
+func TestEnvelope(t *testing.T) {
+	t.Parallel()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	statement, err := NewStatement("registry.example.org/repo", digest.FromString("test"), "https://example.com/test", []byte(`{}`))
+	if err != nil {
+		t.Fatalf("failed to create statement: %v", err)
+	}
+	b, err := Sign(key, statement)
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	env, err := ParseEnvelope(b)
+	if err != nil {
+		t.Fatalf("failed to parse envelope: %v", err)
+	}
+	if string(env.Payload) != string(statement) {
+		t.Errorf("unexpected payload, expected %s, received %s", statement, env.Payload)
+	}
+	err = env.Verify(key.Public())
+	if err != nil {
+		t.Errorf("failed to verify: %v", err)
+	}
+	err = env.Verify(otherKey.Public())
+	if !errors.Is(err, errs.ErrMismatch) {
+		t.Errorf("wrong key did not fail verification: %v", err)
+	}
+	env.Signatures = nil
+	err = env.Verify(key.Public())
+	if !errors.Is(err, errs.ErrNotFound) {
+		t.Errorf("unsigned envelope did not fail verification: %v", err)
+	}
+	if pae := string(PAE("type", []byte("hi"))); pae != "DSSEv1 4 type 2 hi" {
+		t.Errorf("unexpected PAE: %s", pae)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"math/rand"
 	"net/http"
 	"net/url"
 	"os"
@@ -30,34 +31,44 @@ import (
 	"github.com/regclient/regclient/internal/auth"
 	"github.com/regclient/regclient/internal/pqueue"
 	"github.com/regclient/regclient/internal/reqmeta"
+	"github.com/regclient/regclient/pkg/metrics"
 	"github.com/regclient/regclient/types"
 	"github.com/regclient/regclient/types/errs"
+	"github.com/regclient/regclient/types/ratelimit"
 	"github.com/regclient/regclient/types/warning"
 )
 
 var defaultDelayInit, _ = time.ParseDuration("0.1s")
 var defaultDelayMax, _ = time.ParseDuration("30s")
+var defaultMirrorDemoteInit, _ = time.ParseDuration("30s")
+var defaultMirrorDemoteMax, _ = time.ParseDuration("10m")
 var warnRegexp = regexp.MustCompile(`^299\s+-\s+"([^"]+)"`)
 
 const (
 	DefaultRetryLimit = 5 // number of times a request will be retried
 	backoffResetCount = 5 // number of successful requests needed to reduce the backoff
+	mirrorFailLimit   = 3 // number of consecutive failures before a mirror is demoted
 )
 
 // Client is an HTTP client wrapper.
 // It handles features like authentication, retries, backoff delays, TLS settings.
 type Client struct {
-	httpClient    *http.Client              // upstream [http.Client], this is wrapped per repository for an auth handler on redirects
-	getConfigHost func(string) *config.Host // call-back to get the [config.Host] for a specific registry
-	host          map[string]*clientHost    // host specific settings, wrap access with a mutex lock
-	rootCAPool    [][]byte                  // list of root CAs for configuring the http.Client transport
-	rootCADirs    []string                  // list of directories for additional root CAs
-	retryLimit    int                       // number of retries before failing a request, this applies to each host, and each request
-	delayInit     time.Duration             // how long to initially delay requests on a failure
-	delayMax      time.Duration             // maximum time to delay a request
-	slog          *slog.Logger              // logging for tracing and failures
-	userAgent     string                    // user agent to specify in http request headers
-	mu            sync.Mutex                // mutex to prevent data races
+	httpClient    *http.Client                              // upstream [http.Client], this is wrapped per repository for an auth handler on redirects
+	getConfigHost func(string) *config.Host                 // call-back to get the [config.Host] for a specific registry
+	host          map[string]*clientHost                    // host specific settings, wrap access with a mutex lock
+	rootCAPool    [][]byte                                  // list of root CAs for configuring the http.Client transport
+	rootCADirs    []string                                  // list of directories for additional root CAs
+	retryLimit    int                                       // number of retries before failing a request, this applies to each host, and each request
+	delayInit     time.Duration                             // how long to initially delay requests on a failure
+	delayMax      time.Duration                             // maximum time to delay a request
+	metrics       *metrics.Metrics                          // statistics on requests to each host
+	demoteInit    time.Duration                             // how long a failing mirror is initially demoted
+	demoteMax     time.Duration                             // maximum time to demote a failing mirror
+	slog          *slog.Logger                              // logging for tracing and failures
+	tokenCache    auth.Cache                                // cache of bearer tokens shared between processes
+	transportWrap func(http.RoundTripper) http.RoundTripper // wraps the transport of each host, e.g. for tracing
+	userAgent     string                                    // user agent to specify in http request headers
+	mu            sync.Mutex                                // mutex to prevent data races
 }
 
 type clientHost struct {
@@ -65,10 +76,15 @@ type clientHost struct {
 	httpClient   *http.Client                // modified http client for registry specific settings
 	userAgent    string                      // user agent to specify in http request headers
 	slog         *slog.Logger                // logging for tracing and failures
+	tokenCache   auth.Cache                  // cache of bearer tokens shared between processes
 	auth         map[string]*auth.Auth       // map of auth handlers by repository
 	backoffCur   int                         // current count of backoffs for this host
 	backoffLast  time.Time                   // time the last request was released, this may be in the future if there is a queue, or zero if no delay is needed
 	backoffReset int                         // count of successful requests when a backoff is experienced, once [backoffResetCount] is reached, [backoffCur] is reduced by one and this is reset to 0
+	mirrorFail   int                         // count of consecutive failed requests to this host as a mirror
+	mirrorDemote int                         // count of times this host has been demoted as a mirror without a successful request
+	mirrorUntil  time.Time                   // time the host is demoted as a mirror, requests skip this mirror until then
+	latency      time.Duration               // moving average of the time to receive a response from this host
 	reqFreq      time.Duration               // how long between submitting requests for this host
 	reqNext      time.Time                   // time to release the next request
 	throttle     *pqueue.Queue[reqmeta.Data] // limit concurrent requests to the host
@@ -106,6 +122,7 @@ type Resp struct {
 	reader           io.Reader
 	readCur, readMax int64
 	retryCount       int
+	credRefreshed    bool
 	throttleDone     func()
 }
 
@@ -120,6 +137,8 @@ func NewClient(opts ...Opts) *Client {
 		retryLimit: DefaultRetryLimit,
 		delayInit:  defaultDelayInit,
 		delayMax:   defaultDelayMax,
+		demoteInit: defaultMirrorDemoteInit,
+		demoteMax:  defaultMirrorDemoteMax,
 		slog:       slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})),
 		rootCAPool: [][]byte{},
 		rootCADirs: []string{},
@@ -193,6 +212,13 @@ func WithHTTPClient(hc *http.Client) Opts {
 	}
 }
 
+// WithMetrics records statistics on the requests to each host.
+func WithMetrics(m *metrics.Metrics) Opts {
+	return func(c *Client) {
+		c.metrics = m
+	}
+}
+
 // WithRetryLimit restricts the number of retries (defaults to 5).
 func WithRetryLimit(rl int) Opts {
 	return func(c *Client) {
@@ -209,6 +235,13 @@ func WithLog(slog *slog.Logger) Opts {
 	}
 }
 
+// WithTokenCache saves bearer tokens to a cache to be reused by later processes.
+func WithTokenCache(tc auth.Cache) Opts {
+	return func(c *Client) {
+		c.tokenCache = tc
+	}
+}
+
 // WithTransport uses a specific http transport with retryable requests.
 func WithTransport(t *http.Transport) Opts {
 	return func(c *Client) {
@@ -216,6 +249,14 @@ func WithTransport(t *http.Transport) Opts {
 	}
 }
 
+// WithTransportWrap wraps the transport used for each host after the host specific settings are applied.
+// This may be used to instrument requests, e.g. with OpenTelemetry tracing.
+func WithTransportWrap(fn func(http.RoundTripper) http.RoundTripper) Opts {
+	return func(c *Client) {
+		c.transportWrap = fn
+	}
+}
+
 // WithUserAgent sets a user agent header.
 func WithUserAgent(ua string) Opts {
 	return func(c *Client) {
@@ -247,11 +288,17 @@ func (resp *Resp) next() error {
 	hosts := make([]*clientHost, 0, 1+len(reqHost.config.Mirrors))
 	if !req.NoMirrors {
 		for _, m := range reqHost.config.Mirrors {
-			hosts = append(hosts, c.getHost(m))
+			h := c.getHost(m)
+			if h.mirrorDemoted() {
+				continue
+			}
+			hosts = append(hosts, h)
 		}
 	}
 	hosts = append(hosts, reqHost)
 	sort.Slice(hosts, sortHostsCmp(hosts, reqHost.config.Name))
+	// the retry limit of the requested host applies to the requests across all mirrors
+	retryLimit := c.hostRetryLimit(reqHost)
 	// loop over requests to mirrors and retries
 	curHost := 0
 	for {
@@ -270,7 +317,7 @@ func (resp *Resp) next() error {
 		h := hosts[curHost]
 		resp.mirror = h.config.Name
 		// there is an intentional extra retry in this check to allow for auth requests
-		if resp.retryCount > c.retryLimit {
+		if resp.retryCount > retryLimit {
 			return errs.ErrRetryLimitExceeded
 		}
 		resp.retryCount++
@@ -290,6 +337,7 @@ func (resp *Resp) next() error {
 		}
 
 		// try each host in a closure to handle all the backoff/dropHost from one place
+		var latency time.Duration
 		loopErr := func() error {
 			var err error
 			if req.Method == "HEAD" && h.config.APIOpts != nil {
@@ -403,6 +451,21 @@ func (resp *Resp) next() error {
 				}
 			}
 
+			// add headers from the host config, these override any other headers
+			headers := h.config.Headers
+			if req.DirectURL != nil && u.Host != h.config.Hostname {
+				// requests to another host, like a vendor API, only include the headers configured for that host
+				headers = nil
+				if c.getConfigHost != nil {
+					if hc := c.getConfigHost(u.Host); hc != nil {
+						headers = hc.Headers
+					}
+				}
+			}
+			for k, v := range headers {
+				httpReq.Header.Set(k, v)
+			}
+
 			// delay for the rate limit
 			if h.reqFreq > 0 {
 				sleep := time.Duration(0)
@@ -421,7 +484,9 @@ func (resp *Resp) next() error {
 
 			// send request
 			hc := h.getHTTPClient(req.Repository)
+			start := time.Now()
 			resp.resp, err = hc.Do(httpReq)
+			latency = time.Since(start)
 
 			if err != nil {
 				c.slog.Debug("Request failed",
@@ -451,10 +516,19 @@ func (resp *Resp) next() error {
 								slog.String("URL", u.String()),
 								slog.String("Err", err.Error()))
 						}
-						dropHost = true
+						if !resp.credRefreshed && hAuth != nil && h.expireCred(req.Repository) {
+							// the credential from a helper may have expired early, e.g. cloud registry tokens, retry once with a new credential
+							c.slog.Debug("Refreshing credential from helper",
+								slog.String("host", h.config.Name))
+							resp.credRefreshed = true
+							retryHost = true
+						} else {
+							dropHost = true
+						}
 					} else {
 						err = fmt.Errorf("authentication required")
 						retryHost = true
+						c.metrics.AuthRefresh(h.config.Name)
 					}
 					return err
 				case http.StatusNotFound:
@@ -474,7 +548,7 @@ func (resp *Resp) next() error {
 				errHTTP := HTTPError(resp.resp.StatusCode)
 				errBody, _ := io.ReadAll(resp.resp.Body)
 				_ = resp.resp.Body.Close()
-				return fmt.Errorf("request failed: %w: %s", errHTTP, errBody)
+				return fmt.Errorf("request failed: %w", errs.NewRegistryError(req.Method, u.Redacted(), statusCode, resp.resp.Header, errBody, errHTTP))
 			}
 
 			resp.reader = resp.resp.Body
@@ -503,6 +577,14 @@ func (resp *Resp) next() error {
 			}
 			return nil
 		}()
+		// track the health of mirrors, the upstream host is never demoted
+		if h != reqHost {
+			if loopErr == nil {
+				h.mirrorSuccess(latency)
+			} else if backoff && !req.IgnoreErr {
+				c.mirrorFailure(h)
+			}
+		}
 		// return on success
 		if loopErr == nil {
 			resp.throttleDone = throttleDone
@@ -642,9 +724,14 @@ func (resp *Resp) backoffGet() time.Time {
 	ch.mu.Lock()
 	defer ch.mu.Unlock()
 	if ch.backoffCur > 0 {
-		delay := c.delayInit << ch.backoffCur
-		if delay > c.delayMax {
-			delay = c.delayMax
+		delayInit, delayMax, jitter := c.hostDelay(ch)
+		delay := delayInit << ch.backoffCur
+		if delay > delayMax {
+			delay = delayMax
+		}
+		if jitter > 0 {
+			//#nosec G404 jitter does not need a cryptographically secure random number
+			delay += time.Duration(rand.Int63n(int64(jitter)))
 		}
 		next := ch.backoffLast.Add(delay)
 		now := time.Now()
@@ -684,7 +771,7 @@ func (resp *Resp) backoffSet() error {
 	if ch.backoffLast.IsZero() {
 		ch.backoffLast = time.Now()
 	}
-	if ch.backoffCur >= c.retryLimit {
+	if ch.backoffCur >= c.hostRetryLimit(ch) {
 		return fmt.Errorf("%w: backoffs %d", errs.ErrBackoffLimit, ch.backoffCur)
 	}
 
@@ -700,7 +787,7 @@ func (resp *Resp) backoffReset() {
 		ch.backoffReset++
 		// If enough successful requests are seen, lower the backoffCur count.
 		// This requires multiple successful requests of a flaky server, but quickly drops when above the retry limit.
-		if ch.backoffReset > backoffResetCount || ch.backoffCur > c.retryLimit {
+		if ch.backoffReset > backoffResetCount || ch.backoffCur > c.hostRetryLimit(ch) {
 			ch.backoffReset = 0
 			ch.backoffCur--
 			if ch.backoffCur == 0 {
@@ -711,6 +798,86 @@ func (resp *Resp) backoffReset() {
 	}
 }
 
+// mirrorDemoted returns true when a host should be skipped as a mirror.
+func (h *clientHost) mirrorDemoted() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return !h.mirrorUntil.IsZero() && time.Now().Before(h.mirrorUntil)
+}
+
+// mirrorSuccess records a successful response from a mirror, restoring it if it was demoted.
+func (h *clientHost) mirrorSuccess(latency time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.mirrorDemote > 0 {
+		h.slog.Info("Mirror recovered",
+			slog.String("host", h.config.Name))
+	}
+	h.mirrorFail = 0
+	h.mirrorDemote = 0
+	h.mirrorUntil = time.Time{}
+	if h.latency == 0 {
+		h.latency = latency
+	} else {
+		h.latency = (h.latency*7 + latency) / 8
+	}
+}
+
+// getLatency returns the average time to receive a response from a host.
+func (h *clientHost) getLatency() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.latency
+}
+
+// mirrorFailure records a failed request to a mirror, demoting it after repeated failures.
+// Each demotion without a successful request in between doubles the time the mirror is skipped.
+func (c *Client) mirrorFailure(h *clientHost) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.mirrorFail++
+	// a demoted mirror that fails again after recovering is immediately demoted
+	if h.mirrorFail < mirrorFailLimit && h.mirrorDemote == 0 {
+		return
+	}
+	delay := c.demoteInit << h.mirrorDemote
+	if delay > c.demoteMax || delay <= 0 {
+		delay = c.demoteMax
+	}
+	h.mirrorDemote++
+	h.mirrorFail = 0
+	h.mirrorUntil = time.Now().Add(delay)
+	h.slog.Warn("Mirror demoted after failed requests",
+		slog.String("host", h.config.Name),
+		slog.Duration("duration", delay))
+}
+
+// hostRetryLimit returns the retry limit for a host, falling back to the client default.
+func (c *Client) hostRetryLimit(h *clientHost) int {
+	if h.config != nil && h.config.RetryLimit > 0 {
+		return h.config.RetryLimit
+	}
+	return c.retryLimit
+}
+
+// hostDelay returns the initial delay, maximum delay, and jitter for a host, falling back to the client defaults.
+func (c *Client) hostDelay(h *clientHost) (time.Duration, time.Duration, time.Duration) {
+	delayInit, delayMax := c.delayInit, c.delayMax
+	if h.config == nil {
+		return delayInit, delayMax, 0
+	}
+	if h.config.RetryDelay > 0 {
+		delayInit = time.Duration(h.config.RetryDelay)
+	}
+	if h.config.RetryDelayMax > 0 {
+		delayMax = time.Duration(h.config.RetryDelayMax)
+	}
+	if delayMax < delayInit {
+		delayMax = delayInit
+	}
+	return delayInit, delayMax, time.Duration(h.config.RetryJitter)
+}
+
 // getHost looks up or creates a clientHost for a given registry.
 func (c *Client) getHost(host string) *clientHost {
 	c.mu.Lock()
@@ -730,10 +897,11 @@ func (c *Client) getHost(host string) *clientHost {
 		}
 	}
 	h := &clientHost{
-		config:    conf,
-		userAgent: c.userAgent,
-		slog:      c.slog,
-		auth:      map[string]*auth.Auth{},
+		config:     conf,
+		userAgent:  c.userAgent,
+		slog:       c.slog,
+		tokenCache: c.tokenCache,
+		auth:       map[string]*auth.Auth{},
 	}
 	if h.config.ReqPerSec > 0 {
 		h.reqFreq = time.Duration(float64(time.Second) / h.config.ReqPerSec)
@@ -769,21 +937,51 @@ func (c *Client) getHost(host string) *clientHost {
 					tlsc.RootCAs = rootPool
 				}
 			}
+			certs, err := makeClientCerts(c.rootCADirs, h.config.Hostname)
+			if err != nil {
+				c.slog.Warn("failed to load client certs",
+					slog.String("err", err.Error()))
+			}
 			if h.config.ClientCert != "" && h.config.ClientKey != "" {
-				cert, err := tls.X509KeyPair([]byte(h.config.ClientCert), []byte(h.config.ClientKey))
+				cert, err := loadClientCert(h.config.ClientCert, h.config.ClientKey)
 				if err != nil {
 					c.slog.Warn("failed to configure client certs",
 						slog.String("err", err.Error()))
 				} else {
-					tlsc.Certificates = []tls.Certificate{cert}
+					certs = append([]tls.Certificate{cert}, certs...)
 				}
 			}
+			if len(certs) > 0 {
+				tlsc.Certificates = certs
+			}
 			t.TLSClientConfig = tlsc
 			h.httpClient.Transport = t
 		}
 	}
+	// configure a proxy for the host, overriding the environment
+	if h.config.Proxy != "" {
+		t, ok := h.httpClient.Transport.(*http.Transport)
+		if ok {
+			proxyURL, err := url.Parse(h.config.Proxy)
+			if err != nil || proxyURL.Host == "" {
+				c.slog.Warn("failed to configure proxy",
+					slog.String("host", h.config.Name))
+			} else {
+				t = t.Clone()
+				t.Proxy = http.ProxyURL(proxyURL)
+				h.httpClient.Transport = t
+			}
+		}
+	}
+	if c.transportWrap != nil {
+		h.httpClient.Transport = c.transportWrap(h.httpClient.Transport)
+	}
 	// wrap the transport for logging and to handle warning headers
-	h.httpClient.Transport = &wrapTransport{c: c, orig: h.httpClient.Transport}
+	wt := &wrapTransport{c: c, orig: h.httpClient.Transport, name: conf.Name, censor: []string{"Authorization"}}
+	for k := range h.config.Headers {
+		wt.censor = append(wt.censor, k)
+	}
+	h.httpClient.Transport = wt
 
 	c.host[conf.Name] = h
 	if conf.Name != host {
@@ -808,6 +1006,12 @@ func (ch *clientHost) checkRedirect(repo string, orig func(req *http.Request, vi
 		if len(via) >= 10 {
 			return errors.New("stopped after 10 redirects")
 		}
+		// only send headers from the host config to the registry, not to redirected hosts like blob storage
+		if req.URL.Host != ch.config.Hostname {
+			for k := range ch.config.Headers {
+				req.Header.Del(k)
+			}
+		}
 		// add auth headers if appropriate for the target host
 		hAuth := ch.getAuth(repo)
 		err := hAuth.UpdateRequest(req)
@@ -830,16 +1034,38 @@ func (ch *clientHost) getAuth(repo string) *auth.Auth {
 		repo = "" // without RepoAuth, unset the provided repo
 	}
 	if _, ok := ch.auth[repo]; !ok {
-		ch.auth[repo] = auth.NewAuth(
+		authOpts := []auth.Opts{
 			auth.WithLog(ch.slog),
 			auth.WithHTTPClient(ch.httpClient),
 			auth.WithCreds(ch.AuthCreds()),
 			auth.WithClientID(ch.userAgent),
-		)
+		}
+		if ch.tokenCache != nil {
+			authOpts = append(authOpts, auth.WithCache(ch.tokenCache))
+		}
+		if ch.config.AnonFallback {
+			authOpts = append(authOpts, auth.WithAnonFallback())
+		}
+		ch.auth[repo] = auth.NewAuth(authOpts...)
 	}
 	return ch.auth[repo]
 }
 
+// expireCred forces a credential helper to run again and resets the auth for the repository.
+// It returns false when the host does not use a credential helper.
+func (ch *clientHost) expireCred(repo string) bool {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	if !ch.config.ExpireCred() {
+		return false
+	}
+	if !ch.config.RepoAuth {
+		repo = ""
+	}
+	delete(ch.auth, repo)
+	return true
+}
+
 func (ch *clientHost) AuthCreds() func(h string) auth.Cred {
 	if ch == nil || ch.config == nil {
 		return auth.DefaultCredsFn
@@ -851,41 +1077,148 @@ func (ch *clientHost) AuthCreds() func(h string) auth.Cred {
 }
 
 type wrapTransport struct {
-	c    *Client
-	orig http.RoundTripper
+	c      *Client
+	orig   http.RoundTripper
+	name   string   // host name for metrics
+	censor []string // headers to censor from logs
 }
 
 func (wt *wrapTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
 	resp, err := wt.orig.RoundTrip(req)
-	// copy headers to censor auth field
+	if wt.c.metrics != nil {
+		status := 0
+		if err == nil {
+			status = resp.StatusCode
+			if status == http.StatusTooManyRequests {
+				wt.c.metrics.RateLimit(wt.name)
+			}
+			resp.Body = &countReader{rc: resp.Body, fn: func(n int) { wt.c.metrics.BytesReceived(wt.name, int64(n)) }}
+		}
+		wt.c.metrics.Request(wt.name, req.Method, status, time.Since(start))
+		wt.c.metrics.BytesSent(wt.name, req.ContentLength)
+	}
+	dur := time.Since(start)
+	level := types.LevelTrace
+	if err != nil {
+		level = slog.LevelDebug
+	}
+	if !wt.c.slog.Enabled(req.Context(), level) {
+		// skip formatting the log fields when they would be discarded
+		if err == nil {
+			wt.handleResp(req, resp)
+		}
+		return resp, err
+	}
+	// copy headers to censor auth and configured header fields
 	reqHead := req.Header.Clone()
-	if reqHead.Get("Authorization") != "" {
-		reqHead.Set("Authorization", "[censored]")
+	censorHost := []string{}
+	if wt.c.getConfigHost != nil {
+		// include headers configured for another host, like a vendor API
+		if hc := wt.c.getConfigHost(req.URL.Host); hc != nil {
+			for k := range hc.Headers {
+				censorHost = append(censorHost, k)
+			}
+		}
 	}
+	for _, censor := range [][]string{wt.censor, censorHost, censorReqHeaders} {
+		for _, k := range censor {
+			if reqHead.Get(k) != "" {
+				reqHead.Set(k, "[censored]")
+			}
+		}
+	}
+	reqURL := redactURL(req.URL)
 	if err != nil {
 		wt.c.slog.Debug("reg http request",
 			slog.String("req-method", req.Method),
-			slog.String("req-url", req.URL.String()),
+			slog.String("req-url", reqURL),
 			slog.Any("req-headers", reqHead),
-			slog.String("err", err.Error()))
+			slog.Duration("duration", dur),
+			slog.String("err", strings.ReplaceAll(err.Error(), req.URL.String(), reqURL)))
 	} else {
-		// extract any warnings
-		for _, wh := range resp.Header.Values("Warning") {
-			if match := warnRegexp.FindStringSubmatch(wh); len(match) == 2 {
-				// TODO(bmitch): pass other fields (registry hostname) with structured logging
-				warning.Handle(req.Context(), wt.c.slog, match[1])
+		wt.handleResp(req, resp)
+		respHead := resp.Header.Clone()
+		for _, k := range censorRespHeaders {
+			if respHead.Get(k) != "" {
+				respHead.Set(k, "[censored]")
+			}
+		}
+		if loc := respHead.Get("Location"); loc != "" {
+			if u, errParse := url.Parse(loc); errParse == nil {
+				respHead.Set("Location", redactURL(u))
 			}
 		}
 		wt.c.slog.Log(req.Context(), types.LevelTrace, "reg http request",
 			slog.String("req-method", req.Method),
-			slog.String("req-url", req.URL.String()),
+			slog.String("req-url", reqURL),
 			slog.Any("req-headers", reqHead),
+			slog.Duration("duration", dur),
 			slog.String("resp-status", resp.Status),
-			slog.Any("resp-headers", resp.Header))
+			slog.Any("resp-headers", respHead))
 	}
 	return resp, err
 }
 
+// handleResp processes warning and rate limit headers from a response.
+func (wt *wrapTransport) handleResp(req *http.Request, resp *http.Response) {
+	// extract any warnings
+	for _, wh := range resp.Header.Values("Warning") {
+		if match := warnRegexp.FindStringSubmatch(wh); len(match) == 2 {
+			// TODO(bmitch): pass other fields (registry hostname) with structured logging
+			warning.Handle(req.Context(), wt.c.slog, match[1])
+		}
+	}
+	ratelimit.Handle(req.Context(), req.URL.Host, resp.Header)
+}
+
+var (
+	// censorReqHeaders and censorRespHeaders contain credentials and are not logged.
+	censorReqHeaders  = []string{"Cookie", "Proxy-Authorization"}
+	censorRespHeaders = []string{"Set-Cookie"}
+	// censorQueryRE matches query parameters with credentials, like the signatures added to redirects for blob storage.
+	censorQueryRE = regexp.MustCompile(`(?i)(signature|token|credential|^sig$|^key$|secret|password)`)
+)
+
+// redactURL returns a url with the password and any credentials in the query removed.
+func redactURL(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.Redacted()
+	}
+	query := u.Query()
+	changed := false
+	for k := range query {
+		if censorQueryRE.MatchString(k) {
+			query.Set(k, "censored")
+			changed = true
+		}
+	}
+	if !changed {
+		return u.Redacted()
+	}
+	uc := *u
+	uc.RawQuery = query.Encode()
+	return uc.Redacted()
+}
+
+// countReader reports the bytes read from a response body.
+type countReader struct {
+	rc io.ReadCloser
+	fn func(int)
+}
+
+func (cr *countReader) Read(p []byte) (int, error) {
+	n, err := cr.rc.Read(p)
+	if n > 0 {
+		cr.fn(n)
+	}
+	return n, err
+}
+
+func (cr *countReader) Close() error {
+	return cr.rc.Close()
+}
+
 // HTTPError returns an error based on the status code.
 func HTTPError(statusCode int) error {
 	switch statusCode {
@@ -902,6 +1235,62 @@ func HTTPError(statusCode int) error {
 	}
 }
 
+// loadClientCert parses a client certificate and key for mTLS.
+// Each value may be inline PEM content or the name of a file containing the PEM content.
+func loadClientCert(cert, key string) (tls.Certificate, error) {
+	certPEM, err := readPEM(cert)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	keyPEM, err := readPEM(key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.X509KeyPair(certPEM, keyPEM)
+}
+
+// readPEM returns inline PEM content, or reads the content from a file.
+func readPEM(val string) ([]byte, error) {
+	if strings.Contains(val, "-----BEGIN") {
+		return []byte(val), nil
+	}
+	//#nosec G304 file name is provided by the user running the command on their own host
+	b, err := os.ReadFile(strings.TrimSpace(val))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", val, err)
+	}
+	return b, nil
+}
+
+// makeClientCerts loads client certificates for a host from the cert directories.
+// Similar to Docker, each "name.cert" file is paired with a "name.key" file.
+func makeClientCerts(rootCADirs []string, hostname string) ([]tls.Certificate, error) {
+	certs := []tls.Certificate{}
+	for _, dir := range rootCADirs {
+		hostDir := filepath.Join(dir, hostname)
+		files, err := os.ReadDir(hostDir)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				return certs, fmt.Errorf("failed to read directory %s: %w", hostDir, err)
+			}
+			continue
+		}
+		for _, f := range files {
+			if f.IsDir() || !strings.HasSuffix(f.Name(), ".cert") {
+				continue
+			}
+			certFile := filepath.Join(hostDir, f.Name())
+			keyFile := strings.TrimSuffix(certFile, ".cert") + ".key"
+			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			if err != nil {
+				return certs, fmt.Errorf("failed to load client cert %s: %w", certFile, err)
+			}
+			certs = append(certs, cert)
+		}
+	}
+	return certs, nil
+}
+
 func makeRootPool(rootCAPool [][]byte, rootCADirs []string, hostname string, hostcert string) (*x509.CertPool, error) {
 	pool, err := x509.SystemCertPool()
 	if err != nil {
@@ -956,7 +1345,7 @@ func makeRootPool(rootCAPool [][]byte, rootCADirs []string, hostname string, hos
 // sortHostCmp to sort host list of mirrors.
 func sortHostsCmp(hosts []*clientHost, upstream string) func(i, j int) bool {
 	now := time.Now()
-	// sort by backoff first, then priority decending, then upstream name last
+	// sort by backoff first, then priority decending, then upstream name last, then mirror latency
 	return func(i, j int) bool {
 		if now.Before(hosts[i].backoffLast) || now.Before(hosts[j].backoffLast) {
 			return hosts[i].backoffLast.Before(hosts[j].backoffLast)
@@ -964,6 +1353,9 @@ func sortHostsCmp(hosts []*clientHost, upstream string) func(i, j int) bool {
 		if hosts[i].config.Priority != hosts[j].config.Priority {
 			return hosts[i].config.Priority < hosts[j].config.Priority
 		}
-		return hosts[i].config.Name != upstream
+		if hosts[i].config.Name == upstream || hosts[j].config.Name == upstream {
+			return hosts[i].config.Name != upstream
+		}
+		return hosts[i].getLatency() < hosts[j].getLatency()
 	}
 }
@@ -3,14 +3,27 @@ package reghttp
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -18,7 +31,11 @@ import (
 
 	"github.com/regclient/regclient/config"
 	"github.com/regclient/regclient/internal/reqresp"
+	"github.com/regclient/regclient/internal/timejson"
+	"github.com/regclient/regclient/pkg/metrics"
+	"github.com/regclient/regclient/types"
 	"github.com/regclient/regclient/types/errs"
+	"github.com/regclient/regclient/types/ratelimit"
 	"github.com/regclient/regclient/types/warning"
 )
 
@@ -450,6 +467,7 @@ func TestRegHttp(t *testing.T) {
 			},
 			RespEntry: reqresp.RespEntry{
 				Status: http.StatusNotFound,
+				Body:   []byte(`{"errors":[{"code":"MANIFEST_UNKNOWN","message":"manifest unknown","detail":{"tag":"tag-get"}}]}`),
 			},
 		},
 		{
@@ -712,6 +730,24 @@ func TestRegHttp(t *testing.T) {
 				},
 			},
 		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "get ratelimit",
+				Method: "GET",
+				Path:   "/v2/project/manifests/ratelimit",
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Body:   getBody,
+				Headers: http.Header{
+					"Content-Length":        {fmt.Sprintf("%d", len(getBody))},
+					"Content-Type":          []string{"application/vnd.docker.distribution.manifest.v2+json"},
+					"Docker-Content-Digest": []string{getDigest.String()},
+					"RateLimit-Limit":       []string{"100;w=21600"},
+					"RateLimit-Remaining":   []string{"42;w=21600"},
+				},
+			},
+		},
 	}
 	// create a server
 	ts := httptest.NewServer(reqresp.NewHandler(t, rrs))
@@ -1424,6 +1460,49 @@ func TestRegHttp(t *testing.T) {
 			t.Errorf("unexpected error: expected %v, received %v", errs.ErrRetryLimitExceeded, err)
 		}
 	})
+	// test the retry settings configured on the host override the client default
+	t.Run("retry-host-limit", func(t *testing.T) {
+		var mu sync.Mutex
+		count := 0
+		tsRetry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			count++
+			mu.Unlock()
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer tsRetry.Close()
+		tsRetryURL, _ := url.Parse(tsRetry.URL)
+		retryHost := &config.Host{
+			Name:       tsRetryURL.Host,
+			Hostname:   tsRetryURL.Host,
+			TLS:        config.TLSDisabled,
+			RetryLimit: 2,
+			RetryDelay: timejson.Duration(delayInit),
+		}
+		hcRetry := NewClient(
+			WithConfigHostFn(func(name string) *config.Host {
+				return retryHost
+			}),
+			WithDelay(delayInit, delayMax),
+			WithRetryLimit(10),
+		)
+		resp, err := hcRetry.Do(ctx, &Req{
+			Host:       tsRetryURL.Host,
+			Method:     "GET",
+			Repository: "project",
+			Path:       "manifests/tag-get",
+			Headers:    headers,
+		})
+		if err == nil {
+			_ = resp.Close()
+			t.Fatalf("request did not fail")
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if count == 0 || count > 3 {
+			t.Errorf("unexpected number of requests, expected 1 to 3, received %d", count)
+		}
+	})
 	// test error statuses (404, rate limit, timeout, server error)
 	t.Run("Missing", func(t *testing.T) {
 		getReq := &Req{
@@ -1440,6 +1519,19 @@ func TestRegHttp(t *testing.T) {
 		} else if !errors.Is(err, errs.ErrNotFound) {
 			t.Errorf("unexpected error, expected %v, received %v", errs.ErrNotFound, err)
 		}
+		var regErr *errs.RegistryError
+		if !errors.As(err, &regErr) {
+			t.Fatalf("registry error not found in %v", err)
+		}
+		if regErr.StatusCode != http.StatusNotFound || regErr.Method != "GET" {
+			t.Errorf("unexpected status or method: %d %s", regErr.StatusCode, regErr.Method)
+		}
+		if !regErr.HasCode(errs.CodeManifestUnknown) || len(regErr.Errors) != 1 || regErr.Errors[0].Message != "manifest unknown" {
+			t.Errorf("unexpected error entries: %v", regErr.Errors)
+		}
+		if len(regErr.Body) == 0 {
+			t.Errorf("body missing from registry error")
+		}
 	})
 	t.Run("Forbidden", func(t *testing.T) {
 		getReq := &Req{
@@ -1456,6 +1548,13 @@ func TestRegHttp(t *testing.T) {
 		} else if !errors.Is(err, errs.ErrHTTPUnauthorized) {
 			t.Errorf("unexpected error, expected %v, received %v", errs.ErrHTTPUnauthorized, err)
 		}
+		var regErr *errs.RegistryError
+		if !errors.As(err, &regErr) {
+			t.Fatalf("registry error not found in %v", err)
+		}
+		if regErr.StatusCode != http.StatusForbidden || len(regErr.Errors) != 0 || regErr.HasCode(errs.CodeDenied) {
+			t.Errorf("unexpected registry error: %d %v", regErr.StatusCode, regErr.Errors)
+		}
 	})
 	t.Run("Bad GW", func(t *testing.T) {
 		getReq := &Req{
@@ -1606,6 +1705,40 @@ func TestRegHttp(t *testing.T) {
 			t.Errorf("error closing request: %v", err)
 		}
 	})
+	t.Run("Rate limit headers", func(t *testing.T) {
+		getReq := &Req{
+			Host:       tsHost,
+			Method:     "GET",
+			Repository: "project",
+			Path:       "manifests/ratelimit",
+			Headers:    headers,
+		}
+		hookCalls := 0
+		rec := &ratelimit.Recorder{
+			Hook: func(_ context.Context, host string, rl types.RateLimit) {
+				hookCalls++
+			},
+		}
+		rlCtx := ratelimit.NewContext(ctx, rec)
+		resp, err := hc.Do(rlCtx, getReq)
+		if err != nil {
+			t.Fatalf("failed to run get: %v", err)
+		}
+		err = resp.Close()
+		if err != nil {
+			t.Errorf("error closing request: %v", err)
+		}
+		rl, ok := rec.Get(tsHost)
+		if !ok {
+			t.Fatalf("rate limit was not recorded")
+		}
+		if rl.Limit != 100 || rl.Remain != 42 {
+			t.Errorf("unexpected rate limit: %v", rl)
+		}
+		if hookCalls != 1 {
+			t.Errorf("hook calls, expected 1, received %d", hookCalls)
+		}
+	})
 	t.Run("Host Normalized", func(t *testing.T) {
 		getReq := &Req{
 			Host:       tsHost + "/path",
@@ -1697,3 +1830,669 @@ func TestRegHttp(t *testing.T) {
 	})
 	// TODO: test various TLS configs (custom root for all hosts, custom root for one host, insecure)
 }
+
+func TestMirrorHealth(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	var mu sync.Mutex
+	mirrorHits := 0
+	mirrorUp := false
+	tsMirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		mirrorHits++
+		up := mirrorUp
+		mu.Unlock()
+		if !up {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte("mirror"))
+	}))
+	t.Cleanup(tsMirror.Close)
+	tsUpstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("upstream"))
+	}))
+	t.Cleanup(tsUpstream.Close)
+	tsMirrorURL, _ := url.Parse(tsMirror.URL)
+	tsUpstreamURL, _ := url.Parse(tsUpstream.URL)
+	configHosts := map[string]*config.Host{
+		"upstream": {
+			Name:     "upstream",
+			Hostname: tsUpstreamURL.Host,
+			TLS:      config.TLSDisabled,
+			Mirrors:  []string{"mirror"},
+		},
+		"mirror": {
+			Name:     "mirror",
+			Hostname: tsMirrorURL.Host,
+			TLS:      config.TLSDisabled,
+		},
+	}
+	delayInit, _ := time.ParseDuration("0.0005s")
+	delayMax, _ := time.ParseDuration("0.0010s")
+	hc := NewClient(
+		WithConfigHostFn(func(name string) *config.Host {
+			return configHosts[name]
+		}),
+		WithDelay(delayInit, delayMax),
+	)
+	hc.demoteInit = 50 * time.Millisecond
+	hc.demoteMax = 100 * time.Millisecond
+	getReq := &Req{
+		Host:       "upstream",
+		Method:     "GET",
+		Repository: "project",
+		Path:       "manifests/tag-get",
+	}
+	get := func(t *testing.T, expectBody string, expectHits int) {
+		t.Helper()
+		// wait for any retry backoff on the mirror to expire
+		time.Sleep(5 * time.Millisecond)
+		resp, err := hc.Do(ctx, getReq)
+		if err != nil {
+			t.Fatalf("failed to run get: %v", err)
+		}
+		body, err := io.ReadAll(resp)
+		_ = resp.Close()
+		if err != nil {
+			t.Fatalf("body read failure: %v", err)
+		}
+		if string(body) != expectBody {
+			t.Errorf("unexpected body, expected %s, received %s", expectBody, body)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if mirrorHits != expectHits {
+			t.Errorf("unexpected mirror requests, expected %d, received %d", expectHits, mirrorHits)
+		}
+	}
+
+	// each failure falls back to the upstream until the mirror is demoted
+	for i := 1; i <= mirrorFailLimit; i++ {
+		get(t, "upstream", i)
+	}
+	// demoted mirror is skipped
+	get(t, "upstream", mirrorFailLimit)
+	// after the demotion expires, a single failure demotes the mirror again
+	time.Sleep(hc.demoteInit)
+	get(t, "upstream", mirrorFailLimit+1)
+	get(t, "upstream", mirrorFailLimit+1)
+	// once the mirror is working, it is used again after the demotion expires
+	mu.Lock()
+	mirrorUp = true
+	mu.Unlock()
+	time.Sleep(hc.demoteMax)
+	get(t, "mirror", mirrorFailLimit+2)
+	get(t, "mirror", mirrorFailLimit+3)
+}
+
+func TestReqConcurrent(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	limit := 2
+	var mu sync.Mutex
+	cur, maxCur := 0, 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		cur++
+		if cur > maxCur {
+			maxCur = cur
+		}
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		cur--
+		mu.Unlock()
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(ts.Close)
+	tsURL, _ := url.Parse(ts.URL)
+	host := &config.Host{
+		Name:          tsURL.Host,
+		Hostname:      tsURL.Host,
+		TLS:           config.TLSDisabled,
+		ReqConcurrent: int64(limit),
+	}
+	hc := NewClient(
+		WithConfigHostFn(func(name string) *config.Host {
+			return host
+		}),
+	)
+	var wg sync.WaitGroup
+	errList := make([]error, 6)
+	for i := range errList {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := hc.Do(ctx, &Req{
+				Host:       tsURL.Host,
+				Method:     "GET",
+				Repository: "project",
+				Path:       "manifests/tag-get",
+			})
+			if err != nil {
+				errList[i] = err
+				return
+			}
+			_, errList[i] = io.ReadAll(resp)
+			_ = resp.Close()
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errList {
+		if err != nil {
+			t.Errorf("request failed: %v", err)
+		}
+	}
+	if maxCur == 0 || maxCur > limit {
+		t.Errorf("unexpected concurrent requests, expected 1 to %d, received %d", limit, maxCur)
+	}
+}
+
+func TestProxy(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	var mu sync.Mutex
+	proxied := []string{}
+	tsProxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		proxied = append(proxied, r.URL.Host)
+		mu.Unlock()
+		_, _ = w.Write([]byte("proxied"))
+	}))
+	t.Cleanup(tsProxy.Close)
+	host := &config.Host{
+		Name:     "registry.example.invalid",
+		Hostname: "registry.example.invalid",
+		TLS:      config.TLSDisabled,
+		Proxy:    tsProxy.URL,
+	}
+	hc := NewClient(
+		WithConfigHostFn(func(name string) *config.Host {
+			return host
+		}),
+	)
+	resp, err := hc.Do(ctx, &Req{
+		Host:       host.Name,
+		Method:     "GET",
+		Repository: "project",
+		Path:       "manifests/tag-get",
+	})
+	if err != nil {
+		t.Fatalf("failed to run get: %v", err)
+	}
+	body, err := io.ReadAll(resp)
+	_ = resp.Close()
+	if err != nil || string(body) != "proxied" {
+		t.Errorf("unexpected body: %s, %v", body, err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(proxied) != 1 || proxied[0] != host.Hostname {
+		t.Errorf("unexpected requests to the proxy: %v", proxied)
+	}
+}
+
+func TestClientCert(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	// generate a self signed client cert
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create cert: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(certPEM)
+	// server requires the client cert
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	ts.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  pool,
+		MinVersion: tls.VersionTLS12,
+	}
+	ts.StartTLS()
+	t.Cleanup(ts.Close)
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	// write files for the file and cert dir tests
+	tempDir := t.TempDir()
+	certFile := filepath.Join(tempDir, "client.pem")
+	keyFile := filepath.Join(tempDir, "client-key.pem")
+	certDir := filepath.Join(tempDir, "certs.d")
+	hostDir := filepath.Join(certDir, tsHost)
+	err = os.MkdirAll(hostDir, 0700)
+	if err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	for file, content := range map[string][]byte{
+		certFile:                              certPEM,
+		keyFile:                               keyPEM,
+		filepath.Join(hostDir, "client.cert"): certPEM,
+		filepath.Join(hostDir, "client.key"):  keyPEM,
+	} {
+		err = os.WriteFile(file, content, 0600)
+		if err != nil {
+			t.Fatalf("failed to write %s: %v", file, err)
+		}
+	}
+
+	tt := []struct {
+		name      string
+		cert, key string
+		certDirs  []string
+		expectErr bool
+	}{
+		{
+			name:      "missing",
+			expectErr: true,
+		},
+		{
+			name: "inline",
+			cert: string(certPEM),
+			key:  string(keyPEM),
+		},
+		{
+			name: "file",
+			cert: certFile,
+			key:  keyFile,
+		},
+		{
+			name:     "cert dir",
+			certDirs: []string{certDir},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			host := &config.Host{
+				Name:       tsHost,
+				Hostname:   tsHost,
+				TLS:        config.TLSInsecure,
+				ClientCert: tc.cert,
+				ClientKey:  tc.key,
+			}
+			delayInit, _ := time.ParseDuration("0.0005s")
+			hc := NewClient(
+				WithConfigHostFn(func(name string) *config.Host {
+					return host
+				}),
+				WithCertDirs(tc.certDirs),
+				WithDelay(delayInit, delayInit),
+				WithRetryLimit(1),
+			)
+			resp, err := hc.Do(ctx, &Req{
+				Host:       tsHost,
+				Method:     "GET",
+				Repository: "project",
+				Path:       "manifests/tag-get",
+			})
+			if tc.expectErr {
+				if err == nil {
+					_ = resp.Close()
+					t.Errorf("request without a client cert did not fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to run get: %v", err)
+			}
+			body, err := io.ReadAll(resp)
+			_ = resp.Close()
+			if err != nil || string(body) != "ok" {
+				t.Errorf("unexpected body: %s, %v", body, err)
+			}
+		})
+	}
+}
+
+func TestHostHeaders(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	secret := "secret-api-key"
+	var mu sync.Mutex
+	redirectHeader := "unset"
+	tsRedirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		redirectHeader = r.Header.Get("X-Api-Key")
+		mu.Unlock()
+		_, _ = w.Write([]byte("redirected"))
+	}))
+	t.Cleanup(tsRedirect.Close)
+	// a vendor API on another host with its own headers
+	apiSecret := "secret-vendor-token"
+	apiHeaders := http.Header{}
+	tsAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		apiHeaders = r.Header.Clone()
+		mu.Unlock()
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(tsAPI.Close)
+	tsAPIURL, _ := url.Parse(tsAPI.URL)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != secret {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path == "/v2/project/blobs/redirect" {
+			http.Redirect(w, r, tsRedirect.URL+"/blob", http.StatusTemporaryRedirect)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(ts.Close)
+	tsURL, _ := url.Parse(ts.URL)
+	host := &config.Host{
+		Name:     tsURL.Host,
+		Hostname: tsURL.Host,
+		TLS:      config.TLSDisabled,
+		Headers:  map[string]string{"X-Api-Key": secret},
+	}
+	apiHost := &config.Host{
+		Name:     tsAPIURL.Host,
+		Hostname: tsAPIURL.Host,
+		TLS:      config.TLSDisabled,
+		Headers:  map[string]string{"X-Vendor-Token": apiSecret},
+	}
+	logBuf := &bytes.Buffer{}
+	var logMu sync.Mutex
+	hc := NewClient(
+		WithConfigHostFn(func(name string) *config.Host {
+			if name == apiHost.Name {
+				return apiHost
+			}
+			return host
+		}),
+		WithLog(slog.New(slog.NewTextHandler(&syncWriter{w: logBuf, mu: &logMu}, &slog.HandlerOptions{Level: types.LevelTrace}))),
+	)
+	for _, path := range []string{"manifests/tag-get", "blobs/redirect"} {
+		resp, err := hc.Do(ctx, &Req{
+			Host:       tsURL.Host,
+			Method:     "GET",
+			Repository: "project",
+			Path:       path,
+		})
+		if err != nil {
+			t.Fatalf("failed to run get %s: %v", path, err)
+		}
+		_, err = io.ReadAll(resp)
+		_ = resp.Close()
+		if err != nil {
+			t.Errorf("failed to read %s: %v", path, err)
+		}
+	}
+	resp, err := hc.Do(ctx, &Req{
+		Host:      tsURL.Host,
+		Method:    "GET",
+		DirectURL: tsAPIURL.JoinPath("api"),
+	})
+	if err != nil {
+		t.Fatalf("failed to run get on the vendor API: %v", err)
+	}
+	_ = resp.Close()
+	mu.Lock()
+	if redirectHeader != "" {
+		t.Errorf("header sent to redirected host: %s", redirectHeader)
+	}
+	if apiHeaders.Get("X-Api-Key") != "" {
+		t.Errorf("registry header sent to the vendor API")
+	}
+	if apiHeaders.Get("X-Vendor-Token") != apiSecret {
+		t.Errorf("vendor API header was not sent")
+	}
+	mu.Unlock()
+	logMu.Lock()
+	defer logMu.Unlock()
+	if strings.Contains(logBuf.String(), secret) || strings.Contains(logBuf.String(), apiSecret) {
+		t.Errorf("header value found in logs")
+	}
+	if !strings.Contains(logBuf.String(), "X-Api-Key") {
+		t.Errorf("header not found in logs")
+	}
+}
+
+func TestCredHelperRefresh(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	// the helper returns a new password each time it runs, only the second is accepted
+	tempDir := t.TempDir()
+	helper := filepath.Join(tempDir, "docker-credential-counter")
+	script := `#!/bin/sh
+count=$(cat "` + tempDir + `/count" 2>/dev/null || echo 0)
+count=$((count + 1))
+echo "$count" > "` + tempDir + `/count"
+echo '{"Username": "hello", "Secret": "pass'"$count"'"}'
+`
+	err := os.WriteFile(helper, []byte(script), 0700)
+	if err != nil {
+		t.Fatalf("failed to write helper: %v", err)
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "hello" || pass != "pass2" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(ts.Close)
+	tsURL, _ := url.Parse(ts.URL)
+	host := &config.Host{
+		Name:       tsURL.Host,
+		Hostname:   tsURL.Host,
+		TLS:        config.TLSDisabled,
+		CredHelper: helper,
+		CredExpire: timejson.Duration(time.Hour),
+	}
+	hc := NewClient(
+		WithConfigHostFn(func(name string) *config.Host {
+			return host
+		}),
+	)
+	resp, err := hc.Do(ctx, &Req{
+		Host:       tsURL.Host,
+		Method:     "GET",
+		Repository: "project",
+		Path:       "manifests/tag-get",
+	})
+	if err != nil {
+		t.Fatalf("failed to run get: %v", err)
+	}
+	_ = resp.Close()
+	count, err := os.ReadFile(filepath.Join(tempDir, "count"))
+	if err != nil {
+		t.Fatalf("failed to read count: %v", err)
+	}
+	if strings.TrimSpace(string(count)) != "2" {
+		t.Errorf("unexpected helper count: %s", count)
+	}
+}
+
+type syncWriter struct {
+	w  io.Writer
+	mu *sync.Mutex
+}
+
+func (sw *syncWriter) Write(p []byte) (int, error) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.Write(p)
+}
+
+func TestTransportWrap(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(ts.Close)
+	tsURL, _ := url.Parse(ts.URL)
+	host := &config.Host{
+		Name:     tsURL.Host,
+		Hostname: tsURL.Host,
+		TLS:      config.TLSDisabled,
+	}
+	var mu sync.Mutex
+	wrapped := []string{}
+	hc := NewClient(
+		WithConfigHostFn(func(name string) *config.Host {
+			return host
+		}),
+		WithTransportWrap(func(rt http.RoundTripper) http.RoundTripper {
+			return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				mu.Lock()
+				wrapped = append(wrapped, req.URL.Path)
+				mu.Unlock()
+				return rt.RoundTrip(req)
+			})
+		}),
+	)
+	resp, err := hc.Do(ctx, &Req{
+		Host:       tsURL.Host,
+		Method:     "GET",
+		Repository: "project",
+		Path:       "manifests/tag-get",
+	})
+	if err != nil {
+		t.Fatalf("failed to run get: %v", err)
+	}
+	_ = resp.Close()
+	mu.Lock()
+	defer mu.Unlock()
+	if len(wrapped) != 1 || wrapped[0] != "/v2/project/manifests/tag-get" {
+		t.Errorf("unexpected requests through the wrapped transport: %v", wrapped)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (fn roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	body := []byte("metrics body")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(ts.Close)
+	tsURL, _ := url.Parse(ts.URL)
+	host := &config.Host{
+		Name:     tsURL.Host,
+		Hostname: tsURL.Host,
+		TLS:      config.TLSDisabled,
+	}
+	m := metrics.New()
+	hc := NewClient(
+		WithConfigHostFn(func(name string) *config.Host {
+			return host
+		}),
+		WithMetrics(m),
+	)
+	resp, err := hc.Do(ctx, &Req{
+		Host:       tsURL.Host,
+		Method:     "GET",
+		Repository: "project",
+		Path:       "manifests/tag-get",
+	})
+	if err != nil {
+		t.Fatalf("failed to run get: %v", err)
+	}
+	_, err = io.ReadAll(resp)
+	_ = resp.Close()
+	if err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+	buf := &bytes.Buffer{}
+	err = m.Write(buf)
+	if err != nil {
+		t.Fatalf("failed to write metrics: %v", err)
+	}
+	for _, expect := range []string{
+		fmt.Sprintf(`regclient_requests_total{host="%s",method="GET",status="200"} 1`, tsURL.Host),
+		fmt.Sprintf(`regclient_received_bytes_total{host="%s"} %d`, tsURL.Host, len(body)),
+	} {
+		if !strings.Contains(buf.String(), expect) {
+			t.Errorf("metrics missing %s, output:\n%s", expect, buf.String())
+		}
+	}
+}
+
+func TestTraceLog(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	secret := "secret-value"
+	tsBlob := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("blob"))
+	}))
+	t.Cleanup(tsBlob.Close)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: secret})
+		http.Redirect(w, r, tsBlob.URL+"/blob?X-Amz-Signature="+secret+"&X-Amz-Expires=300", http.StatusTemporaryRedirect)
+	}))
+	t.Cleanup(ts.Close)
+	tsURL, _ := url.Parse(ts.URL)
+	host := &config.Host{
+		Name:     tsURL.Host,
+		Hostname: tsURL.Host,
+		TLS:      config.TLSDisabled,
+		User:     "user",
+		Pass:     secret,
+	}
+	logBuf := &bytes.Buffer{}
+	var logMu sync.Mutex
+	hc := NewClient(
+		WithConfigHostFn(func(name string) *config.Host {
+			return host
+		}),
+		WithLog(slog.New(slog.NewTextHandler(&syncWriter{w: logBuf, mu: &logMu}, &slog.HandlerOptions{Level: types.LevelTrace}))),
+	)
+	resp, err := hc.Do(ctx, &Req{
+		Host:       tsURL.Host,
+		Method:     "GET",
+		Repository: "project",
+		Path:       "blobs/redirect",
+		Headers:    http.Header{"Proxy-Authorization": {"Basic " + secret}},
+	})
+	if err != nil {
+		t.Fatalf("failed to run get: %v", err)
+	}
+	_, _ = io.ReadAll(resp)
+	_ = resp.Close()
+	logMu.Lock()
+	defer logMu.Unlock()
+	out := logBuf.String()
+	if strings.Contains(out, secret) {
+		t.Errorf("secret found in logs:\n%s", out)
+	}
+	for _, expect := range []string{"duration=", "X-Amz-Expires=300", "X-Amz-Signature=censored"} {
+		if !strings.Contains(out, expect) {
+			t.Errorf("log missing %s:\n%s", expect, out)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package semver
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/regclient/regclient/types/errs"
+)
+
+// Constraint is a set of version ranges, e.g. ">=1.20 <2.0 || ~3.1".
+//
+// Terms separated by spaces or commas must all match, and "||" separates alternatives.
+// The operators are "=", "!=", ">", ">=", "<", "<=", "~", and "^", with "=" used when no operator is given.
+// Only the fields included in the constraint are compared, so "<2.0" matches every 1.x.x version and "1.20" matches 1.20.x.
+// Trailing "x" or "*" fields are the same as leaving the field off, e.g. "1.x".
+// The "~" operator allows the patch to increase when a minor is given, e.g. "~1.20.3" matches 1.20.x at or above 1.20.3.
+// The "^" operator allows anything but the first non-zero field to increase, e.g. "^1.20" matches 1.x.x at or above 1.20.
+type Constraint struct {
+	alts [][]term
+	orig string
+}
+
+type term struct {
+	op  string
+	ver Version
+}
+
+var constraintOps = []string{">=", "<=", "!=", ">", "<", "=", "~", "^"}
+
+// ParseConstraint returns the constraint from a string.
+func ParseConstraint(s string) (Constraint, error) {
+	c := Constraint{orig: s}
+	for _, alt := range strings.Split(s, "||") {
+		terms := []term{}
+		op := ""
+		for _, field := range strings.FieldsFunc(alt, func(r rune) bool { return r == ' ' || r == ',' }) {
+			for _, cur := range constraintOps {
+				if strings.HasPrefix(field, cur) {
+					if op != "" {
+						return c, fmt.Errorf("missing version after %s in %s%.0w", op, s, errs.ErrParsingFailed)
+					}
+					op = cur
+					field = field[len(cur):]
+					break
+				}
+			}
+			if field == "" {
+				// operator separated from the version by a space
+				continue
+			}
+			v, err := parseConstraintVersion(field)
+			if err != nil {
+				return c, fmt.Errorf("invalid constraint %s: %w", s, err)
+			}
+			if op == "" {
+				op = "="
+			}
+			terms = append(terms, term{op: op, ver: v})
+			op = ""
+		}
+		if op != "" {
+			return c, fmt.Errorf("missing version after %s in %s%.0w", op, s, errs.ErrParsingFailed)
+		}
+		if len(terms) == 0 {
+			return c, fmt.Errorf("empty constraint in %s%.0w", s, errs.ErrParsingFailed)
+		}
+		c.alts = append(c.alts, terms)
+	}
+	return c, nil
+}
+
+// parseConstraintVersion parses a version, dropping trailing wildcard fields.
+func parseConstraintVersion(s string) (Version, error) {
+	str := s
+	for _, wild := range []string{".x", ".X", ".*"} {
+		for strings.HasSuffix(str, wild) {
+			str = strings.TrimSuffix(str, wild)
+		}
+	}
+	if str == "x" || str == "X" || str == "*" {
+		return Version{orig: s}, nil
+	}
+	v, err := Parse(str)
+	v.orig = s
+	return v, err
+}
+
+// String returns the original string that was parsed.
+func (c Constraint) String() string {
+	return c.orig
+}
+
+// Check returns true when the version matches the constraint.
+func (c Constraint) Check(v Version) bool {
+	for _, terms := range c.alts {
+		match := true
+		for _, t := range terms {
+			if !t.check(v) {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+func (t term) check(v Version) bool {
+	cmp := comparePrefix(v, t.ver, t.ver.Parts)
+	switch t.op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case "~":
+		// the patch may increase when the minor is given, otherwise the minor may increase
+		fixed := t.ver.Parts
+		if fixed < 1 {
+			fixed = 1
+		}
+		if fixed > 2 {
+			fixed = 2
+		}
+		return Compare(v, t.ver) >= 0 && comparePrefix(v, t.ver, fixed) == 0
+	case "^":
+		// fields up to the first non-zero one are fixed
+		fixed := 1
+		if t.ver.Major == 0 && t.ver.Parts > 1 {
+			fixed = 2
+			if t.ver.Minor == 0 && t.ver.Parts > 2 {
+				fixed = 3
+			}
+		}
+		return Compare(v, t.ver) >= 0 && comparePrefix(v, t.ver, fixed) == 0
+	}
+	return false
+}
+
+// comparePrefix compares the first n numeric fields of the versions, including the pre-release when all fields are compared.
+func comparePrefix(a, b Version, n int) int {
+	if n >= 3 {
+		return Compare(a, b)
+	}
+	for i, pair := range [][2]uint64{{a.Major, b.Major}, {a.Minor, b.Minor}} {
+		if i >= n {
+			break
+		}
+		if pair[0] < pair[1] {
+			return -1
+		} else if pair[0] > pair[1] {
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,157 @@
+package semver
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/regclient/regclient/types/errs"
+)
+
+func TestConstraint(t *testing.T) {
+	tt := []struct {
+		name     string
+		con      string
+		match    []string
+		mismatch []string
+		err      error
+	}{
+		{
+			name:     "range",
+			con:      ">=1.20 <2.0",
+			match:    []string{"1.20", "1.20.0", "v1.21.5", "1.99.99"},
+			mismatch: []string{"1.19.9", "2.0.0", "2.1", "0.1.0"},
+		},
+		{
+			name:     "range with commas and spaces",
+			con:      ">= 1.2.3, < 1.3",
+			match:    []string{"1.2.3", "1.2.10"},
+			mismatch: []string{"1.2.2", "1.3.0", "1.2.3-rc.1"},
+		},
+		{
+			name:     "partial equal",
+			con:      "1.20",
+			match:    []string{"1.20", "1.20.0", "1.20.9"},
+			mismatch: []string{"1.2", "1.21.0", "2.20.0"},
+		},
+		{
+			name:     "wildcard",
+			con:      "1.x",
+			match:    []string{"1", "1.0.0", "1.99.3"},
+			mismatch: []string{"0.9.9", "2.0.0"},
+		},
+		{
+			name:  "match all",
+			con:   "*",
+			match: []string{"0.0.1", "1.2.3", "99"},
+		},
+		{
+			name:     "not equal",
+			con:      ">1.0 !=1.5",
+			match:    []string{"1.1.0", "1.4.9", "1.6.0"},
+			mismatch: []string{"1.0.9", "1.5.0", "1.5.3"},
+		},
+		{
+			name:     "tilde patch",
+			con:      "~1.20.3",
+			match:    []string{"1.20.3", "1.20.9"},
+			mismatch: []string{"1.20.2", "1.21.0", "2.20.3"},
+		},
+		{
+			name:     "tilde minor",
+			con:      "~1.20",
+			match:    []string{"1.20.0", "1.20.9"},
+			mismatch: []string{"1.19.9", "1.21.0"},
+		},
+		{
+			name:     "tilde major",
+			con:      "~1",
+			match:    []string{"1.0.0", "1.20.9"},
+			mismatch: []string{"0.9.0", "2.0.0"},
+		},
+		{
+			name:     "caret",
+			con:      "^1.20.3",
+			match:    []string{"1.20.3", "1.21.0", "1.99.0"},
+			mismatch: []string{"1.20.2", "2.0.0"},
+		},
+		{
+			name:     "caret zero major",
+			con:      "^0.2.3",
+			match:    []string{"0.2.3", "0.2.9"},
+			mismatch: []string{"0.3.0", "0.2.2", "1.0.0"},
+		},
+		{
+			name:     "caret zero minor",
+			con:      "^0.0.3",
+			match:    []string{"0.0.3"},
+			mismatch: []string{"0.0.4", "0.1.0"},
+		},
+		{
+			name:     "alternatives",
+			con:      "~1.20 || >=3",
+			match:    []string{"1.20.5", "3.0.0", "4.1.0"},
+			mismatch: []string{"1.21.0", "2.0.0"},
+		},
+		{
+			name:     "pre-release",
+			con:      ">=1.2.3-rc.2",
+			match:    []string{"1.2.3-rc.2", "1.2.3-rc.10", "1.2.3"},
+			mismatch: []string{"1.2.3-rc.1", "1.2.2"},
+		},
+		{
+			name: "missing version",
+			con:  ">=1.0 <",
+			err:  errs.ErrParsingFailed,
+		},
+		{
+			name: "double operator",
+			con:  ">= <1.0",
+			err:  errs.ErrParsingFailed,
+		},
+		{
+			name: "empty alternative",
+			con:  "1.0 ||",
+			err:  errs.ErrParsingFailed,
+		},
+		{
+			name: "invalid version",
+			con:  ">=latest",
+			err:  errs.ErrParsingFailed,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := ParseConstraint(tc.con)
+			if tc.err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Errorf("unexpected error, expected %v, received %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			if c.String() != tc.con {
+				t.Errorf("unexpected string, expected %s, received %s", tc.con, c.String())
+			}
+			for _, s := range tc.match {
+				v, err := Parse(s)
+				if err != nil {
+					t.Fatalf("failed to parse %s: %v", s, err)
+				}
+				if !c.Check(v) {
+					t.Errorf("%s did not match %s", s, tc.con)
+				}
+			}
+			for _, s := range tc.mismatch {
+				v, err := Parse(s)
+				if err != nil {
+					t.Fatalf("failed to parse %s: %v", s, err)
+				}
+				if c.Check(v) {
+					t.Errorf("%s matched %s", s, tc.con)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+// Package semver parses and compares semantic versions found in image tags.
+//
+// Tags commonly drop the patch or minor version and may include a leading "v", e.g. "v1.2" or "3".
+// These are accepted with the missing fields set to 0.
+package semver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/regclient/regclient/types/errs"
+)
+
+// Version is a parsed semantic version.
+type Version struct {
+	Major, Minor, Patch uint64
+	// Pre contains the dot separated pre-release identifiers, e.g. "rc.1" is ["rc", "1"].
+	Pre []string
+	// Build is the build metadata, which is ignored when comparing.
+	Build string
+	// Parts is the number of numeric fields in the original string, between 1 and 3.
+	Parts int
+	orig  string
+}
+
+// Parse returns the version from a string.
+func Parse(s string) (Version, error) {
+	v := Version{orig: s}
+	str := strings.TrimPrefix(s, "v")
+	if i := strings.Index(str, "+"); i >= 0 {
+		v.Build = str[i+1:]
+		str = str[:i]
+		if v.Build == "" {
+			return v, fmt.Errorf("empty build metadata in %s%.0w", s, errs.ErrParsingFailed)
+		}
+	}
+	if i := strings.Index(str, "-"); i >= 0 {
+		pre := str[i+1:]
+		str = str[:i]
+		if pre == "" {
+			return v, fmt.Errorf("empty pre-release in %s%.0w", s, errs.ErrParsingFailed)
+		}
+		v.Pre = strings.Split(pre, ".")
+		for _, p := range v.Pre {
+			if p == "" {
+				return v, fmt.Errorf("empty pre-release identifier in %s%.0w", s, errs.ErrParsingFailed)
+			}
+		}
+	}
+	nums := strings.Split(str, ".")
+	if len(nums) > 3 {
+		return v, fmt.Errorf("too many version fields in %s%.0w", s, errs.ErrParsingFailed)
+	}
+	vals := []*uint64{&v.Major, &v.Minor, &v.Patch}
+	for i, num := range nums {
+		if num == "" || strings.Trim(num, "0123456789") != "" {
+			return v, fmt.Errorf("invalid version field %q in %s%.0w", num, s, errs.ErrParsingFailed)
+		}
+		n, err := strconv.ParseUint(num, 10, 64)
+		if err != nil {
+			return v, fmt.Errorf("invalid version field %q in %s: %w", num, s, err)
+		}
+		*vals[i] = n
+	}
+	v.Parts = len(nums)
+	return v, nil
+}
+
+// String returns the original string that was parsed.
+func (v Version) String() string {
+	if v.orig != "" {
+		return v.orig
+	}
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Pre) > 0 {
+		s += "-" + strings.Join(v.Pre, ".")
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// Compare returns -1 if a < b, 1 if a > b, and 0 when the versions have the same precedence.
+// Versions with a pre-release have a lower precedence than the release.
+func Compare(a, b Version) int {
+	for _, pair := range [][2]uint64{{a.Major, b.Major}, {a.Minor, b.Minor}, {a.Patch, b.Patch}} {
+		if pair[0] < pair[1] {
+			return -1
+		} else if pair[0] > pair[1] {
+			return 1
+		}
+	}
+	switch {
+	case len(a.Pre) == 0 && len(b.Pre) == 0:
+		return 0
+	case len(a.Pre) == 0:
+		return 1
+	case len(b.Pre) == 0:
+		return -1
+	}
+	for i := 0; i < len(a.Pre) && i < len(b.Pre); i++ {
+		if c := comparePre(a.Pre[i], b.Pre[i]); c != 0 {
+			return c
+		}
+	}
+	if len(a.Pre) < len(b.Pre) {
+		return -1
+	} else if len(a.Pre) > len(b.Pre) {
+		return 1
+	}
+	return 0
+}
+
+// comparePre compares pre-release identifiers, numeric identifiers have a lower precedence than alphanumeric.
+func comparePre(a, b string) int {
+	aNum, aErr := strconv.ParseUint(a, 10, 64)
+	bNum, bErr := strconv.ParseUint(b, 10, 64)
+	switch {
+	case aErr == nil && bErr == nil:
+		if aNum < bNum {
+			return -1
+		} else if aNum > bNum {
+			return 1
+		}
+		return 0
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,140 @@
+package semver
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/regclient/regclient/types/errs"
+)
+
+func TestParse(t *testing.T) {
+	tt := []struct {
+		name   string
+		str    string
+		expect Version
+		err    error
+	}{
+		{
+			name:   "full",
+			str:    "1.2.3",
+			expect: Version{Major: 1, Minor: 2, Patch: 3, Parts: 3},
+		},
+		{
+			name:   "prefix",
+			str:    "v10.20.30",
+			expect: Version{Major: 10, Minor: 20, Patch: 30, Parts: 3},
+		},
+		{
+			name:   "major",
+			str:    "3",
+			expect: Version{Major: 3, Parts: 1},
+		},
+		{
+			name:   "minor",
+			str:    "v1.2",
+			expect: Version{Major: 1, Minor: 2, Parts: 2},
+		},
+		{
+			name:   "pre and build",
+			str:    "1.2.3-rc.1+build.5",
+			expect: Version{Major: 1, Minor: 2, Patch: 3, Pre: []string{"rc", "1"}, Build: "build.5", Parts: 3},
+		},
+		{
+			name:   "variant",
+			str:    "1.25-alpine",
+			expect: Version{Major: 1, Minor: 25, Pre: []string{"alpine"}, Parts: 2},
+		},
+		{
+			name: "latest",
+			str:  "latest",
+			err:  errs.ErrParsingFailed,
+		},
+		{
+			name: "empty",
+			str:  "",
+			err:  errs.ErrParsingFailed,
+		},
+		{
+			name: "too many fields",
+			str:  "1.2.3.4",
+			err:  errs.ErrParsingFailed,
+		},
+		{
+			name: "empty pre",
+			str:  "1.2.3-",
+			err:  errs.ErrParsingFailed,
+		},
+		{
+			name: "signed",
+			str:  "1.-2.3",
+			err:  errs.ErrParsingFailed,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			v, err := Parse(tc.str)
+			if tc.err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Errorf("unexpected error, expected %v, received %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			if v.Major != tc.expect.Major || v.Minor != tc.expect.Minor || v.Patch != tc.expect.Patch ||
+				v.Parts != tc.expect.Parts || v.Build != tc.expect.Build || len(v.Pre) != len(tc.expect.Pre) {
+				t.Fatalf("unexpected version, expected %v, received %v", tc.expect, v)
+			}
+			for i := range v.Pre {
+				if v.Pre[i] != tc.expect.Pre[i] {
+					t.Errorf("unexpected pre-release, expected %v, received %v", tc.expect.Pre, v.Pre)
+				}
+			}
+			if v.String() != tc.str {
+				t.Errorf("unexpected string, expected %s, received %s", tc.str, v.String())
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	// each entry has a lower precedence than the next, following the semver spec examples
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"v1.0.1",
+		"1.2",
+		"1.10.0",
+		"2",
+	}
+	for i := range ordered {
+		a, err := Parse(ordered[i])
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", ordered[i], err)
+		}
+		if Compare(a, a) != 0 {
+			t.Errorf("%s does not equal itself", ordered[i])
+		}
+		for j := i + 1; j < len(ordered); j++ {
+			b, err := Parse(ordered[j])
+			if err != nil {
+				t.Fatalf("failed to parse %s: %v", ordered[j], err)
+			}
+			if Compare(a, b) != -1 || Compare(b, a) != 1 {
+				t.Errorf("expected %s < %s", ordered[i], ordered[j])
+			}
+		}
+	}
+	a, _ := Parse("1.2.3+build.1")
+	b, _ := Parse("v1.2.3+build.2")
+	if Compare(a, b) != 0 {
+		t.Errorf("build metadata should be ignored")
+	}
+}
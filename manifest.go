@@ -9,6 +9,7 @@ import (
 	"github.com/regclient/regclient/types/descriptor"
 	"github.com/regclient/regclient/types/errs"
 	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/mediatype"
 	"github.com/regclient/regclient/types/platform"
 	"github.com/regclient/regclient/types/ref"
 	"github.com/regclient/regclient/types/warning"
@@ -171,6 +172,9 @@ func (rc *RegClient) ManifestHead(ctx context.Context, r ref.Ref, opts ...Manife
 	for opt.platform != nil && m.IsList() {
 		if !m.IsSet() {
 			m, err = schemeAPI.ManifestGet(ctx, r)
+			if err != nil {
+				return m, err
+			}
 		}
 		d, err := manifest.GetPlatformDesc(m, opt.platform)
 		if err != nil {
@@ -188,6 +192,45 @@ func (rc *RegClient) ManifestHead(ctx context.Context, r ref.Ref, opts ...Manife
 	return m, err
 }
 
+// ManifestPlatform resolves an Index or Manifest List to the descriptor of the image for a platform.
+// Nested indexes are followed, selecting the best compatible platform at each level using [platform.Compatible].
+// If the reference is not an Index or Manifest List, the descriptor of the manifest is returned.
+func (rc *RegClient) ManifestPlatform(ctx context.Context, r ref.Ref, p platform.Platform) (descriptor.Descriptor, error) {
+	if !r.IsSet() {
+		return descriptor.Descriptor{}, fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), errs.ErrInvalidReference)
+	}
+	// dedup warnings
+	if w := warning.FromContext(ctx); w == nil {
+		ctx = warning.NewContext(ctx, &warning.Warning{Hook: warning.DefaultHook()})
+	}
+	m, err := rc.ManifestGet(ctx, r)
+	if err != nil {
+		return descriptor.Descriptor{}, err
+	}
+	seen := map[string]bool{}
+	for m.IsList() {
+		dig := m.GetDescriptor().Digest.String()
+		if seen[dig] {
+			return descriptor.Descriptor{}, fmt.Errorf("index %s references itself%.0w", dig, errs.ErrLoopDetected)
+		}
+		seen[dig] = true
+		d, err := manifest.GetPlatformDesc(m, &p)
+		if err != nil {
+			return descriptor.Descriptor{}, err
+		}
+		// only pull the child manifest when it is another index
+		if d.MediaType != mediatype.OCI1ManifestList && d.MediaType != mediatype.Docker2ManifestList {
+			return *d, nil
+		}
+		r = r.SetDigest(d.Digest.String())
+		m, err = rc.ManifestGet(ctx, r, WithManifestDesc(*d))
+		if err != nil {
+			return descriptor.Descriptor{}, err
+		}
+	}
+	return m.GetDescriptor(), nil
+}
+
 // ManifestPut pushes a manifest.
 // Any descriptors referenced by the manifest typically need to be pushed first.
 func (rc *RegClient) ManifestPut(ctx context.Context, r ref.Ref, m manifest.Manifest, opts ...ManifestOpts) error {
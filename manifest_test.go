@@ -368,6 +368,66 @@ func TestManifest(t *testing.T) {
 			t.Errorf("Expected error %v, received %v", errs.ErrNotFound, err)
 		}
 	})
+	t.Run("Platform", func(t *testing.T) {
+		r, err := ref.New(tsOlaregHost + "/" + repoPath + ":" + goodTag)
+		if err != nil {
+			t.Fatalf("Failed creating ref: %v", err)
+		}
+		mList, err := rc.ManifestGet(ctx, r)
+		if err != nil {
+			t.Fatalf("Failed running ManifestGet: %v", err)
+		}
+		for _, pStr := range []string{"linux/amd64", "linux/arm64/v8"} {
+			p, err := platform.Parse(pStr)
+			if err != nil {
+				t.Fatalf("Failed parsing platform: %v", err)
+			}
+			dExpect, err := manifest.GetPlatformDesc(mList, &p)
+			if err != nil {
+				t.Fatalf("Failed to get platform descriptor: %v", err)
+			}
+			d, err := rc.ManifestPlatform(ctx, r, p)
+			if err != nil {
+				t.Fatalf("Failed running ManifestPlatform: %v", err)
+			}
+			if d.Digest != dExpect.Digest || d.MediaType != dExpect.MediaType || d.Size != dExpect.Size {
+				t.Errorf("unexpected descriptor for %s, expected %v, received %v", pStr, *dExpect, d)
+			}
+		}
+		// an image returns its own descriptor
+		p, err := platform.Parse("linux/amd64")
+		if err != nil {
+			t.Fatalf("Failed parsing platform: %v", err)
+		}
+		dImage, err := rc.ManifestPlatform(ctx, r, p)
+		if err != nil {
+			t.Fatalf("Failed running ManifestPlatform: %v", err)
+		}
+		d, err := rc.ManifestPlatform(ctx, r.SetDigest(dImage.Digest.String()), p)
+		if err != nil {
+			t.Fatalf("Failed running ManifestPlatform on image: %v", err)
+		}
+		if d.Digest != dImage.Digest {
+			t.Errorf("unexpected digest, expected %s, received %s", dImage.Digest, d.Digest)
+		}
+	})
+	t.Run("Platform Missing", func(t *testing.T) {
+		r, err := ref.New(tsOlaregHost + "/" + repoPath + ":" + goodTag)
+		if err != nil {
+			t.Fatalf("Failed creating ref: %v", err)
+		}
+		p, err := platform.Parse("linux/ppc64le")
+		if err != nil {
+			t.Fatalf("Failed parsing platform: %v", err)
+		}
+		_, err = rc.ManifestPlatform(ctx, r, p)
+		if err == nil {
+			t.Fatalf("Success running ManifestPlatform on missing platform")
+		}
+		if !errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("Expected error %v, received %v", errs.ErrNotFound, err)
+		}
+	})
 	t.Run("Data", func(t *testing.T) {
 		r, err := ref.New(tsInternalHost + "/" + repoPath + ":data")
 		if err != nil {
@@ -55,6 +55,48 @@ func WithBuildArgRm(arg string, value *regexp.Regexp) Opts {
 	}
 }
 
+// WithBuildArgRmAll removes every build arg from the config history.
+// This deletes the ARG history entries and the build args prefixed to RUN steps.
+func WithBuildArgRmAll() Opts {
+	return func(dc *dagConfig, dm *dagManifest) error {
+		argexp := regexp.MustCompile(`^ARG `)
+		runexp := regexp.MustCompile(`(?s)^RUN \|([0-9]+) (.*)$`)
+		kvexp := regexp.MustCompile(`^[^=\s]+=\S* `)
+		dc.stepsOCIConfig = append(dc.stepsOCIConfig, func(ctx context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, doc *dagOCIConfig) error {
+			changed := false
+			oc := doc.oc.GetConfig()
+			for i := len(oc.History) - 1; i >= 0; i-- {
+				if argexp.MatchString(oc.History[i].CreatedBy) && oc.History[i].EmptyLayer {
+					oc.History = append(oc.History[:i], oc.History[i+1:]...)
+					changed = true
+				} else if match := runexp.FindStringSubmatch(oc.History[i].CreatedBy); len(match) == 3 {
+					count, err := strconv.Atoi(match[1])
+					if err != nil {
+						return fmt.Errorf("failed parsing history \"%s\": %w", oc.History[i].CreatedBy, err)
+					}
+					// each build arg is a name=value pair before the command
+					cmd := match[2]
+					for j := 0; j < count; j++ {
+						kv := kvexp.FindString(cmd)
+						if kv == "" {
+							return fmt.Errorf("failed parsing build args in history \"%s\"", oc.History[i].CreatedBy)
+						}
+						cmd = cmd[len(kv):]
+					}
+					oc.History[i].CreatedBy = "RUN " + cmd
+					changed = true
+				}
+			}
+			if changed {
+				doc.oc.SetConfig(oc)
+				doc.modified = true
+			}
+			return nil
+		})
+		return nil
+	}
+}
+
 // WithConfigCmd sets the command in the config.
 // For running a shell command, the `cmd` value should be `[]string{"/bin/sh", "-c", command}`.
 func WithConfigCmd(cmd []string) Opts {
@@ -207,6 +249,9 @@ func WithConfigTimestamp(optTime OptTime) Opts {
 				*oc.Created, changed = timeModOpt(*oc.Created, optTime)
 			}
 			for i := startHistory; i < len(oc.History); i++ {
+				if oc.History[i].Created == nil {
+					continue
+				}
 				*oc.History[i].Created, cCur = timeModOpt(*oc.History[i].Created, optTime)
 				changed = changed || cCur
 			}
@@ -272,6 +317,42 @@ func WithConfigTimestampMax(t time.Time) Opts {
 	})
 }
 
+// WithConfigUser sets the user in the config, e.g. "1000:1000".
+// An empty string deletes the user so the container runs as root.
+func WithConfigUser(user string) Opts {
+	return func(dc *dagConfig, dm *dagManifest) error {
+		dc.stepsOCIConfig = append(dc.stepsOCIConfig, func(ctx context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, doc *dagOCIConfig) error {
+			oc := doc.oc.GetConfig()
+			if oc.Config.User == user {
+				return nil
+			}
+			oc.Config.User = user
+			doc.oc.SetConfig(oc)
+			doc.modified = true
+			return nil
+		})
+		return nil
+	}
+}
+
+// WithConfigWorkingDir sets the working directory in the config.
+// An empty string deletes the working directory.
+func WithConfigWorkingDir(dir string) Opts {
+	return func(dc *dagConfig, dm *dagManifest) error {
+		dc.stepsOCIConfig = append(dc.stepsOCIConfig, func(ctx context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, doc *dagOCIConfig) error {
+			oc := doc.oc.GetConfig()
+			if oc.Config.WorkingDir == dir {
+				return nil
+			}
+			oc.Config.WorkingDir = dir
+			doc.oc.SetConfig(oc)
+			doc.modified = true
+			return nil
+		})
+		return nil
+	}
+}
+
 // WithEnv sets or deletes an environment variable from the image config.
 func WithEnv(name, value string) Opts {
 	return func(dc *dagConfig, dm *dagManifest) error {
@@ -397,6 +478,65 @@ func WithExposeRm(port string) Opts {
 	}
 }
 
+// WithHistoryCreatedBy replaces text in the created by field of the config history.
+// Each match of the regexp is replaced with repl, which may reference submatches, e.g. "${1}".
+// An empty repl deletes the matching text.
+func WithHistoryCreatedBy(createdBy *regexp.Regexp, repl string) Opts {
+	return func(dc *dagConfig, dm *dagManifest) error {
+		dc.stepsOCIConfig = append(dc.stepsOCIConfig, func(ctx context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, doc *dagOCIConfig) error {
+			changed := false
+			oc := doc.oc.GetConfig()
+			for i, h := range oc.History {
+				cb := createdBy.ReplaceAllString(h.CreatedBy, repl)
+				if cb != h.CreatedBy {
+					oc.History[i].CreatedBy = cb
+					changed = true
+				}
+			}
+			if changed {
+				doc.oc.SetConfig(oc)
+				doc.modified = true
+			}
+			return nil
+		})
+		return nil
+	}
+}
+
+// WithHistoryRm removes entries from the config history with a created by field matching the regexp.
+// A nil regexp matches every entry.
+// Entries for a layer cannot be removed without changing the layers,
+// so those entries are kept with the created by, author, and comment fields cleared.
+func WithHistoryRm(createdBy *regexp.Regexp) Opts {
+	return func(dc *dagConfig, dm *dagManifest) error {
+		dc.stepsOCIConfig = append(dc.stepsOCIConfig, func(ctx context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, doc *dagOCIConfig) error {
+			changed := false
+			oc := doc.oc.GetConfig()
+			for i := len(oc.History) - 1; i >= 0; i-- {
+				h := oc.History[i]
+				if createdBy != nil && !createdBy.MatchString(h.CreatedBy) {
+					continue
+				}
+				if h.EmptyLayer {
+					oc.History = append(oc.History[:i], oc.History[i+1:]...)
+					changed = true
+				} else if h.CreatedBy != "" || h.Author != "" || h.Comment != "" {
+					oc.History[i].CreatedBy = ""
+					oc.History[i].Author = ""
+					oc.History[i].Comment = ""
+					changed = true
+				}
+			}
+			if changed {
+				doc.oc.SetConfig(oc)
+				doc.modified = true
+			}
+			return nil
+		})
+		return nil
+	}
+}
+
 // WithLabel sets or deletes a label from the image config.
 func WithLabel(name, value string) Opts {
 	return func(dc *dagConfig, dm *dagManifest) error {
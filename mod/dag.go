@@ -36,6 +36,8 @@ type dagConfig struct {
 	maxDataSize    int64
 	rTgt           ref.Ref
 	forceLayerWalk bool
+	referrersCopy  bool     // copy referrers when the target is a different repository
+	referrersSkip  []string // artifact types of referrers that are not moved to a new digest
 }
 
 type dagManifest struct {
@@ -48,6 +50,7 @@ type dagManifest struct {
 	layers    []*dagLayer
 	manifests []*dagManifest
 	referrers []*dagManifest
+	artType   string // artifact type of a referrer
 }
 
 type dagOCIConfig struct {
@@ -62,6 +65,7 @@ type dagLayer struct {
 	ucDigest digest.Digest // uncompressed descriptor
 	desc     descriptor.Descriptor
 	rSrc     ref.Ref
+	history  *v1.History // history entry for added layers
 }
 
 func dagGet(ctx context.Context, rc *regclient.RegClient, rSrc ref.Ref, d descriptor.Descriptor) (*dagManifest, error) {
@@ -123,6 +127,7 @@ func dagGet(ctx context.Context, rc *regclient.RegClient, rSrc ref.Ref, d descri
 	}
 	for _, desc := range rl.Descriptors {
 		// strip referrers metadata from descriptor (annotations and artifact type)
+		artType := desc.ArtifactType
 		desc.ArtifactType = ""
 		if len(desc.Annotations) > 0 {
 			desc.Annotations = nil
@@ -132,6 +137,7 @@ func dagGet(ctx context.Context, rc *regclient.RegClient, rSrc ref.Ref, d descri
 		if err != nil {
 			return nil, err
 		}
+		curMM.artType = artType
 		dm.referrers = append(dm.referrers, curMM)
 	}
 	return &dm, nil
@@ -282,6 +288,15 @@ func dagPut(ctx context.Context, rc *regclient.RegClient, mc dagConfig, rSrc, rT
 					Created: &timeStart,
 					Comment: "regclient",
 				}
+				if layer.history != nil {
+					newHistory = *layer.history
+					if newHistory.Created == nil {
+						newHistory.Created = &timeStart
+					}
+					if newHistory.Comment == "" {
+						newHistory.Comment = "regclient"
+					}
+				}
 				if iConfig < 0 {
 					// noop
 				} else if iConfig >= len(oc.History) {
@@ -342,7 +357,10 @@ func dagPut(ctx context.Context, rc *regclient.RegClient, mc dagConfig, rSrc, rT
 				if err != nil {
 					return err
 				}
-				ociM.Config.MediaType = dm.config.newDesc.MediaType
+				// keep the media type from the manifest, it may have been changed by a manifest step
+				if ociM.Config.MediaType == "" {
+					ociM.Config.MediaType = dm.config.newDesc.MediaType
+				}
 				ociM.Config.Digest = dm.config.newDesc.Digest
 				ociM.Config.Size = dm.config.newDesc.Size
 				changed = true
@@ -401,12 +419,23 @@ func dagPut(ctx context.Context, rc *regclient.RegClient, mc dagConfig, rSrc, rT
 	if dm.mod == replaced || dm.mod == added {
 		dm.newDesc = dm.m.GetDescriptor()
 	}
-	if ref.EqualRepository(rSrc, rTgt) {
-		// only update referrers when modifying a manifest in the same repository
+	sameRepo := ref.EqualRepository(rSrc, rTgt)
+	if sameRepo || mc.referrersCopy {
+		// only update referrers when modifying a manifest in the same repository, or when copying referrers is enabled
 		for i := range dm.referrers {
-			if dm.referrers[i].mod == deleted || !(dm.mod == replaced || dm.mod == added || dm.referrers[i].mod == added) {
+			if inListStr(dm.referrers[i].artType, mc.referrersSkip) && dm.referrers[i].mod != added {
+				// skipped referrers remain attached to the original digest
+				dm.referrers[i].mod = deleted
+			}
+			if dm.referrers[i].mod == deleted || (sameRepo && !(dm.mod == replaced || dm.mod == added || dm.referrers[i].mod == added)) {
 				continue
 			}
+			if !sameRepo && dm.referrers[i].mod != added {
+				err = dagCopyReferrerBlobs(ctx, rc, rSrc, rTgt, dm.referrers[i])
+				if err != nil {
+					return err
+				}
+			}
 			sm, ok := dm.referrers[i].m.(manifest.Subjecter)
 			if !ok {
 				return fmt.Errorf("referrer does not support subject field, mt=%s", dm.referrers[i].m.GetDescriptor().MediaType)
@@ -456,6 +485,39 @@ func dagPut(ctx context.Context, rc *regclient.RegClient, mc dagConfig, rSrc, rT
 	return nil
 }
 
+// dagCopyReferrerBlobs copies the layers and child manifests of a referrer to another repository.
+// The config is copied by dagPut.
+func dagCopyReferrerBlobs(ctx context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, dm *dagManifest) error {
+	switch mm := dm.m.(type) {
+	case manifest.Imager:
+		layers, err := mm.GetLayers()
+		if err != nil {
+			return err
+		}
+		for _, d := range layers {
+			if len(d.URLs) > 0 {
+				continue
+			}
+			err = rc.BlobCopy(ctx, rSrc, rTgt, d)
+			if err != nil {
+				return err
+			}
+		}
+	case manifest.Indexer:
+		dl, err := mm.GetManifestList()
+		if err != nil {
+			return err
+		}
+		for _, d := range dl {
+			err = rc.ImageCopy(ctx, rSrc.SetDigest(d.Digest.String()), rTgt.SetDigest(d.Digest.String()), regclient.ImageWithChild())
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func dagWalkManifests(dm *dagManifest, fn func(*dagManifest) (*dagManifest, error)) error {
 	if dm.manifests != nil {
 		for _, child := range dm.manifests {
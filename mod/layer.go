@@ -7,8 +7,10 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -20,6 +22,7 @@ import (
 	"github.com/regclient/regclient/types/errs"
 	"github.com/regclient/regclient/types/manifest"
 	"github.com/regclient/regclient/types/mediatype"
+	v1 "github.com/regclient/regclient/types/oci/v1"
 	"github.com/regclient/regclient/types/platform"
 	"github.com/regclient/regclient/types/ref"
 )
@@ -28,7 +31,21 @@ import (
 // If media type (mt) is not defined, it will default to Gzip and match Docker or OCI based on the manifest media type.
 // If the platform slice is empty, the layer is added to all platforms.
 func WithLayerAddTar(rdr io.Reader, mt string, platforms []platform.Platform) Opts {
+	return layerAddTar(rdr, mt, platforms, nil)
+}
+
+// WithLayerAddTarHistory appends a new layer to the image with a history entry in the config.
+// See [WithLayerAddTar] for details on the other arguments.
+// The created time defaults to the current time and comment defaults to "regclient" when they are not set.
+func WithLayerAddTarHistory(rdr io.Reader, mt string, platforms []platform.Platform, history v1.History) Opts {
+	return layerAddTar(rdr, mt, platforms, &history)
+}
+
+func layerAddTar(rdr io.Reader, mt string, platforms []platform.Platform, history *v1.History) Opts {
 	return func(dc *dagConfig, dm *dagManifest) error {
+		if history != nil && history.EmptyLayer {
+			return fmt.Errorf("history of a new layer cannot be an empty layer")
+		}
 		if mt == "" {
 			switch dm.m.GetDescriptor().MediaType {
 			case mediatype.Docker2Manifest, mediatype.Docker2ManifestList:
@@ -96,6 +113,7 @@ func WithLayerAddTar(rdr io.Reader, mt string, platforms []platform.Platform) Op
 				mod:      added,
 				desc:     desc,
 				ucDigest: ucDig,
+				history:  history,
 			})
 			return nil
 		})
@@ -104,15 +122,32 @@ func WithLayerAddTar(rdr io.Reader, mt string, platforms []platform.Platform) Op
 }
 
 // WithLayerCompression alters the media type and compression algorithm of the layers.
-func WithLayerCompression(algo archive.CompressType) Opts {
+// Compression options, like the level, are passed to [archive.Compress].
+// Layers that already use the algorithm are only recompressed when options are provided.
+func WithLayerCompression(algo archive.CompressType, opts ...archive.CompressOpts) Opts {
+	return layerCompression(algo, "", opts)
+}
+
+// WithLayerCompressionDigest alters the media type and compression algorithm of a single layer.
+// The digest is the current digest of the layer in the manifest.
+func WithLayerCompressionDigest(d digest.Digest, algo archive.CompressType, opts ...archive.CompressOpts) Opts {
+	return layerCompression(algo, d, opts)
+}
+
+func layerCompression(algo archive.CompressType, d digest.Digest, opts []archive.CompressOpts) Opts {
 	return func(dc *dagConfig, dm *dagManifest) error {
 		switch algo {
 		case archive.CompressNone, archive.CompressGzip, archive.CompressZstd:
 		default:
 			return fmt.Errorf("unsupported layer compression: %s", algo.String())
 		}
+		if d != "" {
+			if err := d.Validate(); err != nil {
+				return fmt.Errorf("invalid layer digest %s: %w", d.String(), err)
+			}
+		}
 		dc.stepsLayer = append(dc.stepsLayer, func(ctx context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, dl *dagLayer, rdr io.ReadCloser) (io.ReadCloser, error) {
-			if dl.mod == deleted {
+			if dl.mod == deleted || (d != "" && dl.desc.Digest != d) {
 				return rdr, nil
 			}
 			desc := dl.desc
@@ -126,52 +161,22 @@ func WithLayerCompression(algo archive.CompressType) Opts {
 			}
 			desc.Digest = ""
 			switch algo {
-			case archive.CompressGzip:
-				switch desc.MediaType {
-				case mediatype.Docker2Layer, mediatype.Docker2LayerZstd:
-					desc.MediaType = mediatype.Docker2LayerGzip
-				case mediatype.OCI1Layer, mediatype.OCI1LayerZstd:
-					desc.MediaType = mediatype.OCI1LayerGzip
-				default:
-					return rdr, nil
+			case archive.CompressGzip, archive.CompressZstd:
+				mtDocker, mtOCI := mediatype.Docker2LayerGzip, mediatype.OCI1LayerGzip
+				if algo == archive.CompressZstd {
+					mtDocker, mtOCI = mediatype.Docker2LayerZstd, mediatype.OCI1LayerZstd
 				}
-				if dl.mod == unchanged {
-					dl.mod = replaced
-				}
-				dl.newDesc = desc
-				digRaw := desc.DigestAlgo().Digester() // raw/compressed digest
-				digUC := desc.DigestAlgo().Digester()  // uncompressed digest
-				ucRdr, err := archive.Decompress(rdr)
-				if err != nil {
-					_ = rdr.Close()
-					return nil, err
-				}
-				ucDigRdr := io.TeeReader(ucRdr, digUC.Hash())
-				cRdr, err := archive.Compress(ucDigRdr, algo)
-				if err != nil {
-					_ = rdr.Close()
-					return nil, err
-				}
-				digRdr := io.TeeReader(cRdr, digRaw.Hash())
-				return readCloserFn{
-					Reader: digRdr,
-					closeFn: func() error {
-						err := rdr.Close()
-						if err != nil {
-							return err
-						}
-						_ = cRdr.Close()
-						dl.newDesc.Digest = digRaw.Digest()
-						dl.ucDigest = digUC.Digest()
-						return nil
-					}}, nil
-
-			case archive.CompressZstd:
 				switch desc.MediaType {
-				case mediatype.Docker2Layer, mediatype.Docker2LayerGzip:
-					desc.MediaType = mediatype.Docker2LayerZstd
-				case mediatype.OCI1Layer, mediatype.OCI1LayerGzip:
-					desc.MediaType = mediatype.OCI1LayerZstd
+				case mediatype.Docker2Layer, mediatype.Docker2LayerGzip, mediatype.Docker2LayerZstd:
+					if desc.MediaType == mtDocker && len(opts) == 0 {
+						return rdr, nil
+					}
+					desc.MediaType = mtDocker
+				case mediatype.OCI1Layer, mediatype.OCI1LayerGzip, mediatype.OCI1LayerZstd:
+					if desc.MediaType == mtOCI && len(opts) == 0 {
+						return rdr, nil
+					}
+					desc.MediaType = mtOCI
 				default:
 					return rdr, nil
 				}
@@ -187,7 +192,7 @@ func WithLayerCompression(algo archive.CompressType) Opts {
 					return nil, err
 				}
 				ucDigRdr := io.TeeReader(ucRdr, digUC.Hash())
-				cRdr, err := archive.Compress(ucDigRdr, algo)
+				cRdr, err := archive.Compress(ucDigRdr, algo, opts...)
 				if err != nil {
 					_ = rdr.Close()
 					return nil, err
@@ -317,6 +322,270 @@ func WithLayerDigestAlgo(algo digest.Algorithm) Opts {
 	}
 }
 
+// OptNormalize defines the changes made to layer tar files by [WithLayerNormalize].
+type OptNormalize struct {
+	KeepOrder bool  // do not sort the entries by name
+	KeepOwner bool  // do not reset the uid, gid, user name, and group name
+	FileMode  int64 // permissions of regular files without an executable bit, 0 keeps the current value
+	ExecMode  int64 // permissions of regular files with any executable bit, 0 keeps the current value
+	DirMode   int64 // permissions of directories, 0 keeps the current value
+}
+
+// WithLayerNormalize rewrites the layer tar files so the same content always results in the same digest.
+// Entries are sorted by name, the owner is set to uid and gid 0 without a user or group name,
+// access and change times are removed, and permissions are set according to the options.
+// Setting a mode also clears the setuid, setgid, and sticky bits of matching entries.
+// Modification times are not changed, see [WithLayerTimestamp].
+func WithLayerNormalize(on OptNormalize) Opts {
+	return func(dc *dagConfig, dm *dagManifest) error {
+		for _, mode := range []int64{on.FileMode, on.ExecMode, on.DirMode} {
+			if mode < 0 || mode > 0o7777 {
+				return fmt.Errorf("invalid normalize mode %o", mode)
+			}
+		}
+		if !on.KeepOrder {
+			dc.stepsLayer = append(dc.stepsLayer, layerSort)
+		}
+		dc.stepsLayerFile = append(dc.stepsLayerFile,
+			func(c context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, dl *dagLayer, th *tar.Header, tr io.Reader) (*tar.Header, io.Reader, changes, error) {
+				changed := false
+				if !on.KeepOwner && (th.Uid != 0 || th.Gid != 0 || th.Uname != "" || th.Gname != "") {
+					th.Uid, th.Gid = 0, 0
+					th.Uname, th.Gname = "", ""
+					changed = true
+				}
+				if !th.AccessTime.IsZero() || !th.ChangeTime.IsZero() {
+					th.AccessTime, th.ChangeTime = time.Time{}, time.Time{}
+					changed = true
+				}
+				mode := int64(0)
+				switch th.Typeflag {
+				case tar.TypeReg:
+					mode = on.FileMode
+					if th.Mode&0o111 != 0 {
+						mode = on.ExecMode
+					}
+				case tar.TypeDir:
+					mode = on.DirMode
+				}
+				if mode != 0 && th.Mode&0o7777 != mode {
+					th.Mode = th.Mode&^0o7777 | mode
+					changed = true
+				}
+				if changed {
+					return th, tr, replaced, nil
+				}
+				return th, tr, unchanged, nil
+			})
+		return nil
+	}
+}
+
+type layerSortEntry struct {
+	th     *tar.Header
+	offset int64 // offset of the content in the data file
+}
+
+// layerSort rewrites a layer with the tar entries sorted by name.
+// Hard links are written after their target.
+func layerSort(ctx context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, dl *dagLayer, rdr io.ReadCloser) (io.ReadCloser, error) {
+	desc := dl.desc
+	if dl.newDesc.MediaType != "" {
+		desc = dl.newDesc
+	}
+	if dl.mod == deleted || !inListStr(desc.MediaType, mtKnownTar) {
+		return rdr, nil
+	}
+	algo := archive.CompressNone
+	switch desc.MediaType {
+	case mediatype.Docker2LayerGzip, mediatype.OCI1LayerGzip:
+		algo = archive.CompressGzip
+	case mediatype.Docker2LayerZstd, mediatype.OCI1LayerZstd:
+		algo = archive.CompressZstd
+	}
+	tmpFiles := []*os.File{}
+	cleanup := func() error {
+		for _, fh := range tmpFiles {
+			_ = fh.Close()
+			_ = os.Remove(fh.Name())
+		}
+		return nil
+	}
+	tmpFile := func() (*os.File, error) {
+		fh, err := os.CreateTemp("", "regclient-mod-")
+		if err != nil {
+			return nil, err
+		}
+		tmpFiles = append(tmpFiles, fh)
+		return fh, nil
+	}
+	// save the layer so it can be returned when the entries are already sorted
+	fhRaw, err := tmpFile()
+	if err == nil {
+		_, err = io.Copy(fhRaw, rdr)
+	}
+	_ = rdr.Close()
+	if err == nil {
+		_, err = fhRaw.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		_ = cleanup()
+		return nil, err
+	}
+	// read the headers and copy the file content to a data file
+	fhData, err := tmpFile()
+	if err != nil {
+		_ = cleanup()
+		return nil, err
+	}
+	ucRdr, err := archive.Decompress(fhRaw)
+	if err != nil {
+		_ = cleanup()
+		return nil, err
+	}
+	entries := []layerSortEntry{}
+	offset := int64(0)
+	tr := tar.NewReader(ucRdr)
+	for {
+		th, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			_ = cleanup()
+			return nil, err
+		}
+		n, err := io.Copy(fhData, tr)
+		if err != nil {
+			_ = cleanup()
+			return nil, err
+		}
+		entries = append(entries, layerSortEntry{th: th, offset: offset})
+		offset += n
+	}
+	sorted := layerSortOrder(entries)
+	inOrder := true
+	for i := range sorted {
+		if sorted[i].th != entries[i].th {
+			inOrder = false
+			break
+		}
+	}
+	if inOrder {
+		_, err = fhRaw.Seek(0, io.SeekStart)
+		if err != nil {
+			_ = cleanup()
+			return nil, err
+		}
+		return readCloserFn{Reader: fhRaw, closeFn: cleanup}, nil
+	}
+	// write the sorted tar, compressed with the same algorithm
+	fhOut, err := tmpFile()
+	if err != nil {
+		_ = cleanup()
+		return nil, err
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(pw)
+		for _, e := range sorted {
+			err := tw.WriteHeader(e.th)
+			if err == nil && e.th.Size > 0 {
+				_, err = io.Copy(tw, io.NewSectionReader(fhData, e.offset, e.th.Size))
+			}
+			if err != nil {
+				_ = pw.CloseWithError(err)
+				return
+			}
+		}
+		_ = pw.CloseWithError(tw.Close())
+	}()
+	err = desc.DigestAlgoPrefer(desc.DigestAlgo())
+	if err != nil {
+		_ = pr.Close()
+		_ = cleanup()
+		return nil, fmt.Errorf("failed to configure digest algorithm for sorting layer: %w", err)
+	}
+	digRaw := desc.DigestAlgo().Digester() // raw/compressed digest
+	digUC := desc.DigestAlgo().Digester()  // uncompressed digest
+	cRdr, err := archive.Compress(io.TeeReader(pr, digUC.Hash()), algo)
+	if err != nil {
+		_ = pr.Close()
+		_ = cleanup()
+		return nil, err
+	}
+	size, err := io.Copy(io.MultiWriter(fhOut, digRaw.Hash()), cRdr)
+	_ = cRdr.Close()
+	_ = pr.Close()
+	if err == nil {
+		_, err = fhOut.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		_ = cleanup()
+		return nil, fmt.Errorf("failed to sort layer %s: %w", dl.desc.Digest.String(), err)
+	}
+	desc.Digest = digRaw.Digest()
+	desc.Size = size
+	dl.newDesc = desc
+	dl.ucDigest = digUC.Digest()
+	if dl.mod == unchanged {
+		dl.mod = replaced
+	}
+	return readCloserFn{Reader: fhOut, closeFn: cleanup}, nil
+}
+
+// layerSortOrder returns the entries sorted by name, with each hard link moved after its target.
+func layerSortOrder(entries []layerSortEntry) []layerSortEntry {
+	sorted := make([]layerSortEntry, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return layerSortName(sorted[i].th.Name) < layerSortName(sorted[j].th.Name)
+	})
+	names := map[string]bool{}
+	for _, e := range sorted {
+		names[layerSortName(e.th.Name)] = true
+	}
+	result := make([]layerSortEntry, 0, len(sorted))
+	written := map[string]bool{}
+	pending := map[string][]layerSortEntry{}
+	var add func(e layerSortEntry)
+	add = func(e layerSortEntry) {
+		name := layerSortName(e.th.Name)
+		result = append(result, e)
+		written[name] = true
+		links := pending[name]
+		delete(pending, name)
+		for _, l := range links {
+			add(l)
+		}
+	}
+	for _, e := range sorted {
+		if e.th.Typeflag == tar.TypeLink {
+			target := layerSortName(e.th.Linkname)
+			if names[target] && !written[target] {
+				pending[target] = append(pending[target], e)
+				continue
+			}
+		}
+		add(e)
+	}
+	// links in a cycle are never written by their target, keep them in the sorted order
+	for _, e := range sorted {
+		target := layerSortName(e.th.Linkname)
+		if e.th.Typeflag == tar.TypeLink && !written[target] {
+			if _, ok := pending[target]; ok {
+				result = append(result, pending[target]...)
+				delete(pending, target)
+			}
+		}
+	}
+	return result
+}
+
+func layerSortName(name string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(name, "./"), "/")
+}
+
 // WithLayerReproducible modifies the layer with reproducible options.
 // This currently configures users and groups with numeric ids.
 func WithLayerReproducible() Opts {
@@ -392,6 +661,42 @@ func WithLayerRmCreatedBy(re regexp.Regexp) Opts {
 	}
 }
 
+// WithLayerRmDigest deletes a layer from every image that includes it.
+// The digest may be the compressed layer digest from the manifest or the uncompressed diff_id from the config.
+func WithLayerRmDigest(d digest.Digest) Opts {
+	return func(dc *dagConfig, dm *dagManifest) error {
+		if err := d.Validate(); err != nil {
+			return fmt.Errorf("invalid layer digest %s: %w", d.String(), err)
+		}
+		found := false
+		dc.stepsManifest = append(dc.stepsManifest, func(c context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, dm *dagManifest) error {
+			if dm.mod != deleted && !dm.m.IsList() {
+				var diffIDs []digest.Digest
+				if dm.config != nil && dm.config.oc != nil {
+					diffIDs = dm.config.oc.GetConfig().RootFS.DiffIDs
+				}
+				curOrigLayer := 0
+				for _, dl := range dm.layers {
+					if dl.mod == added {
+						continue
+					}
+					if dl.desc.Digest == d || (curOrigLayer < len(diffIDs) && diffIDs[curOrigLayer] == d) {
+						dl.mod = deleted
+						found = true
+					}
+					curOrigLayer++
+				}
+			}
+			// the top manifest is processed last
+			if dm.top && !found {
+				return fmt.Errorf("layer not found: %s", d.String())
+			}
+			return nil
+		})
+		return nil
+	}
+}
+
 // WithLayerRmIndex deletes a layer by index. The index starts at 0.
 func WithLayerRmIndex(index int) Opts {
 	return func(dc *dagConfig, dm *dagManifest) error {
@@ -425,6 +730,269 @@ func WithLayerRmIndex(index int) Opts {
 	}
 }
 
+// WithLayerSquash merges the top count layers of each image into a single layer.
+// When count is 0 or exceeds the number of layers, all layers are squashed.
+// Files replaced or deleted by a later layer are excluded from the new layer,
+// and the history entries for the squashed layers are replaced with a single entry.
+func WithLayerSquash(count int) Opts {
+	return func(dc *dagConfig, dm *dagManifest) error {
+		if count < 0 {
+			return fmt.Errorf("squash layer count must not be negative: %d", count)
+		}
+		dc.stepsManifest = append(dc.stepsManifest, func(ctx context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, dm *dagManifest) error {
+			if dm.mod == deleted || dm.m.IsList() || dm.config == nil || dm.config.oc == nil {
+				return nil
+			}
+			// select the layers to squash
+			idx := []int{}
+			for i, dl := range dm.layers {
+				if dl.mod != deleted {
+					idx = append(idx, i)
+				}
+			}
+			squashAll := true
+			if count > 0 && count < len(idx) {
+				idx = idx[len(idx)-count:]
+				squashAll = false
+			}
+			if len(idx) < 2 {
+				return nil
+			}
+			layers := make([]*dagLayer, len(idx))
+			for i, li := range idx {
+				dl := dm.layers[li]
+				if dl.mod == added {
+					return fmt.Errorf("cannot squash a layer added by another mod")
+				}
+				if len(dl.desc.URLs) > 0 || !inListStr(dl.desc.MediaType, mtKnownTar) {
+					return fmt.Errorf("cannot squash layer %s with media type %s%.0w", dl.desc.Digest.String(), dl.desc.MediaType, errs.ErrUnsupportedMediaType)
+				}
+				layers[i] = dl
+			}
+			open := func(dl *dagLayer) (io.ReadCloser, error) {
+				r := rSrc
+				if dl.rSrc.IsSet() {
+					r = dl.rSrc
+				}
+				br, err := rc.BlobGet(ctx, r, dl.desc)
+				if err != nil {
+					return nil, err
+				}
+				dr, err := archive.Decompress(br)
+				if err != nil {
+					_ = br.Close()
+					return nil, err
+				}
+				return readCloserFn{Reader: dr, closeFn: br.Close}, nil
+			}
+			// first pass from the top layer down, selecting the entries that are visible
+			keep, err := layerSquashPlan(layers, open, !squashAll)
+			if err != nil {
+				return err
+			}
+			// second pass from the bottom layer up, writing the selected entries to the new layer
+			desc := descriptor.Descriptor{MediaType: mediatype.OCI1LayerGzip}
+			switch dm.m.GetDescriptor().MediaType {
+			case mediatype.Docker2Manifest, mediatype.Docker2ManifestList:
+				desc.MediaType = mediatype.Docker2LayerGzip
+			}
+			err = desc.DigestAlgoPrefer(dm.m.GetDescriptor().DigestAlgo())
+			if err != nil {
+				return fmt.Errorf("failed to configure digest algorithm for squashed layer: %w", err)
+			}
+			pr, pw := io.Pipe()
+			go func() {
+				_ = pw.CloseWithError(layerSquashWrite(layers, open, keep, pw))
+			}()
+			digUC := desc.DigestAlgo().Digester() // uncompressed digest
+			cRdr, err := archive.Compress(io.TeeReader(pr, digUC.Hash()), archive.CompressGzip)
+			if err != nil {
+				_ = pr.Close()
+				return fmt.Errorf("failed to compress squashed layer: %w", err)
+			}
+			descPut, err := rc.BlobPut(ctx, rTgt, desc, cRdr)
+			_ = cRdr.Close()
+			_ = pr.Close()
+			if err != nil {
+				return fmt.Errorf("failed to push squashed layer to %s: %w", rTgt.CommonName(), err)
+			}
+			desc.Digest = descPut.Digest
+			desc.Size = descPut.Size
+			// replace the history of the bottom layer, dagPut removes the history of deleted layers
+			oc := dm.config.oc.GetConfig()
+			layerNum := 0
+			for i := range oc.History {
+				if oc.History[i].EmptyLayer {
+					continue
+				}
+				if layerNum == idx[0] {
+					oc.History[i] = v1.History{
+						Created:   &timeStart,
+						CreatedBy: fmt.Sprintf("squash %d layers", len(idx)),
+						Comment:   "regclient",
+					}
+					dm.config.oc.SetConfig(oc)
+					dm.config.modified = true
+					break
+				}
+				layerNum++
+			}
+			// the bottom layer is replaced with the squashed layer, the others are deleted
+			for i, dl := range layers {
+				if i > 0 {
+					dl.mod = deleted
+					continue
+				}
+				dl.desc = desc
+				dl.newDesc = desc
+				dl.ucDigest = digUC.Digest()
+				dl.rSrc = rTgt
+				dl.mod = replaced
+			}
+			return nil
+		})
+		return nil
+	}
+}
+
+const (
+	whiteoutPrefix = ".wh."
+	whiteoutOpaque = ".wh..wh..opq"
+)
+
+// layerSquashState tracks the paths from upper layers that hide entries in lower layers.
+type layerSquashState struct {
+	seen    map[string]bool // paths defined by an upper layer
+	deleted map[string]bool // paths and their children deleted by an upper layer
+	opaque  map[string]bool // directories with the children of lower layers hidden
+}
+
+func (s *layerSquashState) hidden(name string) bool {
+	if s.seen[name] || s.deleted[name] {
+		return true
+	}
+	if name == "." {
+		return false
+	}
+	for dir := path.Dir(name); ; dir = path.Dir(dir) {
+		if s.deleted[dir] || s.opaque[dir] {
+			return true
+		}
+		if dir == "." {
+			return false
+		}
+	}
+}
+
+// layerSquashName returns a normalized path for a tar entry.
+func layerSquashName(name string) string {
+	return path.Clean(strings.TrimPrefix(filepath.ToSlash(name), "/"))
+}
+
+// layerSquashPlan returns the entries of each layer to include in the squashed layer.
+// Whiteout files are only included when there are layers below the squashed layers.
+func layerSquashPlan(layers []*dagLayer, open func(*dagLayer) (io.ReadCloser, error), keepWhiteout bool) ([][]bool, error) {
+	keep := make([][]bool, len(layers))
+	state := layerSquashState{
+		seen:    map[string]bool{},
+		deleted: map[string]bool{},
+		opaque:  map[string]bool{},
+	}
+	for i := len(layers) - 1; i >= 0; i-- {
+		rdr, err := open(layers[i])
+		if err != nil {
+			return nil, err
+		}
+		// changes from this layer only apply to lower layers
+		seen, deleted, opaque := map[string]bool{}, map[string]bool{}, map[string]bool{}
+		tr := tar.NewReader(rdr)
+		for {
+			th, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				_ = rdr.Close()
+				return nil, fmt.Errorf("failed to read layer %s: %w", layers[i].desc.Digest.String(), err)
+			}
+			name := layerSquashName(th.Name)
+			base := path.Base(name)
+			switch {
+			case base == whiteoutOpaque:
+				keep[i] = append(keep[i], keepWhiteout && !state.hidden(name))
+				opaque[path.Dir(name)] = true
+			case strings.HasPrefix(base, whiteoutPrefix):
+				target := path.Join(path.Dir(name), strings.TrimPrefix(base, whiteoutPrefix))
+				keep[i] = append(keep[i], keepWhiteout && !state.hidden(name) && !state.hidden(target))
+				deleted[target] = true
+			default:
+				keep[i] = append(keep[i], !state.hidden(name))
+				seen[name] = true
+			}
+		}
+		err = rdr.Close()
+		if err != nil {
+			return nil, err
+		}
+		for name := range seen {
+			state.seen[name] = true
+		}
+		for name := range deleted {
+			state.deleted[name] = true
+		}
+		for name := range opaque {
+			state.opaque[name] = true
+		}
+	}
+	return keep, nil
+}
+
+// layerSquashWrite outputs the selected entries of each layer as a single tar.
+func layerSquashWrite(layers []*dagLayer, open func(*dagLayer) (io.ReadCloser, error), keep [][]bool, w io.Writer) error {
+	tw := tar.NewWriter(w)
+	for i, dl := range layers {
+		rdr, err := open(dl)
+		if err != nil {
+			return err
+		}
+		tr := tar.NewReader(rdr)
+		for j := 0; ; j++ {
+			th, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				_ = rdr.Close()
+				return err
+			}
+			if j >= len(keep[i]) {
+				_ = rdr.Close()
+				return fmt.Errorf("layer %s changed while squashing", dl.desc.Digest.String())
+			}
+			if !keep[i][j] {
+				continue
+			}
+			err = tw.WriteHeader(th)
+			if err != nil {
+				_ = rdr.Close()
+				return err
+			}
+			if th.Typeflag == tar.TypeReg && th.Size > 0 {
+				_, err = io.CopyN(tw, tr, th.Size)
+				if err != nil {
+					_ = rdr.Close()
+					return err
+				}
+			}
+		}
+		err = rdr.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
 // WithLayerStripFile removes a file from within the layer tar.
 func WithLayerStripFile(file string) Opts {
 	file = strings.Trim(filepath.ToSlash(file), "/")
@@ -440,6 +1008,39 @@ func WithLayerStripFile(file string) Opts {
 	}
 }
 
+// WithLayerStripGlob removes files from within the layer tar that match a glob pattern.
+// Patterns containing a "/" are matched against the full path, e.g. "/root/.npmrc" or "/etc/ssl/private/*".
+// Other patterns are matched against each element of the path, e.g. "*.pem" or ".git".
+// Directories that match are removed with their contents.
+// The pattern syntax is defined by [path.Match].
+func WithLayerStripGlob(pattern string) Opts {
+	full := strings.Contains(strings.Trim(filepath.ToSlash(pattern), "/"), "/")
+	pattern = strings.Trim(filepath.ToSlash(pattern), "/")
+	return func(dc *dagConfig, dm *dagManifest) error {
+		if pattern == "" {
+			return fmt.Errorf("strip glob pattern is empty")
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid strip glob pattern %s: %w", pattern, err)
+		}
+		dc.stepsLayerFile = append(dc.stepsLayerFile, func(c context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, dl *dagLayer, th *tar.Header, tr io.Reader) (*tar.Header, io.Reader, changes, error) {
+			name := strings.Trim(strings.TrimPrefix(th.Name, "./"), "/")
+			elems := strings.Split(name, "/")
+			for i := range elems {
+				cmp := elems[i]
+				if full {
+					cmp = strings.Join(elems[:i+1], "/")
+				}
+				if match, _ := path.Match(pattern, cmp); match {
+					return th, tr, deleted, nil
+				}
+			}
+			return th, tr, unchanged, nil
+		})
+		return nil
+	}
+}
+
 // WithLayerTimestamp sets the timestamp on files in the layers based on options.
 func WithLayerTimestamp(optTime OptTime) Opts {
 	return func(dc *dagConfig, dm *dagManifest) error {
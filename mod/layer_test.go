@@ -0,0 +1,518 @@
+package mod
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	digest "github.com/opencontainers/go-digest"
+
+	"github.com/regclient/regclient"
+	"github.com/regclient/regclient/pkg/archive"
+	"github.com/regclient/regclient/regtest"
+	"github.com/regclient/regclient/types/descriptor"
+	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/mediatype"
+	v1 "github.com/regclient/regclient/types/oci/v1"
+	"github.com/regclient/regclient/types/ref"
+)
+
+type testTarEntry struct {
+	name    string
+	content string // directories end with a slash
+}
+
+func testTar(t *testing.T, entries ...testTarEntry) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	for _, e := range entries {
+		th := &tar.Header{Name: e.name, Mode: 0644, Typeflag: tar.TypeReg, Size: int64(len(e.content))}
+		if e.name[len(e.name)-1] == '/' {
+			th.Typeflag = tar.TypeDir
+			th.Mode = 0755
+			th.Size = 0
+		}
+		err := tw.WriteHeader(th)
+		if err != nil {
+			t.Fatalf("failed to write tar header: %v", err)
+		}
+		_, err = tw.Write([]byte(e.content))
+		if err != nil {
+			t.Fatalf("failed to write tar content: %v", err)
+		}
+	}
+	err := tw.Close()
+	if err != nil {
+		t.Fatalf("failed to close tar: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// testLayerFiles returns the files in a layer and verifies the diff id
+func testLayerFiles(t *testing.T, rc *regclient.RegClient, r ref.Ref, d descriptor.Descriptor, diffID digest.Digest) []testTarEntry {
+	t.Helper()
+	br, err := rc.BlobGet(context.Background(), r, d)
+	if err != nil {
+		t.Fatalf("failed to get layer: %v", err)
+	}
+	defer br.Close()
+	dr, err := archive.Decompress(br)
+	if err != nil {
+		t.Fatalf("failed to decompress layer: %v", err)
+	}
+	digUC := diffID.Algorithm().Digester()
+	tr := tar.NewReader(io.TeeReader(dr, digUC.Hash()))
+	files := []testTarEntry{}
+	for {
+		th, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read layer: %v", err)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("failed to read file: %v", err)
+		}
+		files = append(files, testTarEntry{name: th.Name, content: string(content)})
+	}
+	_, _ = io.Copy(io.Discard, io.TeeReader(dr, digUC.Hash()))
+	if digUC.Digest() != diffID {
+		t.Errorf("diff id mismatch, expected %s, received %s", diffID, digUC.Digest())
+	}
+	return files
+}
+
+func TestLayerSquash(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	s := regtest.New(t)
+	rc := s.RegClient()
+	layers := [][]byte{
+		testTar(t,
+			testTarEntry{name: "a/"},
+			testTarEntry{name: "a/one", content: "1"},
+			testTarEntry{name: "a/two", content: "2"},
+			testTarEntry{name: "b/"},
+			testTarEntry{name: "b/x", content: "x"},
+		),
+		testTar(t,
+			testTarEntry{name: "a/one", content: "1b"},
+			testTarEntry{name: "a/.wh.two"},
+		),
+		testTar(t,
+			testTarEntry{name: "b/"},
+			testTarEntry{name: "b/.wh..wh..opq"},
+			testTarEntry{name: "b/y", content: "y"},
+			testTarEntry{name: "c", content: "c"},
+		),
+	}
+	_, err := s.AddImage(ctx, "squash:v1", nil, layers...)
+	if err != nil {
+		t.Fatalf("failed to add image: %v", err)
+	}
+
+	tt := []struct {
+		name       string
+		count      int
+		wantLayers int
+		wantFiles  []testTarEntry
+	}{
+		{
+			name:       "all",
+			count:      0,
+			wantLayers: 1,
+			wantFiles: []testTarEntry{
+				{name: "a/"},
+				{name: "a/one", content: "1b"},
+				{name: "b/"},
+				{name: "b/y", content: "y"},
+				{name: "c", content: "c"},
+			},
+		},
+		{
+			name:       "top two",
+			count:      2,
+			wantLayers: 2,
+			wantFiles: []testTarEntry{
+				{name: "a/one", content: "1b"},
+				{name: "a/.wh.two"},
+				{name: "b/"},
+				{name: "b/.wh..wh..opq"},
+				{name: "b/y", content: "y"},
+				{name: "c", content: "c"},
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rMod, err := Apply(ctx, rc, s.Ref(t, "squash:v1"), WithLayerSquash(tc.count), WithRefTgt(s.Ref(t, "squash:"+tc.name[:3])))
+			if err != nil {
+				t.Fatalf("failed to squash: %v", err)
+			}
+			m, err := rc.ManifestGet(ctx, rMod)
+			if err != nil {
+				t.Fatalf("failed to get manifest: %v", err)
+			}
+			mi, ok := m.(manifest.Imager)
+			if !ok {
+				t.Fatalf("manifest is not an image")
+			}
+			ml, err := mi.GetLayers()
+			if err != nil {
+				t.Fatalf("failed to get layers: %v", err)
+			}
+			if len(ml) != tc.wantLayers {
+				t.Fatalf("unexpected layer count, expected %d, received %d", tc.wantLayers, len(ml))
+			}
+			conf, err := rc.ImageConfig(ctx, rMod)
+			if err != nil {
+				t.Fatalf("failed to get config: %v", err)
+			}
+			diffIDs := conf.GetConfig().RootFS.DiffIDs
+			if len(diffIDs) != tc.wantLayers {
+				t.Fatalf("unexpected diff id count, expected %d, received %d", tc.wantLayers, len(diffIDs))
+			}
+			files := testLayerFiles(t, rc, rMod, ml[len(ml)-1], diffIDs[len(diffIDs)-1])
+			if len(files) != len(tc.wantFiles) {
+				t.Fatalf("unexpected files, expected %v, received %v", tc.wantFiles, files)
+			}
+			for i := range files {
+				if files[i] != tc.wantFiles[i] {
+					t.Errorf("unexpected file %d, expected %v, received %v", i, tc.wantFiles[i], files[i])
+				}
+			}
+		})
+	}
+	t.Run("negative", func(t *testing.T) {
+		_, err := Apply(ctx, rc, s.Ref(t, "squash:v1"), WithLayerSquash(-1))
+		if err == nil {
+			t.Errorf("negative count did not fail")
+		}
+	})
+}
+
+func TestLayerStripGlob(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	s := regtest.New(t)
+	rc := s.RegClient()
+	layers := [][]byte{
+		testTar(t,
+			testTarEntry{name: "etc/"},
+			testTarEntry{name: "etc/ssl/"},
+			testTarEntry{name: "etc/ssl/cert.pem", content: "cert"},
+			testTarEntry{name: "etc/hosts", content: "hosts"},
+			testTarEntry{name: "root/"},
+			testTarEntry{name: "root/.npmrc", content: "token"},
+		),
+		testTar(t,
+			testTarEntry{name: "./app/"},
+			testTarEntry{name: "./app/key.pem", content: "key"},
+			testTarEntry{name: "./app/.git/"},
+			testTarEntry{name: "./app/.git/config", content: "git"},
+			testTarEntry{name: "./app/main", content: "main"},
+		),
+	}
+	_, err := s.AddImage(ctx, "strip:v1", nil, layers...)
+	if err != nil {
+		t.Fatalf("failed to add image: %v", err)
+	}
+	tt := []struct {
+		name      string
+		patterns  []string
+		wantFiles [][]testTarEntry
+		wantErr   bool
+	}{
+		{
+			name:     "base name",
+			patterns: []string{"*.pem", ".git"},
+			wantFiles: [][]testTarEntry{
+				{{name: "etc/"}, {name: "etc/ssl/"}, {name: "etc/hosts", content: "hosts"}, {name: "root/"}, {name: "root/.npmrc", content: "token"}},
+				{{name: "./app/"}, {name: "./app/main", content: "main"}},
+			},
+		},
+		{
+			name:     "full path",
+			patterns: []string{"/root/.npmrc", "app/*"},
+			wantFiles: [][]testTarEntry{
+				{{name: "etc/"}, {name: "etc/ssl/"}, {name: "etc/ssl/cert.pem", content: "cert"}, {name: "etc/hosts", content: "hosts"}, {name: "root/"}},
+				{{name: "./app/"}},
+			},
+		},
+		{
+			name:     "invalid pattern",
+			patterns: []string{"[a-"},
+			wantErr:  true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rTgt := s.Ref(t, "strip:"+strings.ReplaceAll(tc.name, " ", "-"))
+			opts := []Opts{WithRefTgt(rTgt)}
+			for _, p := range tc.patterns {
+				opts = append(opts, WithLayerStripGlob(p))
+			}
+			rMod, err := Apply(ctx, rc, s.Ref(t, "strip:v1"), opts...)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("strip did not fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to strip: %v", err)
+			}
+			m, err := rc.ManifestGet(ctx, rMod)
+			if err != nil {
+				t.Fatalf("failed to get manifest: %v", err)
+			}
+			ml, err := m.(manifest.Imager).GetLayers()
+			if err != nil {
+				t.Fatalf("failed to get layers: %v", err)
+			}
+			conf, err := rc.ImageConfig(ctx, rMod)
+			if err != nil {
+				t.Fatalf("failed to get config: %v", err)
+			}
+			diffIDs := conf.GetConfig().RootFS.DiffIDs
+			if len(ml) != len(tc.wantFiles) || len(diffIDs) != len(tc.wantFiles) {
+				t.Fatalf("unexpected layer count, expected %d, received %d layers and %d diff ids", len(tc.wantFiles), len(ml), len(diffIDs))
+			}
+			for i := range ml {
+				files := testLayerFiles(t, rc, rMod, ml[i], diffIDs[i])
+				if len(files) != len(tc.wantFiles[i]) {
+					t.Errorf("unexpected files in layer %d, expected %v, received %v", i, tc.wantFiles[i], files)
+					continue
+				}
+				for j := range files {
+					if files[j] != tc.wantFiles[i][j] {
+						t.Errorf("unexpected file in layer %d, expected %v, received %v", i, tc.wantFiles[i][j], files[j])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestLayerAddTarHistory(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	s := regtest.New(t)
+	rc := s.RegClient()
+	_, err := s.AddImage(ctx, "add:v1", nil, testTar(t, testTarEntry{name: "base", content: "base"}))
+	if err != nil {
+		t.Fatalf("failed to add image: %v", err)
+	}
+	layer := testTar(t, testTarEntry{name: "app/"}, testTarEntry{name: "app/main", content: "main"})
+	rMod, err := Apply(ctx, rc, s.Ref(t, "add:v1"),
+		WithLayerAddTarHistory(bytes.NewReader(layer), "", nil, v1.History{CreatedBy: "COPY app /app"}),
+		WithRefTgt(s.Ref(t, "add:v2")))
+	if err != nil {
+		t.Fatalf("failed to add layer: %v", err)
+	}
+	m, err := rc.ManifestGet(ctx, rMod)
+	if err != nil {
+		t.Fatalf("failed to get manifest: %v", err)
+	}
+	ml, err := m.(manifest.Imager).GetLayers()
+	if err != nil {
+		t.Fatalf("failed to get layers: %v", err)
+	}
+	conf, err := rc.ImageConfig(ctx, rMod)
+	if err != nil {
+		t.Fatalf("failed to get config: %v", err)
+	}
+	oc := conf.GetConfig()
+	if len(ml) != 2 || len(oc.RootFS.DiffIDs) != 2 {
+		t.Fatalf("unexpected layer count, received %d layers and %d diff ids", len(ml), len(oc.RootFS.DiffIDs))
+	}
+	if ml[1].MediaType != mediatype.OCI1LayerGzip {
+		t.Errorf("unexpected media type, expected %s, received %s", mediatype.OCI1LayerGzip, ml[1].MediaType)
+	}
+	files := testLayerFiles(t, rc, rMod, ml[1], oc.RootFS.DiffIDs[1])
+	if len(files) != 2 || files[1].name != "app/main" {
+		t.Errorf("unexpected files in new layer: %v", files)
+	}
+	if len(oc.History) == 0 {
+		t.Fatalf("history missing")
+	}
+	h := oc.History[len(oc.History)-1]
+	if h.CreatedBy != "COPY app /app" || h.Comment != "regclient" || h.Created == nil {
+		t.Errorf("unexpected history: %v", h)
+	}
+	_, err = Apply(ctx, rc, s.Ref(t, "add:v1"),
+		WithLayerAddTarHistory(bytes.NewReader(layer), "", nil, v1.History{EmptyLayer: true}))
+	if err == nil {
+		t.Errorf("empty layer history did not fail")
+	}
+}
+
+func TestLayerNormalize(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	s := regtest.New(t)
+	rc := s.RegClient()
+	type hdr struct {
+		name, link string
+		typeflag   byte
+		mode       int64
+		uid        int
+		content    string
+	}
+	build := func(hdrs ...hdr) []byte {
+		buf := &bytes.Buffer{}
+		tw := tar.NewWriter(buf)
+		for _, h := range hdrs {
+			th := &tar.Header{Name: h.name, Linkname: h.link, Typeflag: h.typeflag, Mode: h.mode, Uid: h.uid, Gid: h.uid, Uname: "builder", Size: int64(len(h.content))}
+			if h.uid == 0 {
+				th.Uname = ""
+			}
+			err := tw.WriteHeader(th)
+			if err != nil {
+				t.Fatalf("failed to write tar header: %v", err)
+			}
+			_, err = tw.Write([]byte(h.content))
+			if err != nil {
+				t.Fatalf("failed to write tar content: %v", err)
+			}
+		}
+		err := tw.Close()
+		if err != nil {
+			t.Fatalf("failed to close tar: %v", err)
+		}
+		return buf.Bytes()
+	}
+	// the same content, written in a different order with different owners and modes
+	_, err := s.AddImage(ctx, "normalize:a", nil, build(
+		hdr{name: "usr/", typeflag: tar.TypeDir, mode: 0o700, uid: 1000},
+		hdr{name: "usr/bin/", typeflag: tar.TypeDir, mode: 0o755, uid: 1000},
+		hdr{name: "usr/bin/b", typeflag: tar.TypeLink, link: "usr/bin/z", uid: 1000},
+		hdr{name: "usr/bin/z", typeflag: tar.TypeReg, mode: 0o700, uid: 1000, content: "exec"},
+		hdr{name: "etc/", typeflag: tar.TypeDir, mode: 0o755, uid: 1000},
+		hdr{name: "etc/conf", typeflag: tar.TypeReg, mode: 0o600, uid: 1000, content: "conf"},
+	))
+	if err != nil {
+		t.Fatalf("failed to add image: %v", err)
+	}
+	_, err = s.AddImage(ctx, "normalize:b", nil, build(
+		hdr{name: "etc/", typeflag: tar.TypeDir, mode: 0o750},
+		hdr{name: "etc/conf", typeflag: tar.TypeReg, mode: 0o644, content: "conf"},
+		hdr{name: "usr/", typeflag: tar.TypeDir, mode: 0o755},
+		hdr{name: "usr/bin/", typeflag: tar.TypeDir, mode: 0o755},
+		hdr{name: "usr/bin/z", typeflag: tar.TypeReg, mode: 0o755, content: "exec"},
+		hdr{name: "usr/bin/b", typeflag: tar.TypeLink, link: "usr/bin/z"},
+	))
+	if err != nil {
+		t.Fatalf("failed to add image: %v", err)
+	}
+	on := OptNormalize{FileMode: 0o644, ExecMode: 0o755, DirMode: 0o755}
+	layers := []descriptor.Descriptor{}
+	for _, tag := range []string{"a", "b"} {
+		rMod, err := Apply(ctx, rc, s.Ref(t, "normalize:"+tag), WithLayerNormalize(on), WithRefTgt(s.Ref(t, "normalize:"+tag+"-mod")))
+		if err != nil {
+			t.Fatalf("failed to normalize: %v", err)
+		}
+		m, err := rc.ManifestGet(ctx, rMod)
+		if err != nil {
+			t.Fatalf("failed to get manifest: %v", err)
+		}
+		ml, err := m.(manifest.Imager).GetLayers()
+		if err != nil || len(ml) != 1 {
+			t.Fatalf("failed to get layers: %v, %v", ml, err)
+		}
+		layers = append(layers, ml[0])
+	}
+	if layers[0].Digest != layers[1].Digest {
+		t.Errorf("normalized layers differ, %s and %s", layers[0].Digest, layers[1].Digest)
+	}
+	br, err := rc.BlobGet(ctx, s.Ref(t, "normalize:a-mod"), layers[0])
+	if err != nil {
+		t.Fatalf("failed to get layer: %v", err)
+	}
+	defer br.Close()
+	tr := tar.NewReader(struct{ io.Reader }{br})
+	wantNames := []string{"etc/", "etc/conf", "usr/", "usr/bin/", "usr/bin/z", "usr/bin/b"}
+	wantModes := []int64{0o755, 0o644, 0o755, 0o755, 0o755, 0}
+	for i := 0; ; i++ {
+		th, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			if i != len(wantNames) {
+				t.Errorf("unexpected entry count, expected %d, received %d", len(wantNames), i)
+			}
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read layer: %v", err)
+		}
+		if i >= len(wantNames) {
+			t.Fatalf("unexpected entry %s", th.Name)
+		}
+		if th.Name != wantNames[i] {
+			t.Errorf("unexpected entry %d, expected %s, received %s", i, wantNames[i], th.Name)
+		}
+		if th.Mode != wantModes[i] {
+			t.Errorf("unexpected mode for %s, expected %o, received %o", th.Name, wantModes[i], th.Mode)
+		}
+		if th.Uid != 0 || th.Gid != 0 || th.Uname != "" || th.Gname != "" {
+			t.Errorf("owner not reset for %s", th.Name)
+		}
+	}
+	t.Run("compressed", func(t *testing.T) {
+		digs := []digest.Digest{}
+		for _, tag := range []string{"a", "b"} {
+			rMod, err := Apply(ctx, rc, s.Ref(t, "normalize:"+tag),
+				WithLayerCompression(archive.CompressGzip),
+				WithLayerNormalize(on),
+				WithRefTgt(s.Ref(t, "normalize:"+tag+"-gzip")))
+			if err != nil {
+				t.Fatalf("failed to normalize: %v", err)
+			}
+			m, err := rc.ManifestGet(ctx, rMod)
+			if err != nil {
+				t.Fatalf("failed to get manifest: %v", err)
+			}
+			ml, err := m.(manifest.Imager).GetLayers()
+			if err != nil || len(ml) != 1 {
+				t.Fatalf("failed to get layers: %v, %v", ml, err)
+			}
+			if ml[0].MediaType != mediatype.OCI1LayerGzip {
+				t.Errorf("unexpected media type %s", ml[0].MediaType)
+			}
+			conf, err := rc.ImageConfig(ctx, rMod)
+			if err != nil {
+				t.Fatalf("failed to get config: %v", err)
+			}
+			testLayerFiles(t, rc, rMod, ml[0], conf.GetConfig().RootFS.DiffIDs[0])
+			br, err := rc.BlobGet(ctx, rMod, ml[0])
+			if err != nil {
+				t.Fatalf("failed to get layer: %v", err)
+			}
+			head := make([]byte, 10)
+			_, err = io.ReadFull(br, head)
+			_ = br.Close()
+			if err != nil {
+				t.Fatalf("failed to read layer: %v", err)
+			}
+			if archive.DetectCompression(head) != archive.CompressGzip {
+				t.Errorf("layer is not gzip compressed")
+			}
+			digs = append(digs, ml[0].Digest)
+		}
+		if digs[0] != digs[1] {
+			t.Errorf("normalized layers differ, %s and %s", digs[0], digs[1])
+		}
+	})
+	t.Run("invalid mode", func(t *testing.T) {
+		_, err := Apply(ctx, rc, s.Ref(t, "normalize:a"), WithLayerNormalize(OptNormalize{FileMode: 0o17777}), WithRefTgt(s.Ref(t, "normalize:invalid")))
+		if err == nil {
+			t.Errorf("invalid mode did not fail")
+		}
+	})
+}
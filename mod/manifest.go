@@ -13,6 +13,7 @@ import (
 	"github.com/regclient/regclient/types/errs"
 	"github.com/regclient/regclient/types/manifest"
 	"github.com/regclient/regclient/types/mediatype"
+	v1 "github.com/regclient/regclient/types/oci/v1"
 	"github.com/regclient/regclient/types/platform"
 	"github.com/regclient/regclient/types/ref"
 )
@@ -27,7 +28,7 @@ func WithAnnotation(name, value string) Opts {
 		name = strings.TrimSpace(name)
 		platforms := []platform.Platform{}
 		allPlatforms := false
-		if name[0] == '[' && strings.Index(name, "]") > 0 {
+		if strings.HasPrefix(name, "[") && strings.Index(name, "]") > 0 {
 			end := strings.Index(name, "]")
 			list := strings.Split(name[1:end], ",")
 			for _, entry := range list {
@@ -44,6 +45,9 @@ func WithAnnotation(name, value string) Opts {
 			}
 			name = name[end+1:]
 		}
+		if name == "" {
+			return fmt.Errorf("annotation name missing%.0w", errs.ErrMissingName)
+		}
 		dc.stepsManifest = append(dc.stepsManifest, func(ctx context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, dm *dagManifest) error {
 			// skip deleted manifest, those not in the platform list, or the non-top manifest if no platform list provided
 			if dm.mod == deleted {
@@ -235,6 +239,80 @@ func WithAnnotationPromoteCommon() Opts {
 	}
 }
 
+// WithArtifactType sets the artifactType of the top level OCI manifest or index.
+// An empty value removes the artifactType.
+// Docker manifests do not support an artifactType.
+func WithArtifactType(artifactType string) Opts {
+	return func(dc *dagConfig, dm *dagManifest) error {
+		dc.stepsManifest = append(dc.stepsManifest, func(c context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, dm *dagManifest) error {
+			if !dm.top || dm.mod == deleted {
+				return nil
+			}
+			om := dm.m.GetOrig()
+			switch orig := om.(type) {
+			case v1.Manifest:
+				if orig.ArtifactType == artifactType {
+					return nil
+				}
+				orig.ArtifactType = artifactType
+				om = orig
+			case v1.Index:
+				if orig.ArtifactType == artifactType {
+					return nil
+				}
+				orig.ArtifactType = artifactType
+				om = orig
+			default:
+				return fmt.Errorf("artifactType is not supported on media type %s, convert to OCI first%.0w", dm.m.GetDescriptor().MediaType, errs.ErrUnsupportedMediaType)
+			}
+			err := dm.m.SetOrig(om)
+			if err != nil {
+				return err
+			}
+			dm.newDesc = dm.m.GetDescriptor()
+			if dm.mod == unchanged {
+				dm.mod = replaced
+			}
+			return nil
+		})
+		return nil
+	}
+}
+
+// WithConfigMediaType sets the media type of the config descriptor in the top level OCI manifest.
+// The config blob is not modified.
+// This is used with [WithArtifactType] to migrate artifacts that set their type with the config media type.
+func WithConfigMediaType(mt string) Opts {
+	return func(dc *dagConfig, dm *dagManifest) error {
+		if mt == "" {
+			return fmt.Errorf("config media type must not be empty")
+		}
+		dc.stepsManifest = append(dc.stepsManifest, func(c context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, dm *dagManifest) error {
+			if !dm.top || dm.mod == deleted {
+				return nil
+			}
+			orig, ok := dm.m.GetOrig().(v1.Manifest)
+			if !ok {
+				return fmt.Errorf("config media type can only be set on an OCI image manifest, media type %s%.0w", dm.m.GetDescriptor().MediaType, errs.ErrUnsupportedMediaType)
+			}
+			if orig.Config.MediaType == mt {
+				return nil
+			}
+			orig.Config.MediaType = mt
+			err := dm.m.SetOrig(orig)
+			if err != nil {
+				return err
+			}
+			dm.newDesc = dm.m.GetDescriptor()
+			if dm.mod == unchanged {
+				dm.mod = replaced
+			}
+			return nil
+		})
+		return nil
+	}
+}
+
 // WithLabelToAnnotation copies image config labels to manifest annotations.
 func WithLabelToAnnotation() Opts {
 	return func(dc *dagConfig, dm *dagManifest) error {
@@ -560,8 +638,12 @@ func WithManifestToOCIReferrers() Opts {
 }
 
 // WithExternalURLsRm strips external URLs from descriptors and adjusts media type to match.
+// The content of the external layers is pulled from the URLs when it is not found in the target repository,
+// converting foreign layers to regular layers that may be pushed to registries that reject external references.
 func WithExternalURLsRm() Opts {
 	return func(dc *dagConfig, dm *dagManifest) error {
+		// walk the layers to pull the external content
+		dc.forceLayerWalk = true
 		dc.stepsManifest = append(dc.stepsManifest, func(ctx context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, dm *dagManifest) error {
 			if dm.mod == deleted {
 				return nil
@@ -579,18 +661,7 @@ func WithExternalURLsRm() Opts {
 			for i := range ociOM.Layers {
 				if len(ociOM.Layers[i].URLs) > 0 {
 					ociOM.Layers[i].URLs = []string{}
-					mt := ociOM.Layers[i].MediaType
-					switch mt {
-					case mediatype.Docker2ForeignLayer:
-						mt = mediatype.Docker2LayerGzip
-					case mediatype.OCI1ForeignLayer:
-						mt = mediatype.OCI1Layer
-					case mediatype.OCI1ForeignLayerGzip:
-						mt = mediatype.OCI1LayerGzip
-					case mediatype.OCI1ForeignLayerZstd:
-						mt = mediatype.OCI1LayerZstd
-					}
-					ociOM.Layers[i].MediaType = mt
+					ociOM.Layers[i].MediaType = externalMediaType(ociOM.Layers[i].MediaType)
 					changed = true
 				}
 			}
@@ -604,6 +675,7 @@ func WithExternalURLsRm() Opts {
 				}
 				if len(dl.newDesc.URLs) > 0 {
 					dl.newDesc.URLs = []string{}
+					dl.newDesc.MediaType = externalMediaType(dl.newDesc.MediaType)
 					dm.layers[i] = dl
 				}
 			}
@@ -628,6 +700,84 @@ func WithExternalURLsRm() Opts {
 	}
 }
 
+// externalMediaType returns the media type of a regular layer for a foreign layer media type.
+func externalMediaType(mt string) string {
+	switch mt {
+	case mediatype.Docker2ForeignLayer:
+		return mediatype.Docker2LayerGzip
+	case mediatype.OCI1ForeignLayer:
+		return mediatype.OCI1Layer
+	case mediatype.OCI1ForeignLayerGzip:
+		return mediatype.OCI1LayerGzip
+	case mediatype.OCI1ForeignLayerZstd:
+		return mediatype.OCI1LayerZstd
+	}
+	return mt
+}
+
+// WithPlatformsKeep removes entries from an index that do not match one of the platforms.
+// Entries without a platform, like attestations, are kept unless they refer to a removed image with the "vnd.docker.reference.digest" annotation.
+func WithPlatformsKeep(platforms []platform.Platform) Opts {
+	return func(dc *dagConfig, dm *dagManifest) error {
+		if len(platforms) == 0 {
+			return fmt.Errorf("at least one platform must be kept")
+		}
+		dc.stepsManifest = append(dc.stepsManifest, func(ctx context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, dm *dagManifest) error {
+			if dm.mod == deleted || !dm.m.IsList() {
+				return nil
+			}
+			mi, ok := dm.m.(manifest.Indexer)
+			if !ok {
+				return fmt.Errorf("manifest list is not an Indexer")
+			}
+			dl, err := mi.GetManifestList()
+			if err != nil {
+				return err
+			}
+			removed := map[digest.Digest]bool{}
+			kept := 0
+			for i, d := range dl {
+				if i >= len(dm.manifests) || d.Platform == nil || d.Platform.OS == "unknown" {
+					continue
+				}
+				found := false
+				for _, p := range platforms {
+					if platform.Match(*d.Platform, p) {
+						found = true
+						break
+					}
+				}
+				if found {
+					kept++
+					continue
+				}
+				dm.manifests[i].mod = deleted
+				removed[d.Digest] = true
+			}
+			if kept == 0 {
+				return fmt.Errorf("no platforms in %s match the platforms to keep%.0w", rSrc.CommonName(), errs.ErrNotFound)
+			}
+			if len(removed) == 0 {
+				return nil
+			}
+			// remove attestations of the removed images
+			for i, d := range dl {
+				if i >= len(dm.manifests) || d.Annotations == nil || d.Annotations[dockerReferenceDigest] == "" {
+					continue
+				}
+				if removed[digest.Digest(d.Annotations[dockerReferenceDigest])] {
+					dm.manifests[i].mod = deleted
+				}
+			}
+			if dm.mod == unchanged {
+				dm.mod = replaced
+			}
+			return nil
+		})
+		return nil
+	}
+}
+
 // WithRebase attempts to rebase the image using OCI annotations identifying the base image.
 func WithRebase() Opts {
 	return func(dc *dagConfig, dm *dagManifest) error {
@@ -673,8 +823,18 @@ func WithRebaseRefs(rOld, rNew ref.Ref) Opts {
 func rebaseAddStep(dc *dagConfig, rBaseOld, rBaseNew ref.Ref) error {
 	var mbOldCache, mbNewCache manifest.Manifest
 	dc.stepsManifest = append(dc.stepsManifest, func(ctx context.Context, rc *regclient.RegClient, rSrc, rTgt ref.Ref, dm *dagManifest) error {
-		// skip if manifest list or deleted
-		if dm.m.IsList() || dm.mod == deleted || dm.config == nil {
+		// skip if deleted
+		if dm.mod == deleted {
+			return nil
+		}
+		// indexes are processed after their child images, update the base annotation if any child was rebased
+		if dm.m.IsList() {
+			if mbOldCache == nil || mbNewCache == nil || mbOldCache.GetDescriptor().Equal(mbNewCache.GetDescriptor()) {
+				return nil
+			}
+			return rebaseAnnotations(dm, rBaseNew, mbNewCache.GetDescriptor().Digest)
+		}
+		if dm.config == nil {
 			return nil
 		}
 		// get and cache base manifests
@@ -875,7 +1035,38 @@ func rebaseAddStep(dc *dagConfig, rBaseOld, rBaseNew ref.Ref) error {
 		}
 		dc.forceLayerWalk = true
 
-		return nil
+		return rebaseAnnotations(dm, rBaseNew, mbNewCache.GetDescriptor().Digest)
 	})
 	return nil
 }
+
+// rebaseAnnotations updates existing base image annotations to point to the new base.
+// Manifests without a base digest annotation are not modified.
+func rebaseAnnotations(dm *dagManifest, rBaseNew ref.Ref, dBaseNew digest.Digest) error {
+	ma, ok := dm.m.(manifest.Annotator)
+	if !ok {
+		return nil
+	}
+	annot, err := ma.GetAnnotations()
+	if err != nil {
+		return err
+	}
+	if dig, ok := annot[types.AnnotationBaseImageDigest]; !ok || dig == dBaseNew.String() {
+		return nil
+	}
+	err = ma.SetAnnotation(types.AnnotationBaseImageDigest, dBaseNew.String())
+	if err != nil {
+		return err
+	}
+	if rBaseNew.Tag != "" {
+		err = ma.SetAnnotation(types.AnnotationBaseImageName, rBaseNew.SetTag(rBaseNew.Tag).CommonName())
+		if err != nil {
+			return err
+		}
+	}
+	dm.newDesc = dm.m.GetDescriptor()
+	if dm.mod == unchanged {
+		dm.mod = replaced
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package mod
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/regclient/regclient"
+	"github.com/regclient/regclient/pkg/archive"
+	"github.com/regclient/regclient/regtest"
+	"github.com/regclient/regclient/types/descriptor"
+	"github.com/regclient/regclient/types/docker/schema2"
+	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/mediatype"
+	v1 "github.com/regclient/regclient/types/oci/v1"
+	"github.com/regclient/regclient/types/platform"
+	"github.com/regclient/regclient/types/ref"
+)
+
+func TestExternalURLsRm(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	// serve a foreign layer outside of the registry
+	layerTar := testTar(t, testTarEntry{name: "Files/"}, testTarEntry{name: "Files/foreign.txt", content: "foreign"})
+	cRdr, err := archive.Compress(bytes.NewReader(layerTar), archive.CompressGzip)
+	if err != nil {
+		t.Fatalf("failed to compress layer: %v", err)
+	}
+	layerBuf := &bytes.Buffer{}
+	_, err = layerBuf.ReadFrom(cRdr)
+	if err != nil {
+		t.Fatalf("failed to compress layer: %v", err)
+	}
+	layerBytes := layerBuf.Bytes()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(layerBytes)
+	}))
+	t.Cleanup(ts.Close)
+	// registries reject manifests with missing layers, so the image is preloaded from an OCI Layout
+	tmpDir := t.TempDir()
+	rOCI, err := ref.New("ocidir://" + tmpDir + "/external:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	rcOCI := regclient.New()
+	conf := v1.Image{
+		Platform: platform.Platform{OS: "windows", Architecture: "amd64"},
+		RootFS: v1.RootFS{
+			Type:    "layers",
+			DiffIDs: []digest.Digest{digest.FromBytes(layerTar)},
+		},
+	}
+	confBytes, err := json.Marshal(conf)
+	if err != nil {
+		t.Fatalf("failed to marshal config: %v", err)
+	}
+	dConf, err := rcOCI.BlobPut(ctx, rOCI, descriptor.Descriptor{}, bytes.NewReader(confBytes))
+	if err != nil {
+		t.Fatalf("failed to push config: %v", err)
+	}
+	dConf.MediaType = mediatype.Docker2ImageConfig
+	dLayer := descriptor.Descriptor{
+		MediaType: mediatype.Docker2ForeignLayer,
+		Digest:    digest.FromBytes(layerBytes),
+		Size:      int64(len(layerBytes)),
+		URLs:      []string{ts.URL + "/layer.tar.gz"},
+	}
+	m, err := manifest.New(manifest.WithOrig(schema2.Manifest{
+		Versioned: schema2.ManifestSchemaVersion,
+		Config:    dConf,
+		Layers:    []descriptor.Descriptor{dLayer},
+	}))
+	if err != nil {
+		t.Fatalf("failed to create manifest: %v", err)
+	}
+	err = rcOCI.ManifestPut(ctx, rOCI, m)
+	if err != nil {
+		t.Fatalf("failed to push manifest: %v", err)
+	}
+	s := regtest.New(t, regtest.WithTestdata(tmpDir))
+	rc := s.RegClient()
+	rSrc := s.Ref(t, "external:v1")
+
+	tt := []struct {
+		name    string
+		tgt     string
+		opts    []Opts
+		wantMT  string
+		wantDig digest.Digest
+	}{
+		{
+			name:    "copy",
+			tgt:     "flatten:v1",
+			wantMT:  mediatype.Docker2LayerGzip,
+			wantDig: dLayer.Digest,
+		},
+		{
+			name:    "in place",
+			tgt:     "external:flat",
+			wantMT:  mediatype.Docker2LayerGzip,
+			wantDig: dLayer.Digest,
+		},
+		{
+			name:   "recompress",
+			tgt:    "flatten:zstd",
+			opts:   []Opts{WithLayerCompression(archive.CompressZstd)},
+			wantMT: mediatype.Docker2LayerZstd,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rTgt := s.Ref(t, tc.tgt)
+			opts := append([]Opts{WithExternalURLsRm(), WithRefTgt(rTgt)}, tc.opts...)
+			rMod, err := Apply(ctx, rc, rSrc, opts...)
+			if err != nil {
+				t.Fatalf("failed to apply: %v", err)
+			}
+			mMod, err := rc.ManifestGet(ctx, rMod)
+			if err != nil {
+				t.Fatalf("failed to get manifest: %v", err)
+			}
+			layers, err := mMod.(manifest.Imager).GetLayers()
+			if err != nil || len(layers) != 1 {
+				t.Fatalf("failed to get layers: %v, %v", layers, err)
+			}
+			if len(layers[0].URLs) > 0 || layers[0].MediaType != tc.wantMT {
+				t.Errorf("unexpected layer descriptor: %v", layers[0])
+			}
+			if tc.wantDig != "" && layers[0].Digest != tc.wantDig {
+				t.Errorf("unexpected layer digest, expected %s, received %s", tc.wantDig, layers[0].Digest)
+			}
+			// the layer is pulled from the registry without the URLs
+			layers[0].URLs = nil
+			files := testLayerFiles(t, rc, rMod, layers[0], conf.RootFS.DiffIDs[0])
+			if len(files) != 2 || files[1].content != "foreign" {
+				t.Errorf("unexpected files: %v", files)
+			}
+		})
+	}
+}
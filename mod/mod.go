@@ -115,7 +115,7 @@ func Apply(ctx context.Context, rc *regclient.RegClient, rSrc ref.Ref, opts ...O
 	}
 	// perform layer changes and copy layers to target repository
 	if len(dc.stepsLayer) > 0 || len(dc.stepsLayerFile) > 0 || !ref.EqualRepository(rSrc, rTgt) || dc.forceLayerWalk {
-		err = dagWalkLayers(dm, func(dl *dagLayer) (*dagLayer, error) {
+		applyLayer := func(dl *dagLayer) (*dagLayer, error) {
 			var rdr io.ReadCloser
 			defer func() {
 				if rdr != nil {
@@ -127,7 +127,9 @@ func Apply(ctx context.Context, rc *regclient.RegClient, rSrc ref.Ref, opts ...O
 			if dl.rSrc.IsSet() {
 				rSrc = dl.rSrc
 			}
-			if dl.mod == deleted || len(dl.desc.URLs) > 0 {
+			// external layers are only processed when converted to regular layers
+			external := len(dl.desc.URLs) > 0
+			if dl.mod == deleted || (external && (dl.newDesc.MediaType == "" || len(dl.newDesc.URLs) > 0)) {
 				// skip deleted and external layers
 				return dl, nil
 			}
@@ -173,8 +175,9 @@ func Apply(ctx context.Context, rc *regclient.RegClient, rSrc ref.Ref, opts ...O
 					}
 					rdr = readCloserFn{Reader: dr, closeFn: rdr.Close}
 				}
-				// setup tar reader to process layer
-				tr := tar.NewReader(rdr)
+				// setup tar reader to process layer, hiding the Seek method of uncompressed blobs
+				// since the tar reader seeks to skip unread content and the blob reader only supports seeking to the start
+				tr := tar.NewReader(struct{ io.Reader }{rdr})
 				// create temp file and setup tar writer
 				fh, err := os.CreateTemp("", "regclient-mod-")
 				if err != nil {
@@ -190,13 +193,13 @@ func Apply(ctx context.Context, rc *regclient.RegClient, rSrc ref.Ref, opts ...O
 				var zw *zstd.Encoder
 				digRaw := desc.DigestAlgo().Digester() // raw/compressed digest
 				digUC := desc.DigestAlgo().Digester()  // uncompressed digest
-				if dl.desc.MediaType == mediatype.Docker2LayerGzip || dl.desc.MediaType == mediatype.OCI1LayerGzip {
+				if desc.MediaType == mediatype.Docker2LayerGzip || desc.MediaType == mediatype.OCI1LayerGzip {
 					cw := io.MultiWriter(fh, digRaw.Hash())
 					gw = gzip.NewWriter(cw)
 					defer gw.Close()
 					ucw := io.MultiWriter(gw, digUC.Hash())
 					tw = tar.NewWriter(ucw)
-				} else if dl.desc.MediaType == mediatype.Docker2LayerZstd || dl.desc.MediaType == mediatype.OCI1LayerZstd {
+				} else if desc.MediaType == mediatype.Docker2LayerZstd || desc.MediaType == mediatype.OCI1LayerZstd {
 					cw := io.MultiWriter(fh, digRaw.Hash())
 					zw, err = zstd.NewWriter(cw)
 					if err != nil {
@@ -330,8 +333,40 @@ func Apply(ctx context.Context, rc *regclient.RegClient, rSrc ref.Ref, opts ...O
 				if err != nil {
 					return nil, err
 				}
+			} else if dl.mod == unchanged && external {
+				// converted external layers in the same repository are pulled from the URLs when missing
+				if _, err := rc.BlobHead(ctx, rTgt, dl.newDesc); err != nil {
+					bRdr, err := rc.BlobGet(ctx, rSrc, dl.desc)
+					if err != nil {
+						return nil, fmt.Errorf("failed to pull external layer %s: %w", dl.desc.Digest.String(), err)
+					}
+					_, err = rc.BlobPut(ctx, rTgt, dl.newDesc, bRdr)
+					_ = bRdr.Close()
+					if err != nil {
+						return nil, fmt.Errorf("failed to push external layer %s: %w", dl.desc.Digest.String(), err)
+					}
+				}
 			}
 			return dl, nil
+		}
+		// layers shared between platforms are only processed once
+		layersDone := map[string]*dagLayer{}
+		err = dagWalkLayers(dm, func(dl *dagLayer) (*dagLayer, error) {
+			doneKey := ""
+			if dl.desc.Digest != "" {
+				doneKey = fmt.Sprintf("%s|%s|%d|%s|%s", dl.rSrc.CommonName(), dl.desc.Digest.String(), dl.mod, dl.newDesc.MediaType, dl.newDesc.Digest.String())
+				if prev, ok := layersDone[doneKey]; ok {
+					dl.mod = prev.mod
+					dl.newDesc = prev.newDesc
+					dl.ucDigest = prev.ucDigest
+					return dl, nil
+				}
+			}
+			dl, err := applyLayer(dl)
+			if err == nil && doneKey != "" {
+				layersDone[doneKey] = dl
+			}
+			return dl, err
 		})
 		if err != nil {
 			return rTgt, err
@@ -358,6 +393,26 @@ func WithRefTgt(rTgt ref.Ref) Opts {
 	}
 }
 
+// WithReferrersCopy copies referrers to the target when it is in a different repository.
+// The subject of each referrer is updated to the modified manifest.
+// By default, referrers are only updated when the target is in the same repository.
+func WithReferrersCopy() Opts {
+	return func(dc *dagConfig, dm *dagManifest) error {
+		dc.referrersCopy = true
+		return nil
+	}
+}
+
+// WithReferrersSkip leaves referrers with any of the artifact types attached to the original manifest.
+// Signatures are typically skipped since they are not valid for a modified digest,
+// e.g. "application/vnd.dev.cosign.artifact.sig.v1+json" or "application/vnd.cncf.notary.signature".
+func WithReferrersSkip(artifactTypes ...string) Opts {
+	return func(dc *dagConfig, dm *dagManifest) error {
+		dc.referrersSkip = append(dc.referrersSkip, artifactTypes...)
+		return nil
+	}
+}
+
 // WithData sets the descriptor data field max size.
 // This also strips the data field off descriptors above the max size.
 func WithData(maxDataSize int64) Opts {
@@ -389,6 +444,29 @@ func WithDigestAlgo(algo digest.Algorithm) Opts {
 	}
 }
 
+// WithReproducible sets every timestamp in the config, history, and layer files to t, and fixes tar headers for reproducibility.
+// When t is zero, the time is read from the SOURCE_DATE_EPOCH environment variable.
+// Rebuilding the same content with the same time results in the same digest.
+func WithReproducible(t time.Time) Opts {
+	return func(dc *dagConfig, dm *dagManifest) error {
+		if t.IsZero() {
+			var err error
+			t, err = timeEpocEnv()
+			if err != nil {
+				return fmt.Errorf("WithReproducible requires a time or the %s variable: %w", epocEnv, err)
+			}
+		}
+		ot := OptTime{Set: t}
+		for _, opt := range []Opts{WithConfigTimestamp(ot), WithLayerTimestamp(ot), WithLayerReproducible()} {
+			err := opt(dc, dm)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
 func inListStr(str string, list []string) bool {
 	for _, s := range list {
 		if str == s {
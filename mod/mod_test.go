@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
@@ -21,7 +22,9 @@ import (
 	"github.com/regclient/regclient/config"
 	"github.com/regclient/regclient/internal/copyfs"
 	"github.com/regclient/regclient/pkg/archive"
+	"github.com/regclient/regclient/scheme"
 	"github.com/regclient/regclient/scheme/reg"
+	"github.com/regclient/regclient/types"
 	"github.com/regclient/regclient/types/errs"
 	"github.com/regclient/regclient/types/manifest"
 	"github.com/regclient/regclient/types/mediatype"
@@ -118,6 +121,10 @@ func TestMod(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to parse ref: %v", err)
 	}
+	rZstd, err := ref.New(tTgtHost + "/tgtzstd:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
 	rb1, err := ref.New("registry.example.org/testrepo:b1")
 	if err != nil {
 		t.Fatalf("failed to parse ref: %v", err)
@@ -158,6 +165,18 @@ func TestMod(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to parse platform specific descriptor: %v", err)
 	}
+	m3amd, err := rc.ManifestGet(ctx, r3amd)
+	if err != nil {
+		t.Fatalf("failed to get amd64 manifest: %v", err)
+	}
+	m3amdLayers, err := m3amd.(manifest.Imager).GetLayers()
+	if err != nil || len(m3amdLayers) < 2 {
+		t.Fatalf("failed to get amd64 layers: %v", err)
+	}
+	m3amdConf, err := rc.ImageConfig(ctx, r3amd)
+	if err != nil {
+		t.Fatalf("failed to get amd64 config: %v", err)
+	}
 	plat, err := platform.Parse("linux/amd64/v3")
 	if err != nil {
 		t.Fatalf("failed to parse the platform: %v", err)
@@ -254,6 +273,21 @@ func TestMod(t *testing.T) {
 			},
 			ref: tTgtHost + "/testrepo:v1",
 		},
+		{
+			name: "Change Annotation",
+			opts: []Opts{
+				WithAnnotation("[*]org.example.version", "changed"),
+			},
+			ref: tTgtHost + "/testrepo:v1",
+		},
+		{
+			name: "Annotation Missing Name",
+			opts: []Opts{
+				WithAnnotation("[*]", "hello"),
+			},
+			ref:     tTgtHost + "/testrepo:v1",
+			wantErr: errs.ErrMissingName,
+		},
 		{
 			name: "Delete Missing Annotation",
 			opts: []Opts{
@@ -591,6 +625,51 @@ func TestMod(t *testing.T) {
 			},
 			ref: tTgtHost + "/testrepo:v1",
 		},
+		{
+			name: "Layer Compressed zstd copy",
+			opts: []Opts{
+				WithLayerCompression(archive.CompressZstd),
+				WithRefTgt(rZstd),
+			},
+			ref: tSrcHost + "/testrepo:v1",
+		},
+		{
+			name: "Layer Compressed gzip level",
+			opts: []Opts{
+				WithLayerCompression(archive.CompressGzip, archive.CompressWithLevel(1)),
+			},
+			ref: tTgtHost + "/testrepo:v1",
+		},
+		{
+			name: "Layer Compressed zstd invalid level",
+			opts: []Opts{
+				WithLayerCompression(archive.CompressZstd, archive.CompressWithLevel(23)),
+			},
+			ref:     tTgtHost + "/testrepo:v1",
+			wantErr: fmt.Errorf("zstd compression level 23 is out of range (1-22)"),
+		},
+		{
+			name: "Layer Compressed single layer",
+			opts: []Opts{
+				WithLayerCompressionDigest(m3amdLayers[0].Digest, archive.CompressZstd, archive.CompressWithLevel(19)),
+			},
+			ref: r3amd.CommonName(),
+		},
+		{
+			name: "Layer Compressed single layer missing",
+			opts: []Opts{
+				WithLayerCompressionDigest(digest.FromString("missing layer"), archive.CompressZstd),
+			},
+			ref:      r3amd.CommonName(),
+			wantSame: true,
+		},
+		{
+			name: "Layer Compressed zstd to gzip",
+			opts: []Opts{
+				WithLayerCompression(archive.CompressGzip),
+			},
+			ref: rZstd.CommonName(),
+		},
 		{
 			name: "Layer Digest sha256",
 			opts: []Opts{
@@ -834,6 +913,28 @@ func TestMod(t *testing.T) {
 			ref:     r3amd.CommonName(),
 			wantErr: fmt.Errorf("layer not found"),
 		},
+		{
+			name: "Layer Remove by digest",
+			opts: []Opts{
+				WithLayerRmDigest(m3amdLayers[1].Digest),
+			},
+			ref: tTgtHost + "/testrepo:v3",
+		},
+		{
+			name: "Layer Remove by diff id",
+			opts: []Opts{
+				WithLayerRmDigest(m3amdConf.GetConfig().RootFS.DiffIDs[1]),
+			},
+			ref: r3amd.CommonName(),
+		},
+		{
+			name: "Layer Remove by digest missing",
+			opts: []Opts{
+				WithLayerRmDigest(digest.FromString("missing layer")),
+			},
+			ref:     tTgtHost + "/testrepo:v3",
+			wantErr: fmt.Errorf("layer not found: %s", digest.FromString("missing layer").String()),
+		},
 		{
 			name: "Manifest Digest sha256",
 			opts: []Opts{
@@ -901,6 +1002,36 @@ func TestMod(t *testing.T) {
 			ref:      tTgtHost + "/testrepo:a-example",
 			wantSame: true,
 		},
+		{
+			name: "Artifact type",
+			opts: []Opts{
+				WithArtifactType("application/example.other"),
+			},
+			ref: tTgtHost + "/testrepo:a-example",
+		},
+		{
+			name: "Artifact type unchanged",
+			opts: []Opts{
+				WithArtifactType("application/example"),
+			},
+			ref:      tTgtHost + "/testrepo:a-example",
+			wantSame: true,
+		},
+		{
+			name: "Artifact type index",
+			opts: []Opts{
+				WithArtifactType("application/example.index"),
+			},
+			ref: tTgtHost + "/testrepo:v1",
+		},
+		{
+			name: "Config media type index",
+			opts: []Opts{
+				WithConfigMediaType(mediatype.OCI1Empty),
+			},
+			ref:     tTgtHost + "/testrepo:v1",
+			wantErr: errs.ErrUnsupportedMediaType,
+		},
 		{
 			name: "Remove Command",
 			opts: []Opts{
@@ -937,6 +1068,35 @@ func TestMod(t *testing.T) {
 			},
 			ref: tTgtHost + "/testrepo:v1",
 		},
+		{
+			name: "Squash Layers",
+			opts: []Opts{
+				WithLayerSquash(0),
+			},
+			ref: tTgtHost + "/testrepo:v1",
+		},
+		{
+			name: "Set User",
+			opts: []Opts{
+				WithConfigUser("1000:1000"),
+			},
+			ref: tTgtHost + "/testrepo:v1",
+		},
+		{
+			name: "Remove Missing User",
+			opts: []Opts{
+				WithConfigUser(""),
+			},
+			ref:      tTgtHost + "/testrepo:v1",
+			wantSame: true,
+		},
+		{
+			name: "Set Working Dir",
+			opts: []Opts{
+				WithConfigWorkingDir("/app"),
+			},
+			ref: tTgtHost + "/testrepo:v1",
+		},
 		{
 			name: "Build arg rm",
 			opts: []Opts{
@@ -959,6 +1119,58 @@ func TestMod(t *testing.T) {
 			ref:      tTgtHost + "/testrepo:v1",
 			wantSame: true,
 		},
+		{
+			name: "Build arg rm all",
+			opts: []Opts{
+				WithBuildArgRmAll(),
+			},
+			ref: tTgtHost + "/testrepo:v1",
+		},
+		{
+			name: "History rm",
+			opts: []Opts{
+				WithHistoryRm(regexp.MustCompile(`^(ARG|LABEL) `)),
+			},
+			ref: tTgtHost + "/testrepo:v1",
+		},
+		{
+			name: "History rm all",
+			opts: []Opts{
+				WithHistoryRm(nil),
+			},
+			ref: tTgtHost + "/testrepo:v2",
+		},
+		{
+			name: "History rm missing",
+			opts: []Opts{
+				WithHistoryRm(regexp.MustCompile(`^no such command`)),
+			},
+			ref:      tTgtHost + "/testrepo:v1",
+			wantSame: true,
+		},
+		{
+			name: "History created by",
+			opts: []Opts{
+				WithHistoryCreatedBy(regexp.MustCompile(` # buildkit$`), ""),
+			},
+			ref: tTgtHost + "/testrepo:v1",
+		},
+		{
+			name: "Platforms keep missing",
+			opts: []Opts{
+				WithPlatformsKeep([]platform.Platform{{OS: "plan9", Architecture: "amd64"}}),
+			},
+			ref:     tTgtHost + "/testrepo:v1",
+			wantErr: errs.ErrNotFound,
+		},
+		{
+			name: "Platforms keep image",
+			opts: []Opts{
+				WithPlatformsKeep([]platform.Platform{{OS: "linux", Architecture: "amd64"}}),
+			},
+			ref:      r3amd.CommonName(),
+			wantSame: true,
+		},
 		{
 			name: "Rebase with annotations v2",
 			opts: []Opts{
@@ -1089,6 +1301,357 @@ func TestMod(t *testing.T) {
 			}
 		})
 	}
+
+	t.Run("Reproducible", func(t *testing.T) {
+		rSrc, err := ref.New(tSrcHost + "/testrepo:v1")
+		if err != nil {
+			t.Fatalf("failed creating ref: %v", err)
+		}
+		rRepro1 := rTgt1.SetTag("repro1")
+		rRepro2 := rTgt1.SetTag("repro2")
+		rRepro3 := rTgt1.SetTag("repro3")
+		reproTime := time.Unix(1700000000, 0).UTC()
+		// a build with the same content at a different time
+		_, err = Apply(ctx, rc, rSrc,
+			WithConfigTimestamp(OptTime{Set: oldTime}),
+			WithLayerTimestamp(OptTime{Set: oldTime}),
+			WithRefTgt(rRepro2))
+		if err != nil {
+			t.Fatalf("failed to change timestamps: %v", err)
+		}
+		rMod1, err := Apply(ctx, rc, rSrc, WithReproducible(reproTime), WithRefTgt(rRepro1))
+		if err != nil {
+			t.Fatalf("failed to apply reproducible: %v", err)
+		}
+		rMod3, err := Apply(ctx, rc, rRepro2, WithReproducible(reproTime), WithRefTgt(rRepro3))
+		if err != nil {
+			t.Fatalf("failed to apply reproducible: %v", err)
+		}
+		m1, err := rc.ManifestHead(ctx, rMod1, regclient.WithManifestRequireDigest())
+		if err != nil {
+			t.Fatalf("failed to head manifest: %v", err)
+		}
+		m3, err := rc.ManifestHead(ctx, rMod3, regclient.WithManifestRequireDigest())
+		if err != nil {
+			t.Fatalf("failed to head manifest: %v", err)
+		}
+		if m1.GetDescriptor().Digest != m3.GetDescriptor().Digest {
+			t.Errorf("reproducible digest mismatch, %s and %s", m1.GetDescriptor().Digest, m3.GetDescriptor().Digest)
+		}
+	})
+
+	t.Run("Artifact Migrate", func(t *testing.T) {
+		rSrc, err := ref.New(tSrcHost + "/testrepo:a-docker")
+		if err != nil {
+			t.Fatalf("failed creating ref: %v", err)
+		}
+		at := "application/vnd.example.build-type"
+		rMod, err := Apply(ctx, rc, rSrc,
+			WithArtifactType(at),
+			WithConfigMediaType(mediatype.OCI1Empty),
+			WithRefTgt(rTgt1.SetTag("artifact")))
+		if err != nil {
+			t.Fatalf("failed to apply: %v", err)
+		}
+		m, err := rc.ManifestGet(ctx, rMod)
+		if err != nil {
+			t.Fatalf("failed to get manifest: %v", err)
+		}
+		ociM, err := manifest.OCIManifestFromAny(m.GetOrig())
+		if err != nil {
+			t.Fatalf("failed to convert manifest: %v", err)
+		}
+		if ociM.ArtifactType != at {
+			t.Errorf("unexpected artifactType, expected %s, received %s", at, ociM.ArtifactType)
+		}
+		if ociM.Config.MediaType != mediatype.OCI1Empty {
+			t.Errorf("unexpected config media type, expected %s, received %s", mediatype.OCI1Empty, ociM.Config.MediaType)
+		}
+		_, err = rc.BlobHead(ctx, rMod, ociM.Config)
+		if err != nil {
+			t.Errorf("config blob missing: %v", err)
+		}
+	})
+
+	t.Run("History", func(t *testing.T) {
+		rSrc, err := ref.New(tSrcHost + "/testrepo:v1")
+		if err != nil {
+			t.Fatalf("failed creating ref: %v", err)
+		}
+		pAMD := platform.Platform{OS: "linux", Architecture: "amd64"}
+		confSrc, err := rc.ImageConfig(ctx, rSrc, regclient.ImageWithPlatform(pAMD.String()))
+		if err != nil {
+			t.Fatalf("failed to get config: %v", err)
+		}
+		// convert a step into a RUN with build args for the build arg removal
+		rRun, err := Apply(ctx, rc, rSrc,
+			WithHistoryCreatedBy(regexp.MustCompile(`^COPY layer1.txt /layer1 # buildkit$`), "RUN |2 arg=value arg_label=arg_for_label /bin/sh -c echo hello"),
+			WithRefTgt(rTgt1.SetTag("history-run")))
+		if err != nil {
+			t.Fatalf("failed to edit history: %v", err)
+		}
+		rMod, err := Apply(ctx, rc, rRun,
+			WithBuildArgRmAll(),
+			WithHistoryRm(regexp.MustCompile(`^LABEL `)),
+			WithRefTgt(rTgt1.SetTag("history")))
+		if err != nil {
+			t.Fatalf("failed to remove history: %v", err)
+		}
+		conf, err := rc.ImageConfig(ctx, rMod, regclient.ImageWithPlatform(pAMD.String()))
+		if err != nil {
+			t.Fatalf("failed to get config: %v", err)
+		}
+		srcLayers, layers := 0, 0
+		for _, h := range confSrc.GetConfig().History {
+			if !h.EmptyLayer {
+				srcLayers++
+			}
+		}
+		foundRun := false
+		for _, h := range conf.GetConfig().History {
+			if !h.EmptyLayer {
+				layers++
+			}
+			if strings.HasPrefix(h.CreatedBy, "ARG ") || strings.HasPrefix(h.CreatedBy, "LABEL ") {
+				t.Errorf("history entry not removed: %s", h.CreatedBy)
+			}
+			if strings.HasPrefix(h.CreatedBy, "RUN ") {
+				foundRun = true
+				if h.CreatedBy != "RUN /bin/sh -c echo hello" {
+					t.Errorf("build args not removed: %s", h.CreatedBy)
+				}
+			}
+		}
+		if !foundRun {
+			t.Errorf("RUN entry missing from history")
+		}
+		if layers != srcLayers {
+			t.Errorf("layer history entries changed, expected %d, received %d", srcLayers, layers)
+		}
+	})
+
+	t.Run("Shared Layers", func(t *testing.T) {
+		rSrc, err := ref.New(tSrcHost + "/testrepo:v1")
+		if err != nil {
+			t.Fatalf("failed creating ref: %v", err)
+		}
+		rMod, err := Apply(ctx, rc, rSrc, WithLayerCompression(archive.CompressZstd), WithRefTgt(rTgt1.SetTag("shared")))
+		if err != nil {
+			t.Fatalf("failed to recompress: %v", err)
+		}
+		mSrc, err := rc.ManifestGet(ctx, rSrc)
+		if err != nil {
+			t.Fatalf("failed to get manifest: %v", err)
+		}
+		mMod, err := rc.ManifestGet(ctx, rMod)
+		if err != nil {
+			t.Fatalf("failed to get manifest: %v", err)
+		}
+		dlSrc, _ := mSrc.(manifest.Indexer).GetManifestList()
+		dlMod, _ := mMod.(manifest.Indexer).GetManifestList()
+		if len(dlSrc) != len(dlMod) {
+			t.Fatalf("manifest list length changed, source %d, modified %d", len(dlSrc), len(dlMod))
+		}
+		// each source layer should map to a single converted layer across every platform
+		converted := map[digest.Digest]digest.Digest{}
+		sharedFound := false
+		for i := range dlSrc {
+			mpSrc, err := rc.ManifestGet(ctx, rSrc.SetDigest(dlSrc[i].Digest.String()))
+			if err != nil {
+				t.Fatalf("failed to get manifest: %v", err)
+			}
+			mpMod, err := rc.ManifestGet(ctx, rMod.SetDigest(dlMod[i].Digest.String()))
+			if err != nil {
+				t.Fatalf("failed to get manifest: %v", err)
+			}
+			lSrc, err := mpSrc.(manifest.Imager).GetLayers()
+			if err != nil {
+				continue
+			}
+			lMod, err := mpMod.(manifest.Imager).GetLayers()
+			if err != nil || len(lSrc) != len(lMod) {
+				t.Fatalf("failed to get layers: %v", err)
+			}
+			for j := range lSrc {
+				if prev, ok := converted[lSrc[j].Digest]; ok {
+					sharedFound = true
+					if prev != lMod[j].Digest {
+						t.Errorf("shared layer %s converted to %s and %s", lSrc[j].Digest, prev, lMod[j].Digest)
+					}
+				}
+				converted[lSrc[j].Digest] = lMod[j].Digest
+			}
+		}
+		if !sharedFound {
+			t.Errorf("no shared layers found in source")
+		}
+	})
+
+	t.Run("Platforms Keep", func(t *testing.T) {
+		rSrc, err := ref.New(tSrcHost + "/testrepo:v1")
+		if err != nil {
+			t.Fatalf("failed creating ref: %v", err)
+		}
+		pAMD := platform.Platform{OS: "linux", Architecture: "amd64"}
+		rMod, err := Apply(ctx, rc, rSrc, WithPlatformsKeep([]platform.Platform{pAMD}), WithRefTgt(rTgt1.SetTag("platforms")))
+		if err != nil {
+			t.Fatalf("failed to remove platforms: %v", err)
+		}
+		mSrc, err := rc.ManifestGet(ctx, rSrc)
+		if err != nil {
+			t.Fatalf("failed to get manifest: %v", err)
+		}
+		dlSrc, err := mSrc.(manifest.Indexer).GetManifestList()
+		if err != nil {
+			t.Fatalf("failed to get manifest list: %v", err)
+		}
+		mMod, err := rc.ManifestGet(ctx, rMod)
+		if err != nil {
+			t.Fatalf("failed to get manifest: %v", err)
+		}
+		dl, err := mMod.(manifest.Indexer).GetManifestList()
+		if err != nil {
+			t.Fatalf("failed to get manifest list: %v", err)
+		}
+		if len(dl) == 0 || len(dl) >= len(dlSrc) {
+			t.Fatalf("unexpected number of entries, source %d, modified %d", len(dlSrc), len(dl))
+		}
+		dAMD := digest.Digest("")
+		for _, d := range dl {
+			if d.Platform != nil && d.Platform.OS != "unknown" {
+				if !platform.Match(*d.Platform, pAMD) {
+					t.Errorf("unexpected platform %s", d.Platform.String())
+				}
+				dAMD = d.Digest
+			}
+		}
+		for _, d := range dl {
+			if refDig, ok := d.Annotations[dockerReferenceDigest]; ok && refDig != dAMD.String() {
+				t.Errorf("attestation for removed image was not removed: %s", refDig)
+			}
+		}
+	})
+
+	t.Run("Referrers", func(t *testing.T) {
+		rSrc, err := ref.New(tSrcHost + "/testrepo:v2")
+		if err != nil {
+			t.Fatalf("failed creating ref: %v", err)
+		}
+		rlSrc, err := rc.ReferrerList(ctx, rSrc)
+		if err != nil {
+			t.Fatalf("failed to list referrers: %v", err)
+		}
+		if len(rlSrc.Descriptors) < 2 {
+			t.Fatalf("source has too few referrers: %d", len(rlSrc.Descriptors))
+		}
+		skipType := "application/example.signature"
+		// without the copy option, referrers are not copied to another repository
+		rMod, err := Apply(ctx, rc, rSrc, WithAnnotation("test", "referrers"), WithRefTgt(rTgt1.SetTag("referrers-none")))
+		if err != nil {
+			t.Fatalf("failed to apply: %v", err)
+		}
+		rl, err := rc.ReferrerList(ctx, rMod)
+		if err != nil {
+			t.Fatalf("failed to list referrers: %v", err)
+		}
+		if len(rl.Descriptors) != 0 {
+			t.Errorf("referrers copied without the option: %v", rl.Descriptors)
+		}
+		rMod, err = Apply(ctx, rc, rSrc,
+			WithAnnotation("test", "referrers"),
+			WithReferrersCopy(),
+			WithReferrersSkip(skipType),
+			WithRefTgt(rTgt1.SetTag("referrers")))
+		if err != nil {
+			t.Fatalf("failed to apply: %v", err)
+		}
+		rl, err = rc.ReferrerList(ctx, rMod)
+		if err != nil {
+			t.Fatalf("failed to list referrers: %v", err)
+		}
+		if len(rl.Descriptors) != len(rlSrc.Descriptors)-1 {
+			t.Errorf("unexpected referrers, expected %d, received %v", len(rlSrc.Descriptors)-1, rl.Descriptors)
+		}
+		for _, d := range rl.Descriptors {
+			if d.ArtifactType == skipType {
+				t.Errorf("skipped referrer was copied: %v", d)
+			}
+			// verify the blobs were copied
+			m, err := rc.ManifestGet(ctx, rMod.SetDigest(d.Digest.String()))
+			if err != nil {
+				t.Fatalf("failed to get referrer: %v", err)
+			}
+			layers, err := m.(manifest.Imager).GetLayers()
+			if err != nil {
+				t.Fatalf("failed to get layers: %v", err)
+			}
+			for _, l := range layers {
+				_, err = rc.BlobHead(ctx, rMod, l)
+				if err != nil {
+					t.Errorf("referrer layer %s missing: %v", l.Digest, err)
+				}
+			}
+		}
+		// referrers of child manifests are also copied
+		rArm, err := ref.New(tSrcHost + "/testrepo:v2")
+		if err != nil {
+			t.Fatalf("failed creating ref: %v", err)
+		}
+		rlArm, err := rc.ReferrerList(ctx, rArm, scheme.WithReferrerPlatform("linux/arm64"))
+		if err != nil {
+			t.Fatalf("failed to list referrers: %v", err)
+		}
+		rlArmMod, err := rc.ReferrerList(ctx, rMod, scheme.WithReferrerPlatform("linux/arm64"))
+		if err != nil {
+			t.Fatalf("failed to list referrers: %v", err)
+		}
+		if len(rlArm.Descriptors) == 0 || len(rlArmMod.Descriptors) != len(rlArm.Descriptors) {
+			t.Errorf("unexpected child referrers, expected %d, received %d", len(rlArm.Descriptors), len(rlArmMod.Descriptors))
+		}
+	})
+
+	t.Run("Rebase Annotations", func(t *testing.T) {
+		rSrc, err := ref.New(tTgtHost + "/testrepo:v3")
+		if err != nil {
+			t.Fatalf("failed creating ref: %v", err)
+		}
+		rRebase := rTgt3.SetTag("rebase")
+		rMod, err := Apply(ctx, rc, rSrc, WithRebase(), WithRefTgt(rRebase))
+		if err != nil {
+			t.Fatalf("failed to rebase: %v", err)
+		}
+		mMod, err := rc.ManifestGet(ctx, rMod)
+		if err != nil {
+			t.Fatalf("failed to get manifest: %v", err)
+		}
+		annot, err := mMod.(manifest.Annotator).GetAnnotations()
+		if err != nil {
+			t.Fatalf("failed to get annotations: %v", err)
+		}
+		rBase, err := ref.New(annot[types.AnnotationBaseImageName])
+		if err != nil {
+			t.Fatalf("failed to parse base name: %v", err)
+		}
+		mBase, err := rc.ManifestHead(ctx, rBase, regclient.WithManifestRequireDigest())
+		if err != nil {
+			t.Fatalf("failed to head base: %v", err)
+		}
+		if annot[types.AnnotationBaseImageDigest] != mBase.GetDescriptor().Digest.String() {
+			t.Errorf("base digest annotation not updated, expected %s, received %s", mBase.GetDescriptor().Digest.String(), annot[types.AnnotationBaseImageDigest])
+		}
+		// a second rebase finds the image already on the new base
+		_, err = Apply(ctx, rc, rMod, WithRebase())
+		if err != nil {
+			t.Fatalf("failed to rebase again: %v", err)
+		}
+		mAgain, err := rc.ManifestHead(ctx, rRebase, regclient.WithManifestRequireDigest())
+		if err != nil {
+			t.Fatalf("failed to head manifest: %v", err)
+		}
+		if mAgain.GetDescriptor().Digest != mMod.GetDescriptor().Digest {
+			t.Errorf("second rebase changed the image")
+		}
+	})
 }
 
 func TestInList(t *testing.T) {
@@ -7,7 +7,10 @@ import (
 	"time"
 )
 
-const epocEnv = "SOURCE_DATE_EPOC"
+const (
+	epocEnv       = "SOURCE_DATE_EPOCH"
+	epocEnvLegacy = "SOURCE_DATE_EPOC" // misspelled name supported for compatibility
+)
 
 var (
 	errInvalidEpoc = errors.New("invalid epoc var")
@@ -24,6 +27,9 @@ func timeNow() time.Time {
 
 func timeEpocEnv() (time.Time, error) {
 	sec := os.Getenv(epocEnv)
+	if sec == "" {
+		sec = os.Getenv(epocEnvLegacy)
+	}
 	if sec == "" {
 		return time.Time{}, errInvalidEpoc
 	}
@@ -31,7 +37,7 @@ func timeEpocEnv() (time.Time, error) {
 	if err != nil {
 		return time.Time{}, err
 	}
-	return time.Unix(secI, 0), nil
+	return time.Unix(secI, 0).UTC(), nil
 }
 
 // timeModOpt adjusts time t according to the opts.
@@ -31,4 +31,13 @@ func TestTimeNow(t *testing.T) {
 			t.Errorf("timeNow did not use the epoc, expected %d, received %d", timePrev.Unix(), curTimeNow.Unix())
 		}
 	})
+	t.Run("WithLegacyEnv", func(t *testing.T) {
+		timePrev := time.Now().Add(-2 * time.Hour).Round(time.Second)
+		t.Setenv(epocEnv, "")
+		t.Setenv(epocEnvLegacy, fmt.Sprintf("%d", timePrev.Unix()))
+		curTimeNow := timeNow()
+		if !curTimeNow.Equal(timePrev) {
+			t.Errorf("timeNow did not use the legacy epoc, expected %d, received %d", timePrev.Unix(), curTimeNow.Unix())
+		}
+	})
 }
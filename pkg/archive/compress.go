@@ -32,14 +32,50 @@ var compressHeaders = map[CompressType][]byte{
 	CompressZstd:  []byte("\x28\xB5\x2F\xFD"),
 }
 
-func Compress(r io.Reader, oComp CompressType) (io.ReadCloser, error) {
+// CompressOpts configures options for Compress.
+type CompressOpts func(*compressOpts)
+
+type compressOpts struct {
+	level int
+}
+
+// CompressWithLevel sets the compression level, 0 uses the default for the algorithm.
+// Gzip supports levels 1 (fastest) to 9 (best), and zstd supports levels 1 to 22, which are mapped to the nearest zstd encoder level.
+func CompressWithLevel(level int) CompressOpts {
+	return func(co *compressOpts) {
+		co.level = level
+	}
+}
+
+// Compress returns a reader of the compressed content from r.
+func Compress(r io.Reader, oComp CompressType, opts ...CompressOpts) (io.ReadCloser, error) {
+	co := compressOpts{}
+	for _, opt := range opts {
+		opt(&co)
+	}
 	switch oComp {
 	// note, bzip2 compression is not supported
 	case CompressGzip:
+		if co.level != 0 {
+			if co.level < gzip.BestSpeed || co.level > gzip.BestCompression {
+				return nil, fmt.Errorf("gzip compression level %d is out of range (%d-%d)", co.level, gzip.BestSpeed, gzip.BestCompression)
+			}
+			return writeToRead(r, func(w io.Writer) (*gzip.Writer, error) {
+				return gzip.NewWriterLevel(w, co.level)
+			})
+		}
 		return writeToRead(r, newGzipWriter)
 	case CompressXz:
 		return writeToRead(r, xz.NewWriter)
 	case CompressZstd:
+		if co.level != 0 {
+			if co.level < 1 || co.level > 22 {
+				return nil, fmt.Errorf("zstd compression level %d is out of range (1-22)", co.level)
+			}
+			return writeToRead(r, func(w io.Writer) (*zstd.Encoder, error) {
+				return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(co.level)))
+			})
+		}
 		return writeToRead(r, newZstdWriter)
 	case CompressNone:
 		return io.NopCloser(r), nil
@@ -70,6 +70,51 @@ func TestRoundtrip(t *testing.T) {
 	}
 }
 
+func TestCompressLevel(t *testing.T) {
+	t.Parallel()
+	content := bytes.Repeat([]byte("hello world "), 1000)
+	tt := []struct {
+		name    string
+		algo    CompressType
+		level   int
+		wantErr bool
+	}{
+		{name: "gzip fast", algo: CompressGzip, level: 1},
+		{name: "gzip best", algo: CompressGzip, level: 9},
+		{name: "gzip invalid", algo: CompressGzip, level: 10, wantErr: true},
+		{name: "zstd fast", algo: CompressZstd, level: 1},
+		{name: "zstd best", algo: CompressZstd, level: 19},
+		{name: "zstd invalid", algo: CompressZstd, level: 23, wantErr: true},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			cr, err := Compress(bytes.NewReader(content), tc.algo, CompressWithLevel(tc.level))
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("compress did not fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to compress: %v", err)
+			}
+			dr, err := Decompress(cr)
+			if err != nil {
+				t.Fatalf("failed to decompress: %v", err)
+			}
+			out, err := io.ReadAll(dr)
+			if err != nil {
+				t.Fatalf("failed to ReadAll: %v", err)
+			}
+			if !bytes.Equal(content, out) {
+				t.Errorf("output mismatch")
+			}
+		})
+	}
+}
+
 func FuzzRoundTrip(f *testing.F) {
 	f.Add(int(CompressNone), "hello world")
 	f.Fuzz(func(t *testing.T, comp int, s string) {
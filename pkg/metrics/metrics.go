@@ -0,0 +1,236 @@
+// Package metrics collects statistics on registry requests.
+//
+// The statistics are exposed in the Prometheus text format without depending on the Prometheus client library.
+// A [Metrics] is an [http.Handler] that may be added to an existing server, or the output may be included in another collector using [Metrics.Write].
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are the upper bounds in seconds of the request duration histogram.
+var DefaultBuckets = []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// Metrics tracks the requests to each registry host.
+// All methods are safe to call concurrently and on a nil value.
+type Metrics struct {
+	mu      sync.Mutex
+	prefix  string
+	buckets []float64
+	hosts   map[string]*hostStats
+}
+
+type hostStats struct {
+	requests    map[reqKey]uint64
+	durBuckets  []uint64
+	durCount    uint64
+	durSum      float64
+	bytesSent   uint64
+	bytesRecv   uint64
+	authRefresh uint64
+	rateLimit   uint64
+}
+
+type reqKey struct {
+	method string
+	status string
+}
+
+// Opts is used to configure metrics.
+type Opts func(*Metrics)
+
+// New creates a new set of metrics.
+func New(opts ...Opts) *Metrics {
+	m := &Metrics{
+		prefix:  "regclient",
+		buckets: DefaultBuckets,
+		hosts:   map[string]*hostStats{},
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// WithBuckets sets the upper bounds in seconds of the request duration histogram.
+func WithBuckets(buckets []float64) Opts {
+	return func(m *Metrics) {
+		b := make([]float64, len(buckets))
+		copy(b, buckets)
+		sort.Float64s(b)
+		m.buckets = b
+	}
+}
+
+// WithPrefix sets the prefix of each metric name, the default is "regclient".
+func WithPrefix(prefix string) Opts {
+	return func(m *Metrics) {
+		m.prefix = prefix
+	}
+}
+
+// getHost returns the stats for a host, the caller must hold the lock.
+func (m *Metrics) getHost(host string) *hostStats {
+	hs, ok := m.hosts[host]
+	if !ok {
+		hs = &hostStats{
+			requests:   map[reqKey]uint64{},
+			durBuckets: make([]uint64, len(m.buckets)),
+		}
+		m.hosts[host] = hs
+	}
+	return hs
+}
+
+// Request records a completed request to a host.
+// A status of 0 indicates the request failed without a response.
+func (m *Metrics) Request(host, method string, status int, dur time.Duration) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	hs := m.getHost(host)
+	key := reqKey{method: method, status: "error"}
+	if status > 0 {
+		key.status = strconv.Itoa(status)
+	}
+	hs.requests[key]++
+	sec := dur.Seconds()
+	for i, b := range m.buckets {
+		if sec <= b {
+			hs.durBuckets[i]++
+		}
+	}
+	hs.durCount++
+	hs.durSum += sec
+}
+
+// BytesSent records the bytes sent to a host.
+func (m *Metrics) BytesSent(host string, n int64) {
+	if m == nil || n <= 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.getHost(host).bytesSent += uint64(n)
+}
+
+// BytesReceived records the bytes received from a host.
+func (m *Metrics) BytesReceived(host string, n int64) {
+	if m == nil || n <= 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.getHost(host).bytesRecv += uint64(n)
+}
+
+// AuthRefresh records a request that needed new credentials after an authentication challenge.
+func (m *Metrics) AuthRefresh(host string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.getHost(host).authRefresh++
+}
+
+// RateLimit records a request that was rejected by the rate limit of a host.
+func (m *Metrics) RateLimit(host string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.getHost(host).rateLimit++
+}
+
+// ServeHTTP outputs the metrics in the Prometheus text format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = m.Write(w)
+}
+
+// Write outputs the metrics in the Prometheus text format.
+func (m *Metrics) Write(w io.Writer) error {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	hosts := make([]string, 0, len(m.hosts))
+	for h := range m.hosts {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+	sb := &strings.Builder{}
+
+	name := m.prefix + "_requests_total"
+	writeHeader(sb, name, "counter", "Count of HTTP requests to registries.")
+	for _, h := range hosts {
+		hs := m.hosts[h]
+		keys := make([]reqKey, 0, len(hs.requests))
+		for k := range hs.requests {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if keys[i].method != keys[j].method {
+				return keys[i].method < keys[j].method
+			}
+			return keys[i].status < keys[j].status
+		})
+		for _, k := range keys {
+			fmt.Fprintf(sb, "%s{host=%s,method=%s,status=%s} %d\n", name, quote(h), quote(k.method), quote(k.status), hs.requests[k])
+		}
+	}
+
+	name = m.prefix + "_request_duration_seconds"
+	writeHeader(sb, name, "histogram", "Duration of HTTP requests to registries until the response headers are received.")
+	for _, h := range hosts {
+		hs := m.hosts[h]
+		for i, b := range m.buckets {
+			fmt.Fprintf(sb, "%s_bucket{host=%s,le=\"%s\"} %d\n", name, quote(h), strconv.FormatFloat(b, 'g', -1, 64), hs.durBuckets[i])
+		}
+		fmt.Fprintf(sb, "%s_bucket{host=%s,le=\"+Inf\"} %d\n", name, quote(h), hs.durCount)
+		fmt.Fprintf(sb, "%s_sum{host=%s} %s\n", name, quote(h), strconv.FormatFloat(hs.durSum, 'g', -1, 64))
+		fmt.Fprintf(sb, "%s_count{host=%s} %d\n", name, quote(h), hs.durCount)
+	}
+
+	for _, c := range []struct {
+		name, help string
+		val        func(*hostStats) uint64
+	}{
+		{name: "_sent_bytes_total", help: "Bytes sent to registries in request bodies.", val: func(hs *hostStats) uint64 { return hs.bytesSent }},
+		{name: "_received_bytes_total", help: "Bytes received from registries in response bodies.", val: func(hs *hostStats) uint64 { return hs.bytesRecv }},
+		{name: "_auth_refresh_total", help: "Count of authentication challenges that required new credentials.", val: func(hs *hostStats) uint64 { return hs.authRefresh }},
+		{name: "_rate_limit_total", help: "Count of requests rejected by a registry rate limit.", val: func(hs *hostStats) uint64 { return hs.rateLimit }},
+	} {
+		name = m.prefix + c.name
+		writeHeader(sb, name, "counter", c.help)
+		for _, h := range hosts {
+			fmt.Fprintf(sb, "%s{host=%s} %d\n", name, quote(h), c.val(m.hosts[h]))
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writeHeader(sb *strings.Builder, name, kind, help string) {
+	fmt.Fprintf(sb, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// quote returns a label value escaped for the Prometheus text format.
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+	var mNil *Metrics
+	mNil.Request("example.com", "GET", 200, time.Second)
+	mNil.AuthRefresh("example.com")
+	err := mNil.Write(&bytes.Buffer{})
+	if err != nil {
+		t.Errorf("write of nil metrics failed: %v", err)
+	}
+
+	m := New(WithBuckets([]float64{1, 0.1}))
+	m.Request("registry.example.com", "GET", 200, 50*time.Millisecond)
+	m.Request("registry.example.com", "GET", 200, 500*time.Millisecond)
+	m.Request("registry.example.com", "HEAD", 0, 2*time.Second)
+	m.Request(`quote"host`, "GET", 429, time.Millisecond)
+	m.BytesSent("registry.example.com", 10)
+	m.BytesReceived("registry.example.com", 100)
+	m.BytesReceived("registry.example.com", 23)
+	m.AuthRefresh("registry.example.com")
+	m.RateLimit(`quote"host`)
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("unexpected content type: %s", rec.Header().Get("Content-Type"))
+	}
+	out := rec.Body.String()
+	for _, expect := range []string{
+		"# TYPE regclient_requests_total counter\n",
+		`regclient_requests_total{host="registry.example.com",method="GET",status="200"} 2` + "\n",
+		`regclient_requests_total{host="registry.example.com",method="HEAD",status="error"} 1` + "\n",
+		`regclient_requests_total{host="quote\"host",method="GET",status="429"} 1` + "\n",
+		"# TYPE regclient_request_duration_seconds histogram\n",
+		`regclient_request_duration_seconds_bucket{host="registry.example.com",le="0.1"} 1` + "\n",
+		`regclient_request_duration_seconds_bucket{host="registry.example.com",le="1"} 2` + "\n",
+		`regclient_request_duration_seconds_bucket{host="registry.example.com",le="+Inf"} 3` + "\n",
+		`regclient_request_duration_seconds_count{host="registry.example.com"} 3` + "\n",
+		`regclient_sent_bytes_total{host="registry.example.com"} 10` + "\n",
+		`regclient_received_bytes_total{host="registry.example.com"} 123` + "\n",
+		`regclient_auth_refresh_total{host="registry.example.com"} 1` + "\n",
+		`regclient_rate_limit_total{host="quote\"host"} 1` + "\n",
+	} {
+		if !strings.Contains(out, expect) {
+			t.Errorf("output missing %q", expect)
+		}
+	}
+	if t.Failed() {
+		t.Logf("output:\n%s", out)
+	}
+
+	mPrefix := New(WithPrefix("example"))
+	mPrefix.Request("registry.example.com", "GET", 200, time.Millisecond)
+	buf := &bytes.Buffer{}
+	err = mPrefix.Write(buf)
+	if err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	if !strings.Contains(buf.String(), "example_requests_total{") || strings.Contains(buf.String(), "regclient_") {
+		t.Errorf("prefix not applied: %s", buf.String())
+	}
+}
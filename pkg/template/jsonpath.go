@@ -0,0 +1,91 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// jsonPath returns a value from the json representation of v.
+// The path is a list of field names separated by ".", array indexes in brackets, and quoted keys in brackets for names containing a ".",
+// e.g. `config.Labels["org.opencontainers.image.version"]` or `layers[0].digest`.
+func jsonPath(v interface{}, path string) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var cur interface{}
+	err = json.Unmarshal(b, &cur)
+	if err != nil {
+		return nil, err
+	}
+	segs, err := jsonPathParse(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, seg := range segs {
+		switch c := cur.(type) {
+		case map[string]interface{}:
+			next, ok := c[seg]
+			if !ok {
+				return nil, nil
+			}
+			cur = next
+		case []interface{}:
+			i, err := strconv.Atoi(seg)
+			if err != nil {
+				return nil, fmt.Errorf("json path %s: invalid array index %q", path, seg)
+			}
+			if i < 0 || i >= len(c) {
+				return nil, nil
+			}
+			cur = c[i]
+		default:
+			return nil, nil
+		}
+	}
+	return cur, nil
+}
+
+// jsonPathParse splits a json path into each key or index.
+func jsonPathParse(path string) ([]string, error) {
+	segs := []string{}
+	cur := ""
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '.':
+			if cur != "" {
+				segs = append(segs, cur)
+				cur = ""
+			}
+		case '[':
+			if cur != "" {
+				segs = append(segs, cur)
+				cur = ""
+			}
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("json path %s: missing closing bracket", path)
+			}
+			// quoted keys may contain a "]", search for the closing quote first
+			if path[i+1] == '"' {
+				q := strings.IndexByte(path[i+2:], '"')
+				if q < 0 || i+2+q+1 >= len(path) || path[i+2+q+1] != ']' {
+					return nil, fmt.Errorf("json path %s: invalid quoted key", path)
+				}
+				segs = append(segs, path[i+2:i+2+q])
+				i = i + 2 + q + 1
+				continue
+			}
+			segs = append(segs, path[i+1:i+end])
+			i = i + end
+		default:
+			cur += string(path[i])
+		}
+	}
+	if cur != "" {
+		segs = append(segs, cur)
+	}
+	return segs, nil
+}
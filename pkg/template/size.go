@@ -0,0 +1,22 @@
+package template
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/regclient/regclient/internal/units"
+)
+
+// size returns a human readable size for a number of bytes, e.g. "2.746MB".
+func size(v interface{}) (string, error) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return units.HumanSize(float64(rv.Int())), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return units.HumanSize(float64(rv.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		return units.HumanSize(rv.Float()), nil
+	}
+	return "", fmt.Errorf("size requires a number, received %T", v)
+}
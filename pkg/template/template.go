@@ -45,8 +45,10 @@ var tmplFuncs = gotemplate.FuncMap{
 		_ = enc.Encode(v)
 		return buf.String()
 	},
+	"jsonPath":    jsonPath,
 	"printPretty": printPretty,
 	"lower":       strings.ToLower,
+	"size":        size,
 	"split":       strings.Split,
 	"time":        func() *TimeFuncs { return &TimeFuncs{} },
 	"upper":       strings.ToUpper,
@@ -75,7 +77,7 @@ func Writer(out io.Writer, tmpl string, data interface{}, opts ...Opt) error {
 // String converts a template to a string
 func String(tmpl string, data interface{}, opts ...Opt) (string, error) {
 	var sb strings.Builder
-	err := Writer(&sb, tmpl, data)
+	err := Writer(&sb, tmpl, data, opts...)
 	if err != nil {
 		return "", err
 	}
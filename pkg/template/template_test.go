@@ -0,0 +1,107 @@
+package template
+
+import (
+	"testing"
+	"time"
+)
+
+func TestString(t *testing.T) {
+	t.Parallel()
+	created := time.Date(2020, 6, 7, 8, 9, 10, 0, time.UTC)
+	data := struct {
+		Name    string
+		Size    int64
+		Created *time.Time
+		Config  map[string]interface{}
+		Layers  []map[string]interface{}
+	}{
+		Name:    "test",
+		Size:    2746000,
+		Created: &created,
+		Config: map[string]interface{}{
+			"Labels": map[string]string{
+				"org.opencontainers.image.version": "1.2.3",
+			},
+		},
+		Layers: []map[string]interface{}{
+			{"digest": "sha256:a"},
+			{"digest": "sha256:b"},
+		},
+	}
+	tt := []struct {
+		name   string
+		tmpl   string
+		expect string
+		err    bool
+	}{
+		{
+			name:   "field",
+			tmpl:   "{{ .Name }}",
+			expect: "test",
+		},
+		{
+			name:   "jsonPath field",
+			tmpl:   `{{ jsonPath . "Name" }}`,
+			expect: "test",
+		},
+		{
+			name:   "jsonPath quoted key",
+			tmpl:   `{{ jsonPath . "Config.Labels[\"org.opencontainers.image.version\"]" }}`,
+			expect: "1.2.3",
+		},
+		{
+			name:   "jsonPath index",
+			tmpl:   `{{ jsonPath . "Layers[1].digest" }}`,
+			expect: "sha256:b",
+		},
+		{
+			name:   "jsonPath missing",
+			tmpl:   `{{ jsonPath . "Config.missing" | default "none" }}`,
+			expect: "none",
+		},
+		{
+			name: "jsonPath invalid index",
+			tmpl: `{{ jsonPath . "Layers.x" }}`,
+			err:  true,
+		},
+		{
+			name:   "size",
+			tmpl:   "{{ size .Size }}",
+			expect: "2.746MB",
+		},
+		{
+			name: "size invalid",
+			tmpl: "{{ size .Name }}",
+			err:  true,
+		},
+		{
+			name:   "time format",
+			tmpl:   `{{ time.Format "2006-01-02" .Created }}`,
+			expect: "2020-06-07",
+		},
+		{
+			name:   "time unix",
+			tmpl:   `{{ (time.Unix 1591517350).Format "2006-01-02T15:04:05Z07:00" }}`,
+			expect: "2020-06-07T08:09:10Z",
+		},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			out, err := String(tc.tmpl, data)
+			if tc.err {
+				if err == nil {
+					t.Errorf("did not receive expected error, output: %s", out)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out != tc.expect {
+				t.Errorf("unexpected output, expected %s, received %s", tc.expect, out)
+			}
+		})
+	}
+}
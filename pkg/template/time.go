@@ -21,3 +21,18 @@ func (t *TimeFuncs) Now() time.Time {
 func (t *TimeFuncs) Parse(layout string, value string) (time.Time, error) {
 	return time.Parse(layout, value)
 }
+
+// Format outputs the time according to layout
+func (t *TimeFuncs) Format(layout string, value time.Time) string {
+	return value.Format(layout)
+}
+
+// Since returns the duration since the time, rounded to the second
+func (t *TimeFuncs) Since(value time.Time) time.Duration {
+	return time.Since(value).Round(time.Second)
+}
+
+// Unix returns the time from the seconds since the Unix epoch
+func (t *TimeFuncs) Unix(sec int64) time.Time {
+	return time.Unix(sec, 0).UTC()
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 
 	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/types/descriptor"
 	"github.com/regclient/regclient/types/errs"
+	"github.com/regclient/regclient/types/manifest"
 	"github.com/regclient/regclient/types/platform"
 	"github.com/regclient/regclient/types/ref"
 	"github.com/regclient/regclient/types/referrer"
@@ -55,3 +57,55 @@ func (rc *RegClient) ReferrerList(ctx context.Context, rSubject ref.Ref, opts ..
 	}
 	return schemeAPI.ReferrerList(ctx, rSubject, opts...)
 }
+
+// ReferrerDelete removes a referrer manifest and updates the referrers list of the subject.
+// The reference must include the digest of the referrer.
+// On registries without the referrers API, the fallback tag for the subject is updated or removed.
+func (rc *RegClient) ReferrerDelete(ctx context.Context, r ref.Ref) error {
+	if !r.IsSet() {
+		return fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), errs.ErrInvalidReference)
+	}
+	if r.Digest == "" {
+		return fmt.Errorf("digest is required to delete a referrer: %s%.0w", r.CommonName(), errs.ErrMissingDigest)
+	}
+	return rc.ManifestDelete(ctx, r, WithManifestCheckReferrers())
+}
+
+// ReferrerPut pushes a manifest as a referrer to the subject.
+// If the manifest does not have a subject, it is set to the descriptor of rSubject, which changes the manifest digest.
+// The manifest is pushed by digest to the repository of rSubject.
+// On registries without the referrers API, the fallback tag for the subject is updated.
+func (rc *RegClient) ReferrerPut(ctx context.Context, rSubject ref.Ref, m manifest.Manifest) error {
+	if !rSubject.IsSet() {
+		return fmt.Errorf("ref is not set: %s%.0w", rSubject.CommonName(), errs.ErrInvalidReference)
+	}
+	ms, ok := m.(manifest.Subjecter)
+	if !ok {
+		return fmt.Errorf("manifest does not support the subject field: %w", errs.ErrUnsupportedMediaType)
+	}
+	subject, err := ms.GetSubject()
+	if err != nil {
+		return err
+	}
+	if subject == nil || subject.Digest == "" {
+		// lookup the subject descriptor
+		mh, err := rc.ManifestHead(ctx, rSubject, WithManifestRequireDigest())
+		if err != nil {
+			return fmt.Errorf("failed to get digest for subject: %w", err)
+		}
+		mhDesc := mh.GetDescriptor()
+		subject = &descriptor.Descriptor{
+			MediaType: mhDesc.MediaType,
+			Digest:    mhDesc.Digest,
+			Size:      mhDesc.Size,
+		}
+		err = ms.SetSubject(subject)
+		if err != nil {
+			return err
+		}
+	} else if rSubject.Digest != "" && rSubject.Digest != subject.Digest.String() {
+		return fmt.Errorf("manifest subject %s does not match %s%.0w", subject.Digest.String(), rSubject.CommonName(), errs.ErrMismatch)
+	}
+	r := rSubject.SetDigest(m.GetDescriptor().Digest.String())
+	return rc.ManifestPut(ctx, r, m)
+}
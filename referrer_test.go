@@ -17,6 +17,9 @@ import (
 	"github.com/regclient/regclient/internal/copyfs"
 	"github.com/regclient/regclient/scheme"
 	"github.com/regclient/regclient/types/descriptor"
+	"github.com/regclient/regclient/types/errs"
+	"github.com/regclient/regclient/types/manifest"
+	v1 "github.com/regclient/regclient/types/oci/v1"
 	"github.com/regclient/regclient/types/ref"
 )
 
@@ -204,3 +207,168 @@ func TestReferrerList(t *testing.T) {
 		})
 	}
 }
+
+func TestReferrerPutDelete(t *testing.T) {
+	ctx := context.Background()
+	t.Parallel()
+	testRepo := "testrepo"
+	boolT := true
+	boolF := false
+	tempDir := t.TempDir()
+	err := copyfs.Copy(tempDir+"/"+testRepo, "./testdata/"+testRepo)
+	if err != nil {
+		t.Fatalf("failed to copy %s to tempDir: %v", testRepo, err)
+	}
+	regRefHandler := olareg.New(oConfig.Config{
+		Storage: oConfig.ConfigStorage{
+			StoreType: oConfig.StoreMem,
+			RootDir:   "./testdata",
+		},
+		API: oConfig.ConfigAPI{
+			DeleteEnabled: &boolT,
+			Referrer: oConfig.ConfigAPIReferrer{
+				Enabled: &boolT,
+			},
+		},
+	})
+	regNoRefHandler := olareg.New(oConfig.Config{
+		Storage: oConfig.ConfigStorage{
+			StoreType: oConfig.StoreMem,
+			RootDir:   "./testdata",
+		},
+		API: oConfig.ConfigAPI{
+			DeleteEnabled: &boolT,
+			Referrer: oConfig.ConfigAPIReferrer{
+				Enabled: &boolF,
+			},
+		},
+	})
+	tsRef := httptest.NewServer(regRefHandler)
+	tsRefURL, _ := url.Parse(tsRef.URL)
+	tsRefHost := tsRefURL.Host
+	tsNoRef := httptest.NewServer(regNoRefHandler)
+	tsNoRefURL, _ := url.Parse(tsNoRef.URL)
+	tsNoRefHost := tsNoRefURL.Host
+	t.Cleanup(func() {
+		tsRef.Close()
+		tsNoRef.Close()
+		_ = regRefHandler.Close()
+		_ = regNoRefHandler.Close()
+	})
+	rcHosts := []config.Host{
+		{
+			Name:     tsRefHost,
+			Hostname: tsRefHost,
+			TLS:      config.TLSDisabled,
+		},
+		{
+			Name:     tsNoRefHost,
+			Hostname: tsNoRefHost,
+			TLS:      config.TLSDisabled,
+		},
+	}
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	rc := New(
+		WithConfigHost(rcHosts...),
+		WithSlog(log),
+	)
+	tt := []struct {
+		name string
+		reg  string
+	}{
+		{
+			name: "ocidir",
+			reg:  "ocidir://" + tempDir,
+		},
+		{
+			name: "reg-with-referrer",
+			reg:  tsRefHost,
+		},
+		{
+			name: "reg-wo-referrer",
+			reg:  tsNoRefHost,
+		},
+	}
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rSrc, err := ref.New(fmt.Sprintf("%s/%s:v2", tc.reg, testRepo))
+			if err != nil {
+				t.Fatalf("failed to generate ref: %v", err)
+			}
+			rSubject, err := ref.New(fmt.Sprintf("%s/%s:v3", tc.reg, testRepo))
+			if err != nil {
+				t.Fatalf("failed to generate ref: %v", err)
+			}
+			// build a new referrer from an existing referrer without the subject
+			rlSrc, err := rc.ReferrerList(ctx, rSrc)
+			if err != nil || len(rlSrc.Descriptors) == 0 {
+				t.Fatalf("failed to list source referrers: %v", err)
+			}
+			mSrc, err := rc.ManifestGet(ctx, rSrc.SetDigest(rlSrc.Descriptors[0].Digest.String()))
+			if err != nil {
+				t.Fatalf("failed to get source referrer: %v", err)
+			}
+			orig, ok := mSrc.GetOrig().(v1.Manifest)
+			if !ok {
+				t.Fatalf("source referrer is not an OCI manifest: %T", mSrc.GetOrig())
+			}
+			orig.Subject = nil
+			orig.Annotations = map[string]string{"org.example.test": tc.name}
+			m, err := manifest.New(manifest.WithOrig(orig))
+			if err != nil {
+				t.Fatalf("failed to create manifest: %v", err)
+			}
+			rlBefore, err := rc.ReferrerList(ctx, rSubject)
+			if err != nil {
+				t.Fatalf("failed to list referrers: %v", err)
+			}
+			// push the referrer and verify it is listed
+			err = rc.ReferrerPut(ctx, rSubject, m)
+			if err != nil {
+				t.Fatalf("failed to put referrer: %v", err)
+			}
+			ms, ok := m.(manifest.Subjecter)
+			if !ok {
+				t.Fatalf("manifest does not support subject")
+			}
+			subject, err := ms.GetSubject()
+			if err != nil || subject == nil || subject.Digest == "" {
+				t.Fatalf("subject was not set on the manifest: %v", err)
+			}
+			rlPut, err := rc.ReferrerList(ctx, rSubject)
+			if err != nil {
+				t.Fatalf("failed to list referrers: %v", err)
+			}
+			if len(rlPut.Descriptors) != len(rlBefore.Descriptors)+1 {
+				t.Errorf("unexpected referrer count after put, expected %d, received %d", len(rlBefore.Descriptors)+1, len(rlPut.Descriptors))
+			}
+			_, err = descriptor.DescriptorListSearch(rlPut.Descriptors, descriptor.MatchOpt{Annotations: map[string]string{"org.example.test": tc.name}})
+			if err != nil {
+				t.Errorf("pushed referrer not found: %v", err)
+			}
+			// a mismatched subject should fail
+			err = rc.ReferrerPut(ctx, rSrc.SetDigest(rlSrc.Descriptors[0].Digest.String()), m)
+			if err == nil || !errors.Is(err, errs.ErrMismatch) {
+				t.Errorf("put with mismatched subject did not fail with mismatch: %v", err)
+			}
+			// delete the referrer and verify it is removed
+			err = rc.ReferrerDelete(ctx, rSubject)
+			if err == nil || !errors.Is(err, errs.ErrMissingDigest) {
+				t.Errorf("delete without digest did not fail with missing digest: %v", err)
+			}
+			err = rc.ReferrerDelete(ctx, rSubject.SetDigest(m.GetDescriptor().Digest.String()))
+			if err != nil {
+				t.Fatalf("failed to delete referrer: %v", err)
+			}
+			rlDel, err := rc.ReferrerList(ctx, rSubject)
+			if err != nil {
+				t.Fatalf("failed to list referrers: %v", err)
+			}
+			if len(rlDel.Descriptors) != len(rlBefore.Descriptors) {
+				t.Errorf("unexpected referrer count after delete, expected %d, received %d", len(rlBefore.Descriptors), len(rlDel.Descriptors))
+			}
+		})
+	}
+}
@@ -30,12 +30,14 @@ const (
 
 // RegClient is used to access OCI distribution-spec registries.
 type RegClient struct {
-	hosts       map[string]*config.Host
-	hostDefault *config.Host
-	regOpts     []reg.Opts
-	schemes     map[string]scheme.API
-	slog        *slog.Logger
-	userAgent   string
+	blobCacheDir string
+	dockerHost   string
+	hosts        map[string]*config.Host
+	hostDefault  *config.Host
+	regOpts      []reg.Opts
+	schemes      map[string]scheme.API
+	slog         *slog.Logger
+	userAgent    string
 }
 
 // Opt functions are used by [New] to create a [*RegClient].
@@ -108,6 +110,15 @@ func WithBlobSize(chunk, max int64) Opt {
 	}
 }
 
+// WithBlobCache stores blobs pulled from registries in a content addressable directory.
+// Later pulls of the same digest, e.g. a common base image copied to many targets, read from the directory instead of the registry.
+// Blobs are only added after the digest is verified, and the cache is never pruned.
+func WithBlobCache(dir string) Opt {
+	return func(rc *RegClient) {
+		rc.blobCacheDir = dir
+	}
+}
+
 // WithCertDir adds a path of certificates to trust similar to Docker's /etc/docker/certs.d.
 //
 // Deprecated: replace with WithRegOpts(reg.WithCertDirs(path)), see [WithRegOpts] and [reg.WithCertDirs].
@@ -171,6 +182,14 @@ func WithDockerCredsFile(fname string) Opt {
 	}
 }
 
+// WithDockerHost sets the Docker engine used by "docker-daemon://" references.
+// The default uses DOCKER_HOST or "unix:///var/run/docker.sock".
+func WithDockerHost(host string) Opt {
+	return func(rc *RegClient) {
+		rc.dockerHost = host
+	}
+}
+
 // WithRegOpts passes through opts to the reg scheme.
 func WithRegOpts(opts ...reg.Opts) Opt {
 	return func(rc *RegClient) {
@@ -237,6 +256,7 @@ func (rc *RegClient) hostLoad(src string, hosts []config.Host) {
 			slog.Int64("blobChunk", configHost.BlobChunk),
 			slog.Int64("blobMax", configHost.BlobMax),
 			slog.String("helper", configHost.CredHelper),
+			slog.String("provider", configHost.CredProvider),
 			slog.String("hostname", configHost.Hostname),
 			slog.Any("mirrors", configHost.Mirrors),
 			slog.String("name", configHost.Name),
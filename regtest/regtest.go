@@ -0,0 +1,207 @@
+// Package regtest runs an in-memory registry for unit tests.
+//
+// The registry is served with [httptest] and does not require network access.
+// Images may be preloaded from an OCI Layout, copied from another reference, or generated from layer content.
+package regtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/olareg/olareg"
+	oConfig "github.com/olareg/olareg/config"
+	digest "github.com/opencontainers/go-digest"
+
+	"github.com/regclient/regclient"
+	"github.com/regclient/regclient/config"
+	"github.com/regclient/regclient/types/descriptor"
+	"github.com/regclient/regclient/types/manifest"
+	"github.com/regclient/regclient/types/mediatype"
+	v1 "github.com/regclient/regclient/types/oci/v1"
+	"github.com/regclient/regclient/types/platform"
+	"github.com/regclient/regclient/types/ref"
+)
+
+// Server is an in-memory registry.
+type Server struct {
+	// Host is the host and port of the registry, used as the registry in a reference.
+	Host string
+	reg  *olareg.Server
+	ts   *httptest.Server
+}
+
+// Opts is used to configure the registry.
+type Opts func(*oConfig.Config)
+
+// WithTestdata preloads the registry from a directory.
+// Each repository is a subdirectory containing an OCI Layout.
+// Changes are only made in memory, the directory is not modified.
+func WithTestdata(dir string) Opts {
+	return func(c *oConfig.Config) {
+		c.Storage.RootDir = dir
+	}
+}
+
+// WithReadOnly disables pushing to the registry.
+func WithReadOnly() Opts {
+	return func(c *oConfig.Config) {
+		c.API.PushEnabled = boolPtr(false)
+	}
+}
+
+// WithDelete enables the APIs to delete manifests, tags, and blobs.
+func WithDelete() Opts {
+	return func(c *oConfig.Config) {
+		c.API.DeleteEnabled = boolPtr(true)
+		c.API.Blob.DeleteEnabled = boolPtr(true)
+	}
+}
+
+// WithoutReferrers disables the referrers API, clients fall back to the tag schema.
+func WithoutReferrers() Opts {
+	return func(c *oConfig.Config) {
+		c.API.Referrer.Enabled = boolPtr(false)
+	}
+}
+
+// New starts a registry that is stopped when the test completes.
+func New(t testing.TB, opts ...Opts) *Server {
+	t.Helper()
+	conf := oConfig.Config{
+		Storage: oConfig.ConfigStorage{
+			StoreType: oConfig.StoreMem,
+		},
+	}
+	for _, opt := range opts {
+		opt(&conf)
+	}
+	s := &Server{
+		reg: olareg.New(conf),
+	}
+	s.ts = httptest.NewServer(s.reg)
+	u, err := url.Parse(s.ts.URL)
+	if err != nil {
+		s.Close()
+		t.Fatalf("failed to parse registry url: %v", err)
+	}
+	s.Host = u.Host
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Close stops the registry.
+func (s *Server) Close() {
+	s.ts.Close()
+	_ = s.reg.Close()
+}
+
+// ConfigHost returns the host configuration to access the registry.
+func (s *Server) ConfigHost() config.Host {
+	return config.Host{
+		Name:     s.Host,
+		Hostname: s.Host,
+		TLS:      config.TLSDisabled,
+	}
+}
+
+// RegClient returns a client configured to access the registry.
+// Additional options are applied after the host configuration.
+func (s *Server) RegClient(opts ...regclient.Opt) *regclient.RegClient {
+	opts = append([]regclient.Opt{regclient.WithConfigHost(s.ConfigHost())}, opts...)
+	return regclient.New(opts...)
+}
+
+// Ref parses a repository and tag or digest in the registry, e.g. "project/repo:v1".
+// The test fails if the reference is invalid.
+func (s *Server) Ref(t testing.TB, repoTag string) ref.Ref {
+	t.Helper()
+	r, err := ref.New(s.Host + "/" + repoTag)
+	if err != nil {
+		t.Fatalf("failed to parse ref %s: %v", repoTag, err)
+	}
+	return r
+}
+
+// CopyImage copies an image from any reference, e.g. "ocidir://testdata/repo:v1", into the registry.
+func (s *Server) CopyImage(ctx context.Context, src, repoTag string, opts ...regclient.ImageOpts) error {
+	rSrc, err := ref.New(src)
+	if err != nil {
+		return err
+	}
+	rTgt, err := ref.New(s.Host + "/" + repoTag)
+	if err != nil {
+		return err
+	}
+	rc := s.RegClient()
+	defer rc.Close(ctx, rSrc)
+	return rc.ImageCopy(ctx, rSrc, rTgt, opts...)
+}
+
+// AddImage pushes a single platform OCI image with each layer containing an uncompressed tar.
+// Each layer has a history entry in the config.
+// The platform is "linux/amd64" when not provided.
+func (s *Server) AddImage(ctx context.Context, repoTag string, p *platform.Platform, layers ...[]byte) (descriptor.Descriptor, error) {
+	r, err := ref.New(s.Host + "/" + repoTag)
+	if err != nil {
+		return descriptor.Descriptor{}, err
+	}
+	if p == nil {
+		p = &platform.Platform{OS: "linux", Architecture: "amd64"}
+	}
+	rc := s.RegClient()
+	// a fixed time keeps the digest reproducible
+	created := time.Unix(0, 0).UTC()
+	conf := v1.Image{
+		Platform: *p,
+		RootFS: v1.RootFS{
+			Type:    "layers",
+			DiffIDs: []digest.Digest{},
+		},
+	}
+	m := v1.Manifest{
+		Versioned: v1.ManifestSchemaVersion,
+		MediaType: mediatype.OCI1Manifest,
+		Layers:    []descriptor.Descriptor{},
+	}
+	for i, layer := range layers {
+		d, err := rc.BlobPut(ctx, r, descriptor.Descriptor{MediaType: mediatype.OCI1Layer}, bytes.NewReader(layer))
+		if err != nil {
+			return descriptor.Descriptor{}, fmt.Errorf("failed to push layer %d: %w", i, err)
+		}
+		d.MediaType = mediatype.OCI1Layer
+		m.Layers = append(m.Layers, d)
+		conf.RootFS.DiffIDs = append(conf.RootFS.DiffIDs, d.Digest)
+		conf.History = append(conf.History, v1.History{
+			Created:   &created,
+			CreatedBy: fmt.Sprintf("regtest layer %d", i),
+		})
+	}
+	confBytes, err := json.Marshal(conf)
+	if err != nil {
+		return descriptor.Descriptor{}, err
+	}
+	m.Config, err = rc.BlobPut(ctx, r, descriptor.Descriptor{}, bytes.NewReader(confBytes))
+	if err != nil {
+		return descriptor.Descriptor{}, fmt.Errorf("failed to push config: %w", err)
+	}
+	m.Config.MediaType = mediatype.OCI1ImageConfig
+	mm, err := manifest.New(manifest.WithOrig(m))
+	if err != nil {
+		return descriptor.Descriptor{}, err
+	}
+	err = rc.ManifestPut(ctx, r, mm)
+	if err != nil {
+		return descriptor.Descriptor{}, fmt.Errorf("failed to push manifest: %w", err)
+	}
+	return mm.GetDescriptor(), nil
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
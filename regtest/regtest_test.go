@@ -0,0 +1,98 @@
+package regtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/regclient/regclient"
+	"github.com/regclient/regclient/types/errs"
+	"github.com/regclient/regclient/types/platform"
+)
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	s := New(t, WithTestdata("../testdata"))
+	rc := s.RegClient()
+
+	t.Run("testdata", func(t *testing.T) {
+		m, err := rc.ManifestHead(ctx, s.Ref(t, "testrepo:v1"))
+		if err != nil {
+			t.Fatalf("failed to head preloaded manifest: %v", err)
+		}
+		if m.GetDescriptor().Digest == "" {
+			t.Errorf("digest missing from preloaded manifest")
+		}
+	})
+	t.Run("copy", func(t *testing.T) {
+		err := s.CopyImage(ctx, "ocidir://../testdata/testrepo:v2", "copy:v2", regclient.ImageWithReferrers())
+		if err != nil {
+			t.Fatalf("failed to copy image: %v", err)
+		}
+		mSrc, err := rc.ManifestHead(ctx, s.Ref(t, "testrepo:v2"))
+		if err != nil {
+			t.Fatalf("failed to head source manifest: %v", err)
+		}
+		m, err := rc.ManifestHead(ctx, s.Ref(t, "copy:v2"))
+		if err != nil {
+			t.Fatalf("failed to head copied manifest: %v", err)
+		}
+		if m.GetDescriptor().Digest != mSrc.GetDescriptor().Digest {
+			t.Errorf("digest mismatch, expected %s, received %s", mSrc.GetDescriptor().Digest, m.GetDescriptor().Digest)
+		}
+	})
+	t.Run("add", func(t *testing.T) {
+		p := platform.Platform{OS: "linux", Architecture: "arm64"}
+		d, err := s.AddImage(ctx, "add:v1", &p, []byte("layer one"), []byte("layer two"))
+		if err != nil {
+			t.Fatalf("failed to add image: %v", err)
+		}
+		r := s.Ref(t, "add:v1")
+		m, err := rc.ManifestHead(ctx, r)
+		if err != nil {
+			t.Fatalf("failed to head manifest: %v", err)
+		}
+		if m.GetDescriptor().Digest != d.Digest {
+			t.Errorf("digest mismatch, expected %s, received %s", d.Digest, m.GetDescriptor().Digest)
+		}
+		conf, err := rc.ImageConfig(ctx, r)
+		if err != nil {
+			t.Fatalf("failed to get config: %v", err)
+		}
+		if conf.GetConfig().Architecture != "arm64" || len(conf.GetConfig().RootFS.DiffIDs) != 2 {
+			t.Errorf("unexpected config: %v", conf.GetConfig())
+		}
+	})
+	t.Run("readonly", func(t *testing.T) {
+		sRO := New(t, WithReadOnly())
+		_, err := sRO.AddImage(ctx, "add:v1", nil, []byte("layer"))
+		if err == nil {
+			t.Errorf("push to read only registry did not fail")
+		}
+	})
+	t.Run("delete", func(t *testing.T) {
+		_, err := s.AddImage(ctx, "del:v1", nil, []byte("layer"))
+		if err != nil {
+			t.Fatalf("failed to add image: %v", err)
+		}
+		err = rc.TagDelete(ctx, s.Ref(t, "del:v1"))
+		if err == nil {
+			t.Errorf("delete succeeded without delete enabled")
+		}
+		sDel := New(t, WithDelete())
+		rcDel := sDel.RegClient()
+		_, err = sDel.AddImage(ctx, "del:v1", nil, []byte("layer"))
+		if err != nil {
+			t.Fatalf("failed to add image: %v", err)
+		}
+		err = rcDel.TagDelete(ctx, sDel.Ref(t, "del:v1"))
+		if err != nil {
+			t.Fatalf("failed to delete tag: %v", err)
+		}
+		_, err = rcDel.ManifestHead(ctx, sDel.Ref(t, "del:v1"))
+		if !errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("unexpected error after delete: %v", err)
+		}
+	})
+}
@@ -14,6 +14,14 @@ type repoLister interface {
 	RepoList(ctx context.Context, hostname string, opts ...scheme.RepoOpts) (*repo.RepoList, error)
 }
 
+type repoNamespacer interface {
+	RepoListNamespace(ctx context.Context, hostname, namespace string) ([]string, error)
+}
+
+type repoWalker interface {
+	RepoListWalk(ctx context.Context, hostname string, fn func(*repo.RepoList) error, opts ...scheme.RepoOpts) error
+}
+
 // RepoList returns a list of repositories on a registry.
 // Note the underlying "_catalog" API is not supported on many cloud registries.
 func (rc *RegClient) RepoList(ctx context.Context, hostname string, opts ...scheme.RepoOpts) (*repo.RepoList, error) {
@@ -31,3 +39,43 @@ func (rc *RegClient) RepoList(ctx context.Context, hostname string, opts ...sche
 	}
 	return rl.RepoList(ctx, hostname, opts...)
 }
+
+// RepoListWalk calls fn with each page of repositories on a registry, following the pagination from the registry.
+// This avoids holding the full list in memory on registries with many repositories.
+// Use [scheme.WithRepoLimit] to set the page size and [scheme.WithRepoLast] to start after a repository.
+// Returning an error from fn stops the walk and that error is returned.
+func (rc *RegClient) RepoListWalk(ctx context.Context, hostname string, fn func(*repo.RepoList) error, opts ...scheme.RepoOpts) error {
+	i := strings.Index(hostname, "/")
+	if i > 0 {
+		return fmt.Errorf("invalid hostname: %s%.0w", hostname, errs.ErrParsingFailed)
+	}
+	schemeAPI, err := rc.schemeGet("reg")
+	if err != nil {
+		return err
+	}
+	rw, ok := schemeAPI.(repoWalker)
+	if !ok {
+		return errs.ErrNotImplemented
+	}
+	return rw.RepoListWalk(ctx, hostname, fn, opts...)
+}
+
+// RepoListNamespace returns the repositories within a namespace of a registry, e.g. "docker.io" and "myorg".
+// The vendor API of Docker Hub, Quay, and Harbor is used when available, see the host APIOpts to enable each vendor API.
+// Other registries filter the catalog API to the repositories beginning with the namespace.
+// The returned names include the namespace, e.g. "myorg/app".
+func (rc *RegClient) RepoListNamespace(ctx context.Context, hostname, namespace string) ([]string, error) {
+	i := strings.Index(hostname, "/")
+	if i > 0 {
+		return nil, fmt.Errorf("invalid hostname: %s%.0w", hostname, errs.ErrParsingFailed)
+	}
+	schemeAPI, err := rc.schemeGet("reg")
+	if err != nil {
+		return nil, err
+	}
+	rn, ok := schemeAPI.(repoNamespacer)
+	if !ok {
+		return nil, errs.ErrNotImplemented
+	}
+	return rn.RepoListNamespace(ctx, hostname, namespace)
+}
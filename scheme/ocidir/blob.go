@@ -87,9 +87,44 @@ func (o *OCIDir) BlobHead(ctx context.Context, r ref.Ref, d descriptor.Descripto
 	return br, nil
 }
 
-// BlobMount attempts to perform a server side copy of the blob
+// BlobMount links a blob from another OCI Layout on the local filesystem.
+// When a hard link cannot be created, e.g. between filesystems, [errs.ErrUnsupported] is returned and the blob should be copied.
 func (o *OCIDir) BlobMount(ctx context.Context, refSrc ref.Ref, refTgt ref.Ref, d descriptor.Descriptor) error {
-	return errs.ErrUnsupported
+	if refSrc.Scheme != refTgt.Scheme {
+		return errs.ErrUnsupported
+	}
+	err := d.Digest.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate digest %s: %w", d.Digest.String(), err)
+	}
+	fileSrc := path.Join(refSrc.Path, "blobs", d.Digest.Algorithm().String(), d.Digest.Encoded())
+	if _, err := os.Stat(fileSrc); err != nil {
+		return fmt.Errorf("failed to mount blob %s: %w", fileSrc, err)
+	}
+	err = o.initIndex(refTgt, false)
+	if err != nil {
+		return err
+	}
+	dir := path.Join(refTgt.Path, "blobs", d.Digest.Algorithm().String())
+	//#nosec G301 defer to user umask settings
+	err = os.MkdirAll(dir, 0777)
+	if err != nil && !errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("failed creating %s: %w", dir, err)
+	}
+	fileTgt := path.Join(dir, d.Digest.Encoded())
+	err = os.Link(fileSrc, fileTgt)
+	if err != nil && !errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("failed to link blob %s to %s: %w%.0w", fileSrc, fileTgt, err, errs.ErrUnsupported)
+	}
+	o.slog.Debug("mounted blob",
+		slog.String("src", refSrc.CommonName()),
+		slog.String("tgt", refTgt.CommonName()),
+		slog.String("file", fileTgt))
+
+	o.mu.Lock()
+	o.refMod(refTgt)
+	o.mu.Unlock()
+	return nil
 }
 
 // BlobPut sends a blob to the repository, returns the digest and size when successful
@@ -148,6 +148,30 @@ func TestBlob(t *testing.T) {
 	if err == nil {
 		t.Errorf("stat of a deleted blob did not fail")
 	}
+	// blob mount from another layout
+	rMount, err := ref.New("ocidir://" + tempDir + "/mount:v1")
+	if err != nil {
+		t.Fatalf("failed to parse ref: %v", err)
+	}
+	err = o.BlobMount(ctx, rImg, rMount, cd)
+	if err != nil {
+		t.Fatalf("blob mount: %v", err)
+	}
+	fBytes, err = os.ReadFile(filepath.Join(tempDir, "mount/blobs", cd.Digest.Algorithm().String(), cd.Digest.Encoded()))
+	if err != nil {
+		t.Fatalf("blob mount read file: %v", err)
+	}
+	if !bytes.Equal(fBytes, bBytes) {
+		t.Errorf("blob mount bytes, expected %s, saw %s", string(bBytes), string(fBytes))
+	}
+	err = o.BlobMount(ctx, rImg, rMount, cd)
+	if err != nil {
+		t.Errorf("blob mount of an existing blob: %v", err)
+	}
+	err = o.BlobMount(ctx, rNew, rMount, cd)
+	if err == nil {
+		t.Errorf("blob mount of a missing blob did not fail")
+	}
 	// concurrent blob put, without the descriptor to test for races
 	rPut, err := ref.New("ocidir://" + tempDir + "/put@" + dl[0].Digest.String())
 	if err != nil {
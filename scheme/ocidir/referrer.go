@@ -62,7 +62,7 @@ func (o *OCIDir) referrerList(ctx context.Context, rSubject ref.Ref, opts ...sch
 	}
 	ociML, ok := m.GetOrig().(v1.Index)
 	if !ok {
-		return rl, fmt.Errorf("manifest is not an OCI index: %s", rlTag.CommonName())
+		return rl, fmt.Errorf("manifest is not an OCI index: %s%.0w", rlTag.CommonName(), errs.ErrUnsupportedMediaType)
 	}
 	// update referrer list
 	rl.Subject = rSubject
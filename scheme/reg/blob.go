@@ -44,6 +44,7 @@ func (reg *Reg) BlobDelete(ctx context.Context, r ref.Ref, d descriptor.Descript
 	if err != nil {
 		return fmt.Errorf("failed to delete blob, digest %s, ref %s: %w", d.Digest.String(), r.CommonName(), err)
 	}
+	defer resp.Close()
 	if resp.HTTPResponse().StatusCode != 202 {
 		return fmt.Errorf("failed to delete blob, digest %s, ref %s: %w", d.Digest.String(), r.CommonName(), reghttp.HTTPError(resp.HTTPResponse().StatusCode))
 	}
@@ -456,6 +457,9 @@ func (reg *Reg) blobPutUploadChunked(ctx context.Context, r ref.Ref, d descripto
 	}
 	chunkURL := *putURL
 	retryLimit := 10 // TODO: pull limit from reghttp
+	if host.RetryLimit > 0 {
+		retryLimit = host.RetryLimit
+	}
 	retryCur := 0
 	var err error
 
@@ -488,6 +492,10 @@ func (reg *Reg) blobPutUploadChunked(ctx context.Context, r ref.Ref, d descripto
 			chunkSize = len(bufBytes)
 			bufChange = true
 		}
+		if chunkSize > 0 && chunkStart < bufStart {
+			// the registry reported an offset that has already been discarded from the buffer
+			return d, fmt.Errorf("failed to send blob (chunk), ref %s: upload offset %d is before the buffered chunk at %d%.0w", r.CommonName(), chunkStart, bufStart, errs.ErrNotRetryable)
+		}
 		if chunkSize > 0 && chunkStart != bufStart {
 			return d, fmt.Errorf("chunkStart (%d) != bufStart (%d)", chunkStart, bufStart)
 		}
@@ -517,7 +525,35 @@ func (reg *Reg) blobPutUploadChunked(ctx context.Context, r ref.Ref, d descripto
 			}
 			resp, err := reg.reghttp.Do(ctx, req)
 			if err != nil && !errors.Is(err, errs.ErrHTTPStatus) && !errors.Is(err, errs.ErrNotFound) {
-				return d, fmt.Errorf("failed to send blob (chunk), ref %s: http do: %w", r.CommonName(), err)
+				// the connection failed, query the upload status to resume from the last committed offset
+				_ = resp.Close()
+				retryCur++
+				if ctx.Err() != nil || retryCur > retryLimit {
+					return d, fmt.Errorf("failed to send blob (chunk), ref %s: http do: %w", r.CommonName(), err)
+				}
+				statusResp, statusErr := reg.blobUploadStatus(ctx, r, &chunkURL)
+				if statusErr != nil {
+					return d, fmt.Errorf("failed to send blob (chunk), ref %s: http do: %w", r.CommonName(), err)
+				}
+				rangeEnd, rangeErr := blobUploadCurBytes(statusResp)
+				if rangeErr != nil {
+					return d, fmt.Errorf("failed to send blob (chunk), ref %s: upload status %v: http do: %w", r.CommonName(), rangeErr, err)
+				}
+				reg.slog.Debug("Resuming chunk upload after failure",
+					slog.String("ref", r.CommonName()),
+					slog.Int64("chunkStart", chunkStart),
+					slog.Int("chunkSize", chunkSize),
+					slog.Int64("offset", rangeEnd+1),
+					slog.String("err", err.Error()))
+				chunkStart = rangeEnd + 1
+				if location := statusResp.Header.Get("Location"); location != "" {
+					parseURL, err := statusResp.Request.URL.Parse(location)
+					if err != nil {
+						return d, fmt.Errorf("failed to send blob (parse next chunk location), ref %s: %w", r.CommonName(), err)
+					}
+					chunkURL = *parseURL
+				}
+				continue
 			}
 			err = resp.Close()
 			if err != nil {
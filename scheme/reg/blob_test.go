@@ -402,6 +402,7 @@ func TestBlobPut(t *testing.T) {
 	blobRepo := "/proj/repo"
 	blobRepo5 := "/proj/repo5"
 	blobRepo6 := "/proj/repo6"
+	blobRepo7 := "/proj/repo7"
 	blobRepo1sha512 := "/proj/repo1-sha512"
 	blobRepo5sha512 := "/proj/repo5-sha512"
 	// privateRepo := "/proj/private"
@@ -414,6 +415,8 @@ func TestBlobPut(t *testing.T) {
 	blobLen3 := 1000 // blob without a full final chunk
 	blobLen4 := 2048 // must be blobChunk < blobLen <= blobChunk * 2
 	blobLen5 := 500  // single chunk
+	blobLen7 := 1024 // must be blobChunk < blobLen <= blobChunk * 2
+	blobPart7 := 256 // bytes of the second chunk committed before a failure
 	d1, blob1 := reqresp.NewRandomBlob(blobLen, seed)
 	d2, blob2 := reqresp.NewRandomBlob(blobLen, seed+1)
 	d2Bad := digest.SHA256.FromString("digest 2 bad")
@@ -422,6 +425,7 @@ func TestBlobPut(t *testing.T) {
 	d5, blob5 := reqresp.NewRandomBlob(blobLen5, seed+4)
 	blob6 := []byte{}
 	d6 := digest.SHA256.FromBytes(blob6)
+	d7, blob7 := reqresp.NewRandomBlob(blobLen7, seed+5)
 	d1sha512 := digest.SHA512.FromBytes(blob1)
 	d5sha512 := digest.SHA512.FromBytes(blob5)
 	uuid1 := reqresp.NewRandomID(seed + 10)
@@ -431,6 +435,7 @@ func TestBlobPut(t *testing.T) {
 	uuid4 := reqresp.NewRandomID(seed + 14)
 	uuid5 := reqresp.NewRandomID(seed + 15)
 	uuid6 := reqresp.NewRandomID(seed + 16)
+	uuid7 := reqresp.NewRandomID(seed + 17)
 	// dMissing := digest.FromBytes([]byte("missing"))
 	user := "testing"
 	pass := "password"
@@ -1337,6 +1342,137 @@ func TestBlobPut(t *testing.T) {
 				},
 			},
 		},
+		// get upload7 location
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "POST for d7",
+				Method: "POST",
+				Path:   "/v2" + blobRepo7 + "/blobs/uploads/",
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusAccepted,
+				Headers: http.Header{
+					"Content-Length": {"0"},
+					"Location":       {uuid7},
+				},
+			},
+		},
+		// upload put for d7
+		{
+			ReqEntry: reqresp.ReqEntry{
+				DelOnUse: false,
+				Name:     "PUT for patched d7",
+				Method:   "PUT",
+				Path:     "/v2" + blobRepo7 + "/blobs/uploads/" + uuid7,
+				Query: map[string][]string{
+					"digest": {d7.String()},
+					"chunk":  {"3"},
+				},
+				Headers: http.Header{
+					"Content-Length": {"0"},
+					"Content-Type":   {"application/octet-stream"},
+				},
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusCreated,
+				Headers: http.Header{
+					"Content-Length":        {"0"},
+					"Location":              {"/v2" + blobRepo7 + "/blobs/" + d7.String()},
+					"Docker-Content-Digest": {d7.String()},
+				},
+			},
+		},
+		// upload patch 2 for d7 drops the connection
+		{
+			ReqEntry: reqresp.ReqEntry{
+				DelOnUse: false,
+				Name:     "PATCH 2 for d7",
+				Method:   "PATCH",
+				Path:     "/v2" + blobRepo7 + "/blobs/uploads/" + uuid7,
+				Query: map[string][]string{
+					"chunk": {"2"},
+				},
+				Headers: http.Header{
+					"Content-Length": {fmt.Sprintf("%d", blobLen7-blobChunk)},
+					"Content-Range":  {fmt.Sprintf("%d-%d", blobChunk, blobLen7-1)},
+					"Content-Type":   {"application/octet-stream"},
+				},
+				Body: blob7[blobChunk:],
+			},
+			RespEntry: reqresp.RespEntry{
+				Fail: true,
+			},
+		},
+		// get status for d7 reports part of chunk 2 was committed
+		{
+			ReqEntry: reqresp.ReqEntry{
+				DelOnUse: false,
+				Name:     "GET 2 for d7",
+				Method:   "GET",
+				Path:     "/v2" + blobRepo7 + "/blobs/uploads/" + uuid7,
+				Query: map[string][]string{
+					"chunk": {"2"},
+				},
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusNoContent,
+				Headers: http.Header{
+					"Content-Length": {fmt.Sprintf("%d", 0)},
+					"Range":          {fmt.Sprintf("bytes=0-%d", blobChunk+blobPart7-1)},
+					"Location":       {uuid7 + "?chunk=2b"},
+				},
+			},
+		},
+		// upload patch 2b for d7 resumes from the committed offset
+		{
+			ReqEntry: reqresp.ReqEntry{
+				DelOnUse: false,
+				Name:     "PATCH 2b for d7",
+				Method:   "PATCH",
+				Path:     "/v2" + blobRepo7 + "/blobs/uploads/" + uuid7,
+				Query: map[string][]string{
+					"chunk": {"2b"},
+				},
+				Headers: http.Header{
+					"Content-Length": {fmt.Sprintf("%d", blobLen7-blobChunk-blobPart7)},
+					"Content-Range":  {fmt.Sprintf("%d-%d", blobChunk+blobPart7, blobLen7-1)},
+					"Content-Type":   {"application/octet-stream"},
+				},
+				Body: blob7[blobChunk+blobPart7:],
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusAccepted,
+				Headers: http.Header{
+					"Content-Length": {fmt.Sprintf("%d", 0)},
+					"Range":          {fmt.Sprintf("bytes=0-%d", blobLen7-1)},
+					"Location":       {uuid7 + "?chunk=3"},
+				},
+			},
+		},
+		// upload patch 1 for d7
+		{
+			ReqEntry: reqresp.ReqEntry{
+				DelOnUse: false,
+				Name:     "PATCH 1 for d7",
+				Method:   "PATCH",
+				Path:     "/v2" + blobRepo7 + "/blobs/uploads/" + uuid7,
+				Query:    map[string][]string{},
+				Headers: http.Header{
+					"Content-Length": {fmt.Sprintf("%d", blobChunk)},
+					"Content-Range":  {fmt.Sprintf("0-%d", blobChunk-1)},
+					"Content-Type":   {"application/octet-stream"},
+				},
+				Body: blob7[0:blobChunk],
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusAccepted,
+				Headers: http.Header{
+					"Content-Length": {fmt.Sprintf("%d", 0)},
+					"Range":          {fmt.Sprintf("bytes=0-%d", blobChunk-1)},
+					"Location":       {uuid7 + "?chunk=2"},
+				},
+			},
+		},
 	}
 	rrs = append(rrs, reqresp.BaseEntries...)
 	// create a server
@@ -1560,5 +1696,24 @@ func TestBlobPut(t *testing.T) {
 		}
 	})
 
+	// test resuming a chunked upload after a connection failure
+	t.Run("Resume", func(t *testing.T) {
+		r, err := ref.New(tsURL.Host + blobRepo7)
+		if err != nil {
+			t.Fatalf("Failed creating ref: %v", err)
+		}
+		br := bytes.NewReader(blob7)
+		dp, err := reg.BlobPut(ctx, r, descriptor.Descriptor{}, br)
+		if err != nil {
+			t.Fatalf("Failed running BlobPut: %v", err)
+		}
+		if dp.Digest.String() != d7.String() {
+			t.Errorf("Digest mismatch, expected %s, received %s", d7.String(), dp.Digest.String())
+		}
+		if dp.Size != int64(len(blob7)) {
+			t.Errorf("Content length mismatch, expected %d, received %d", len(blob7), dp.Size)
+		}
+	})
+
 	// TODO: test failed mount (blobGetUploadURL)
 }
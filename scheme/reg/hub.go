@@ -0,0 +1,125 @@
+package reg
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/regclient/regclient/types/ref"
+	"github.com/regclient/regclient/types/tag"
+)
+
+const (
+	// hubAPIOpt is the host APIOpts key to enable the Docker Hub API
+	hubAPIOpt = "hubAPI"
+	// hubURLOpt is the host APIOpts key to override the Docker Hub API URL
+	hubURLOpt = "hubURL"
+	// hubURLDefault is the Docker Hub API used when the hubURL option is not set
+	hubURLDefault = "https://hub.docker.com"
+	// hubPageSize is the number of tags requested per page, the maximum allowed by Hub
+	hubPageSize = 100
+	// hubPageMax limits the number of pages requested from Hub
+	hubPageMax = 1000
+)
+
+type hubTagResp struct {
+	Count   int              `json:"count"`
+	Next    string           `json:"next"`
+	Results []tag.HubTagInfo `json:"results"`
+}
+
+// hubURL returns the base URL of the Hub API when it has been enabled for the registry host.
+func (reg *Reg) hubURL(r ref.Ref) (*url.URL, bool) {
+	h := reg.hostGet(r.Registry)
+	if h == nil || h.APIOpts == nil {
+		return nil, false
+	}
+	enabled, err := strconv.ParseBool(h.APIOpts[hubAPIOpt])
+	if err != nil || !enabled {
+		return nil, false
+	}
+	base := hubURLDefault
+	if h.APIOpts[hubURLOpt] != "" {
+		base = h.APIOpts[hubURLOpt]
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		reg.slog.Warn("Failed to parse Hub API URL",
+			slog.String("url", base),
+			slog.String("err", err.Error()))
+		return nil, false
+	}
+	return u, true
+}
+
+// hubTagsAdd includes the metadata from the Hub API in the tag list.
+// The Hub API is optional, so failures are logged without returning an error.
+func (reg *Reg) hubTagsAdd(ctx context.Context, r ref.Ref, tl *tag.List, tags map[string]tag.HubTagInfo) map[string]tag.HubTagInfo {
+	if tags == nil {
+		base, ok := reg.hubURL(r)
+		if !ok {
+			return nil
+		}
+		var err error
+		tags, err = reg.hubTagList(ctx, r, base)
+		if err != nil {
+			reg.slog.Warn("Failed to get tag metadata from the Hub API",
+				slog.String("ref", r.CommonName()),
+				slog.String("err", err.Error()))
+			// do not retry on following pages
+			return map[string]tag.HubTagInfo{}
+		}
+	}
+	for _, t := range tl.Tags {
+		if info, ok := tags[t]; ok {
+			if tl.HubTags == nil {
+				tl.HubTags = map[string]tag.HubTagInfo{}
+			}
+			tl.HubTags[t] = info
+		}
+	}
+	return tags
+}
+
+// hubTagList returns the metadata of every tag in a repository from the Hub API.
+func (reg *Reg) hubTagList(ctx context.Context, r ref.Ref, base *url.URL) (map[string]tag.HubTagInfo, error) {
+	ns, repo, ok := strings.Cut(r.Repository, "/")
+	if !ok {
+		ns, repo = "library", r.Repository
+	}
+	u := base.JoinPath("v2", "namespaces", ns, "repositories", repo, "tags")
+	u.RawQuery = url.Values{"page_size": []string{strconv.Itoa(hubPageSize)}}.Encode()
+	tags := map[string]tag.HubTagInfo{}
+	for page := 0; u != nil; page++ {
+		if page >= hubPageMax {
+			return tags, fmt.Errorf("hub tag list exceeded %d pages", hubPageMax)
+		}
+		hr, err := reg.hubTagPage(ctx, r, u)
+		if err != nil {
+			return tags, err
+		}
+		for _, info := range hr.Results {
+			tags[info.Name] = info
+		}
+		u = nil
+		if hr.Next != "" {
+			u, err = base.Parse(hr.Next)
+			if err != nil {
+				return tags, fmt.Errorf("failed to parse next page from hub: %w", err)
+			}
+		}
+	}
+	return tags, nil
+}
+
+func (reg *Reg) hubTagPage(ctx context.Context, r ref.Ref, u *url.URL) (hubTagResp, error) {
+	hr := hubTagResp{}
+	err := reg.vendorGet(ctx, r, u, &hr)
+	if err != nil {
+		return hr, fmt.Errorf("failed to list tags from hub for %s: %w", r.CommonName(), err)
+	}
+	return hr, nil
+}
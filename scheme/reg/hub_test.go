@@ -0,0 +1,244 @@
+package reg
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/regclient/regclient/config"
+	"github.com/regclient/regclient/internal/reqresp"
+	"github.com/regclient/regclient/types/ref"
+	"github.com/regclient/regclient/types/tag"
+)
+
+func TestHubTags(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	tagBody := []byte(`{"name":"library/alpine","tags":["3.19","3.20","latest"]}`)
+	brokenBody := []byte(`{"name":"library/broken","tags":["latest"]}`)
+	hubPath := "/v2/namespaces/library/repositories/alpine/tags"
+	hubBody1 := []byte(`{"count":3,"next":"` + hubPath + `?page=2&page_size=100","results":[` +
+		`{"name":"latest","digest":"sha256:1111111111111111111111111111111111111111111111111111111111111111","full_size":3400000,"last_updated":"2024-05-22T18:00:00Z","tag_last_pushed":"2024-05-22T18:00:00Z","tag_last_pulled":"2024-06-01T10:00:00Z"},` +
+		`{"name":"3.20","full_size":3410000,"last_updated":"2024-05-22T18:00:00Z","tag_last_pushed":"2024-05-22T18:00:00Z","tag_last_pulled":"2024-06-01T09:00:00Z"}]}`)
+	hubBody2 := []byte(`{"count":3,"next":null,"results":[` +
+		`{"name":"3.19","full_size":3300000,"last_updated":"2023-12-07T18:00:00Z","tag_last_pushed":"2023-12-07T18:00:00Z","tag_last_pulled":null}]}`)
+	rrsReg := []reqresp.ReqResp{
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "tag list",
+				Method: "GET",
+				Path:   "/v2/library/alpine/tags/list",
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Headers: http.Header{
+					"Content-Length": {fmt.Sprintf("%d", len(tagBody))},
+					"Content-Type":   {"application/json"},
+				},
+				Body: tagBody,
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "tag list broken",
+				Method: "GET",
+				Path:   "/v2/library/broken/tags/list",
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Headers: http.Header{
+					"Content-Length": {fmt.Sprintf("%d", len(brokenBody))},
+					"Content-Type":   {"application/json"},
+				},
+				Body: brokenBody,
+			},
+		},
+	}
+	rrsHub := []reqresp.ReqResp{
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "hub page 2",
+				Method: "GET",
+				Path:   hubPath,
+				Query: map[string][]string{
+					"page": {"2"},
+				},
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Headers: http.Header{
+					"Content-Type": {"application/json"},
+				},
+				Body: hubBody2,
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "hub page 1",
+				Method: "GET",
+				Path:   hubPath,
+				Query: map[string][]string{
+					"page_size": {"100"},
+				},
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Headers: http.Header{
+					"Content-Type": {"application/json"},
+				},
+				Body: hubBody1,
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "hub broken",
+				Method: "GET",
+				Path:   "/v2/namespaces/library/repositories/broken/tags",
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusInternalServerError,
+			},
+		},
+	}
+	tsReg := httptest.NewServer(reqresp.NewHandler(t, rrsReg))
+	defer tsReg.Close()
+	// track the configured headers received by the hub API
+	var hubRegHeader, hubHeader atomic.Bool
+	hubHandler := reqresp.NewHandler(t, rrsHub)
+	tsHub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Registry-Secret") != "" {
+			hubRegHeader.Store(true)
+		}
+		if r.Header.Get("X-Hub-Header") == "hub" {
+			hubHeader.Store(true)
+		}
+		hubHandler.ServeHTTP(w, r)
+	}))
+	defer tsHub.Close()
+	tsRegURL, _ := url.Parse(tsReg.URL)
+	tsRegHost := tsRegURL.Host
+	tsHubURL, _ := url.Parse(tsHub.URL)
+	tsHubHost := tsHubURL.Host
+	rcHosts := []*config.Host{
+		{
+			Name:     tsRegHost,
+			Hostname: tsRegHost,
+			TLS:      config.TLSDisabled,
+			APIOpts: map[string]string{
+				"hubAPI": "true",
+				"hubURL": tsHub.URL,
+			},
+			Headers: map[string]string{
+				"X-Registry-Secret": "secret",
+			},
+		},
+		{
+			Name:     tsHubHost,
+			Hostname: tsHubHost,
+			TLS:      config.TLSDisabled,
+			Headers: map[string]string{
+				"X-Hub-Header": "hub",
+			},
+		},
+		{
+			Name:     "off.example.com",
+			Hostname: tsRegHost,
+			TLS:      config.TLSDisabled,
+		},
+	}
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	delayInit, _ := time.ParseDuration("0.05s")
+	delayMax, _ := time.ParseDuration("0.10s")
+	reg := New(
+		WithConfigHosts(rcHosts),
+		WithSlog(log),
+		WithDelay(delayInit, delayMax),
+	)
+
+	t.Run("TagList", func(t *testing.T) {
+		r, err := ref.New(tsRegHost + "/library/alpine")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		tl, err := reg.TagList(ctx, r)
+		if err != nil {
+			t.Fatalf("failed to list tags: %v", err)
+		}
+		if len(tl.Tags) != 3 {
+			t.Errorf("unexpected tags: %v", tl.Tags)
+		}
+		if len(tl.HubTags) != 3 {
+			t.Fatalf("unexpected hub tags: %v", tl.HubTags)
+		}
+		latest, ok := tl.GetHubTag("latest")
+		if !ok {
+			t.Fatalf("latest missing from hub tags")
+		}
+		if latest.Size != 3400000 || latest.LastPulled.IsZero() || latest.LastPushed.IsZero() || latest.Digest == "" {
+			t.Errorf("unexpected metadata for latest: %v", latest)
+		}
+		old, ok := tl.GetHubTag("3.19")
+		if !ok {
+			t.Fatalf("3.19 missing from hub tags")
+		}
+		if !old.LastPulled.IsZero() || !old.LastPushed.Before(latest.LastPushed) {
+			t.Errorf("unexpected metadata for 3.19: %v", old)
+		}
+		if hubRegHeader.Load() {
+			t.Errorf("headers configured for the registry were sent to the hub API")
+		}
+		if !hubHeader.Load() {
+			t.Errorf("headers configured for the hub API were not sent")
+		}
+	})
+	t.Run("TagListWalk", func(t *testing.T) {
+		r, err := ref.New(tsRegHost + "/library/alpine")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		hubTags := 0
+		err = reg.TagListWalk(ctx, r, func(tl *tag.List) error {
+			hubTags += len(tl.HubTags)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("failed to walk tags: %v", err)
+		}
+		if hubTags != 3 {
+			t.Errorf("unexpected number of hub tags: %d", hubTags)
+		}
+	})
+	t.Run("Disabled", func(t *testing.T) {
+		r, err := ref.New("off.example.com/library/alpine")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		tl, err := reg.TagList(ctx, r)
+		if err != nil {
+			t.Fatalf("failed to list tags: %v", err)
+		}
+		if len(tl.Tags) != 3 || tl.HubTags != nil {
+			t.Errorf("unexpected tag list: %v, %v", tl.Tags, tl.HubTags)
+		}
+	})
+	t.Run("Hub error", func(t *testing.T) {
+		r, err := ref.New(tsRegHost + "/library/broken")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		tl, err := reg.TagList(ctx, r)
+		if err != nil {
+			t.Fatalf("failed to list tags: %v", err)
+		}
+		if len(tl.Tags) != 1 || tl.HubTags != nil {
+			t.Errorf("unexpected tag list: %v, %v", tl.Tags, tl.HubTags)
+		}
+	})
+}
@@ -0,0 +1,149 @@
+package reg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/regclient/regclient/scheme"
+	"github.com/regclient/regclient/types/errs"
+	"github.com/regclient/regclient/types/ref"
+	"github.com/regclient/regclient/types/repo"
+)
+
+const (
+	// namespacePageSize is the number of repositories requested per page from a vendor API
+	namespacePageSize = 100
+	// namespacePageMax limits the number of pages requested from a vendor API
+	namespacePageMax = 1000
+)
+
+// RepoListNamespace returns the repositories within a namespace of a registry, e.g. the organization on Docker Hub or Quay, or the project on Harbor.
+// The vendor API is used when available (see [Reg.TagInfo] for enabling each vendor),
+// otherwise the catalog API is filtered to repositories beginning with the namespace.
+// The returned names include the namespace, e.g. "org/repo".
+func (reg *Reg) RepoListNamespace(ctx context.Context, hostname, namespace string) ([]string, error) {
+	namespace = strings.Trim(namespace, "/")
+	if namespace == "" {
+		return nil, fmt.Errorf("namespace is required for %s%.0w", hostname, errs.ErrMissingName)
+	}
+	r := ref.Ref{Scheme: "reg", Registry: hostname}
+	vendor, base, err := reg.vendorURL(r)
+	if err != nil && !errors.Is(err, errs.ErrUnsupportedAPI) {
+		return nil, err
+	}
+	switch vendor {
+	case vendorHub:
+		return reg.hubRepoList(ctx, r, base, namespace)
+	case vendorQuay:
+		return reg.quayRepoList(ctx, r, base, namespace)
+	case vendorHarbor:
+		return reg.harborRepoList(ctx, r, base, namespace)
+	}
+	repos := []string{}
+	err = reg.RepoListWalk(ctx, hostname, func(rl *repo.RepoList) error {
+		for _, name := range rl.Repositories {
+			if strings.HasPrefix(name, namespace+"/") {
+				repos = append(repos, name)
+			}
+		}
+		return nil
+	}, scheme.WithRepoLimit(namespacePageSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repositories on %s: %w", hostname, err)
+	}
+	return repos, nil
+}
+
+func (reg *Reg) hubRepoList(ctx context.Context, r ref.Ref, base *url.URL, namespace string) ([]string, error) {
+	u := base.JoinPath("v2", "namespaces", namespace, "repositories")
+	u.RawQuery = url.Values{"page_size": []string{strconv.Itoa(namespacePageSize)}}.Encode()
+	repos := []string{}
+	for page := 0; u != nil; page++ {
+		if page >= namespacePageMax {
+			return repos, fmt.Errorf("hub repository list exceeded %d pages", namespacePageMax)
+		}
+		hr := struct {
+			Next    string `json:"next"`
+			Results []struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"results"`
+		}{}
+		err := reg.vendorGet(ctx, r, u, &hr)
+		if err != nil {
+			return repos, fmt.Errorf("failed to list repositories from hub for %s: %w", namespace, err)
+		}
+		for _, result := range hr.Results {
+			repos = append(repos, namespace+"/"+result.Name)
+		}
+		u = nil
+		if hr.Next != "" {
+			u, err = base.Parse(hr.Next)
+			if err != nil {
+				return repos, fmt.Errorf("failed to parse next page from hub: %w", err)
+			}
+		}
+	}
+	return repos, nil
+}
+
+func (reg *Reg) quayRepoList(ctx context.Context, r ref.Ref, base *url.URL, namespace string) ([]string, error) {
+	repos := []string{}
+	next := ""
+	for page := 0; page == 0 || next != ""; page++ {
+		if page >= namespacePageMax {
+			return repos, fmt.Errorf("quay repository list exceeded %d pages", namespacePageMax)
+		}
+		u := base.JoinPath("api", "v1", "repository")
+		q := url.Values{"namespace": []string{namespace}}
+		if next != "" {
+			q.Set("next_page", next)
+		}
+		u.RawQuery = q.Encode()
+		qr := struct {
+			NextPage     string `json:"next_page"`
+			Repositories []struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"repositories"`
+		}{}
+		err := reg.vendorGet(ctx, r, u, &qr)
+		if err != nil {
+			return repos, fmt.Errorf("failed to list repositories from quay for %s: %w", namespace, err)
+		}
+		for _, result := range qr.Repositories {
+			repos = append(repos, namespace+"/"+result.Name)
+		}
+		next = qr.NextPage
+	}
+	return repos, nil
+}
+
+func (reg *Reg) harborRepoList(ctx context.Context, r ref.Ref, base *url.URL, namespace string) ([]string, error) {
+	repos := []string{}
+	for page := 1; page <= namespacePageMax; page++ {
+		u := base.JoinPath("api", "v2.0", "projects", namespace, "repositories")
+		u.RawQuery = url.Values{
+			"page":      []string{strconv.Itoa(page)},
+			"page_size": []string{strconv.Itoa(namespacePageSize)},
+		}.Encode()
+		hr := []struct {
+			Name string `json:"name"` // harbor includes the project in the name
+		}{}
+		err := reg.vendorGet(ctx, r, u, &hr)
+		if err != nil {
+			return repos, fmt.Errorf("failed to list repositories from harbor for %s: %w", namespace, err)
+		}
+		for _, result := range hr {
+			repos = append(repos, result.Name)
+		}
+		if len(hr) < namespacePageSize {
+			return repos, nil
+		}
+	}
+	return repos, fmt.Errorf("harbor repository list exceeded %d pages", namespacePageMax)
+}
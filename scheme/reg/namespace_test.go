@@ -0,0 +1,235 @@
+package reg
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/regclient/regclient/config"
+	"github.com/regclient/regclient/internal/reqresp"
+)
+
+func TestRepoListNamespace(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	jsonHeaders := http.Header{"Content-Type": {"application/json"}}
+	harborPage := make([]string, namespacePageSize)
+	for i := range harborPage {
+		harborPage[i] = `{"name":"proj/app` + string(rune('a'+i%26)) + `"}`
+	}
+	rrs := []reqresp.ReqResp{
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "hub page 2",
+				Method: "GET",
+				Path:   "/v2/namespaces/myorg/repositories",
+				Query: map[string][]string{
+					"page": {"2"},
+				},
+			},
+			RespEntry: reqresp.RespEntry{
+				Status:  http.StatusOK,
+				Headers: jsonHeaders,
+				Body:    []byte(`{"next":"","results":[{"name":"app3","namespace":"myorg"}]}`),
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "hub page 1",
+				Method: "GET",
+				Path:   "/v2/namespaces/myorg/repositories",
+			},
+			RespEntry: reqresp.RespEntry{
+				Status:  http.StatusOK,
+				Headers: jsonHeaders,
+				Body:    []byte(`{"next":"/v2/namespaces/myorg/repositories?page=2&page_size=100","results":[{"name":"app1","namespace":"myorg"},{"name":"app2","namespace":"myorg"}]}`),
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "quay page 2",
+				Method: "GET",
+				Path:   "/api/v1/repository",
+				Query: map[string][]string{
+					"namespace": {"team"},
+					"next_page": {"token2"},
+				},
+			},
+			RespEntry: reqresp.RespEntry{
+				Status:  http.StatusOK,
+				Headers: jsonHeaders,
+				Body:    []byte(`{"repositories":[{"namespace":"team","name":"web"}]}`),
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "quay page 1",
+				Method: "GET",
+				Path:   "/api/v1/repository",
+				Query: map[string][]string{
+					"namespace": {"team"},
+				},
+			},
+			RespEntry: reqresp.RespEntry{
+				Status:  http.StatusOK,
+				Headers: jsonHeaders,
+				Body:    []byte(`{"repositories":[{"namespace":"team","name":"api"}],"next_page":"token2"}`),
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "harbor page 1",
+				Method: "GET",
+				Path:   "/api/v2.0/projects/proj/repositories",
+				Query: map[string][]string{
+					"page": {"1"},
+				},
+			},
+			RespEntry: reqresp.RespEntry{
+				Status:  http.StatusOK,
+				Headers: jsonHeaders,
+				Body:    []byte(`[` + strings.Join(harborPage, ",") + `]`),
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "harbor page 2",
+				Method: "GET",
+				Path:   "/api/v2.0/projects/proj/repositories",
+				Query: map[string][]string{
+					"page": {"2"},
+				},
+			},
+			RespEntry: reqresp.RespEntry{
+				Status:  http.StatusOK,
+				Headers: jsonHeaders,
+				Body:    []byte(`[{"name":"proj/team/last"}]`),
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "catalog",
+				Method: "GET",
+				Path:   "/v2/_catalog",
+			},
+			RespEntry: reqresp.RespEntry{
+				Status:  http.StatusOK,
+				Headers: jsonHeaders,
+				Body:    []byte(`{"repositories":["other/app","team/a","team/b/c","teamb/d"]}`),
+			},
+		},
+	}
+	rrs = append(rrs, reqresp.BaseEntries...)
+	ts := httptest.NewServer(reqresp.NewHandler(t, rrs))
+	t.Cleanup(ts.Close)
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	rcHosts := []*config.Host{
+		{
+			Name:     "hub.example.com",
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+			APIOpts: map[string]string{
+				"hubAPI": "true",
+				"hubURL": ts.URL,
+			},
+		},
+		{
+			Name:     "quay.example.com",
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+			APIOpts: map[string]string{
+				"quayAPI": "true",
+			},
+		},
+		{
+			Name:     "harbor.example.com",
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+			APIOpts: map[string]string{
+				"harborAPI": "true",
+			},
+		},
+		{
+			Name:     "plain.example.com",
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+		},
+	}
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	delayInit, _ := time.ParseDuration("0.05s")
+	delayMax, _ := time.ParseDuration("0.10s")
+	reg := New(
+		WithConfigHosts(rcHosts),
+		WithSlog(log),
+		WithDelay(delayInit, delayMax),
+	)
+	tt := []struct {
+		name      string
+		host      string
+		namespace string
+		expect    []string
+		expectLen int
+		expectErr bool
+	}{
+		{
+			name:      "hub",
+			host:      "hub.example.com",
+			namespace: "myorg",
+			expect:    []string{"myorg/app1", "myorg/app2", "myorg/app3"},
+		},
+		{
+			name:      "quay",
+			host:      "quay.example.com",
+			namespace: "team",
+			expect:    []string{"team/api", "team/web"},
+		},
+		{
+			name:      "harbor",
+			host:      "harbor.example.com",
+			namespace: "proj",
+			expectLen: namespacePageSize + 1,
+		},
+		{
+			name:      "catalog",
+			host:      "plain.example.com",
+			namespace: "team/",
+			expect:    []string{"team/a", "team/b/c"},
+		},
+		{
+			name:      "missing namespace",
+			host:      "plain.example.com",
+			namespace: "",
+			expectErr: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			repos, err := reg.RepoListNamespace(ctx, tc.host, tc.namespace)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("did not fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to list repositories: %v", err)
+			}
+			if tc.expectLen > 0 {
+				if len(repos) != tc.expectLen || repos[len(repos)-1] != "proj/team/last" {
+					t.Errorf("unexpected repositories, received %d: %v", len(repos), repos)
+				}
+				return
+			}
+			if strings.Join(repos, ",") != strings.Join(tc.expect, ",") {
+				t.Errorf("unexpected repositories, expected %v, received %v", tc.expect, repos)
+			}
+		})
+	}
+}
@@ -2,8 +2,11 @@ package reg
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
+	"strings"
 
+	"github.com/regclient/regclient/internal/auth"
 	"github.com/regclient/regclient/internal/reghttp"
 	"github.com/regclient/regclient/internal/reqmeta"
 	"github.com/regclient/regclient/types/ping"
@@ -24,6 +27,9 @@ func (reg *Reg) Ping(ctx context.Context, r ref.Ref) (ping.Result, error) {
 	resp, err := reg.reghttp.Do(ctx, req)
 	if resp != nil && resp.HTTPResponse() != nil {
 		ret.Header = resp.HTTPResponse().Header
+		if resp.HTTPResponse().Request != nil {
+			ret.Auth = pingAuth(resp.HTTPResponse().Request.Header.Get("Authorization"))
+		}
 	}
 	if err != nil {
 		return ret, fmt.Errorf("failed to ping registry %s: %w", r.Registry, err)
@@ -37,3 +43,25 @@ func (reg *Reg) Ping(ctx context.Context, r ref.Ref) (ping.Result, error) {
 
 	return ret, nil
 }
+
+// pingAuth describes the authorization header sent to the registry without including the secret.
+func pingAuth(header string) *ping.Auth {
+	a := &ping.Auth{}
+	authType, value, _ := strings.Cut(header, " ")
+	a.Type = authType
+	switch strings.ToLower(authType) {
+	case "basic":
+		if b, err := base64.StdEncoding.DecodeString(value); err == nil {
+			a.User, _, _ = strings.Cut(string(b), ":")
+		}
+	case "bearer", "jwt":
+		if c, err := auth.ParseClaims(value); err == nil {
+			a.User = c.Subject
+			a.Issuer = c.Issuer
+			a.IssuedAt = c.IssuedAt
+			a.Expires = c.Expires
+			a.Scopes = c.Scopes
+		}
+	}
+	return a
+}
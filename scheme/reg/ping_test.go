@@ -2,6 +2,7 @@ package reg
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -77,6 +78,29 @@ func TestPing(t *testing.T) {
 			},
 		},
 	}
+	basicCred := base64.StdEncoding.EncodeToString([]byte("testuser:testpass"))
+	rrsBasic := []reqresp.ReqResp{
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "Get Basic Authorized",
+				Method: "GET",
+				Path:   "/v2/",
+				Headers: http.Header{
+					"Authorization": {"Basic " + basicCred},
+				},
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Headers: http.Header{
+					"Content-Length":                  {fmt.Sprintf("%d", len(respOkay))},
+					"Content-Type":                    []string{contentType},
+					"Docker-Distribution-API-Version": {"registry/2.0"},
+				},
+				Body: []byte(respOkay),
+			},
+		},
+		rrsUnauth[0],
+	}
 	// create a server
 	tsOkay := httptest.NewServer(reqresp.NewHandler(t, rrsOkay))
 	defer tsOkay.Close()
@@ -84,6 +108,8 @@ func TestPing(t *testing.T) {
 	defer tsUnauth.Close()
 	tsNotFound := httptest.NewServer(reqresp.NewHandler(t, rrsNotFound))
 	defer tsNotFound.Close()
+	tsBasic := httptest.NewServer(reqresp.NewHandler(t, rrsBasic))
+	defer tsBasic.Close()
 	// setup the reg
 	tsOkayURL, _ := url.Parse(tsOkay.URL)
 	tsOkayHost := tsOkayURL.Host
@@ -91,6 +117,8 @@ func TestPing(t *testing.T) {
 	tsUnauthHost := tsUnauthURL.Host
 	tsNotFoundURL, _ := url.Parse(tsNotFound.URL)
 	tsNotFoundHost := tsNotFoundURL.Host
+	tsBasicURL, _ := url.Parse(tsBasic.URL)
+	tsBasicHost := tsBasicURL.Host
 	rcHosts := []*config.Host{
 		{
 			Name:     tsOkayHost,
@@ -107,6 +135,13 @@ func TestPing(t *testing.T) {
 			Hostname: tsNotFoundHost,
 			TLS:      config.TLSDisabled,
 		},
+		{
+			Name:     tsBasicHost,
+			Hostname: tsBasicHost,
+			TLS:      config.TLSDisabled,
+			User:     "testuser",
+			Pass:     "testpass",
+		},
 	}
 	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
 	delayInit, _ := time.ParseDuration("0.05s")
@@ -131,6 +166,22 @@ func TestPing(t *testing.T) {
 		} else if result.Header.Get("Content-Type") != contentType {
 			t.Errorf("unexpected content type, expected %s, received %s", contentType, result.Header.Get("Content-Type"))
 		}
+		if result.Auth == nil || result.Auth.Type != "" || result.Auth.User != "" {
+			t.Errorf("unexpected auth for an anonymous request: %v", result.Auth)
+		}
+	})
+	t.Run("Basic", func(t *testing.T) {
+		r, err := ref.NewHost(tsBasicHost)
+		if err != nil {
+			t.Fatalf("failed to create ref \"%s\": %v", tsBasicHost, err)
+		}
+		result, err := reg.Ping(ctx, r)
+		if err != nil {
+			t.Fatalf("failed to ping registry: %v", err)
+		}
+		if result.Auth == nil || result.Auth.Type != "Basic" || result.Auth.User != "testuser" {
+			t.Errorf("unexpected auth: %v", result.Auth)
+		}
 	})
 	t.Run("Unauth", func(t *testing.T) {
 		r, err := ref.NewHost(tsUnauthHost)
@@ -8,10 +8,12 @@ import (
 	"time"
 
 	"github.com/regclient/regclient/config"
+	"github.com/regclient/regclient/internal/auth"
 	"github.com/regclient/regclient/internal/cache"
 	"github.com/regclient/regclient/internal/pqueue"
 	"github.com/regclient/regclient/internal/reghttp"
 	"github.com/regclient/regclient/internal/reqmeta"
+	"github.com/regclient/regclient/pkg/metrics"
 	"github.com/regclient/regclient/types/manifest"
 	"github.com/regclient/regclient/types/ref"
 	"github.com/regclient/regclient/types/referrer"
@@ -242,6 +244,13 @@ func WithManifestMax(push, pull int64) Opts {
 	}
 }
 
+// WithMetrics records statistics on the requests to each registry
+func WithMetrics(m *metrics.Metrics) Opts {
+	return func(r *Reg) {
+		r.reghttpOpts = append(r.reghttpOpts, reghttp.WithMetrics(m))
+	}
+}
+
 // WithRetryLimit restricts the number of retries (defaults to 5)
 func WithRetryLimit(l int) Opts {
 	return func(r *Reg) {
@@ -257,6 +266,16 @@ func WithSlog(slog *slog.Logger) Opts {
 	}
 }
 
+// WithTokenCacheFile saves bearer tokens to a file to be reused by later processes.
+// The file is created with permissions limiting access to the current user.
+func WithTokenCacheFile(filename string) Opts {
+	return func(r *Reg) {
+		if filename != "" {
+			r.reghttpOpts = append(r.reghttpOpts, reghttp.WithTokenCache(auth.NewFileCache(filename)))
+		}
+	}
+}
+
 // WithTransport uses a specific http transport with retryable requests
 func WithTransport(t *http.Transport) Opts {
 	return func(r *Reg) {
@@ -264,6 +283,14 @@ func WithTransport(t *http.Transport) Opts {
 	}
 }
 
+// WithTransportWrap wraps the http transport for each registry after the registry specific TLS and proxy settings are applied.
+// This may be used to instrument requests, e.g. with OpenTelemetry tracing.
+func WithTransportWrap(fn func(http.RoundTripper) http.RoundTripper) Opts {
+	return func(r *Reg) {
+		r.reghttpOpts = append(r.reghttpOpts, reghttp.WithTransportWrap(fn))
+	}
+}
+
 // WithUserAgent sets a user agent header
 func WithUserAgent(ua string) Opts {
 	return func(r *Reg) {
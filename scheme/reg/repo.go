@@ -9,6 +9,7 @@ import (
 	"net/url"
 	"strconv"
 
+	"github.com/regclient/regclient/internal/httplink"
 	"github.com/regclient/regclient/internal/reghttp"
 	"github.com/regclient/regclient/internal/reqmeta"
 	"github.com/regclient/regclient/scheme"
@@ -17,6 +18,7 @@ import (
 )
 
 // RepoList returns a list of repositories on a registry
+// Additional pages are requested when the registry includes a Link header, up to the limit
 // Note the underlying "_catalog" API is not supported on many cloud registries
 func (reg *Reg) RepoList(ctx context.Context, hostname string, opts ...scheme.RepoOpts) (*repo.RepoList, error) {
 	config := scheme.RepoConfig{}
@@ -24,14 +26,99 @@ func (reg *Reg) RepoList(ctx context.Context, hostname string, opts ...scheme.Re
 		opt(&config)
 	}
 
-	query := url.Values{}
-	if config.Last != "" {
-		query.Set("last", config.Last)
+	rl, err := reg.repoListReq(ctx, hostname, config, nil)
+	if err != nil {
+		return nil, err
+	}
+	for config.Limit <= 0 || len(rl.Repositories) < config.Limit {
+		link, err := repoListNext(rl)
+		if err != nil {
+			return rl, err
+		}
+		if link == nil {
+			break
+		}
+		rlAdd, err := reg.repoListReq(ctx, hostname, config, link)
+		if err != nil {
+			return rl, err
+		}
+		err = rl.Append(rlAdd)
+		if err != nil {
+			return rl, fmt.Errorf("repo list failed to append entries: %w", err)
+		}
+	}
+	return rl, nil
+}
+
+// RepoListWalk calls fn with each page of repositories from a registry.
+// Pages are requested using the Link header, or when the header is missing and a limit is set, with the last repository of the previous page.
+// The limit option sets the size of each page.
+// The walk stops on the first error returned by fn.
+func (reg *Reg) RepoListWalk(ctx context.Context, hostname string, fn func(*repo.RepoList) error, opts ...scheme.RepoOpts) error {
+	config := scheme.RepoConfig{}
+	for _, opt := range opts {
+		opt(&config)
 	}
-	if config.Limit > 0 {
-		query.Set("n", strconv.Itoa(config.Limit))
+
+	var link *url.URL
+	seen := map[string]bool{}
+	for {
+		rl, err := reg.repoListReq(ctx, hostname, config, link)
+		if err != nil {
+			return err
+		}
+		err = fn(rl)
+		if err != nil {
+			return err
+		}
+		link, err = repoListNext(rl)
+		if err != nil {
+			return err
+		}
+		if link == nil {
+			// registries without a Link header may still support the last parameter
+			if config.Limit <= 0 || len(rl.Repositories) < config.Limit {
+				return nil
+			}
+			// stop if the registry ignores the last parameter and returns a previous page
+			last := rl.Repositories[len(rl.Repositories)-1]
+			if seen[last] {
+				return nil
+			}
+			seen[last] = true
+			config.Last = last
+		}
 	}
+}
 
+// repoListNext returns the URL for the next page of a repository list, or nil when there are no more pages
+func repoListNext(rl *repo.RepoList) (*url.URL, error) {
+	rlHead, err := rl.RawHeaders()
+	if err != nil {
+		return nil, err
+	}
+	links, err := httplink.Parse(rlHead.Values("Link"))
+	if err != nil {
+		return nil, err
+	}
+	next, err := links.Get("rel", "next")
+	if err != nil {
+		// no next link
+		return nil, nil
+	}
+	link := rl.GetURL()
+	if link == nil {
+		return nil, fmt.Errorf("repo list, failed to get URL of previous request")
+	}
+	link, err = link.Parse(next.URI)
+	if err != nil {
+		return nil, fmt.Errorf("repo list failed to parse Link: %w", err)
+	}
+	return link, nil
+}
+
+// repoListReq requests a single page of repositories, using the link when provided
+func (reg *Reg) repoListReq(ctx context.Context, hostname string, config scheme.RepoConfig, link *url.URL) (*repo.RepoList, error) {
 	headers := http.Header{
 		"Accept": []string{"application/json"},
 	}
@@ -40,11 +127,22 @@ func (reg *Reg) RepoList(ctx context.Context, hostname string, opts ...scheme.Re
 		Host:      hostname,
 		NoMirrors: true,
 		Method:    "GET",
-		Path:      "_catalog",
-		NoPrefix:  true,
-		Query:     query,
 		Headers:   headers,
 	}
+	if link != nil {
+		req.DirectURL = link
+	} else {
+		query := url.Values{}
+		if config.Last != "" {
+			query.Set("last", config.Last)
+		}
+		if config.Limit > 0 {
+			query.Set("n", strconv.Itoa(config.Limit))
+		}
+		req.Path = "_catalog"
+		req.NoPrefix = true
+		req.Query = query
+	}
 	resp, err := reg.reghttp.Do(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list repositories for %s: %w", hostname, err)
@@ -67,6 +165,7 @@ func (reg *Reg) RepoList(ctx context.Context, hostname string, opts ...scheme.Re
 		repo.WithRaw(respBody),
 		repo.WithHost(hostname),
 		repo.WithHeaders(resp.HTTPResponse().Header),
+		repo.WithURL(resp.HTTPResponse().Request.URL),
 	)
 	if err != nil {
 		reg.slog.Warn("Failed to unmarshal repo list",
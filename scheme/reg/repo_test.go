@@ -17,6 +17,7 @@ import (
 	"github.com/regclient/regclient/internal/reqresp"
 	"github.com/regclient/regclient/scheme"
 	"github.com/regclient/regclient/types/errs"
+	"github.com/regclient/regclient/types/repo"
 )
 
 func TestRepo(t *testing.T) {
@@ -133,6 +134,95 @@ func TestRepo(t *testing.T) {
 			},
 		},
 	}
+	rrss["link"] = []reqresp.ReqResp{
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "Link page 2",
+				Method: "GET",
+				Path:   "/v2/_catalog",
+				Query: map[string][]string{
+					"last": {listRegistry[partialLen-1]},
+				},
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Body:   []byte(fmt.Sprintf(`{"repositories":["%s"]}`, strings.Join(listRegistry[partialLen:], `","`))),
+				Headers: http.Header{
+					"Content-Type": {"application/json"},
+				},
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "Link page 1",
+				Method: "GET",
+				Path:   "/v2/_catalog",
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Body:   []byte(fmt.Sprintf(`{"repositories":["%s"]}`, strings.Join(listRegistry[:partialLen], `","`))),
+				Headers: http.Header{
+					"Content-Type": {"application/json"},
+					"Link":         {fmt.Sprintf(`</v2/_catalog?last=%s&n=%d>; rel="next"`, listRegistry[partialLen-1], partialLen)},
+				},
+			},
+		},
+	}
+	rrss["paged"] = []reqresp.ReqResp{
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "Paged end",
+				Method: "GET",
+				Path:   "/v2/_catalog",
+				Query: map[string][]string{
+					"last": {listRegistry[len(listRegistry)-1]},
+					"n":    {fmt.Sprintf("%d", partialLen)},
+				},
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Body:   []byte(`{"repositories":[]}`),
+				Headers: http.Header{
+					"Content-Type": {"application/json"},
+				},
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "Paged 2",
+				Method: "GET",
+				Path:   "/v2/_catalog",
+				Query: map[string][]string{
+					"last": {listRegistry[partialLen-1]},
+					"n":    {fmt.Sprintf("%d", partialLen)},
+				},
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Body:   []byte(fmt.Sprintf(`{"repositories":["%s"]}`, strings.Join(listRegistry[partialLen:], `","`))),
+				Headers: http.Header{
+					"Content-Type": {"application/json"},
+				},
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "Paged 1",
+				Method: "GET",
+				Path:   "/v2/_catalog",
+				Query: map[string][]string{
+					"n": {fmt.Sprintf("%d", partialLen)},
+				},
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Body:   []byte(fmt.Sprintf(`{"repositories":["%s"]}`, strings.Join(listRegistry[:partialLen], `","`))),
+				Headers: http.Header{
+					"Content-Type": {"application/json"},
+				},
+			},
+		},
+	}
 	tss := map[string]*httptest.Server{}
 	rcHosts := []*config.Host{}
 	for name := range rrss {
@@ -214,6 +304,84 @@ func TestRepo(t *testing.T) {
 		}
 
 	})
+	// follow Link headers
+	t.Run("Link", func(t *testing.T) {
+		u, _ := url.Parse(tss["link"].URL)
+		host := u.Host
+		rl, err := reg.RepoList(ctx, host)
+		if err != nil {
+			t.Fatalf("error listing repos: %v", err)
+		}
+		rlRepos, err := rl.GetRepos()
+		if err != nil {
+			t.Errorf("error retrieving repos: %v", err)
+		} else if stringSliceCmp(listRegistry, rlRepos) == false {
+			t.Errorf("repositories do not match: expected %v, received %v", listRegistry, rlRepos)
+		}
+		body, err := rl.RawBody()
+		if err != nil {
+			t.Fatalf("error retrieving body: %v", err)
+		}
+		if !strings.Contains(string(body), listRegistry[0]) || !strings.Contains(string(body), listRegistry[len(listRegistry)-1]) {
+			t.Errorf("body does not include every page: %s", body)
+		}
+	})
+	t.Run("Walk Link", func(t *testing.T) {
+		u, _ := url.Parse(tss["link"].URL)
+		host := u.Host
+		pages := 0
+		repos := []string{}
+		err := reg.RepoListWalk(ctx, host, func(rl *repo.RepoList) error {
+			pages++
+			repos = append(repos, rl.Repositories...)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("error walking repos: %v", err)
+		}
+		if pages != 2 {
+			t.Errorf("unexpected number of pages, expected 2, received %d", pages)
+		}
+		if stringSliceCmp(listRegistry, repos) == false {
+			t.Errorf("repositories do not match: expected %v, received %v", listRegistry, repos)
+		}
+	})
+	t.Run("Walk Last", func(t *testing.T) {
+		u, _ := url.Parse(tss["paged"].URL)
+		host := u.Host
+		pages := 0
+		repos := []string{}
+		err := reg.RepoListWalk(ctx, host, func(rl *repo.RepoList) error {
+			pages++
+			repos = append(repos, rl.Repositories...)
+			return nil
+		}, scheme.WithRepoLimit(partialLen))
+		if err != nil {
+			t.Fatalf("error walking repos: %v", err)
+		}
+		if pages != 3 {
+			t.Errorf("unexpected number of pages, expected 3, received %d", pages)
+		}
+		if stringSliceCmp(listRegistry, repos) == false {
+			t.Errorf("repositories do not match: expected %v, received %v", listRegistry, repos)
+		}
+	})
+	t.Run("Walk Stop", func(t *testing.T) {
+		u, _ := url.Parse(tss["link"].URL)
+		host := u.Host
+		errStop := errors.New("stop")
+		pages := 0
+		err := reg.RepoListWalk(ctx, host, func(rl *repo.RepoList) error {
+			pages++
+			return errStop
+		})
+		if !errors.Is(err, errStop) {
+			t.Errorf("unexpected error: expected %v, received %v", errStop, err)
+		}
+		if pages != 1 {
+			t.Errorf("unexpected number of pages, expected 1, received %d", pages)
+		}
+	})
 	// test with http errors
 	t.Run("Disabled", func(t *testing.T) {
 		u, _ := url.Parse(tss["disabled"].URL)
@@ -196,6 +196,7 @@ func (reg *Reg) TagDelete(ctx context.Context, r ref.Ref) error {
 }
 
 // TagList returns a listing to tags from the repository
+// When the "hubAPI" option is enabled on the host, metadata from the Docker Hub API is included in the HubTags field.
 func (reg *Reg) TagList(ctx context.Context, r ref.Ref, opts ...scheme.TagOpts) (*tag.List, error) {
 	var config scheme.TagConfig
 	for _, opt := range opts {
@@ -245,10 +246,75 @@ func (reg *Reg) TagList(ctx context.Context, r ref.Ref, opts ...scheme.TagOpts)
 			break
 		}
 	}
+	reg.hubTagsAdd(ctx, r, tl, nil)
 
 	return tl, nil
 }
 
+// TagListWalk calls fn with each page of tags from the repository.
+// Pages are requested using the Link header, or when the header is missing and a limit is set, with the last tag of the previous page.
+// The limit option sets the size of each page.
+// The walk stops on the first error returned by fn.
+func (reg *Reg) TagListWalk(ctx context.Context, r ref.Ref, fn func(*tag.List) error, opts ...scheme.TagOpts) error {
+	var config scheme.TagConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	var tl *tag.List
+	var err error
+	var link *url.URL
+	var hubTags map[string]tag.HubTagInfo
+	seen := map[string]bool{}
+	for {
+		if link == nil {
+			tl, err = reg.tagListOCI(ctx, r, config)
+		} else {
+			tl, err = reg.tagListLink(ctx, r, config, link)
+		}
+		if err != nil {
+			return err
+		}
+		hubTags = reg.hubTagsAdd(ctx, r, tl, hubTags)
+		err = fn(tl)
+		if err != nil {
+			return err
+		}
+		tlHead, err := tl.RawHeaders()
+		if err != nil {
+			return err
+		}
+		links, err := httplink.Parse(tlHead.Values("Link"))
+		if err != nil {
+			return err
+		}
+		next, err := links.Get("rel", "next")
+		if err == nil {
+			link = tl.GetURL()
+			if link == nil {
+				return fmt.Errorf("tag list, failed to get URL of previous request")
+			}
+			link, err = link.Parse(next.URI)
+			if err != nil {
+				return fmt.Errorf("tag list failed to parse Link: %w", err)
+			}
+			continue
+		}
+		// registries without a Link header may still support the last parameter
+		link = nil
+		if config.Limit <= 0 || len(tl.Tags) < config.Limit {
+			return nil
+		}
+		// stop if the registry ignores the last parameter and returns a previous page
+		last := tl.Tags[len(tl.Tags)-1]
+		if seen[last] {
+			return nil
+		}
+		seen[last] = true
+		config.Last = last
+	}
+}
+
 func (reg *Reg) tagListOCI(ctx context.Context, r ref.Ref, config scheme.TagConfig) (*tag.List, error) {
 	query := url.Values{}
 	if config.Last != "" {
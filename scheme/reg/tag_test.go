@@ -22,6 +22,7 @@ import (
 	"github.com/regclient/regclient/types/errs"
 	"github.com/regclient/regclient/types/mediatype"
 	"github.com/regclient/regclient/types/ref"
+	"github.com/regclient/regclient/types/tag"
 )
 
 func TestTag(t *testing.T) {
@@ -48,7 +49,27 @@ func TestTag(t *testing.T) {
 	delFallbackDigest := digest.FromString(delFallbackManifest)
 	uuid1 := reqresp.NewRandomID(seed)
 	ctx := context.Background()
+	listTagBodyEmpty := []byte(fmt.Sprintf("{\"name\":\"%s\",\"tags\":[]}", strings.TrimLeft(repoPath, "/")))
 	rrs := []reqresp.ReqResp{
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "tag get page 3",
+				Method: "GET",
+				Path:   "/v2" + repoPath + "/tags/list",
+				Query: map[string][]string{
+					"n":    {fmt.Sprintf("%d", pageLen)},
+					"last": {listTagList[len(listTagList)-1]},
+				},
+			},
+			RespEntry: reqresp.RespEntry{
+				Status: http.StatusOK,
+				Headers: http.Header{
+					"Content-Length": {fmt.Sprintf("%d", len(listTagBodyEmpty))},
+					"Content-Type":   {"application/json"},
+				},
+				Body: listTagBodyEmpty,
+			},
+		},
 		{
 			ReqEntry: reqresp.ReqEntry{
 				Name:   "tag get page 2",
@@ -334,6 +355,52 @@ func TestTag(t *testing.T) {
 			t.Errorf("returned list mismatch, expected %v, received %v", listTagList, tags)
 		}
 	})
+	// walk tags using the last parameter
+	t.Run("Walk", func(t *testing.T) {
+		listRef, err := ref.New(tsURL.Host + repoPath)
+		if err != nil {
+			t.Fatalf("failed creating getRef: %v", err)
+		}
+		pages := 0
+		tags := []string{}
+		err = reg.TagListWalk(ctx, listRef, func(tl *tag.List) error {
+			pages++
+			tags = append(tags, tl.Tags...)
+			return nil
+		}, scheme.WithTagLimit(pageLen))
+		if err != nil {
+			t.Fatalf("failed to walk tags: %v", err)
+		}
+		if pages != 3 {
+			t.Errorf("unexpected number of pages, expected 3, received %d", pages)
+		}
+		if !stringSliceCmp(tags, listTagList) {
+			t.Errorf("returned list mismatch, expected %v, received %v", listTagList, tags)
+		}
+	})
+	// walk tags using the Link header
+	t.Run("Walk Link", func(t *testing.T) {
+		listRef, err := ref.New(tsURL.Host + repoPath2)
+		if err != nil {
+			t.Fatalf("failed creating getRef: %v", err)
+		}
+		pages := 0
+		tags := []string{}
+		err = reg.TagListWalk(ctx, listRef, func(tl *tag.List) error {
+			pages++
+			tags = append(tags, tl.Tags...)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("failed to walk tags: %v", err)
+		}
+		if pages != 2 {
+			t.Errorf("unexpected number of pages, expected 2, received %d", pages)
+		}
+		if !stringSliceCmp(tags, listTagList) {
+			t.Errorf("returned list mismatch, expected %v, received %v", listTagList, tags)
+		}
+	})
 	// list tags on missing repos
 	t.Run("Missing", func(t *testing.T) {
 		listRef, err := ref.New(tsURL.Host + missingRepo)
@@ -0,0 +1,275 @@
+package reg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/regclient/regclient/config"
+	"github.com/regclient/regclient/internal/reghttp"
+	"github.com/regclient/regclient/internal/reqmeta"
+	"github.com/regclient/regclient/types/errs"
+	"github.com/regclient/regclient/types/ref"
+	"github.com/regclient/regclient/types/tag"
+)
+
+const (
+	// quayAPIOpt is the host APIOpts key to enable the Quay API
+	quayAPIOpt = "quayAPI"
+	// quayURLOpt is the host APIOpts key to override the Quay API URL
+	quayURLOpt = "quayURL"
+	// harborAPIOpt is the host APIOpts key to enable the Harbor API
+	harborAPIOpt = "harborAPI"
+	// harborURLOpt is the host APIOpts key to override the Harbor API URL
+	harborURLOpt = "harborURL"
+
+	vendorHub    = "hub"
+	vendorQuay   = "quay"
+	vendorHarbor = "harbor"
+)
+
+// TagInfo returns the metadata for a tag from the vendor API of the registry.
+// Docker Hub and quay.io are detected from the registry name.
+// Other registries need the "hubAPI", "quayAPI", or "harborAPI" option enabled on the host,
+// and the API URL can be changed with the "hubURL", "quayURL", or "harborURL" option.
+// [errs.ErrUnsupportedAPI] is returned when no vendor API is available.
+func (reg *Reg) TagInfo(ctx context.Context, r ref.Ref) (tag.Info, error) {
+	if r.Tag == "" {
+		return tag.Info{}, fmt.Errorf("tag is required for %s%.0w", r.CommonName(), errs.ErrMissingTag)
+	}
+	vendor, base, err := reg.vendorURL(r)
+	if err != nil {
+		return tag.Info{}, err
+	}
+	switch vendor {
+	case vendorHub:
+		return reg.hubTagInfo(ctx, r, base)
+	case vendorQuay:
+		return reg.quayTagInfo(ctx, r, base)
+	case vendorHarbor:
+		return reg.harborTagInfo(ctx, r, base)
+	}
+	return tag.Info{}, fmt.Errorf("no vendor API for %s%.0w", r.Registry, errs.ErrUnsupportedAPI)
+}
+
+// vendorURL returns the vendor and base URL of the API for the registry.
+func (reg *Reg) vendorURL(r ref.Ref) (string, *url.URL, error) {
+	h := reg.hostGet(r.Registry)
+	scheme := "https://"
+	if h.TLS == config.TLSDisabled {
+		scheme = "http://"
+	}
+	vendors := []struct {
+		name, apiOpt, urlOpt, def string
+	}{
+		{name: vendorHub, apiOpt: hubAPIOpt, urlOpt: hubURLOpt, def: hubURLDefault},
+		{name: vendorQuay, apiOpt: quayAPIOpt, urlOpt: quayURLOpt, def: scheme + h.Hostname},
+		{name: vendorHarbor, apiOpt: harborAPIOpt, urlOpt: harborURLOpt, def: scheme + h.Hostname},
+	}
+	vendor := -1
+	for i, v := range vendors {
+		if enabled, err := strconv.ParseBool(h.APIOpts[v.apiOpt]); err == nil && enabled {
+			vendor = i
+			break
+		}
+	}
+	if vendor < 0 {
+		switch h.Name {
+		case config.DockerRegistry:
+			vendor = 0
+		case "quay.io":
+			vendor = 1
+		default:
+			return "", nil, fmt.Errorf("no vendor API for %s%.0w", r.Registry, errs.ErrUnsupportedAPI)
+		}
+	}
+	base := vendors[vendor].def
+	if h.APIOpts[vendors[vendor].urlOpt] != "" {
+		base = h.APIOpts[vendors[vendor].urlOpt]
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse %s API URL %s: %w", vendors[vendor].name, base, err)
+	}
+	return vendors[vendor].name, u, nil
+}
+
+func (reg *Reg) hubTagInfo(ctx context.Context, r ref.Ref, base *url.URL) (tag.Info, error) {
+	ns, repo, ok := strings.Cut(r.Repository, "/")
+	if !ok {
+		ns, repo = "library", r.Repository
+	}
+	ht := tag.HubTagInfo{}
+	err := reg.vendorGet(ctx, r, base.JoinPath("v2", "namespaces", ns, "repositories", repo, "tags", r.Tag), &ht)
+	if err != nil {
+		return tag.Info{}, fmt.Errorf("failed to get tag from hub for %s: %w", r.CommonName(), err)
+	}
+	info := tag.Info{
+		Name:     r.Tag,
+		Vendor:   vendorHub,
+		Digest:   ht.Digest,
+		Size:     ht.Size,
+		Pushed:   ht.LastPushed,
+		Pulled:   ht.LastPulled,
+		PushedBy: ht.LastUpdater,
+	}
+	hr := struct {
+		PullCount int64 `json:"pull_count"`
+	}{}
+	err = reg.vendorGet(ctx, r, base.JoinPath("v2", "namespaces", ns, "repositories", repo), &hr)
+	if err != nil {
+		reg.slog.Info("Failed to get repository pull count from hub",
+			slog.String("ref", r.CommonName()),
+			slog.String("err", err.Error()))
+	}
+	info.PullCount = hr.PullCount
+	return info, nil
+}
+
+func (reg *Reg) quayTagInfo(ctx context.Context, r ref.Ref, base *url.URL) (tag.Info, error) {
+	u := base.JoinPath("api", "v1", "repository", r.Repository, "tag") // trailing slash is required by quay
+	u.Path += "/"
+	u.RawQuery = url.Values{"specificTag": []string{r.Tag}, "onlyActiveTags": []string{"true"}}.Encode()
+	qr := struct {
+		Tags []struct {
+			Name           string `json:"name"`
+			ManifestDigest string `json:"manifest_digest"`
+			Size           int64  `json:"size"`
+			StartTS        int64  `json:"start_ts"`
+		} `json:"tags"`
+	}{}
+	err := reg.vendorGet(ctx, r, u, &qr)
+	if err != nil {
+		return tag.Info{}, fmt.Errorf("failed to get tag from quay for %s: %w", r.CommonName(), err)
+	}
+	for _, qt := range qr.Tags {
+		if qt.Name != r.Tag {
+			continue
+		}
+		info := tag.Info{
+			Name:   r.Tag,
+			Vendor: vendorQuay,
+			Digest: qt.ManifestDigest,
+			Size:   qt.Size,
+		}
+		if qt.StartTS > 0 {
+			info.Pushed = time.Unix(qt.StartTS, 0).UTC()
+		}
+		return info, nil
+	}
+	return tag.Info{}, fmt.Errorf("tag not found in quay for %s%.0w", r.CommonName(), errs.ErrNotFound)
+}
+
+func (reg *Reg) harborTagInfo(ctx context.Context, r ref.Ref, base *url.URL) (tag.Info, error) {
+	project, repo, ok := strings.Cut(r.Repository, "/")
+	if !ok {
+		return tag.Info{}, fmt.Errorf("harbor repository must include a project: %s%.0w", r.CommonName(), errs.ErrInvalidReference)
+	}
+	// harbor requires slashes in the repository name to be double encoded
+	repoPath := strings.TrimSuffix(base.String(), "/") + "/api/v2.0/projects/" + url.PathEscape(project) +
+		"/repositories/" + url.PathEscape(url.PathEscape(repo))
+	u, err := url.Parse(repoPath + "/artifacts/" + url.PathEscape(r.Tag) + "?with_tag=true")
+	if err != nil {
+		return tag.Info{}, err
+	}
+	ha := struct {
+		Digest   string    `json:"digest"`
+		Size     int64     `json:"size"`
+		PushTime time.Time `json:"push_time"`
+		PullTime time.Time `json:"pull_time"`
+		Tags     []struct {
+			Name     string    `json:"name"`
+			PushTime time.Time `json:"push_time"`
+			PullTime time.Time `json:"pull_time"`
+		} `json:"tags"`
+	}{}
+	err = reg.vendorGet(ctx, r, u, &ha)
+	if err != nil {
+		return tag.Info{}, fmt.Errorf("failed to get artifact from harbor for %s: %w", r.CommonName(), err)
+	}
+	info := tag.Info{
+		Name:   r.Tag,
+		Vendor: vendorHarbor,
+		Digest: ha.Digest,
+		Size:   ha.Size,
+		Pushed: ha.PushTime,
+		Pulled: ha.PullTime,
+	}
+	for _, ht := range ha.Tags {
+		if ht.Name == r.Tag {
+			info.Pushed = ht.PushTime
+			if !ht.PullTime.IsZero() {
+				info.Pulled = ht.PullTime
+			}
+		}
+	}
+	// the pull count and audit log are optional
+	hr := struct {
+		PullCount int64 `json:"pull_count"`
+	}{}
+	u, err = url.Parse(repoPath)
+	if err == nil {
+		err = reg.vendorGet(ctx, r, u, &hr)
+	}
+	if err != nil {
+		reg.slog.Info("Failed to get repository pull count from harbor",
+			slog.String("ref", r.CommonName()),
+			slog.String("err", err.Error()))
+	}
+	info.PullCount = hr.PullCount
+	logs := []struct {
+		Username string `json:"username"`
+	}{}
+	u, err = url.Parse(strings.TrimSuffix(base.String(), "/") + "/api/v2.0/projects/" + url.PathEscape(project) + "/logs")
+	if err == nil {
+		u.RawQuery = url.Values{
+			"q":         []string{"operation=create,resource=" + r.Repository + ":" + r.Tag},
+			"sort":      []string{"-op_time"},
+			"page_size": []string{"1"},
+		}.Encode()
+		err = reg.vendorGet(ctx, r, u, &logs)
+	}
+	if err != nil {
+		reg.slog.Info("Failed to get audit log from harbor",
+			slog.String("ref", r.CommonName()),
+			slog.String("err", err.Error()))
+	} else if len(logs) > 0 {
+		info.PushedBy = logs[0].Username
+	}
+	return info, nil
+}
+
+// vendorGet sends a GET request to a vendor API and parses the json response into out.
+func (reg *Reg) vendorGet(ctx context.Context, r ref.Ref, u *url.URL, out interface{}) error {
+	req := &reghttp.Req{
+		MetaKind:  reqmeta.Query,
+		Host:      r.Registry,
+		NoMirrors: true,
+		Method:    "GET",
+		DirectURL: u,
+		Headers: http.Header{
+			"Accept": []string{"application/json"},
+		},
+		IgnoreErr: true, // do not trigger backoffs on the registry if the vendor API fails
+	}
+	resp, err := reg.reghttp.Do(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Close()
+	if resp.HTTPResponse().StatusCode != 200 {
+		return reghttp.HTTPError(resp.HTTPResponse().StatusCode)
+	}
+	respBody, err := io.ReadAll(resp)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(respBody, out)
+}
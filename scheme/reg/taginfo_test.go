@@ -0,0 +1,242 @@
+package reg
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/regclient/regclient/config"
+	"github.com/regclient/regclient/internal/reqresp"
+	"github.com/regclient/regclient/types/errs"
+	"github.com/regclient/regclient/types/ref"
+)
+
+func TestTagInfo(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	digest1 := "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	jsonHeaders := http.Header{"Content-Type": {"application/json"}}
+	rrs := []reqresp.ReqResp{
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "hub tag",
+				Method: "GET",
+				Path:   "/v2/namespaces/library/repositories/alpine/tags/latest",
+			},
+			RespEntry: reqresp.RespEntry{
+				Status:  http.StatusOK,
+				Headers: jsonHeaders,
+				Body:    []byte(`{"name":"latest","digest":"` + digest1 + `","full_size":3400000,"tag_last_pushed":"2024-05-22T18:00:00Z","tag_last_pulled":"2024-06-01T10:00:00Z","last_updater_username":"doijanky"}`),
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "hub repo",
+				Method: "GET",
+				Path:   "/v2/namespaces/library/repositories/alpine",
+			},
+			RespEntry: reqresp.RespEntry{
+				Status:  http.StatusOK,
+				Headers: jsonHeaders,
+				Body:    []byte(`{"name":"alpine","pull_count":1234}`),
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "quay tag",
+				Method: "GET",
+				Path:   "/api/v1/repository/team/app/tag/",
+				Query: map[string][]string{
+					"specificTag":    {"v1"},
+					"onlyActiveTags": {"true"},
+				},
+			},
+			RespEntry: reqresp.RespEntry{
+				Status:  http.StatusOK,
+				Headers: jsonHeaders,
+				Body:    []byte(`{"tags":[{"name":"v1","manifest_digest":"` + digest1 + `","size":2000,"start_ts":1716400800}]}`),
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "quay missing",
+				Method: "GET",
+				Path:   "/api/v1/repository/team/app/tag/",
+				Query: map[string][]string{
+					"specificTag": {"missing"},
+				},
+			},
+			RespEntry: reqresp.RespEntry{
+				Status:  http.StatusOK,
+				Headers: jsonHeaders,
+				Body:    []byte(`{"tags":[]}`),
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "harbor artifact",
+				Method: "GET",
+				Path:   "/api/v2.0/projects/proj/repositories/team%2Fapp/artifacts/v1",
+			},
+			RespEntry: reqresp.RespEntry{
+				Status:  http.StatusOK,
+				Headers: jsonHeaders,
+				Body:    []byte(`{"digest":"` + digest1 + `","size":5000,"push_time":"2024-01-01T00:00:00Z","pull_time":"2024-06-01T10:00:00Z","tags":[{"name":"v1","push_time":"2024-05-22T18:00:00Z"}]}`),
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "harbor repo",
+				Method: "GET",
+				Path:   "/api/v2.0/projects/proj/repositories/team%2Fapp",
+			},
+			RespEntry: reqresp.RespEntry{
+				Status:  http.StatusOK,
+				Headers: jsonHeaders,
+				Body:    []byte(`{"name":"proj/team/app","pull_count":42}`),
+			},
+		},
+		{
+			ReqEntry: reqresp.ReqEntry{
+				Name:   "harbor logs",
+				Method: "GET",
+				Path:   "/api/v2.0/projects/proj/logs",
+				Query: map[string][]string{
+					"q": {"operation=create,resource=proj/team/app:v1"},
+				},
+			},
+			RespEntry: reqresp.RespEntry{
+				Status:  http.StatusOK,
+				Headers: jsonHeaders,
+				Body:    []byte(`[{"username":"robot$ci","operation":"create"}]`),
+			},
+		},
+	}
+	ts := httptest.NewServer(reqresp.NewHandler(t, rrs))
+	defer ts.Close()
+	tsURL, _ := url.Parse(ts.URL)
+	tsHost := tsURL.Host
+	rcHosts := []*config.Host{
+		{
+			Name:     "hub.example.com",
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+			APIOpts: map[string]string{
+				"hubAPI": "true",
+				"hubURL": ts.URL,
+			},
+		},
+		{
+			Name:     "quay.example.com",
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+			APIOpts: map[string]string{
+				"quayAPI": "true",
+			},
+		},
+		{
+			Name:     "harbor.example.com",
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+			APIOpts: map[string]string{
+				"harborAPI": "true",
+			},
+		},
+		{
+			Name:     "plain.example.com",
+			Hostname: tsHost,
+			TLS:      config.TLSDisabled,
+		},
+	}
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	delayInit, _ := time.ParseDuration("0.05s")
+	delayMax, _ := time.ParseDuration("0.10s")
+	reg := New(
+		WithConfigHosts(rcHosts),
+		WithSlog(log),
+		WithDelay(delayInit, delayMax),
+	)
+	pushed := time.Date(2024, 5, 22, 18, 0, 0, 0, time.UTC)
+
+	t.Run("Hub", func(t *testing.T) {
+		r, err := ref.New("hub.example.com/library/alpine:latest")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		info, err := reg.TagInfo(ctx, r)
+		if err != nil {
+			t.Fatalf("failed to get tag info: %v", err)
+		}
+		if info.Vendor != "hub" || info.Digest != digest1 || info.Size != 3400000 || info.PushedBy != "doijanky" || info.PullCount != 1234 {
+			t.Errorf("unexpected info: %v", info)
+		}
+		if !info.Pushed.Equal(pushed) || info.Pulled.IsZero() {
+			t.Errorf("unexpected times: %v", info)
+		}
+	})
+	t.Run("Quay", func(t *testing.T) {
+		r, err := ref.New("quay.example.com/team/app:v1")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		info, err := reg.TagInfo(ctx, r)
+		if err != nil {
+			t.Fatalf("failed to get tag info: %v", err)
+		}
+		if info.Vendor != "quay" || info.Digest != digest1 || info.Size != 2000 || !info.Pushed.Equal(pushed) {
+			t.Errorf("unexpected info: %v", info)
+		}
+	})
+	t.Run("Quay missing", func(t *testing.T) {
+		r, err := ref.New("quay.example.com/team/app:missing")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		_, err = reg.TagInfo(ctx, r)
+		if !errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+	t.Run("Harbor", func(t *testing.T) {
+		r, err := ref.New("harbor.example.com/proj/team/app:v1")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		info, err := reg.TagInfo(ctx, r)
+		if err != nil {
+			t.Fatalf("failed to get tag info: %v", err)
+		}
+		if info.Vendor != "harbor" || info.Digest != digest1 || info.Size != 5000 || info.PushedBy != "robot$ci" || info.PullCount != 42 {
+			t.Errorf("unexpected info: %v", info)
+		}
+		if !info.Pushed.Equal(pushed) || info.Pulled.IsZero() {
+			t.Errorf("unexpected times: %v", info)
+		}
+	})
+	t.Run("Unsupported", func(t *testing.T) {
+		r, err := ref.New("plain.example.com/team/app:v1")
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		_, err = reg.TagInfo(ctx, r)
+		if !errors.Is(err, errs.ErrUnsupportedAPI) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+	t.Run("Missing tag", func(t *testing.T) {
+		r, err := ref.New("hub.example.com/library/alpine@" + digest1)
+		if err != nil {
+			t.Fatalf("failed to parse ref: %v", err)
+		}
+		_, err = reg.TagInfo(ctx, r)
+		if !errors.Is(err, errs.ErrMissingTag) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
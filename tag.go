@@ -10,6 +10,14 @@ import (
 	"github.com/regclient/regclient/types/tag"
 )
 
+type tagInfoer interface {
+	TagInfo(ctx context.Context, r ref.Ref) (tag.Info, error)
+}
+
+type tagWalker interface {
+	TagListWalk(ctx context.Context, r ref.Ref, fn func(*tag.List) error, opts ...scheme.TagOpts) error
+}
+
 // TagDelete deletes a tag from the registry. Since there's no API for this,
 // you'd want to normally just delete the manifest. However multiple tags may
 // point to the same manifest, so instead you must:
@@ -27,6 +35,24 @@ func (rc *RegClient) TagDelete(ctx context.Context, r ref.Ref) error {
 	return schemeAPI.TagDelete(ctx, r)
 }
 
+// TagInfo returns the metadata for a tag from the vendor API of the registry, e.g. push and pull times.
+// This is supported for Docker Hub, Quay, and Harbor, see the host APIOpts to enable each vendor API.
+// [errs.ErrUnsupportedAPI] is returned when the registry does not have a supported vendor API.
+func (rc *RegClient) TagInfo(ctx context.Context, r ref.Ref) (tag.Info, error) {
+	if !r.IsSet() {
+		return tag.Info{}, fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), errs.ErrInvalidReference)
+	}
+	schemeAPI, err := rc.schemeGet(r.Scheme)
+	if err != nil {
+		return tag.Info{}, err
+	}
+	ti, ok := schemeAPI.(tagInfoer)
+	if !ok {
+		return tag.Info{}, fmt.Errorf("tag info is not supported for %s%.0w", r.Scheme, errs.ErrUnsupportedAPI)
+	}
+	return ti.TagInfo(ctx, r)
+}
+
 // TagList returns a tag list from a repository
 func (rc *RegClient) TagList(ctx context.Context, r ref.Ref, opts ...scheme.TagOpts) (*tag.List, error) {
 	if !r.IsSetRepo() {
@@ -38,3 +64,26 @@ func (rc *RegClient) TagList(ctx context.Context, r ref.Ref, opts ...scheme.TagO
 	}
 	return schemeAPI.TagList(ctx, r, opts...)
 }
+
+// TagListWalk calls fn with each page of tags from a repository, following the pagination from the registry.
+// This avoids holding the full list in memory on repositories with many tags.
+// Use [scheme.WithTagLimit] to set the page size and [scheme.WithTagLast] to start after a tag.
+// Schemes without pagination call fn once with the full list.
+// Returning an error from fn stops the walk and that error is returned.
+func (rc *RegClient) TagListWalk(ctx context.Context, r ref.Ref, fn func(*tag.List) error, opts ...scheme.TagOpts) error {
+	if !r.IsSetRepo() {
+		return fmt.Errorf("ref is not set: %s%.0w", r.CommonName(), errs.ErrInvalidReference)
+	}
+	schemeAPI, err := rc.schemeGet(r.Scheme)
+	if err != nil {
+		return err
+	}
+	if tw, ok := schemeAPI.(tagWalker); ok {
+		return tw.TagListWalk(ctx, r, fn, opts...)
+	}
+	tl, err := schemeAPI.TagList(ctx, r, opts...)
+	if err != nil {
+		return err
+	}
+	return fn(tl)
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/regclient/regclient/config"
 	"github.com/regclient/regclient/internal/copyfs"
 	"github.com/regclient/regclient/types/ref"
+	"github.com/regclient/regclient/types/tag"
 )
 
 func TestTag(t *testing.T) {
@@ -111,6 +112,17 @@ func TestTag(t *testing.T) {
 			if len(tl.Tags) == 0 {
 				t.Fatalf("failed to get tags: %v", tl)
 			}
+			walkTags := []string{}
+			err = rc.TagListWalk(ctx, r, func(tlPage *tag.List) error {
+				walkTags = append(walkTags, tlPage.Tags...)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("failed to walk tags: %v", err)
+			}
+			if len(walkTags) != len(tl.Tags) {
+				t.Errorf("walk returned %d tags, expected %d", len(walkTags), len(tl.Tags))
+			}
 			rDel, err := ref.New(tc.repo + ":" + existingTag)
 			if err != nil {
 				t.Fatalf("failed to parse ref %s: %v", tc.repo+":"+existingTag, err)
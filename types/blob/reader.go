@@ -23,11 +23,12 @@ type Reader = *BReader
 // BReader is used to read blobs.
 type BReader struct {
 	BCommon
-	readBytes int64
-	reader    io.Reader
-	origRdr   io.Reader
-	digester  digest.Digester
-	mu        sync.Mutex
+	readBytes  int64
+	reader     io.Reader
+	origRdr    io.Reader
+	digester   digest.Digester
+	skipVerify bool
+	mu         sync.Mutex
 }
 
 // NewReader creates a new BReader.
@@ -121,7 +122,7 @@ func (r *BReader) Read(p []byte) (int, error) {
 		// check/save digest
 		if r.desc.Digest.Validate() != nil {
 			r.desc.Digest = r.digester.Digest()
-		} else if r.desc.Digest != r.digester.Digest() {
+		} else if r.desc.Digest != r.digester.Digest() && !r.skipVerify {
 			err = fmt.Errorf("%w [expected %s, calculated %s]: %w", errs.ErrDigestMismatch, r.desc.Digest.String(), r.digester.Digest().String(), err)
 		}
 	}
@@ -165,6 +166,18 @@ func (r *BReader) Seek(offset int64, whence int) (int64, error) {
 	return 0, nil
 }
 
+// SkipVerify disables the digest check when the last byte is read.
+// This is used for raw access to content that may not match the descriptor.
+// The size is still checked.
+func (r *BReader) SkipVerify() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.skipVerify = true
+	r.mu.Unlock()
+}
+
 // ToOCIConfig converts a BReader to a BOCIConfig.
 func (r *BReader) ToOCIConfig() (*BOCIConfig, error) {
 	if r == nil || !r.blobSet {
@@ -9,8 +9,27 @@ const (
 	CallbackActive
 	CallbackFinished
 	CallbackArchived
+	CallbackShared // a blob already copied for another manifest in the same image
 )
 
+func (s CallbackState) String() string {
+	switch s {
+	case CallbackSkipped:
+		return "skipped"
+	case CallbackStarted:
+		return "started"
+	case CallbackActive:
+		return "active"
+	case CallbackFinished:
+		return "finished"
+	case CallbackArchived:
+		return "archived"
+	case CallbackShared:
+		return "shared"
+	}
+	return "unknown"
+}
+
 type CallbackKind int
 
 const (
@@ -38,6 +38,8 @@ var (
 	ErrMissingAnnotation = errors.New("annotation is missing")
 	// ErrMissingDigest returned when image reference does not include a digest
 	ErrMissingDigest = errors.New("digest missing from image reference")
+	// ErrMissingInput returned when a required option or value is not provided
+	ErrMissingInput = errors.New("required input missing")
 	// ErrMissingLocation returned when the location header is missing
 	ErrMissingLocation = errors.New("location header missing")
 	// ErrMissingName returned when name missing for host
@@ -0,0 +1,90 @@
+package errs
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Error codes returned by registries, defined by the OCI distribution-spec.
+const (
+	CodeBlobUnknown         = "BLOB_UNKNOWN"
+	CodeBlobUploadInvalid   = "BLOB_UPLOAD_INVALID"
+	CodeBlobUploadUnknown   = "BLOB_UPLOAD_UNKNOWN"
+	CodeDenied              = "DENIED"
+	CodeDigestInvalid       = "DIGEST_INVALID"
+	CodeManifestBlobUnknown = "MANIFEST_BLOB_UNKNOWN"
+	CodeManifestInvalid     = "MANIFEST_INVALID"
+	CodeManifestUnknown     = "MANIFEST_UNKNOWN"
+	CodeNameInvalid         = "NAME_INVALID"
+	CodeNameUnknown         = "NAME_UNKNOWN"
+	CodeSizeInvalid         = "SIZE_INVALID"
+	CodeTooManyRequests     = "TOOMANYREQUESTS"
+	CodeUnauthorized        = "UNAUTHORIZED"
+	CodeUnsupported         = "UNSUPPORTED"
+)
+
+// RegistryError contains the details of a failed request to a registry.
+// It is retrieved from a returned error with [errors.As].
+// Unwrapping returns the error for the status code (e.g. [ErrNotFound]) so [errors.Is] continues to work.
+type RegistryError struct {
+	Method     string               // method of the request
+	URL        string               // url of the request
+	StatusCode int                  // http status code of the response
+	Header     http.Header          // headers of the response
+	Body       []byte               // raw body of the response
+	Errors     []RegistryErrorEntry // errors parsed from the body
+	Err        error                // error for the status code
+}
+
+// RegistryErrorEntry is a single entry in the errors list returned by a registry.
+type RegistryErrorEntry struct {
+	Code    string          `json:"code"`
+	Message string          `json:"message,omitempty"`
+	Detail  json.RawMessage `json:"detail,omitempty"`
+}
+
+// NewRegistryError parses the response body from a registry into a [RegistryError].
+// Bodies that do not contain the error list from the distribution-spec are included without being parsed.
+func NewRegistryError(method, url string, statusCode int, header http.Header, body []byte, err error) *RegistryError {
+	re := RegistryError{
+		Method:     method,
+		URL:        url,
+		StatusCode: statusCode,
+		Header:     header,
+		Body:       body,
+		Err:        err,
+	}
+	if len(body) > 0 {
+		parsed := struct {
+			Errors []RegistryErrorEntry `json:"errors"`
+		}{}
+		if json.Unmarshal(body, &parsed) == nil {
+			re.Errors = parsed.Errors
+		}
+	}
+	return &re
+}
+
+// Error returns the status code error followed by the body.
+func (e *RegistryError) Error() string {
+	msg := "registry request failed"
+	if e.Err != nil {
+		msg = e.Err.Error()
+	}
+	return msg + ": " + string(e.Body)
+}
+
+// Unwrap returns the error for the status code.
+func (e *RegistryError) Unwrap() error {
+	return e.Err
+}
+
+// HasCode returns true if any entry in the errors list matches the code.
+func (e *RegistryError) HasCode(code string) bool {
+	for _, entry := range e.Errors {
+		if entry.Code == code {
+			return true
+		}
+	}
+	return false
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/regclient/regclient/types/mediatype"
 	v1 "github.com/regclient/regclient/types/oci/v1"
 	"github.com/regclient/regclient/types/platform"
+	"github.com/regclient/regclient/types/ratelimit"
 	"github.com/regclient/regclient/types/ref"
 )
 
@@ -203,7 +204,15 @@ func GetPlatformDesc(m Manifest, p *platform.Platform) (*descriptor.Descriptor,
 	}
 	d, err := descriptor.DescriptorListSearch(dl, descriptor.MatchOpt{Platform: p})
 	if err != nil {
-		return nil, fmt.Errorf("platform not found: %s%.0w", *p, err)
+		pl, _ := getPlatformList(dl)
+		if len(pl) == 0 {
+			return nil, fmt.Errorf("platform not found: %s%.0w", *p, err)
+		}
+		available := make([]string, len(pl))
+		for i, pc := range pl {
+			available[i] = pc.String()
+		}
+		return nil, fmt.Errorf("platform not found: %s, available platforms: %s%.0w", *p, strings.Join(available, ", "), err)
 	}
 	return &d, nil
 }
@@ -222,50 +231,13 @@ func GetPlatformList(m Manifest) ([]*platform.Platform, error) {
 }
 
 // GetRateLimit returns the current rate limit seen in headers.
+// Use [ratelimit.NewContext] to record the rate limit from every request instead of only manifest requests.
 func GetRateLimit(m Manifest) types.RateLimit {
-	rl := types.RateLimit{}
 	header, err := m.RawHeaders()
 	if err != nil {
-		return rl
-	}
-	// check for rate limit headers
-	rlLimit := header.Get("RateLimit-Limit")
-	rlRemain := header.Get("RateLimit-Remaining")
-	rlReset := header.Get("RateLimit-Reset")
-	if rlLimit != "" {
-		lpSplit := strings.Split(rlLimit, ",")
-		lSplit := strings.Split(lpSplit[0], ";")
-		rlLimitI, err := strconv.Atoi(lSplit[0])
-		if err != nil {
-			rl.Limit = 0
-		} else {
-			rl.Limit = rlLimitI
-		}
-		if len(lSplit) > 1 {
-			rl.Policies = lpSplit
-		} else if len(lpSplit) > 1 {
-			rl.Policies = lpSplit[1:]
-		}
-	}
-	if rlRemain != "" {
-		rSplit := strings.Split(rlRemain, ";")
-		rlRemainI, err := strconv.Atoi(rSplit[0])
-		if err != nil {
-			rl.Remain = 0
-		} else {
-			rl.Remain = rlRemainI
-			rl.Set = true
-		}
+		return types.RateLimit{}
 	}
-	if rlReset != "" {
-		rlResetI, err := strconv.Atoi(rlReset)
-		if err != nil {
-			rl.Reset = 0
-		} else {
-			rl.Reset = rlResetI
-		}
-	}
-	return rl
+	return ratelimit.Parse(header)
 }
 
 // HasRateLimit indicates whether the rate limit is set and available.
@@ -476,6 +448,7 @@ func fromCommon(c common) (Manifest, error) {
 				Signatures    []interface{}           `json:"signatures,omitempty"`
 				Manifests     []descriptor.Descriptor `json:"manifests,omitempty"`
 				Layers        []descriptor.Descriptor `json:"layers,omitempty"`
+				Config        *descriptor.Descriptor  `json:"config,omitempty"`
 			}{}
 			err = json.Unmarshal(c.rawBody, &mt)
 			if mt.MediaType != "" {
@@ -496,6 +469,12 @@ func fromCommon(c common) (Manifest, error) {
 				} else {
 					c.desc.MediaType = mediatype.OCI1Manifest
 				}
+			} else if mt.Config != nil {
+				if strings.HasPrefix(mt.Config.MediaType, "application/vnd.docker.") {
+					c.desc.MediaType = mediatype.Docker2Manifest
+				} else {
+					c.desc.MediaType = mediatype.OCI1Manifest
+				}
 			}
 		}
 		// compute digest
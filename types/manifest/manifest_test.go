@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/opencontainers/go-digest"
@@ -326,6 +327,16 @@ var (
 			}
 		}
 	`)
+	rawOCIImageConfigDuck = []byte(`
+		{
+			"schemaVersion": 2,
+			"config": {
+				"mediaType": "application/vnd.example.config+json",
+				"size": 2,
+				"digest": "sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a"
+			}
+		}
+	`)
 	rawOCI1Artifact = []byte(`
 		{
 			"schemaVersion": 2,
@@ -400,6 +411,7 @@ var (
 	digestOCIIndex               = digest.SHA256.FromBytes(rawOCIIndex)
 	digestOCIImageDuck           = digest.SHA256.FromBytes(rawOCIImageDuck)
 	digestOCIIndexDuck           = digest.SHA256.FromBytes(rawOCIIndexDuck)
+	digestOCIImageConfigDuck     = digest.SHA256.FromBytes(rawOCIImageConfigDuck)
 	digestOCIArtifact            = digest.SHA256.FromBytes(rawOCI1Artifact)
 )
 
@@ -925,6 +937,19 @@ func TestNew(t *testing.T) {
 				Digest:    digestOCIIndexDuck,
 			},
 		},
+		{
+			name: "OCI Image without mediaType or layers",
+			opts: []Opts{
+				WithRaw(rawOCIImageConfigDuck),
+			},
+			wantE: nil,
+			isSet: true,
+			wantDesc: descriptor.Descriptor{
+				MediaType: mediatype.OCI1Manifest,
+				Size:      int64(len(rawOCIImageConfigDuck)),
+				Digest:    digestOCIImageConfigDuck,
+			},
+		},
 
 		// TODO: add more tests to improve coverage
 		// - test rate limit
@@ -1112,6 +1137,28 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestGetPlatformDescMissing(t *testing.T) {
+	t.Parallel()
+	m, err := New(WithRaw(rawDockerSchema2List))
+	if err != nil {
+		t.Fatalf("failed to parse manifest list: %v", err)
+	}
+	p, err := platform.Parse("linux/riscv64")
+	if err != nil {
+		t.Fatalf("failed to parse platform: %v", err)
+	}
+	_, err = GetPlatformDesc(m, &p)
+	if err == nil {
+		t.Fatalf("missing platform did not fail")
+	}
+	if !errors.Is(err, errs.ErrNotFound) {
+		t.Errorf("unexpected error, expected %v, received %v", errs.ErrNotFound, err)
+	}
+	if !strings.Contains(err.Error(), "linux/amd64") {
+		t.Errorf("available platforms are not listed: %v", err)
+	}
+}
+
 func TestModify(t *testing.T) {
 	t.Parallel()
 	addDigest := digest.FromString("new layer digest")
@@ -4,10 +4,23 @@ package ping
 import (
 	"io/fs"
 	"net/http"
+	"time"
 )
 
 // Result is the response to a ping request.
 type Result struct {
 	Header http.Header // Header is defined for responses from a registry.
 	Stat   fs.FileInfo // Stat is defined for responses from an ocidir.
+	Auth   *Auth       // Auth is defined for responses from a registry.
+}
+
+// Auth describes the authorization sent with the ping request.
+// Details from a bearer token are only available when the registry returns a JWT.
+type Auth struct {
+	Type     string    `json:"type,omitempty"`     // Type is the authorization scheme, e.g. "Basic" or "Bearer", empty for anonymous requests.
+	User     string    `json:"user,omitempty"`     // User is the basic auth login or the subject of the token.
+	Issuer   string    `json:"issuer,omitempty"`   // Issuer of the token.
+	IssuedAt time.Time `json:"issuedAt,omitempty"` // IssuedAt is when the token was created.
+	Expires  time.Time `json:"expires,omitempty"`  // Expires is when the token is no longer valid.
+	Scopes   []string  `json:"scopes,omitempty"`   // Scopes granted to the token.
 }
@@ -3,6 +3,8 @@ package types
 // RateLimit is returned from some http requests
 type RateLimit struct {
 	Remain, Limit, Reset int
-	Set                  bool
-	Policies             []string
+	// Window is the duration of the limit in seconds, from the "w" parameter of the limit policy.
+	Window   int
+	Set      bool
+	Policies []string
 }
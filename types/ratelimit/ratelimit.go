@@ -0,0 +1,136 @@
+// Package ratelimit is used to handle HTTP rate limit headers
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/regclient/regclient/types"
+)
+
+type contextKey string
+
+var key contextKey = "key"
+
+// Recorder tracks the most recent rate limit returned by each registry host.
+// Add a Recorder to a context with [NewContext] to receive the rate limit from every request made with that context.
+type Recorder struct {
+	// Hook is called with each rate limit that is received.
+	Hook  func(ctx context.Context, host string, rl types.RateLimit)
+	hosts map[string]types.RateLimit
+	mu    sync.Mutex
+}
+
+// Handle saves the rate limit for a host and calls the hook.
+func (r *Recorder) Handle(ctx context.Context, host string, rl types.RateLimit) {
+	r.mu.Lock()
+	if r.hosts == nil {
+		r.hosts = map[string]types.RateLimit{}
+	}
+	r.hosts[host] = rl
+	hook := r.Hook
+	r.mu.Unlock()
+	if hook != nil {
+		hook(ctx, host, rl)
+	}
+}
+
+// Get returns the most recent rate limit for a host.
+func (r *Recorder) Get(host string) (types.RateLimit, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rl, ok := r.hosts[host]
+	return rl, ok
+}
+
+// NewContext returns a context that records rate limits to r.
+func NewContext(ctx context.Context, r *Recorder) context.Context {
+	return context.WithValue(ctx, key, r)
+}
+
+// FromContext returns the Recorder from a context, or nil if one was not added.
+func FromContext(ctx context.Context) *Recorder {
+	rAny := ctx.Value(key)
+	if rAny == nil {
+		return nil
+	}
+	r, ok := rAny.(*Recorder)
+	if !ok {
+		return nil
+	}
+	return r
+}
+
+// Handle parses the rate limit headers of a response and passes them to the Recorder in the context.
+// Responses without rate limit headers are ignored.
+func Handle(ctx context.Context, host string, header http.Header) {
+	r := FromContext(ctx)
+	if r == nil {
+		return
+	}
+	rl := Parse(header)
+	if !rl.Set {
+		return
+	}
+	r.Handle(ctx, host, rl)
+}
+
+// Parse returns the rate limit from the RateLimit-Limit, RateLimit-Remaining, and RateLimit-Reset headers.
+// The Set field is true when the remaining count was found.
+// The Window is set from the first policy that includes a "w" parameter.
+func Parse(header http.Header) types.RateLimit {
+	rl := types.RateLimit{}
+	rlLimit := header.Get("RateLimit-Limit")
+	rlRemain := header.Get("RateLimit-Remaining")
+	rlReset := header.Get("RateLimit-Reset")
+	if rlLimit != "" {
+		lpSplit := strings.Split(rlLimit, ",")
+		lSplit := strings.Split(lpSplit[0], ";")
+		rlLimitI, err := strconv.Atoi(lSplit[0])
+		if err != nil {
+			rl.Limit = 0
+		} else {
+			rl.Limit = rlLimitI
+		}
+		if len(lSplit) > 1 {
+			rl.Policies = lpSplit
+		} else if len(lpSplit) > 1 {
+			rl.Policies = lpSplit[1:]
+		}
+		// the window is the "w" parameter of the first policy, e.g. "100;w=21600"
+		for _, policy := range rl.Policies {
+			for _, param := range strings.Split(policy, ";")[1:] {
+				if v, ok := strings.CutPrefix(strings.TrimSpace(param), "w="); ok {
+					if w, err := strconv.Atoi(v); err == nil {
+						rl.Window = w
+					}
+				}
+			}
+			if rl.Window > 0 {
+				break
+			}
+		}
+	}
+	if rlRemain != "" {
+		rSplit := strings.Split(rlRemain, ";")
+		rlRemainI, err := strconv.Atoi(rSplit[0])
+		if err != nil {
+			rl.Remain = 0
+		} else {
+			rl.Remain = rlRemainI
+			rl.Set = true
+		}
+	}
+	if rlReset != "" {
+		rlResetI, err := strconv.Atoi(rlReset)
+		if err != nil {
+			rl.Reset = 0
+		} else {
+			rl.Reset = rlResetI
+		}
+	}
+	return rl
+}
@@ -0,0 +1,104 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/regclient/regclient/types"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	tt := []struct {
+		name   string
+		header http.Header
+		expect types.RateLimit
+	}{
+		{
+			name:   "empty",
+			header: http.Header{},
+			expect: types.RateLimit{},
+		},
+		{
+			name: "docker hub",
+			header: http.Header{
+				"Ratelimit-Limit":     []string{"100;w=21600"},
+				"Ratelimit-Remaining": []string{"76;w=21600"},
+			},
+			expect: types.RateLimit{Limit: 100, Remain: 76, Window: 21600, Set: true, Policies: []string{"100;w=21600"}},
+		},
+		{
+			name: "policies",
+			header: http.Header{
+				"Ratelimit-Limit":     []string{"10, 10;w=1, 1000;w=3600"},
+				"Ratelimit-Remaining": []string{"9"},
+				"Ratelimit-Reset":     []string{"30"},
+			},
+			expect: types.RateLimit{Limit: 10, Remain: 9, Reset: 30, Window: 1, Set: true, Policies: []string{" 10;w=1", " 1000;w=3600"}},
+		},
+		{
+			name: "invalid",
+			header: http.Header{
+				"Ratelimit-Limit":     []string{"unknown"},
+				"Ratelimit-Remaining": []string{"unknown"},
+			},
+			expect: types.RateLimit{},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rl := Parse(tc.header)
+			if rl.Limit != tc.expect.Limit || rl.Remain != tc.expect.Remain || rl.Reset != tc.expect.Reset || rl.Window != tc.expect.Window || rl.Set != tc.expect.Set {
+				t.Errorf("unexpected rate limit, expected %v, received %v", tc.expect, rl)
+			}
+			if len(rl.Policies) != len(tc.expect.Policies) {
+				t.Fatalf("unexpected policies, expected %v, received %v", tc.expect.Policies, rl.Policies)
+			}
+			for i := range rl.Policies {
+				if rl.Policies[i] != tc.expect.Policies[i] {
+					t.Errorf("unexpected policy %d, expected %s, received %s", i, tc.expect.Policies[i], rl.Policies[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+	ctxBase := context.Background()
+	header := http.Header{
+		"Ratelimit-Limit":     []string{"100"},
+		"Ratelimit-Remaining": []string{"50"},
+	}
+	// without a recorder, the headers are ignored
+	Handle(ctxBase, "registry.example.com", header)
+	if FromContext(ctxBase) != nil {
+		t.Errorf("recorder found on base context")
+	}
+
+	hookHosts := []string{}
+	r := &Recorder{
+		Hook: func(_ context.Context, host string, _ types.RateLimit) {
+			hookHosts = append(hookHosts, host)
+		},
+	}
+	ctx := NewContext(ctxBase, r)
+	if FromContext(ctx) != r {
+		t.Fatalf("recorder not found in context")
+	}
+	Handle(ctx, "registry.example.com", header)
+	Handle(ctx, "registry.example.com", http.Header{})
+	header.Set("RateLimit-Remaining", "49")
+	Handle(ctx, "registry.example.com", header)
+	rl, ok := r.Get("registry.example.com")
+	if !ok || rl.Remain != 49 || rl.Limit != 100 {
+		t.Errorf("unexpected rate limit: %v, %t", rl, ok)
+	}
+	if _, ok := r.Get("other.example.com"); ok {
+		t.Errorf("rate limit returned for unknown host")
+	}
+	if len(hookHosts) != 2 {
+		t.Errorf("hook calls, expected 2, received %d", len(hookHosts))
+	}
+}
@@ -32,7 +32,7 @@ var (
 	pathS       = `[/a-zA-Z0-9_\-. ~\+]+`
 	tagS        = `[a-zA-Z0-9_][a-zA-Z0-9._-]{0,127}`
 	digestS     = `[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*[:][[:xdigit:]]{32,}`
-	schemeRE    = regexp.MustCompile(`^([a-z]+)://(.+)$`)
+	schemeRE    = regexp.MustCompile(`^([a-z]+(?:-[a-z]+)*)://(.+)$`)
 	registryRE  = regexp.MustCompile(`^(` + registryS + `)$`)
 	refRE       = regexp.MustCompile(`^(?:(` + registryS + `)` + regexp.QuoteMeta(`/`) + `)?` +
 		`(` + repoPartS + `(?:` + regexp.QuoteMeta(`/`) + repoPartS + `)*)` +
@@ -46,10 +46,10 @@ var (
 // Ref is a reference to a registry/repository.
 // Direct access to the contents of this struct should not be assumed.
 type Ref struct {
-	Scheme     string // Scheme is the type of reference, "reg" or "ocidir".
+	Scheme     string // Scheme is the type of reference, "reg", "ocidir", or "docker-daemon".
 	Reference  string // Reference is the unparsed string or common name.
-	Registry   string // Registry is the server for the "reg" scheme.
-	Repository string // Repository is the path on the registry for the "reg" scheme.
+	Registry   string // Registry is the server for the "reg" and "docker-daemon" schemes.
+	Repository string // Repository is the path on the registry for the "reg" and "docker-daemon" schemes.
 	Tag        string // Tag is a mutable tag for a reference.
 	Digest     string // Digest is an immutable hash for a reference.
 	Path       string // Path is the directory of the OCI Layout for "ocidir".
@@ -71,37 +71,10 @@ func New(parse string) (Ref, error) {
 	switch scheme {
 	case "":
 		ret.Scheme = "reg"
-		matchRef := refRE.FindStringSubmatch(tail)
-		if matchRef == nil || len(matchRef) < 5 {
-			if refRE.FindStringSubmatch(strings.ToLower(tail)) != nil {
-				return Ref{}, fmt.Errorf("%w \"%s\", repo must be lowercase", errs.ErrInvalidReference, tail)
-			}
-			return Ref{}, fmt.Errorf("%w \"%s\"", errs.ErrInvalidReference, tail)
-		}
-		ret.Registry = matchRef[1]
-		ret.Repository = matchRef[2]
-		ret.Tag = matchRef[3]
-		ret.Digest = matchRef[4]
+		return parseReg(ret, tail)
 
-		// handle localhost use case since it matches the regex for a repo path entry
-		repoPath := strings.Split(ret.Repository, "/")
-		if ret.Registry == "" && repoPath[0] == "localhost" {
-			ret.Registry = repoPath[0]
-			ret.Repository = strings.Join(repoPath[1:], "/")
-		}
-		switch ret.Registry {
-		case "", dockerRegistryDNS, dockerRegistryLegacy:
-			ret.Registry = dockerRegistry
-		}
-		if ret.Registry == dockerRegistry && !strings.Contains(ret.Repository, "/") {
-			ret.Repository = dockerLibrary + "/" + ret.Repository
-		}
-		if ret.Tag == "" && ret.Digest == "" {
-			ret.Tag = "latest"
-		}
-		if ret.Repository == "" {
-			return Ref{}, fmt.Errorf("%w \"%s\"", errs.ErrInvalidReference, tail)
-		}
+	case "docker-daemon":
+		return parseReg(ret, tail)
 
 	case "ocidir", "ocifile":
 		matchPath := ocidirRE.FindStringSubmatch(tail)
@@ -122,6 +95,42 @@ func New(parse string) (Ref, error) {
 	return ret, nil
 }
 
+// parseReg parses the registry, repository, tag, and digest used by the "reg" and "docker-daemon" schemes.
+func parseReg(ret Ref, tail string) (Ref, error) {
+	matchRef := refRE.FindStringSubmatch(tail)
+	if matchRef == nil || len(matchRef) < 5 {
+		if refRE.FindStringSubmatch(strings.ToLower(tail)) != nil {
+			return Ref{}, fmt.Errorf("%w \"%s\", repo must be lowercase", errs.ErrInvalidReference, tail)
+		}
+		return Ref{}, fmt.Errorf("%w \"%s\"", errs.ErrInvalidReference, tail)
+	}
+	ret.Registry = matchRef[1]
+	ret.Repository = matchRef[2]
+	ret.Tag = matchRef[3]
+	ret.Digest = matchRef[4]
+
+	// handle localhost use case since it matches the regex for a repo path entry
+	repoPath := strings.Split(ret.Repository, "/")
+	if ret.Registry == "" && repoPath[0] == "localhost" {
+		ret.Registry = repoPath[0]
+		ret.Repository = strings.Join(repoPath[1:], "/")
+	}
+	switch ret.Registry {
+	case "", dockerRegistryDNS, dockerRegistryLegacy:
+		ret.Registry = dockerRegistry
+	}
+	if ret.Registry == dockerRegistry && !strings.Contains(ret.Repository, "/") {
+		ret.Repository = dockerLibrary + "/" + ret.Repository
+	}
+	if ret.Tag == "" && ret.Digest == "" {
+		ret.Tag = "latest"
+	}
+	if ret.Repository == "" {
+		return Ref{}, fmt.Errorf("%w \"%s\"", errs.ErrInvalidReference, tail)
+	}
+	return ret, nil
+}
+
 // NewHost returns a Reg for a registry hostname or equivalent.
 // The ocidir schema equivalent is the path.
 func NewHost(parse string) (Ref, error) {
@@ -165,9 +174,12 @@ func NewHost(parse string) (Ref, error) {
 func (r Ref) CommonName() string {
 	cn := ""
 	switch r.Scheme {
-	case "reg":
+	case "reg", "docker-daemon":
+		if r.Scheme != "reg" {
+			cn = r.Scheme + "://"
+		}
 		if r.Registry != "" {
-			cn = r.Registry + "/"
+			cn = cn + r.Registry + "/"
 		}
 		if r.Repository == "" {
 			return ""
@@ -197,7 +209,7 @@ func (r Ref) IsSet() bool {
 		return false
 	}
 	// Registry requires a tag or digest, OCI Layout doesn't require these.
-	if r.Scheme == "reg" && r.Tag == "" && r.Digest == "" {
+	if (r.Scheme == "reg" || r.Scheme == "docker-daemon") && r.Tag == "" && r.Digest == "" {
 		return false
 	}
 	return true
@@ -206,7 +218,7 @@ func (r Ref) IsSet() bool {
 // IsSetRepo returns true when the ref includes values for a specific repository.
 func (r Ref) IsSetRepo() bool {
 	switch r.Scheme {
-	case "reg":
+	case "reg", "docker-daemon":
 		if r.Registry != "" && r.Repository != "" {
 			return true
 		}
@@ -247,6 +259,9 @@ func (r Ref) SetTag(tag string) Ref {
 // ToReg converts a reference to a registry like syntax.
 func (r Ref) ToReg() Ref {
 	switch r.Scheme {
+	case "docker-daemon":
+		r.Scheme = "reg"
+		r.Reference = r.CommonName()
 	case "ocidir":
 		r.Scheme = "reg"
 		r.Registry = "localhost"
@@ -266,7 +281,7 @@ func EqualRegistry(a, b Ref) bool {
 		return false
 	}
 	switch a.Scheme {
-	case "reg":
+	case "reg", "docker-daemon":
 		return a.Registry == b.Registry
 	case "ocidir":
 		return a.Path == b.Path
@@ -284,7 +299,7 @@ func EqualRepository(a, b Ref) bool {
 		return false
 	}
 	switch a.Scheme {
-	case "reg":
+	case "reg", "docker-daemon":
 		return a.Registry == b.Registry && a.Repository == b.Repository
 	case "ocidir":
 		return a.Path == b.Path
@@ -374,6 +374,33 @@ func TestNew(t *testing.T) {
 			ref:   "project/image@sha256:gggg40677a5e245d9ea199eb9b026b1539208a5183621dced7b469f6aa678115",
 			wantE: errs.ErrInvalidReference,
 		},
+		{
+			name:       "Docker daemon",
+			ref:        "docker-daemon://alpine",
+			scheme:     "docker-daemon",
+			registry:   "docker.io",
+			repository: "library/alpine",
+			tag:        "latest",
+			digest:     "",
+			path:       "",
+			wantE:      nil,
+		},
+		{
+			name:       "Docker daemon with registry and tag",
+			ref:        "docker-daemon://registry:5000/group/image:v1",
+			scheme:     "docker-daemon",
+			registry:   "registry:5000",
+			repository: "group/image",
+			tag:        "v1",
+			digest:     "",
+			path:       "",
+			wantE:      nil,
+		},
+		{
+			name:  "invalid docker daemon ref",
+			ref:   "docker-daemon://Image:tag",
+			wantE: errs.ErrInvalidReference,
+		},
 		{
 			name:  "invalid ocidir path",
 			ref:   "ocidir://invalid*filename:tag",
@@ -638,6 +665,10 @@ func TestCommon(t *testing.T) {
 			name: "ocidir with tag",
 			str:  "ocidir:///tmp/image:tag",
 		},
+		{
+			name: "docker daemon with tag",
+			str:  "docker-daemon://docker.io/group/image:tag",
+		},
 		{
 			name: "ocidir with digest",
 			str:  "ocidir://image@" + testDigest,
@@ -960,6 +991,11 @@ func TestToReg(t *testing.T) {
 			inRef:  "ocidir://test_-_hello world",
 			expect: "localhost/test-hello-world",
 		},
+		{
+			name:   "docker daemon",
+			inRef:  "docker-daemon://alpine:3",
+			expect: "docker.io/library/alpine:3",
+		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
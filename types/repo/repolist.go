@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"sort"
 	"strings"
 
@@ -24,6 +25,7 @@ type repoCommon struct {
 	orig      interface{}
 	rawHeader http.Header
 	rawBody   []byte
+	url       *url.URL
 }
 
 type repoConfig struct {
@@ -31,6 +33,7 @@ type repoConfig struct {
 	mt     string
 	raw    []byte
 	header http.Header
+	url    *url.URL
 }
 
 type Opts func(*repoConfig)
@@ -49,6 +52,7 @@ func New(opts ...Opts) (*RepoList, error) {
 		rawHeader: conf.header,
 		rawBody:   conf.raw,
 		host:      conf.host,
+		url:       conf.url,
 	}
 
 	mt := strings.Split(conf.mt, ";")[0] // "application/json; charset=utf-8" -> "application/json"
@@ -87,6 +91,37 @@ func WithRaw(raw []byte) Opts {
 	}
 }
 
+// WithURL sets the URL of the request, used to resolve relative Link headers.
+func WithURL(u *url.URL) Opts {
+	return func(c *repoConfig) {
+		c.url = u
+	}
+}
+
+// Append extends a repository list with the next page of results.
+func (rl *RepoList) Append(add *RepoList) error {
+	if rl.host != add.host || rl.mt != add.mt {
+		return fmt.Errorf("unable to append, lists are incompatible")
+	}
+	if add.orig != nil {
+		rl.orig = add.orig
+	}
+	if add.rawHeader != nil {
+		rl.rawHeader = add.rawHeader
+	}
+	if add.url != nil {
+		rl.url = add.url
+	}
+	rl.Repositories = append(rl.Repositories, add.Repositories...)
+	// the body is regenerated to include every page
+	body, err := json.Marshal(rl.RepoRegistryList)
+	if err != nil {
+		return err
+	}
+	rl.rawBody = body
+	return nil
+}
+
 // RepoRegistryList is a list of repositories from the _catalog API
 type RepoRegistryList struct {
 	Repositories []string `json:"repositories"`
@@ -115,6 +150,11 @@ func (r repoCommon) RawHeaders() (http.Header, error) {
 	return r.rawHeader, nil
 }
 
+// GetURL returns the URL of the request.
+func (r repoCommon) GetURL() *url.URL {
+	return r.url
+}
+
 // GetRepos returns the repositories
 func (rl RepoRegistryList) GetRepos() ([]string, error) {
 	return rl.Repositories, nil
@@ -89,6 +89,43 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestAppend(t *testing.T) {
+	t.Parallel()
+	host := "localhost:5000"
+	rl1, err := New(WithHost(host), WithRaw([]byte(`{"repositories":["a","b"]}`)))
+	if err != nil {
+		t.Fatalf("failed to create list: %v", err)
+	}
+	rl2, err := New(WithHost(host), WithRaw([]byte(`{"repositories":["c"]}`)))
+	if err != nil {
+		t.Fatalf("failed to create list: %v", err)
+	}
+	rlOther, err := New(WithHost("registry.example.org"), WithRaw([]byte(`{"repositories":["d"]}`)))
+	if err != nil {
+		t.Fatalf("failed to create list: %v", err)
+	}
+	err = rl1.Append(rl2)
+	if err != nil {
+		t.Fatalf("failed to append: %v", err)
+	}
+	expect := []string{"a", "b", "c"}
+	if !cmpSliceString(expect, rl1.Repositories) {
+		t.Errorf("unexpected repo list: expected %v, received %v", expect, rl1.Repositories)
+	}
+	raw, err := rl1.RawBody()
+	if err != nil {
+		t.Fatalf("error from RawBody: %v", err)
+	}
+	expectRaw := []byte(`{"repositories":["a","b","c"]}`)
+	if !bytes.Equal(expectRaw, raw) {
+		t.Errorf("unexpected raw body: expected %s, received %s", expectRaw, raw)
+	}
+	err = rl1.Append(rlOther)
+	if err == nil {
+		t.Errorf("append of a different host did not fail")
+	}
+}
+
 func cmpSliceString(a, b []string) bool {
 	if len(a) != len(b) {
 		return false
@@ -0,0 +1,31 @@
+package tag
+
+import (
+	"time"
+)
+
+// HubList fields are from the Docker Hub API.
+// These are only populated when the Hub API is enabled for the registry host.
+type HubList struct {
+	HubTags map[string]HubTagInfo `json:"-"`
+}
+
+// HubTagInfo contains the metadata for a tag returned by the Docker Hub API.
+type HubTagInfo struct {
+	Name        string    `json:"name"`
+	Digest      string    `json:"digest,omitempty"`
+	Size        int64     `json:"full_size"`
+	LastUpdated time.Time `json:"last_updated"`
+	LastPushed  time.Time `json:"tag_last_pushed"`
+	LastPulled  time.Time `json:"tag_last_pulled"`
+	LastUpdater string    `json:"last_updater_username,omitempty"`
+}
+
+// GetHubTag returns the Hub API metadata for a tag.
+func (hl HubList) GetHubTag(tag string) (HubTagInfo, bool) {
+	if hl.HubTags == nil {
+		return HubTagInfo{}, false
+	}
+	info, ok := hl.HubTags[tag]
+	return info, ok
+}
@@ -0,0 +1,50 @@
+package tag
+
+import (
+	"bytes"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/regclient/regclient/internal/units"
+)
+
+// Info contains the metadata for a single tag from a registry vendor API.
+// Fields are left empty when the vendor does not provide the value.
+type Info struct {
+	Name      string    `json:"name"`
+	Vendor    string    `json:"vendor"`              // vendor API that provided the metadata, e.g. "hub", "quay", or "harbor"
+	Digest    string    `json:"digest,omitempty"`    // digest of the manifest
+	Size      int64     `json:"size,omitempty"`      // size of the image reported by the vendor
+	Pushed    time.Time `json:"pushed"`              // time the tag was last pushed
+	Pulled    time.Time `json:"pulled"`              // time the tag was last pulled
+	PushedBy  string    `json:"pushedBy,omitempty"`  // user that last pushed the tag
+	PullCount int64     `json:"pullCount,omitempty"` // number of pulls of the repository
+}
+
+// MarshalPretty is used for printPretty template formatting.
+func (i Info) MarshalPretty() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	tw := tabwriter.NewWriter(buf, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(tw, "Vendor:\t%s\n", i.Vendor)
+	if i.Digest != "" {
+		fmt.Fprintf(tw, "Vendor Digest:\t%s\n", i.Digest)
+	}
+	if i.Size > 0 {
+		fmt.Fprintf(tw, "Size:\t%s\n", units.HumanSize(float64(i.Size)))
+	}
+	if !i.Pushed.IsZero() {
+		fmt.Fprintf(tw, "Pushed:\t%s\n", i.Pushed.UTC().Format(time.RFC3339))
+	}
+	if i.PushedBy != "" {
+		fmt.Fprintf(tw, "Pushed By:\t%s\n", i.PushedBy)
+	}
+	if !i.Pulled.IsZero() {
+		fmt.Fprintf(tw, "Last Pulled:\t%s\n", i.Pulled.UTC().Format(time.RFC3339))
+	}
+	if i.PullCount > 0 {
+		fmt.Fprintf(tw, "Repository Pulls:\t%d\n", i.PullCount)
+	}
+	err := tw.Flush()
+	return buf.Bytes(), err
+}
@@ -23,6 +23,7 @@ type List struct {
 	tagCommon
 	DockerList
 	GCRList
+	HubList
 	LayoutList
 }
 
@@ -193,6 +194,14 @@ func (l *List) Append(add *List) error {
 	if add.Children != nil {
 		l.Children = append(l.Children, add.Children...)
 	}
+	if add.HubTags != nil {
+		if l.HubTags == nil {
+			l.HubTags = map[string]HubTagInfo{}
+		}
+		for k, v := range add.HubTags {
+			l.HubTags[k] = v
+		}
+	}
 	if add.Manifests != nil {
 		if l.Manifests == nil {
 			l.Manifests = add.Manifests